@@ -0,0 +1,174 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// entry is what the access log knows of a request.
+type entry struct {
+	Time      time.Time `json:"time"`
+	RemoteIP  string    `json:"remote_ip"`
+	User      string    `json:"user,omitempty"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Protocol  string    `json:"protocol"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Latency   float64   `json:"latency_ms"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// Logger writes an access log line per request to its sink.
+type Logger struct {
+	format func(entry *entry) ([]byte, error)
+	mutex  sync.Mutex
+	sink   io.Writer
+	closer io.Closer
+}
+
+// New opens the sink of config, which should have been resolved for the
+// environment with For.
+func New(config config.AccessLogConfig) (*Logger, error) {
+	logger := &Logger{}
+	switch config.Format {
+	case "", "combined":
+		logger.format = combined
+	case "json":
+		logger.format = jsonLine
+	default:
+		return nil, errors.New("unknown access log format " + config.Format)
+	}
+
+	switch config.Sink {
+	case "", "stdout":
+		logger.sink = os.Stdout
+	case "file":
+		file, err := OpenRotatingFile(config.File)
+		if err != nil {
+			return nil, err
+		}
+		logger.sink, logger.closer = file, file
+	case "syslog":
+		writer, err := openSyslog(config.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		logger.sink, logger.closer = writer, writer
+	default:
+		return nil, errors.New("unknown access log sink " + config.Sink)
+	}
+	return logger, nil
+}
+
+// Middleware logs every request once handled, with the status the error
+// handler answers a returned error with.
+func (logger *Logger) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+
+		logged := &entry{
+			Time:      start,
+			RemoteIP:  ctx.IP(),
+			Method:    ctx.Method(),
+			URI:       ctx.OriginalURL(),
+			Protocol:  string(ctx.Request().Header.Protocol()),
+			Status:    ctx.Response().StatusCode(),
+			Bytes:     len(ctx.Response().Body()),
+			Referer:   ctx.Get(fiber.HeaderReferer),
+			UserAgent: ctx.Get(fiber.HeaderUserAgent),
+			Latency:   float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			logged.Status = web.ProblemFromError(ctx, err).Status
+			logged.Bytes = 0
+		}
+		if userID, ok := ctx.Locals("user_id").(string); ok {
+			logged.User = userID
+		}
+		if requestID, ok := ctx.Locals("requestid").(string); ok {
+			logged.RequestID = requestID
+		}
+		logger.write(logged)
+		return err
+	}
+}
+
+func (logger *Logger) write(logged *entry) {
+	line, err := logger.format(logged)
+	if err != nil {
+		return
+	}
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.sink.Write(line)
+}
+
+// Close closes the file or the syslog connection of the sink.
+func (logger *Logger) Close() error {
+	if logger.closer == nil {
+		return nil
+	}
+	return logger.closer.Close()
+}
+
+// combined formats the entry as Apache's combined log format:
+// %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i".
+func combined(logged *entry) ([]byte, error) {
+	var line strings.Builder
+	line.WriteString(logged.RemoteIP)
+	line.WriteString(" - ")
+	line.WriteString(orDash(logged.User))
+	line.WriteString(" [")
+	line.WriteString(logged.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	line.WriteString(`] "`)
+	line.WriteString(escape(logged.Method + " " + logged.URI + " " + logged.Protocol))
+	line.WriteString(`" `)
+	line.WriteString(strconv.Itoa(logged.Status))
+	line.WriteString(" ")
+	if logged.Bytes > 0 {
+		line.WriteString(strconv.Itoa(logged.Bytes))
+	} else {
+		line.WriteString("-")
+	}
+	line.WriteString(` "`)
+	line.WriteString(escape(orDash(logged.Referer)))
+	line.WriteString(`" "`)
+	line.WriteString(escape(orDash(logged.UserAgent)))
+	line.WriteString("\"\n")
+	return []byte(line.String()), nil
+}
+
+func jsonLine(logged *entry) ([]byte, error) {
+	line, err := json.Marshal(logged)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// escape keeps quotes and control characters of request data from breaking
+// the line, as Apache does.
+func escape(value string) string {
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1]
+}
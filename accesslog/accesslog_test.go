@@ -0,0 +1,92 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func logRequests(t *testing.T, logger *Logger) {
+	logApp := fiber.New()
+	logApp.Use(logger.Middleware())
+	logApp.Get("/users", func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", "42")
+		return ctx.SendString("users")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/users?page=2", nil)
+	request.Header.Set(fiber.HeaderUserAgent, `curl "8"`)
+	_, err := logApp.Test(request)
+	assert.Nil(t, err)
+	_, err = logApp.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Nil(t, err)
+	assert.Nil(t, logger.Close())
+}
+
+func TestCombinedFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := New(config.AccessLogConfig{Format: "combined", Sink: "file", File: config.RotatingFileConfig{Path: path}})
+	assert.Nil(t, err)
+	logRequests(t, logger)
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Regexp(t, regexp.MustCompile(`^0\.0\.0\.0 - 42 \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /users\?page=2 HTTP/1\.1" 200 5 "-" "curl \\"8\\""$`), lines[0])
+	assert.Contains(t, lines[1], `"GET /missing HTTP/1.1" 404 - "-" "-"`)
+}
+
+func TestJSONFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := New(config.AccessLogConfig{Format: "json", Sink: "file", File: config.RotatingFileConfig{Path: path}})
+	assert.Nil(t, err)
+	logRequests(t, logger)
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	var logged entry
+	assert.Nil(t, json.Unmarshal([]byte(strings.Split(string(data), "\n")[0]), &logged))
+	assert.Equal(t, "GET", logged.Method)
+	assert.Equal(t, "/users?page=2", logged.URI)
+	assert.Equal(t, 200, logged.Status)
+	assert.Equal(t, 5, logged.Bytes)
+	assert.Equal(t, "42", logged.User)
+	assert.Equal(t, `curl "8"`, logged.UserAgent)
+}
+
+func TestUnknownFormatAndSink(t *testing.T) {
+	_, err := New(config.AccessLogConfig{Format: "common"})
+	assert.NotNil(t, err)
+	_, err = New(config.AccessLogConfig{Sink: "kafka"})
+	assert.NotNil(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "access.log")
+	file, err := OpenRotatingFile(config.RotatingFileConfig{Path: path, MaxSize: 10, MaxBackups: 2})
+	assert.Nil(t, err)
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = file.Write([]byte(line))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, file.Close())
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		assert.Nil(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
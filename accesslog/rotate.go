@@ -0,0 +1,96 @@
+package accesslog
+
+import (
+	"errors"
+	"golang-fiber-web/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// RotatingFile is an append-only file that is rotated once it reaches its
+// MaxSize. Under Prefork every child rotates on its own, so the file sink is
+// best used without it.
+type RotatingFile struct {
+	config config.RotatingFileConfig
+	mutex  sync.Mutex
+	file   *os.File
+	size   int64
+}
+
+func OpenRotatingFile(config config.RotatingFileConfig) (*RotatingFile, error) {
+	if config.Path == "" {
+		return nil, errors.New("the access log file path is not configured")
+	}
+	err := os.MkdirAll(filepath.Dir(config.Path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	file := &RotatingFile{config: config}
+	err = file.open()
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (file *RotatingFile) Write(data []byte) (int, error) {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	if file.config.MaxSize > 0 && file.size > 0 && file.size+int64(len(data)) > int64(file.config.MaxSize) {
+		err := file.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+	written, err := file.file.Write(data)
+	file.size += int64(written)
+	return written, err
+}
+
+func (file *RotatingFile) Close() error {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	return file.file.Close()
+}
+
+func (file *RotatingFile) open() error {
+	opened, err := os.OpenFile(file.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := opened.Stat()
+	if err != nil {
+		opened.Close()
+		return err
+	}
+	file.file, file.size = opened, info.Size()
+	return nil
+}
+
+// rotate shifts the backups up by one, dropping the oldest, and moves the
+// current file to Path.1, or removes it when no backups are kept.
+func (file *RotatingFile) rotate() error {
+	err := file.file.Close()
+	if err != nil {
+		return err
+	}
+	path := file.config.Path
+	if file.config.MaxBackups <= 0 {
+		err = os.Remove(path)
+	} else {
+		for i := file.config.MaxBackups - 1; i > 0; i-- {
+			err = os.Rename(path+"."+strconv.Itoa(i), path+"."+strconv.Itoa(i+1))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		err = os.Rename(path, path+".1")
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return file.open()
+}
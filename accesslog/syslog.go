@@ -0,0 +1,13 @@
+//go:build !windows && !plan9
+
+package accesslog
+
+import (
+	"golang-fiber-web/config"
+	"io"
+	"log/syslog"
+)
+
+func openSyslog(config config.SyslogConfig) (io.WriteCloser, error) {
+	return syslog.Dial(config.Network, config.Address, syslog.LOG_INFO|syslog.LOG_LOCAL0, config.Tag)
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package accesslog
+
+import (
+	"errors"
+	"golang-fiber-web/config"
+	"io"
+)
+
+func openSyslog(config config.SyslogConfig) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
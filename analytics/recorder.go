@@ -0,0 +1,144 @@
+package analytics
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/web"
+	"sync"
+	"time"
+)
+
+var logger = telemetry.Logger("analytics")
+
+type statKey struct {
+	bucket   time.Time
+	method   string
+	route    string
+	consumer string
+}
+
+// Recorder aggregates the requests it sees by bucket, route and consumer in
+// memory and adds them to the usage repository every interval, so that
+// counting a request costs no database write.
+type Recorder struct {
+	usage    repository.UsageRepository
+	bucket   time.Duration
+	interval time.Duration
+
+	mutex   sync.Mutex
+	pending map[statKey]*model.UsageStat
+}
+
+func NewRecorder(usage repository.UsageRepository, config config.AnalyticsConfig) *Recorder {
+	return &Recorder{usage: usage, bucket: config.Bucket, interval: config.FlushInterval, pending: map[statKey]*model.UsageStat{}}
+}
+
+// Middleware records every request once it has been handled. The consumer
+// is the API key of the request, else its user, else "anonymous", so it must
+// run before the authentication sets them.
+func (recorder *Recorder) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+		latency := time.Since(start)
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = web.ProblemFromError(ctx, err).Status
+		}
+		recorder.Record(start, ctx.Method(), ctx.Route().Path, consumer(ctx), status, latency)
+		return err
+	}
+}
+
+// Record counts a request made at start.
+func (recorder *Recorder) Record(start time.Time, method, route, consumer string, status int, latency time.Duration) {
+	key := statKey{bucket: start.UTC().Truncate(recorder.bucket), method: method, route: route, consumer: consumer}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	stat := recorder.pending[key]
+	if stat == nil {
+		stat = &model.UsageStat{Bucket: key.bucket, Method: method, Route: route, Consumer: consumer}
+		recorder.pending[key] = stat
+	}
+	request := &model.UsageStat{Requests: 1, LatencyTotal: latency, LatencyMax: latency}
+	switch status / 100 {
+	case 2:
+		request.Status2xx = 1
+	case 3:
+		request.Status3xx = 1
+	case 4:
+		request.Status4xx = 1
+	case 5:
+		request.Status5xx = 1
+	}
+	stat.Add(request)
+}
+
+// Run flushes the recorded requests every interval until ctx is done, and
+// once more then.
+func (recorder *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(recorder.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			err := recorder.Flush(context.WithoutCancel(ctx))
+			if err != nil {
+				logger.Error("flushing request statistics", "error", err)
+			}
+			return
+		case <-ticker.C:
+		}
+		err := recorder.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("flushing request statistics", "error", err)
+		}
+	}
+}
+
+// Flush adds the requests recorded since the last flush to the repository.
+// Those it fails to add are kept for the next one.
+func (recorder *Recorder) Flush(ctx context.Context) error {
+	recorder.mutex.Lock()
+	pending := recorder.pending
+	recorder.pending = map[statKey]*model.UsageStat{}
+	recorder.mutex.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	stats := make([]*model.UsageStat, 0, len(pending))
+	for _, stat := range pending {
+		stats = append(stats, stat)
+	}
+	err := recorder.usage.Add(ctx, stats)
+	if err != nil {
+		recorder.mutex.Lock()
+		for key, stat := range pending {
+			if current := recorder.pending[key]; current != nil {
+				stat.Add(current)
+			}
+			recorder.pending[key] = stat
+		}
+		recorder.mutex.Unlock()
+		return err
+	}
+	logger.Debug("flushed request statistics", "stats", len(stats))
+	return nil
+}
+
+func consumer(ctx *fiber.Ctx) string {
+	if apiKeyID, ok := ctx.Locals("api_key_id").(string); ok && apiKeyID != "" {
+		return "api_key:" + apiKeyID
+	}
+	if userID, ok := ctx.Locals("user_id").(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return "anonymous"
+}
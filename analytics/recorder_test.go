@@ -0,0 +1,66 @@
+package analytics
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	usage := repository.NewMemoryUsageRepository()
+	recorder := NewRecorder(usage, config.AnalyticsConfig{Bucket: time.Hour, FlushInterval: time.Second})
+	recordedApp := fiber.New()
+	recordedApp.Use(recorder.Middleware())
+	recordedApp.Use(func(ctx *fiber.Ctx) error {
+		if key := ctx.Get("X-API-Key"); key != "" {
+			ctx.Locals("api_key_id", key)
+		}
+		return ctx.Next()
+	})
+	recordedApp.Get("/users/:id", func(ctx *fiber.Ctx) error {
+		if ctx.Params("id") == "0" {
+			return fiber.ErrNotFound
+		}
+		if ctx.Params("id") == "crash" {
+			return fiber.ErrInternalServerError
+		}
+		return ctx.SendString("ok")
+	})
+
+	for _, target := range []string{"/users/1", "/users/2", "/users/0", "/users/crash"} {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		request.Header.Set("X-API-Key", "1")
+		_, err := recordedApp.Test(request)
+		assert.Nil(t, err)
+	}
+	_, err := recordedApp.Test(httptest.NewRequest(http.MethodGet, "/users/3", nil))
+	assert.Nil(t, err)
+
+	stats, err := usage.List(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Empty(t, stats)
+
+	assert.Nil(t, recorder.Flush(context.Background()))
+	stats, err = usage.List(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Len(t, stats, 2)
+	byConsumer := map[string]int{}
+	for _, stat := range stats {
+		assert.Equal(t, "/users/:id", stat.Route)
+		assert.Equal(t, time.Now().UTC().Truncate(time.Hour), stat.Bucket)
+		byConsumer[stat.Consumer] = stat.Requests
+		if stat.Consumer == "api_key:1" {
+			assert.Equal(t, 2, stat.Status2xx)
+			assert.Equal(t, 1, stat.Status4xx)
+			assert.Equal(t, 1, stat.Status5xx)
+			assert.Positive(t, stat.LatencyMax)
+		}
+	}
+	assert.Equal(t, map[string]int{"api_key:1": 4, "anonymous": 1}, byConsumer)
+}
@@ -0,0 +1,87 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// code128Patterns are the widths of the alternating bars and spaces of each
+// Code 128 symbol value, starting with a bar.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Code128 is a Code 128 barcode. Digits only are encoded in code set C, two
+// digits per symbol; anything else in code set B.
+type Code128 struct {
+	bars []bool
+}
+
+// EncodeCode128 encodes data, which must be printable ASCII.
+func EncodeCode128(data string) (*Code128, error) {
+	if data == "" {
+		return nil, errors.New("no data to encode")
+	}
+	var values []int
+	if len(data)%2 == 0 && strings.Trim(data, "0123456789") == "" {
+		values = append(values, code128StartC)
+		for i := 0; i < len(data); i += 2 {
+			values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(data); i++ {
+			if data[i] < ' ' || data[i] > '~' {
+				return nil, errors.New("only printable ASCII can be encoded in Code 128")
+			}
+			values = append(values, int(data[i]-' '))
+		}
+	}
+	checksum := values[0]
+	for i, value := range values[1:] {
+		checksum += (i + 1) * value
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	code := &Code128{}
+	for _, value := range values {
+		for i, width := range code128Patterns[value] {
+			for j := 0; j < int(width-'0'); j++ {
+				code.bars = append(code.bars, i%2 == 0)
+			}
+		}
+	}
+	return code, nil
+}
+
+// Bounds returns the width of the barcode in modules, the narrowest bar, and
+// a height of one module, the bars being as tall as they are drawn.
+func (code *Code128) Bounds() (int, int) {
+	return len(code.bars), 1
+}
+
+func (code *Code128) Dark(x, y int) bool {
+	return code.bars[x]
+}
+
+// QuietZone is the light margin a reader needs on each side of the
+// barcode, in modules. None is needed above and below.
+func (code *Code128) QuietZone() (int, int) {
+	return 10, 0
+}
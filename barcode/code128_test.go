@@ -0,0 +1,53 @@
+package barcode
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCode128Patterns(t *testing.T) {
+	for value, pattern := range code128Patterns {
+		sum := 0
+		for _, width := range pattern {
+			sum += int(width - '0')
+		}
+		if value == code128Stop {
+			assert.Equal(t, 13, sum)
+		} else {
+			assert.Equal(t, 11, sum, "value %d", value)
+		}
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	code, err := EncodeCode128("PJJ123C")
+	assert.Nil(t, err)
+	width, height := code.Bounds()
+	assert.Equal(t, (1+7+1)*11+13, width)
+	assert.Equal(t, 1, height)
+	// Start B, then the bars of "P".
+	assert.Equal(t, bars("211214"+"313121"), code.bars[:22])
+	// The checksum is 55, before the stop symbol.
+	assert.Equal(t, bars(code128Patterns[55]), code.bars[width-24:width-13])
+
+	code, err = EncodeCode128("123456")
+	assert.Nil(t, err)
+	width, _ = code.Bounds()
+	assert.Equal(t, (1+3+1)*11+13, width)
+	assert.Equal(t, bars("211232"), code.bars[:11])
+
+	_, err = EncodeCode128("naïve")
+	assert.NotNil(t, err)
+	_, err = EncodeCode128("")
+	assert.NotNil(t, err)
+}
+
+func bars(pattern string) []bool {
+	var result []bool
+	for i, width := range pattern {
+		for j := 0; j < int(width-'0'); j++ {
+			result = append(result, i%2 == 0)
+		}
+	}
+	return result
+}
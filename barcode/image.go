@@ -0,0 +1,76 @@
+package barcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Symbol is a QR code or a barcode, as a grid of dark and light modules.
+type Symbol interface {
+	Bounds() (width, height int)
+	Dark(x, y int) bool
+	// QuietZone is the light margin a reader needs on the sides and above
+	// and below the symbol, in modules.
+	QuietZone() (horizontal, vertical int)
+}
+
+// Scale sizes the modules of a symbol drawn as an image, in pixels.
+type Scale struct {
+	Width, Height int
+}
+
+// Image draws symbol in black on white, with its quiet zone.
+func Image(symbol Symbol, scale Scale) image.Image {
+	width, height := symbol.Bounds()
+	quietX, quietY := symbol.QuietZone()
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, (width+2*quietX)*scale.Width, (height+2*quietY)*scale.Height), palette)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !symbol.Dark(x, y) {
+				continue
+			}
+			for py := (y + quietY) * scale.Height; py < (y+quietY+1)*scale.Height; py++ {
+				for px := (x + quietX) * scale.Width; px < (x+quietX+1)*scale.Width; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+	return img
+}
+
+func WritePNG(writer io.Writer, symbol Symbol, scale Scale) error {
+	return png.Encode(writer, Image(symbol, scale))
+}
+
+// WriteSVG draws symbol like Image, as a single path of the dark runs of
+// each row.
+func WriteSVG(writer io.Writer, symbol Symbol, scale Scale) error {
+	width, height := symbol.Bounds()
+	quietX, quietY := symbol.QuietZone()
+	var path strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; {
+			if !symbol.Dark(x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < width && symbol.Dark(x, y) {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv%dh-%dz", (start+quietX)*scale.Width, (y+quietY)*scale.Height,
+				(x-start)*scale.Width, scale.Height, (x-start)*scale.Width)
+		}
+	}
+	pixelWidth, pixelHeight := (width+2*quietX)*scale.Width, (height+2*quietY)*scale.Height
+	_, err := fmt.Fprintf(writer, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		pixelWidth, pixelHeight, pixelWidth, pixelHeight, path.String())
+	return err
+}
@@ -0,0 +1,35 @@
+package barcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestWritePNG(t *testing.T) {
+	code, err := EncodeQR([]byte("hello"), LevelM)
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	assert.Nil(t, WritePNG(&output, code, Scale{Width: 4, Height: 4}))
+	img, err := png.Decode(&output)
+	assert.Nil(t, err)
+	assert.Equal(t, (21+8)*4, img.Bounds().Dx())
+	assert.Equal(t, color.Gray{}, color.GrayModel.Convert(img.At(16, 16)))
+	assert.Equal(t, color.Gray{Y: 0xff}, color.GrayModel.Convert(img.At(15, 15)))
+}
+
+func TestWriteSVG(t *testing.T) {
+	code, err := EncodeCode128("1234")
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	assert.Nil(t, WriteSVG(&output, code, Scale{Width: 2, Height: 50}))
+	svg := output.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="154" height="50" viewBox="0 0 154 50"`))
+	// The start symbol C begins with a bar two modules wide.
+	assert.Contains(t, svg, `d="M20 0h4v50h-4z`)
+}
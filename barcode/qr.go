@@ -0,0 +1,492 @@
+// Package barcode encodes QR codes and Code 128 barcodes and draws them as
+// PNG or SVG images.
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// Level is the error correction level of a QR code: the share of the code
+// that can be damaged and still be read, from about 7% for LevelL to 30% for
+// LevelH.
+type Level int
+
+const (
+	LevelL Level = iota
+	LevelM
+	LevelQ
+	LevelH
+)
+
+var ErrDataTooLong = errors.New("data too long to encode")
+
+// ParseLevel parses "L", "M", "Q" or "H", in any case.
+func ParseLevel(level string) (Level, error) {
+	index := strings.Index("LMQH", strings.ToUpper(level))
+	if len(level) != 1 || index < 0 {
+		return 0, errors.New("unknown error correction level " + level)
+	}
+	return Level(index), nil
+}
+
+// formatBits are the bits of each level in the format information.
+var formatBits = [4]int{1, 0, 3, 2}
+
+// eccCodewordsPerBlock and eccBlocks are, by level and version, the number
+// of error correction codewords of each block and the number of blocks.
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var eccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// QRCode is a QR code of the smallest version that holds its data, encoded
+// in byte mode.
+type QRCode struct {
+	Version int
+	size    int
+	modules [][]bool
+	// function marks the modules of the patterns, which hold no data.
+	function [][]bool
+}
+
+// EncodeQR encodes data as a QR code at level, failing with ErrDataTooLong
+// when not even version 40 holds it.
+func EncodeQR(data []byte, level Level) (*QRCode, error) {
+	version := 1
+	for ; ; version++ {
+		if version > 40 {
+			return nil, ErrDataTooLong
+		}
+		if 4+countBits(version)+len(data)*8 <= dataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := dataCodewords(version, level) * 8
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	code := newQRCode(version)
+	code.drawFunctionPatterns(level)
+	code.drawCodewords(addErrorCorrection(bits.bytes(), version, level))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(level, mask)
+		penalty := code.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask)
+	}
+	code.applyMask(best)
+	code.drawFormatBits(level, best)
+	return code, nil
+}
+
+// Bounds returns the number of modules on each side of the code.
+func (code *QRCode) Bounds() (int, int) {
+	return code.size, code.size
+}
+
+func (code *QRCode) Dark(x, y int) bool {
+	return code.modules[y][x]
+}
+
+// QuietZone is the light margin a reader needs around the code, in modules.
+func (code *QRCode) QuietZone() (int, int) {
+	return 4, 4
+}
+
+func newQRCode(version int) *QRCode {
+	size := version*4 + 17
+	code := &QRCode{Version: version, size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for y := range code.modules {
+		code.modules[y] = make([]bool, size)
+		code.function[y] = make([]bool, size)
+	}
+	return code
+}
+
+func (code *QRCode) set(x, y int, dark bool) {
+	code.modules[y][x] = dark
+	code.function[y][x] = true
+}
+
+func (code *QRCode) drawFunctionPatterns(level Level) {
+	for i := 0; i < code.size; i++ {
+		code.set(6, i, i%2 == 0)
+		code.set(i, 6, i%2 == 0)
+	}
+	code.drawFinder(3, 3)
+	code.drawFinder(code.size-4, 3)
+	code.drawFinder(3, code.size-4)
+
+	positions := alignmentPositions(code.Version, code.size)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					code.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// The format bits are drawn for real once the mask is chosen; drawing
+	// them now reserves their modules.
+	code.drawFormatBits(level, 0)
+	code.drawVersion()
+}
+
+func (code *QRCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			distance := max(abs(dx), abs(dy))
+			if x+dx >= 0 && x+dx < code.size && y+dy >= 0 && y+dy < code.size {
+				code.set(x+dx, y+dy, distance != 2 && distance != 4)
+			}
+		}
+	}
+}
+
+// formatInformation returns the 15 bits telling level and mask, with their
+// BCH error correction.
+func formatInformation(level Level, mask int) int {
+	data := formatBits[level]<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// versionInformation returns the 18 bits telling version, with their BCH
+// error correction.
+func versionInformation(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+	}
+	return version<<12 | remainder
+}
+
+func (code *QRCode) drawFormatBits(level Level, mask int) {
+	bits := formatInformation(level, mask)
+	bit := func(i int) bool {
+		return bits>>i&1 != 0
+	}
+
+	for i := 0; i <= 5; i++ {
+		code.set(8, i, bit(i))
+	}
+	code.set(8, 7, bit(6))
+	code.set(8, 8, bit(7))
+	code.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		code.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		code.set(code.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		code.set(8, code.size-15+i, bit(i))
+	}
+	code.set(8, code.size-8, true)
+}
+
+func (code *QRCode) drawVersion() {
+	if code.Version < 7 {
+		return
+	}
+	bits := versionInformation(code.Version)
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 != 0
+		a, b := code.size-11+i%3, i/3
+		code.set(a, b, dark)
+		code.set(b, a, dark)
+	}
+}
+
+// drawCodewords lays out data in the zigzag of two-module columns, from the
+// bottom right corner, skipping the function patterns.
+func (code *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < code.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vertical
+				}
+				if !code.function[y][x] && i < len(data)*8 {
+					code.modules[y][x] = data[i>>3]>>(7-i&7)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask; applying it twice
+// undoes it.
+func (code *QRCode) applyMask(mask int) {
+	for y := 0; y < code.size; y++ {
+		for x := 0; x < code.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !code.function[y][x] {
+				code.modules[y][x] = !code.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to read, lower being better: long
+// runs and blocks of one color, patterns that look like the finders, and an
+// unbalanced share of dark modules.
+func (code *QRCode) penalty() int {
+	penalty := 0
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, transposed := range []bool{false, true} {
+		at := func(i, j int) bool {
+			if transposed {
+				return code.modules[j][i]
+			}
+			return code.modules[i][j]
+		}
+		for i := 0; i < code.size; i++ {
+			run := 1
+			for j := 1; j <= code.size; j++ {
+				if j < code.size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+			for j := 0; j+11 <= code.size; j++ {
+				for _, pattern := range finderLike {
+					matches := true
+					for k, dark := range pattern {
+						if at(i, j+k) != dark {
+							matches = false
+							break
+						}
+					}
+					if matches {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < code.size; y++ {
+		for x := 0; x < code.size; x++ {
+			if code.modules[y][x] {
+				dark++
+			}
+			if x+1 < code.size && y+1 < code.size {
+				color := code.modules[y][x]
+				if color == code.modules[y][x+1] && color == code.modules[y+1][x] && color == code.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := code.size * code.size
+	penalty += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return penalty
+}
+
+// alignmentPositions returns the centers of the alignment patterns on each
+// axis.
+func alignmentPositions(version, size int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, position := count-1, size-7; i >= 1; i, position = i-1, position-step {
+		positions[i] = position
+	}
+	return positions
+}
+
+// rawModules is the number of modules of a version that hold codewords.
+func rawModules(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		modules -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+	return modules
+}
+
+func dataCodewords(version int, level Level) int {
+	return rawModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// addErrorCorrection splits data into blocks, appends the Reed-Solomon
+// codewords of each and interleaves them.
+func addErrorCorrection(data []byte, version int, level Level) []byte {
+	blocks := eccBlocks[level][version]
+	eccLength := eccCodewordsPerBlock[level][version]
+	raw := rawModules(version) / 8
+	shortBlocks := blocks - raw%blocks
+	shortLength := raw / blocks
+
+	divisor := reedSolomonDivisor(eccLength)
+	var interleave [][]byte
+	for i, k := 0, 0; i < blocks; i++ {
+		length := shortLength - eccLength
+		if i >= shortBlocks {
+			length++
+		}
+		block := append([]byte(nil), data[k:k+length]...)
+		k += length
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < shortBlocks {
+			block = append(block, 0)
+		}
+		interleave = append(interleave, append(block, ecc...))
+	}
+
+	result := make([]byte, 0, raw)
+	for i := range interleave[0] {
+		for j, block := range interleave {
+			if i != shortLength-eccLength || j >= shortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func reedSolomonDivisor(degree int) []byte {
+	divisor := make([]byte, degree)
+	divisor[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range divisor {
+			divisor[j] = gfMultiply(divisor[j], root)
+			if j+1 < degree {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+	return divisor
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	remainder := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[len(remainder)-1] = 0
+		for i, coefficient := range divisor {
+			remainder[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return remainder
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (buffer *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*buffer = append(*buffer, value>>i&1 != 0)
+	}
+}
+
+func (buffer bitBuffer) bytes() []byte {
+	result := make([]byte, len(buffer)/8)
+	for i, bit := range buffer {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,133 @@
+package barcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestFormatAndVersionInformation(t *testing.T) {
+	assert.Equal(t, 0b111011111000100, formatInformation(LevelL, 0))
+	assert.Equal(t, 0b101010000010010, formatInformation(LevelM, 0))
+	assert.Equal(t, 0b011010101011111, formatInformation(LevelQ, 0))
+	assert.Equal(t, 0b001011010001001, formatInformation(LevelH, 0))
+	assert.Equal(t, 0b000111110010010100, versionInformation(7))
+}
+
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, reedSolomonRemainder(data, reedSolomonDivisor(10)))
+}
+
+func TestCapacity(t *testing.T) {
+	// The byte mode capacities of the smallest and largest versions.
+	assert.Equal(t, 17, dataCodewords(1, LevelL)-2)
+	assert.Equal(t, 7, dataCodewords(1, LevelH)-2)
+	assert.Equal(t, 2953, dataCodewords(40, LevelL)-3)
+	assert.Equal(t, 1273, dataCodewords(40, LevelH)-3)
+
+	_, err := EncodeQR(bytes.Repeat([]byte("a"), 2953), LevelL)
+	assert.Nil(t, err)
+	_, err = EncodeQR(bytes.Repeat([]byte("a"), 2954), LevelL)
+	assert.Equal(t, ErrDataTooLong, err)
+}
+
+// TestEncodeQR reads the codes back: the format information, then the
+// codewords, checking their error correction, then the data.
+func TestEncodeQR(t *testing.T) {
+	for _, test := range []struct {
+		data    string
+		level   Level
+		version int
+	}{
+		{"https://example.com/orders/1", LevelM, 3},
+		{"", LevelL, 1},
+		{strings.Repeat("0123456789", 30), LevelQ, 16},
+		{strings.Repeat("order ", 200), LevelH, 39},
+	} {
+		code, err := EncodeQR([]byte(test.data), test.level)
+		assert.Nil(t, err)
+		assert.Equal(t, test.version, code.Version)
+		assert.Equal(t, []byte(test.data), readQR(t, code, test.level))
+	}
+}
+
+func readQR(t *testing.T, code *QRCode, level Level) []byte {
+	format := 0
+	for i := 0; i < 15; i++ {
+		x, y := 8, code.size-15+i
+		if i < 8 {
+			x, y = code.size-1-i, 8
+		}
+		if code.Dark(x, y) {
+			format |= 1 << i
+		}
+	}
+	mask := (format ^ 0x5412) >> 10 & 7
+	assert.Equal(t, formatInformation(level, mask), format)
+
+	// Unmask a copy, and read the codewords in the order they are drawn.
+	unmasked := newQRCode(code.Version)
+	unmasked.function = code.function
+	for y := range code.modules {
+		copy(unmasked.modules[y], code.modules[y])
+	}
+	unmasked.applyMask(mask)
+	var bits bitBuffer
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < code.size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vertical
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vertical
+				}
+				if !code.function[y][x] {
+					bits = append(bits, unmasked.modules[y][x])
+				}
+			}
+		}
+	}
+	codewords := bits[:len(bits)/8*8].bytes()
+
+	// Deinterleave the blocks and check their error correction.
+	blocks := eccBlocks[level][code.Version]
+	eccLength := eccCodewordsPerBlock[level][code.Version]
+	shortBlocks := blocks - len(codewords)%blocks
+	shortLength := len(codewords) / blocks
+	deinterleaved := make([][]byte, blocks)
+	k := 0
+	for i := 0; i <= shortLength; i++ {
+		for j := range deinterleaved {
+			if i != shortLength-eccLength || j >= shortBlocks {
+				deinterleaved[j] = append(deinterleaved[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for _, block := range deinterleaved {
+		length := len(block) - eccLength
+		assert.Equal(t, block[length:], reedSolomonRemainder(block[:length], reedSolomonDivisor(eccLength)))
+		data = append(data, block[:length]...)
+	}
+
+	// Byte mode, the count, then the bytes.
+	assert.Equal(t, byte(0b0100), data[0]>>4)
+	var stream bitBuffer
+	for _, b := range data {
+		stream.append(int(b), 8)
+	}
+	count := 0
+	for _, bit := range stream[4 : 4+countBits(code.Version)] {
+		count <<= 1
+		if bit {
+			count |= 1
+		}
+	}
+	start := 4 + countBits(code.Version)
+	return stream[start : start+count*8].bytes()
+}
@@ -0,0 +1,137 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/telemetry"
+	"time"
+)
+
+var logger = telemetry.Logger("broker")
+
+// Message is an event as it is sent to the broker. Events with the same Key,
+// those of one user, stay in order on Kafka.
+type Message struct {
+	Key     string
+	Value   []byte
+	Headers map[string]string
+}
+
+// Sender sends messages to the topics of one broker.
+type Sender interface {
+	Send(ctx context.Context, topic string, message Message) error
+	Close() error
+}
+
+// Publisher forwards the events of the event bus to a broker. Subscribe
+// Handle to the events to forward.
+type Publisher struct {
+	sender          Sender
+	codec           Codec
+	topicPrefix     string
+	retries         int
+	retryBackoff    time.Duration
+	deadLetterTopic string
+}
+
+// New connects to the broker of config.Kind.
+func New(config config.BrokerConfig) (*Publisher, error) {
+	codec, err := NewCodec(config.Serialization)
+	if err != nil {
+		return nil, err
+	}
+
+	var sender Sender
+	switch config.Kind {
+	case "kafka":
+		sender = NewKafkaSender(config.Addresses)
+	case "nats":
+		sender, err = NewNATSSender(config.Addresses)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("unknown broker kind " + config.Kind)
+	}
+	return NewPublisher(sender, codec, config), nil
+}
+
+func NewPublisher(sender Sender, codec Codec, config config.BrokerConfig) *Publisher {
+	return &Publisher{
+		sender:          sender,
+		codec:           codec,
+		topicPrefix:     config.TopicPrefix,
+		retries:         config.Retries,
+		retryBackoff:    config.RetryBackoff,
+		deadLetterTopic: config.DeadLetterTopic,
+	}
+}
+
+// Handle is the event.Handler sending published to its topic. Once the
+// retries are used up the message goes to the dead-letter topic, with the
+// topic it was meant for and the error in its headers.
+func (publisher *Publisher) Handle(ctx context.Context, published event.Event) error {
+	value, err := publisher.codec.Marshal(published)
+	if err != nil {
+		return err
+	}
+	topic := publisher.topicPrefix + published.Name()
+	message := Message{
+		Key:   key(published),
+		Value: value,
+		Headers: map[string]string{
+			"content-type": publisher.codec.ContentType(),
+			"event":        published.Name(),
+		},
+	}
+
+	err = publisher.send(ctx, topic, message)
+	if err == nil || publisher.deadLetterTopic == "" {
+		return err
+	}
+	message.Headers["topic"] = topic
+	message.Headers["error"] = err.Error()
+	deadLetterErr := publisher.send(ctx, publisher.deadLetterTopic, message)
+	if deadLetterErr != nil {
+		return errors.Join(err, deadLetterErr)
+	}
+	logger.Warn("sent to the dead letter topic", "event", published.Name(), "topic", publisher.deadLetterTopic, "error", err)
+	return nil
+}
+
+func (publisher *Publisher) Close() error {
+	return publisher.sender.Close()
+}
+
+func (publisher *Publisher) send(ctx context.Context, topic string, message Message) error {
+	backoff := publisher.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := publisher.sender.Send(ctx, topic, message)
+		if err == nil || attempt == publisher.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// key is the ID of the user an event is about.
+func key(published event.Event) string {
+	switch published := published.(type) {
+	case event.UserRegistered:
+		return published.User.ID
+	case event.UserUpdated:
+		return published.User.ID
+	case event.UserDeleted:
+		return published.UserID
+	case event.UserRestored:
+		return published.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/proto/pb"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+type sent struct {
+	topic   string
+	message Message
+}
+
+type fakeSender struct {
+	failures map[string]int
+	sent     []sent
+}
+
+func (sender *fakeSender) Send(ctx context.Context, topic string, message Message) error {
+	if sender.failures[topic] > 0 {
+		sender.failures[topic]--
+		return errors.New("broker unavailable")
+	}
+	sender.sent = append(sender.sent, sent{topic: topic, message: message})
+	return nil
+}
+
+func (sender *fakeSender) Close() error {
+	return nil
+}
+
+var testConfig = config.BrokerConfig{TopicPrefix: "app.", Retries: 2, DeadLetterTopic: "app.dead_letter"}
+
+func TestPublisherRetries(t *testing.T) {
+	sender := &fakeSender{failures: map[string]int{"app.user.deleted": 2}}
+	publisher := NewPublisher(sender, jsonCodec{}, testConfig)
+
+	assert.Nil(t, publisher.Handle(context.Background(), event.UserDeleted{UserID: "1"}))
+	assert.Len(t, sender.sent, 1)
+	assert.Equal(t, "app.user.deleted", sender.sent[0].topic)
+	assert.Equal(t, "1", sender.sent[0].message.Key)
+	assert.JSONEq(t, `{"user_id":"1"}`, string(sender.sent[0].message.Value))
+	assert.Equal(t, "application/json", sender.sent[0].message.Headers["content-type"])
+}
+
+func TestPublisherDeadLetter(t *testing.T) {
+	sender := &fakeSender{failures: map[string]int{"app.user.deleted": 3}}
+	publisher := NewPublisher(sender, jsonCodec{}, testConfig)
+
+	assert.Nil(t, publisher.Handle(context.Background(), event.UserDeleted{UserID: "1"}))
+	assert.Len(t, sender.sent, 1)
+	assert.Equal(t, "app.dead_letter", sender.sent[0].topic)
+	assert.Equal(t, "app.user.deleted", sender.sent[0].message.Headers["topic"])
+	assert.Equal(t, "broker unavailable", sender.sent[0].message.Headers["error"])
+
+	sender = &fakeSender{failures: map[string]int{"app.user.deleted": 3, "app.dead_letter": 3}}
+	publisher = NewPublisher(sender, jsonCodec{}, testConfig)
+	assert.NotNil(t, publisher.Handle(context.Background(), event.UserDeleted{UserID: "1"}))
+	assert.Empty(t, sender.sent)
+}
+
+func TestProtobufCodec(t *testing.T) {
+	codec, err := NewCodec("protobuf")
+	assert.Nil(t, err)
+	value, err := codec.Marshal(event.UserRegistered{User: &model.User{ID: "1", Username: "brian"}})
+	assert.Nil(t, err)
+
+	var message pb.UserRegistered
+	assert.Nil(t, proto.Unmarshal(value, &message))
+	assert.Equal(t, "brian", message.User.Username)
+
+	_, err = NewCodec("xml")
+	assert.NotNil(t, err)
+}
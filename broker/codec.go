@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"golang-fiber-web/event"
+	"golang-fiber-web/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec serializes events for the broker.
+type Codec interface {
+	ContentType() string
+	Marshal(published event.Event) ([]byte, error)
+}
+
+// NewCodec returns the codec for serialization "json" or "protobuf".
+func NewCodec(serialization string) (Codec, error) {
+	switch serialization {
+	case "", "json":
+		return jsonCodec{}, nil
+	case "protobuf":
+		return protobufCodec{}, nil
+	}
+	return nil, errors.New("unknown serialization " + serialization)
+}
+
+type jsonCodec struct{}
+
+func (codec jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (codec jsonCodec) Marshal(published event.Event) ([]byte, error) {
+	return json.Marshal(published)
+}
+
+// protobufCodec writes the messages of proto/event.proto.
+type protobufCodec struct{}
+
+func (codec protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (codec protobufCodec) Marshal(published event.Event) ([]byte, error) {
+	var message proto.Message
+	switch published := published.(type) {
+	case event.UserRegistered:
+		message = &pb.UserRegistered{User: pb.FromUser(published.User)}
+	case event.UserUpdated:
+		message = &pb.UserUpdated{User: pb.FromUser(published.User)}
+	case event.UserDeleted:
+		message = &pb.UserDeleted{UserId: published.UserID}
+	case event.UserRestored:
+		message = &pb.UserRestored{User: pb.FromUser(published.User)}
+	case event.FileUploaded:
+		message = &pb.FileUploaded{FileName: published.FileName, Size: published.Size}
+	case event.WebhookReceived:
+		message = &pb.WebhookReceived{Provider: published.Provider, EventId: published.EventID, Type: published.Type, Payload: published.Payload}
+	default:
+		return nil, errors.New("no protobuf message for event " + published.Name())
+	}
+	return proto.Marshal(message)
+}
@@ -0,0 +1,35 @@
+package broker
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+)
+
+type kafkaSender struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSender writes to the Kafka cluster of addresses. Every message is
+// written on its own and acknowledged by all in-sync replicas; retrying is
+// left to the Publisher.
+func NewKafkaSender(addresses []string) Sender {
+	return &kafkaSender{writer: &kafka.Writer{
+		Addr:         kafka.TCP(addresses...),
+		Balancer:     &kafka.Hash{},
+		BatchSize:    1,
+		MaxAttempts:  1,
+		RequiredAcks: kafka.RequireAll,
+	}}
+}
+
+func (sender *kafkaSender) Send(ctx context.Context, topic string, message Message) error {
+	record := kafka.Message{Topic: topic, Key: []byte(message.Key), Value: message.Value}
+	for name, value := range message.Headers {
+		record.Headers = append(record.Headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+	return sender.writer.WriteMessages(ctx, record)
+}
+
+func (sender *kafkaSender) Close() error {
+	return sender.writer.Close()
+}
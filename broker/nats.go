@@ -0,0 +1,48 @@
+package broker
+
+import (
+	"context"
+	"github.com/nats-io/nats.go"
+	"strings"
+	"time"
+)
+
+// natsFlushTimeout bounds the wait for the server when ctx has no deadline,
+// which FlushWithContext requires.
+const natsFlushTimeout = time.Second * 5
+
+type natsSender struct {
+	conn *nats.Conn
+}
+
+// NewNATSSender connects to the NATS servers of addresses. Topics are
+// subjects, and the key travels in the Key header.
+func NewNATSSender(addresses []string) (Sender, error) {
+	conn, err := nats.Connect(strings.Join(addresses, ","))
+	if err != nil {
+		return nil, err
+	}
+	return &natsSender{conn: conn}, nil
+}
+
+// Send waits for the server to have received the message, so a failure is
+// reported instead of lost in the client's buffer.
+func (sender *natsSender) Send(ctx context.Context, topic string, message Message) error {
+	msg := nats.NewMsg(topic)
+	msg.Data = message.Value
+	msg.Header.Set("Key", message.Key)
+	for name, value := range message.Headers {
+		msg.Header.Set(name, value)
+	}
+	err := sender.conn.PublishMsg(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, natsFlushTimeout)
+	defer cancel()
+	return sender.conn.FlushWithContext(ctx)
+}
+
+func (sender *natsSender) Close() error {
+	return sender.conn.Drain()
+}
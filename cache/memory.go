@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store keeps cached values with a TTL. Tags group keys so they can be
+// purged together, e.g. every cached variant of a path.
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration, tags ...string) error
+	// SetNX stores the value only if the key does not exist and reports
+	// whether it did, so it can serve as a lock.
+	SetNX(key string, value []byte, ttl time.Duration) (bool, error)
+	// Increment adds one to the counter at key, starting it at 1 for ttl
+	// when it does not exist, and returns the count and how long the counter
+	// has left.
+	Increment(key string, ttl time.Duration) (int64, time.Duration, error)
+	Delete(keys ...string) error
+	InvalidateTag(tag string) error
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+type memoryStore struct {
+	mutex   sync.Mutex
+	entries map[string]memoryEntry
+	tags    map[string]map[string]struct{}
+	// pruned is when Increment last dropped the expired entries.
+	pruned time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: map[string]memoryEntry{},
+		tags:    map[string]map[string]struct{}{},
+	}
+}
+
+func (store *memoryStore) Get(key string) ([]byte, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(store.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (store *memoryStore) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	for existing, entry := range store.entries {
+		if now.After(entry.expires) {
+			delete(store.entries, existing)
+		}
+	}
+
+	store.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	for _, tag := range tags {
+		if store.tags[tag] == nil {
+			store.tags[tag] = map[string]struct{}{}
+		}
+		store.tags[tag][key] = struct{}{}
+	}
+	return nil
+}
+
+func (store *memoryStore) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	if entry, ok := store.entries[key]; ok && !now.After(entry.expires) {
+		return false, nil
+	}
+	store.entries[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (store *memoryStore) Increment(key string, ttl time.Duration) (int64, time.Duration, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(store.pruned) >= time.Minute {
+		for existing, entry := range store.entries {
+			if now.After(entry.expires) {
+				delete(store.entries, existing)
+			}
+		}
+		store.pruned = now
+	}
+	entry, ok := store.entries[key]
+	count := int64(0)
+	if ok && !now.After(entry.expires) {
+		count, _ = strconv.ParseInt(string(entry.value), 10, 64)
+	} else {
+		entry.expires = now.Add(ttl)
+	}
+	count++
+	entry.value = []byte(strconv.FormatInt(count, 10))
+	store.entries[key] = entry
+	return count, entry.expires.Sub(now), nil
+}
+
+func (store *memoryStore) Delete(keys ...string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(store.entries, key)
+	}
+	return nil
+}
+
+func (store *memoryStore) InvalidateTag(tag string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for key := range store.tags[tag] {
+		delete(store.entries, key)
+	}
+	delete(store.tags, tag)
+	return nil
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	assert.Nil(t, store.Set("key", []byte("value"), time.Millisecond))
+	time.Sleep(time.Millisecond * 5)
+	_, ok, err := store.Get("key")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestStoreSetNX(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "redis": NewRedisStore(client)} {
+		t.Run(name, func(t *testing.T) {
+			added, err := store.SetNX("lock", []byte("first"), time.Minute)
+			assert.Nil(t, err)
+			assert.True(t, added)
+
+			added, err = store.SetNX("lock", []byte("second"), time.Minute)
+			assert.Nil(t, err)
+			assert.False(t, added)
+			value, _, err := store.Get("lock")
+			assert.Nil(t, err)
+			assert.Equal(t, "first", string(value))
+
+			assert.Nil(t, store.Delete("lock"))
+			added, err = store.SetNX("lock", []byte("third"), time.Minute)
+			assert.Nil(t, err)
+			assert.True(t, added)
+		})
+	}
+}
+
+func TestStoreIncrement(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "redis": NewRedisStore(client)} {
+		t.Run(name, func(t *testing.T) {
+			count, remaining, err := store.Increment("hits", time.Minute)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(1), count)
+			assert.InDelta(t, time.Minute, remaining, float64(time.Second))
+
+			count, remaining, err = store.Increment("hits", time.Hour)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(2), count)
+			assert.LessOrEqual(t, remaining, time.Minute)
+		})
+	}
+}
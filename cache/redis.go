@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"golang-fiber-web/config"
+	"time"
+)
+
+func NewRedisClient(config config.RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Address,
+		Password: config.Password,
+		DB:       config.DB,
+	})
+	err := redisotel.InstrumentTracing(client)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (store *redisStore) Get(key string) ([]byte, bool, error) {
+	value, err := store.client.Get(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the value and records the key in a Redis set per tag. The tag
+// sets live as long as their longest-lived key.
+func (store *redisStore) Set(key string, value []byte, ttl time.Duration, tags ...string) error {
+	ctx := context.Background()
+	_, err := store.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, ttl)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, tag, key)
+			pipe.ExpireGT(ctx, tag, ttl)
+			pipe.ExpireNX(ctx, tag, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (store *redisStore) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	return store.client.SetNX(context.Background(), key, value, ttl).Result()
+}
+
+// Increment counts with INCR, setting the TTL only on the command that
+// creates the counter so later increments keep its window.
+func (store *redisStore) Increment(key string, ttl time.Duration) (int64, time.Duration, error) {
+	ctx := context.Background()
+	var count *redis.IntCmd
+	var remaining *redis.DurationCmd
+	_, err := store.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, ttl)
+		remaining = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return count.Val(), remaining.Val(), nil
+}
+
+func (store *redisStore) Delete(keys ...string) error {
+	return store.client.Del(context.Background(), keys...).Err()
+}
+
+func (store *redisStore) InvalidateTag(tag string) error {
+	ctx := context.Background()
+	keys, err := store.client.SMembers(ctx, tag).Result()
+	if err != nil {
+		return err
+	}
+	return store.client.Del(ctx, append(keys, tag)...).Err()
+}
@@ -0,0 +1,640 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/cobra"
+	"golang-fiber-web/config"
+	"golang-fiber-web/database"
+	"golang-fiber-web/di"
+	"golang-fiber-web/seed"
+	"golang-fiber-web/server"
+	"golang-fiber-web/systemd"
+	"golang-fiber-web/telemetry"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+type configLoader func() (*config.Config, error)
+
+// Exit codes, after sysexits.h, so a supervisor can tell a broken config,
+// which restarting doesn't fix, from a failure. An unhealthy check exits 1,
+// as container HEALTHCHECKs expect.
+const (
+	exitFailure = 1
+	exitUsage   = 64
+	exitConfig  = 78
+)
+
+// exitError ends the command with code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (exitErr *exitError) Error() string {
+	return exitErr.err.Error()
+}
+
+func (exitErr *exitError) Unwrap() error {
+	return exitErr.err
+}
+
+// exitCode is the exit code of the command that returned err.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
+}
+
+func NewRootCommand() *cobra.Command {
+	var configPath string
+	root := &cobra.Command{
+		Use:          "golang-fiber-web",
+		Short:        "Belajar Golang Fiber web application",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVarP(&configPath, "config", "c", "config.yaml", "path to the config file")
+
+	load := func() (*config.Config, error) {
+		return config.Load(configPath)
+	}
+	root.AddCommand(
+		newServeCommand(load),
+		newMigrateCommand(load),
+		newSeedCommand(load),
+		newRoutesCommand(load),
+		newCleanCommand(load),
+		newHealthCommand(load),
+	)
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &exitError{code: exitUsage, err: err}
+	})
+	return root
+}
+
+func newServeCommand(load configLoader) *cobra.Command {
+	var allowPendingMigrations bool
+	serve := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server and, when configured, the gRPC server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(load, func(container *di.Container) error {
+				config := container.Config()
+				err := telemetry.InitLogging(config.Log)
+				if err != nil {
+					return err
+				}
+				err = container.WatchConfig()
+				if err != nil {
+					return err
+				}
+				if config.Tracing.Enabled {
+					provider, err := telemetry.NewTracerProvider(config.Tracing)
+					if err != nil {
+						return err
+					}
+					defer provider.Shutdown(cmd.Context())
+				}
+				if config.Sentry.DSN != "" {
+					err := telemetry.InitSentry(config.Sentry)
+					if err != nil {
+						return err
+					}
+					defer sentry.Flush(time.Second * 2)
+				}
+
+				if config.Database.DSN != "" && !config.Database.AllowPendingMigrations && !allowPendingMigrations {
+					err = checkMigrations(container)
+					if err != nil {
+						return err
+					}
+				}
+
+				app, err := container.App()
+				if err != nil {
+					return err
+				}
+
+				if fiber.IsChild() {
+					fmt.Println("I'm child process")
+					if config.Server.Prefork {
+						metrics, err := telemetry.ServeChildMetrics(telemetry.ChildMetricsDir(os.Getppid()))
+						if err != nil {
+							return err
+						}
+						defer metrics.Close()
+					}
+				} else {
+					fmt.Println("I'm parent process")
+					container.CheckPrefork()
+					if config.Server.Prefork {
+						defer os.RemoveAll(telemetry.ChildMetricsDir(os.Getpid()))
+					}
+				}
+
+				// Under prefork only the parent serves gRPC, so the address is
+				// bound once.
+				if config.GRPC.Address != "" && !fiber.IsChild() {
+					grpcServer, err := container.GRPCServer()
+					if err != nil {
+						return err
+					}
+					listener, err := net.Listen("tcp", config.GRPC.Address)
+					if err != nil {
+						return err
+					}
+					defer grpcServer.GracefulStop()
+					go func() {
+						fmt.Printf("Serving gRPC on %s\n", listener.Addr())
+						err := grpcServer.Serve(listener)
+						if err != nil {
+							fmt.Println("gRPC server stopped:", err)
+							app.Shutdown()
+						}
+					}()
+				}
+
+				relay, err := container.OutboxRelay()
+				if err != nil {
+					return err
+				}
+				dispatcher, err := container.WebhookDispatcher()
+				if err != nil {
+					return err
+				}
+				tokenKeys, err := container.TokenKeys()
+				if err != nil {
+					return err
+				}
+				workers := []func(ctx context.Context){relay.Run, dispatcher.Run, tokenKeys.Run}
+				usageRecorder, err := container.UsageRecorder()
+				if err != nil {
+					return err
+				}
+				if usageRecorder != nil {
+					workers = append(workers, usageRecorder.Run)
+				}
+				if loadShedder := container.LoadShedder(); loadShedder != nil {
+					workers = append(workers, loadShedder.Run)
+				}
+				if config.Database.DSN != "" && len(config.Database.Replicas) > 0 {
+					db, err := container.Database()
+					if err != nil {
+						return err
+					}
+					workers = append(workers, db.Run)
+				}
+				siteMap, err := container.Sitemap()
+				if err != nil {
+					return err
+				}
+				workers = append(workers, siteMap.Run)
+				if !fiber.IsChild() {
+					workers = append(workers, func(ctx context.Context) {
+						systemd.Watchdog(ctx, container.Health)
+					})
+				}
+				if config.Janitor.Enabled {
+					janitor, err := container.Janitor()
+					if err != nil {
+						return err
+					}
+					workers = append(workers, janitor.Run)
+				}
+				// The workers stop before the container closes the event bus
+				// the relay publishes to.
+				stop := background(cmd.Context(), workers...)
+				defer stop()
+
+				upgrader, err := server.NewUpgrader()
+				if err != nil {
+					return err
+				}
+				stopSignals := handleSignals(app, upgrader, config.Server)
+				defer stopSignals()
+				return server.Listen(app, config.Server, upgrader)
+			})
+		},
+	}
+	serve.Flags().BoolVar(&allowPendingMigrations, "allow-pending-migrations", false, "serve even though database migrations are pending")
+	return serve
+}
+
+func newMigrateCommand(load configLoader) *cobra.Command {
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, roll back or check database migrations",
+	}
+
+	migrate.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(load, func(container *di.Container) error {
+				migrator, err := newMigrator(container)
+				if err != nil {
+					return err
+				}
+				migrations, err := migrator.Up()
+				printMigrations(cmd.OutOrStdout(), "applied", migrations)
+				return err
+			})
+		},
+	})
+
+	migrate.AddCommand(&cobra.Command{
+		Use:   "down [steps]",
+		Short: "Roll back the latest migrations (default 1)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps := 1
+			if len(args) == 1 {
+				var err error
+				steps, err = strconv.Atoi(args[0])
+				if err != nil || steps < 1 {
+					return errors.New("steps must be a positive number")
+				}
+			}
+
+			return withContainer(load, func(container *di.Container) error {
+				migrator, err := newMigrator(container)
+				if err != nil {
+					return err
+				}
+				migrations, err := migrator.Down(steps)
+				printMigrations(cmd.OutOrStdout(), "rolled back", migrations)
+				return err
+			})
+		},
+	})
+
+	migrate.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and whether they are applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(load, func(container *di.Container) error {
+				migrator, err := newMigrator(container)
+				if err != nil {
+					return err
+				}
+				statuses, err := migrator.Status()
+				if err != nil {
+					return err
+				}
+				printMigrationStatus(cmd.OutOrStdout(), statuses)
+				return nil
+			})
+		},
+	})
+
+	migrate.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Check the database schema and the applied migrations against the migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(load, func(container *di.Container) error {
+				migrator, err := newMigrator(container)
+				if err != nil {
+					return err
+				}
+				problems, err := migrator.Verify()
+				if err != nil {
+					return err
+				}
+				for _, problem := range problems {
+					fmt.Fprintln(cmd.OutOrStdout(), problem)
+				}
+				if len(problems) > 0 {
+					return fmt.Errorf("the schema drifted from the migrations: %d problems", len(problems))
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "the schema matches the migrations")
+				return nil
+			})
+		},
+	})
+
+	return migrate
+}
+
+func newSeedCommand(load configLoader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed",
+		Short: "Insert the initial data into the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(load, func(container *di.Container) error {
+				if container.Config().Database.DSN == "" {
+					return errors.New("database.dsn is not configured")
+				}
+				repositories, err := container.Repositories()
+				if err != nil {
+					return err
+				}
+
+				names, err := seed.Run(cmd.Context(), repositories, seed.Defaults(container.Config().Seed))
+				for _, name := range names {
+					fmt.Fprintln(cmd.OutOrStdout(), "seeded", name)
+				}
+				return err
+			})
+		},
+	}
+}
+
+func newCleanCommand(load configLoader) *cobra.Command {
+	var dryRun bool
+	clean := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove the temporary, orphaned and incomplete files once",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withContainer(load, func(container *di.Container) error {
+				janitor, err := container.Janitor()
+				if err != nil {
+					return err
+				}
+				if !cmd.Flags().Changed("dry-run") {
+					dryRun = container.Config().Janitor.DryRun
+				}
+
+				report, err := janitor.Clean(cmd.Context(), dryRun)
+				verb := "removed"
+				if dryRun {
+					verb = "would remove"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %d files (%d bytes)\n", verb, report.Files, report.Bytes)
+				return err
+			})
+		},
+	}
+	clean.Flags().BoolVar(&dryRun, "dry-run", false, "only report the files to remove (default from janitor.dry_run)")
+	return clean
+}
+
+func newRoutesCommand(load configLoader) *cobra.Command {
+	return &cobra.Command{
+		Use:   "routes",
+		Short: "Print the registered route table",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := load()
+			if err != nil {
+				return &exitError{code: exitConfig, err: err}
+			}
+
+			offline := *config
+			offline.Server.Prefork = false
+			offline.Database.DSN = ""
+			offline.Cache.Backend = "memory"
+			app, err := di.New(&offline).App()
+			if err != nil {
+				return err
+			}
+
+			PrintRoutes(cmd.OutOrStdout(), app)
+			return nil
+		},
+	}
+}
+
+func newHealthCommand(load configLoader) *cobra.Command {
+	var target string
+	var timeout time.Duration
+	health := &cobra.Command{
+		Use:   "health",
+		Short: "Check that the running server is ready, exiting 1 when it is not",
+		Long: "Check that the running server is ready, exiting 1 when it is not. It asks\n" +
+			"/readyz on the first listener of the config, or on --url, so it can serve as\n" +
+			"a container health check: HEALTHCHECK CMD [\"golang-fiber-web\", \"health\"]",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := &http.Client{Timeout: timeout}
+			if target == "" {
+				config, err := load()
+				if err != nil {
+					return &exitError{code: exitConfig, err: err}
+				}
+				target, client.Transport, err = healthTarget(config.Server)
+				if err != nil {
+					return &exitError{code: exitConfig, err: err}
+				}
+			}
+
+			response, err := client.Get(target)
+			if err != nil {
+				return &exitError{code: exitFailure, err: err}
+			}
+			response.Body.Close()
+			if response.StatusCode != http.StatusOK {
+				return &exitError{code: exitFailure, err: fmt.Errorf("%s answered %s", target, response.Status)}
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "ready")
+			return nil
+		},
+	}
+	health.Flags().StringVar(&target, "url", "", "readiness URL to check instead of the configured listener")
+	health.Flags().DurationVar(&timeout, "timeout", time.Second*5, "how long to wait for the answer")
+	return health
+}
+
+// healthTarget is the readiness URL of the first listener of config, with
+// the transport reaching it. A wildcard host is asked on the loopback, and a
+// Unix socket through its path. The certificate is not verified, since it
+// names the public domains rather than the loopback.
+func healthTarget(config config.ServerConfig) (string, http.RoundTripper, error) {
+	listeners := config.ListenerConfigs()
+	if len(listeners) == 0 {
+		return "", nil, errors.New("server has no listeners configured")
+	}
+	listener := listeners[0]
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	scheme := "http"
+	if listener.TLS.Enabled {
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	if listener.Network == server.NetworkUnix {
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, server.NetworkUnix, listener.Address)
+		}
+		return scheme + "://localhost/readyz", transport, nil
+	}
+	host, port, err := net.SplitHostPort(listener.Address)
+	if err != nil {
+		return "", nil, err
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + "/readyz", transport, nil
+}
+
+func PrintRoutes(out io.Writer, app *fiber.App) {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "METHOD\tPATH\tNAME\tHANDLERS")
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\n", route.Method, route.Path, route.Name, len(route.Handlers))
+	}
+	writer.Flush()
+}
+
+// background runs the workers until the returned stop is called, which waits
+// for them to return.
+func background(ctx context.Context, workers ...func(ctx context.Context)) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	var wait sync.WaitGroup
+	for _, worker := range workers {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			worker(ctx)
+		}()
+	}
+	return func() {
+		cancel()
+		wait.Wait()
+	}
+}
+
+// handleSignals drains the app on SIGTERM or SIGINT until the returned stop
+// is called. On SIGHUP it first hands its sockets over to a new process of
+// the binary, and goes on serving if that fails. The prefork parent keeps
+// the default handling of SIGTERM and SIGINT, which takes its children down
+// with it.
+func handleSignals(app *fiber.App, upgrader *server.Upgrader, config config.ServerConfig) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	if !config.Prefork || fiber.IsChild() {
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case received := <-signals:
+				if received == syscall.SIGHUP {
+					if config.Prefork {
+						fmt.Println("Upgrades are not supported under prefork")
+						continue
+					}
+					fmt.Println("Upgrading")
+					err := upgrader.Upgrade(config.UpgradeTimeout)
+					if err != nil {
+						fmt.Println("Upgrade failed:", err)
+						continue
+					}
+				} else if !fiber.IsChild() {
+					// Not after an upgrade, which the service goes on with.
+					systemd.Notify(systemd.Stopping)
+				}
+				fmt.Println("Draining connections")
+				err := app.ShutdownWithTimeout(config.ShutdownTimeout)
+				if err != nil {
+					fmt.Println("Shutdown:", err)
+				}
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// withContainer loads the config and runs fn with a container that is closed
+// once fn returns.
+func withContainer(load configLoader, fn func(container *di.Container) error) error {
+	config, err := load()
+	if err != nil {
+		return &exitError{code: exitConfig, err: err}
+	}
+	container := di.New(config)
+	defer container.Close()
+	return fn(container)
+}
+
+func newMigrator(container *di.Container) (*database.Migrator, error) {
+	db, err := container.DB()
+	if err != nil {
+		return nil, err
+	}
+	return database.NewMigrator(db)
+}
+
+// checkMigrations fails when migrations are pending, so the server doesn't
+// serve with a schema its code doesn't expect.
+func checkMigrations(container *di.Container) error {
+	migrator, err := newMigrator(container)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(pending))
+	for _, migration := range pending {
+		names = append(names, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+	}
+	return fmt.Errorf("%d migrations are pending (%s): run migrate up, or serve with --allow-pending-migrations", len(pending), strings.Join(names, ", "))
+}
+
+func printMigrationStatus(out io.Writer, statuses []database.MigrationStatus) {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, status := range statuses {
+		name, state := fmt.Sprintf("%04d_%s", status.Version, status.Name), "pending"
+		if status.Unknown {
+			name = fmt.Sprintf("%04d", status.Version)
+		}
+		if status.AppliedAt != nil {
+			state = "applied " + status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Unknown {
+			state += " (no migration)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", name, state)
+	}
+	writer.Flush()
+}
+
+func printMigrations(out io.Writer, action string, migrations []database.Migration) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(out, "nothing to do")
+	}
+	for _, migration := range migrations {
+		fmt.Fprintf(out, "%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoutesCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("server:\n  prefork: true\n"), 0644)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	command := NewRootCommand()
+	command.SetOut(&out)
+	command.SetArgs([]string{"routes", "--config", path})
+	err = command.Execute()
+	assert.Nil(t, err)
+
+	assert.Contains(t, out.String(), "METHOD")
+	assert.Regexp(t, `GET\s+/users/:id`, out.String())
+	assert.Regexp(t, `PUT\s+/users/:id`, out.String())
+	assert.NotContains(t, out.String(), "HEAD")
+}
+
+func TestMigrateRequiresDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("database:\n  dsn: \"\"\n"), 0644)
+	assert.Nil(t, err)
+
+	command := NewRootCommand()
+	command.SetOut(&bytes.Buffer{})
+	command.SetErr(&bytes.Buffer{})
+	command.SetArgs([]string{"migrate", "up", "--config", path})
+	assert.NotNil(t, command.Execute())
+}
+
+func TestHealthCommand(t *testing.T) {
+	status := http.StatusOK
+	ready := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/readyz", request.URL.Path)
+		writer.WriteHeader(status)
+	}))
+	defer ready.Close()
+
+	var out bytes.Buffer
+	command := NewRootCommand()
+	command.SetOut(&out)
+	command.SetArgs([]string{"health", "--url", ready.URL + "/readyz"})
+	assert.Nil(t, command.Execute())
+	assert.Equal(t, "ready\n", out.String())
+
+	status = http.StatusServiceUnavailable
+	command = NewRootCommand()
+	command.SetOut(&bytes.Buffer{})
+	command.SetErr(&bytes.Buffer{})
+	command.SetArgs([]string{"health", "--url", ready.URL + "/readyz"})
+	assert.Equal(t, exitFailure, exitCode(command.Execute()))
+}
+
+func TestExitCodes(t *testing.T) {
+	command := NewRootCommand()
+	command.SetOut(&bytes.Buffer{})
+	command.SetErr(&bytes.Buffer{})
+	command.SetArgs([]string{"routes", "--unknown"})
+	assert.Equal(t, exitUsage, exitCode(command.Execute()))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("server: ["), 0644)
+	assert.Nil(t, err)
+	command = NewRootCommand()
+	command.SetOut(&bytes.Buffer{})
+	command.SetErr(&bytes.Buffer{})
+	command.SetArgs([]string{"routes", "--config", path})
+	assert.Equal(t, exitConfig, exitCode(command.Execute()))
+}
+
+func TestHealthTarget(t *testing.T) {
+	target, _, err := healthTarget(config.ServerConfig{Address: ":8080"})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080/readyz", target)
+
+	target, _, err = healthTarget(config.ServerConfig{Address: "0.0.0.0:8443", TLS: config.TLSConfig{Enabled: true}})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://localhost:8443/readyz", target)
+
+	target, _, err = healthTarget(config.ServerConfig{Listeners: []config.ListenerConfig{{Network: "unix", Address: "/run/app.sock"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost/readyz", target)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+)
+
+var byteUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ByteSize is a number of bytes, written in YAML as a plain number or with a
+// KB, MB or GB suffix (powers of 1024).
+type ByteSize int
+
+func ParseByteSize(text string) (ByteSize, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	unit := ByteSize(1)
+	for _, byteUnit := range byteUnits {
+		if strings.HasSuffix(text, byteUnit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, byteUnit.suffix))
+			unit = byteUnit.size
+			break
+		}
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 {
+		return 0, errors.New("invalid byte size " + strconv.Quote(text))
+	}
+	return ByteSize(value) * unit, nil
+}
+
+func (size *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := ParseByteSize(node.Value)
+	if err != nil {
+		return err
+	}
+	*size = parsed
+	return nil
+}
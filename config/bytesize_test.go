@@ -0,0 +1,21 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestByteSize(t *testing.T) {
+	limits := BodyLimitConfig{}
+	err := yaml.Unmarshal([]byte("default: 512KB\nroutes:\n  /upload: 32mb\n  /raw: 100\n"), &limits)
+	assert.Nil(t, err)
+	assert.Equal(t, ByteSize(512*1024), limits.Default)
+	assert.Equal(t, ByteSize(32*1024*1024), limits.Routes["/upload"])
+	assert.Equal(t, ByteSize(100), limits.Routes["/raw"])
+
+	_, err = ParseByteSize("lots")
+	assert.NotNil(t, err)
+	_, err = ParseByteSize("-1MB")
+	assert.NotNil(t, err)
+}
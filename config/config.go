@@ -0,0 +1,1061 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+// Config is the whole application config. Environment is "development" or
+// "production"; production turns off conveniences meant for development, such
+// as Server.ViewsReload. Path is the file it was loaded from, if any.
+type Config struct {
+	Path        string                         `yaml:"-"`
+	Environment string                         `yaml:"environment"`
+	Server      ServerConfig                   `yaml:"server"`
+	GRPC        GRPCConfig                     `yaml:"grpc"`
+	Database    DatabaseConfig                 `yaml:"database"`
+	Redis       RedisConfig                    `yaml:"redis"`
+	Cache       CacheConfig                    `yaml:"cache"`
+	Timeout     TimeoutConfig                  `yaml:"timeout"`
+	SlowRequest SlowRequestConfig              `yaml:"slow_request"`
+	Idempotency IdempotencyConfig              `yaml:"idempotency"`
+	RateLimit   RateLimitConfig                `yaml:"rate_limit"`
+	LoadShed    LoadSheddingConfig             `yaml:"load_shedding"`
+	Concurrency ConcurrencyConfig              `yaml:"concurrency"`
+	Transaction TransactionConfig              `yaml:"transaction"`
+	CORS        CORSConfig                     `yaml:"cors"`
+	CSP         CSPConfig                      `yaml:"csp"`
+	Cookies     CookieConfig                   `yaml:"cookies"`
+	JWT         JWTConfig                      `yaml:"jwt"`
+	Captcha     CaptchaConfig                  `yaml:"captcha"`
+	Features    map[string]bool                `yaml:"features"`
+	Tenancy     TenancyConfig                  `yaml:"tenancy"`
+	Quotas      QuotaConfig                    `yaml:"quotas"`
+	Analytics   AnalyticsConfig                `yaml:"analytics"`
+	Uploads     UploadConfig                   `yaml:"uploads"`
+	Avatars     AvatarConfig                   `yaml:"avatars"`
+	Products    ProductConfig                  `yaml:"products"`
+	Reports     ReportConfig                   `yaml:"reports"`
+	Feeds       FeedConfig                     `yaml:"feeds"`
+	Sitemap     SitemapConfig                  `yaml:"sitemap"`
+	Robots      RobotsConfig                   `yaml:"robots"`
+	Janitor     JanitorConfig                  `yaml:"janitor"`
+	Static      StaticConfig                   `yaml:"static"`
+	Minify      MinifyConfig                   `yaml:"minify"`
+	Events      EventsConfig                   `yaml:"events"`
+	Outbox      OutboxConfig                   `yaml:"outbox"`
+	Webhooks    WebhookConfig                  `yaml:"webhooks"`
+	Payments    PaymentConfig                  `yaml:"payments"`
+	Push        PushConfig                     `yaml:"push"`
+	SMS         SMSConfig                      `yaml:"sms"`
+	Comments    CommentsConfig                 `yaml:"comments"`
+	Mail        MailConfig                     `yaml:"mail"`
+	Contact     ContactConfig                  `yaml:"contact"`
+	Broker      BrokerConfig                   `yaml:"broker"`
+	Proxy       []ProxyRouteConfig             `yaml:"proxy"`
+	HTTPClient  HTTPClientConfig               `yaml:"http_client"`
+	I18n        I18nConfig                     `yaml:"i18n"`
+	Log         LogConfig                      `yaml:"log"`
+	AccessLog   AccessLogConfig                `yaml:"access_log"`
+	Tracing     TracingConfig                  `yaml:"tracing"`
+	Sentry      SentryConfig                   `yaml:"sentry"`
+	Debug       DebugConfig                    `yaml:"debug"`
+	Admin       AdminConfig                    `yaml:"admin"`
+	Seed        SeedConfig                     `yaml:"seed"`
+	OAuth       map[string]OAuthProviderConfig `yaml:"oauth"`
+}
+
+type ServerConfig struct {
+	Address         string           `yaml:"address"`
+	BaseURL         string           `yaml:"base_url"`
+	Prefork         bool             `yaml:"prefork"`
+	ProblemDetails  bool             `yaml:"problem_details"`
+	Views           string           `yaml:"views"`
+	ViewsEngine     string           `yaml:"views_engine"`
+	ViewsLayout     string           `yaml:"views_layout"`
+	ViewsEmbedded   bool             `yaml:"views_embedded"`
+	ViewsReload     bool             `yaml:"views_reload"`
+	TrustedProxies  []string         `yaml:"trusted_proxies"`
+	TLS             TLSConfig        `yaml:"tls"`
+	Listeners       []ListenerConfig `yaml:"listeners"`
+	BodyLimit       BodyLimitConfig  `yaml:"body_limit"`
+	IdleTimeout     time.Duration    `yaml:"idle_timeout"`
+	ReadTimeout     time.Duration    `yaml:"read_timeout"`
+	WriteTimeout    time.Duration    `yaml:"write_timeout"`
+	EarlyHints      EarlyHintsConfig `yaml:"early_hints"`
+	ShutdownTimeout time.Duration    `yaml:"shutdown_timeout"`
+	UpgradeTimeout  time.Duration    `yaml:"upgrade_timeout"`
+	JSONCodec       string           `yaml:"json_codec"`
+}
+
+// EarlyHintsConfig lists, per template, the assets the pages rendering it
+// should preload, such as css/app.css. They are announced in Link headers
+// and, when Enabled, in a 103 Early Hints response sent before the page is
+// built.
+type EarlyHintsConfig struct {
+	Enabled bool                `yaml:"enabled"`
+	Views   map[string][]string `yaml:"views"`
+}
+
+// BodyLimitConfig caps the size of request bodies. The longest route prefix in
+// Routes wins over Default.
+type BodyLimitConfig struct {
+	Default ByteSize            `yaml:"default"`
+	Routes  map[string]ByteSize `yaml:"routes"`
+}
+
+// Max is the largest limit, which the server itself enforces before routing.
+func (limits BodyLimitConfig) Max() ByteSize {
+	largest := limits.Default
+	for _, limit := range limits.Routes {
+		if limit > largest {
+			largest = limit
+		}
+	}
+	return largest
+}
+
+// ListenerConfig is an address the server accepts connections on. Network is
+// the app network (tcp4 by default) or "unix", where Address is a socket path.
+type ListenerConfig struct {
+	Network string    `yaml:"network"`
+	Address string    `yaml:"address"`
+	TLS     TLSConfig `yaml:"tls"`
+}
+
+// ListenerConfigs returns the listener made of Address and TLS, unless Address
+// is empty, followed by the additional Listeners.
+func (server ServerConfig) ListenerConfigs() []ListenerConfig {
+	var listeners []ListenerConfig
+	if server.Address != "" {
+		listeners = append(listeners, ListenerConfig{Address: server.Address, TLS: server.TLS})
+	}
+	return append(listeners, server.Listeners...)
+}
+
+// TLSConfig serves HTTPS from CertFile and KeyFile, or with certificates
+// obtained from Let's Encrypt for Domains when Autocert is set. A non-empty
+// RedirectAddress adds a plain HTTP listener that redirects to HTTPS and
+// answers ACME HTTP-01 challenges. HTTP3 additionally serves HTTP/3 over QUIC
+// on the UDP port of the server address; it is experimental.
+type TLSConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	CertFile        string   `yaml:"cert_file"`
+	KeyFile         string   `yaml:"key_file"`
+	Autocert        bool     `yaml:"autocert"`
+	Domains         []string `yaml:"domains"`
+	Email           string   `yaml:"email"`
+	CacheDir        string   `yaml:"cache_dir"`
+	RedirectAddress string   `yaml:"redirect_address"`
+	HTTP3           bool     `yaml:"http3"`
+}
+
+// GRPCConfig serves the gRPC API on Address, next to the HTTP server. It is
+// off while Address is empty.
+type GRPCConfig struct {
+	Address string `yaml:"address"`
+}
+
+// DatabaseConfig is the primary database and its read Replicas, by DSN. The
+// reads of a request go to the replicas that answer their health check every
+// ReplicaHealthInterval and are at most MaxReplicaLag behind, zero for any,
+// until the request writes; the client then reads from the primary for
+// StickyFor, so it sees its writes before the replicas catch up. The server
+// refuses to start while migrations are pending, unless
+// AllowPendingMigrations.
+type DatabaseConfig struct {
+	DSN                    string        `yaml:"dsn"`
+	MaxOpenConns           int           `yaml:"max_open_conns"`
+	MaxIdleConns           int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime        time.Duration `yaml:"conn_max_lifetime"`
+	Replicas               []string      `yaml:"replicas"`
+	ReplicaHealthInterval  time.Duration `yaml:"replica_health_interval"`
+	MaxReplicaLag          time.Duration `yaml:"max_replica_lag"`
+	StickyFor              time.Duration `yaml:"sticky_for"`
+	AllowPendingMigrations bool          `yaml:"allow_pending_migrations"`
+}
+
+type RedisConfig struct {
+	Address  string `yaml:"address"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// CacheConfig is the store of the cached responses, among others, and their
+// lifetime by route prefix. The frequent reads of the users and products in
+// the database are cached for QueryTTL; zero turns that off.
+type CacheConfig struct {
+	Backend    string                   `yaml:"backend"`
+	DefaultTTL time.Duration            `yaml:"default_ttl"`
+	Routes     map[string]time.Duration `yaml:"routes"`
+	Vary       []string                 `yaml:"vary"`
+	QueryTTL   time.Duration            `yaml:"query_ttl"`
+}
+
+// TimeoutConfig bounds how long handlers may work on a request. The longest
+// route prefix in Routes wins over Default; zero means no deadline.
+type TimeoutConfig struct {
+	Default time.Duration            `yaml:"default"`
+	Routes  map[string]time.Duration `yaml:"routes"`
+}
+
+// SlowRequestConfig flags the requests taking longer than a threshold: the
+// one of the longest route prefix in Routes, else Default; zero turns it off.
+type SlowRequestConfig struct {
+	Default time.Duration            `yaml:"default"`
+	Routes  map[string]time.Duration `yaml:"routes"`
+}
+
+// IdempotencyConfig keeps responses to requests with an Idempotency-Key for
+// TTL. LockTTL bounds how long a request in progress blocks its retries.
+type IdempotencyConfig struct {
+	TTL     time.Duration `yaml:"ttl"`
+	LockTTL time.Duration `yaml:"lock_ttl"`
+}
+
+// RateLimitConfig lets each client IP make Max requests per Window; zero
+// Max turns the limit off.
+type RateLimitConfig struct {
+	Max    int           `yaml:"max"`
+	Window time.Duration `yaml:"window"`
+}
+
+// LoadSheddingConfig answers 503 with Retry-After to the low priority routes
+// while the server is saturated: MaxInFlight requests in progress, zero for
+// 256 per CPU, goroutines waiting MaxSchedLatency to run, or a route that is
+// not low priority over its latency budget. Past twice MaxInFlight the normal
+// routes are shed too; critical ones never are. Budgets and Priorities apply
+// by longest route prefix, over Budget and normal.
+type LoadSheddingConfig struct {
+	Enabled         bool                     `yaml:"enabled"`
+	MaxInFlight     int                      `yaml:"max_in_flight"`
+	MaxSchedLatency time.Duration            `yaml:"max_sched_latency"`
+	Budget          time.Duration            `yaml:"budget"`
+	Budgets         map[string]time.Duration `yaml:"budgets"`
+	Priorities      map[string]string        `yaml:"priorities"`
+	RetryAfter      time.Duration            `yaml:"retry_after"`
+}
+
+// ConcurrencyConfig bounds the requests in progress: Max in all, and Max of
+// each group in Groups, by longest route prefix; zero is unlimited. A request
+// past a limit waits for QueueTimeout, behind up to Queue others, then is
+// answered 503 with Retry-After.
+type ConcurrencyConfig struct {
+	Max          int                         `yaml:"max"`
+	Queue        int                         `yaml:"queue"`
+	QueueTimeout time.Duration               `yaml:"queue_timeout"`
+	RetryAfter   time.Duration               `yaml:"retry_after"`
+	Groups       map[string]ConcurrencyLimit `yaml:"groups"`
+}
+
+type ConcurrencyLimit struct {
+	Max   int `yaml:"max"`
+	Queue int `yaml:"queue"`
+}
+
+// TransactionConfig lists the route prefixes whose requests that change
+// something run in one database transaction each.
+type TransactionConfig struct {
+	Routes []string `yaml:"routes"`
+}
+
+// CORSConfig lists the origins allowed to make cross-origin requests, or "*"
+// for any. No CORS headers are sent while it is empty.
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+// CSPConfig sends Policy as the Content-Security-Policy of every response
+// when Enabled, or as Content-Security-Policy-Report-Only with ReportOnly.
+// Every {nonce} in Policy is replaced by a nonce made for the request, which
+// the views get as cspNonce for their inline scripts and styles.
+type CSPConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	ReportOnly bool   `yaml:"report_only"`
+	Policy     string `yaml:"policy"`
+}
+
+// CookieConfig encrypts the cookies with Keys, base64 AES keys of 16, 24 or
+// 32 bytes. The first encrypts, all of them decrypt, so a new key goes first
+// and the previous ones stay until their cookies expire. Cookies are sent as
+// they are while Keys is empty, and so are those named in Except.
+type CookieConfig struct {
+	Keys   []string `yaml:"keys"`
+	Except []string `yaml:"except"`
+}
+
+// JWTConfig signs the tokens the app issues, valid for TTL, from Issuer.
+// Signing keys are rotated every RotationInterval; a new key is published
+// PublishAhead before it signs, which should exceed how long the services
+// validating the tokens cache the key set. The keys are reloaded every
+// RefreshInterval.
+type JWTConfig struct {
+	Issuer           string        `yaml:"issuer"`
+	TTL              time.Duration `yaml:"ttl"`
+	RotationInterval time.Duration `yaml:"rotation_interval"`
+	PublishAhead     time.Duration `yaml:"publish_ahead"`
+	RefreshInterval  time.Duration `yaml:"refresh_interval"`
+}
+
+// CaptchaConfig makes clients solve a captcha of Provider, "hcaptcha",
+// "recaptcha" or "turnstile", before they log in or sign up. Secret verifies
+// the responses at VerifyURL, the provider's endpoint when empty, and
+// MinScore rejects the lower reCAPTCHA v3 scores. Environments overrides
+// these settings, in part or in full, when the app runs in one of its
+// environments, such as to turn the captcha off in development.
+type CaptchaConfig struct {
+	Enabled      bool                 `yaml:"enabled"`
+	Provider     string               `yaml:"provider"`
+	SiteKey      string               `yaml:"site_key"`
+	Secret       string               `yaml:"secret"`
+	VerifyURL    string               `yaml:"verify_url"`
+	MinScore     float64              `yaml:"min_score"`
+	Environments map[string]yaml.Node `yaml:"environments"`
+}
+
+// For returns the settings of the captcha in environment.
+func (captcha CaptchaConfig) For(environment string) (CaptchaConfig, error) {
+	override, ok := captcha.Environments[environment]
+	captcha.Environments = nil
+	if !ok {
+		return captcha, nil
+	}
+	err := override.Decode(&captcha)
+	return captcha, err
+}
+
+// TenancyConfig resolves the tenant of every request with the first of
+// Resolvers that finds one: "subdomain" takes the label before Domain in the
+// host, "header" the Header value and "jwt" the JWTClaim of a bearer token
+// signed with JWTSecret (HS256) by another issuer. Requests resolving none
+// belong to Default, or are rejected when it is empty. Only the Tenants listed
+// are accepted. A verified token always wins: a request where a resolver
+// before "jwt" finds another tenant than its token is rejected. The access
+// tokens of the app are not read by "jwt"; they carry their tenant in their
+// tenant_id claim, which the token middleware checks against the tenant
+// resolved.
+type TenancyConfig struct {
+	Enabled   bool                    `yaml:"enabled"`
+	Resolvers []string                `yaml:"resolvers"`
+	Domain    string                  `yaml:"domain"`
+	Header    string                  `yaml:"header"`
+	JWTSecret string                  `yaml:"jwt_secret"`
+	JWTClaim  string                  `yaml:"jwt_claim"`
+	Default   string                  `yaml:"default"`
+	Tenants   map[string]TenantConfig `yaml:"tenants"`
+}
+
+// TenantConfig overrides settings for the requests of a tenant.
+type TenantConfig struct {
+	RateLimit *RateLimitConfig `yaml:"rate_limit"`
+	Branding  BrandingConfig   `yaml:"branding"`
+}
+
+// BrandingConfig is how the views present a tenant.
+type BrandingConfig struct {
+	Name         string `yaml:"name"`
+	LogoURL      string `yaml:"logo_url"`
+	PrimaryColor string `yaml:"primary_color"`
+}
+
+// QuotaConfig caps the requests each user, and each of their API keys, make
+// per day and per month, counted in UTC; zero means no cap. Requests to paths
+// starting with one of Exclude are not counted.
+type QuotaConfig struct {
+	User    QuotaLimits `yaml:"user"`
+	APIKey  QuotaLimits `yaml:"api_key"`
+	Exclude []string    `yaml:"exclude"`
+}
+
+type QuotaLimits struct {
+	Daily   int `yaml:"daily"`
+	Monthly int `yaml:"monthly"`
+}
+
+// AnalyticsConfig records request statistics by route and consumer in
+// buckets of Bucket, which are kept in memory and written to the database
+// every FlushInterval.
+type AnalyticsConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Bucket        time.Duration `yaml:"bucket"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+}
+
+// UploadConfig is where the uploaded files are stored and how they are
+// processed once uploaded: thumbnails of the images fit in a square of
+// ThumbnailSize pixels, and ScanCommand, when set, is run with the path of
+// every file to scan it for viruses. The command exits with 0 for a clean
+// file and 1 for an infected one, as clamscan does. The thumbnails asked
+// for on demand are at most ThumbnailMaxSize pixels wide and high, and
+// images wider or taller than ImageMaxDimension pixels get no thumbnail.
+//
+// The files of every user add up to at most StorageQuota, or the quota of the
+// user in StorageQuotas; zero is no limit. The files uploaded without an API
+// key have no owner and no quota.
+type UploadConfig struct {
+	Dir               string              `yaml:"dir"`
+	ThumbnailSize     int                 `yaml:"thumbnail_size"`
+	ThumbnailMaxSize  int                 `yaml:"thumbnail_max_size"`
+	ImageMaxDimension int                 `yaml:"image_max_dimension"`
+	ScanCommand       []string            `yaml:"scan_command"`
+	StorageQuota      ByteSize            `yaml:"storage_quota"`
+	StorageQuotas     map[string]ByteSize `yaml:"storage_quotas"`
+}
+
+// StorageQuotaFor returns the storage quota of the user userID.
+func (uploads UploadConfig) StorageQuotaFor(userID string) ByteSize {
+	if quota, ok := uploads.StorageQuotas[userID]; ok {
+		return quota
+	}
+	return uploads.StorageQuota
+}
+
+// AvatarConfig sets how the avatars of the users are stored: cropped to
+// squares of every one of Sizes, in pixels. Uploads larger than MaxSize or
+// wider or taller than MaxDimension pixels are refused.
+type AvatarConfig struct {
+	Sizes        []int    `yaml:"sizes"`
+	MaxSize      ByteSize `yaml:"max_size"`
+	MaxDimension int      `yaml:"max_dimension"`
+}
+
+// ProductConfig limits the images of the products: each product has at most
+// MaxImages, and images larger than ImageMaxSize are refused.
+type ProductConfig struct {
+	MaxImages    int      `yaml:"max_images"`
+	ImageMaxSize ByteSize `yaml:"image_max_size"`
+}
+
+// ReportConfig sets which PDF reports are rendered while the client waits:
+// those of at most InlineMaxOrders orders. Larger ones are generated in the
+// background, to be downloaded once ready.
+type ReportConfig struct {
+	InlineMaxOrders int `yaml:"inline_max_orders"`
+}
+
+// FeedConfig names the feeds of the latest products and sets how many
+// products they list.
+type FeedConfig struct {
+	Title string `yaml:"title"`
+	Items int    `yaml:"items"`
+}
+
+// SitemapConfig lists in /sitemap.xml the public routes named in Routes,
+// which take no parameters, and the products. The sitemap is split into
+// gzipped pages of at most PageSize URLs and regenerated every Interval.
+type SitemapConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	PageSize int           `yaml:"page_size"`
+	Routes   []string      `yaml:"routes"`
+}
+
+// RobotsConfig is /robots.txt: the path prefixes crawlers may not visit, and
+// those within them they may. It points crawlers to the sitemap.
+type RobotsConfig struct {
+	Disallow []string `yaml:"disallow"`
+	Allow    []string `yaml:"allow"`
+}
+
+// JanitorConfig removes, every Interval, the files of TempDirs older than
+// TempRetention, the uploaded files and thumbnails no file record refers to
+// once older than OrphanRetention, and what is left of the uploads that never
+// completed after PartialRetention. With DryRun, what would be removed is
+// only logged and counted.
+type JanitorConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Interval         time.Duration `yaml:"interval"`
+	DryRun           bool          `yaml:"dry_run"`
+	TempDirs         []string      `yaml:"temp_dirs"`
+	TempRetention    time.Duration `yaml:"temp_retention"`
+	OrphanRetention  time.Duration `yaml:"orphan_retention"`
+	PartialRetention time.Duration `yaml:"partial_retention"`
+}
+
+// StaticConfig serves the embedded front-end assets under Prefix and the
+// embedded ./source files under SourcePrefix. Fingerprinted names are cached
+// for MaxAge. SPA mounts single-page apps built outside of the binary.
+type StaticConfig struct {
+	Prefix       string        `yaml:"prefix"`
+	SourcePrefix string        `yaml:"source_prefix"`
+	MaxAge       time.Duration `yaml:"max_age"`
+	SPA          []SPAConfig   `yaml:"spa"`
+}
+
+// MinifyConfig minifies the HTML, CSS and JavaScript responses when Enabled,
+// in production only, so pages stay readable during development.
+type MinifyConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// SPAConfig serves the files of Dir under Prefix. GET requests for paths
+// that are neither a file nor look like one, having no extension, get Index,
+// index.html when empty, so a history-mode app can route them in the
+// browser. Paths under Exclude, /api when empty, are left to the API and get
+// its 404. Files other than Index are cached for MaxAge.
+type SPAConfig struct {
+	Name    string        `yaml:"name"`
+	Prefix  string        `yaml:"prefix"`
+	Dir     string        `yaml:"dir"`
+	Index   string        `yaml:"index"`
+	Exclude []string      `yaml:"exclude"`
+	MaxAge  time.Duration `yaml:"max_age"`
+}
+
+// EventsConfig sizes the in-process event bus: Workers handle events
+// concurrently and QueueSize events wait before publishers block.
+type EventsConfig struct {
+	Workers   int `yaml:"workers"`
+	QueueSize int `yaml:"queue_size"`
+}
+
+// OutboxConfig sets how often the outbox is relayed to the event bus, and how
+// many messages are claimed at a time.
+type OutboxConfig struct {
+	Interval  time.Duration `yaml:"interval"`
+	BatchSize int           `yaml:"batch_size"`
+}
+
+// BrokerConfig forwards the domain events to Kafka or NATS so other systems
+// can consume them. Kind is "kafka", "nats" or empty, which disables it.
+// Addresses are the Kafka brokers or the NATS server URLs. An event goes to
+// the topic TopicPrefix followed by its name, serialized as "json" or
+// "protobuf". Sending is retried Retries times, RetryBackoff apart and
+// doubling; an event that still fails goes to DeadLetterTopic, unless it is
+// empty.
+type BrokerConfig struct {
+	Kind            string        `yaml:"kind"`
+	Addresses       []string      `yaml:"addresses"`
+	TopicPrefix     string        `yaml:"topic_prefix"`
+	Serialization   string        `yaml:"serialization"`
+	Retries         int           `yaml:"retries"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`
+	DeadLetterTopic string        `yaml:"dead_letter_topic"`
+}
+
+// WebhookConfig sets how webhooks are delivered. Every Interval, up to
+// BatchSize due deliveries are sent, each waiting Timeout for the endpoint.
+// A failed delivery is retried Backoff later, doubling every time, until it
+// has been attempted MaxAttempts times. Receivers are the providers whose
+// webhooks are accepted at /webhooks/:provider.
+type WebhookConfig struct {
+	Interval    time.Duration                    `yaml:"interval"`
+	BatchSize   int                              `yaml:"batch_size"`
+	Timeout     time.Duration                    `yaml:"timeout"`
+	MaxAttempts int                              `yaml:"max_attempts"`
+	Backoff     time.Duration                    `yaml:"backoff"`
+	Receivers   map[string]WebhookReceiverConfig `yaml:"receivers"`
+}
+
+// WebhookReceiverConfig holds the secret a provider, "stripe", "github",
+// "twilio" or "vonage", signs its webhooks with: the auth token of the
+// account for Twilio and the signature secret for Vonage. Twilio signs the
+// URL it posts to as well, which is URL, the public URL of the receiver.
+type WebhookReceiverConfig struct {
+	Secret string `yaml:"secret"`
+	URL    string `yaml:"url"`
+}
+
+// PaymentConfig enables paying the orders through Stripe Checkout when
+// StripeSecretKey is set. The customers are sent back to SuccessURL or
+// CancelURL, where {order_id} stands for the ID of their order. Stripe tells
+// the app about the payments through the "stripe" webhook receiver.
+type PaymentConfig struct {
+	StripeSecretKey string `yaml:"stripe_secret_key"`
+	StripeAPIURL    string `yaml:"stripe_api_url"`
+	SuccessURL      string `yaml:"success_url"`
+	CancelURL       string `yaml:"cancel_url"`
+}
+
+// PushConfig sends the notifications of the users to their devices. Devices
+// registered with Firebase Cloud Messaging are pushed to once FCMProjectID
+// and FCMCredentialsFile, the JSON key of a service account, are set; Apple
+// devices once the APNs fields are, APNsKeyFile being the .p8 signing key
+// whose ID is APNsKeyID and APNsTopic the bundle ID of the app. Use
+// https://api.sandbox.push.apple.com as APNsAPIURL for development builds.
+type PushConfig struct {
+	FCMProjectID       string `yaml:"fcm_project_id"`
+	FCMCredentialsFile string `yaml:"fcm_credentials_file"`
+	FCMAPIURL          string `yaml:"fcm_api_url"`
+	APNsKeyFile        string `yaml:"apns_key_file"`
+	APNsKeyID          string `yaml:"apns_key_id"`
+	APNsTeamID         string `yaml:"apns_team_id"`
+	APNsTopic          string `yaml:"apns_topic"`
+	APNsAPIURL         string `yaml:"apns_api_url"`
+}
+
+// SMSConfig texts the users through Provider, "twilio" or "vonage", from the
+// number or sender ID From; no texts are sent when Provider is empty. A
+// recipient gets at most RecipientDailyLimit texts a day. Twilio reports the
+// delivery of the texts to StatusCallbackURL, the public URL of the "twilio"
+// webhook receiver; Vonage to the status URL of its application, to be set
+// to that of the "vonage" receiver.
+type SMSConfig struct {
+	Provider            string `yaml:"provider"`
+	From                string `yaml:"from"`
+	RecipientDailyLimit int    `yaml:"recipient_daily_limit"`
+	TwilioAccountSID    string `yaml:"twilio_account_sid"`
+	TwilioAuthToken     string `yaml:"twilio_auth_token"`
+	TwilioAPIURL        string `yaml:"twilio_api_url"`
+	StatusCallbackURL   string `yaml:"status_callback_url"`
+	VonageAPIKey        string `yaml:"vonage_api_key"`
+	VonageAPISecret     string `yaml:"vonage_api_secret"`
+	VonageAPIURL        string `yaml:"vonage_api_url"`
+}
+
+// CommentsConfig lets a user post DailyLimit comments a day, replies
+// nested at most MaxDepth levels deep. A comment flagged by FlagThreshold
+// users is hidden until a moderator reviews it.
+type CommentsConfig struct {
+	DailyLimit    int `yaml:"daily_limit"`
+	MaxDepth      int `yaml:"max_depth"`
+	FlagThreshold int `yaml:"flag_threshold"`
+}
+
+// MailConfig sends email through the SMTP server at Host:Port, from the
+// address From, authenticating with Username and Password when set. No
+// email is sent while Host is empty.
+type MailConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// ContactConfig mails the messages of the contact form to To. A client IP
+// sends at most DailyLimit messages a day.
+type ContactConfig struct {
+	To         string `yaml:"to"`
+	DailyLimit int    `yaml:"daily_limit"`
+}
+
+// ProxyRouteConfig forwards the requests under Prefix to the Upstream base
+// URL, without the prefix when StripPrefix is set. An attempt fails after
+// Timeout, 30s when zero. Idempotent requests that fail to connect or get a
+// 502, 503 or 504 are retried Retries times, RetryBackoff apart.
+type ProxyRouteConfig struct {
+	Name            string              `yaml:"name"`
+	Prefix          string              `yaml:"prefix"`
+	Upstream        string              `yaml:"upstream"`
+	StripPrefix     bool                `yaml:"strip_prefix"`
+	Timeout         time.Duration       `yaml:"timeout"`
+	Retries         int                 `yaml:"retries"`
+	RetryBackoff    time.Duration       `yaml:"retry_backoff"`
+	RequestHeaders  HeaderRewriteConfig `yaml:"request_headers"`
+	ResponseHeaders HeaderRewriteConfig `yaml:"response_headers"`
+}
+
+// HeaderRewriteConfig removes the headers of Remove, then sets those of Set.
+type HeaderRewriteConfig struct {
+	Set    map[string]string `yaml:"set"`
+	Remove []string          `yaml:"remove"`
+}
+
+// HTTPClientConfig sets how third-party APIs are called. An attempt fails
+// after Timeout, or the Timeout of its host in Hosts. Idempotent calls that
+// fail to connect or get a 429 or 5xx are retried up to Retries times, after
+// a random wait of up to Backoff that doubles every retry, capped at
+// MaxBackoff. After FailureThreshold failures in a row the circuit of the
+// host opens and calls fail fast for OpenTimeout, until a single call gets
+// through to probe it. A zero FailureThreshold disables circuit breaking.
+type HTTPClientConfig struct {
+	Timeout          time.Duration             `yaml:"timeout"`
+	Retries          int                       `yaml:"retries"`
+	Backoff          time.Duration             `yaml:"backoff"`
+	MaxBackoff       time.Duration             `yaml:"max_backoff"`
+	FailureThreshold int                       `yaml:"failure_threshold"`
+	OpenTimeout      time.Duration             `yaml:"open_timeout"`
+	Hosts            map[string]HTTPHostConfig `yaml:"hosts"`
+}
+
+type HTTPHostConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// I18nConfig loads the message catalogs from Dir, one <locale>.json or
+// <locale>.toml file per locale; an empty Dir leaves every key untranslated.
+// DefaultLocale is used when a request asks for no supported locale, and for
+// keys missing from the catalog of the one it got.
+type I18nConfig struct {
+	Dir           string `yaml:"dir"`
+	DefaultLocale string `yaml:"default_locale"`
+}
+
+// LogConfig sets the level (debug, info, warn or error) and the format (text
+// or json) of the logs. Debug lists the modules, such as "outbox", that log at
+// debug level whatever the level. The admin endpoints can change both at runtime.
+type LogConfig struct {
+	Level  string   `yaml:"level"`
+	Format string   `yaml:"format"`
+	Debug  []string `yaml:"debug"`
+}
+
+// AccessLogConfig writes a line per request in the Apache "combined" format
+// or as "json" to a Sink: "stdout", a "file" rotated by size, or "syslog".
+// Environments overrides these settings, in part or in full, when the app
+// runs in one of its environments.
+type AccessLogConfig struct {
+	Enabled      bool                 `yaml:"enabled"`
+	Format       string               `yaml:"format"`
+	Sink         string               `yaml:"sink"`
+	File         RotatingFileConfig   `yaml:"file"`
+	Syslog       SyslogConfig         `yaml:"syslog"`
+	Environments map[string]yaml.Node `yaml:"environments"`
+}
+
+// For returns the settings of the access log in environment.
+func (accessLog AccessLogConfig) For(environment string) (AccessLogConfig, error) {
+	override, ok := accessLog.Environments[environment]
+	accessLog.Environments = nil
+	if !ok {
+		return accessLog, nil
+	}
+	err := override.Decode(&accessLog)
+	return accessLog, err
+}
+
+// RotatingFileConfig writes to Path until it reaches MaxSize, then renames it
+// Path.1, shifting the older files up to Path.<MaxBackups>, and starts anew.
+type RotatingFileConfig struct {
+	Path       string   `yaml:"path"`
+	MaxSize    ByteSize `yaml:"max_size"`
+	MaxBackups int      `yaml:"max_backups"`
+}
+
+// SyslogConfig sends to the syslog daemon at Address over Network ("udp" or
+// "tcp"), or to the local one when Address is empty, tagging messages with
+// Tag.
+type SyslogConfig struct {
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
+}
+
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+type SentryConfig struct {
+	DSN         string  `yaml:"dsn"`
+	Environment string  `yaml:"environment"`
+	Release     string  `yaml:"release"`
+	SampleRate  float64 `yaml:"sample_rate"`
+}
+
+type DebugConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// AdminConfig holds the basic auth credentials of the admin endpoints, which
+// are disabled while Password is empty.
+type AdminConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type SeedConfig struct {
+	AdminUsername string `yaml:"admin_username"`
+	AdminEmail    string `yaml:"admin_email"`
+	Demo          bool   `yaml:"demo"`
+}
+
+type OAuthProviderConfig struct {
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	AuthURL      string   `yaml:"auth_url"`
+	TokenURL     string   `yaml:"token_url"`
+	UserInfoURL  string   `yaml:"user_info_url"`
+	EmailsURL    string   `yaml:"emails_url"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Address:         "localhost:8080",
+			BaseURL:         "http://localhost:8080",
+			Views:           "./views",
+			ViewsEngine:     "mustache",
+			ViewsLayout:     "layouts/main",
+			IdleTimeout:     time.Minute * 5,
+			ReadTimeout:     time.Minute * 5,
+			WriteTimeout:    time.Minute * 5,
+			ShutdownTimeout: time.Second * 30,
+			UpgradeTimeout:  time.Minute,
+			JSONCodec:       "encoding/json",
+			TLS: TLSConfig{
+				CacheDir: "./certs",
+			},
+			BodyLimit: BodyLimitConfig{
+				Default: 1 << 20,
+				Routes:  map[string]ByteSize{"/upload": 32 << 20, "/users/import": 10 << 20},
+			},
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:          10,
+			MaxIdleConns:          5,
+			ConnMaxLifetime:       time.Minute * 30,
+			ReplicaHealthInterval: time.Second * 5,
+			MaxReplicaLag:         time.Second * 10,
+			StickyFor:             time.Second * 5,
+		},
+		Cache: CacheConfig{
+			Backend: "memory",
+			Vary:    []string{"Accept"},
+		},
+		Timeout: TimeoutConfig{
+			Default: time.Second * 30,
+		},
+		SlowRequest: SlowRequestConfig{
+			Default: time.Second,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:     time.Hour * 24,
+			LockTTL: time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Window: time.Minute,
+		},
+		LoadShed: LoadSheddingConfig{
+			MaxSchedLatency: time.Millisecond * 50,
+			RetryAfter:      time.Second * 5,
+		},
+		Concurrency: ConcurrencyConfig{
+			QueueTimeout: time.Second * 5,
+			RetryAfter:   time.Second * 5,
+		},
+		Features: map[string]bool{},
+		Tenancy: TenancyConfig{
+			Resolvers: []string{"subdomain", "header"},
+			Header:    "X-Tenant-ID",
+			JWTClaim:  "tenant_id",
+			Tenants:   map[string]TenantConfig{},
+		},
+		Quotas: QuotaConfig{
+			Exclude: []string{"/quota"},
+		},
+		Analytics: AnalyticsConfig{
+			Bucket:        time.Hour,
+			FlushInterval: time.Second * 10,
+		},
+		Uploads: UploadConfig{
+			Dir:               "./uploads",
+			ThumbnailSize:     256,
+			ThumbnailMaxSize:  2048,
+			ImageMaxDimension: 8192,
+		},
+		Avatars: AvatarConfig{
+			Sizes:        []int{32, 64, 128, 256},
+			MaxSize:      5 << 20,
+			MaxDimension: 4096,
+		},
+		Products: ProductConfig{
+			MaxImages:    10,
+			ImageMaxSize: 5 << 20,
+		},
+		Reports: ReportConfig{
+			InlineMaxOrders: 200,
+		},
+		Feeds: FeedConfig{
+			Title: "Latest products",
+			Items: 20,
+		},
+		Sitemap: SitemapConfig{
+			Interval: time.Hour,
+			PageSize: 50000,
+			Routes:   []string{"home", "products.list", "products.categories"},
+		},
+		Robots: RobotsConfig{
+			Disallow: []string{"/admin", "/debug", "/api", "/me", "/users", "/auth"},
+		},
+		Janitor: JanitorConfig{
+			Interval:         time.Hour,
+			TempRetention:    time.Hour * 24,
+			OrphanRetention:  time.Hour,
+			PartialRetention: time.Hour * 24,
+		},
+		JWT: JWTConfig{
+			TTL:              time.Minute * 15,
+			RotationInterval: time.Hour * 24,
+			PublishAhead:     time.Hour,
+			RefreshInterval:  time.Minute,
+		},
+		CSP: CSPConfig{
+			Policy: "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'nonce-{nonce}'; " +
+				"object-src 'none'; base-uri 'self'; frame-ancestors 'self'",
+		},
+		Static: StaticConfig{
+			Prefix:       "/assets",
+			SourcePrefix: "/public",
+			MaxAge:       time.Hour * 24 * 365,
+		},
+		Events: EventsConfig{
+			Workers:   4,
+			QueueSize: 1024,
+		},
+		Outbox: OutboxConfig{
+			Interval:  time.Second,
+			BatchSize: 100,
+		},
+		Webhooks: WebhookConfig{
+			Interval:    time.Second,
+			BatchSize:   20,
+			Timeout:     time.Second * 10,
+			MaxAttempts: 8,
+			Backoff:     time.Second * 30,
+			Receivers:   map[string]WebhookReceiverConfig{},
+		},
+		Payments: PaymentConfig{
+			StripeAPIURL: "https://api.stripe.com",
+		},
+		Push: PushConfig{
+			FCMAPIURL:  "https://fcm.googleapis.com",
+			APNsAPIURL: "https://api.push.apple.com",
+		},
+		SMS: SMSConfig{
+			RecipientDailyLimit: 10,
+			TwilioAPIURL:        "https://api.twilio.com",
+			VonageAPIURL:        "https://api.nexmo.com",
+		},
+		Comments: CommentsConfig{
+			DailyLimit:    20,
+			MaxDepth:      5,
+			FlagThreshold: 3,
+		},
+		Mail: MailConfig{
+			Port: 587,
+		},
+		Contact: ContactConfig{
+			DailyLimit: 5,
+		},
+		Broker: BrokerConfig{
+			TopicPrefix:     "golang-fiber-web.",
+			Serialization:   "json",
+			Retries:         3,
+			RetryBackoff:    time.Millisecond * 200,
+			DeadLetterTopic: "golang-fiber-web.dead_letter",
+		},
+		HTTPClient: HTTPClientConfig{
+			Timeout:          time.Second * 10,
+			Retries:          2,
+			Backoff:          time.Millisecond * 100,
+			MaxBackoff:       time.Second * 2,
+			FailureThreshold: 5,
+			OpenTimeout:      time.Second * 30,
+			Hosts:            map[string]HTTPHostConfig{},
+		},
+		I18n: I18nConfig{
+			DefaultLocale: "en",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		AccessLog: AccessLogConfig{
+			Format: "combined",
+			Sink:   "stdout",
+			File: RotatingFileConfig{
+				Path:       "./logs/access.log",
+				MaxSize:    100 << 20,
+				MaxBackups: 5,
+			},
+			Syslog: SyslogConfig{
+				Tag: "golang-fiber-web",
+			},
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "golang-fiber-web",
+		},
+		Sentry: SentryConfig{
+			Environment: "development",
+			SampleRate:  1,
+		},
+		Admin: AdminConfig{
+			Username: "admin",
+		},
+		Seed: SeedConfig{
+			AdminUsername: "admin",
+			AdminEmail:    "admin@example.com",
+		},
+		OAuth: map[string]OAuthProviderConfig{},
+	}
+}
+
+// Load reads a YAML config file on top of Default. ${VAR}
+// references are expanded from the environment so secrets stay out of the file.
+func Load(path string) (*Config, error) {
+	config := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), config)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Path = path
+	return config, nil
+}
+
+const redacted = "[redacted]"
+
+// Redacted returns a copy of config that is safe to show: passwords, secrets,
+// DSNs and the headers set on proxied requests are replaced, when set.
+func (config *Config) Redacted() *Config {
+	copied := *config
+	redact(&copied.Database.DSN)
+	redact(&copied.Redis.Password)
+	redact(&copied.Sentry.DSN)
+	redact(&copied.Debug.Password)
+	redact(&copied.Admin.Password)
+	redact(&copied.Tenancy.JWTSecret)
+	redact(&copied.Captcha.Secret)
+	redact(&copied.Payments.StripeSecretKey)
+	redact(&copied.SMS.TwilioAuthToken)
+	redact(&copied.SMS.VonageAPISecret)
+	redact(&copied.Mail.Password)
+
+	copied.OAuth = make(map[string]OAuthProviderConfig, len(config.OAuth))
+	for name, provider := range config.OAuth {
+		redact(&provider.ClientSecret)
+		copied.OAuth[name] = provider
+	}
+	copied.Webhooks.Receivers = make(map[string]WebhookReceiverConfig, len(config.Webhooks.Receivers))
+	for name, receiver := range config.Webhooks.Receivers {
+		redact(&receiver.Secret)
+		copied.Webhooks.Receivers[name] = receiver
+	}
+	copied.Cookies.Keys = make([]string, len(config.Cookies.Keys))
+	for i := range copied.Cookies.Keys {
+		copied.Cookies.Keys[i] = redacted
+	}
+	copied.Proxy = make([]ProxyRouteConfig, len(config.Proxy))
+	for i, route := range config.Proxy {
+		headers := make(map[string]string, len(route.RequestHeaders.Set))
+		for name := range route.RequestHeaders.Set {
+			headers[name] = redacted
+		}
+		route.RequestHeaders.Set = headers
+		copied.Proxy[i] = route
+	}
+	return &copied
+}
+
+func redact(value *string) {
+	if *value != "" {
+		*value = redacted
+	}
+}
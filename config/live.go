@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// reloadable are the sections of Config, by YAML name, that take effect
+// without a restart because the code reading them goes through Live on every
+// use.
+var reloadable = map[string]bool{
+	"log":        true,
+	"rate_limit": true,
+	"features":   true,
+	"cors":       true,
+	"quotas":     true,
+}
+
+// Live is the config of the running process. Its reloadable sections can be
+// replaced while the app serves requests, so it is read with Get each time
+// rather than kept.
+type Live struct {
+	current     atomic.Pointer[Config]
+	mutex       sync.Mutex
+	subscribers []func(previous, current *Config)
+}
+
+func NewLive(config *Config) *Live {
+	live := &Live{}
+	live.current.Store(config)
+	return live
+}
+
+func (live *Live) Get() *Config {
+	return live.current.Load()
+}
+
+// Subscribe calls changed after every successful Update, with the config
+// before and after it.
+func (live *Live) Subscribe(changed func(previous, current *Config)) {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+	live.subscribers = append(live.subscribers, changed)
+}
+
+// Update takes the reloadable sections of next and keeps the others as they
+// are. It returns the YAML names of the sections that differ in next but need
+// a restart to change, which are left out.
+func (live *Live) Update(next *Config) []string {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+
+	previous := live.current.Load()
+	merged := *previous
+	var restart []string
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	nextValue := reflect.ValueOf(next).Elem()
+	for i := 0; i < mergedValue.NumField(); i++ {
+		name, _, _ := strings.Cut(mergedValue.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "-" || reflect.DeepEqual(mergedValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+		if reloadable[name] {
+			mergedValue.Field(i).Set(nextValue.Field(i))
+		} else {
+			restart = append(restart, name)
+		}
+	}
+
+	live.current.Store(&merged)
+	for _, changed := range live.subscribers {
+		changed(previous, &merged)
+	}
+	return restart
+}
+
+// FeatureEnabled reports whether the feature flag name is on.
+func (config *Config) FeatureEnabled(name string) bool {
+	return config.Features[name]
+}
+
+// RateLimitFor is the rate limit of the requests of a tenant: its own, when
+// it has one, or RateLimit.
+func (config *Config) RateLimitFor(tenant string) RateLimitConfig {
+	if override := config.Tenancy.Tenants[tenant].RateLimit; override != nil {
+		return *override
+	}
+	return config.RateLimit
+}
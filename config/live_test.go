@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLiveUpdate(t *testing.T) {
+	live := NewLive(Default())
+	var changes int
+	live.Subscribe(func(previous, current *Config) {
+		assert.Equal(t, 0, previous.RateLimit.Max)
+		assert.Equal(t, 10, current.RateLimit.Max)
+		changes++
+	})
+
+	next := Default()
+	next.RateLimit.Max = 10
+	next.Features["beta"] = true
+	next.Server.Address = "localhost:9090"
+	next.Database.DSN = "postgres://localhost/app"
+
+	restart := live.Update(next)
+	assert.Equal(t, []string{"server", "database"}, restart)
+	assert.Equal(t, 1, changes)
+	assert.Equal(t, 10, live.Get().RateLimit.Max)
+	assert.True(t, live.Get().FeatureEnabled("beta"))
+	assert.Equal(t, "localhost:8080", live.Get().Server.Address)
+	assert.Equal(t, "", live.Get().Database.DSN)
+}
+
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(p []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.Write(p)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.String()
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("rate_limit:\n  max: 5\n"), 0644))
+	loaded, err := Load(path)
+	assert.Nil(t, err)
+	live := NewLive(loaded)
+
+	var logs syncBuffer
+	watcher, err := Watch(path, live, slog.New(slog.NewTextHandler(&logs, nil)))
+	assert.Nil(t, err)
+	defer watcher.Close()
+
+	assert.Nil(t, os.WriteFile(path, []byte("rate_limit:\n  max: 20\nserver:\n  address: localhost:9090\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return live.Get().RateLimit.Max == 20
+	}, time.Second*2, time.Millisecond*10)
+	assert.Equal(t, "localhost:8080", live.Get().Server.Address)
+	assert.Eventually(t, func() bool {
+		return bytes.Contains([]byte(logs.String()), []byte(`need a restart and were ignored" path=`+path+" sections=server"))
+	}, time.Second*2, time.Millisecond*10)
+
+	assert.Nil(t, os.WriteFile(path, []byte("rate_limit: [broken"), 0644))
+	assert.Eventually(t, func() bool {
+		return bytes.Contains([]byte(logs.String()), []byte("config not reloaded"))
+	}, time.Second*2, time.Millisecond*10)
+	assert.Equal(t, 20, live.Get().RateLimit.Max)
+}
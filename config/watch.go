@@ -0,0 +1,84 @@
+package config
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// settleDelay is how long the config file must stay unchanged before it is
+// loaded again.
+const settleDelay = time.Millisecond * 100
+
+// Watcher loads the config file again whenever it changes and updates a Live
+// config with it.
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// Watch reloads path into live on every change. The directory of path is
+// watched rather than the file, since editors and config management usually
+// replace the file instead of writing to it. Files that fail to load and
+// changes needing a restart are logged to logger and otherwise ignored.
+func Watch(path string, live *Live, logger *slog.Logger) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	err = fsWatcher.Add(filepath.Dir(path))
+	if err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	watcher := &Watcher{watcher: fsWatcher, done: make(chan struct{})}
+	go watcher.run(path, live, logger)
+	return watcher, nil
+}
+
+func (watcher *Watcher) run(path string, live *Live, logger *slog.Logger) {
+	defer close(watcher.done)
+	// Writing a file takes several events, after the first of which it may
+	// still be empty, so the file is loaded once they stop.
+	settle := time.NewTimer(0)
+	<-settle.C
+	defer settle.Stop()
+	for {
+		select {
+		case change, ok := <-watcher.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(change.Name) == filepath.Clean(path) && change.Has(fsnotify.Write|fsnotify.Create) {
+				settle.Reset(settleDelay)
+			}
+		case <-settle.C:
+			next, err := Load(path)
+			if err != nil {
+				logger.Error("config not reloaded", "path", path, "error", err)
+				continue
+			}
+			restart := live.Update(next)
+			if len(restart) > 0 {
+				logger.Warn("config partially reloaded: changes to these sections need a restart and were ignored",
+					"path", path, "sections", strings.Join(restart, ", "))
+			} else {
+				logger.Info("config reloaded", "path", path)
+			}
+		case err, ok := <-watcher.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("watching config", "path", path, "error", err)
+		}
+	}
+}
+
+func (watcher *Watcher) Close() error {
+	err := watcher.watcher.Close()
+	<-watcher.done
+	return err
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/telemetry"
+	"sync/atomic"
+	"time"
+)
+
+var logger = telemetry.Logger("database")
+
+// replicaLagQuery is how far behind the primary a replica is, in seconds; a
+// primary isn't behind.
+const replicaLagQuery = "SELECT CASE WHEN pg_is_in_recovery() THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) ELSE 0 END"
+
+// Cluster is the primary database, which takes the writes, and its read
+// replicas. The reads of a request go to a healthy replica until the request
+// writes, and to the primary from then on, so it reads its own writes; the
+// reads outside a request, of background jobs, always go to the primary.
+type Cluster struct {
+	config   config.DatabaseConfig
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+func NewCluster(config config.DatabaseConfig, primary *sql.DB, replicas ...*sql.DB) *Cluster {
+	cluster := &Cluster{config: config, primary: primary}
+	for _, db := range replicas {
+		cluster.replicas = append(cluster.replicas, &replica{db: db})
+	}
+	return cluster
+}
+
+// OpenCluster opens the primary and the replicas of config. The primary must
+// be reachable; a replica that isn't is left out until it passes a health
+// check.
+func OpenCluster(config config.DatabaseConfig) (*Cluster, error) {
+	primary, err := Open(config)
+	if err != nil {
+		return nil, err
+	}
+	var replicas []*sql.DB
+	for _, dsn := range config.Replicas {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			primary.Close()
+			for _, replica := range replicas {
+				replica.Close()
+			}
+			return nil, err
+		}
+		db.SetMaxOpenConns(config.MaxOpenConns)
+		db.SetMaxIdleConns(config.MaxIdleConns)
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+		replicas = append(replicas, db)
+	}
+
+	cluster := NewCluster(config, primary, replicas...)
+	cluster.CheckReplicas(context.Background())
+	return cluster, nil
+}
+
+// Primary is the database of the writes and the transactions.
+func (cluster *Cluster) Primary() *sql.DB {
+	return cluster.primary
+}
+
+// Reader is the database to read from in ctx: the next healthy replica in
+// turn, or the primary when ctx is not a request that read from replicas,
+// when the request wrote already or when no replica is healthy.
+func (cluster *Cluster) Reader(ctx context.Context) *sql.DB {
+	state, ok := ctx.Value(stickinessKey{}).(*stickiness)
+	if !ok || state.sticky || state.written.Load() || len(cluster.replicas) == 0 {
+		return cluster.primary
+	}
+	start := cluster.next.Add(1)
+	for i := range cluster.replicas {
+		replica := cluster.replicas[(start+uint64(i))%uint64(len(cluster.replicas))]
+		if replica.healthy.Load() {
+			return replica.db
+		}
+	}
+	return cluster.primary
+}
+
+// HasReplicas reports whether the cluster has read replicas.
+func (cluster *Cluster) HasReplicas() bool {
+	return len(cluster.replicas) > 0
+}
+
+// CheckReplicas marks the replicas that answer and are at most MaxReplicaLag
+// behind healthy, and the others unhealthy.
+func (cluster *Cluster) CheckReplicas(ctx context.Context) {
+	for i, replica := range cluster.replicas {
+		err := cluster.checkReplica(ctx, replica.db)
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			logger.Info("replica is healthy", "replica", i)
+		} else {
+			logger.Warn("replica is unhealthy, reading from the others", "replica", i, "error", err)
+		}
+	}
+}
+
+func (cluster *Cluster) checkReplica(ctx context.Context, db *sql.DB) error {
+	timeout := cluster.config.ReplicaHealthInterval
+	if timeout <= 0 {
+		timeout = time.Second * 5
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lag float64
+	err := db.QueryRowContext(ctx, replicaLagQuery).Scan(&lag)
+	if err != nil {
+		return err
+	}
+	if cluster.config.MaxReplicaLag > 0 && lag > cluster.config.MaxReplicaLag.Seconds() {
+		return errors.New("replica is " + time.Duration(lag*float64(time.Second)).Round(time.Millisecond).String() + " behind")
+	}
+	return nil
+}
+
+// Run checks the health of the replicas every ReplicaHealthInterval until
+// ctx is done.
+func (cluster *Cluster) Run(ctx context.Context) {
+	if len(cluster.replicas) == 0 || cluster.config.ReplicaHealthInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cluster.config.ReplicaHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cluster.CheckReplicas(ctx)
+		}
+	}
+}
+
+func (cluster *Cluster) Close() error {
+	errs := []error{cluster.primary.Close()}
+	for _, replica := range cluster.replicas {
+		errs = append(errs, replica.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type stickinessKey struct{}
+
+// stickiness records whether a request reads from the primary from the
+// start, and whether it wrote to it.
+type stickiness struct {
+	sticky  bool
+	written atomic.Bool
+}
+
+// WithReplicas lets the reads in ctx go to the replicas until a write, or
+// none of them when sticky, such as for a client that wrote a moment ago.
+func WithReplicas(ctx context.Context, sticky bool) context.Context {
+	return context.WithValue(ctx, stickinessKey{}, &stickiness{sticky: sticky})
+}
+
+// MarkWritten sends the reads in ctx that follow to the primary.
+func MarkWritten(ctx context.Context) {
+	if state, ok := ctx.Value(stickinessKey{}).(*stickiness); ok {
+		state.written.Store(true)
+	}
+}
+
+// Written reports whether ctx wrote to the primary.
+func Written(ctx context.Context) bool {
+	state, ok := ctx.Value(stickinessKey{}).(*stickiness)
+	return ok && state.written.Load()
+}
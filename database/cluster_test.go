@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"testing"
+	"time"
+)
+
+func newTestCluster(t *testing.T, replicas int) *Cluster {
+	// Nothing listens on the port, and sql.Open doesn't connect.
+	open := func() *sql.DB {
+		db, err := sql.Open("pgx", "postgres://user@127.0.0.1:1/app?connect_timeout=1")
+		assert.Nil(t, err)
+		return db
+	}
+	var dbs []*sql.DB
+	for i := 0; i < replicas; i++ {
+		dbs = append(dbs, open())
+	}
+	cluster := NewCluster(config.DatabaseConfig{ReplicaHealthInterval: time.Second}, open(), dbs...)
+	t.Cleanup(func() { cluster.Close() })
+	return cluster
+}
+
+func TestClusterReader(t *testing.T) {
+	cluster := newTestCluster(t, 2)
+	for _, replica := range cluster.replicas {
+		replica.healthy.Store(true)
+	}
+	first, second := cluster.replicas[0].db, cluster.replicas[1].db
+
+	// Outside a request the reads go to the primary.
+	assert.Same(t, cluster.Primary(), cluster.Reader(context.Background()))
+
+	ctx := WithReplicas(context.Background(), false)
+	reads := []*sql.DB{cluster.Reader(ctx), cluster.Reader(ctx)}
+	assert.ElementsMatch(t, []*sql.DB{first, second}, reads)
+
+	cluster.replicas[0].healthy.Store(false)
+	assert.Same(t, second, cluster.Reader(ctx))
+	assert.Same(t, second, cluster.Reader(ctx))
+	cluster.replicas[1].healthy.Store(false)
+	assert.Same(t, cluster.Primary(), cluster.Reader(ctx))
+
+	// After a write the request reads its writes from the primary.
+	cluster.replicas[1].healthy.Store(true)
+	assert.False(t, Written(ctx))
+	MarkWritten(ctx)
+	assert.True(t, Written(ctx))
+	assert.Same(t, cluster.Primary(), cluster.Reader(ctx))
+
+	assert.Same(t, cluster.Primary(), cluster.Reader(WithReplicas(context.Background(), true)))
+}
+
+func TestClusterCheckReplicas(t *testing.T) {
+	cluster := newTestCluster(t, 1)
+	cluster.replicas[0].healthy.Store(true)
+	cluster.CheckReplicas(context.Background())
+	assert.False(t, cluster.replicas[0].healthy.Load())
+	assert.Same(t, cluster.Primary(), cluster.Reader(WithReplicas(context.Background(), false)))
+
+	assert.False(t, newTestCluster(t, 0).HasReplicas())
+	assert.True(t, cluster.HasReplicas())
+}
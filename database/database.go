@@ -0,0 +1,25 @@
+package database
+
+import (
+	"database/sql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"golang-fiber-web/config"
+)
+
+func Open(config config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("pgx", config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
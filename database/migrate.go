@@ -0,0 +1,391 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum identifies the up script, to tell whether it changed since it was
+// applied.
+func (migration Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(migration.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs, ordered
+// by version.
+func LoadMigrations(files fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(files, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, path := range paths {
+		file := strings.TrimPrefix(path, "migrations/")
+		prefix, rest, ok := strings.Cut(file, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", file)
+		}
+
+		var name, direction string
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			name, direction = strings.TrimSuffix(rest, ".up.sql"), "up"
+		case strings.HasSuffix(rest, ".down.sql"):
+			name, direction = strings.TrimSuffix(rest, ".down.sql"), "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name %s", file)
+		}
+
+		content, err := fs.ReadFile(files, path)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the versions applied. It creates schema_migrations, or adds the
+// checksum column to it, first; the other methods only read it.
+func (migrator *Migrator) Up() ([]Migration, error) {
+	err := migrator.prepare()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range migrator.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err = migrator.run(migration.Up, "INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)", migration.Version, migration.Checksum())
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func (migrator *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrator.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := migrator.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+		err = migrator.run(migration.Down, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// appliedMigration is a row of schema_migrations. Checksum is empty for the
+// migrations applied before it was recorded.
+type appliedMigration struct {
+	AppliedAt time.Time
+	Checksum  string
+}
+
+// prepare creates schema_migrations, with the checksum column the tables of
+// older versions of the app lack.
+func (migrator *Migrator) prepare() error {
+	_, err := migrator.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    BIGINT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.db.Exec("ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT ''")
+	return err
+}
+
+// applied reads schema_migrations without changing it: no migration is
+// applied while it doesn't exist, and the checksums are empty while it has
+// no checksum column.
+func (migrator *Migrator) applied() (map[int]appliedMigration, error) {
+	columns, err := migrator.db.Query("SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'schema_migrations'")
+	if err != nil {
+		return nil, err
+	}
+	defer columns.Close()
+	exists, checksum := false, "''"
+	for columns.Next() {
+		var column string
+		err = columns.Scan(&column)
+		if err != nil {
+			return nil, err
+		}
+		exists = true
+		if column == "checksum" {
+			checksum = "checksum"
+		}
+	}
+	if err = columns.Err(); err != nil {
+		return nil, err
+	}
+
+	applied := map[int]appliedMigration{}
+	if !exists {
+		return applied, nil
+	}
+	rows, err := migrator.db.Query("SELECT version, applied_at, " + checksum + " FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var migration appliedMigration
+		err = rows.Scan(&version, &migration.AppliedAt, &migration.Checksum)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = migration
+	}
+	return applied, rows.Err()
+}
+
+func (migrator *Migrator) run(script, record string, args ...any) error {
+	tx, err := migrator.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(script)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(record, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrationStatus is a migration and when it was applied, if it was. A
+// version applied to the database that has no migration here is Unknown.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+// Status lists the migrations and the versions applied, by version.
+func (migrator *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(migrator.migrations, applied), nil
+}
+
+func migrationStatus(migrations []Migration, applied map[int]appliedMigration) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	known := map[int]bool{}
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range applied {
+		if !known[version] {
+			statuses = append(statuses, MigrationStatus{Migration: Migration{Version: version}, AppliedAt: &row.AppliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
+
+// Pending lists the migrations not applied yet.
+func (migrator *Migrator) Pending() ([]Migration, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range migrator.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Verify looks for drift between the migrations and the database: versions
+// applied that have no migration, migrations changed since they were applied
+// or pending behind newer ones, and tables missing from the schema or not
+// created by any migration. It returns what it found.
+func (migrator *Migrator) Verify() ([]string, error) {
+	applied, err := migrator.applied()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := migrator.db.Query("SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		err = rows.Scan(&table)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return verifyMigrations(migrator.migrations, applied, tables), nil
+}
+
+func verifyMigrations(migrations []Migration, applied map[int]appliedMigration, tables []string) []string {
+	var problems []string
+	latest := 0
+	for version := range applied {
+		latest = max(latest, version)
+	}
+
+	var appliedMigrations []Migration
+	for _, status := range migrationStatus(migrations, applied) {
+		name := fmt.Sprintf("%04d_%s", status.Version, status.Name)
+		switch {
+		case status.Unknown:
+			problems = append(problems, fmt.Sprintf("version %d is applied but has no migration", status.Version))
+		case status.AppliedAt == nil && status.Version < latest:
+			problems = append(problems, fmt.Sprintf("migration %s is pending but newer ones are applied", name))
+		case status.AppliedAt != nil:
+			checksum := applied[status.Version].Checksum
+			if checksum != "" && checksum != status.Checksum() {
+				problems = append(problems, fmt.Sprintf("migration %s changed since it was applied", name))
+			}
+			appliedMigrations = append(appliedMigrations, status.Migration)
+		}
+	}
+
+	expected := migrationTables(appliedMigrations)
+	for _, table := range slices.Sorted(maps.Keys(expected)) {
+		if !slices.Contains(tables, table) {
+			problems = append(problems, fmt.Sprintf("table %s of migration %s is missing", table, expected[table]))
+		}
+	}
+	for _, table := range slices.Sorted(slices.Values(tables)) {
+		if _, ok := expected[table]; !ok && table != "schema_migrations" {
+			problems = append(problems, fmt.Sprintf("table %s is not created by any migration", table))
+		}
+	}
+	return problems
+}
+
+var tableStatement = regexp.MustCompile(`(?i)\b(?:(CREATE)\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?|(DROP)\s+TABLE\s+(?:IF\s+EXISTS\s+)?|ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?)(\w+)(?:\s+(RENAME)\s+TO\s+(\w+))?`)
+
+// migrationTables returns the tables the up scripts of migrations leave, in
+// order, with the migration that created each.
+func migrationTables(migrations []Migration) map[string]string {
+	tables := map[string]string{}
+	for _, migration := range migrations {
+		name := fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+		for _, match := range tableStatement.FindAllStringSubmatch(migration.Up, -1) {
+			table := strings.ToLower(match[3])
+			switch {
+			case match[1] != "":
+				tables[table] = name
+			case match[2] != "":
+				delete(tables, table)
+			case match[4] != "":
+				tables[strings.ToLower(match[5])] = tables[table]
+				delete(tables, table)
+			}
+		}
+	}
+	return tables
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/0002_add_roles.up.sql":      {Data: []byte("CREATE TABLE roles ()")},
+		"migrations/0002_add_roles.down.sql":    {Data: []byte("DROP TABLE roles")},
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users ()")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+	}
+
+	migrations, err := LoadMigrations(files)
+	assert.Nil(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE users", migrations[0].Down)
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.Equal(t, "CREATE TABLE roles ()", migrations[1].Up)
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	_, err := LoadMigrations(fstest.MapFS{"migrations/users.up.sql": {Data: []byte("")}})
+	assert.NotNil(t, err)
+
+	_, err = LoadMigrations(fstest.MapFS{"migrations/0001_users.down.sql": {Data: []byte("DROP TABLE users")}})
+	assert.NotNil(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFiles)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, migrations)
+}
+
+func TestMigrationTables(t *testing.T) {
+	tables := migrationTables([]Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id UUID);\nCREATE TABLE IF NOT EXISTS tags (name TEXT);"},
+		{Version: 2, Name: "rename_tags", Up: "ALTER TABLE users ADD COLUMN name TEXT;\nALTER TABLE tags RENAME TO labels;"},
+		{Version: 3, Name: "drop_labels", Up: "DROP TABLE IF EXISTS labels;\ncreate table roles (name TEXT REFERENCES users (id));"},
+	})
+	assert.Equal(t, map[string]string{"users": "0001_create_users", "roles": "0003_drop_labels"}, tables)
+
+	migrations, err := LoadMigrations(migrationFiles)
+	assert.Nil(t, err)
+	assert.Contains(t, migrationTables(migrations), "users")
+}
+
+func TestMigrationStatus(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "create_users"}, {Version: 2, Name: "create_roles"}}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	statuses := migrationStatus(migrations, map[int]appliedMigration{1: {AppliedAt: at}, 7: {AppliedAt: at}})
+	assert.Len(t, statuses, 3)
+	assert.Equal(t, &at, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.Equal(t, 7, statuses[2].Version)
+	assert.True(t, statuses[2].Unknown)
+}
+
+func TestVerifyMigrations(t *testing.T) {
+	users := Migration{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id UUID);"}
+	roles := Migration{Version: 2, Name: "create_roles", Up: "CREATE TABLE roles (name TEXT);"}
+	orders := Migration{Version: 3, Name: "create_orders", Up: "CREATE TABLE orders (id UUID);"}
+	migrations := []Migration{users, roles, orders}
+
+	applied := map[int]appliedMigration{
+		1: {Checksum: users.Checksum()},
+		2: {Checksum: roles.Checksum()},
+		3: {},
+	}
+	assert.Empty(t, verifyMigrations(migrations, applied, []string{"users", "roles", "orders", "schema_migrations"}))
+
+	applied = map[int]appliedMigration{
+		1: {Checksum: "0000"},
+		3: {Checksum: orders.Checksum()},
+		4: {},
+	}
+	assert.Equal(t, []string{
+		"migration 0001_create_users changed since it was applied",
+		"migration 0002_create_roles is pending but newer ones are applied",
+		"version 4 is applied but has no migration",
+		"table orders of migration 0003_create_orders is missing",
+		"table sessions is not created by any migration",
+	}, verifyMigrations(migrations, applied, []string{"users", "sessions"}))
+}
@@ -0,0 +1,3 @@
+DROP TABLE user_identities;
+
+DROP TABLE users;
@@ -0,0 +1,18 @@
+CREATE TABLE users
+(
+    id         UUID PRIMARY KEY,
+    username   VARCHAR(100) NOT NULL,
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    name       VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX users_email_index ON users (LOWER(email));
+
+CREATE TABLE user_identities
+(
+    provider VARCHAR(50)  NOT NULL,
+    subject  VARCHAR(255) NOT NULL,
+    user_id  UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, subject)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE roles
+(
+    name        VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE user_roles
+(
+    user_id UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role    VARCHAR(50) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE audit_events
+(
+    id          UUID PRIMARY KEY,
+    actor       VARCHAR(255) NOT NULL,
+    action      VARCHAR(100) NOT NULL,
+    resource    VARCHAR(100) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL DEFAULT '',
+    changes     JSONB        NOT NULL DEFAULT '{}',
+    ip          VARCHAR(45)  NOT NULL DEFAULT '',
+    request_id  VARCHAR(100) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_events_created_at_index ON audit_events (created_at);
+CREATE INDEX audit_events_resource_index ON audit_events (resource, resource_id);
@@ -0,0 +1,10 @@
+CREATE TABLE outbox_messages
+(
+    id           UUID PRIMARY KEY,
+    event        VARCHAR(100) NOT NULL,
+    payload      JSONB        NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_messages_unpublished_index ON outbox_messages (created_at) WHERE published_at IS NULL;
@@ -0,0 +1,26 @@
+CREATE TABLE webhooks
+(
+    id         UUID PRIMARY KEY,
+    url        TEXT         NOT NULL,
+    secret     VARCHAR(255) NOT NULL,
+    events     JSONB        NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id              UUID PRIMARY KEY,
+    webhook_id      UUID         NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event           VARCHAR(100) NOT NULL,
+    payload         JSONB        NOT NULL,
+    status          VARCHAR(20)  NOT NULL,
+    attempts        INTEGER      NOT NULL DEFAULT 0,
+    status_code     INTEGER      NOT NULL DEFAULT 0,
+    error           TEXT         NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ  NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_index ON webhook_deliveries (webhook_id, created_at);
+CREATE INDEX webhook_deliveries_pending_index ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,8 @@
+CREATE TABLE received_webhooks
+(
+    provider    VARCHAR(50)  NOT NULL,
+    event_id    VARCHAR(255) NOT NULL,
+    type        VARCHAR(255) NOT NULL DEFAULT '',
+    received_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, event_id)
+);
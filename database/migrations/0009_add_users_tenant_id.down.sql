@@ -0,0 +1,4 @@
+DROP INDEX users_tenant_id_index;
+
+ALTER TABLE users
+    DROP COLUMN tenant_id;
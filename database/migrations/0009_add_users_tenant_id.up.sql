@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX users_tenant_id_index ON users (tenant_id);
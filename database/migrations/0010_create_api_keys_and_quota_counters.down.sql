@@ -0,0 +1,2 @@
+DROP TABLE quota_counters;
+DROP TABLE api_keys;
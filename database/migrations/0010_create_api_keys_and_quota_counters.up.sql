@@ -0,0 +1,20 @@
+CREATE TABLE api_keys
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name       VARCHAR(100) NOT NULL,
+    prefix     VARCHAR(20)  NOT NULL,
+    hash       CHAR(64)     NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX api_keys_user_id_index ON api_keys (user_id);
+
+CREATE TABLE quota_counters
+(
+    subject      VARCHAR(100) NOT NULL,
+    period       VARCHAR(20)  NOT NULL,
+    period_start TIMESTAMPTZ  NOT NULL,
+    count        INTEGER      NOT NULL DEFAULT 0,
+    PRIMARY KEY (subject, period, period_start)
+);
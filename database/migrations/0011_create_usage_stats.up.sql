@@ -0,0 +1,15 @@
+CREATE TABLE usage_stats
+(
+    bucket           TIMESTAMPTZ  NOT NULL,
+    method           VARCHAR(10)  NOT NULL,
+    route            VARCHAR(255) NOT NULL,
+    consumer         VARCHAR(100) NOT NULL,
+    requests         INTEGER      NOT NULL DEFAULT 0,
+    status_2xx       INTEGER      NOT NULL DEFAULT 0,
+    status_3xx       INTEGER      NOT NULL DEFAULT 0,
+    status_4xx       INTEGER      NOT NULL DEFAULT 0,
+    status_5xx       INTEGER      NOT NULL DEFAULT 0,
+    latency_total_us BIGINT       NOT NULL DEFAULT 0,
+    latency_max_us   BIGINT       NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket, method, route, consumer)
+);
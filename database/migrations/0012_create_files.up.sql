@@ -0,0 +1,12 @@
+CREATE TABLE files
+(
+    name         VARCHAR(255) PRIMARY KEY,
+    size         BIGINT       NOT NULL,
+    status       VARCHAR(20)  NOT NULL,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    checksum     VARCHAR(64)  NOT NULL DEFAULT '',
+    thumbnail    VARCHAR(255) NOT NULL DEFAULT '',
+    steps        JSONB        NOT NULL DEFAULT '[]',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+DROP INDEX files_owner_id_index;
+
+ALTER TABLE files
+    DROP CONSTRAINT files_pkey,
+    ADD PRIMARY KEY (name),
+    DROP COLUMN storage_key,
+    DROP COLUMN owner_id,
+    DROP COLUMN id;
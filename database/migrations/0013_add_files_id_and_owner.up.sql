@@ -0,0 +1,14 @@
+ALTER TABLE files
+    ADD COLUMN id          UUID,
+    ADD COLUMN owner_id    VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN storage_key TEXT         NOT NULL DEFAULT '';
+
+-- The files uploaded before are stored under their name.
+UPDATE files SET id = gen_random_uuid(), storage_key = name;
+
+ALTER TABLE files
+    DROP CONSTRAINT files_pkey,
+    ALTER COLUMN id SET NOT NULL,
+    ADD PRIMARY KEY (id);
+
+CREATE INDEX files_owner_id_index ON files (owner_id, created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE signing_keys
+(
+    id          UUID PRIMARY KEY,
+    algorithm   VARCHAR(10) NOT NULL,
+    private_key BYTEA       NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX signing_keys_expires_at_index ON signing_keys (expires_at);
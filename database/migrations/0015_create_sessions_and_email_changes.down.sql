@@ -0,0 +1,5 @@
+DROP TABLE email_changes;
+DROP TABLE sessions;
+
+ALTER TABLE users
+    DROP COLUMN password_hash;
@@ -0,0 +1,23 @@
+ALTER TABLE users
+    ADD COLUMN password_hash VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE TABLE sessions
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_agent VARCHAR(500) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX sessions_user_id_index ON sessions (user_id);
+CREATE INDEX sessions_expires_at_index ON sessions (expires_at);
+
+CREATE TABLE email_changes
+(
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    email      VARCHAR(255) NOT NULL,
+    token_hash CHAR(64)     NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ  NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE orders
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status     VARCHAR(20)   NOT NULL,
+    items      JSONB         NOT NULL DEFAULT '[]',
+    total      BIGINT        NOT NULL DEFAULT 0,
+    currency   CHAR(3)       NOT NULL,
+    notes      VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX orders_user_id_index ON orders (user_id, created_at);
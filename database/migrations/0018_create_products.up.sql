@@ -0,0 +1,15 @@
+CREATE TABLE products
+(
+    id          UUID PRIMARY KEY,
+    name        VARCHAR(255)  NOT NULL,
+    description VARCHAR(5000) NOT NULL DEFAULT '',
+    category    VARCHAR(100)  NOT NULL,
+    price       BIGINT        NOT NULL DEFAULT 0,
+    currency    CHAR(3)       NOT NULL,
+    stock       INTEGER       NOT NULL DEFAULT 0,
+    images      JSONB         NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX products_category_index ON products (category);
@@ -0,0 +1,3 @@
+ALTER TABLE orders
+    DROP COLUMN payment_status,
+    DROP COLUMN payment_id;
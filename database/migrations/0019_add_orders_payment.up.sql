@@ -0,0 +1,3 @@
+ALTER TABLE orders
+    ADD COLUMN payment_status VARCHAR(20)  NOT NULL DEFAULT 'unpaid',
+    ADD COLUMN payment_id     VARCHAR(255) NOT NULL DEFAULT '';
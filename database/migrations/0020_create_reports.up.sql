@@ -0,0 +1,13 @@
+CREATE TABLE reports
+(
+    id           UUID PRIMARY KEY,
+    user_id      UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind         VARCHAR(20)  NOT NULL,
+    status       VARCHAR(20)  NOT NULL,
+    period_from  TIMESTAMPTZ,
+    period_to    TIMESTAMPTZ,
+    size         BIGINT       NOT NULL DEFAULT 0,
+    error        VARCHAR(500) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
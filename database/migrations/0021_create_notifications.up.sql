@@ -0,0 +1,14 @@
+CREATE TABLE notifications
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind       VARCHAR(100)  NOT NULL,
+    title      VARCHAR(200)  NOT NULL,
+    body       VARCHAR(1000) NOT NULL DEFAULT '',
+    link       VARCHAR(500)  NOT NULL DEFAULT '',
+    read_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX notifications_user_id_index ON notifications (user_id, created_at);
+CREATE INDEX notifications_unread_index ON notifications (user_id) WHERE read_at IS NULL;
@@ -0,0 +1,21 @@
+CREATE TABLE devices
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    platform   VARCHAR(10)   NOT NULL,
+    token      VARCHAR(4096) NOT NULL,
+    name       VARCHAR(100)  NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    UNIQUE (platform, token)
+);
+
+CREATE INDEX devices_user_id_index ON devices (user_id);
+
+CREATE TABLE notification_preferences
+(
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    push       BOOLEAN     NOT NULL DEFAULT TRUE,
+    muted      JSONB       NOT NULL DEFAULT '[]',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
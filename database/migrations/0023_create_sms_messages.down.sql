@@ -0,0 +1,5 @@
+ALTER TABLE notification_preferences
+    DROP COLUMN phone,
+    DROP COLUMN sms;
+
+DROP TABLE sms_messages;
@@ -0,0 +1,19 @@
+CREATE TABLE sms_messages
+(
+    id          UUID PRIMARY KEY,
+    recipient   VARCHAR(20)  NOT NULL,
+    kind        VARCHAR(100) NOT NULL,
+    body        TEXT         NOT NULL,
+    provider    VARCHAR(20)  NOT NULL,
+    provider_id VARCHAR(100) NOT NULL DEFAULT '',
+    status      VARCHAR(20)  NOT NULL,
+    error       VARCHAR(500) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX sms_messages_provider_id_index ON sms_messages (provider, provider_id);
+
+ALTER TABLE notification_preferences
+    ADD COLUMN sms   BOOLEAN     NOT NULL DEFAULT FALSE,
+    ADD COLUMN phone VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+CREATE TABLE activities
+(
+    id          UUID PRIMARY KEY,
+    user_id     UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind        VARCHAR(100) NOT NULL,
+    resource    VARCHAR(100) NOT NULL,
+    resource_id VARCHAR(100) NOT NULL,
+    summary     VARCHAR(500) NOT NULL,
+    link        VARCHAR(500) NOT NULL DEFAULT '',
+    public      BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX activities_user_id_index ON activities (user_id, created_at);
@@ -0,0 +1,20 @@
+CREATE TABLE comments
+(
+    id          UUID PRIMARY KEY,
+    resource    VARCHAR(100)  NOT NULL,
+    resource_id VARCHAR(100)  NOT NULL,
+    user_id     UUID          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    parent_id   UUID REFERENCES comments (id) ON DELETE CASCADE,
+    depth       INT           NOT NULL DEFAULT 0,
+    body        VARCHAR(2000) NOT NULL,
+    rating      INT           NOT NULL DEFAULT 0 CHECK (rating BETWEEN 0 AND 5),
+    status      VARCHAR(20)   NOT NULL DEFAULT 'visible',
+    flags       INT           NOT NULL DEFAULT 0,
+    flagged_by  JSONB         NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX comments_resource_index ON comments (resource, resource_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX comments_parent_id_index ON comments (parent_id);
+CREATE UNIQUE INDEX comments_rating_index ON comments (resource, resource_id, user_id) WHERE rating > 0;
@@ -0,0 +1,49 @@
+DROP INDEX comments_tenant_id_index;
+
+ALTER TABLE comments
+    DROP COLUMN tenant_id;
+
+DROP INDEX notifications_tenant_id_index;
+
+ALTER TABLE notifications
+    DROP COLUMN tenant_id;
+
+DROP INDEX products_tenant_id_index;
+
+ALTER TABLE products
+    DROP COLUMN tenant_id;
+
+DROP INDEX files_tenant_id_index;
+
+ALTER TABLE files
+    DROP COLUMN tenant_id;
+
+DROP INDEX orders_tenant_id_index;
+
+ALTER TABLE orders
+    DROP COLUMN tenant_id;
+
+DROP INDEX sessions_tenant_id_index;
+
+ALTER TABLE sessions
+    DROP COLUMN tenant_id;
+
+DROP INDEX outbox_messages_tenant_id_index;
+
+ALTER TABLE outbox_messages
+    DROP COLUMN tenant_id;
+
+DROP INDEX webhook_deliveries_tenant_id_index;
+
+ALTER TABLE webhook_deliveries
+    DROP COLUMN tenant_id;
+
+DROP INDEX webhooks_tenant_id_index;
+
+ALTER TABLE webhooks
+    DROP COLUMN tenant_id;
+
+DROP INDEX audit_events_tenant_id_index;
+
+ALTER TABLE audit_events
+    DROP COLUMN tenant_id;
@@ -0,0 +1,49 @@
+ALTER TABLE audit_events
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX audit_events_tenant_id_index ON audit_events (tenant_id);
+
+ALTER TABLE webhooks
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX webhooks_tenant_id_index ON webhooks (tenant_id);
+
+ALTER TABLE webhook_deliveries
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX webhook_deliveries_tenant_id_index ON webhook_deliveries (tenant_id);
+
+ALTER TABLE outbox_messages
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX outbox_messages_tenant_id_index ON outbox_messages (tenant_id);
+
+ALTER TABLE sessions
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX sessions_tenant_id_index ON sessions (tenant_id);
+
+ALTER TABLE orders
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX orders_tenant_id_index ON orders (tenant_id);
+
+ALTER TABLE files
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX files_tenant_id_index ON files (tenant_id);
+
+ALTER TABLE products
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX products_tenant_id_index ON products (tenant_id);
+
+ALTER TABLE notifications
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX notifications_tenant_id_index ON notifications (tenant_id);
+
+ALTER TABLE comments
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX comments_tenant_id_index ON comments (tenant_id);
@@ -0,0 +1,1500 @@
+package di
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/redis/go-redis/v9"
+	"golang-fiber-web/accesslog"
+	"golang-fiber-web/analytics"
+	"golang-fiber-web/broker"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/database"
+	"golang-fiber-web/event"
+	"golang-fiber-web/handler"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/i18n"
+	"golang-fiber-web/janitor"
+	"golang-fiber-web/jsoncodec"
+	"golang-fiber-web/mail"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/outbox"
+	"golang-fiber-web/payment"
+	"golang-fiber-web/push"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/rpc"
+	"golang-fiber-web/seed"
+	"golang-fiber-web/server"
+	"golang-fiber-web/service"
+	"golang-fiber-web/sitemap"
+	"golang-fiber-web/sms"
+	"golang-fiber-web/source"
+	"golang-fiber-web/static"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/token"
+	"golang-fiber-web/upload"
+	"golang-fiber-web/views"
+	"golang-fiber-web/web"
+	"golang-fiber-web/webhook"
+	"google.golang.org/grpc"
+	"io/fs"
+	"os"
+	"reflect"
+	"time"
+)
+
+// Container builds the application's dependencies from config on first use
+// and hands out the same instance afterwards, so the CLI commands, the server
+// and the tests share one way of wiring the app. It is meant to be used from
+// a single goroutine while the app starts.
+type Container struct {
+	config         *config.Config
+	live           *config.Live
+	configWatcher  *config.Watcher
+	db             *database.Cluster
+	redis          *redis.Client
+	repositories   *repository.Repositories
+	cacheStore     cache.Store
+	responseCache  *middleware.Cache
+	userService    service.UserService
+	authService    service.AuthService
+	sessionService service.SessionService
+	accountService service.AccountService
+	avatarService  service.AvatarService
+	auditService   service.AuditService
+	webhookService service.WebhookService
+	apiKeyService  service.APIKeyService
+	quotaService   service.QuotaService
+	fileService    service.FileService
+	orderService   service.OrderService
+	productService service.ProductService
+	paymentService service.PaymentService
+	reportService  service.ReportService
+	notifications  service.NotificationService
+	pushService    service.PushService
+	smsService     service.SMSService
+	activities     service.ActivityService
+	comments       service.CommentService
+	sitemap        *sitemap.Sitemap
+	storage        storage.Storage
+	assets         *static.Assets
+	tokenKeys      *token.Keys
+	usageRecorder  *analytics.Recorder
+	loadShedder    *middleware.LoadShedder
+	eventBus       *event.Bus
+	broker         *broker.Publisher
+	outbox         *outbox.Outbox
+	httpClient     *httpclient.Client
+	i18n           *i18n.Bundle
+	views          fiber.Views
+	viewsWatcher   *views.Watcher
+	accessLog      *accesslog.Logger
+	localStates    []LocalState
+}
+
+// LocalState is state a module keeps in the memory of its process. Under
+// Prefork every child has its own copy, which silently diverges from the
+// others.
+type LocalState struct {
+	Module string
+	State  string
+}
+
+func New(appConfig *config.Config) *Container {
+	return &Container{config: appConfig, live: config.NewLive(appConfig)}
+}
+
+// LocalStates lists the process-local state of the parts built so far.
+func (container *Container) LocalStates() []LocalState {
+	return append([]LocalState(nil), container.localStates...)
+}
+
+// CheckPrefork warns about every process-local state of the parts built so
+// far when the server preforks, and returns what it warned about. It is
+// meant to run once the app is built, in the prefork parent.
+func (container *Container) CheckPrefork() []LocalState {
+	if !container.config.Server.Prefork {
+		return nil
+	}
+	logger := telemetry.Logger("prefork")
+	for _, state := range container.localStates {
+		logger.Warn("process-local state diverges across prefork children", "module", state.Module, "state", state.State)
+	}
+	return container.LocalStates()
+}
+
+// addLocalState records state the module keeps in the memory of the
+// process, for CheckPrefork.
+func (container *Container) addLocalState(module, state string) {
+	container.localStates = append(container.localStates, LocalState{Module: module, State: state})
+}
+
+// Config is the config the container was built with. The settings that can
+// be reloaded while the app runs are read from Live instead.
+func (container *Container) Config() *config.Config {
+	return container.config
+}
+
+func (container *Container) Live() *config.Live {
+	return container.live
+}
+
+// WatchConfig reloads the config file into Live whenever it changes, and
+// applies the log settings it reloads. It does nothing when the config was not
+// loaded from a file.
+func (container *Container) WatchConfig() error {
+	if container.config.Path == "" || container.configWatcher != nil {
+		return nil
+	}
+
+	logger := telemetry.Logger("config")
+	container.live.Subscribe(func(previous, current *config.Config) {
+		if reflect.DeepEqual(previous.Log, current.Log) {
+			return
+		}
+		err := telemetry.InitLogging(current.Log)
+		if err != nil {
+			logger.Error("log settings not reloaded", "error", err)
+		}
+	})
+	watcher, err := config.Watch(container.config.Path, container.live, logger)
+	if err != nil {
+		return err
+	}
+	container.configWatcher = watcher
+	return nil
+}
+
+// Database is the primary database and its read replicas.
+func (container *Container) Database() (*database.Cluster, error) {
+	if container.db != nil {
+		return container.db, nil
+	}
+	if container.config.Database.DSN == "" {
+		return nil, errors.New("database.dsn is not configured")
+	}
+
+	db, err := database.OpenCluster(container.config.Database)
+	if err != nil {
+		return nil, err
+	}
+	container.db = db
+	return db, nil
+}
+
+// DB is the primary database.
+func (container *Container) DB() (*sql.DB, error) {
+	db, err := container.Database()
+	if err != nil {
+		return nil, err
+	}
+	return db.Primary(), nil
+}
+
+func (container *Container) Redis() (*redis.Client, error) {
+	if container.redis != nil {
+		return container.redis, nil
+	}
+
+	client, err := cache.NewRedisClient(container.config.Redis)
+	if err != nil {
+		return nil, err
+	}
+	container.redis = client
+	return client, nil
+}
+
+// Repositories uses Postgres when a DSN is configured. Otherwise it uses
+// in-memory repositories, which start empty on every run and are therefore
+// seeded here; a database is seeded once through the seed command.
+func (container *Container) Repositories() (*repository.Repositories, error) {
+	if container.repositories != nil {
+		return container.repositories, nil
+	}
+
+	if container.config.Database.DSN != "" {
+		db, err := container.Database()
+		if err != nil {
+			return nil, err
+		}
+		repositories := repository.NewPostgresRepositories(db)
+		if ttl := container.config.Cache.QueryTTL; ttl > 0 {
+			store, err := container.CacheStore()
+			if err != nil {
+				return nil, err
+			}
+			repositories.Users = repository.NewCachedUserRepository(repositories.Users, store, ttl)
+			repositories.Products = repository.NewCachedProductRepository(repositories.Products, store, ttl)
+		}
+		container.repositories = repositories
+		return repositories, nil
+	}
+
+	repositories := repository.NewMemoryRepositories()
+	container.addLocalState("repositories", "every record is kept in memory; set database.dsn to share them")
+	_, err := seed.Run(context.Background(), repositories, seed.Defaults(container.config.Seed))
+	if err != nil {
+		return nil, err
+	}
+	container.repositories = repositories
+	return repositories, nil
+}
+
+func (container *Container) CacheStore() (cache.Store, error) {
+	if container.cacheStore != nil {
+		return container.cacheStore, nil
+	}
+
+	if container.config.Cache.Backend == "redis" {
+		client, err := container.Redis()
+		if err != nil {
+			return nil, err
+		}
+		container.cacheStore = cache.NewRedisStore(client)
+	} else {
+		container.cacheStore = cache.NewMemoryStore()
+		container.addLocalState("cache", "cached responses, idempotency keys and rate limit windows are kept in memory; set cache.backend to redis to share them")
+	}
+	return container.cacheStore, nil
+}
+
+func (container *Container) ResponseCache() (*middleware.Cache, error) {
+	if container.responseCache != nil {
+		return container.responseCache, nil
+	}
+
+	store, err := container.CacheStore()
+	if err != nil {
+		return nil, err
+	}
+	container.responseCache = middleware.NewCache(store, container.config.Cache)
+	return container.responseCache, nil
+}
+
+// EventBus is the bus the services publish domain events on. The handlers
+// still invalidate the response cache themselves so the client that made a
+// change reads it back; the subscriber here covers changes made elsewhere,
+// such as over gRPC. The uploaded files go through the upload pipeline, and
+// the Stripe webhooks update the payments of the orders when payments are
+// enabled, and the large reports are generated. Every event is forwarded to the broker when one is configured.
+func (container *Container) EventBus() (*event.Bus, error) {
+	if container.eventBus != nil {
+		return container.eventBus, nil
+	}
+
+	responseCache, err := container.ResponseCache()
+	if err != nil {
+		return nil, err
+	}
+	bus := event.NewBus(container.config.Events.Workers, container.config.Events.QueueSize)
+	invalidateUser := func(ctx context.Context, published event.Event) error {
+		var id string
+		switch published := published.(type) {
+		case event.UserRegistered:
+			id = published.User.ID
+		case event.UserUpdated:
+			id = published.User.ID
+		case event.UserDeleted:
+			id = published.UserID
+		case event.UserRestored:
+			id = published.User.ID
+		}
+		return responseCache.Invalidate("/users", "/users/"+id)
+	}
+	for _, name := range []string{event.NameUserRegistered, event.NameUserUpdated, event.NameUserDeleted, event.NameUserRestored} {
+		bus.Subscribe(name, invalidateUser)
+	}
+	// The cached queries are invalidated on the writes through the
+	// repository; the events cover the users changed around it, such as
+	// within a transaction that committed after the invalidation.
+	if container.config.Database.DSN != "" && container.config.Cache.QueryTTL > 0 {
+		store, err := container.CacheStore()
+		if err != nil {
+			return nil, err
+		}
+		invalidateUserQueries := func(ctx context.Context, published event.Event) error {
+			repository.InvalidateCachedUsers(store)
+			return nil
+		}
+		for _, name := range []string{event.NameUserRegistered, event.NameUserUpdated, event.NameUserDeleted, event.NameUserRestored} {
+			bus.Subscribe(name, invalidateUserQueries)
+		}
+	}
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	uploads := container.config.Uploads
+	bus.Subscribe(event.NameFileUploaded, upload.NewPipeline(repositories.Files, uploads.Dir, upload.Steps(uploads, container.Storage())...).Handle)
+	if container.config.Payments.StripeSecretKey != "" {
+		paymentService, err := container.PaymentService()
+		if err != nil {
+			return nil, err
+		}
+		bus.Subscribe(event.NameWebhookReceived, paymentService.HandleWebhook)
+	}
+	reportService, err := container.ReportService()
+	if err != nil {
+		return nil, err
+	}
+	bus.Subscribe(event.NameReportRequested, reportService.Generate)
+	notificationService, err := container.NotificationService()
+	if err != nil {
+		return nil, err
+	}
+	bus.Subscribe(event.NameOrderPlaced, notificationService.HandleEvent)
+	bus.Subscribe(event.NameOrderStatusChanged, notificationService.HandleEvent)
+	pushService, err := container.PushService()
+	if err != nil {
+		return nil, err
+	}
+	bus.Subscribe(event.NameNotificationCreated, pushService.Push)
+	smsService, err := container.SMSService()
+	if err != nil {
+		return nil, err
+	}
+	bus.Subscribe(event.NameNotificationCreated, smsService.Notify)
+	bus.Subscribe(event.NameWebhookReceived, smsService.HandleWebhook)
+	activityService, err := container.ActivityService()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{event.NameUserRegistered, event.NameUserUpdated, event.NameUserRestored, event.NameUserEmailChangeRequested,
+		event.NameOrderPlaced, event.NameOrderStatusChanged} {
+		bus.Subscribe(name, activityService.HandleEvent)
+	}
+	dispatcher, err := container.WebhookDispatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range event.Names() {
+		bus.Subscribe(name, dispatcher.Enqueue)
+	}
+	if container.config.Broker.Kind != "" {
+		publisher, err := container.Broker()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range event.Names() {
+			bus.Subscribe(name, publisher.Handle)
+		}
+	}
+	container.eventBus = bus
+	return bus, nil
+}
+
+// WebhookDispatcher queues the deliveries of the events to the webhooks and
+// sends them.
+func (container *Container) WebhookDispatcher() (*webhook.Dispatcher, error) {
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	return webhook.NewDispatcher(repositories.Webhooks, repositories.WebhookDeliveries, container.config.Webhooks), nil
+}
+
+// Janitor removes the files the app leaves behind.
+func (container *Container) Janitor() (*janitor.Janitor, error) {
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	return janitor.New(container.config.Janitor, container.config.Uploads, repositories.Files), nil
+}
+
+// Broker connects to the message broker the events are forwarded to.
+func (container *Container) Broker() (*broker.Publisher, error) {
+	if container.broker != nil {
+		return container.broker, nil
+	}
+
+	publisher, err := broker.New(container.config.Broker)
+	if err != nil {
+		return nil, err
+	}
+	container.broker = publisher
+	return publisher, nil
+}
+
+// Outbox is the publisher of the services: their events are stored with the
+// changes they describe and reach the EventBus through the OutboxRelay.
+func (container *Container) Outbox() (*outbox.Outbox, error) {
+	if container.outbox != nil {
+		return container.outbox, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	container.outbox = outbox.New(repositories.Outbox)
+	return container.outbox, nil
+}
+
+func (container *Container) OutboxRelay() (*outbox.Relay, error) {
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	eventBus, err := container.EventBus()
+	if err != nil {
+		return nil, err
+	}
+	return outbox.NewRelay(repositories.Outbox, eventBus, container.config.Outbox), nil
+}
+
+// AccessLog is the access log of the environment the app runs in, or nil
+// when it is disabled there.
+func (container *Container) AccessLog() (*accesslog.Logger, error) {
+	if container.accessLog != nil {
+		return container.accessLog, nil
+	}
+
+	accessLogConfig, err := container.config.AccessLog.For(container.config.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if !accessLogConfig.Enabled {
+		return nil, nil
+	}
+	container.accessLog, err = accesslog.New(accessLogConfig)
+	return container.accessLog, err
+}
+
+// UsageRecorder records the request statistics of the app, or is nil when
+// analytics are disabled. It must run for them to be saved.
+func (container *Container) UsageRecorder() (*analytics.Recorder, error) {
+	if container.usageRecorder != nil || !container.config.Analytics.Enabled {
+		return container.usageRecorder, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	container.usageRecorder = analytics.NewRecorder(repositories.Usage, container.config.Analytics)
+	return container.usageRecorder, nil
+}
+
+// LoadShedder is nil unless load shedding is enabled.
+func (container *Container) LoadShedder() *middleware.LoadShedder {
+	if container.loadShedder == nil && container.config.LoadShed.Enabled {
+		container.loadShedder = middleware.NewLoadShedder(container.config.LoadShed)
+	}
+	return container.loadShedder
+}
+
+func (container *Container) AuditService() (service.AuditService, error) {
+	if container.auditService != nil {
+		return container.auditService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	container.auditService = service.NewAuditService(repositories.Audit)
+	return container.auditService, nil
+}
+
+func (container *Container) WebhookService() (service.WebhookService, error) {
+	if container.webhookService != nil {
+		return container.webhookService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	container.webhookService = service.NewWebhookService(repositories.Webhooks, repositories.WebhookDeliveries, auditService)
+	return container.webhookService, nil
+}
+
+func (container *Container) APIKeyService() (service.APIKeyService, error) {
+	if container.apiKeyService != nil {
+		return container.apiKeyService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	container.apiKeyService = service.NewAPIKeyService(repositories.APIKeys, repositories.Users, auditService)
+	return container.apiKeyService, nil
+}
+
+func (container *Container) QuotaService() (service.QuotaService, error) {
+	if container.quotaService != nil {
+		return container.quotaService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	container.quotaService = service.NewQuotaService(repositories.Quotas)
+	return container.quotaService, nil
+}
+
+// Storage stores the uploaded files and their thumbnails in the upload
+// directory.
+func (container *Container) Storage() storage.Storage {
+	if container.storage == nil {
+		container.storage = storage.NewLocal(container.config.Uploads.Dir)
+	}
+	return container.storage
+}
+
+// TokenKeys signs the tokens the app issues. The first signing key is
+// created here when there is none yet.
+func (container *Container) TokenKeys() (*token.Keys, error) {
+	if container.tokenKeys != nil {
+		return container.tokenKeys, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	keys := token.NewKeys(container.config.JWT, repositories.SigningKeys)
+	err = keys.Rotate(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	container.tokenKeys = keys
+	return keys, nil
+}
+
+// Mailer sends email through the SMTP server of config.MailConfig, or is nil
+// when there is none.
+func (container *Container) Mailer() mail.Sender {
+	if container.config.Mail.Host == "" {
+		return nil
+	}
+	return mail.NewSMTP(container.config.Mail)
+}
+
+// Captcha checks the captcha of the login and sign-up routes, with the
+// settings of the environment.
+func (container *Container) Captcha() (fiber.Handler, error) {
+	captcha, err := container.config.Captcha.For(container.config.Environment)
+	if err != nil {
+		return nil, err
+	}
+	return middleware.NewCaptcha(captcha, container.HTTPClient())
+}
+
+// Assets serves the embedded front-end assets and resolves the URLs of their
+// fingerprinted names for the templates.
+func (container *Container) Assets() (*static.Assets, error) {
+	if container.assets == nil {
+		files, err := fs.Sub(static.FS, "assets")
+		if err != nil {
+			return nil, err
+		}
+		config := container.config.Static
+		container.assets, err = static.New(config.Prefix, files, config.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return container.assets, nil
+}
+
+func (container *Container) FileService() (service.FileService, error) {
+	if container.fileService != nil {
+		return container.fileService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	uploads := container.config.Uploads
+	container.fileService = service.NewFileService(repositories.Files, container.Storage(), repositories.Transactor, events, func(ownerID string) int64 {
+		return int64(uploads.StorageQuotaFor(ownerID))
+	})
+	return container.fileService, nil
+}
+
+func (container *Container) OrderService() (service.OrderService, error) {
+	if container.orderService != nil {
+		return container.orderService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.orderService = service.NewOrderService(repositories.Orders, repositories.Transactor, auditService, events)
+	return container.orderService, nil
+}
+
+// PaymentService takes the payments of the orders through Stripe Checkout.
+func (container *Container) PaymentService() (service.PaymentService, error) {
+	if container.paymentService != nil {
+		return container.paymentService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.paymentService = service.NewPaymentService(repositories.Orders, payment.NewStripe(container.HTTPClient(), container.config.Payments),
+		repositories.Transactor, auditService, events)
+	return container.paymentService, nil
+}
+
+// ReportService keeps the reports generated in the background in the storage
+// of the uploaded files.
+func (container *Container) ReportService() (service.ReportService, error) {
+	if container.reportService != nil {
+		return container.reportService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.reportService = service.NewReportService(container.config.Reports, repositories.Users, repositories.Orders, repositories.Reports,
+		container.Storage(), repositories.Transactor, events)
+	return container.reportService, nil
+}
+
+// NotificationService delivers the notifications in real time to the
+// subscribers of this process only.
+func (container *Container) NotificationService() (service.NotificationService, error) {
+	if container.notifications != nil {
+		return container.notifications, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.notifications = service.NewNotificationService(repositories.Notifications, repositories.Transactor, events)
+	container.addLocalState("notifications", "the notification streams only hear of the notifications created by the same process")
+	return container.notifications, nil
+}
+
+func (container *Container) ActivityService() (service.ActivityService, error) {
+	if container.activities != nil {
+		return container.activities, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	container.activities = service.NewActivityService(repositories.Users, repositories.Activities)
+	return container.activities, nil
+}
+
+// CommentService keeps the comments on the products, open to everyone, and
+// on the orders, for their users only.
+func (container *Container) CommentService() (service.CommentService, error) {
+	if container.comments != nil {
+		return container.comments, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	quotaService, err := container.QuotaService()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	productService, err := container.ProductService()
+	if err != nil {
+		return nil, err
+	}
+	orderService, err := container.OrderService()
+	if err != nil {
+		return nil, err
+	}
+	targets := map[string]service.CommentTarget{
+		"product": func(ctx context.Context, userID, id string) error {
+			_, err := productService.Get(ctx, id)
+			return err
+		},
+		"order": func(ctx context.Context, userID, id string) error {
+			_, err := orderService.Find(ctx, userID, id)
+			return err
+		},
+	}
+	container.comments = service.NewCommentService(container.config.Comments, targets, repositories.Comments, quotaService, repositories.Transactor, auditService)
+	return container.comments, nil
+}
+
+// PushService pushes through FCM and APNs as far as config.PushConfig sets
+// them up; the devices of the other platforms are kept but not pushed to.
+func (container *Container) PushService() (service.PushService, error) {
+	if container.pushService != nil {
+		return container.pushService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	pushConfig := container.config.Push
+	senders := map[string]push.Sender{}
+	if pushConfig.FCMProjectID != "" && pushConfig.FCMCredentialsFile != "" {
+		senders[model.PlatformFCM], err = push.NewFCM(container.HTTPClient(), pushConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pushConfig.APNsKeyFile != "" && pushConfig.APNsKeyID != "" && pushConfig.APNsTeamID != "" {
+		senders[model.PlatformAPNs], err = push.NewAPNs(pushConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	container.pushService = service.NewPushService(repositories.Devices, repositories.NotificationPreferences, senders)
+	return container.pushService, nil
+}
+
+// SMSService texts through the provider of config.SMSConfig, if any.
+func (container *Container) SMSService() (service.SMSService, error) {
+	if container.smsService != nil {
+		return container.smsService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	quotaService, err := container.QuotaService()
+	if err != nil {
+		return nil, err
+	}
+	var sender sms.Sender
+	if container.config.SMS.Provider != "" {
+		sender, err = sms.New(container.HTTPClient(), container.config.SMS)
+		if err != nil {
+			return nil, err
+		}
+	}
+	container.smsService = service.NewSMSService(sender, container.config.SMS, container.config.Server.BaseURL, repositories.SMS,
+		repositories.NotificationPreferences, quotaService)
+	return container.smsService, nil
+}
+
+// Sitemap lists the products in the sitemap; App adds the public routes of
+// config.SitemapConfig once they are mounted.
+func (container *Container) Sitemap() (*sitemap.Sitemap, error) {
+	if container.sitemap != nil {
+		return container.sitemap, nil
+	}
+
+	productService, err := container.ProductService()
+	if err != nil {
+		return nil, err
+	}
+	products := func(ctx context.Context, add func(path string, modified time.Time)) error {
+		spec := &model.ListSpec{Page: 1, PerPage: 100, Sort: []model.SortField{{Field: "id"}}}
+		for {
+			products, total, err := productService.List(ctx, false, spec)
+			if err != nil {
+				return err
+			}
+			for _, product := range products {
+				add("/products/"+product.ID, product.UpdatedAt)
+			}
+			if spec.Offset()+len(products) >= total || len(products) == 0 {
+				return nil
+			}
+			spec.Page++
+		}
+	}
+	container.addLocalState("sitemap", "the sitemap is generated by every process, which may serve different generations for a while")
+	container.sitemap = sitemap.New(container.config.Sitemap, container.config.Robots, container.config.Server.BaseURL, products)
+	return container.sitemap, nil
+}
+
+// ProductService keeps the images of the products in the storage of the
+// uploaded files.
+func (container *Container) ProductService() (service.ProductService, error) {
+	if container.productService != nil {
+		return container.productService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	container.productService = service.NewProductService(container.config.Products, repositories.Products, container.Storage(), repositories.Transactor, auditService)
+	return container.productService, nil
+}
+
+func (container *Container) UserService() (service.UserService, error) {
+	if container.userService != nil {
+		return container.userService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.userService = service.NewUserService(repositories.Users, repositories.Transactor, auditService, events)
+	return container.userService, nil
+}
+
+func (container *Container) AuthService() (service.AuthService, error) {
+	if container.authService != nil {
+		return container.authService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.authService = service.NewAuthService(repositories.Users, repositories.Transactor, auditService, events)
+	return container.authService, nil
+}
+
+// SessionService keeps the sessions of the logins, lasting as long as the
+// tokens issued for them.
+func (container *Container) SessionService() (service.SessionService, error) {
+	if container.sessionService != nil {
+		return container.sessionService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	container.sessionService = service.NewSessionService(repositories.Sessions, auditService, container.config.JWT.TTL)
+	return container.sessionService, nil
+}
+
+func (container *Container) AccountService() (service.AccountService, error) {
+	if container.accountService != nil {
+		return container.accountService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	userService, err := container.UserService()
+	if err != nil {
+		return nil, err
+	}
+	sessionService, err := container.SessionService()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.accountService = service.NewAccountService(repositories.Users, userService, sessionService, repositories.EmailChanges,
+		repositories.Transactor, auditService, events)
+	return container.accountService, nil
+}
+
+// AvatarService keeps the avatars of the users in the storage of the
+// uploaded files.
+func (container *Container) AvatarService() (service.AvatarService, error) {
+	if container.avatarService != nil {
+		return container.avatarService, nil
+	}
+
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	container.avatarService = service.NewAvatarService(container.config.Avatars, repositories.Users, container.Storage(), repositories.Transactor, auditService, events)
+	return container.avatarService, nil
+}
+
+func (container *Container) I18n() (*i18n.Bundle, error) {
+	if container.i18n != nil {
+		return container.i18n, nil
+	}
+
+	var bundle *i18n.Bundle
+	var err error
+	if container.config.I18n.Dir == "" {
+		bundle, err = i18n.NewBundle(container.config.I18n.DefaultLocale, nil)
+	} else {
+		bundle, err = i18n.Load(container.config.I18n.Dir, container.config.I18n.DefaultLocale)
+	}
+	if err != nil {
+		return nil, err
+	}
+	container.i18n = bundle
+	return bundle, nil
+}
+
+// HTTPClient is shared by every call to a third-party API, so the circuit of
+// a failing host opens for all of them.
+func (container *Container) HTTPClient() *httpclient.Client {
+	if container.httpClient == nil {
+		container.httpClient = httpclient.New(container.config.HTTPClient)
+	}
+	return container.httpClient
+}
+
+// GRPCServer builds the gRPC server on the same services as the HTTP API.
+func (container *Container) GRPCServer() (*grpc.Server, error) {
+	userService, err := container.UserService()
+	if err != nil {
+		return nil, err
+	}
+	authService, err := container.AuthService()
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewServer(userService, authService, container.config.Admin), nil
+}
+
+// Module is a feature of the app and the prefix it is mounted at. An
+// isolated module gets a fiber sub-app of its own instead of a group, so its
+// middleware never runs for the rest of the app.
+type Module struct {
+	Name     string
+	Prefix   string
+	Module   handler.Module
+	Isolated bool
+}
+
+// Modules returns the features that make up the app, in mount order.
+func (container *Container) Modules() ([]Module, error) {
+	responseCache, err := container.ResponseCache()
+	if err != nil {
+		return nil, err
+	}
+	userService, err := container.UserService()
+	if err != nil {
+		return nil, err
+	}
+	authService, err := container.AuthService()
+	if err != nil {
+		return nil, err
+	}
+	auditService, err := container.AuditService()
+	if err != nil {
+		return nil, err
+	}
+	webhookService, err := container.WebhookService()
+	if err != nil {
+		return nil, err
+	}
+	apiKeyService, err := container.APIKeyService()
+	if err != nil {
+		return nil, err
+	}
+	quotaService, err := container.QuotaService()
+	if err != nil {
+		return nil, err
+	}
+	fileService, err := container.FileService()
+	if err != nil {
+		return nil, err
+	}
+	repositories, err := container.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	events, err := container.Outbox()
+	if err != nil {
+		return nil, err
+	}
+	engine, err := container.Views()
+	if err != nil {
+		return nil, err
+	}
+	assets, err := container.Assets()
+	if err != nil {
+		return nil, err
+	}
+	tokenKeys, err := container.TokenKeys()
+	if err != nil {
+		return nil, err
+	}
+	captcha, err := container.Captcha()
+	if err != nil {
+		return nil, err
+	}
+	sessionService, err := container.SessionService()
+	if err != nil {
+		return nil, err
+	}
+	accountService, err := container.AccountService()
+	if err != nil {
+		return nil, err
+	}
+	avatarService, err := container.AvatarService()
+	if err != nil {
+		return nil, err
+	}
+	orderService, err := container.OrderService()
+	if err != nil {
+		return nil, err
+	}
+	productService, err := container.ProductService()
+	if err != nil {
+		return nil, err
+	}
+	reportService, err := container.ReportService()
+	if err != nil {
+		return nil, err
+	}
+	notificationService, err := container.NotificationService()
+	if err != nil {
+		return nil, err
+	}
+	pushService, err := container.PushService()
+	if err != nil {
+		return nil, err
+	}
+	activityService, err := container.ActivityService()
+	if err != nil {
+		return nil, err
+	}
+	commentService, err := container.CommentService()
+	if err != nil {
+		return nil, err
+	}
+	siteMap, err := container.Sitemap()
+	if err != nil {
+		return nil, err
+	}
+	sources, err := static.New(container.config.Static.SourcePrefix, source.FS, container.config.Static.MaxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []Module
+	if container.config.Debug.Enabled && container.config.Debug.Password != "" {
+		modules = append(modules, Module{Name: "debug", Prefix: "/debug", Module: handler.NewDebugHandler(container.config.Debug), Isolated: true})
+	}
+	if container.config.Admin.Password != "" {
+		modules = append(modules,
+			Module{Name: "audit", Prefix: "/admin/audit", Module: handler.NewAuditHandler(container.config.Admin, auditService), Isolated: true},
+			Module{Name: "webhooks", Prefix: "/admin/webhooks", Module: handler.NewWebhookHandler(container.config.Admin, webhookService), Isolated: true},
+			Module{Name: "runtime", Prefix: "/admin/runtime", Module: handler.NewRuntimeHandler(container.live, auditService), Isolated: true},
+			Module{Name: "api_keys", Prefix: "/admin/api-keys", Module: handler.NewAPIKeyHandler(container.config.Admin, apiKeyService), Isolated: true},
+		)
+		if container.config.Analytics.Enabled {
+			modules = append(modules, Module{Name: "analytics", Prefix: "/admin/analytics",
+				Module: handler.NewAnalyticsHandler(container.config.Admin, container.config.Server.EarlyHints, service.NewAnalyticsService(repositories.Usage)), Isolated: true})
+		}
+		// The dashboard comes after the other admin modules so its middleware
+		// does not run for their routes.
+		if engine != nil {
+			queueService := service.NewQueueService(repositories.Outbox, repositories.WebhookDeliveries)
+			modules = append(modules, Module{Name: "admin", Prefix: "/admin", Module: handler.NewAdminHandler(container.config, userService, auditService, queueService), Isolated: true})
+		}
+	}
+	modules = append(modules,
+		Module{Name: "auth", Prefix: "/auth", Module: handler.NewOAuthHandler(container.config, authService, container.HTTPClient(), tokenKeys, sessionService, captcha)},
+		Module{Name: "account", Prefix: "/me", Module: handler.NewAccountHandler(accountService, sessionService, avatarService, responseCache)},
+		Module{Name: "notifications", Prefix: "/me/notifications", Module: handler.NewNotificationHandler(notificationService, pushService)},
+		Module{Name: "users", Prefix: "/users", Module: handler.NewUserHandler(userService, avatarService, responseCache, container.config.Admin)},
+		Module{Name: "orders", Prefix: "/users/:userId/orders", Module: handler.NewOrderHandler(orderService, reportService, container.config.Admin)},
+		Module{Name: "reports", Prefix: "/users/:userId/reports", Module: handler.NewReportHandler(reportService)},
+		Module{Name: "order_comments", Prefix: "/users/:userId/orders/:resourceId/comments", Module: handler.NewCommentHandler("order", true, commentService, container.config.Admin)},
+		Module{Name: "activity", Prefix: "", Module: handler.NewActivityHandler(activityService)},
+		Module{Name: "products", Prefix: "/products", Module: handler.NewProductHandler(productService, container.config.Admin)},
+		Module{Name: "product_comments", Prefix: "/products/:resourceId/comments", Module: handler.NewCommentHandler("product", false, commentService, container.config.Admin)},
+		Module{Name: "feeds", Prefix: "/feeds", Module: handler.NewFeedHandler(container.config.Feeds, container.config.Server.BaseURL, productService)},
+		Module{Name: "quota", Prefix: "/quota", Module: handler.NewQuotaHandler(container.live, quotaService)},
+		Module{Name: "uploads", Prefix: "/upload", Module: handler.NewUploadHandler(fileService)},
+		Module{Name: "files", Prefix: "/files", Module: handler.NewFileHandler(container.config.Uploads, fileService, upload.NewThumbnailer(container.Storage(), container.config.Uploads.ImageMaxDimension))},
+		Module{Name: "codes", Prefix: "", Module: handler.NewCodeHandler()},
+		Module{Name: "sitemap", Prefix: "", Module: siteMap},
+		Module{Name: "batch", Prefix: "/api/v1/batch", Module: handler.NewBatchHandler(repositories.Transactor)},
+		Module{Name: "jwks", Prefix: "/.well-known", Module: handler.NewJWKSHandler(tokenKeys)},
+		Module{Name: "assets", Prefix: container.config.Static.Prefix, Module: assets},
+		Module{Name: "sources", Prefix: container.config.Static.SourcePrefix, Module: sources},
+	)
+	if engine != nil {
+		captchaConfig, err := container.config.Captcha.For(container.config.Environment)
+		if err != nil {
+			return nil, err
+		}
+		contactService := service.NewContactService(container.Mailer(), container.config.Contact, quotaService)
+		modules = append(modules, Module{Name: "contact", Prefix: "/contact", Module: handler.NewContactHandler(contactService, captchaConfig, captcha)})
+	}
+	if len(container.config.Webhooks.Receivers) > 0 {
+		receiver, err := handler.NewWebhookReceiverHandler(container.config.Webhooks.Receivers,
+			service.NewWebhookReceiverService(repositories.ReceivedWebhooks, repositories.Transactor, events))
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, Module{Name: "webhook_receivers", Prefix: "/webhooks", Module: receiver})
+	}
+	if container.config.Payments.StripeSecretKey != "" {
+		paymentService, err := container.PaymentService()
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, Module{Name: "payments", Prefix: "/payments", Module: handler.NewPaymentHandler(paymentService)})
+	}
+	for _, route := range container.config.Proxy {
+		modules = append(modules, Module{Name: "proxy_" + route.Name, Prefix: route.Prefix, Module: handler.NewProxyHandler(route)})
+	}
+	// The single-page apps come last so their fallback does not hide the
+	// routes of the other modules.
+	for _, spa := range container.config.Static.SPA {
+		modules = append(modules, Module{Name: "spa_" + spa.Name, Prefix: spa.Prefix, Module: static.NewSPA(spa)})
+	}
+	return modules, nil
+}
+
+// Views is the view engine of the app, shared by the isolated modules, or nil
+// when views are disabled.
+func (container *Container) Views() (fiber.Views, error) {
+	if container.views != nil {
+		return container.views, nil
+	}
+
+	server := container.config.Server
+	engine, err := views.New(server)
+	if err != nil {
+		return nil, err
+	}
+	if engine != nil && server.ViewsReload && !server.ViewsEmbedded && container.config.Environment != "production" {
+		container.viewsWatcher, err = views.Watch(engine, server.Views)
+		if err != nil {
+			return nil, err
+		}
+	}
+	container.views = engine
+	return engine, nil
+}
+
+// FiberConfig is the fiber.Config of the app: timeouts, prefork, views and
+// the error handler.
+func (container *Container) FiberConfig() (fiber.Config, error) {
+	engine, err := container.Views()
+	if err != nil {
+		return fiber.Config{}, err
+	}
+
+	server := container.config.Server
+	codec, err := jsoncodec.Get(server.JSONCodec)
+	if err != nil {
+		return fiber.Config{}, err
+	}
+	return fiber.Config{
+		IdleTimeout:  server.IdleTimeout,
+		ReadTimeout:  server.ReadTimeout,
+		WriteTimeout: server.WriteTimeout,
+		Prefork:      server.Prefork,
+		BodyLimit:    int(server.BodyLimit.Max()),
+		ErrorHandler: web.NewErrorHandler(server.ProblemDetails),
+		Views:        engine,
+		ViewsLayout:  server.ViewsLayout,
+		JSONEncoder:  codec.Marshal,
+		JSONDecoder:  codec.Unmarshal,
+		// The view helpers of middleware.NewViewHelpers live in the locals.
+		PassLocalsToViews: true,
+	}, nil
+}
+
+// App wires the middleware and routes into a fiber app. It does not start
+// listening.
+func (container *Container) App() (*fiber.App, error) {
+	responseCache, err := container.ResponseCache()
+	if err != nil {
+		return nil, err
+	}
+	store, err := container.CacheStore()
+	if err != nil {
+		return nil, err
+	}
+	modules, err := container.Modules()
+	if err != nil {
+		return nil, err
+	}
+	realIP, err := middleware.NewRealIP(container.config.Server.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := container.I18n()
+	if err != nil {
+		return nil, err
+	}
+	fiberConfig, err := container.FiberConfig()
+	if err != nil {
+		return nil, err
+	}
+	apiKeyService, err := container.APIKeyService()
+	if err != nil {
+		return nil, err
+	}
+	tokenKeys, err := container.TokenKeys()
+	if err != nil {
+		return nil, err
+	}
+	sessionService, err := container.SessionService()
+	if err != nil {
+		return nil, err
+	}
+	quotaService, err := container.QuotaService()
+	if err != nil {
+		return nil, err
+	}
+	usageRecorder, err := container.UsageRecorder()
+	if err != nil {
+		return nil, err
+	}
+	accessLog, err := container.AccessLog()
+	if err != nil {
+		return nil, err
+	}
+	assets, err := container.Assets()
+	if err != nil {
+		return nil, err
+	}
+
+	app := fiber.New(fiberConfig)
+	app.Use(middleware.NewMethodOverride())
+	app.Use(realIP)
+	for _, listener := range container.config.Server.ListenerConfigs() {
+		if listener.TLS.Enabled && listener.TLS.HTTP3 {
+			app.Use(server.NewAltSvc(listener.Address))
+			break
+		}
+	}
+
+	app.Use("/api", func(ctx *fiber.Ctx) error {
+		fmt.Println("I'm a middleware before process")
+		err := ctx.Next()
+		fmt.Println("I'm a middleware after process")
+		return err
+	})
+
+	// The access log comes before the recovery so that it logs the 500 of
+	// a recovered panic too.
+	if accessLog != nil {
+		app.Use(accessLog.Middleware())
+	}
+	app.Use(middleware.NewRecover())
+	// Requests are shed before any work is done for them.
+	if loadShedder := container.LoadShedder(); loadShedder != nil {
+		app.Use(loadShedder.Middleware())
+	}
+	if concurrency := container.config.Concurrency; concurrency.Max > 0 || len(concurrency.Groups) > 0 {
+		container.addLocalState("concurrency", "the limits of requests in progress apply to each process")
+	}
+	app.Use(middleware.NewConcurrencyLimit(container.config.Concurrency))
+	if len(container.config.Cookies.Keys) > 0 {
+		encryptCookie, err := middleware.NewEncryptCookie(container.config.Cookies)
+		if err != nil {
+			return nil, err
+		}
+		app.Use(encryptCookie)
+	}
+	app.Use(middleware.NewCORS(container.live))
+	if container.config.CSP.Enabled {
+		app.Use(middleware.NewCSP(container.config.CSP))
+	}
+	app.Use(tenant.New(container.config.Tenancy))
+	// Requests are recorded once handled, including those the rate limit
+	// turns away, with the consumer the API key authentication finds.
+	if usageRecorder != nil {
+		app.Use(usageRecorder.Middleware())
+	}
+	app.Use(middleware.NewRateLimit(container.live, store))
+	app.Use(requestid.New())
+	app.Use(middleware.NewSlowRequest(container.config.SlowRequest))
+	app.Use(i18n.New(bundle))
+	app.Use(middleware.NewAuditContext())
+	app.Use(telemetry.NewTracing())
+	app.Use(telemetry.NewSentry())
+	app.Use(middleware.NewAPIKeyAuth(apiKeyService))
+	app.Use(middleware.NewTokenAuth(tokenKeys, sessionService))
+	app.Use(middleware.NewQuota(container.live, quotaService))
+	// The quota counts a request before its reads may go to the replicas,
+	// so that the count doesn't send them all to the primary.
+	if container.db != nil && container.db.HasReplicas() {
+		app.Use(middleware.NewReadReplicas(container.config.Database))
+	}
+	app.Use(middleware.NewDisconnect())
+	app.Use(middleware.NewTimeout(container.config.Timeout))
+	app.Use(middleware.NewBodyLimit(container.config.Server.BodyLimit))
+	app.Use(middleware.NewIdempotency(store, container.config.Idempotency))
+	if len(container.config.Transaction.Routes) > 0 {
+		repositories, err := container.Repositories()
+		if err != nil {
+			return nil, err
+		}
+		app.Use(middleware.NewTransaction(repositories.Transactor, container.config.Transaction))
+	}
+	app.Use(middleware.NewETag(true))
+	app.Use(responseCache.Middleware())
+	if container.config.Minify.Enabled && container.config.Environment == "production" {
+		app.Use(middleware.NewMinify())
+	}
+	app.Use(middleware.NewViewHelpers(assets))
+	app.Use(middleware.NewFeatures(container.live))
+
+	// Under prefork each child only counts its own requests, so /metrics
+	// gathers the numbers of all of them, whichever child answers.
+	metrics := telemetry.MetricsHandler()
+	if container.config.Server.Prefork && fiber.IsChild() {
+		metrics = telemetry.PreforkMetricsHandler(telemetry.ChildMetricsDir(os.Getppid()))
+	}
+	app.Get("/metrics", metrics).Name("metrics")
+	handler.NewHealthHandler(container.Health).Register(app)
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("Hello World")
+	}).Name("home")
+
+	for _, module := range modules {
+		if module.Isolated {
+			handler.MountApp(app, module.Prefix, module.Module, fiberConfig)
+		} else {
+			handler.Mount(app, module.Prefix, module.Module)
+		}
+	}
+	siteMap, err := container.Sitemap()
+	if err != nil {
+		return nil, err
+	}
+	siteMap.AddSource(sitemap.Routes(app, container.config.Sitemap.Routes))
+	app.Use(web.NotFound)
+
+	return app, nil
+}
+
+// Health checks the database and Redis the app was built with, for the
+// readiness probe and the systemd watchdog.
+func (container *Container) Health(ctx context.Context) error {
+	var errs []error
+	if container.db != nil {
+		err := container.db.Primary().PingContext(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("database: %w", err))
+		}
+	}
+	if container.redis != nil {
+		err := container.redis.Ping(ctx).Err()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("redis: %w", err))
+		}
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		telemetry.Logger("health").WarnContext(ctx, "health check failed", "error", err)
+	}
+	return err
+}
+
+// Close releases the database pool and Redis client, if they were created.
+func (container *Container) Close() error {
+	var errs []error
+	if container.configWatcher != nil {
+		errs = append(errs, container.configWatcher.Close())
+	}
+	if container.viewsWatcher != nil {
+		errs = append(errs, container.viewsWatcher.Close())
+	}
+	if container.eventBus != nil {
+		errs = append(errs, container.eventBus.Close())
+	}
+	if container.broker != nil {
+		errs = append(errs, container.broker.Close())
+	}
+	if container.db != nil {
+		errs = append(errs, container.db.Close())
+	}
+	if container.redis != nil {
+		errs = append(errs, container.redis.Close())
+	}
+	if container.accessLog != nil {
+		errs = append(errs, container.accessLog.Close())
+	}
+	return errors.Join(errs...)
+}
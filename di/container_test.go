@@ -0,0 +1,143 @@
+package di
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContainerSharesDependencies(t *testing.T) {
+	container := New(config.Default())
+	defer container.Close()
+
+	repositories, err := container.Repositories()
+	assert.Nil(t, err)
+	again, err := container.Repositories()
+	assert.Nil(t, err)
+	assert.Same(t, repositories, again)
+
+	admin, err := repositories.Users.FindByEmail(context.Background(), "admin@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admin"}, admin.Roles)
+
+	userService, err := container.UserService()
+	assert.Nil(t, err)
+	user, err := userService.Get(context.Background(), admin.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, admin.ID, user.ID)
+}
+
+func TestContainerApp(t *testing.T) {
+	container := New(config.Default())
+	defer container.Close()
+
+	app, err := container.App()
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "1", response.Header.Get("X-Total-Count"))
+}
+
+func TestContainerDatabaseRequiresDSN(t *testing.T) {
+	container := New(config.Default())
+	_, err := container.DB()
+	assert.NotNil(t, err)
+	assert.Nil(t, container.Close())
+}
+
+func TestContainerModules(t *testing.T) {
+	appConfig := config.Default()
+	appConfig.Debug.Enabled = true
+	appConfig.Debug.Password = "secret"
+	appConfig.Admin.Password = "secret"
+	appConfig.Analytics.Enabled = true
+	container := New(appConfig)
+	defer container.Close()
+
+	modules, err := container.Modules()
+	assert.Nil(t, err)
+	names := []string{}
+	for _, module := range modules {
+		names = append(names, module.Name)
+	}
+	assert.Equal(t, []string{"debug", "audit", "webhooks", "runtime", "api_keys", "analytics", "admin", "auth", "account", "notifications", "users", "orders", "reports", "order_comments", "activity", "products", "product_comments", "feeds", "quota", "uploads", "files", "codes", "sitemap", "batch", "jwks", "assets", "sources", "contact"}, names)
+
+	app, err := container.App()
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/debug/process", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestContainerAppFallback(t *testing.T) {
+	container := New(config.Default())
+	defer container.Close()
+
+	app, err := container.App()
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest(http.MethodPost, "/users/1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 405, response.StatusCode)
+	assert.Equal(t, "GET, HEAD, PUT, DELETE, PATCH", response.Header.Get("Allow"))
+}
+
+func TestContainerCheckPrefork(t *testing.T) {
+	container := New(config.Default())
+	defer container.Close()
+	_, err := container.App()
+	assert.Nil(t, err)
+	assert.Nil(t, container.CheckPrefork())
+
+	appConfig := config.Default()
+	appConfig.Server.Prefork = true
+	container = New(appConfig)
+	defer container.Close()
+	_, err = container.App()
+	assert.Nil(t, err)
+	var modules []string
+	for _, state := range container.CheckPrefork() {
+		modules = append(modules, state.Module)
+	}
+	assert.Subset(t, modules, []string{"repositories", "cache", "notifications", "sitemap"})
+}
+
+func TestContainerHealth(t *testing.T) {
+	server := miniredis.RunT(t)
+	appConfig := config.Default()
+	appConfig.Cache.Backend = "redis"
+	appConfig.Redis.Address = server.Addr()
+	container := New(appConfig)
+	defer container.Close()
+
+	app, err := container.App()
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	server.Close()
+	response, err = app.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
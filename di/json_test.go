@@ -0,0 +1,79 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/jsoncodec"
+	"golang-fiber-web/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContainerJSONCodec(t *testing.T) {
+	appConfig := config.Default()
+	appConfig.Server.JSONCodec = "yaml"
+	container := New(appConfig)
+	defer container.Close()
+
+	_, err := container.App()
+	assert.EqualError(t, err, `unknown json codec "yaml"`)
+}
+
+// BenchmarkJSONCodecs compares the codecs built in on a user and on a page of
+// users. Build with -tags jsoniter or -tags sonic to include those.
+func BenchmarkJSONCodecs(b *testing.B) {
+	for _, name := range jsoncodec.Names() {
+		appConfig := config.Default()
+		appConfig.Server.JSONCodec = name
+		container := New(appConfig)
+		app, err := container.App()
+		if err != nil {
+			b.Fatal(err)
+		}
+		repositories, err := container.Repositories()
+		if err != nil {
+			b.Fatal(err)
+		}
+		var users []*model.User
+		for i := 0; i < 100; i++ {
+			users = append(users, &model.User{
+				Username:  fmt.Sprintf("user%d", i),
+				Email:     fmt.Sprintf("user%d@example.com", i),
+				Name:      fmt.Sprintf("User %d", i),
+				Roles:     []string{"user"},
+				CreatedAt: time.Now(),
+			})
+		}
+		err = repositories.Users.CreateMany(context.Background(), users)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(name+"/user", func(b *testing.B) {
+			benchmarkRequest(b, app, "/users/"+users[0].ID)
+		})
+		b.Run(name+"/list", func(b *testing.B) {
+			benchmarkRequest(b, app, "/users?per_page=100")
+		})
+		container.Close()
+	}
+}
+
+func benchmarkRequest(b *testing.B, app *fiber.App, target string) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil), -1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			b.Fatalf("%s answered %d", target, response.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package event
+
+import (
+	"context"
+	"golang-fiber-web/telemetry"
+	"runtime/debug"
+	"sync"
+)
+
+var logger = telemetry.Logger("events")
+
+// Handler reacts to an event. Its errors are logged.
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher is where the services publish events: the bus, or the outbox
+// that relays to it.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Discard is a Publisher that drops every event.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(ctx context.Context, event Event) error {
+	return nil
+}
+
+type delivery struct {
+	ctx     context.Context
+	event   Event
+	handler Handler
+}
+
+// Bus delivers events to their subscribers asynchronously, on a fixed number
+// of workers, so publishers never wait for subscribers to finish. Publish
+// only blocks when the queue is full. Events live in memory and are lost
+// when the process exits before they are handled.
+type Bus struct {
+	mutex    sync.RWMutex
+	handlers map[string][]Handler
+	queue    chan delivery
+	workers  sync.WaitGroup
+}
+
+func NewBus(workers, queueSize int) *Bus {
+	bus := &Bus{handlers: map[string][]Handler{}, queue: make(chan delivery, queueSize)}
+	bus.workers.Add(workers)
+	for range workers {
+		go bus.work()
+	}
+	return bus
+}
+
+func (bus *Bus) Subscribe(name string, handler Handler) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.handlers[name] = append(bus.handlers[name], handler)
+}
+
+// Publish queues event for every subscriber of its name. The subscribers get
+// the values of ctx, such as the audit metadata, but not its cancellation,
+// since they run after the request is over.
+func (bus *Bus) Publish(ctx context.Context, event Event) error {
+	bus.mutex.RLock()
+	handlers := bus.handlers[event.Name()]
+	bus.mutex.RUnlock()
+
+	ctx = context.WithoutCancel(ctx)
+	for _, handler := range handlers {
+		bus.queue <- delivery{ctx: ctx, event: event, handler: handler}
+	}
+	return nil
+}
+
+// Close waits for the queued events to be handled. Nothing may be published
+// afterwards.
+func (bus *Bus) Close() error {
+	close(bus.queue)
+	bus.workers.Wait()
+	return nil
+}
+
+func (bus *Bus) work() {
+	defer bus.workers.Done()
+	for delivery := range bus.queue {
+		deliver(delivery)
+	}
+}
+
+func deliver(delivery delivery) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.Error("panic in subscriber", "event", delivery.event.Name(), "panic", recovered, "stack", string(debug.Stack()))
+		}
+	}()
+	err := delivery.handler(delivery.ctx, delivery.event)
+	if err != nil {
+		logger.Error("subscriber failed", "event", delivery.event.Name(), "error", err)
+	}
+}
@@ -0,0 +1,32 @@
+package event
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus(2, 8)
+	var uploads, sizes atomic.Int64
+	bus.Subscribe(NameFileUploaded, func(ctx context.Context, event Event) error {
+		uploads.Add(1)
+		sizes.Add(event.(FileUploaded).Size)
+		return nil
+	})
+	bus.Subscribe(NameFileUploaded, func(ctx context.Context, event Event) error {
+		panic("subscriber bug")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range 3 {
+		assert.Nil(t, bus.Publish(ctx, FileUploaded{FileName: "notes.txt", Size: 5}))
+	}
+	assert.Nil(t, bus.Publish(ctx, UserDeleted{UserID: "1"}))
+	assert.Nil(t, bus.Close())
+
+	assert.Equal(t, int64(3), uploads.Load())
+	assert.Equal(t, int64(15), sizes.Load())
+}
@@ -0,0 +1,191 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"golang-fiber-web/model"
+	"time"
+)
+
+// Event is a domain event. Subscribers are registered by event name.
+type Event interface {
+	Name() string
+}
+
+const (
+	NameUserRegistered  = "user.registered"
+	NameUserUpdated     = "user.updated"
+	NameUserDeleted     = "user.deleted"
+	NameUserRestored    = "user.restored"
+	NameFileUploaded    = "file.uploaded"
+	NameWebhookReceived = "webhook.received"
+
+	NameOrderPlaced        = "order.placed"
+	NameOrderStatusChanged = "order.status_changed"
+	NameReportRequested    = "report.requested"
+
+	NameNotificationCreated = "notification.created"
+
+	NameUserEmailChangeRequested = "user.email_change_requested"
+)
+
+// Names returns the names of every event.
+func Names() []string {
+	return []string{NameUserRegistered, NameUserUpdated, NameUserDeleted, NameUserRestored, NameUserEmailChangeRequested, NameFileUploaded, NameWebhookReceived,
+		NameOrderPlaced, NameOrderStatusChanged, NameReportRequested, NameNotificationCreated}
+}
+
+// UserRegistered is published when a user is created, through OAuth or an
+// import.
+type UserRegistered struct {
+	User *model.User `json:"user"`
+}
+
+func (event UserRegistered) Name() string {
+	return NameUserRegistered
+}
+
+type UserUpdated struct {
+	User *model.User `json:"user"`
+}
+
+func (event UserUpdated) Name() string {
+	return NameUserUpdated
+}
+
+type UserDeleted struct {
+	UserID string `json:"user_id"`
+}
+
+func (event UserDeleted) Name() string {
+	return NameUserDeleted
+}
+
+type UserRestored struct {
+	User *model.User `json:"user"`
+}
+
+func (event UserRestored) Name() string {
+	return NameUserRestored
+}
+
+// UserEmailChangeRequested is published when a user asks to change their
+// email to Email. The app sends no email itself: the subscriber delivering
+// the event, through a webhook or the broker, mails Token to Email, and the
+// user confirms the change with it at POST /me/email/confirm.
+type UserEmailChangeRequested struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (event UserEmailChangeRequested) Name() string {
+	return NameUserEmailChangeRequested
+}
+
+// FileUploaded is published when the file FileID, called FileName, has been
+// stored.
+type FileUploaded struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+}
+
+func (event FileUploaded) Name() string {
+	return NameFileUploaded
+}
+
+// WebhookReceived is published for every verified event a provider sends to
+// /webhooks/:provider, once per event ID. Subscribers process the events of
+// their provider from Payload, the body the provider sent.
+type WebhookReceived struct {
+	Provider string          `json:"provider"`
+	EventID  string          `json:"event_id"`
+	Type     string          `json:"type"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+func (event WebhookReceived) Name() string {
+	return NameWebhookReceived
+}
+
+// OrderPlaced is published when a user places an order.
+type OrderPlaced struct {
+	Order *model.Order `json:"order"`
+}
+
+func (event OrderPlaced) Name() string {
+	return NameOrderPlaced
+}
+
+// OrderStatusChanged is published when the order OrderID moves from the
+// status From to the status To.
+type OrderStatusChanged struct {
+	OrderID string `json:"order_id"`
+	UserID  string `json:"user_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+func (event OrderStatusChanged) Name() string {
+	return NameOrderStatusChanged
+}
+
+// ReportRequested is published when a report too large to render during the
+// request is requested, for the background job generating it.
+type ReportRequested struct {
+	ReportID string `json:"report_id"`
+}
+
+func (event ReportRequested) Name() string {
+	return NameReportRequested
+}
+
+// NotificationCreated is published when a user gets a notification, for the
+// background job pushing it to their devices.
+type NotificationCreated struct {
+	Notification *model.Notification `json:"notification"`
+}
+
+func (event NotificationCreated) Name() string {
+	return NameNotificationCreated
+}
+
+// Decode turns the JSON form of an event back into the event of that name.
+func Decode(name string, payload []byte) (Event, error) {
+	switch name {
+	case NameUserRegistered:
+		return decode[UserRegistered](payload)
+	case NameUserUpdated:
+		return decode[UserUpdated](payload)
+	case NameUserDeleted:
+		return decode[UserDeleted](payload)
+	case NameUserRestored:
+		return decode[UserRestored](payload)
+	case NameUserEmailChangeRequested:
+		return decode[UserEmailChangeRequested](payload)
+	case NameFileUploaded:
+		return decode[FileUploaded](payload)
+	case NameWebhookReceived:
+		return decode[WebhookReceived](payload)
+	case NameOrderPlaced:
+		return decode[OrderPlaced](payload)
+	case NameOrderStatusChanged:
+		return decode[OrderStatusChanged](payload)
+	case NameReportRequested:
+		return decode[ReportRequested](payload)
+	case NameNotificationCreated:
+		return decode[NotificationCreated](payload)
+	}
+	return nil, errors.New("unknown event " + name)
+}
+
+func decode[T Event](payload []byte) (Event, error) {
+	var event T
+	err := json.Unmarshal(payload, &event)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,166 @@
+// Package feed writes RSS 2.0 and Atom feeds.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	rssType  = "application/rss+xml"
+	atomType = "application/atom+xml"
+
+	RSSContentType  = rssType + "; charset=utf-8"
+	AtomContentType = atomType + "; charset=utf-8"
+)
+
+// Feed is a list of items, newest first. Link is the page the feed is about
+// and Self the URL of the feed itself; both must be absolute.
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	Self        string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is an entry of a feed. Its Link, absolute, also identifies it.
+type Item struct {
+	Title     string
+	Link      string
+	Summary   string
+	Category  string
+	Published time.Time
+	Updated   time.Time
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Description string  `xml:"description,omitempty"`
+	Category    string  `xml:"category,omitempty"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	PermaLink bool   `xml:"isPermaLink,attr"`
+	Value     string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Summary string      `xml:"subtitle,omitempty"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Link      atomLink      `xml:"link"`
+	Summary   string        `xml:"summary,omitempty"`
+	Category  *atomCategory `xml:"category"`
+	Published string        `xml:"published"`
+	Updated   string        `xml:"updated"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteRSS writes the feed as RSS 2.0.
+func (feed *Feed) WriteRSS(writer io.Writer) error {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+		Self:        atomLink{Href: feed.Self, Rel: "self", Type: rssType},
+	}
+	if !feed.Updated.IsZero() {
+		channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, item := range feed.Items {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{PermaLink: true, Value: item.Link},
+			Description: item.Summary,
+			Category:    item.Category,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return write(writer, rss{Version: "2.0", Atom: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+// WriteAtom writes the feed as Atom, its author being its title.
+func (feed *Feed) WriteAtom(writer io.Writer) error {
+	atom := atomFeed{
+		ID:      feed.Self,
+		Title:   feed.Title,
+		Summary: feed.Description,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: feed.Title},
+		Links: []atomLink{
+			{Href: feed.Self, Rel: "self", Type: atomType},
+			{Href: feed.Link, Rel: "alternate"},
+		},
+	}
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			ID:        item.Link,
+			Title:     item.Title,
+			Link:      atomLink{Href: item.Link, Rel: "alternate"},
+			Summary:   item.Summary,
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+		}
+		if item.Category != "" {
+			entry.Category = &atomCategory{Term: item.Category}
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+	return write(writer, atom)
+}
+
+func write(writer io.Writer, document interface{}) error {
+	_, err := io.WriteString(writer, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,58 @@
+package feed
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:       "Latest products",
+		Description: "New & updated",
+		Link:        "https://shop.example.com/products",
+		Self:        "https://shop.example.com/feeds/products.rss",
+		Updated:     published.Add(time.Hour),
+		Items: []Item{{
+			Title:     "Book <hardcover>",
+			Link:      "https://shop.example.com/products/1",
+			Summary:   "A book",
+			Category:  "books",
+			Published: published,
+			Updated:   published.Add(time.Hour),
+		}},
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var output bytes.Buffer
+	assert.Nil(t, testFeed().WriteRSS(&output))
+	rss := output.String()
+
+	assert.True(t, strings.HasPrefix(rss, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`))
+	assert.Contains(t, rss, `<atom:link href="https://shop.example.com/feeds/products.rss" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, rss, "<description>New &amp; updated</description>")
+	assert.Contains(t, rss, "<lastBuildDate>Wed, 01 May 2024 11:00:00 +0000</lastBuildDate>")
+	assert.Contains(t, rss, "<title>Book &lt;hardcover&gt;</title>")
+	assert.Contains(t, rss, `<guid isPermaLink="true">https://shop.example.com/products/1</guid>`)
+	assert.Contains(t, rss, "<pubDate>Wed, 01 May 2024 10:00:00 +0000</pubDate>")
+}
+
+func TestWriteAtom(t *testing.T) {
+	feed := testFeed()
+	feed.Self = "https://shop.example.com/feeds/products.atom"
+	var output bytes.Buffer
+	assert.Nil(t, feed.WriteAtom(&output))
+	atom := output.String()
+
+	assert.Contains(t, atom, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, atom, "<id>https://shop.example.com/feeds/products.atom</id>")
+	assert.Contains(t, atom, "<updated>2024-05-01T11:00:00Z</updated>")
+	assert.Contains(t, atom, `<link href="https://shop.example.com/feeds/products.atom" rel="self" type="application/atom+xml"></link>`)
+	assert.Contains(t, atom, "<id>https://shop.example.com/products/1</id>")
+	assert.Contains(t, atom, `<category term="books"></category>`)
+	assert.Contains(t, atom, "<published>2024-05-01T10:00:00Z</published>")
+}
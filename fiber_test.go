@@ -1,37 +1,42 @@
 package main
 
 import (
-	"bytes"
 	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/template/mustache/v2"
 	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/di"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/testfactory"
+	"golang-fiber-web/web"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
-	"time"
 )
 
-var engine = mustache.New("./template", ".mustache")
+// newTestApp builds an app from the default config through the container,
+// so every test gets its own routes instead of sharing one global app.
+func newTestApp(t testing.TB) *fiber.App {
+	container := di.New(config.Default())
+	t.Cleanup(func() {
+		container.Close()
+	})
 
-var app = fiber.New(fiber.Config{
-	Views:        engine,
-	IdleTimeout:  time.Minute * 5,
-	ReadTimeout:  time.Minute * 5,
-	WriteTimeout: time.Minute * 5,
-	ErrorHandler: func(ctx *fiber.Ctx, err error) error {
-		ctx.Status(fiber.StatusInternalServerError)
-		return ctx.SendString("Error: " + err.Error())
-	},
-})
+	fiberConfig, err := container.FiberConfig()
+	assert.Nil(t, err)
+	app := fiber.New(fiberConfig)
+	app.Use(middleware.NewRecover())
+	return app
+}
 
 func TestRoutingHelloWorld(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/", func(ctx *fiber.Ctx) error {
 		return ctx.SendString("Hello World")
 	})
@@ -45,6 +50,7 @@ func TestRoutingHelloWorld(t *testing.T) {
 }
 
 func TestCtx(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/hello", func(ctx *fiber.Ctx) error {
 		query := ctx.Query("name", "Guest")
 		return ctx.SendString("Hello " + query)
@@ -59,6 +65,7 @@ func TestCtx(t *testing.T) {
 }
 
 func TestHttpRequest(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/request", func(ctx *fiber.Ctx) error {
 		first := ctx.Get("firstname")
 		last := ctx.Cookies("lastname")
@@ -76,6 +83,7 @@ func TestHttpRequest(t *testing.T) {
 }
 
 func TestRouteParameter(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/users/:userId/orders/:orderId", func(ctx *fiber.Ctx) error {
 		userId := ctx.Params("userId")
 		orderId := ctx.Params("orderId")
@@ -91,6 +99,7 @@ func TestRouteParameter(t *testing.T) {
 }
 
 func TestFormRequest(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/hello", func(ctx *fiber.Ctx) error {
 		name := ctx.FormValue("name")
 		return ctx.SendString("Hello " + name)
@@ -110,31 +119,24 @@ func TestFormRequest(t *testing.T) {
 var contohFile []byte
 
 func TestFormUpload(t *testing.T) {
+	app := newTestApp(t)
 	app.Post("/upload", func(ctx *fiber.Ctx) error {
 		file, err := ctx.FormFile("file")
 		if err != nil {
-			panic(err)
+			return fiber.NewError(fiber.StatusBadRequest, "missing file")
 		}
 
-		err = ctx.SaveFile(file, "./target/"+file.Filename)
+		err = ctx.SaveFile(file, "./target/"+filepath.Base(file.Filename))
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		return ctx.SendString("Uploaded successfully")
 	})
 
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
-	file, err2 := writer.CreateFormFile("file", "file.txt")
-	assert.Nil(t, err2)
-	_, err2 = file.Write(contohFile)
-	assert.Nil(t, err2)
-	err := writer.Close()
-	assert.Nil(t, err)
-
-	request := httptest.NewRequest("POST", "/upload", body)
-	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request := testfactory.NewRequest(t, "POST", "/upload").
+		Multipart(testfactory.NewMultipart().File("file", "file.txt", contohFile)).
+		Build()
 	response, err := app.Test(request)
 	assert.Nil(t, err)
 	assert.Equal(t, 200, response.StatusCode)
@@ -148,13 +150,14 @@ type LoginRequest struct {
 }
 
 func TestRequestBody(t *testing.T) {
+	app := newTestApp(t)
 	app.Post("/login", func(ctx *fiber.Ctx) error {
 		body := ctx.Body()
 
 		request := new(LoginRequest)
 		err := json.Unmarshal(body, request)
 		if err != nil {
-			panic(err)
+			return fiber.NewError(fiber.StatusBadRequest, "malformed request body")
 		}
 
 		return ctx.SendString("Hello " + request.Username)
@@ -175,20 +178,22 @@ type RegisterRequest struct {
 	Password string `json:"password" form:"password" xml:"password"`
 }
 
-func TestBodyParser(t *testing.T) {
+func newRegisterApp(t testing.TB) *fiber.App {
+	app := newTestApp(t)
 	app.Post("/register", func(ctx *fiber.Ctx) error {
 		request := new(RegisterRequest)
-		err := ctx.BodyParser(request)
+		err := web.ParseBody(ctx, request)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		return ctx.SendString("Hello " + request.Username)
 	})
+	return app
 }
 
 func TestBodyParserJSON(t *testing.T) {
-	TestBodyParser(t)
+	app := newRegisterApp(t)
 
 	body := strings.NewReader(`{"username":"Brian", "password":"12345"}`)
 	request := httptest.NewRequest("POST", "/register", body)
@@ -201,11 +206,11 @@ func TestBodyParserJSON(t *testing.T) {
 }
 
 func TestBodyParserForm(t *testing.T) {
-	TestBodyParser(t)
+	app := newRegisterApp(t)
 
 	body := strings.NewReader(`username=Brian&password=12345`)
 	request := httptest.NewRequest("POST", "/register", body)
-	request.Header.Set("Content-Type", "x-www-form-urlencoded")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	response, err := app.Test(request)
 	assert.Nil(t, err)
 	assert.Equal(t, 200, response.StatusCode)
@@ -214,7 +219,7 @@ func TestBodyParserForm(t *testing.T) {
 }
 
 func TestBodyParserXML(t *testing.T) {
-	TestBodyParser(t)
+	app := newRegisterApp(t)
 
 	body := strings.NewReader(`
 		<RegisterRequest>
@@ -231,13 +236,52 @@ func TestBodyParserXML(t *testing.T) {
 	assert.Equal(t, "Hello Brian", string(bytes))
 }
 
-func TestResponseJSON(t *testing.T) {
+// FuzzBodyParser posts bodies of every content type to /register, which must
+// answer malformed ones with a client error rather than fail.
+//
+//	go test . -run '^$' -fuzz FuzzBodyParser -fuzztime 30s
+func FuzzBodyParser(f *testing.F) {
+	f.Add("application/json", `{"username":"Brian", "password":"12345"}`)
+	f.Add("application/json", `{"username":["Brian"]}`)
+	f.Add("application/xml", `<RegisterRequest><username>Brian</username></RegisterRequest>`)
+	f.Add("application/xml", `<RegisterRequest><username>`)
+	f.Add("application/x-www-form-urlencoded", `username=Brian&password=12345`)
+	f.Add("application/x-www-form-urlencoded", `username=%zz`)
+	f.Add("multipart/form-data; boundary=x", "--x\r\nContent-Disposition: form-data; name=\"username\"\r\n\r\nBrian\r\n--x--\r\n")
+	f.Add("application/x-www-form-urlencoded", `username=Brian`)
+
+	app := newRegisterApp(f)
+	f.Fuzz(func(t *testing.T, contentType, body string) {
+		request := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		response, err := app.Test(request, 2000)
+		if err != nil {
+			if strings.Contains(err.Error(), "timeout") {
+				t.Fatal(err)
+			}
+			// Refused before the handler ran, as unreadable.
+			return
+		}
+		if response.StatusCode >= 500 {
+			bytes, _ := io.ReadAll(response.Body)
+			t.Fatalf("status %d: %s", response.StatusCode, bytes)
+		}
+	})
+}
+
+func newResponseApp(t *testing.T) *fiber.App {
+	app := newTestApp(t)
 	app.Get("/user", func(ctx *fiber.Ctx) error {
-		return ctx.JSON(fiber.Map{
+		return web.Respond(ctx, fiber.StatusOK, fiber.Map{
 			"username": "Brian",
 			"password": "12345",
 		})
 	})
+	return app
+}
+
+func TestResponseJSON(t *testing.T) {
+	app := newResponseApp(t)
 
 	request := httptest.NewRequest(http.MethodGet, "/user", nil)
 	request.Header.Set("Accept", "application/json")
@@ -248,7 +292,46 @@ func TestResponseJSON(t *testing.T) {
 	assert.Equal(t, `{"password":"12345","username":"Brian"}`, string(bytes))
 }
 
+func TestResponseXML(t *testing.T) {
+	app := newResponseApp(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Set("Accept", "application/xml")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/xml", response.Header.Get("Content-Type"))
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, `<response><password>12345</password><username>Brian</username></response>`, string(bytes))
+}
+
+func TestResponseYAML(t *testing.T) {
+	app := newResponseApp(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Set("Accept", "application/yaml")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/yaml", response.Header.Get("Content-Type"))
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "password: \"12345\"\nusername: Brian\n", string(bytes))
+}
+
+func TestResponseDefaultJSON(t *testing.T) {
+	app := newResponseApp(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Set("Accept", "text/csv")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	assert.Equal(t, "Accept", response.Header.Get("Vary"))
+}
+
 func TestDownloadFile(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/download", func(ctx *fiber.Ctx) error {
 		return ctx.Download("./source/file.txt", "file.txt")
 	})
@@ -263,6 +346,7 @@ func TestDownloadFile(t *testing.T) {
 }
 
 func TestRoutingGroup(t *testing.T) {
+	app := newTestApp(t)
 	helloWorld := func(ctx *fiber.Ctx) error {
 		return ctx.SendString("Hello World")
 	}
@@ -289,6 +373,7 @@ func TestRoutingGroup(t *testing.T) {
 }
 
 func TestStatic(t *testing.T) {
+	app := newTestApp(t)
 	app.Static("/public", "./source")
 
 	request := httptest.NewRequest(http.MethodGet, "/public/file.txt", nil)
@@ -300,6 +385,7 @@ func TestStatic(t *testing.T) {
 }
 
 func TestErrorHandling(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/error", func(ctx *fiber.Ctx) error {
 		return errors.New("ups")
 	})
@@ -313,6 +399,7 @@ func TestErrorHandling(t *testing.T) {
 }
 
 func TestView(t *testing.T) {
+	app := newTestApp(t)
 	app.Get("/view", func(ctx *fiber.Ctx) error {
 		return ctx.Render("index", fiber.Map{
 			"title":   "Hello Title",
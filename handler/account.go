@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// AccountHandler serves the account of the user of the request, as
+// authenticated by an access token or an API key.
+type AccountHandler struct {
+	accounts service.AccountService
+	sessions service.SessionService
+	avatars  service.AvatarService
+	cache    middleware.CacheInvalidator
+}
+
+func NewAccountHandler(accounts service.AccountService, sessions service.SessionService, avatars service.AvatarService, cache middleware.CacheInvalidator) *AccountHandler {
+	return &AccountHandler{accounts: accounts, sessions: sessions, avatars: avatars, cache: cache}
+}
+
+// Register adds the account routes. Confirming an email change only takes
+// the token sent to the new address, so it needs no authentication.
+func (handler *AccountHandler) Register(router fiber.Router) {
+	router.Get("", authenticated, handler.Profile).Name("me.show")
+	router.Put("", authenticated, handler.UpdateProfile).Name("me.update")
+	router.Put("/password", authenticated, handler.ChangePassword).Name("me.password")
+	router.Post("/email", authenticated, handler.ChangeEmail).Name("me.email")
+	router.Post("/email/confirm", handler.ConfirmEmail).Name("me.email.confirm")
+	router.Post("/avatar", authenticated, handler.UploadAvatar).Name("me.avatar")
+	router.Delete("/avatar", authenticated, handler.DeleteAvatar).Name("me.avatar.delete")
+	router.Get("/sessions", authenticated, handler.Sessions).Name("me.sessions")
+	router.Delete("/sessions/:id", authenticated, handler.RevokeSession).Name("me.sessions.revoke")
+}
+
+func (handler *AccountHandler) Profile(ctx *fiber.Ctx) error {
+	user, err := handler.accounts.Profile(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return userError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, user)
+}
+
+func (handler *AccountHandler) UpdateProfile(ctx *fiber.Ctx) error {
+	request := new(model.UpdateProfileRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	user, err := handler.accounts.UpdateProfile(ctx.UserContext(), userID(ctx), request)
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+user.ID)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, user)
+}
+
+// ChangePassword also revokes the other sessions of the user.
+func (handler *AccountHandler) ChangePassword(ctx *fiber.Ctx) error {
+	request := new(model.ChangePasswordRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	sessionID, _ := ctx.Locals("session_id").(string)
+	err = handler.accounts.ChangePassword(ctx.UserContext(), userID(ctx), sessionID, request)
+	if err != nil {
+		return userError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ChangeEmail sends a token to the new email, which only replaces the email
+// of the user once confirmed with it.
+func (handler *AccountHandler) ChangeEmail(ctx *fiber.Ctx) error {
+	request := new(model.ChangeEmailRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	err = handler.accounts.RequestEmailChange(ctx.UserContext(), userID(ctx), request)
+	if err != nil {
+		return userError(err)
+	}
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+func (handler *AccountHandler) ConfirmEmail(ctx *fiber.Ctx) error {
+	request := new(model.ConfirmEmailRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	user, err := handler.accounts.ConfirmEmailChange(ctx.UserContext(), request)
+	if errors.Is(err, model.ErrEmailChangeNotFound) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+user.ID)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, user)
+}
+
+// UploadAvatar replaces the avatar of the user with the image of the
+// multipart "avatar" field. The avatar field of the user returned names the
+// new version, for the avatar URL to change along.
+func (handler *AccountHandler) UploadAvatar(ctx *fiber.Ctx) error {
+	header, err := ctx.FormFile("avatar")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "missing avatar")
+	}
+	content, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	user, err := handler.avatars.Upload(ctx.UserContext(), userID(ctx), header.Size, content)
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+user.ID)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, user)
+}
+
+func (handler *AccountHandler) DeleteAvatar(ctx *fiber.Ctx) error {
+	err := handler.avatars.Delete(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+userID(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// Sessions lists the sessions of the user that have not expired, marking the
+// one of the request as current.
+func (handler *AccountHandler) Sessions(ctx *fiber.Ctx) error {
+	sessions, err := handler.sessions.List(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return err
+	}
+	sessionID, _ := ctx.Locals("session_id").(string)
+	for _, session := range sessions {
+		session.Current = session.ID == sessionID
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"data": sessions})
+}
+
+// RevokeSession logs the session out: its tokens are refused from then on.
+func (handler *AccountHandler) RevokeSession(ctx *fiber.Ctx) error {
+	err := handler.sessions.Revoke(ctx.UserContext(), userID(ctx), ctx.Params("id"))
+	if errors.Is(err, model.ErrSessionNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func userID(ctx *fiber.Ctx) string {
+	id, _ := ctx.Locals("user_id").(string)
+	return id
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/testfactory"
+	"golang-fiber-web/token"
+	"golang-fiber-web/web"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type accountTest struct {
+	app       *fiber.App
+	users     repository.UserRepository
+	tokens    *token.Keys
+	sessions  service.SessionService
+	published *recordingPublisher
+}
+
+func newAccountTest(t *testing.T) *accountTest {
+	test := &accountTest{users: repository.NewMemoryUserRepository(), published: &recordingPublisher{}}
+	audit := service.NewAuditService(repository.NewMemoryAuditRepository())
+	transactor := repository.NewMemoryTransactor()
+	jwtConfig := config.Default().JWT
+	test.tokens = token.NewKeys(jwtConfig, repository.NewMemorySigningKeyRepository())
+	assert.Nil(t, test.tokens.Rotate(context.Background()))
+	test.sessions = service.NewSessionService(repository.NewMemorySessionRepository(), audit, jwtConfig.TTL)
+	userService := service.NewUserService(test.users, transactor, audit, event.Discard)
+	accounts := service.NewAccountService(test.users, userService, test.sessions, repository.NewMemoryEmailChangeRepository(), transactor, audit, test.published)
+	avatars := service.NewAvatarService(config.Default().Avatars, test.users, storage.NewLocal(t.TempDir()), transactor, audit, event.Discard)
+
+	test.app = fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	test.app.Use(middleware.NewTokenAuth(test.tokens, test.sessions))
+	Mount(test.app, "/me", NewAccountHandler(accounts, test.sessions, avatars, &cacheInvalidatorMock{}))
+	Mount(test.app, "/users", NewUserHandler(userService, avatars, &cacheInvalidatorMock{}, config.AdminConfig{Username: "admin", Password: "secret"}))
+	return test
+}
+
+// login starts a session of the user and returns its access token.
+func (test *accountTest) login(t *testing.T, userID string) string {
+	return testfactory.Login(t, test.tokens, test.sessions, userID)
+}
+
+func (test *accountTest) request(t *testing.T, method, target, accessToken, body string) *http.Response {
+	builder := testfactory.NewRequest(t, method, target).JSON(body)
+	if accessToken != "" {
+		builder.Bearer(accessToken)
+	}
+	request := builder.Build()
+	// No timeout: hashing the passwords takes longer than the default second
+	// on a busy machine.
+	response, err := test.app.Test(request, -1)
+	require.NoError(t, err)
+	return response
+}
+
+func (test *accountTest) createUser(t *testing.T) *model.User {
+	return testfactory.CreateUser(t, test.users, func(user *model.User) {
+		user.Username = "brian"
+		user.Email = "brian@example.com"
+	})
+}
+
+func TestAccountRequiresAuthentication(t *testing.T) {
+	test := newAccountTest(t)
+
+	response := test.request(t, http.MethodGet, "/me", "", "")
+	assert.Equal(t, 401, response.StatusCode)
+	assert.Equal(t, "Bearer", response.Header.Get("WWW-Authenticate"))
+
+	response = test.request(t, http.MethodGet, "/me", "not-a-token", "")
+	assert.Equal(t, 401, response.StatusCode)
+	assert.Equal(t, `Bearer error="invalid_token"`, response.Header.Get("WWW-Authenticate"))
+}
+
+func TestAccountProfile(t *testing.T) {
+	test := newAccountTest(t)
+	user := test.createUser(t)
+	accessToken := test.login(t, user.ID)
+
+	response := test.request(t, http.MethodGet, "/me", accessToken, "")
+	assert.Equal(t, 200, response.StatusCode)
+	var profile model.User
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&profile))
+	assert.Equal(t, "brian", profile.Username)
+
+	response = test.request(t, http.MethodPut, "/me", accessToken, `{"username":"brian2","name":"Brian Anashari","email":"other@example.com"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&profile))
+	assert.Equal(t, "brian2", profile.Username)
+	assert.Equal(t, "Brian Anashari", profile.Name)
+	assert.Equal(t, "brian@example.com", profile.Email)
+
+	response = test.request(t, http.MethodPut, "/me", accessToken, `{"username":"br"}`)
+	assert.Equal(t, 422, response.StatusCode)
+}
+
+func TestAccountChangePassword(t *testing.T) {
+	test := newAccountTest(t)
+	user := test.createUser(t)
+	accessToken := test.login(t, user.ID)
+	otherToken := test.login(t, user.ID)
+
+	response := test.request(t, http.MethodPut, "/me/password", accessToken, `{"new_password":"short"}`)
+	assert.Equal(t, 422, response.StatusCode)
+
+	response = test.request(t, http.MethodPut, "/me/password", accessToken, `{"new_password":"correct horse"}`)
+	assert.Equal(t, 204, response.StatusCode)
+	saved, err := test.users.FindByID(context.Background(), user.ID)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, saved.PasswordHash)
+	assert.NotContains(t, saved.PasswordHash, "correct horse")
+
+	response = test.request(t, http.MethodGet, "/me", otherToken, "")
+	assert.Equal(t, 401, response.StatusCode, "the other sessions are revoked")
+
+	response = test.request(t, http.MethodPut, "/me/password", accessToken, `{"new_password":"battery staple"}`)
+	assert.Equal(t, 422, response.StatusCode, "the current password is required once set")
+	response = test.request(t, http.MethodPut, "/me/password", accessToken, `{"current_password":"wrong horse","new_password":"battery staple"}`)
+	assert.Equal(t, 422, response.StatusCode)
+	response = test.request(t, http.MethodPut, "/me/password", accessToken, `{"current_password":"correct horse","new_password":"battery staple"}`)
+	assert.Equal(t, 204, response.StatusCode)
+}
+
+func TestAccountChangeEmail(t *testing.T) {
+	test := newAccountTest(t)
+	user := test.createUser(t)
+	assert.Nil(t, test.users.Create(context.Background(), &model.User{Username: "taken", Email: "taken@example.com"}))
+	accessToken := test.login(t, user.ID)
+
+	response := test.request(t, http.MethodPost, "/me/email", accessToken, `{"email":"taken@example.com"}`)
+	assert.Equal(t, 422, response.StatusCode)
+
+	response = test.request(t, http.MethodPost, "/me/email", accessToken, `{"email":"new@example.com"}`)
+	assert.Equal(t, 202, response.StatusCode)
+	assert.Len(t, test.published.events, 1)
+	requested := test.published.events[0].(event.UserEmailChangeRequested)
+	assert.Equal(t, user.ID, requested.UserID)
+	assert.Equal(t, "new@example.com", requested.Email)
+
+	saved, err := test.users.FindByID(context.Background(), user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "brian@example.com", saved.Email, "the email only changes once confirmed")
+
+	response = test.request(t, http.MethodPost, "/me/email/confirm", "", `{"token":"wrong"}`)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = test.request(t, http.MethodPost, "/me/email/confirm", "", `{"token":"`+requested.Token+`"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	var confirmed model.User
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&confirmed))
+	assert.Equal(t, "new@example.com", confirmed.Email)
+
+	response = test.request(t, http.MethodPost, "/me/email/confirm", "", `{"token":"`+requested.Token+`"}`)
+	assert.Equal(t, 400, response.StatusCode, "the token is used up")
+}
+
+func TestAccountSessions(t *testing.T) {
+	test := newAccountTest(t)
+	user := test.createUser(t)
+	other := &model.User{Username: "other"}
+	assert.Nil(t, test.users.Create(context.Background(), other))
+	accessToken := test.login(t, user.ID)
+	otherToken := test.login(t, user.ID)
+	test.login(t, other.ID)
+
+	response := test.request(t, http.MethodGet, "/me/sessions", accessToken, "")
+	assert.Equal(t, 200, response.StatusCode)
+	var body struct {
+		Data []model.Session `json:"data"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 2)
+	var current, revoked string
+	for _, session := range body.Data {
+		assert.Equal(t, user.ID, session.UserID)
+		if session.Current {
+			current = session.ID
+		} else {
+			revoked = session.ID
+		}
+	}
+	assert.NotEmpty(t, current)
+	assert.NotEmpty(t, revoked)
+
+	response = test.request(t, http.MethodDelete, "/me/sessions/"+revoked, accessToken, "")
+	assert.Equal(t, 204, response.StatusCode)
+	response = test.request(t, http.MethodGet, "/me", otherToken, "")
+	assert.Equal(t, 401, response.StatusCode)
+	response = test.request(t, http.MethodDelete, "/me/sessions/"+revoked, accessToken, "")
+	assert.Equal(t, 404, response.StatusCode)
+
+	otherSessions, err := test.sessions.List(context.Background(), other.ID)
+	assert.Nil(t, err)
+	response = test.request(t, http.MethodDelete, "/me/sessions/"+otherSessions[0].ID, accessToken, "")
+	assert.Equal(t, 404, response.StatusCode, "the sessions of other users cannot be revoked")
+}
+
+func (test *accountTest) uploadAvatar(t *testing.T, accessToken string, content []byte) *http.Response {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("avatar", "avatar.png")
+	assert.Nil(t, err)
+	part.Write(content)
+	assert.Nil(t, writer.Close())
+
+	request := httptest.NewRequest(http.MethodPost, "/me/avatar", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	response, err := test.app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func TestAccountAvatar(t *testing.T) {
+	test := newAccountTest(t)
+	user := test.createUser(t)
+	accessToken := test.login(t, user.ID)
+
+	response := test.request(t, http.MethodGet, "/users/"+user.ID+"/avatar", "", "")
+	assert.Equal(t, 404, response.StatusCode)
+	response = test.uploadAvatar(t, accessToken, []byte("not an image"))
+	assert.Equal(t, 422, response.StatusCode)
+
+	var content bytes.Buffer
+	assert.Nil(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 300, 200))))
+	response = test.uploadAvatar(t, accessToken, content.Bytes())
+	assert.Equal(t, 200, response.StatusCode)
+	var uploaded model.User
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&uploaded))
+	assert.NotEmpty(t, uploaded.Avatar)
+
+	response = test.request(t, http.MethodGet, "/users/"+user.ID+"/avatar?size=100", "", "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/png", response.Header.Get("Content-Type"))
+	assert.Equal(t, "public, max-age=300", response.Header.Get("Cache-Control"))
+	assert.Equal(t, `"`+uploaded.Avatar+`-128"`, response.Header.Get("ETag"))
+	avatar, err := png.Decode(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 128, 128), avatar.Bounds(), "the size is rounded up to a stored one")
+
+	request := httptest.NewRequest(http.MethodGet, "/users/"+user.ID+"/avatar?size=100&v="+uploaded.Avatar, nil)
+	request.Header.Set("If-None-Match", `"`+uploaded.Avatar+`-128"`)
+	response, err = test.app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+	assert.Equal(t, "public, max-age=31536000, immutable", response.Header.Get("Cache-Control"))
+
+	response = test.request(t, http.MethodGet, "/users/"+user.ID+"/avatar?size=1000", "", "")
+	assert.Equal(t, 200, response.StatusCode)
+	avatar, err = png.Decode(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 256), avatar.Bounds())
+
+	response = test.request(t, http.MethodDelete, "/me/avatar", accessToken, "")
+	assert.Equal(t, 204, response.StatusCode)
+	response = test.request(t, http.MethodGet, "/users/"+user.ID+"/avatar", "", "")
+	assert.Equal(t, 404, response.StatusCode)
+}
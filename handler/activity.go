@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+var activityListOptions = web.ListOptions{
+	Sortable:   []string{"created_at", "kind"},
+	Filterable: []string{"kind", "resource", "resource_id"},
+	Searchable: true,
+}
+
+// ActivityHandler serves the timelines of the users: the whole one of the
+// current user at /me/activity, and the one of any user at
+// /users/:userId/activity, public activities only unless it is their own.
+type ActivityHandler struct {
+	activities service.ActivityService
+}
+
+func NewActivityHandler(activities service.ActivityService) *ActivityHandler {
+	return &ActivityHandler{activities: activities}
+}
+
+func (handler *ActivityHandler) Register(router fiber.Router) {
+	router.Get("/me/activity", authenticated, handler.Mine).Name("activity.mine")
+	router.Get("/users/:userId/activity", handler.List).Name("activity.list")
+}
+
+func (handler *ActivityHandler) Mine(ctx *fiber.Ctx) error {
+	return handler.list(ctx, userID(ctx))
+}
+
+func (handler *ActivityHandler) List(ctx *fiber.Ctx) error {
+	return handler.list(ctx, ctx.Params("userId"))
+}
+
+// list lists the activities of the user, newest first, paginated with
+// ?cursor as the other lists are.
+func (handler *ActivityHandler) list(ctx *fiber.Ctx, id string) error {
+	spec, err := web.ParseListSpec(ctx, activityListOptions)
+	if err != nil {
+		return err
+	}
+	activities, total, err := handler.activities.List(ctx.UserContext(), id, userID(ctx), spec)
+	if err != nil {
+		return userError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       activities,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"net/http"
+	"testing"
+)
+
+func TestActivity(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "alice", Email: "alice@example.com", Name: "Alice"}
+	assert.Nil(t, users.Create(ctx, user))
+	activities := service.NewActivityService(users, repository.NewMemoryActivityRepository())
+	assert.Nil(t, activities.HandleEvent(ctx, event.UserRegistered{User: user}))
+	for _, id := range []string{"order-1", "order-2"} {
+		assert.Nil(t, activities.HandleEvent(ctx, event.OrderPlaced{Order: &model.Order{ID: id, UserID: user.ID}}))
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	Mount(app, "", NewActivityHandler(activities))
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodGet, "/me/activity", "", "").StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/users/missing/activity", "", "").StatusCode)
+
+	type page struct {
+		Data       []*model.Activity `json:"data"`
+		Pagination web.Pagination    `json:"pagination"`
+	}
+	body := page{}
+	response := fileRequest(t, app, http.MethodGet, "/me/activity?per_page=2&cursor", user.ID, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 2)
+	assert.NotEmpty(t, body.Pagination.NextCursor)
+
+	response = fileRequest(t, app, http.MethodGet, "/me/activity?per_page=2&cursor="+body.Pagination.NextCursor, user.ID, "")
+	body = page{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 1)
+	assert.Empty(t, body.Pagination.NextCursor)
+
+	response = fileRequest(t, app, http.MethodGet, "/users/"+user.ID+"/activity?filter[resource]=order", user.ID, "")
+	body = page{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 2)
+
+	response = fileRequest(t, app, http.MethodGet, "/users/"+user.ID+"/activity", "2", "")
+	body = page{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, event.NameUserRegistered, body.Data[0].Kind)
+}
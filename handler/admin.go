@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const adminRecentAuditEvents = 10
+
+var adminUserListOptions = web.ListOptions{
+	DefaultPerPage: 25,
+	Sortable:       []string{"username", "email", "name", "created_at"},
+	Searchable:     true,
+}
+
+// AdminHandler serves the server-rendered admin pages. The views get plain
+// maps of strings so the mustache and html templates stay free of logic.
+type AdminHandler struct {
+	config *config.Config
+	users  service.UserService
+	audit  service.AuditService
+	queue  service.QueueService
+}
+
+func NewAdminHandler(appConfig *config.Config, users service.UserService, audit service.AuditService, queue service.QueueService) *AdminHandler {
+	return &AdminHandler{config: appConfig, users: users, audit: audit, queue: queue}
+}
+
+// Register adds the dashboard, users and config pages behind the admin basic
+// auth. The handler is meant to be mounted at /admin, after the other admin
+// modules, whose routes it links to.
+func (handler *AdminHandler) Register(router fiber.Router) {
+	router.Use(middleware.NewAdminAuth(handler.config.Admin))
+
+	hints := handler.config.Server.EarlyHints
+	router.Get("", middleware.NewEarlyHints(hints, "admin/dashboard"), handler.Dashboard).Name("admin.dashboard")
+	router.Get("/users", middleware.NewEarlyHints(hints, "admin/users"), handler.Users).Name("admin.users")
+	router.Get("/config", middleware.NewEarlyHints(hints, "admin/config"), handler.Config).Name("admin.config")
+}
+
+func (handler *AdminHandler) Dashboard(ctx *fiber.Ctx) error {
+	status, err := handler.queue.Status(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	_, users, err := handler.users.List(ctx.UserContext(), &model.ListSpec{Page: 1, PerPage: 1})
+	if err != nil {
+		return err
+	}
+	events, _, err := handler.audit.List(ctx.UserContext(), &model.ListSpec{
+		Page:    1,
+		PerPage: adminRecentAuditEvents,
+		Sort:    []model.SortField{{Field: "created_at", Desc: true}},
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]fiber.Map, 0, len(status.Deliveries))
+	for deliveryStatus, count := range status.Deliveries {
+		deliveries = append(deliveries, fiber.Map{"status": deliveryStatus, "count": count})
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i]["status"].(string) < deliveries[j]["status"].(string)
+	})
+
+	recent := make([]fiber.Map, len(events))
+	for i, event := range events {
+		recent[i] = fiber.Map{
+			"created_at":  event.CreatedAt.UTC().Format(time.RFC3339),
+			"actor":       event.Actor,
+			"action":      event.Action,
+			"resource":    event.Resource,
+			"resource_id": event.ResourceID,
+		}
+	}
+
+	return ctx.Render("admin/dashboard", fiber.Map{
+		"title":         "Admin",
+		"users":         users,
+		"outboxPending": status.OutboxPending,
+		"deliveries":    deliveries,
+		"events":        recent,
+	})
+}
+
+func (handler *AdminHandler) Users(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, adminUserListOptions)
+	if err != nil {
+		return err
+	}
+	users, total, err := handler.users.List(ctx.UserContext(), spec)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]fiber.Map, len(users))
+	for i, user := range users {
+		rows[i] = fiber.Map{
+			"id":         user.ID,
+			"username":   user.Username,
+			"email":      user.Email,
+			"name":       user.Name,
+			"created_at": user.CreatedAt.UTC().Format(time.RFC3339),
+		}
+	}
+
+	totalPages := max((total+spec.PerPage-1)/spec.PerPage, 1)
+	data := fiber.Map{
+		"title":      "Users",
+		"q":          spec.Search,
+		"users":      rows,
+		"total":      total,
+		"page":       spec.Page,
+		"totalPages": totalPages,
+	}
+	if spec.Page > 1 {
+		data["prevURL"] = adminPageURL(ctx, spec.Page-1)
+	}
+	if spec.Page < totalPages {
+		data["nextURL"] = adminPageURL(ctx, spec.Page+1)
+	}
+	return ctx.Render("admin/users", data)
+}
+
+// Config shows the running config as YAML, with its secrets redacted.
+func (handler *AdminHandler) Config(ctx *fiber.Ctx) error {
+	data, err := yaml.Marshal(handler.config.Redacted())
+	if err != nil {
+		return err
+	}
+	return ctx.Render("admin/config", fiber.Map{
+		"title":  "Config",
+		"config": string(data),
+	})
+}
+
+// adminPageURL is the current URL with its page replaced, keeping the search.
+func adminPageURL(ctx *fiber.Ctx, page int) string {
+	query, _ := url.ParseQuery(string(ctx.Context().QueryArgs().QueryString()))
+	query.Set("page", strconv.Itoa(page))
+	return ctx.Path() + "?" + query.Encode()
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAnalyticsPeriod = time.Hour * 24
+	defaultTopConsumers    = 10
+)
+
+// AnalyticsHandler shows the usage statistics recorded by the analytics
+// recorder, as an admin page and as JSON.
+type AnalyticsHandler struct {
+	config    config.AdminConfig
+	hints     config.EarlyHintsConfig
+	analytics service.AnalyticsService
+}
+
+func NewAnalyticsHandler(config config.AdminConfig, hints config.EarlyHintsConfig, analytics service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{config: config, hints: hints, analytics: analytics}
+}
+
+// Register adds the page and the report behind basic auth. The handler is
+// meant to be mounted at /admin/analytics.
+func (handler *AnalyticsHandler) Register(router fiber.Router) {
+	router.Use(middleware.NewAdminAuth(handler.config))
+
+	router.Get("", middleware.NewEarlyHints(handler.hints, "admin/analytics"), handler.Page).Name("analytics.page")
+	router.Get("/report", handler.Report).Name("analytics.report")
+}
+
+// Report answers the usage report of the period between the from and to
+// query parameters, in RFC 3339, by default the last day, with the top
+// consumers up to the top parameter.
+func (handler *AnalyticsHandler) Report(ctx *fiber.Ctx) error {
+	report, err := handler.report(ctx)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, report)
+}
+
+// Page renders the report of Report. The views get plain maps of strings, as
+// in the other admin pages.
+func (handler *AnalyticsHandler) Page(ctx *fiber.Ctx) error {
+	report, err := handler.report(ctx)
+	if err != nil {
+		return err
+	}
+
+	trend := make([]fiber.Map, len(report.Trend))
+	for i, row := range report.Trend {
+		trend[i] = fiber.Map{
+			"bucket":     row.Bucket.UTC().Format(time.RFC3339),
+			"requests":   row.Requests,
+			"error_rate": formatRate(row.ErrorRate),
+		}
+	}
+	return ctx.Render("admin/analytics", fiber.Map{
+		"title":     "Analytics",
+		"from":      report.From.UTC().Format(time.RFC3339),
+		"to":        report.To.UTC().Format(time.RFC3339),
+		"routes":    usageRows(report.Routes),
+		"consumers": usageRows(report.Consumers),
+		"trend":     trend,
+	})
+}
+
+func (handler *AnalyticsHandler) report(ctx *fiber.Ctx) (*model.UsageReport, error) {
+	to := time.Now()
+	if ctx.Query("to") != "" {
+		parsed, err := time.Parse(time.RFC3339, ctx.Query("to"))
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "to must be an RFC 3339 time")
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultAnalyticsPeriod)
+	if ctx.Query("from") != "" {
+		parsed, err := time.Parse(time.RFC3339, ctx.Query("from"))
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "from must be an RFC 3339 time")
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "from must be before to")
+	}
+	top := ctx.QueryInt("top", defaultTopConsumers)
+	if top < 1 || top > 100 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "top must be between 1 and 100")
+	}
+	return handler.analytics.Report(ctx.UserContext(), from, to, top)
+}
+
+func usageRows(summaries []model.UsageSummary) []fiber.Map {
+	rows := make([]fiber.Map, len(summaries))
+	for i, summary := range summaries {
+		rows[i] = fiber.Map{
+			"name":              summary.Name,
+			"requests":          summary.Requests,
+			"error_rate":        formatRate(summary.ErrorRate),
+			"client_error_rate": formatRate(summary.ClientErrorRate),
+			"average_latency":   summary.AverageLatency.Round(time.Microsecond).String(),
+			"max_latency":       summary.MaxLatency.Round(time.Microsecond).String(),
+		}
+	}
+	return rows
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', 1, 64) + "%"
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/views"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAnalyticsHandler(t *testing.T) {
+	usage := repository.NewMemoryUsageRepository()
+	bucket := time.Now().UTC().Truncate(time.Hour)
+	assert.Nil(t, usage.Add(context.Background(), []*model.UsageStat{
+		{Bucket: bucket.Add(-time.Hour), Method: "GET", Route: "/users", Consumer: "api_key:1", Requests: 8, Status2xx: 8, LatencyTotal: time.Millisecond * 80, LatencyMax: time.Millisecond * 20},
+		{Bucket: bucket, Method: "GET", Route: "/users", Consumer: "api_key:1", Requests: 2, Status2xx: 1, Status5xx: 1, LatencyTotal: time.Millisecond * 20, LatencyMax: time.Millisecond * 15},
+		{Bucket: bucket, Method: "POST", Route: "/upload", Consumer: "anonymous", Requests: 4, Status2xx: 2, Status4xx: 2, LatencyTotal: time.Millisecond * 4, LatencyMax: time.Millisecond * 2},
+	}))
+	engine, err := views.New(config.ServerConfig{ViewsEmbedded: true})
+	assert.Nil(t, err)
+	analyticsApp := fiber.New()
+	MountApp(analyticsApp, "/admin/analytics", NewAnalyticsHandler(config.AdminConfig{Username: "admin", Password: "secret"}, config.EarlyHintsConfig{}, service.NewAnalyticsService(usage)),
+		fiber.Config{Views: engine, ViewsLayout: "layouts/main"})
+
+	response, err := analyticsApp.Test(webhookRequest(http.MethodGet, "/admin/analytics/report?top=1", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	report := &model.UsageReport{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(report))
+	assert.Equal(t, []model.UsageSummary{
+		{Name: "GET /users", Requests: 10, ErrorRate: 0.1, AverageLatency: time.Millisecond * 10, MaxLatency: time.Millisecond * 20},
+		{Name: "POST /upload", Requests: 4, ClientErrorRate: 0.5, AverageLatency: time.Millisecond, MaxLatency: time.Millisecond * 2},
+	}, report.Routes)
+	assert.Len(t, report.Consumers, 1)
+	assert.Equal(t, "api_key:1", report.Consumers[0].Name)
+	assert.Len(t, report.Trend, 2)
+	assert.Equal(t, 6, report.Trend[1].Requests)
+	assert.InDelta(t, 1.0/6, report.Trend[1].ErrorRate, 0.001)
+
+	response, err = analyticsApp.Test(webhookRequest(http.MethodGet, "/admin/analytics/report?from=yesterday", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	body := adminPage(t, analyticsApp, "/admin/analytics")
+	assert.Contains(t, body, "<td>GET /users</td><td>10</td><td>10.0%</td>")
+	assert.Contains(t, body, "<td>anonymous</td>")
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// createdAPIKey shows the value of an API key, which is only done once.
+type createdAPIKey struct {
+	*model.APIKey
+	Key string `json:"key" xml:"key" yaml:"key"`
+}
+
+type APIKeyHandler struct {
+	config config.AdminConfig
+	keys   service.APIKeyService
+}
+
+func NewAPIKeyHandler(config config.AdminConfig, keys service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{config: config, keys: keys}
+}
+
+// Register adds the API key management routes behind basic auth. The handler
+// is meant to be mounted at /admin/api-keys.
+func (handler *APIKeyHandler) Register(router fiber.Router) {
+	router.Use(middleware.NewAdminAuth(handler.config))
+
+	router.Get("", handler.List).Name("api_keys.list")
+	router.Post("", handler.Create).Name("api_keys.create")
+	router.Delete("/:id", handler.Revoke).Name("api_keys.revoke")
+}
+
+// List lists the keys of the user of the user_id query parameter.
+func (handler *APIKeyHandler) List(ctx *fiber.Ctx) error {
+	userID := ctx.Query("user_id")
+	if userID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "user_id is required")
+	}
+	keys, err := handler.keys.List(ctx.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"data": keys})
+}
+
+func (handler *APIKeyHandler) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateAPIKeyRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	key, value, err := handler.keys.Create(ctx.UserContext(), request)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusCreated, createdAPIKey{APIKey: key, Key: value})
+}
+
+func (handler *APIKeyHandler) Revoke(ctx *fiber.Ctx) error {
+	err := handler.keys.Revoke(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, model.ErrAPIKeyNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIKeyHandler(t *testing.T) {
+	repositories := repository.NewMemoryRepositories()
+	user := &model.User{Username: "brian"}
+	assert.Nil(t, repositories.Users.Create(context.Background(), user))
+	keys := service.NewAPIKeyService(repositories.APIKeys, repositories.Users, service.NewAuditService(repositories.Audit))
+	appConfig := config.Default()
+	appConfig.Quotas.APIKey = config.QuotaLimits{Daily: 5}
+	live := config.NewLive(appConfig)
+
+	keyApp := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	keyApp.Use(middleware.NewAPIKeyAuth(keys))
+	MountApp(keyApp, "/admin/api-keys", NewAPIKeyHandler(config.AdminConfig{Username: "admin", Password: "secret"}, keys),
+		fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	MountApp(keyApp, "/quota", NewQuotaHandler(live, service.NewQuotaService(repositories.Quotas)),
+		fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+
+	response, err := keyApp.Test(webhookRequest(http.MethodPost, "/admin/api-keys", `{"user_id":"unknown","name":"ci"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+
+	response, err = keyApp.Test(webhookRequest(http.MethodPost, "/admin/api-keys", `{"user_id":"`+user.ID+`","name":"ci"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	var created struct {
+		ID     string `json:"id"`
+		Key    string `json:"key"`
+		Prefix string `json:"prefix"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&created))
+	assert.Contains(t, created.Key, created.Prefix)
+
+	response, err = keyApp.Test(httptest.NewRequest(http.MethodGet, "/quota", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	request := httptest.NewRequest(http.MethodGet, "/quota", nil)
+	request.Header.Set("X-API-Key", created.Key)
+	response, err = keyApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var quota struct {
+		Data []struct {
+			Subject   string `json:"subject"`
+			Remaining int    `json:"remaining"`
+		} `json:"data"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&quota))
+	assert.Len(t, quota.Data, 1)
+	assert.Equal(t, "api_key:"+created.ID, quota.Data[0].Subject)
+	assert.Equal(t, 5, quota.Data[0].Remaining)
+
+	response, err = keyApp.Test(webhookRequest(http.MethodDelete, "/admin/api-keys/"+created.ID, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 204, response.StatusCode)
+	response, err = keyApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+}
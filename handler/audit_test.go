@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newAuditApp(t *testing.T) *fiber.App {
+	audit := service.NewAuditService(repository.NewMemoryAuditRepository())
+	for _, id := range []string{"1", "2"} {
+		assert.Nil(t, audit.Record(context.Background(), "user.update", "user", id, &model.User{ID: id}, &model.User{ID: id, Name: "Brian"}))
+	}
+	assert.Nil(t, audit.Record(context.Background(), "user.create", "user", "3", nil, &model.User{ID: "3"}))
+
+	auditApp := fiber.New()
+	MountApp(auditApp, "/admin/audit", NewAuditHandler(config.AdminConfig{Username: "admin", Password: "secret"}, audit), fiber.Config{})
+	return auditApp
+}
+
+func auditRequest(target string) *http.Request {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request.SetBasicAuth("admin", "secret")
+	return request
+}
+
+func TestAuditList(t *testing.T) {
+	auditApp := newAuditApp(t)
+
+	response, err := auditApp.Test(httptest.NewRequest(http.MethodGet, "/admin/audit", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	response, err = auditApp.Test(auditRequest("/admin/audit?filter[action]=user.update&sort=created_at"))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var body struct {
+		Data []model.AuditEvent `json:"data"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 2)
+	assert.Equal(t, "1", body.Data[0].ResourceID)
+	assert.Equal(t, model.Change{Before: "", After: "Brian"}, body.Data[0].Changes["name"])
+}
+
+func TestAuditExport(t *testing.T) {
+	auditApp := newAuditApp(t)
+
+	response, err := auditApp.Test(auditRequest("/admin/audit/export?filter[resource_id]=3"))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Contains(t, response.Header.Get("Content-Type"), "text/csv")
+	assert.Contains(t, response.Header.Get("Content-Disposition"), ".csv")
+	records, err := csv.NewReader(response.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, auditCSVHeader, records[0])
+	assert.Equal(t, "user.create", records[1][3])
+
+	response, err = auditApp.Test(auditRequest("/admin/audit/export?format=json"))
+	assert.Nil(t, err)
+	var events []model.AuditEvent
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&events))
+	assert.Len(t, events, 3)
+}
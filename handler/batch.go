@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/web"
+	"strings"
+)
+
+// The modes of a batch. An atomic batch runs in one database transaction,
+// which its first failed operation rolls back.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// errBatchFailed rolls back the transaction of an atomic batch an operation
+// of which failed.
+var errBatchFailed = errors.New("batch operation failed")
+
+// batchOperationKey marks the requests of the operations of a batch, which
+// cannot run batches themselves whatever path reaches the batch route.
+const batchOperationKey = "batchOperation"
+
+// BatchRequest is a list of requests to the API run in one round trip.
+type BatchRequest struct {
+	Mode       string           `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BatchOperation struct {
+	Method  string            `json:"method" validate:"required,oneof=GET POST PUT PATCH DELETE"`
+	Path    string            `json:"path" validate:"required,startswith=/"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResult is the response to one operation. Body holds JSON responses as
+// is and anything else as a string. Operations skipped after a failure in
+// atomic mode have status 424 Failed Dependency; the ones that ran before it
+// keep their status, although the batch response reports them rolled back.
+type BatchResult struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+type BatchHandler struct {
+	transactor repository.Transactor
+}
+
+func NewBatchHandler(transactor repository.Transactor) *BatchHandler {
+	return &BatchHandler{transactor: transactor}
+}
+
+func (handler *BatchHandler) Register(router fiber.Router) {
+	router.Post("", handler.Batch).Name("batch")
+}
+
+// Batch runs the operations in order through the whole app, middleware
+// included, with the headers of the batch request plus the operation's own.
+// In atomic mode, the default, the operations run in one transaction, which
+// the first operation that fails stops and rolls back; in best_effort mode
+// each operation runs on its own, regardless of the others. The memory
+// repositories apply every change at once, so nothing is rolled back with
+// them.
+func (handler *BatchHandler) Batch(ctx *fiber.Ctx) error {
+	if ctx.Context().UserValue(batchOperationKey) != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "batches cannot be nested")
+	}
+	request := new(BatchRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	err = model.ValidateStruct(request)
+	if err != nil {
+		return err
+	}
+	for _, operation := range request.Operations {
+		prefix := ctx.Route().Path
+		if len(operation.Path) >= len(prefix) && strings.EqualFold(operation.Path[:len(prefix)], prefix) {
+			return fiber.NewError(fiber.StatusBadRequest, "batches cannot be nested")
+		}
+	}
+
+	results := make([]BatchResult, len(request.Operations))
+	if request.Mode == BatchModeBestEffort {
+		for i, operation := range request.Operations {
+			results[i] = handler.run(ctx, ctx.UserContext(), operation)
+		}
+		return web.Respond(ctx, fiber.StatusOK, fiber.Map{"results": results})
+	}
+
+	err = handler.transactor.Transaction(ctx.UserContext(), func(txContext context.Context) error {
+		for i, operation := range request.Operations {
+			results[i] = handler.run(ctx, txContext, operation)
+			if results[i].Status >= fiber.StatusBadRequest {
+				for j := i + 1; j < len(results); j++ {
+					results[j] = BatchResult{Status: fiber.StatusFailedDependency}
+				}
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFailed) {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"results": results, "rolled_back": err != nil})
+}
+
+// run runs operation with userContext as the user context of its request, so
+// that it takes part in the transaction of an atomic batch.
+func (handler *BatchHandler) run(ctx *fiber.Ctx, userContext context.Context, operation BatchOperation) BatchResult {
+	request := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(request)
+	ctx.Request().Header.CopyTo(&request.Header)
+	request.Header.Del(fiber.HeaderContentLength)
+	request.Header.Del(fiber.HeaderContentType)
+	request.Header.Del(fiber.HeaderXRequestID)
+	request.Header.SetMethod(operation.Method)
+	request.SetRequestURI(operation.Path)
+	if len(operation.Body) > 0 {
+		request.Header.SetContentType(fiber.MIMEApplicationJSON)
+		request.SetBody(operation.Body)
+	}
+	for name, value := range operation.Headers {
+		request.Header.Set(name, value)
+	}
+
+	var subCtx fasthttp.RequestCtx
+	subCtx.Init(request, ctx.Context().RemoteAddr(), nil)
+	subCtx.SetUserValue(batchOperationKey, true)
+	operationCtx := ctx.App().AcquireCtx(&subCtx)
+	operationCtx.SetUserContext(userContext)
+	ctx.App().ReleaseCtx(operationCtx)
+	ctx.App().Server().Handler(&subCtx)
+
+	response := &subCtx.Response
+	result := BatchResult{Status: response.StatusCode(), Headers: map[string]string{}}
+	response.Header.VisitAll(func(name, value []byte) {
+		switch string(name) {
+		case fiber.HeaderContentLength, fiber.HeaderContentType, fiber.HeaderDate, fiber.HeaderServer:
+		default:
+			result.Headers[string(name)] = string(value)
+		}
+	})
+	if body := response.Body(); len(body) > 0 {
+		if json.Valid(body) && strings.Contains(string(response.Header.ContentType()), "json") {
+			result.Body = json.RawMessage(append([]byte(nil), body...))
+		} else {
+			result.Body = string(body)
+		}
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type batchResponse struct {
+	Results []struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	} `json:"results"`
+	RolledBack bool `json:"rolled_back"`
+}
+
+type batchTxKey struct{}
+
+// batchTransactor records how its transactions ended.
+type batchTransactor struct {
+	outcomes []string
+}
+
+func (transactor *batchTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(context.WithValue(ctx, batchTxKey{}, true))
+	if err != nil {
+		transactor.outcomes = append(transactor.outcomes, "rolled back")
+	} else {
+		transactor.outcomes = append(transactor.outcomes, "committed")
+	}
+	return err
+}
+
+func TestBatch(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	batchApp := newUserApp(users, &cacheInvalidatorMock{})
+	Mount(batchApp, "/api/v1/batch", NewBatchHandler(repository.NewMemoryTransactor()))
+
+	batch := func(body string) batchResponse {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-User", user.ID)
+		response, err := batchApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		var result batchResponse
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+		return result
+	}
+
+	operations := `[
+		{"method": "PUT", "path": "/users/` + user.ID + `", "body": {"username": "brian", "name": "Brian"}},
+		{"method": "GET", "path": "/users/missing"},
+		{"method": "GET", "path": "/users/` + user.ID + `"}
+	]`
+	result := batch(`{"operations": ` + operations + `}`)
+	assert.Equal(t, []int{200, 404, 424}, []int{result.Results[0].Status, result.Results[1].Status, result.Results[2].Status})
+
+	result = batch(`{"mode": "best_effort", "operations": ` + operations + `}`)
+	assert.Equal(t, 200, result.Results[2].Status)
+	var saved model.User
+	assert.Nil(t, json.Unmarshal(result.Results[2].Body, &saved))
+	assert.Equal(t, "Brian", saved.Name)
+
+	for _, path := range []string{"/api/v1/batch", "/API/V1/Batch"} {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(`{"operations": [{"method": "POST", "path": "`+path+`"}]}`))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := batchApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 400, response.StatusCode, path)
+	}
+
+	// An operation reaching another route to the batches is refused too.
+	Mount(batchApp, "/batch", NewBatchHandler(repository.NewMemoryTransactor()))
+	result = batch(`{"operations": [{"method": "POST", "path": "/batch", "body": {"operations": [{"method": "GET", "path": "/users/missing"}]}}]}`)
+	assert.Equal(t, 400, result.Results[0].Status)
+}
+
+func TestBatchTransaction(t *testing.T) {
+	transactor := &batchTransactor{}
+	batchApp := fiber.New()
+	var inTransaction []bool
+	batchApp.Post("/items/:status", func(ctx *fiber.Ctx) error {
+		inTransaction = append(inTransaction, ctx.UserContext().Value(batchTxKey{}) != nil)
+		status, err := ctx.ParamsInt("status")
+		if err != nil {
+			return err
+		}
+		return ctx.SendStatus(status)
+	})
+	Mount(batchApp, "/batch", NewBatchHandler(transactor))
+
+	tests := []struct {
+		mode          string
+		paths         []string
+		statuses      []int
+		outcomes      []string
+		inTransaction []bool
+		rolledBack    bool
+	}{
+		{"atomic", []string{"/items/201", "/items/204"}, []int{201, 204}, []string{"committed"}, []bool{true, true}, false},
+		{"atomic", []string{"/items/201", "/items/409", "/items/201"}, []int{201, 409, 424}, []string{"rolled back"}, []bool{true, true}, true},
+		{"best_effort", []string{"/items/201", "/items/409", "/items/201"}, []int{201, 409, 201}, nil, []bool{false, false, false}, false},
+	}
+	for _, test := range tests {
+		transactor.outcomes, inTransaction = nil, nil
+		var operations []string
+		for _, path := range test.paths {
+			operations = append(operations, `{"method": "POST", "path": "`+path+`"}`)
+		}
+		body := `{"mode": "` + test.mode + `", "operations": [` + strings.Join(operations, ",") + `]}`
+		request := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := batchApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode, test.paths)
+		var result batchResponse
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+
+		var statuses []int
+		for _, operation := range result.Results {
+			statuses = append(statuses, operation.Status)
+		}
+		assert.Equal(t, test.statuses, statuses, test.paths)
+		assert.Equal(t, test.outcomes, transactor.outcomes, test.paths)
+		assert.Equal(t, test.inTransaction, inTransaction, test.paths)
+		assert.Equal(t, test.rolledBack, result.RolledBack, test.paths)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/barcode"
+	"golang-fiber-web/middleware"
+	"strconv"
+)
+
+const (
+	defaultQRCodeSize = 256
+	maxQRCodeSize     = 2048
+	maxBarcodeData    = 80
+	maxBarcodeHeight  = 1000
+	maxBarcodePixels  = 4000000
+	maxBarcodeWidth   = 10
+)
+
+// CodeHandler draws QR codes and Code 128 barcodes of the data of the query,
+// for pages, emails and documents to embed as images. The same query always
+// draws the same image, so they are cached for good.
+type CodeHandler struct{}
+
+func NewCodeHandler() *CodeHandler {
+	return &CodeHandler{}
+}
+
+func (handler *CodeHandler) Register(router fiber.Router) {
+	router.Get("/qrcode", handler.QRCode).Name("codes.qrcode")
+	router.Get("/barcode", handler.Barcode).Name("codes.barcode")
+}
+
+// QRCode draws ?data at the error correction ?level, L, M (the default), Q
+// or H, as a ?format of png (the default) or svg. The image is the largest
+// whole multiple of the modules, quiet zone included, that fits in ?size
+// pixels.
+func (handler *CodeHandler) QRCode(ctx *fiber.Ctx) error {
+	data := ctx.Query("data")
+	if data == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "data is required")
+	}
+	size := ctx.QueryInt("size", defaultQRCodeSize)
+	if size < 1 || size > maxQRCodeSize {
+		return fiber.NewError(fiber.StatusBadRequest, "size must be between 1 and "+strconv.Itoa(maxQRCodeSize))
+	}
+	level, err := barcode.ParseLevel(ctx.Query("level", "M"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "level must be L, M, Q or H")
+	}
+	code, err := barcode.EncodeQR([]byte(data), level)
+	if errors.Is(err, barcode.ErrDataTooLong) {
+		return fiber.NewError(fiber.StatusBadRequest, "data is too long for a QR code")
+	}
+	if err != nil {
+		return err
+	}
+	modules, _ := code.Bounds()
+	quiet, _ := code.QuietZone()
+	scale := max(1, size/(modules+2*quiet))
+	return sendCode(ctx, code, barcode.Scale{Width: scale, Height: scale})
+}
+
+// Barcode draws ?data, printable ASCII, as a Code 128 barcode of ?height
+// pixels (80 by default) whose narrowest bars are ?width pixels wide (2 by
+// default), as a ?format of png (the default) or svg. The data is at most 80
+// characters and the image at most 4 million pixels.
+func (handler *CodeHandler) Barcode(ctx *fiber.Ctx) error {
+	scale := barcode.Scale{Width: ctx.QueryInt("width", 2), Height: ctx.QueryInt("height", 80)}
+	if scale.Width < 1 || scale.Width > maxBarcodeWidth {
+		return fiber.NewError(fiber.StatusBadRequest, "width must be between 1 and "+strconv.Itoa(maxBarcodeWidth))
+	}
+	if scale.Height < 1 || scale.Height > maxBarcodeHeight {
+		return fiber.NewError(fiber.StatusBadRequest, "height must be between 1 and "+strconv.Itoa(maxBarcodeHeight))
+	}
+	data := ctx.Query("data")
+	if len(data) > maxBarcodeData {
+		return fiber.NewError(fiber.StatusBadRequest, "data must be at most "+strconv.Itoa(maxBarcodeData)+" characters")
+	}
+	code, err := barcode.EncodeCode128(data)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	modules, _ := code.Bounds()
+	quiet, _ := code.QuietZone()
+	if (modules+2*quiet)*scale.Width*scale.Height > maxBarcodePixels {
+		return fiber.NewError(fiber.StatusBadRequest, "the barcode would be larger than "+strconv.Itoa(maxBarcodePixels)+" pixels; lower width or height")
+	}
+	return sendCode(ctx, code, scale)
+}
+
+// sendCode answers symbol in the ?format of the request, tagged by the
+// query that drew it.
+func sendCode(ctx *fiber.Ctx, symbol barcode.Symbol, scale barcode.Scale) error {
+	var image bytes.Buffer
+	var err error
+	switch ctx.Query("format", "png") {
+	case "png":
+		ctx.Type("png")
+		err = barcode.WritePNG(&image, symbol, scale)
+	case "svg":
+		ctx.Type("svg")
+		err = barcode.WriteSVG(&image, symbol, scale)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be png or svg")
+	}
+	if err != nil {
+		return err
+	}
+
+	etag, err := middleware.ETagOf(ctx.OriginalURL())
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	return ctx.Send(image.Bytes())
+}
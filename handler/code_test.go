@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/web"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func codeApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(middleware.NewETag(true))
+	Mount(app, "", NewCodeHandler())
+	return app
+}
+
+func TestQRCode(t *testing.T) {
+	app := codeApp()
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/qrcode?data=order-1&size=100", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/png", response.Header.Get("Content-Type"))
+	assert.Equal(t, "public, max-age=31536000, immutable", response.Header.Get("Cache-Control"))
+	img, err := png.Decode(response.Body)
+	assert.Nil(t, err)
+	// A version 1 code, 21 modules and a quiet zone of 4 on each side, at 3
+	// pixels a module.
+	assert.Equal(t, 87, img.Bounds().Dx())
+
+	request := httptest.NewRequest(http.MethodGet, "/qrcode?data=order-1&size=100", nil)
+	request.Header.Set("If-None-Match", response.Header.Get("ETag"))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest(http.MethodGet, "/qrcode?data=order-1&format=svg&level=h", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/svg+xml", response.Header.Get("Content-Type"))
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "<svg"))
+
+	for _, query := range []string{"", "data=a&size=0", "data=a&size=5000", "data=a&level=X", "data=a&format=gif", "data=" + strings.Repeat("a", 3000)} {
+		response, err = app.Test(httptest.NewRequest(http.MethodGet, "/qrcode?"+query, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, 400, response.StatusCode, query)
+	}
+}
+
+func TestBarcode(t *testing.T) {
+	app := codeApp()
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/barcode?data=1234&height=40&width=1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	img, err := png.Decode(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, 77, img.Bounds().Dx())
+	assert.Equal(t, 40, img.Bounds().Dy())
+
+	long := strings.Repeat("a", 80)
+	for _, query := range []string{"", "data=caf%C3%A9", "data=a&height=0", "data=a&width=11", "data=" + long + "a", "data=" + long + "&width=10&height=1000"} {
+		response, err = app.Test(httptest.NewRequest(http.MethodGet, "/barcode?"+query, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, 400, response.StatusCode, query)
+	}
+}
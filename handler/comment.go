@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+var commentListOptions = web.ListOptions{
+	Sortable:   []string{"created_at", "rating"},
+	Filterable: []string{"user_id", "rating"},
+	Searchable: true,
+}
+
+// CommentHandler serves the comments and ratings on the resources of one
+// type, mounted under the path of a resource as :resourceId. Anyone who can
+// see the resource reads them, the users post, flag and delete their own,
+// and the admin moderates them with basic auth.
+type CommentHandler struct {
+	resource string
+	comments service.CommentService
+	access   fiber.Handler
+	admin    fiber.Handler
+}
+
+// NewCommentHandler serves the comments on the resources of the type
+// resource. Owned resources are mounted under /users/:userId, and their
+// comments are for that user only.
+func NewCommentHandler(resource string, owned bool, comments service.CommentService, admin config.AdminConfig) *CommentHandler {
+	access := func(ctx *fiber.Ctx) error {
+		return ctx.Next()
+	}
+	if owned {
+		access = owner("comments")
+	}
+	return &CommentHandler{resource: resource, comments: comments, access: access, admin: middleware.NewAdminAuth(admin)}
+}
+
+func (handler *CommentHandler) Register(router fiber.Router) {
+	name := "comments." + handler.resource + "."
+	router.Get("", handler.access, handler.List).Name(name + "list")
+	router.Get("/rating", handler.access, handler.Rating).Name(name + "rating")
+	router.Post("", handler.access, authenticated, handler.Create).Name(name + "create")
+	router.Post("/:commentId/flag", handler.access, authenticated, handler.Flag).Name(name + "flag")
+	router.Delete("/:commentId", handler.access, authenticated, handler.Delete).Name(name + "delete")
+	router.Put("/:commentId/status", handler.admin, handler.Moderate).Name(name + "moderate")
+}
+
+// List lists the comments on the resource, newest first, each with its
+// replies nested oldest first.
+func (handler *CommentHandler) List(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, commentListOptions)
+	if err != nil {
+		return err
+	}
+	comments, total, err := handler.comments.List(ctx.UserContext(), handler.resource, ctx.Params("resourceId"), userID(ctx), spec)
+	if err != nil {
+		return commentError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       comments,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+func (handler *CommentHandler) Rating(ctx *fiber.Ctx) error {
+	rating, err := handler.comments.Rating(ctx.UserContext(), handler.resource, ctx.Params("resourceId"), userID(ctx))
+	if err != nil {
+		return commentError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, rating)
+}
+
+func (handler *CommentHandler) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateCommentRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	comment, err := handler.comments.Create(ctx.UserContext(), handler.resource, ctx.Params("resourceId"), userID(ctx), request)
+	if err != nil {
+		return commentError(err)
+	}
+	return web.Respond(ctx, fiber.StatusCreated, comment)
+}
+
+func (handler *CommentHandler) Flag(ctx *fiber.Ctx) error {
+	comment, err := handler.comments.Flag(ctx.UserContext(), handler.resource, ctx.Params("resourceId"), userID(ctx), ctx.Params("commentId"))
+	if err != nil {
+		return commentError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, comment)
+}
+
+func (handler *CommentHandler) Delete(ctx *fiber.Ctx) error {
+	err := handler.comments.Delete(ctx.UserContext(), handler.resource, ctx.Params("resourceId"), userID(ctx), ctx.Params("commentId"))
+	if err != nil {
+		return commentError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// Moderate sets the status of a comment to visible or hidden, bringing back
+// or hiding it whatever its flags.
+func (handler *CommentHandler) Moderate(ctx *fiber.Ctx) error {
+	request := new(model.ModerateCommentRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	comment, err := handler.comments.Moderate(ctx.UserContext(), handler.resource, ctx.Params("resourceId"), ctx.Params("commentId"), request)
+	if err != nil {
+		return commentError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, comment)
+}
+
+func commentError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrCommentNotFound), errors.Is(err, model.ErrProductNotFound), errors.Is(err, model.ErrOrderNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrAlreadyRated):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, model.ErrCommentTooDeep), errors.Is(err, model.ErrRatingOnReply):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, model.ErrQuotaExceeded):
+		return fiber.NewError(fiber.StatusTooManyRequests, "daily comment limit reached")
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	ctx := context.Background()
+	orders := service.NewOrderService(repository.NewMemoryOrderRepository(), repository.NewMemoryTransactor(),
+		service.NewAuditService(repository.NewMemoryAuditRepository()), event.Discard)
+	order, err := orders.Place(ctx, "1", &model.CreateOrderRequest{Items: []model.OrderItem{{Name: "Book", Quantity: 1, UnitPrice: 100}}, Currency: "IDR"})
+	assert.Nil(t, err)
+	targets := map[string]service.CommentTarget{
+		"product": func(ctx context.Context, userID, id string) error {
+			if id != "p1" {
+				return model.ErrProductNotFound
+			}
+			return nil
+		},
+		"order": func(ctx context.Context, userID, id string) error {
+			_, err := orders.Find(ctx, userID, id)
+			return err
+		},
+	}
+	comments := service.NewCommentService(config.CommentsConfig{DailyLimit: 10, MaxDepth: 3, FlagThreshold: 1}, targets, repository.NewMemoryCommentRepository(),
+		service.NewQuotaService(repository.NewMemoryQuotaRepository()), repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()))
+
+	admin := config.AdminConfig{Username: "admin", Password: "secret"}
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", strings.Clone(ctx.Get("X-User")))
+		return ctx.Next()
+	})
+	Mount(app, "/products/:resourceId/comments", NewCommentHandler("product", false, comments, admin))
+	Mount(app, "/users/:userId/orders/:resourceId/comments", NewCommentHandler("order", true, comments, admin))
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodPost, "/products/p1/comments", "", `{"body":"Nice"}`).StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodPost, "/products/p2/comments", "1", `{"body":"Nice"}`).StatusCode)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPost, "/products/p1/comments", "1", `{"body":"Nice","rating":6}`).StatusCode)
+
+	response := fileRequest(t, app, http.MethodPost, "/products/p1/comments", "1", `{"body":"Nice","rating":4}`)
+	assert.Equal(t, 201, response.StatusCode)
+	comment := &model.Comment{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(comment))
+	assert.Equal(t, 409, fileRequest(t, app, http.MethodPost, "/products/p1/comments", "1", `{"body":"Again","rating":5}`).StatusCode)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPost, "/products/p1/comments", "2", `{"body":"Yes","rating":5,"parent_id":"`+comment.ID+`"}`).StatusCode)
+	assert.Equal(t, 201, fileRequest(t, app, http.MethodPost, "/products/p1/comments", "2", `{"body":"Yes","parent_id":"`+comment.ID+`"}`).StatusCode)
+
+	body := struct {
+		Data []*model.Comment `json:"data"`
+	}{}
+	response = fileRequest(t, app, http.MethodGet, "/products/p1/comments", "", "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 1)
+	assert.Len(t, body.Data[0].Replies, 1)
+
+	rating := &model.RatingSummary{}
+	response = fileRequest(t, app, http.MethodGet, "/products/p1/comments/rating", "", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(rating))
+	assert.Equal(t, 1, rating.Count)
+	assert.Equal(t, 4.0, rating.Average)
+
+	assert.Equal(t, 200, fileRequest(t, app, http.MethodPost, "/products/p1/comments/"+comment.ID+"/flag", "2", "").StatusCode)
+	response = fileRequest(t, app, http.MethodGet, "/products/p1/comments", "", "")
+	body.Data = nil
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Empty(t, body.Data)
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodPut, "/products/p1/comments/"+comment.ID+"/status", "", `{"status":"visible"}`).StatusCode)
+	request := httptest.NewRequest(http.MethodPut, "/products/p1/comments/"+comment.ID+"/status", strings.NewReader(`{"status":"visible"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.SetBasicAuth("admin", "secret")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodDelete, "/products/p1/comments/"+comment.ID, "2", "").StatusCode)
+	assert.Equal(t, 204, fileRequest(t, app, http.MethodDelete, "/products/p1/comments/"+comment.ID, "1", "").StatusCode)
+
+	orderComments := "/users/1/orders/" + order.ID + "/comments"
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodGet, orderComments, "2", "").StatusCode)
+	assert.Equal(t, 201, fileRequest(t, app, http.MethodPost, orderComments, "1", `{"body":"Please gift wrap it"}`).StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/users/1/orders/missing/comments", "1", "").StatusCode)
+}
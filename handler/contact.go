@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/i18n"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// captchaWidgets are the scripts and the classes of the widgets of the
+// captcha providers, and the origins the Content-Security-Policy lets them
+// load scripts, frames and requests from.
+var captchaWidgets = map[string]struct {
+	script  string
+	class   string
+	sources []string
+}{
+	"hcaptcha":  {script: "https://js.hcaptcha.com/1/api.js", class: "h-captcha", sources: []string{"https://hcaptcha.com", "https://*.hcaptcha.com"}},
+	"recaptcha": {script: "https://www.google.com/recaptcha/api.js", class: "g-recaptcha", sources: []string{"https://www.google.com/recaptcha/", "https://www.gstatic.com/recaptcha/", "https://recaptcha.google.com/recaptcha/"}},
+	"turnstile": {script: "https://challenges.cloudflare.com/turnstile/v0/api.js", class: "cf-turnstile", sources: []string{"https://challenges.cloudflare.com"}},
+}
+
+// ContactHandler serves the contact page and mails the messages posted from
+// it, once they pass the captcha.
+type ContactHandler struct {
+	contact       service.ContactService
+	captchaConfig config.CaptchaConfig
+	captcha       fiber.Handler
+}
+
+// NewContactHandler shows the widget of captchaConfig on the page and checks
+// its response with captcha, both built from the same settings.
+func NewContactHandler(contact service.ContactService, captchaConfig config.CaptchaConfig, captcha fiber.Handler) *ContactHandler {
+	return &ContactHandler{contact: contact, captchaConfig: captchaConfig, captcha: captcha}
+}
+
+func (handler *ContactHandler) Register(router fiber.Router) {
+	router.Get("", handler.Form).Name("contact.form")
+	router.Post("", handler.captcha, handler.Send).Name("contact.send")
+}
+
+func (handler *ContactHandler) Form(ctx *fiber.Ctx) error {
+	return handler.render(ctx, fiber.StatusOK, &model.ContactRequest{}, nil)
+}
+
+// Send mails the message of the form and shows the page telling it was
+// sent, or the form again with the errors of its fields.
+func (handler *ContactHandler) Send(ctx *fiber.Ctx) error {
+	request := new(model.ContactRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	err = handler.contact.Send(ctx.UserContext(), ctx.IP(), request)
+	var validationErrors model.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrors):
+		problem := web.ProblemFromError(ctx, err)
+		localized, _ := problem.Extensions["errors"].(model.ValidationErrors)
+		if localized == nil {
+			localized = validationErrors
+		}
+		return handler.render(ctx, fiber.StatusUnprocessableEntity, request, localized)
+	case errors.Is(err, model.ErrQuotaExceeded):
+		return fiber.NewError(fiber.StatusTooManyRequests, "too many messages sent, try again tomorrow")
+	case errors.Is(err, model.ErrMailDisabled):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	case err != nil:
+		return err
+	}
+	return ctx.Render("contact_sent", fiber.Map{
+		"title":  i18n.T(ctx, "contact.sent_title"),
+		"detail": i18n.T(ctx, "contact.sent", "name", request.Name),
+	})
+}
+
+func (handler *ContactHandler) render(ctx *fiber.Ctx, status int, request *model.ContactRequest, fieldErrors model.ValidationErrors) error {
+	errorList := make([]fiber.Map, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		errorList[i] = fiber.Map{"field": fieldError.Field, "message": fieldError.Message}
+	}
+	data := fiber.Map{
+		"title":     i18n.T(ctx, "contact.title"),
+		"action":    ctx.OriginalURL(),
+		"name":      request.Name,
+		"email":     request.Email,
+		"subject":   request.Subject,
+		"message":   request.Message,
+		"errors":    errorList,
+		"hasErrors": len(errorList) > 0,
+	}
+	if widget, ok := captchaWidgets[handler.captchaConfig.Provider]; ok && handler.captchaConfig.Enabled {
+		data["captcha"] = fiber.Map{"script": widget.script, "class": widget.class, "siteKey": handler.captchaConfig.SiteKey}
+		middleware.AllowCSPSources(ctx, []string{"script-src", "frame-src", "connect-src"}, widget.sources...)
+	}
+	return ctx.Status(status).Render("contact", data)
+}
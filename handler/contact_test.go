@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/i18n"
+	"golang-fiber-web/mail"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/views"
+	"golang-fiber-web/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type recordingMailer struct {
+	sent []*mail.Message
+}
+
+func (mailer *recordingMailer) Send(ctx context.Context, message *mail.Message) error {
+	mailer.sent = append(mailer.sent, message)
+	return nil
+}
+
+func contactRequest(t *testing.T, app *fiber.App, method, target string, form url.Values) (int, string) {
+	request := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return response.StatusCode, string(body)
+}
+
+func TestContact(t *testing.T) {
+	mailer := &recordingMailer{}
+	contact := service.NewContactService(mailer, config.ContactConfig{To: "support@example.com", DailyLimit: 1},
+		service.NewQuotaService(repository.NewMemoryQuotaRepository()))
+	engine, err := views.New(config.ServerConfig{ViewsEmbedded: true})
+	assert.Nil(t, err)
+	bundle, err := i18n.Load("../locales", "en")
+	assert.Nil(t, err)
+
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true), Views: engine, ViewsLayout: "layouts/main", PassLocalsToViews: true})
+	app.Use(i18n.New(bundle))
+	app.Use(middleware.NewCSP(config.Default().CSP))
+	captchaConfig := config.CaptchaConfig{Enabled: true, Provider: "turnstile", SiteKey: "site-key"}
+	passed := func(ctx *fiber.Ctx) error {
+		return ctx.Next()
+	}
+	Mount(app, "/contact", NewContactHandler(contact, captchaConfig, passed))
+
+	status, body := contactRequest(t, app, http.MethodGet, "/contact?lang=id", nil)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, "Hubungi kami")
+	assert.Contains(t, body, `<div class="cf-turnstile" data-sitekey="site-key">`)
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/contact", nil))
+	assert.Nil(t, err)
+	policy := response.Header.Get(fiber.HeaderContentSecurityPolicy)
+	assert.Regexp(t, `script-src 'self' 'nonce-[^']+' https://challenges.cloudflare.com;`, policy)
+	assert.Contains(t, policy, "frame-src 'self' https://challenges.cloudflare.com")
+	assert.Contains(t, policy, "connect-src 'self' https://challenges.cloudflare.com")
+
+	status, body = contactRequest(t, app, http.MethodPost, "/contact", url.Values{"name": {"Brian"}, "email": {"nope"}, "message": {"Hi <b>there</b>"}})
+	assert.Equal(t, 422, status)
+	assert.Contains(t, body, "email: must be a valid email address")
+	assert.Contains(t, body, "Hi &lt;b&gt;there&lt;/b&gt;")
+	assert.Empty(t, mailer.sent)
+
+	status, _ = contactRequest(t, app, http.MethodPost, "/contact", url.Values{"name": {"Bot"}, "email": {"bot@example.com"}, "message": {"Buy"}, "website": {"spam"}})
+	assert.Equal(t, 200, status)
+	assert.Empty(t, mailer.sent)
+
+	form := url.Values{"name": {"Brian Ashari"}, "email": {"brian@example.com"}, "message": {"Where is my order?"}}
+	status, body = contactRequest(t, app, http.MethodPost, "/contact", form)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, "Thank you, Brian Ashari.")
+	assert.Len(t, mailer.sent, 1)
+
+	status, _ = contactRequest(t, app, http.MethodPost, "/contact", form)
+	assert.Equal(t, 429, status)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"expvar"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/basicauth"
+	fiberexpvar "github.com/gofiber/fiber/v2/middleware/expvar"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+const HeaderDebugPID = "X-Debug-Pid"
+
+func init() {
+	expvar.Publish("process", expvar.Func(processInfo))
+}
+
+func processInfo() interface{} {
+	return fiber.Map{
+		"pid":        os.Getpid(),
+		"ppid":       os.Getppid(),
+		"child":      fiber.IsChild(),
+		"goroutines": runtime.NumGoroutine(),
+	}
+}
+
+type DebugHandler struct {
+	config config.DebugConfig
+	pid    string
+}
+
+func NewDebugHandler(config config.DebugConfig) *DebugHandler {
+	return &DebugHandler{config: config, pid: strconv.Itoa(os.Getpid())}
+}
+
+// Register adds pprof profiles, expvar, process info and the route table
+// behind basic auth.
+// The handler is meant to be mounted at /debug. Every response names the
+// serving process in X-Debug-Pid. Under Prefork a specific child can be
+// targeted with ?pid=: other children answer 421 and close the connection so
+// the client can retry on a new one.
+func (handler *DebugHandler) Register(router fiber.Router) {
+	router.Use(basicauth.New(basicauth.Config{
+		Users: map[string]string{handler.config.Username: handler.config.Password},
+		Realm: "debug",
+	}), handler.targetProcess)
+
+	router.Use(pprof.New())
+	router.Use(fiberexpvar.New())
+	router.Get("/process", handler.Process).Name("debug.process")
+	router.Get("/routes", handler.Routes).Name("debug.routes")
+}
+
+func (handler *DebugHandler) Process(ctx *fiber.Ctx) error {
+	return web.Respond(ctx, fiber.StatusOK, processInfo())
+}
+
+// Routes dumps the route table of the app serving the request, which is the
+// parent app when the handler is mounted on a sub-app.
+func (handler *DebugHandler) Routes(ctx *fiber.Ctx) error {
+	return web.Respond(ctx, fiber.StatusOK, web.RouteTable(ctx.App()))
+}
+
+func (handler *DebugHandler) targetProcess(ctx *fiber.Ctx) error {
+	ctx.Set(HeaderDebugPID, handler.pid)
+	if want := ctx.Query("pid"); want != "" && want != handler.pid {
+		ctx.Context().SetConnectionClose()
+		return fiber.NewError(fiber.StatusMisdirectedRequest, "served by pid "+handler.pid+", retry on a new connection")
+	}
+	return ctx.Next()
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func newDebugApp() *fiber.App {
+	debugApp := fiber.New()
+	MountApp(debugApp, "/debug", NewDebugHandler(config.DebugConfig{Enabled: true, Username: "admin", Password: "secret"}), fiber.Config{})
+	return debugApp
+}
+
+func debugRequest(target string, authenticated bool) *http.Request {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	if authenticated {
+		request.SetBasicAuth("admin", "secret")
+	}
+	return request
+}
+
+func TestDebugRequiresAuth(t *testing.T) {
+	response, err := newDebugApp().Test(debugRequest("/debug/pprof/", false))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+}
+
+func TestDebugPprofAndExpvar(t *testing.T) {
+	debugApp := newDebugApp()
+	pid := strconv.Itoa(os.Getpid())
+
+	response, err := debugApp.Test(debugRequest("/debug/pprof/goroutine?debug=1", true))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, pid, response.Header.Get("X-Debug-Pid"))
+	bytes, err := io.ReadAll(response.Body)
+	assert.Contains(t, string(bytes), "goroutine profile")
+
+	response, err = debugApp.Test(debugRequest("/debug/vars", true))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	vars := map[string]interface{}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&vars))
+	assert.Contains(t, vars, "memstats")
+	assert.Equal(t, float64(os.Getpid()), vars["process"].(map[string]interface{})["pid"])
+}
+
+func TestDebugTargetsProcess(t *testing.T) {
+	debugApp := newDebugApp()
+
+	response, err := debugApp.Test(debugRequest("/debug/process?pid="+strconv.Itoa(os.Getpid()), true))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response, err = debugApp.Test(debugRequest("/debug/process?pid=1", true))
+	assert.Nil(t, err)
+	assert.Equal(t, 421, response.StatusCode)
+	assert.True(t, response.Close)
+}
+
+func TestDebugRoutes(t *testing.T) {
+	debugApp := newDebugApp()
+	debugApp.Use("/users", func(ctx *fiber.Ctx) error {
+		return ctx.Next()
+	})
+	Mount(debugApp, "/users", NewUserHandler(nil, nil, nil, config.AdminConfig{}))
+
+	response, err := debugApp.Test(debugRequest("/debug/routes", false))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	response, err = debugApp.Test(debugRequest("/debug/routes", true))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	routes := []web.RouteInfo{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&routes))
+
+	byPath := map[string]web.RouteInfo{}
+	for _, route := range routes {
+		byPath[route.Method+" "+route.Path] = route
+	}
+	assert.Contains(t, byPath, "GET /debug/routes")
+	assert.Equal(t, "golang-fiber-web/handler.(*UserHandler).Update", byPath["PUT /users/:id"].Handler)
+	assert.Equal(t, "golang-fiber-web/handler.(*UserHandler).selfOrAdmin", byPath["PUT /users/:id"].Middleware[1])
+	assert.Len(t, byPath["GET /debug/process"].Middleware, 4)
+}
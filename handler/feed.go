@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/feed"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// FeedHandler publishes the latest products as RSS and Atom feeds, linking
+// to them under the base URL of the app.
+type FeedHandler struct {
+	config   config.FeedConfig
+	baseURL  string
+	products service.ProductService
+}
+
+func NewFeedHandler(feedConfig config.FeedConfig, baseURL string, products service.ProductService) *FeedHandler {
+	return &FeedHandler{config: feedConfig, baseURL: strings.TrimSuffix(baseURL, "/"), products: products}
+}
+
+func (handler *FeedHandler) Register(router fiber.Router) {
+	router.Get("/products.rss", handler.ProductsRSS).Name("feeds.products_rss")
+	router.Get("/products.atom", handler.ProductsAtom).Name("feeds.products_atom")
+}
+
+func (handler *FeedHandler) ProductsRSS(ctx *fiber.Ctx) error {
+	products, err := handler.productFeed(ctx)
+	if err != nil {
+		return err
+	}
+	return sendFeed(ctx, products, feed.RSSContentType, products.WriteRSS)
+}
+
+func (handler *FeedHandler) ProductsAtom(ctx *fiber.Ctx) error {
+	products, err := handler.productFeed(ctx)
+	if err != nil {
+		return err
+	}
+	return sendFeed(ctx, products, feed.AtomContentType, products.WriteAtom)
+}
+
+// productFeed lists the products updated last.
+func (handler *FeedHandler) productFeed(ctx *fiber.Ctx) (*feed.Feed, error) {
+	spec := &model.ListSpec{Page: 1, PerPage: handler.config.Items, Sort: []model.SortField{{Field: "updated_at", Desc: true}}}
+	products, _, err := handler.products.List(ctx.UserContext(), false, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	productFeed := &feed.Feed{
+		Title:       handler.config.Title,
+		Description: handler.config.Title,
+		Link:        handler.baseURL + "/products",
+		Self:        handler.baseURL + ctx.OriginalURL(),
+	}
+	for _, product := range products {
+		productFeed.Items = append(productFeed.Items, feed.Item{
+			Title:     product.Name,
+			Link:      handler.baseURL + "/products/" + product.ID,
+			Summary:   product.Description,
+			Category:  product.Category,
+			Published: product.CreatedAt,
+			Updated:   product.UpdatedAt,
+		})
+		if product.UpdatedAt.After(productFeed.Updated) {
+			productFeed.Updated = product.UpdatedAt
+		}
+	}
+	return productFeed, nil
+}
+
+// sendFeed answers the feed written by write, or 304 Not Modified when the
+// client has it already, by its ETag or its last update.
+func sendFeed(ctx *fiber.Ctx, content *feed.Feed, contentType string, write func(writer io.Writer) error) error {
+	etag, err := middleware.ETagOf(content)
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderETag, etag)
+	if !content.Updated.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, content.Updated.UTC().Format(http.TimeFormat))
+	}
+	ctx.Set(fiber.HeaderCacheControl, "public, no-cache")
+	if ctx.Fresh() {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	var body bytes.Buffer
+	err = write(&body)
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.Send(body.Bytes())
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProductFeeds(t *testing.T) {
+	products := service.NewProductService(config.ProductConfig{}, repository.NewMemoryProductRepository(),
+		storage.NewLocal(t.TempDir()), repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()))
+	for _, name := range []string{"Book", "Pen", "Ink"} {
+		_, err := products.Create(context.Background(), &model.SaveProductRequest{Name: name, Category: "stationery", Price: 100, Currency: "IDR"})
+		assert.Nil(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	Mount(app, "/feeds", NewFeedHandler(config.FeedConfig{Title: "Latest products", Items: 2}, "https://shop.example.com/", products))
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/feeds/products.rss", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", response.Header.Get("Content-Type"))
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<atom:link href="https://shop.example.com/feeds/products.rss" rel="self"`)
+	assert.Contains(t, string(body), "<title>Ink</title>")
+	assert.Contains(t, string(body), "<title>Pen</title>")
+	assert.NotContains(t, string(body), "<title>Book</title>")
+
+	etag, lastModified := response.Header.Get("ETag"), response.Header.Get("Last-Modified")
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, lastModified)
+	request := httptest.NewRequest(http.MethodGet, "/feeds/products.rss", nil)
+	request.Header.Set("If-None-Match", etag)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+	request = httptest.NewRequest(http.MethodGet, "/feeds/products.rss", nil)
+	request.Header.Set("If-Modified-Since", lastModified)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest(http.MethodGet, "/feeds/products.atom", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", response.Header.Get("Content-Type"))
+	body, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, string(body), "<id>https://shop.example.com/feeds/products.atom</id>")
+}
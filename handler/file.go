@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/upload"
+	"golang-fiber-web/web"
+	"strconv"
+	"strings"
+)
+
+var fileListOptions = web.ListOptions{
+	Sortable:   []string{"created_at", "name", "size"},
+	Filterable: []string{"status", "content_type"},
+	Searchable: true,
+}
+
+// FileHandler manages the uploaded files of the user of the API key of the
+// request, and serves their thumbnails to anyone knowing their ID.
+type FileHandler struct {
+	files      service.FileService
+	thumbnails *upload.Thumbnailer
+	maxSize    int
+}
+
+func NewFileHandler(config config.UploadConfig, files service.FileService, thumbnails *upload.Thumbnailer) *FileHandler {
+	return &FileHandler{files: files, thumbnails: thumbnails, maxSize: config.ThumbnailMaxSize}
+}
+
+func (handler *FileHandler) Register(router fiber.Router) {
+	router.Get("", handler.List).Name("files.list")
+	router.Get("/usage", handler.Usage).Name("files.usage")
+	router.Get("/:id", handler.Get).Name("files.show")
+	router.Patch("/:id", handler.Rename).Name("files.rename")
+	router.Delete("/:id", handler.Delete).Name("files.delete")
+	router.Get("/:id/thumb", handler.Thumbnail).Name("files.thumbnail")
+}
+
+// List lists the files of the user, newest first.
+func (handler *FileHandler) List(ctx *fiber.Ctx) error {
+	ownerID, err := fileOwner(ctx)
+	if err != nil {
+		return err
+	}
+	spec, err := web.ParseListSpec(ctx, fileListOptions)
+	if err != nil {
+		return err
+	}
+	files, total, err := handler.files.List(ctx.UserContext(), ownerID, spec)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       files,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+// Usage answers how much storage the files of the user take up and how much
+// of their storage quota is left; remaining is -1 when there is no quota.
+func (handler *FileHandler) Usage(ctx *fiber.Ctx) error {
+	ownerID, err := fileOwner(ctx)
+	if err != nil {
+		return err
+	}
+	usage, err := handler.files.Usage(ctx.UserContext(), ownerID)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"files":     usage.Files,
+		"used":      usage.Used,
+		"limit":     usage.Limit,
+		"remaining": usage.Remaining(),
+	})
+}
+
+// Get answers the metadata of a file, along with the status of its
+// processing.
+func (handler *FileHandler) Get(ctx *fiber.Ctx) error {
+	ownerID, err := fileOwner(ctx)
+	if err != nil {
+		return err
+	}
+	file, err := handler.files.Find(ctx.UserContext(), ownerID, ctx.Params("id"))
+	if err != nil {
+		return fileError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, file)
+}
+
+func (handler *FileHandler) Rename(ctx *fiber.Ctx) error {
+	ownerID, err := fileOwner(ctx)
+	if err != nil {
+		return err
+	}
+	request := new(model.RenameFileRequest)
+	err = web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	file, err := handler.files.Rename(ctx.UserContext(), ownerID, ctx.Params("id"), request)
+	if err != nil {
+		return fileError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, file)
+}
+
+func (handler *FileHandler) Delete(ctx *fiber.Ctx) error {
+	ownerID, err := fileOwner(ctx)
+	if err != nil {
+		return err
+	}
+	err = handler.files.Delete(ctx.UserContext(), ownerID, ctx.Params("id"))
+	if err != nil {
+		return fileError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// Thumbnail answers the uploaded image resized to the w and h
+// query parameters, cropped to exactly that size unless fit is "contain". It
+// is encoded in the format of upload.Formats the client prefers, by its
+// Accept header.
+func (handler *FileHandler) Thumbnail(ctx *fiber.Ctx) error {
+	resize := upload.Resize{Width: ctx.QueryInt("w"), Height: ctx.QueryInt("h")}
+	if resize.Width == 0 && resize.Height == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "w or h is required")
+	}
+	if resize.Width < 0 || resize.Width > handler.maxSize || resize.Height < 0 || resize.Height > handler.maxSize {
+		return fiber.NewError(fiber.StatusBadRequest, "w and h must be between 1 and "+strconv.Itoa(handler.maxSize))
+	}
+	switch ctx.Query("fit", "cover") {
+	case "cover":
+		resize.Crop = true
+	case "contain":
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "fit must be cover or contain")
+	}
+
+	formats := upload.Formats()
+	offers := make([]string, len(formats))
+	for i, format := range formats {
+		offers[i] = format.ContentType
+	}
+	accepted := ctx.Accepts(offers...)
+	ctx.Vary(fiber.HeaderAccept)
+	if accepted == "" {
+		return fiber.NewError(fiber.StatusNotAcceptable, "accepted formats are "+strings.Join(offers, ", "))
+	}
+	format := formats[0]
+	for _, candidate := range formats {
+		if candidate.ContentType == accepted {
+			format = candidate
+			break
+		}
+	}
+
+	file, err := handler.files.FindByID(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return fileError(err)
+	}
+	switch {
+	case file.Status == model.FilePending || file.Status == model.FileProcessing:
+		return fiber.NewError(fiber.StatusConflict, "file is still being processed")
+	case file.Status == model.FileFailed:
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "file failed processing")
+	case file.Thumbnail == "":
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "file is not an image")
+	}
+
+	thumbnail, err := handler.thumbnails.Thumbnail(ctx.UserContext(), file, resize, format)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, model.ErrFileNotFound.Error())
+	}
+	if errors.Is(err, upload.ErrImageTooLarge) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "image is too large to thumbnail")
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderContentType, format.ContentType)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	return ctx.SendStream(thumbnail)
+}
+
+// fileOwner returns the user of the API key of the request, who owns the
+// files it manages.
+func fileOwner(ctx *fiber.Ctx) (string, error) {
+	ownerID, _ := ctx.Locals("user_id").(string)
+	if ownerID == "" {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "an API key is required")
+	}
+	return ownerID, nil
+}
+
+func fileError(err error) error {
+	if errors.Is(err, model.ErrFileNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/upload"
+	"golang-fiber-web/web"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fileApp serves the files of the user named by the X-User header, which
+// stands for the API key authentication.
+func fileApp(t *testing.T) (*fiber.App, service.FileService, repository.FileRepository, string) {
+	dir := t.TempDir()
+	fileRepository := repository.NewMemoryFileRepository()
+	files := service.NewFileService(fileRepository, storage.NewLocal(dir), repository.NewMemoryTransactor(), event.Discard, nil)
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	Mount(app, "/files", NewFileHandler(config.UploadConfig{ThumbnailMaxSize: 200}, files, upload.NewThumbnailer(storage.NewLocal(dir), 1024)))
+	return app, files, fileRepository, dir
+}
+
+func fileRequest(t *testing.T, app *fiber.App, method, target, user, body string) *http.Response {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		request.Header.Set("X-User", user)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func TestFiles(t *testing.T) {
+	app, files, _, dir := fileApp(t)
+	ctx := context.Background()
+	notes, err := files.Upload(ctx, "1", "notes.txt", 5, strings.NewReader("hello"))
+	assert.Nil(t, err)
+	_, err = files.Upload(ctx, "1", "todo.txt", 4, strings.NewReader("todo"))
+	assert.Nil(t, err)
+	other, err := files.Upload(ctx, "2", "other.txt", 5, strings.NewReader("other"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodGet, "/files", "", "").StatusCode)
+
+	response := fileRequest(t, app, http.MethodGet, "/files/usage", "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	var usage map[string]int64
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&usage))
+	assert.Equal(t, map[string]int64{"files": 2, "used": 9, "limit": 0, "remaining": -1}, usage)
+
+	response = fileRequest(t, app, http.MethodGet, "/files?q=notes", "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	var list struct {
+		Data       []model.File `json:"data"`
+		Pagination struct {
+			Total int `json:"total"`
+		} `json:"pagination"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&list))
+	assert.Equal(t, 1, list.Pagination.Total)
+	assert.Equal(t, "notes.txt", list.Data[0].Name)
+
+	response = fileRequest(t, app, http.MethodGet, "/files/"+notes.ID, "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `"status":"pending"`)
+	assert.NotContains(t, string(body), "storage")
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/files/"+other.ID, "1", "").StatusCode)
+
+	response = fileRequest(t, app, http.MethodPatch, "/files/"+notes.ID, "1", `{"name":"renamed.txt"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	renamed, err := files.Find(ctx, "1", notes.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "renamed.txt", renamed.Name)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPatch, "/files/"+notes.ID, "1", `{"name":"../escape.txt"}`).StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodPatch, "/files/"+other.ID, "1", `{"name":"mine.txt"}`).StatusCode)
+
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodDelete, "/files/"+other.ID, "1", "").StatusCode)
+	assert.Equal(t, 204, fileRequest(t, app, http.MethodDelete, "/files/"+notes.ID, "1", "").StatusCode)
+	assert.NoFileExists(t, filepath.Join(dir, "files", notes.ID))
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/files/"+notes.ID, "1", "").StatusCode)
+}
+
+func TestFileThumbnail(t *testing.T) {
+	app, files, fileRepository, dir := fileApp(t)
+	ctx := context.Background()
+	var content bytes.Buffer
+	assert.Nil(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 100, 50))))
+	photo, err := files.Upload(ctx, "1", "photo.png", int64(content.Len()), &content)
+	assert.Nil(t, err)
+	notes, err := files.Upload(ctx, "1", "notes.txt", 5, strings.NewReader("hello"))
+	assert.Nil(t, err)
+	pending, err := files.Upload(ctx, "1", "new.png", 0, strings.NewReader(""))
+	assert.Nil(t, err)
+	content.Reset()
+	assert.Nil(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 2000, 10))))
+	panorama, err := files.Upload(ctx, "1", "panorama.png", int64(content.Len()), &content)
+	assert.Nil(t, err)
+	// The pipeline allows larger images than the thumbnails made on demand.
+	pipeline := upload.NewPipeline(fileRepository, dir, upload.Steps(config.UploadConfig{ThumbnailSize: 32, ImageMaxDimension: 4096}, storage.NewLocal(dir))...)
+	assert.Nil(t, pipeline.Process(ctx, photo.ID))
+	assert.Nil(t, pipeline.Process(ctx, notes.ID))
+	assert.Nil(t, pipeline.Process(ctx, panorama.ID))
+
+	thumbnail := func(target, accept string) *http.Response {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response := thumbnail("/files/"+photo.ID+"/thumb?w=20&h=20", "image/avif,image/webp,image/*,*/*;q=0.8")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/jpeg", response.Header.Get("Content-Type"))
+	assert.Equal(t, "Accept", response.Header.Get("Vary"))
+	decoded, err := jpeg.DecodeConfig(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{20, 20}, []int{decoded.Width, decoded.Height})
+	checksum, err := files.FindByID(ctx, photo.ID)
+	assert.Nil(t, err)
+	assert.FileExists(t, filepath.Join(dir, ".thumbnails", photo.ID, checksum.Checksum, "20x20-crop.jpg"))
+
+	response = thumbnail("/files/"+photo.ID+"/thumb?w=20&h=20&fit=contain", "image/png")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/png", response.Header.Get("Content-Type"))
+	decodedPNG, err := png.DecodeConfig(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{20, 10}, []int{decodedPNG.Width, decodedPNG.Height})
+
+	assert.Equal(t, 406, thumbnail("/files/"+photo.ID+"/thumb?w=20", "image/webp").StatusCode)
+	assert.Equal(t, 400, thumbnail("/files/"+photo.ID+"/thumb", "").StatusCode)
+	assert.Equal(t, 400, thumbnail("/files/"+photo.ID+"/thumb?w=500", "").StatusCode)
+	assert.Equal(t, 400, thumbnail("/files/"+photo.ID+"/thumb?w=20&fit=stretch", "").StatusCode)
+	assert.Equal(t, 415, thumbnail("/files/"+notes.ID+"/thumb?w=20", "").StatusCode)
+	assert.Equal(t, 409, thumbnail("/files/"+pending.ID+"/thumb?w=20", "").StatusCode)
+	assert.Equal(t, 404, thumbnail("/files/missing/thumb?w=20", "").StatusCode)
+	assert.Equal(t, 422, thumbnail("/files/"+panorama.ID+"/thumb?w=20", "").StatusCode)
+
+	assert.Nil(t, files.Delete(ctx, "1", photo.ID))
+	assert.NoDirExists(t, filepath.Join(dir, ".thumbnails", photo.ID))
+}
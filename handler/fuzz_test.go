@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/event"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/testfactory"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fuzzRequest sends the request and fails the fuzz test when the app answers
+// a malformed body with a server error or hangs on it. A panic fails it too,
+// as the apps have no recover middleware.
+func fuzzRequest(t *testing.T, app *fiber.App, request *http.Request) {
+	response, err := app.Test(request, 2000)
+	if err != nil {
+		if strings.Contains(err.Error(), "timeout") {
+			t.Fatal(err)
+		}
+		// The server refused a request it couldn't read, such as a
+		// truncated multipart body, before any handler ran.
+		return
+	}
+	if response.StatusCode >= 500 {
+		body, _ := io.ReadAll(response.Body)
+		t.Fatalf("status %d: %s", response.StatusCode, body)
+	}
+}
+
+// FuzzOrderBody parses order bodies of every content type BodyParser knows.
+//
+//	go test ./handler -run '^$' -fuzz FuzzOrderBody -fuzztime 30s
+func FuzzOrderBody(f *testing.F) {
+	f.Add("application/json", `{"currency":"idr","items":[{"name":"Book","quantity":2,"unit_price":50000}]}`)
+	f.Add("application/json", `{"currency":"IDR","items":[{"name":"Book","quantity":"2"}]}`)
+	f.Add("application/json", `{"items":[`)
+	f.Add("application/xml", `<CreateOrderRequest><currency>IDR</currency><items><item><name>Book</name><quantity>1</quantity></item></items></CreateOrderRequest>`)
+	f.Add("text/xml", `<CreateOrderRequest><items><item>`)
+	f.Add("application/x-www-form-urlencoded", `currency=IDR&notes=fragile`)
+	f.Add("application/x-www-form-urlencoded", `currency=%zz&items[0].quantity=x`)
+	f.Add("multipart/form-data; boundary=x", "--x\r\nContent-Disposition: form-data; name=\"currency\"\r\n\r\nIDR\r\n--x--\r\n")
+	f.Add("text/plain", "currency=IDR")
+
+	app, _, _ := orderApp(f)
+	f.Fuzz(func(t *testing.T, contentType, body string) {
+		request := httptest.NewRequest(http.MethodPost, "/users/1/orders", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("X-User", "1")
+		fuzzRequest(t, app, request)
+	})
+}
+
+// FuzzUploadMultipart parses multipart upload bodies.
+//
+//	go test ./handler -run '^$' -fuzz FuzzUploadMultipart -fuzztime 30s
+func FuzzUploadMultipart(f *testing.F) {
+	valid := testfactory.NewRequest(f, http.MethodPost, "/upload").
+		Multipart(testfactory.NewMultipart().Field("title", "Notes").File("file", "notes.txt", []byte("hello"))).
+		Build()
+	body, err := io.ReadAll(valid.Body)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(valid.Header.Get("Content-Type"), body)
+	f.Add("multipart/form-data; boundary=x", []byte("--x\r\nContent-Disposition: form-data; name=\"file\"; filename=\"../../\"\r\n\r\nhello\r\n--x--\r\n"))
+	f.Add("multipart/form-data; boundary=x", []byte("--x\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.txt\"\r\n\r\nunterminated"))
+	f.Add("multipart/form-data", []byte("--x--\r\n"))
+	f.Add("multipart/form-data; boundary=\"", []byte{})
+
+	app := newUploadApp(service.NewFileService(repository.NewMemoryFileRepository(), storage.NewLocal(f.TempDir()), repository.NewMemoryTransactor(), event.Discard, nil))
+	f.Fuzz(func(t *testing.T, contentType string, body []byte) {
+		request := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(string(body)))
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("X-User", "1")
+		fuzzRequest(t, app, request)
+	})
+}
@@ -0,0 +1,23 @@
+package handler
+
+import "github.com/gofiber/fiber/v2"
+
+// Module is a feature that plugs its routes into the server. Register adds
+// routes relative to the router the module is mounted on.
+type Module interface {
+	Register(router fiber.Router)
+}
+
+// Mount registers module on a group at prefix. The handlers run before the
+// module's routes only.
+func Mount(router fiber.Router, prefix string, module Module, handlers ...fiber.Handler) {
+	module.Register(router.Group(prefix, handlers...))
+}
+
+// MountApp registers module on a sub-app of its own, mounted at prefix, so the
+// module's middleware and settings stay isolated from the rest of the app.
+func MountApp(app *fiber.App, prefix string, module Module, config fiber.Config) {
+	subApp := fiber.New(config)
+	module.Register(subApp)
+	app.Mount(prefix, subApp)
+}
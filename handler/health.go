@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthHandler answers the probes of orchestrators: /healthz while the
+// process serves, /readyz only while the dependencies check passes too.
+type HealthHandler struct {
+	check func(ctx context.Context) error
+}
+
+func NewHealthHandler(check func(ctx context.Context) error) *HealthHandler {
+	return &HealthHandler{check: check}
+}
+
+func (handler *HealthHandler) Register(router fiber.Router) {
+	router.Get("/healthz", handler.Live).Name("health.live")
+	router.Get("/readyz", handler.Ready).Name("health.ready")
+}
+
+func (handler *HealthHandler) Live(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.SendString("ok")
+}
+
+// Ready answers 503 while a dependency fails, without telling which one to
+// the public; the check logs it.
+func (handler *HealthHandler) Ready(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	err := handler.check(ctx.UserContext())
+	if err != nil {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "not ready")
+	}
+	return ctx.SendString("ok")
+}
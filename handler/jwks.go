@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/token"
+	"strconv"
+	"time"
+)
+
+// jwksMaxAge is how long clients may cache the key set. A new signing key is
+// published config.JWTConfig.PublishAhead before it signs, which must be
+// longer.
+const jwksMaxAge = time.Minute * 5
+
+// JWKSHandler publishes the public keys verifying the tokens the app issues,
+// so other services can validate them.
+type JWKSHandler struct {
+	keys *token.Keys
+}
+
+func NewJWKSHandler(keys *token.Keys) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Register adds the key set route. The handler is meant to be mounted at
+// /.well-known.
+func (handler *JWKSHandler) Register(router fiber.Router) {
+	router.Get("/jwks.json", handler.Show).Name("jwks.show")
+}
+
+func (handler *JWKSHandler) Show(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(jwksMaxAge.Seconds())))
+	return ctx.JSON(handler.keys.JWKS(), "application/jwk-set+json")
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJWKS(t *testing.T) {
+	keys := token.NewKeys(config.Default().JWT, repository.NewMemorySigningKeyRepository())
+	assert.Nil(t, keys.Rotate(context.Background()))
+	jwksApp := fiber.New()
+	Mount(jwksApp, "/.well-known", NewJWKSHandler(keys))
+
+	response, err := jwksApp.Test(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/jwk-set+json", response.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, "public, max-age=300", response.Header.Get(fiber.HeaderCacheControl))
+	var set token.JSONWebKeySet
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&set))
+	assert.Equal(t, keys.JWKS(), set)
+}
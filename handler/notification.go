@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+var notificationListOptions = web.ListOptions{
+	Sortable:   []string{"created_at", "kind"},
+	Filterable: []string{"kind"},
+	Searchable: true,
+}
+
+// NotificationHandler serves the in-app notifications of the current user,
+// along with the devices they are pushed to and the preferences about
+// pushing them, mounted at /me/notifications.
+type NotificationHandler struct {
+	notifications service.NotificationService
+	push          service.PushService
+}
+
+func NewNotificationHandler(notifications service.NotificationService, push service.PushService) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications, push: push}
+}
+
+func (handler *NotificationHandler) Register(router fiber.Router) {
+	router.Use(authenticated)
+	router.Get("", handler.List).Name("notifications.list")
+	router.Get("/unread-count", handler.UnreadCount).Name("notifications.unread_count")
+	router.Get("/stream", handler.Stream).Name("notifications.stream")
+	router.Post("/read-all", handler.MarkAllRead).Name("notifications.read_all")
+	router.Get("/devices", handler.Devices).Name("notifications.devices")
+	router.Post("/devices", handler.RegisterDevice).Name("notifications.devices.register")
+	router.Delete("/devices/:id", handler.UnregisterDevice).Name("notifications.devices.unregister")
+	router.Get("/preferences", handler.Preferences).Name("notifications.preferences")
+	router.Put("/preferences", handler.UpdatePreferences).Name("notifications.preferences.update")
+	router.Post("/:id/read", handler.MarkRead).Name("notifications.read")
+}
+
+// List lists the notifications of the user, newest first; ?unread keeps the
+// unread ones only.
+func (handler *NotificationHandler) List(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, notificationListOptions)
+	if err != nil {
+		return err
+	}
+	notifications, total, err := handler.notifications.List(ctx.UserContext(), userID(ctx), ctx.QueryBool("unread"), spec)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       notifications,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+func (handler *NotificationHandler) UnreadCount(ctx *fiber.Ctx) error {
+	unread, err := handler.notifications.UnreadCount(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"unread": unread})
+}
+
+func (handler *NotificationHandler) MarkRead(ctx *fiber.Ctx) error {
+	notification, err := handler.notifications.MarkRead(ctx.UserContext(), userID(ctx), ctx.Params("id"))
+	if err != nil {
+		return notificationError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, notification)
+}
+
+func (handler *NotificationHandler) MarkAllRead(ctx *fiber.Ctx) error {
+	marked, err := handler.notifications.MarkAllRead(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"marked": marked})
+}
+
+// Stream sends the notifications of the user as server-sent events as they
+// come: first an "unread" event with the unread count, then a
+// "notification" event per new notification and an "unread" event whenever
+// the count changes.
+func (handler *NotificationHandler) Stream(ctx *fiber.Ctx) error {
+	id := userID(ctx)
+	unread, err := handler.notifications.UnreadCount(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	messages, unsubscribe := handler.notifications.Subscribe(id)
+	return web.StreamSSE(ctx, func(streamContext context.Context, send func(event string, data interface{}) error) error {
+		defer unsubscribe()
+		err := send("unread", fiber.Map{"unread": unread})
+		if err != nil {
+			return err
+		}
+		for {
+			select {
+			case <-streamContext.Done():
+				return nil
+			case message := <-messages:
+				if message.Notification != nil {
+					err = send("notification", message.Notification)
+					if err != nil {
+						return err
+					}
+				}
+				err = send("unread", fiber.Map{"unread": message.Unread})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	})
+}
+
+func (handler *NotificationHandler) Devices(ctx *fiber.Ctx) error {
+	devices, err := handler.push.Devices(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"data": devices})
+}
+
+// RegisterDevice registers the push token of a device of the user. Apps
+// register their token on every start, so registering it again answers
+// with the same device.
+func (handler *NotificationHandler) RegisterDevice(ctx *fiber.Ctx) error {
+	request := new(model.RegisterDeviceRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	device, err := handler.push.RegisterDevice(ctx.UserContext(), userID(ctx), request)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, device)
+}
+
+func (handler *NotificationHandler) UnregisterDevice(ctx *fiber.Ctx) error {
+	err := handler.push.UnregisterDevice(ctx.UserContext(), userID(ctx), ctx.Params("id"))
+	if err != nil {
+		return notificationError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (handler *NotificationHandler) Preferences(ctx *fiber.Ctx) error {
+	preferences, err := handler.push.Preferences(ctx.UserContext(), userID(ctx))
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, preferences)
+}
+
+func (handler *NotificationHandler) UpdatePreferences(ctx *fiber.Ctx) error {
+	request := new(model.UpdateNotificationPreferencesRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	preferences, err := handler.push.UpdatePreferences(ctx.UserContext(), userID(ctx), request)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, preferences)
+}
+
+func notificationError(err error) error {
+	if errors.Is(err, model.ErrNotificationNotFound) || errors.Is(err, model.ErrDeviceNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return err
+}
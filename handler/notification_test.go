@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// notificationApp serves the notifications of the user named by the X-User
+// header like fileApp, copying the header as the devices keep it.
+func notificationApp() (*fiber.App, service.NotificationService) {
+	notifications := service.NewNotificationService(repository.NewMemoryNotificationRepository(), repository.NewMemoryTransactor(), event.Discard)
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true), DisableStartupMessage: true})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", strings.Clone(ctx.Get("X-User")))
+		return ctx.Next()
+	})
+	pushes := service.NewPushService(repository.NewMemoryDeviceRepository(), repository.NewMemoryNotificationPreferencesRepository(), nil)
+	Mount(app, "/me/notifications", NewNotificationHandler(notifications, pushes))
+	return app, notifications
+}
+
+func TestNotifications(t *testing.T) {
+	app, notifications := notificationApp()
+	ctx := context.Background()
+	first := &model.Notification{UserID: "1", Kind: "order.placed", Title: "Order placed", CreatedAt: time.Now().Add(-time.Minute)}
+	assert.Nil(t, notifications.Notify(ctx, first))
+	second := &model.Notification{UserID: "1", Kind: "order.status_changed", Title: "Order paid"}
+	assert.Nil(t, notifications.Notify(ctx, second))
+	other := &model.Notification{UserID: "2", Kind: "order.placed", Title: "Order placed"}
+	assert.Nil(t, notifications.Notify(ctx, other))
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodGet, "/me/notifications", "", "").StatusCode)
+
+	response := fileRequest(t, app, http.MethodGet, "/me/notifications", "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	body := struct {
+		Data []*model.Notification `json:"data"`
+	}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 2)
+	assert.Equal(t, second.ID, body.Data[0].ID)
+
+	unread := struct {
+		Unread int `json:"unread"`
+	}{}
+	response = fileRequest(t, app, http.MethodGet, "/me/notifications/unread-count", "1", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&unread))
+	assert.Equal(t, 2, unread.Unread)
+
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodPost, "/me/notifications/"+other.ID+"/read", "1", "").StatusCode)
+	response = fileRequest(t, app, http.MethodPost, "/me/notifications/"+first.ID+"/read", "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	read := &model.Notification{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(read))
+	assert.NotNil(t, read.ReadAt)
+
+	response = fileRequest(t, app, http.MethodGet, "/me/notifications?unread=true", "1", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, second.ID, body.Data[0].ID)
+
+	marked := struct {
+		Marked int `json:"marked"`
+	}{}
+	response = fileRequest(t, app, http.MethodPost, "/me/notifications/read-all", "1", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&marked))
+	assert.Equal(t, 1, marked.Marked)
+
+	response = fileRequest(t, app, http.MethodGet, "/me/notifications/unread-count", "2", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&unread))
+	assert.Equal(t, 1, unread.Unread)
+}
+
+func TestNotificationStream(t *testing.T) {
+	app, notifications := notificationApp()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	request, err := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String()+"/me/notifications/stream", nil)
+	assert.Nil(t, err)
+	request.Header.Set("X-User", "1")
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, web.MIMETextEventStream, response.Header.Get(fiber.HeaderContentType))
+
+	reader := bufio.NewReader(response.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			assert.Nil(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "event: unread\ndata: {\"unread\":0}\n", readEvent())
+
+	notification := &model.Notification{UserID: "1", Kind: "order.placed", Title: "Order placed"}
+	assert.Nil(t, notifications.Notify(context.Background(), notification))
+	assert.Contains(t, readEvent(), "event: notification\ndata: {\"id\":\""+notification.ID+"\"")
+	assert.Equal(t, "event: unread\ndata: {\"unread\":1}\n", readEvent())
+
+	_, err = notifications.MarkRead(context.Background(), "1", notification.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "event: unread\ndata: {\"unread\":0}\n", readEvent())
+}
+
+func TestNotificationDevicesAndPreferences(t *testing.T) {
+	app, _ := notificationApp()
+
+	response := fileRequest(t, app, http.MethodPost, "/me/notifications/devices", "1", `{"platform":"fcm","token":"device-token","name":"Pixel"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	device := &model.Device{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(device))
+	assert.Equal(t, "1", device.UserID)
+
+	response = fileRequest(t, app, http.MethodPost, "/me/notifications/devices", "1", `{"platform":"fcm","token":"device-token","name":"Pixel 9"}`)
+	again := &model.Device{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(again))
+	assert.Equal(t, device.ID, again.ID)
+	assert.Equal(t, "Pixel 9", again.Name)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPost, "/me/notifications/devices", "1", `{"platform":"sms","token":"device-token"}`).StatusCode)
+
+	devices := struct {
+		Data []*model.Device `json:"data"`
+	}{}
+	response = fileRequest(t, app, http.MethodGet, "/me/notifications/devices", "1", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&devices))
+	assert.Len(t, devices.Data, 1)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodDelete, "/me/notifications/devices/"+device.ID, "2", "").StatusCode)
+	assert.Equal(t, 204, fileRequest(t, app, http.MethodDelete, "/me/notifications/devices/"+device.ID, "1", "").StatusCode)
+
+	preferences := &model.NotificationPreferences{}
+	response = fileRequest(t, app, http.MethodGet, "/me/notifications/preferences", "1", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(preferences))
+	assert.True(t, preferences.Push)
+	assert.Empty(t, preferences.Muted)
+
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPut, "/me/notifications/preferences", "1", `{"muted":[]}`).StatusCode)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPut, "/me/notifications/preferences", "1", `{"push":true,"sms":true}`).StatusCode)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPut, "/me/notifications/preferences", "1", `{"push":true,"sms":true,"phone":"0812"}`).StatusCode)
+	response = fileRequest(t, app, http.MethodPut, "/me/notifications/preferences", "1", `{"push":true,"muted":["order.placed"]}`)
+	assert.Equal(t, 200, response.StatusCode)
+	response = fileRequest(t, app, http.MethodGet, "/me/notifications/preferences", "1", "")
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(preferences))
+	assert.Equal(t, []string{"order.placed"}, preferences.Muted)
+}
@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/service"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/token"
+	"golang-fiber-web/web"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var oauthEndpoints = map[string]config.OAuthProviderConfig{
+	"google": {
+		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:    "https://oauth2.googleapis.com/token",
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:      []string{"openid", "email", "profile"},
+	},
+	"github": {
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		EmailsURL:   "https://api.github.com/user/emails",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+}
+
+const (
+	oauthStateCookie    = "oauth_state"
+	oauthVerifierCookie = "oauth_verifier"
+	oauthCookieLifetime = time.Minute * 10
+)
+
+type OAuthHandler struct {
+	providers map[string]config.OAuthProviderConfig
+	baseURL   string
+	auth      service.AuthService
+	client    *httpclient.Client
+	tokens    *token.Keys
+	sessions  service.SessionService
+	captcha   fiber.Handler
+}
+
+// NewOAuthHandler logs users in with the OAuth providers of appConfig, which
+// also signs them up on their first login, once captcha lets them through.
+// Every login starts a session, which the access token issued for it names.
+func NewOAuthHandler(appConfig *config.Config, auth service.AuthService, client *httpclient.Client, tokens *token.Keys, sessions service.SessionService,
+	captcha fiber.Handler) *OAuthHandler {
+	providers := map[string]config.OAuthProviderConfig{}
+	for name, provider := range appConfig.OAuth {
+		if provider.ClientID == "" {
+			continue
+		}
+		defaults := oauthEndpoints[name]
+		if provider.AuthURL == "" {
+			provider.AuthURL = defaults.AuthURL
+		}
+		if provider.TokenURL == "" {
+			provider.TokenURL = defaults.TokenURL
+		}
+		if provider.UserInfoURL == "" {
+			provider.UserInfoURL = defaults.UserInfoURL
+		}
+		if provider.EmailsURL == "" {
+			provider.EmailsURL = defaults.EmailsURL
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = defaults.Scopes
+		}
+		providers[name] = provider
+	}
+
+	return &OAuthHandler{
+		providers: providers,
+		baseURL:   strings.TrimSuffix(appConfig.Server.BaseURL, "/"),
+		auth:      auth,
+		client:    client,
+		tokens:    tokens,
+		sessions:  sessions,
+		captcha:   captcha,
+	}
+}
+
+func (handler *OAuthHandler) Register(router fiber.Router) {
+	router.Get("/:provider/login", handler.captcha, handler.Login).Name("auth.login")
+	router.Get("/:provider/callback", handler.Callback).Name("auth.callback")
+}
+
+func (handler *OAuthHandler) Login(ctx *fiber.Ctx) error {
+	name := ctx.Params("provider")
+	provider, ok := handler.providers[name]
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	callback, err := web.URLFor(ctx, "auth.callback", fiber.Map{"provider": name})
+	if err != nil {
+		return err
+	}
+	state, err := randomString(32)
+	if err != nil {
+		return err
+	}
+	verifier, err := randomString(48)
+	if err != nil {
+		return err
+	}
+	handler.setCookie(ctx, callback, oauthStateCookie, state, oauthCookieLifetime)
+	handler.setCookie(ctx, callback, oauthVerifierCookie, verifier, oauthCookieLifetime)
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{}
+	query.Set("client_id", provider.ClientID)
+	query.Set("redirect_uri", handler.baseURL+callback)
+	query.Set("response_type", "code")
+	query.Set("scope", strings.Join(provider.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	return ctx.Redirect(provider.AuthURL+"?"+query.Encode(), fiber.StatusFound)
+}
+
+func (handler *OAuthHandler) Callback(ctx *fiber.Ctx) error {
+	name := ctx.Params("provider")
+	provider, ok := handler.providers[name]
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	callback, err := web.URLFor(ctx, "auth.callback", fiber.Map{"provider": name})
+	if err != nil {
+		return err
+	}
+	state := ctx.Cookies(oauthStateCookie)
+	verifier := ctx.Cookies(oauthVerifierCookie)
+	handler.setCookie(ctx, callback, oauthStateCookie, "", -time.Hour)
+	handler.setCookie(ctx, callback, oauthVerifierCookie, "", -time.Hour)
+
+	if reason := ctx.Query("error"); reason != "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "oauth login failed: "+reason)
+	}
+	if state == "" || verifier == "" || subtle.ConstantTimeCompare([]byte(state), []byte(ctx.Query("state"))) != 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid oauth state")
+	}
+	code := ctx.Query("code")
+	if code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "missing authorization code")
+	}
+
+	accessToken, err := handler.exchange(ctx, provider, handler.baseURL+callback, code, verifier)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadGateway, err.Error())
+	}
+	profile, err := handler.fetchProfile(ctx, provider, accessToken)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadGateway, err.Error())
+	}
+
+	user, err := handler.auth.Provision(ctx.UserContext(), name, profile)
+	if err != nil {
+		return err
+	}
+	session, err := handler.sessions.Start(ctx.UserContext(), user.ID, ctx.Get(fiber.HeaderUserAgent), ctx.IP())
+	if err != nil {
+		return err
+	}
+	accessToken, err = handler.tokens.Sign(token.Claims{"sub": user.ID, "sid": session.ID, "tenant_id": tenant.From(ctx.UserContext())})
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"user":         user,
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(handler.tokens.TTL().Seconds()),
+	})
+}
+
+func (handler *OAuthHandler) exchange(ctx *fiber.Ctx, provider config.OAuthProviderConfig, redirectURI, code, verifier string) (string, error) {
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+	args.Set("grant_type", "authorization_code")
+	args.Set("code", code)
+	args.Set("redirect_uri", redirectURI)
+	args.Set("client_id", provider.ClientID)
+	args.Set("client_secret", provider.ClientSecret)
+	args.Set("code_verifier", verifier)
+
+	token := struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	err := telemetry.Trace(ctx.UserContext(), "POST "+provider.TokenURL, trace.SpanKindClient, func(spanContext context.Context) error {
+		outbound := web.Outbound(ctx).WithContext(spanContext)
+		response, err := handler.client.Post(spanContext, provider.TokenURL, func(agent *fiber.Agent) {
+			outbound.Propagate(agent).Form(args).Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+		})
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(response.Body, &token)
+		if err != nil {
+			return err
+		}
+		if response.Status != fiber.StatusOK || token.AccessToken == "" {
+			return fmt.Errorf("token exchange failed with status %d: %s %s", response.Status, token.Error, token.ErrorDescription)
+		}
+		return nil
+	})
+	return token.AccessToken, err
+}
+
+func (handler *OAuthHandler) fetchProfile(ctx *fiber.Ctx, provider config.OAuthProviderConfig, accessToken string) (*service.OAuthProfile, error) {
+	info := map[string]interface{}{}
+	err := handler.getJSON(ctx, provider.UserInfoURL, accessToken, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &service.OAuthProfile{
+		Subject:  claimString(info, "sub", "id"),
+		Email:    claimString(info, "email"),
+		Username: claimString(info, "preferred_username", "login", "email"),
+		Name:     claimString(info, "name"),
+	}
+	if profile.Subject == "" {
+		return nil, errors.New("provider did not return an account id")
+	}
+
+	if verified, ok := info["email_verified"].(bool); ok {
+		profile.EmailVerified = verified
+	} else if provider.EmailsURL != "" {
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		err = handler.getJSON(ctx, provider.EmailsURL, accessToken, &emails)
+		if err != nil {
+			return nil, err
+		}
+		for _, email := range emails {
+			if email.Primary && email.Verified {
+				profile.Email = email.Email
+				profile.EmailVerified = true
+			}
+		}
+	}
+
+	return profile, nil
+}
+
+// setCookie scopes the cookie to the directory of the callback path, which
+// also holds the login route of the provider.
+func (handler *OAuthHandler) setCookie(ctx *fiber.Ctx, callback, name, value string, lifetime time.Duration) {
+	ctx.Cookie(&fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path.Dir(callback),
+		Expires:  time.Now().Add(lifetime),
+		Secure:   strings.HasPrefix(handler.baseURL, "https://"),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func (handler *OAuthHandler) getJSON(ctx *fiber.Ctx, url, accessToken string, result interface{}) error {
+	return telemetry.Trace(ctx.UserContext(), "GET "+url, trace.SpanKindClient, func(spanContext context.Context) error {
+		outbound := web.Outbound(ctx).WithContext(spanContext)
+		response, err := handler.client.Get(spanContext, url, func(agent *fiber.Agent) {
+			outbound.Propagate(agent).
+				Set(fiber.HeaderAuthorization, "Bearer "+accessToken).
+				Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+		})
+		if err != nil {
+			return err
+		}
+		if response.Status != fiber.StatusOK {
+			return fmt.Errorf("GET %s failed with status %d: %s", url, response.Status, response.Body)
+		}
+		return json.Unmarshal(response.Body, result)
+	})
+}
+
+func claimString(claims map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch value := claims[key].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", value)
+		}
+	}
+	return ""
+}
+
+func randomString(size int) (string, error) {
+	buffer := make([]byte, size)
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
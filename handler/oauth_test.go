@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func newFakeOAuthProvider(t *testing.T, userInfo map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(writer http.ResponseWriter, request *http.Request) {
+		assert.Nil(t, request.ParseForm())
+		if request.PostForm.Get("code") != "valid-code" || request.PostForm.Get("code_verifier") == "" {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte(`{"access_token":"access-token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/userinfo", func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "Bearer access-token", request.Header.Get("Authorization"))
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(userInfo)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newOAuthApp(t *testing.T, provider *httptest.Server, users repository.UserRepository) (*fiber.App, *token.Keys) {
+	appConfig := config.Default()
+	appConfig.OAuth["google"] = config.OAuthProviderConfig{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		AuthURL:      provider.URL + "/authorize",
+		TokenURL:     provider.URL + "/token",
+		UserInfoURL:  provider.URL + "/userinfo",
+	}
+
+	tokens := token.NewKeys(appConfig.JWT, repository.NewMemorySigningKeyRepository())
+	assert.Nil(t, tokens.Rotate(context.Background()))
+
+	audit := service.NewAuditService(repository.NewMemoryAuditRepository())
+	sessions := service.NewSessionService(repository.NewMemorySessionRepository(), audit, appConfig.JWT.TTL)
+
+	oauthApp := fiber.New()
+	Mount(oauthApp, "/auth", NewOAuthHandler(appConfig, service.NewAuthService(users, repository.NewMemoryTransactor(), audit, event.Discard), httpclient.New(appConfig.HTTPClient), tokens, sessions, func(ctx *fiber.Ctx) error {
+		return ctx.Next()
+	}))
+	return oauthApp, tokens
+}
+
+func oauthLogin(t *testing.T, oauthApp *fiber.App) (string, []*http.Cookie) {
+	request := httptest.NewRequest(http.MethodGet, "/auth/google/login", nil)
+	response, err := oauthApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 302, response.StatusCode)
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	assert.Nil(t, err)
+	return location.Query().Get("state"), response.Cookies()
+}
+
+func TestOAuthLoginRedirect(t *testing.T) {
+	provider := newFakeOAuthProvider(t, nil)
+	defer provider.Close()
+	oauthApp, _ := newOAuthApp(t, provider, repository.NewMemoryUserRepository())
+
+	request := httptest.NewRequest(http.MethodGet, "/auth/google/login", nil)
+	response, err := oauthApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 302, response.StatusCode)
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, provider.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(t, "client-id", location.Query().Get("client_id"))
+	assert.Equal(t, "http://localhost:8080/auth/google/callback", location.Query().Get("redirect_uri"))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+	assert.NotEmpty(t, location.Query().Get("code_challenge"))
+	assert.NotEmpty(t, location.Query().Get("state"))
+	assert.Len(t, response.Cookies(), 2)
+
+	request = httptest.NewRequest(http.MethodGet, "/auth/gitlab/login", nil)
+	response, err = oauthApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestOAuthCallbackProvisionsUser(t *testing.T) {
+	provider := newFakeOAuthProvider(t, map[string]interface{}{
+		"sub":            "google-123",
+		"email":          "brian@example.com",
+		"email_verified": true,
+		"name":           "Brian Anashari",
+	})
+	defer provider.Close()
+	users := repository.NewMemoryUserRepository()
+	oauthApp, tokens := newOAuthApp(t, provider, users)
+
+	state, cookies := oauthLogin(t, oauthApp)
+	request := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=valid-code&state="+state, nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	response, err := oauthApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	user, err := users.FindByIdentity(context.Background(), "google", "google-123")
+	assert.Nil(t, err)
+	assert.Equal(t, "brian@example.com", user.Email)
+	assert.Equal(t, "Brian Anashari", user.Name)
+
+	body := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, "Bearer", body.TokenType)
+	assert.Equal(t, 900, body.ExpiresIn)
+	claims, err := tokens.Verify(context.Background(), body.AccessToken)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, claims["sub"])
+	assert.NotEmpty(t, claims["sid"])
+	assert.Equal(t, "", claims["tenant_id"], "the token carries the tenant it was issued in")
+}
+
+func TestOAuthCallbackLinksLocalAccount(t *testing.T) {
+	provider := newFakeOAuthProvider(t, map[string]interface{}{
+		"sub":            "google-456",
+		"email":          "Brian@Example.com",
+		"email_verified": true,
+	})
+	defer provider.Close()
+	users := repository.NewMemoryUserRepository()
+	local := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), local))
+	oauthApp, _ := newOAuthApp(t, provider, users)
+
+	state, cookies := oauthLogin(t, oauthApp)
+	request := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=valid-code&state="+state, nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	response, err := oauthApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	user, err := users.FindByIdentity(context.Background(), "google", "google-456")
+	assert.Nil(t, err)
+	assert.Equal(t, local.ID, user.ID)
+}
+
+func TestOAuthCallbackInvalidState(t *testing.T) {
+	provider := newFakeOAuthProvider(t, nil)
+	defer provider.Close()
+	oauthApp, _ := newOAuthApp(t, provider, repository.NewMemoryUserRepository())
+
+	_, cookies := oauthLogin(t, oauthApp)
+	request := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=valid-code&state=forged", nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	response, err := oauthApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "invalid oauth state", string(bytes))
+}
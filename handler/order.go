@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"time"
+)
+
+var orderListOptions = web.ListOptions{
+	Sortable:   []string{"created_at", "updated_at", "total", "status"},
+	Filterable: []string{"status", "currency", "payment_status"},
+	Searchable: true,
+}
+
+// OrderHandler manages the orders of the user of :userId, mounted at
+// /users/:userId/orders. Only that user, as authenticated by an access token
+// or an API key, reaches their orders, and may only cancel them: orders are
+// paid through the payment provider, and shipped and delivered by the admin.
+type OrderHandler struct {
+	orders  service.OrderService
+	reports service.ReportService
+	owner   fiber.Handler
+	admin   fiber.Handler
+}
+
+func NewOrderHandler(orders service.OrderService, reports service.ReportService, admin config.AdminConfig) *OrderHandler {
+	return &OrderHandler{orders: orders, reports: reports, owner: owner("orders"), admin: middleware.NewAdminAuth(admin)}
+}
+
+func (handler *OrderHandler) Register(router fiber.Router) {
+	router.Get("", handler.owner, handler.List).Name("orders.list")
+	router.Post("", handler.owner, handler.Create).Name("orders.create")
+	router.Get("/:orderId", handler.owner, handler.Get).Name("orders.show")
+	router.Put("/:orderId", handler.owner, handler.Update).Name("orders.update")
+	router.Delete("/:orderId", handler.owner, handler.Delete).Name("orders.delete")
+	router.Post("/:orderId/status", handler.owner, handler.Cancel).Name("orders.transition")
+	router.Post("/:orderId/fulfillment", handler.admin, handler.Transition).Name("orders.fulfill")
+	router.Get("/:orderId/invoice", handler.owner, handler.Invoice).Name("orders.invoice")
+}
+
+// List lists the orders of the user, newest first. ?from and ?to keep the
+// orders placed within those dates or times; a date given to ?to includes
+// that whole day.
+func (handler *OrderHandler) List(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, orderListOptions)
+	if err != nil {
+		return err
+	}
+	created, err := parseTimeRange(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		return err
+	}
+	orders, total, err := handler.orders.List(ctx.UserContext(), ctx.Params("userId"), created, spec)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       orders,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+func (handler *OrderHandler) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateOrderRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	order, err := handler.orders.Place(ctx.UserContext(), ctx.Params("userId"), request)
+	if err != nil {
+		return orderError(err)
+	}
+	return web.Respond(ctx, fiber.StatusCreated, order)
+}
+
+func (handler *OrderHandler) Get(ctx *fiber.Ctx) error {
+	order, err := handler.orders.Find(ctx.UserContext(), ctx.Params("userId"), ctx.Params("orderId"))
+	if err != nil {
+		return orderError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, order)
+}
+
+func (handler *OrderHandler) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateOrderRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	order, err := handler.orders.Update(ctx.UserContext(), ctx.Params("userId"), ctx.Params("orderId"), request)
+	if err != nil {
+		return orderError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, order)
+}
+
+func (handler *OrderHandler) Delete(ctx *fiber.Ctx) error {
+	err := handler.orders.Delete(ctx.UserContext(), ctx.Params("userId"), ctx.Params("orderId"))
+	if err != nil {
+		return orderError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// Cancel is the status change open to the user: to cancelled, the only
+// status the body may give.
+func (handler *OrderHandler) Cancel(ctx *fiber.Ctx) error {
+	request := new(model.TransitionOrderRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	if request.Status != model.OrderCancelled {
+		return fiber.NewError(fiber.StatusForbidden, "orders can only be cancelled; they are paid through checkout and fulfilled by the shop")
+	}
+	return handler.transition(ctx, request)
+}
+
+// Transition moves the order to the status of the body for the admin, such as
+// to shipped, answering 409 when the order cannot go there from its current
+// status.
+func (handler *OrderHandler) Transition(ctx *fiber.Ctx) error {
+	request := new(model.TransitionOrderRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	return handler.transition(ctx, request)
+}
+
+func (handler *OrderHandler) transition(ctx *fiber.Ctx, request *model.TransitionOrderRequest) error {
+	order, err := handler.orders.Transition(ctx.UserContext(), ctx.Params("userId"), ctx.Params("orderId"), request)
+	if err != nil {
+		return orderError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, order)
+}
+
+// Invoice downloads the invoice of the order as a PDF.
+func (handler *OrderHandler) Invoice(ctx *fiber.Ctx) error {
+	document, err := handler.reports.Invoice(ctx.UserContext(), ctx.Params("userId"), ctx.Params("orderId"))
+	if err != nil {
+		return orderError(err)
+	}
+	return sendPDF(ctx, "invoice-"+ctx.Params("orderId")+".pdf", document)
+}
+
+// owner lets through the requests of the user of :userId only, to their
+// resources, as named in the error of the other users.
+func owner(resources string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id := userID(ctx)
+		if id == "" {
+			return authenticationRequired(ctx)
+		}
+		if id != ctx.Params("userId") {
+			return fiber.NewError(fiber.StatusForbidden, resources+" of other users are not accessible")
+		}
+		return ctx.Next()
+	}
+}
+
+// authenticated lets through the requests of a user authenticated by an
+// access token or an API key.
+func authenticated(ctx *fiber.Ctx) error {
+	if userID(ctx) == "" {
+		return authenticationRequired(ctx)
+	}
+	return ctx.Next()
+}
+
+func authenticationRequired(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+	return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+}
+
+// parseTimeRange parses ?from and ?to, each either a date or an RFC 3339
+// time.
+func parseTimeRange(from, to string) (model.TimeRange, error) {
+	var timeRange model.TimeRange
+	var err error
+	if from != "" {
+		timeRange.From, _, err = parseDateOrTime(from)
+		if err != nil {
+			return timeRange, fiber.NewError(fiber.StatusBadRequest, "from must be a date or an RFC 3339 time")
+		}
+	}
+	if to != "" {
+		var date bool
+		timeRange.To, date, err = parseDateOrTime(to)
+		if err != nil {
+			return timeRange, fiber.NewError(fiber.StatusBadRequest, "to must be a date or an RFC 3339 time")
+		}
+		if date {
+			timeRange.To = timeRange.To.AddDate(0, 0, 1)
+		}
+	}
+	return timeRange, nil
+}
+
+func parseDateOrTime(value string) (time.Time, bool, error) {
+	at, err := time.Parse(time.DateOnly, value)
+	if err == nil {
+		return at, true, nil
+	}
+	at, err = time.Parse(time.RFC3339, value)
+	return at, false, err
+}
+
+func orderError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrOrderNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrOrderNotEditable), errors.Is(err, model.ErrInvalidOrderTransition):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return err
+}
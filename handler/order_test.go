@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/testfactory"
+	"golang-fiber-web/web"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// orderApp serves the orders of the users, authenticating the user named by
+// the X-User header like fileApp.
+func orderApp(t testing.TB) (*fiber.App, repository.OrderRepository, *recordingPublisher) {
+	orders := repository.NewMemoryOrderRepository()
+	published := &recordingPublisher{}
+	orderService := service.NewOrderService(orders, repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()), published)
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	reportService := service.NewReportService(config.ReportConfig{InlineMaxOrders: 10}, repository.NewMemoryUserRepository(), orders,
+		repository.NewMemoryReportRepository(), storage.NewLocal(t.TempDir()), repository.NewMemoryTransactor(), published)
+	Mount(app, "/users/:userId/orders", NewOrderHandler(orderService, reportService, config.AdminConfig{Username: "admin", Password: "secret"}))
+	return app, orders, published
+}
+
+func adminOrderRequest(t *testing.T, app *fiber.App, target, body string) *http.Response {
+	request := testfactory.NewRequest(t, http.MethodPost, target).BasicAuth("admin", "secret").JSON(body).Build()
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func decodeOrder(t *testing.T, response *http.Response) *model.Order {
+	order := &model.Order{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(order))
+	return order
+}
+
+func TestOrders(t *testing.T) {
+	app, orders, published := orderApp(t)
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodGet, "/users/1/orders", "", "").StatusCode)
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodGet, "/users/1/orders", "2", "").StatusCode)
+
+	response := fileRequest(t, app, http.MethodPost, "/users/1/orders", "1", `{"currency":"idr","items":[{"name":"Book","quantity":2,"unit_price":50000},{"name":"Pen","quantity":1,"unit_price":5000}]}`)
+	assert.Equal(t, 201, response.StatusCode)
+	order := decodeOrder(t, response)
+	assert.Equal(t, model.OrderPending, order.Status)
+	assert.Equal(t, int64(105000), order.Total)
+	assert.Equal(t, "IDR", order.Currency)
+	assert.Equal(t, model.PaymentUnpaid, order.PaymentStatus)
+	assert.Equal(t, event.NameOrderPlaced, published.events[0].Name())
+
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPost, "/users/1/orders", "1", `{"currency":"IDR","items":[]}`).StatusCode)
+	assert.Equal(t, 422, fileRequest(t, app, http.MethodPost, "/users/1/orders", "1", `{"currency":"IDR","items":[{"name":"Book","quantity":0}]}`).StatusCode)
+
+	response = fileRequest(t, app, http.MethodGet, "/users/1/orders/"+order.ID, "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, order.ID, decodeOrder(t, response).ID)
+
+	response = fileRequest(t, app, http.MethodPut, "/users/1/orders/"+order.ID, "1", `{"items":[{"name":"Book","quantity":1,"unit_price":50000}],"notes":"gift wrap"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	updated := decodeOrder(t, response)
+	assert.Equal(t, int64(50000), updated.Total)
+	assert.Equal(t, "gift wrap", updated.Notes)
+
+	// Only the payment provider pays orders, and only the admin ships them.
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodPost, "/users/1/orders/"+order.ID+"/status", "1", `{"status":"paid"}`).StatusCode)
+	assert.Equal(t, 422, adminOrderRequest(t, app, "/users/1/orders/"+order.ID+"/fulfillment", `{"status":"paid"}`).StatusCode)
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodPost, "/users/1/orders/"+order.ID+"/status", "1", `{"status":"shipped"}`).StatusCode)
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodPost, "/users/1/orders/"+order.ID+"/fulfillment", "1", `{"status":"shipped"}`).StatusCode)
+	response = adminOrderRequest(t, app, "/users/1/orders/"+order.ID+"/fulfillment", `{"status":"shipped"}`)
+	assert.Equal(t, 409, response.StatusCode)
+	paid, err := orders.FindByID(context.Background(), order.ID)
+	assert.Nil(t, err)
+	paid.Status = model.OrderPaid
+	assert.Nil(t, orders.Update(context.Background(), paid))
+	response = adminOrderRequest(t, app, "/users/1/orders/"+order.ID+"/fulfillment", `{"status":"shipped"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, model.OrderShipped, decodeOrder(t, response).Status)
+	assert.Equal(t, event.OrderStatusChanged{OrderID: order.ID, UserID: "1", From: model.OrderPaid, To: model.OrderShipped}, published.events[1])
+
+	assert.Equal(t, 409, fileRequest(t, app, http.MethodPut, "/users/1/orders/"+order.ID, "1", `{"items":[{"name":"Pen","quantity":1}]}`).StatusCode)
+	assert.Equal(t, 409, fileRequest(t, app, http.MethodDelete, "/users/1/orders/"+order.ID, "1", "").StatusCode)
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodGet, "/users/2/orders/"+order.ID, "1", "").StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/users/2/orders/"+order.ID, "2", "").StatusCode)
+
+	response = fileRequest(t, app, http.MethodPost, "/users/1/orders", "1", `{"currency":"IDR","items":[{"name":"Pen","quantity":1,"unit_price":5000}]}`)
+	pending := decodeOrder(t, response)
+	response = fileRequest(t, app, http.MethodPost, "/users/1/orders/"+pending.ID+"/status", "1", `{"status":"cancelled"}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, model.OrderCancelled, decodeOrder(t, response).Status)
+
+	response = fileRequest(t, app, http.MethodPost, "/users/1/orders", "1", `{"currency":"IDR","items":[{"name":"Pen","quantity":1,"unit_price":5000}]}`)
+	pending = decodeOrder(t, response)
+	assert.Equal(t, 204, fileRequest(t, app, http.MethodDelete, "/users/1/orders/"+pending.ID, "1", "").StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/users/1/orders/"+pending.ID, "1", "").StatusCode)
+}
+
+func TestListOrders(t *testing.T) {
+	app, orders, _ := orderApp(t)
+	ctx := context.Background()
+	for _, order := range []*model.Order{
+		{UserID: "1", Status: model.OrderPending, Currency: "IDR", CreatedAt: time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)},
+		{UserID: "1", Status: model.OrderPaid, Currency: "IDR", CreatedAt: time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC)},
+		{UserID: "1", Status: model.OrderPaid, Currency: "IDR", CreatedAt: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{UserID: "2", Status: model.OrderPaid, Currency: "IDR", CreatedAt: time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC)},
+	} {
+		assert.Nil(t, orders.Create(ctx, order))
+	}
+
+	list := func(query string) []model.Order {
+		response := fileRequest(t, app, http.MethodGet, "/users/1/orders"+query, "1", "")
+		assert.Equal(t, 200, response.StatusCode)
+		var body struct {
+			Data []model.Order `json:"data"`
+		}
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+		return body.Data
+	}
+
+	all := list("")
+	assert.Len(t, all, 3)
+	assert.Equal(t, time.March, all[0].CreatedAt.Month())
+	assert.Len(t, list("?filter[status]=paid"), 2)
+	assert.Len(t, list("?from=2024-02-01"), 2)
+	assert.Len(t, list("?from=2024-02-01&to=2024-02-10"), 1)
+	assert.Len(t, list("?to=2024-02-10T00:00:00Z"), 1)
+	assert.Len(t, list("?filter[status]=paid&to=2024-02-29"), 1)
+
+	assert.Equal(t, 400, fileRequest(t, app, http.MethodGet, "/users/1/orders?from=yesterday", "1", "").StatusCode)
+	assert.Equal(t, 400, fileRequest(t, app, http.MethodGet, "/users/1/orders?filter[notes]=x", "1", "").StatusCode)
+}
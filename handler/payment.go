@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// PaymentHandler starts the payments of the orders of the user of the
+// request. Their outcome comes from the webhooks of the payment provider.
+type PaymentHandler struct {
+	payments service.PaymentService
+}
+
+func NewPaymentHandler(payments service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{payments: payments}
+}
+
+func (handler *PaymentHandler) Register(router fiber.Router) {
+	router.Post("/checkout", authenticated, handler.Checkout).Name("payments.checkout")
+}
+
+// Checkout starts the payment of an order, answering the URL to send the
+// customer to.
+func (handler *PaymentHandler) Checkout(ctx *fiber.Ctx) error {
+	request := new(model.CheckoutRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	checkout, err := handler.payments.Checkout(ctx.UserContext(), userID(ctx), request)
+	if errors.Is(err, model.ErrOrderNotPayable) {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return orderError(err)
+	}
+	return web.Respond(ctx, fiber.StatusCreated, checkout)
+}
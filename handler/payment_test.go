@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"net/http"
+	"testing"
+)
+
+type fakeCheckoutProvider struct {
+	orders []string
+}
+
+func (provider *fakeCheckoutProvider) CreateCheckout(ctx context.Context, order *model.Order) (*model.Checkout, error) {
+	provider.orders = append(provider.orders, order.ID)
+	return &model.Checkout{ID: "cs_" + order.ID, URL: "https://checkout.stripe.com/c/pay/cs_" + order.ID}, nil
+}
+
+func TestPayments(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+	provider := &fakeCheckoutProvider{}
+	published := &recordingPublisher{}
+	payments := service.NewPaymentService(orders, provider, repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()), published)
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	Mount(app, "/payments", NewPaymentHandler(payments))
+
+	ctx := context.Background()
+	order := &model.Order{UserID: "1", Status: model.OrderPending, PaymentStatus: model.PaymentUnpaid, Currency: "IDR",
+		Items: []model.OrderItem{{Name: "Book", Quantity: 1, UnitPrice: 50000}}}
+	assert.Nil(t, orders.Create(ctx, order))
+
+	assert.Equal(t, 401, fileRequest(t, app, http.MethodPost, "/payments/checkout", "", `{"order_id":"`+order.ID+`"}`).StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodPost, "/payments/checkout", "2", `{"order_id":"`+order.ID+`"}`).StatusCode)
+
+	response := fileRequest(t, app, http.MethodPost, "/payments/checkout", "1", `{"order_id":"`+order.ID+`"}`)
+	assert.Equal(t, 201, response.StatusCode)
+	var checkout model.Checkout
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&checkout))
+	assert.Equal(t, "cs_"+order.ID, checkout.ID)
+	pending, err := orders.FindByID(ctx, order.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.PaymentPending, pending.PaymentStatus)
+
+	completed := event.WebhookReceived{Provider: "stripe", EventID: "evt_1", Type: "checkout.session.completed",
+		Payload: json.RawMessage(`{"data":{"object":{"id":"cs_` + order.ID + `","client_reference_id":"` + order.ID + `","payment_status":"paid"}}}`)}
+	assert.Nil(t, payments.HandleWebhook(ctx, completed))
+	assert.Nil(t, payments.HandleWebhook(ctx, completed))
+	assert.Nil(t, payments.HandleWebhook(ctx, event.WebhookReceived{Provider: "stripe", EventID: "evt_2", Type: "checkout.session.expired", Payload: completed.Payload}))
+
+	paid, err := orders.FindByID(ctx, order.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.OrderPaid, paid.Status)
+	assert.Equal(t, model.PaymentPaid, paid.PaymentStatus)
+	assert.Equal(t, []event.Event{event.OrderStatusChanged{OrderID: order.ID, UserID: "1", From: model.OrderPending, To: model.OrderPaid}}, published.events)
+
+	assert.Equal(t, 409, fileRequest(t, app, http.MethodPost, "/payments/checkout", "1", `{"order_id":"`+order.ID+`"}`).StatusCode)
+	assert.Equal(t, []string{order.ID}, provider.orders)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+var productListOptions = web.ListOptions{
+	Sortable:   []string{"name", "price", "stock", "created_at", "updated_at"},
+	Filterable: []string{"category", "currency"},
+	Searchable: true,
+}
+
+// ProductHandler serves the catalog to anyone, and lets the admin manage it
+// with basic auth.
+type ProductHandler struct {
+	products service.ProductService
+	admin    fiber.Handler
+}
+
+func NewProductHandler(products service.ProductService, admin config.AdminConfig) *ProductHandler {
+	return &ProductHandler{products: products, admin: middleware.NewAdminAuth(admin)}
+}
+
+func (handler *ProductHandler) Register(router fiber.Router) {
+	router.Get("", handler.List).Name("products.list")
+	router.Get("/categories", handler.Categories).Name("products.categories")
+	router.Get("/:id", handler.Get).Name("products.show")
+	router.Get("/:id/images/:imageId", handler.Image).Name("products.image")
+	router.Post("", handler.admin, handler.Create).Name("products.create")
+	router.Put("/:id", handler.admin, handler.Update).Name("products.update")
+	router.Delete("/:id", handler.admin, handler.Delete).Name("products.delete")
+	router.Post("/:id/images", handler.admin, handler.AddImage).Name("products.images.add")
+	router.Delete("/:id/images/:imageId", handler.admin, handler.DeleteImage).Name("products.images.delete")
+}
+
+// List lists the products by name. ?in_stock=true leaves out the products
+// out of stock.
+func (handler *ProductHandler) List(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, productListOptions)
+	if err != nil {
+		return err
+	}
+	products, total, err := handler.products.List(ctx.UserContext(), ctx.QueryBool("in_stock"), spec)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       products,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+// Categories lists the categories of the products along with how many
+// products each has.
+func (handler *ProductHandler) Categories(ctx *fiber.Ctx) error {
+	categories, err := handler.products.Categories(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"data": categories})
+}
+
+func (handler *ProductHandler) Get(ctx *fiber.Ctx) error {
+	product, err := handler.products.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return productError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, product)
+}
+
+func (handler *ProductHandler) Create(ctx *fiber.Ctx) error {
+	request := new(model.SaveProductRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	product, err := handler.products.Create(ctx.UserContext(), request)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusCreated, product)
+}
+
+func (handler *ProductHandler) Update(ctx *fiber.Ctx) error {
+	request := new(model.SaveProductRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	product, err := handler.products.Update(ctx.UserContext(), ctx.Params("id"), request)
+	if err != nil {
+		return productError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, product)
+}
+
+func (handler *ProductHandler) Delete(ctx *fiber.Ctx) error {
+	err := handler.products.Delete(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return productError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// AddImage adds the image of the multipart field "image" to the product.
+func (handler *ProductHandler) AddImage(ctx *fiber.Ctx) error {
+	header, err := ctx.FormFile("image")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "missing image")
+	}
+	content, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	image, err := handler.products.AddImage(ctx.UserContext(), ctx.Params("id"), header.Size, content)
+	if err != nil {
+		return productError(err)
+	}
+	return web.Respond(ctx, fiber.StatusCreated, image)
+}
+
+func (handler *ProductHandler) DeleteImage(ctx *fiber.Ctx) error {
+	err := handler.products.DeleteImage(ctx.UserContext(), ctx.Params("id"), ctx.Params("imageId"))
+	if err != nil {
+		return productError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// Image answers an image of the product. An image never changes once
+// uploaded, so it is cached for good.
+func (handler *ProductHandler) Image(ctx *fiber.Ctx) error {
+	content, image, err := handler.products.OpenImage(ctx.UserContext(), ctx.Params("id"), ctx.Params("imageId"))
+	if err != nil {
+		return productError(err)
+	}
+	ctx.Set(fiber.HeaderContentType, image.ContentType)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	return ctx.SendStream(content, int(image.Size))
+}
+
+func productError(err error) error {
+	if errors.Is(err, model.ErrProductNotFound) || errors.Is(err, model.ErrProductImageNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return err
+}
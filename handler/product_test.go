@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/web"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func productApp(t *testing.T) *fiber.App {
+	products := service.NewProductService(config.ProductConfig{MaxImages: 1, ImageMaxSize: 1 << 20}, repository.NewMemoryProductRepository(),
+		storage.NewLocal(t.TempDir()), repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()))
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	Mount(app, "/products", NewProductHandler(products, config.AdminConfig{Username: "admin", Password: "secret"}))
+	return app
+}
+
+func productRequest(t *testing.T, app *fiber.App, method, target string, admin bool, body string) *http.Response {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if admin {
+		request.SetBasicAuth("admin", "secret")
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func createProduct(t *testing.T, app *fiber.App, body string) *model.Product {
+	response := productRequest(t, app, http.MethodPost, "/products", true, body)
+	assert.Equal(t, 201, response.StatusCode)
+	product := &model.Product{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(product))
+	return product
+}
+
+func TestProducts(t *testing.T) {
+	app := productApp(t)
+
+	assert.Equal(t, 401, productRequest(t, app, http.MethodPost, "/products", false, `{"name":"Book"}`).StatusCode)
+	assert.Equal(t, 422, productRequest(t, app, http.MethodPost, "/products", true, `{"name":"Book","category":"books","currency":"IDR","stock":-1}`).StatusCode)
+
+	book := createProduct(t, app, `{"name":"Go Book","category":"books","price":150000,"currency":"idr","stock":3}`)
+	assert.Equal(t, "IDR", book.Currency)
+	createProduct(t, app, `{"name":"Fiber Book","category":"books","price":120000,"currency":"IDR","stock":0}`)
+	createProduct(t, app, `{"name":"Gopher Mug","category":"mugs","price":50000,"currency":"IDR","stock":10}`)
+
+	list := func(query string) []model.Product {
+		response := productRequest(t, app, http.MethodGet, "/products"+query, false, "")
+		assert.Equal(t, 200, response.StatusCode)
+		var body struct {
+			Data []model.Product `json:"data"`
+		}
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+		return body.Data
+	}
+	all := list("")
+	assert.Len(t, all, 3)
+	assert.Equal(t, "Fiber Book", all[0].Name)
+	assert.Len(t, list("?filter[category]=books"), 2)
+	assert.Len(t, list("?filter[category]=books&in_stock=true"), 1)
+	assert.Equal(t, "Gopher Mug", list("?sort=price&per_page=1")[0].Name)
+	assert.Len(t, list("?q=gopher"), 1)
+
+	response := productRequest(t, app, http.MethodGet, "/products/categories", false, "")
+	assert.Equal(t, 200, response.StatusCode)
+	var categories struct {
+		Data []model.ProductCategory `json:"data"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&categories))
+	assert.Equal(t, []model.ProductCategory{{Name: "books", Products: 2}, {Name: "mugs", Products: 1}}, categories.Data)
+
+	response = productRequest(t, app, http.MethodPut, "/products/"+book.ID, true, `{"name":"Go Book","category":"books","price":140000,"currency":"IDR","stock":2}`)
+	assert.Equal(t, 200, response.StatusCode)
+	response = productRequest(t, app, http.MethodGet, "/products/"+book.ID, false, "")
+	assert.Equal(t, 200, response.StatusCode)
+	updated := &model.Product{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(updated))
+	assert.Equal(t, int64(140000), updated.Price)
+	assert.Equal(t, 2, updated.Stock)
+
+	assert.Equal(t, 401, productRequest(t, app, http.MethodDelete, "/products/"+book.ID, false, "").StatusCode)
+	assert.Equal(t, 204, productRequest(t, app, http.MethodDelete, "/products/"+book.ID, true, "").StatusCode)
+	assert.Equal(t, 404, productRequest(t, app, http.MethodGet, "/products/"+book.ID, false, "").StatusCode)
+}
+
+func TestProductImages(t *testing.T) {
+	app := productApp(t)
+	product := createProduct(t, app, `{"name":"Gopher Mug","category":"mugs","price":50000,"currency":"IDR","stock":10}`)
+
+	addImage := func(content []byte) *http.Response {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("image", "mug.png")
+		assert.Nil(t, err)
+		part.Write(content)
+		assert.Nil(t, writer.Close())
+
+		request := httptest.NewRequest(http.MethodPost, "/products/"+product.ID+"/images", &body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		request.SetBasicAuth("admin", "secret")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	assert.Equal(t, 422, addImage([]byte("not an image")).StatusCode)
+	var content bytes.Buffer
+	assert.Nil(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 20, 10))))
+	response := addImage(content.Bytes())
+	assert.Equal(t, 201, response.StatusCode)
+	var added model.ProductImage
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&added))
+	assert.Equal(t, "image/png", added.ContentType)
+	assert.Equal(t, 422, addImage(content.Bytes()).StatusCode)
+
+	response = productRequest(t, app, http.MethodGet, "/products/"+product.ID+"/images/"+added.ID, false, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/png", response.Header.Get("Content-Type"))
+	assert.Contains(t, response.Header.Get("Cache-Control"), "immutable")
+	served, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, content.Bytes(), served)
+
+	assert.Equal(t, 204, productRequest(t, app, http.MethodDelete, "/products/"+product.ID+"/images/"+added.ID, true, "").StatusCode)
+	assert.Equal(t, 404, productRequest(t, app, http.MethodGet, "/products/"+product.ID+"/images/"+added.ID, false, "").StatusCode)
+}
+
+func TestProductsAreScopedByTenant(t *testing.T) {
+	products := service.NewProductService(config.ProductConfig{MaxImages: 1, ImageMaxSize: 1 << 20}, repository.NewMemoryProductRepository(),
+		storage.NewLocal(t.TempDir()), repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()))
+	tenancy := config.Default().Tenancy
+	tenancy.Enabled = true
+	tenancy.Tenants = map[string]config.TenantConfig{"acme": {}, "globex": {}}
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(tenant.New(tenancy))
+	Mount(app, "/products", NewProductHandler(products, config.AdminConfig{Username: "admin", Password: "secret"}))
+
+	send := func(method, target, tenantID, body string) *http.Response {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Tenant-ID", tenantID)
+		request.SetBasicAuth("admin", "secret")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response := send(http.MethodPost, "/products", "acme", `{"name":"Go Book","category":"books","price":150000,"currency":"IDR","stock":3}`)
+	assert.Equal(t, 201, response.StatusCode)
+	book := &model.Product{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(book))
+	assert.Equal(t, "acme", book.TenantID)
+
+	assert.Equal(t, 200, send(http.MethodGet, "/products/"+book.ID, "acme", "").StatusCode)
+	assert.Equal(t, 404, send(http.MethodGet, "/products/"+book.ID, "globex", "").StatusCode)
+	assert.Equal(t, 404, send(http.MethodDelete, "/products/"+book.ID, "globex", "").StatusCode)
+
+	response = send(http.MethodGet, "/products", "globex", "")
+	assert.Equal(t, 200, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(body), book.ID)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/proxy"
+	"github.com/valyala/fasthttp"
+	"golang-fiber-web/config"
+	"strings"
+	"time"
+)
+
+const defaultProxyTimeout = time.Second * 30
+
+// ProxyHandler forwards every request it receives to an upstream service, so
+// the app can front services that have moved out of it.
+type ProxyHandler struct {
+	config config.ProxyRouteConfig
+	client *fasthttp.Client
+}
+
+func NewProxyHandler(config config.ProxyRouteConfig) *ProxyHandler {
+	if config.Timeout == 0 {
+		config.Timeout = defaultProxyTimeout
+	}
+	return &ProxyHandler{config: config, client: &fasthttp.Client{
+		NoDefaultUserAgentHeader: true,
+		DisablePathNormalizing:   true,
+	}}
+}
+
+// Register forwards every method and path. The handler is meant to be
+// mounted at the prefix of its config.
+func (handler *ProxyHandler) Register(router fiber.Router) {
+	router.All("/*", handler.Forward).Name("proxy." + handler.config.Name)
+}
+
+// Forward sends the request upstream with the configured header rewrites
+// and X-Forwarded-* headers, and answers with the upstream response. An
+// upstream that cannot be reached is answered 502, one that times out 504.
+func (handler *ProxyHandler) Forward(ctx *fiber.Ctx) error {
+	path := ctx.Path()
+	if handler.config.StripPrefix {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(handler.config.Prefix, "/"))
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	target := strings.TrimSuffix(handler.config.Upstream, "/") + path
+	if query := ctx.Request().URI().QueryString(); len(query) > 0 {
+		target += "?" + string(query)
+	}
+
+	request := &ctx.Request().Header
+	forwardedFor := ctx.IP()
+	if previous := ctx.Get(fiber.HeaderXForwardedFor); previous != "" {
+		forwardedFor = previous + ", " + forwardedFor
+	}
+	request.Set(fiber.HeaderXForwardedFor, forwardedFor)
+	request.Set(fiber.HeaderXForwardedHost, ctx.Hostname())
+	request.Set(fiber.HeaderXForwardedProto, ctx.Protocol())
+	rewriteHeaders(request, handler.config.RequestHeaders)
+
+	attempts := 1
+	if isIdempotent(ctx.Method()) {
+		attempts += handler.config.Retries
+	}
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(handler.config.RetryBackoff)
+		}
+		err = proxy.DoTimeout(ctx, target, handler.config.Timeout, handler.client)
+		if err == nil && !isRetryableStatus(ctx.Response().StatusCode()) {
+			break
+		}
+	}
+	if errors.Is(err, fasthttp.ErrTimeout) {
+		return fiber.NewError(fiber.StatusGatewayTimeout, "upstream timed out")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadGateway, "upstream unavailable")
+	}
+
+	rewriteHeaders(&ctx.Response().Header, handler.config.ResponseHeaders)
+	return nil
+}
+
+type headers interface {
+	Set(key, value string)
+	Del(key string)
+}
+
+func rewriteHeaders(target headers, rewrite config.HeaderRewriteConfig) {
+	for _, name := range rewrite.Remove {
+		target.Del(name)
+	}
+	for name, value := range rewrite.Set {
+		target.Set(name, value)
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodPut, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	return status == fiber.StatusBadGateway || status == fiber.StatusServiceUnavailable || status == fiber.StatusGatewayTimeout
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyForward(t *testing.T) {
+	var received *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request
+		writer.Header().Set("Server", "orders")
+		writer.Write([]byte("order " + request.URL.RequestURI()))
+	}))
+	defer upstream.Close()
+
+	proxyApp := fiber.New()
+	Mount(proxyApp, "/api/v1/orders", NewProxyHandler(config.ProxyRouteConfig{
+		Name:            "orders",
+		Prefix:          "/api/v1/orders",
+		Upstream:        upstream.URL,
+		StripPrefix:     true,
+		RequestHeaders:  config.HeaderRewriteConfig{Set: map[string]string{"X-Gateway": "fiber"}, Remove: []string{"Cookie"}},
+		ResponseHeaders: config.HeaderRewriteConfig{Remove: []string{"Server"}},
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/orders/42?expand=items", nil)
+	request.Header.Set("Cookie", "session=secret")
+	response, err := proxyApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "order /42?expand=items", string(body))
+	assert.Empty(t, response.Header.Get("Server"))
+	assert.Equal(t, "fiber", received.Header.Get("X-Gateway"))
+	assert.Empty(t, received.Header.Get("Cookie"))
+	assert.Equal(t, "0.0.0.0", received.Header.Get(fiber.HeaderXForwardedFor))
+}
+
+func TestProxyRetries(t *testing.T) {
+	attempts := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts++
+		if attempts < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer upstream.Close()
+
+	proxyApp := fiber.New()
+	Mount(proxyApp, "/orders", NewProxyHandler(config.ProxyRouteConfig{Name: "orders", Prefix: "/orders", Upstream: upstream.URL, Retries: 2}))
+
+	response, err := proxyApp.Test(httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	response, err = proxyApp.Test(httptest.NewRequest(http.MethodPost, "/orders", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+	assert.Equal(t, 1, attempts)
+
+	upstream.Close()
+	response, err = proxyApp.Test(httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 502, response.StatusCode)
+}
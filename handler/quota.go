@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// QuotaHandler shows the authenticated user what is left of their quotas.
+type QuotaHandler struct {
+	live   *config.Live
+	quotas service.QuotaService
+}
+
+func NewQuotaHandler(live *config.Live, quotas service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{live: live, quotas: quotas}
+}
+
+// Register adds the quota route. The handler is meant to be mounted at
+// /quota, which is excluded from the quotas by default so that checking them
+// does not use them up.
+func (handler *QuotaHandler) Register(router fiber.Router) {
+	router.Get("", handler.Show).Name("quota.show")
+}
+
+// Show lists the counters of the quotas of the user and the API key of the
+// request, which must be authenticated with one.
+func (handler *QuotaHandler) Show(ctx *fiber.Ctx) error {
+	userID, _ := ctx.Locals("user_id").(string)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "an API key is required")
+	}
+	apiKeyID, _ := ctx.Locals("api_key_id").(string)
+	counters, err := handler.quotas.Usage(ctx.UserContext(), middleware.QuotaLimits(handler.live.Get().Quotas, userID, apiKeyID))
+	if err != nil {
+		return err
+	}
+	remaining := make([]fiber.Map, 0, len(counters))
+	for _, counter := range counters {
+		remaining = append(remaining, fiber.Map{
+			"subject":   counter.Subject,
+			"period":    counter.Period,
+			"limit":     counter.Limit,
+			"used":      counter.Used,
+			"remaining": counter.Remaining(),
+			"reset_at":  counter.ResetAt,
+		})
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"data": remaining})
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"golang-fiber-web/pdf"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// ReportHandler renders the reports of the user of :userId as PDF, mounted at
+// /users/:userId/reports. Small reports are answered right away; larger ones
+// are generated in the background and downloaded once ready.
+type ReportHandler struct {
+	reports service.ReportService
+	owner   fiber.Handler
+}
+
+func NewReportHandler(reports service.ReportService) *ReportHandler {
+	return &ReportHandler{reports: reports, owner: owner("reports")}
+}
+
+func (handler *ReportHandler) Register(router fiber.Router) {
+	router.Post("/orders", handler.owner, handler.Orders).Name("reports.orders")
+	router.Get("/:reportId", handler.owner, handler.Get).Name("reports.show")
+	router.Get("/:reportId/download", handler.owner, handler.Download).Name("reports.download")
+}
+
+// reportResponse is a report with the link to download it once ready.
+type reportResponse struct {
+	*model.Report
+	DownloadURL string `json:"download_url,omitempty" xml:"download_url,omitempty" yaml:"download_url,omitempty"`
+}
+
+// Orders reports the orders of the user placed within ?from and ?to, as
+// the orders list takes them. It answers the PDF, or 202 with the pending
+// report when there are too many orders to render it right away; the
+// Location header is then where its status is found.
+func (handler *ReportHandler) Orders(ctx *fiber.Ctx) error {
+	created, err := parseTimeRange(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		return err
+	}
+	document, report, err := handler.reports.OrdersReport(ctx.UserContext(), ctx.Params("userId"), created)
+	if err != nil {
+		return err
+	}
+	if document != nil {
+		return sendPDF(ctx, "orders.pdf", document)
+	}
+
+	location, err := web.URLFor(ctx, "reports.show", fiber.Map{"userId": report.UserID, "reportId": report.ID})
+	if err != nil {
+		return err
+	}
+	ctx.Location(location)
+	return web.Respond(ctx, fiber.StatusAccepted, reportResponse{Report: report})
+}
+
+// Get answers the status of the report, with its download_url once ready.
+func (handler *ReportHandler) Get(ctx *fiber.Ctx) error {
+	report, err := handler.reports.Find(ctx.UserContext(), ctx.Params("userId"), ctx.Params("reportId"))
+	if err != nil {
+		return reportError(err)
+	}
+	response := reportResponse{Report: report}
+	if report.Status == model.ReportReady {
+		response.DownloadURL, err = web.URLFor(ctx, "reports.download", fiber.Map{"userId": report.UserID, "reportId": report.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return web.Respond(ctx, fiber.StatusOK, response)
+}
+
+// Download streams the PDF of the report, answering 409 until it is ready.
+func (handler *ReportHandler) Download(ctx *fiber.Ctx) error {
+	content, report, err := handler.reports.Open(ctx.UserContext(), ctx.Params("userId"), ctx.Params("reportId"))
+	if err != nil {
+		return reportError(err)
+	}
+	ctx.Attachment(report.Kind + "-" + report.ID + ".pdf")
+	return ctx.SendStream(content, int(report.Size))
+}
+
+// sendPDF answers document as the attachment name, which also sets the
+// Content-Type from its extension.
+func sendPDF(ctx *fiber.Ctx, name string, document *pdf.Document) error {
+	ctx.Attachment(name)
+	_, err := document.WriteTo(ctx.Response().BodyWriter())
+	return err
+}
+
+func reportError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrReportNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrReportNotReady):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/web"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// reportApp serves the orders and the reports of the users, rendering up to
+// inlineMax orders right away, with the user "1" and their orders.
+func reportApp(t *testing.T, inlineMax int) (*fiber.App, service.ReportService, *recordingPublisher) {
+	users := repository.NewMemoryUserRepository()
+	assert.Nil(t, users.Create(context.Background(), &model.User{ID: "1", Username: "brian", Email: "brian@example.com"}))
+	orders := repository.NewMemoryOrderRepository()
+	published := &recordingPublisher{}
+	transactor := repository.NewMemoryTransactor()
+	orderService := service.NewOrderService(orders, transactor, service.NewAuditService(repository.NewMemoryAuditRepository()), published)
+	reportService := service.NewReportService(config.ReportConfig{InlineMaxOrders: inlineMax}, users, orders, repository.NewMemoryReportRepository(),
+		storage.NewLocal(t.TempDir()), transactor, published)
+
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	Mount(app, "/users/:userId/orders", NewOrderHandler(orderService, reportService, config.AdminConfig{Username: "admin", Password: "secret"}))
+	Mount(app, "/users/:userId/reports", NewReportHandler(reportService))
+
+	for _, body := range []string{
+		`{"currency":"IDR","items":[{"name":"Book","quantity":2,"unit_price":50000}]}`,
+		`{"currency":"USD","items":[{"name":"Pen","quantity":3,"unit_price":250}],"notes":"gift wrap"}`,
+	} {
+		assert.Equal(t, 201, fileRequest(t, app, http.MethodPost, "/users/1/orders", "1", body).StatusCode)
+	}
+	return app, reportService, published
+}
+
+func assertPDF(t *testing.T, response *http.Response, name string) {
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/pdf", response.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="`+name+`"`, response.Header.Get("Content-Disposition"))
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(string(body), "%%EOF\n"))
+}
+
+func TestOrderInvoice(t *testing.T) {
+	app, _, published := reportApp(t, 10)
+	order := published.events[0].(event.OrderPlaced).Order
+
+	assertPDF(t, fileRequest(t, app, http.MethodGet, "/users/1/orders/"+order.ID+"/invoice", "1", ""), "invoice-"+order.ID+".pdf")
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodGet, "/users/1/orders/"+order.ID+"/invoice", "2", "").StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/users/2/orders/"+order.ID+"/invoice", "2", "").StatusCode)
+}
+
+func TestOrdersReportInline(t *testing.T) {
+	app, _, published := reportApp(t, 10)
+
+	assertPDF(t, fileRequest(t, app, http.MethodPost, "/users/1/reports/orders?from=2020-01-01", "1", ""), "orders.pdf")
+	assert.Equal(t, 400, fileRequest(t, app, http.MethodPost, "/users/1/reports/orders?from=yesterday", "1", "").StatusCode)
+	assert.Equal(t, 403, fileRequest(t, app, http.MethodPost, "/users/1/reports/orders", "2", "").StatusCode)
+	assert.Len(t, published.events, 2)
+}
+
+func TestOrdersReportInBackground(t *testing.T) {
+	app, reports, published := reportApp(t, 1)
+
+	response := fileRequest(t, app, http.MethodPost, "/users/1/reports/orders", "1", "")
+	assert.Equal(t, 202, response.StatusCode)
+	report := &model.Report{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(report))
+	assert.Equal(t, model.ReportPending, report.Status)
+	assert.Equal(t, "/users/1/reports/"+report.ID, response.Header.Get("Location"))
+	requested := published.events[2]
+	assert.Equal(t, event.ReportRequested{ReportID: report.ID}, requested)
+
+	assert.Equal(t, 409, fileRequest(t, app, http.MethodGet, "/users/1/reports/"+report.ID+"/download", "1", "").StatusCode)
+	assert.Equal(t, 404, fileRequest(t, app, http.MethodGet, "/users/2/reports/"+report.ID, "2", "").StatusCode)
+
+	assert.Nil(t, reports.Generate(context.Background(), requested))
+	assert.Nil(t, reports.Generate(context.Background(), requested))
+
+	response = fileRequest(t, app, http.MethodGet, "/users/1/reports/"+report.ID, "1", "")
+	assert.Equal(t, 200, response.StatusCode)
+	status := struct {
+		Status      string `json:"status"`
+		Size        int64  `json:"size"`
+		DownloadURL string `json:"download_url"`
+	}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&status))
+	assert.Equal(t, model.ReportReady, status.Status)
+	assert.Positive(t, status.Size)
+	assert.Equal(t, "/users/1/reports/"+report.ID+"/download", status.DownloadURL)
+
+	assertPDF(t, fileRequest(t, app, http.MethodGet, status.DownloadURL, "1", ""), "orders-"+report.ID+".pdf")
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/service"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/web"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+type logSettings struct {
+	Level string   `json:"level" xml:"level" yaml:"level"`
+	Debug []string `json:"debug" xml:"debug" yaml:"debug"`
+}
+
+// updateLogSettingsRequest changes the level when Level is set and turns the
+// debug logging of each module of Debug on or off.
+type updateLogSettingsRequest struct {
+	Level string          `json:"level"`
+	Debug map[string]bool `json:"debug"`
+}
+
+// RuntimeHandler inspects and adjusts the running process: its logging and
+// its effective config, including the sections reloaded since it started.
+type RuntimeHandler struct {
+	live  *config.Live
+	audit service.AuditService
+}
+
+func NewRuntimeHandler(live *config.Live, audit service.AuditService) *RuntimeHandler {
+	return &RuntimeHandler{live: live, audit: audit}
+}
+
+// Register adds the log settings and the config behind basic auth. The
+// handler is meant to be mounted at /admin/runtime. Changes apply to the
+// process serving the request only, so under Prefork to one child.
+func (handler *RuntimeHandler) Register(router fiber.Router) {
+	router.Use(middleware.NewAdminAuth(handler.live.Get().Admin))
+
+	router.Get("/log", handler.Log).Name("runtime.log")
+	router.Patch("/log", handler.UpdateLog).Name("runtime.log.update")
+	router.Get("/config", handler.Config).Name("runtime.config")
+}
+
+func (handler *RuntimeHandler) Log(ctx *fiber.Ctx) error {
+	return web.Respond(ctx, fiber.StatusOK, currentLogSettings())
+}
+
+// UpdateLog applies the new log settings at once and records them in the
+// audit log.
+func (handler *RuntimeHandler) UpdateLog(ctx *fiber.Ctx) error {
+	request := new(updateLogSettingsRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	level, err := telemetry.ParseLogLevel(request.Level)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "level must be debug, info, warn or error")
+	}
+	for module := range request.Debug {
+		if module == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "debug modules must be named")
+		}
+	}
+
+	before := currentLogSettings()
+	if request.Level != "" {
+		telemetry.SetLogLevel(level)
+	}
+	for module, enabled := range request.Debug {
+		telemetry.SetModuleDebug(module, enabled)
+	}
+	after := currentLogSettings()
+
+	err = handler.audit.Record(ctx.UserContext(), "log.update", "log", "", before, after)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, after)
+}
+
+// Config shows the config the process runs with as YAML, the format of the
+// config file, with secrets redacted and the log settings changed since it
+// started.
+func (handler *RuntimeHandler) Config(ctx *fiber.Ctx) error {
+	effective := handler.live.Get().Redacted()
+	settings := currentLogSettings()
+	effective.Log.Level = settings.Level
+	effective.Log.Debug = settings.Debug
+	body, err := yaml.Marshal(effective)
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderContentType, web.MIMEApplicationYAML)
+	return ctx.Send(body)
+}
+
+func currentLogSettings() logSettings {
+	return logSettings{
+		Level: strings.ToLower(telemetry.LogLevel().String()),
+		Debug: telemetry.DebugModules(),
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"path/filepath"
+	"strconv"
+)
+
+type UploadHandler struct {
+	files service.FileService
+}
+
+func NewUploadHandler(files service.FileService) *UploadHandler {
+	return &UploadHandler{files: files}
+}
+
+// Register adds the upload route, which is for authenticated users only so
+// that every upload counts against the storage quota of its owner.
+func (handler *UploadHandler) Register(router fiber.Router) {
+	router.Post("", authenticated, handler.Upload).Name("uploads.create")
+}
+
+// Upload stores the multipart "file" field as a file named after the base
+// name of the uploaded file, owned by the user of the request. The file is
+// then processed in the background; the Location header is where its status
+// and metadata are found.
+func (handler *UploadHandler) Upload(ctx *fiber.Ctx) error {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "missing file")
+	}
+	name := filepath.Base(header.Filename)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid file name")
+	}
+	content, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	ownerID := userID(ctx)
+	file, err := handler.files.Upload(ctx.UserContext(), ownerID, name, header.Size, content)
+	if errors.Is(err, model.ErrStorageQuotaExceeded) {
+		return handler.quotaExceeded(ctx, ownerID, header.Size)
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Location("/files/" + file.ID)
+	return web.Respond(ctx, fiber.StatusCreated, file)
+}
+
+// quotaExceeded answers 413 with the storage usage of the owner, so the
+// client can tell how much must be deleted for the upload to fit.
+func (handler *UploadHandler) quotaExceeded(ctx *fiber.Ctx, ownerID string, size int64) error {
+	usage, err := handler.files.Usage(ctx.UserContext(), ownerID)
+	if err != nil {
+		return err
+	}
+	return web.SendError(ctx, web.NewProblem(fiber.StatusRequestEntityTooLarge,
+		"uploading "+strconv.FormatInt(size, 10)+" bytes would exceed the storage quota of "+strconv.FormatInt(usage.Limit, 10)+" bytes").
+		With("used", usage.Used).
+		With("limit", usage.Limit).
+		With("remaining", usage.Remaining()))
+}
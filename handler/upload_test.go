@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/testfactory"
+	"golang-fiber-web/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newUploadApp mounts the upload handler of files behind a middleware
+// authenticating the user of the X-User header, which stands for an access
+// token or an API key.
+func newUploadApp(files service.FileService) *fiber.App {
+	uploadApp := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	uploadApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	Mount(uploadApp, "/upload", NewUploadHandler(files))
+	return uploadApp
+}
+
+func uploadRequest(t *testing.T, filename, content string) *http.Request {
+	return testfactory.NewRequest(t, http.MethodPost, "/upload").
+		Header("X-User", "1").
+		Multipart(testfactory.NewMultipart().File("file", filename, []byte(content))).
+		Build()
+}
+
+func TestUpload(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	files := repository.NewMemoryFileRepository()
+	uploadApp := newUploadApp(service.NewFileService(files, storage.NewLocal(dir), repository.NewMemoryTransactor(), event.Discard, nil))
+
+	response, err := uploadApp.Test(uploadRequest(t, "../../notes.txt", "hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	var file model.File
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&file))
+	assert.Equal(t, "notes.txt", file.Name)
+	assert.Equal(t, "1", file.OwnerID)
+	assert.Equal(t, int64(5), file.Size)
+	assert.Equal(t, model.FilePending, file.Status)
+	assert.Equal(t, "/files/"+file.ID, response.Header.Get("Location"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "files", file.ID))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(content))
+	saved, err := files.FindByID(context.Background(), file.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "files/"+file.ID, saved.StorageKey)
+
+	request := httptest.NewRequest(http.MethodPost, "/upload", nil)
+	request.Header.Set("X-User", "1")
+	response, err = uploadApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	anonymous := uploadRequest(t, "notes.txt", "hello")
+	anonymous.Header.Del("X-User")
+	response, err = uploadApp.Test(anonymous)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode, "anonymous uploads would escape the storage quota")
+}
+
+func TestUploadStorageQuota(t *testing.T) {
+	files := service.NewFileService(repository.NewMemoryFileRepository(), storage.NewLocal(t.TempDir()), repository.NewMemoryTransactor(), event.Discard,
+		func(ownerID string) int64 { return 8 })
+	uploadApp := newUploadApp(files)
+	upload := func(user, content string) *http.Response {
+		request := uploadRequest(t, "notes.txt", content)
+		request.Header.Set("X-User", user)
+		response, err := uploadApp.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	assert.Equal(t, 201, upload("1", "hello").StatusCode)
+	response := upload("1", "hello")
+	assert.Equal(t, 413, response.StatusCode)
+	var problem map[string]interface{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(t, "uploading 5 bytes would exceed the storage quota of 8 bytes", problem["detail"])
+	assert.Equal(t, float64(5), problem["used"])
+	assert.Equal(t, float64(3), problem["remaining"])
+
+	assert.Equal(t, 201, upload("1", "abc").StatusCode)
+	assert.Equal(t, 401, upload("", "no owner, no quota").StatusCode)
+
+	usage, err := files.Usage(context.Background(), "1")
+	assert.Nil(t, err)
+	assert.Equal(t, &model.StorageUsage{Files: 2, Used: 8, Limit: 8}, usage)
+}
@@ -0,0 +1,388 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserListOptions are the sort and filter fields of user lists, over HTTP
+// and gRPC.
+var UserListOptions = web.ListOptions{
+	Sortable:   []string{"username", "email", "name", "created_at"},
+	Filterable: []string{"username", "email", "name"},
+	Searchable: true,
+}
+
+const maxImportRows = 10000
+
+// userExportColumns are the columns of GET /users/export in their default
+// order.
+var userExportColumns = []string{"id", "username", "email", "name", "version", "created_at", "deleted_at"}
+
+func userExportValue(user *model.User, column string) string {
+	switch column {
+	case "id":
+		return user.ID
+	case "username":
+		return user.Username
+	case "email":
+		return user.Email
+	case "name":
+		return user.Name
+	case "version":
+		return strconv.Itoa(user.Version)
+	case "created_at":
+		return user.CreatedAt.UTC().Format(time.RFC3339)
+	case "deleted_at":
+		if user.DeletedAt == nil {
+			return ""
+		}
+		return user.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+type UserHandler struct {
+	users   service.UserService
+	avatars service.AvatarService
+	cache   middleware.CacheInvalidator
+	admin   fiber.Handler
+}
+
+func NewUserHandler(users service.UserService, avatars service.AvatarService, cache middleware.CacheInvalidator, admin config.AdminConfig) *UserHandler {
+	return &UserHandler{users: users, avatars: avatars, cache: cache, admin: middleware.NewAdminAuth(admin)}
+}
+
+// Register adds the user routes. Importing users, restoring deleted users and
+// listing them with ?include_deleted=true are for the admin only, and changing or deleting
+// a user for that user or the admin.
+func (handler *UserHandler) Register(router fiber.Router) {
+	router.Get("", handler.adminForDeleted, handler.List).Name("users.list")
+	router.Get("/export", handler.adminForDeleted, handler.Export).Name("users.export")
+	router.Post("/import", handler.admin, handler.Import).Name("users.import")
+	router.Get("/:id", handler.Get).Name("users.show")
+	router.Get("/:id/avatar", handler.Avatar).Name("users.avatar")
+	router.Put("/:id", handler.selfOrAdmin, handler.Update).Name("users.update")
+	router.Patch("/:id", handler.selfOrAdmin, handler.Patch).Name("users.patch")
+	router.Delete("/:id", handler.selfOrAdmin, handler.Delete).Name("users.delete")
+	router.Post("/:id/restore", handler.admin, handler.Restore).Name("users.restore")
+}
+
+// selfOrAdmin lets through the user of :id, as authenticated by an access
+// token or an API key, and the admin.
+func (handler *UserHandler) selfOrAdmin(ctx *fiber.Ctx) error {
+	if id := userID(ctx); id != "" && id == ctx.Params("id") {
+		return ctx.Next()
+	}
+	return handler.admin(ctx)
+}
+
+func (handler *UserHandler) List(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, UserListOptions)
+	if err != nil {
+		return err
+	}
+	spec.IncludeDeleted = ctx.QueryBool("include_deleted")
+
+	users, total, err := handler.users.List(ctx.UserContext(), spec)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       users,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+// Export streams the users matching the filters and sort of the list as CSV
+// or XLSX, where ?columns picks and orders the columns, or as NDJSON with
+// ?format=ndjson.
+func (handler *UserHandler) Export(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, UserListOptions)
+	if err != nil {
+		return err
+	}
+	spec.IncludeDeleted = ctx.QueryBool("include_deleted")
+
+	columns := userExportColumns
+	if selected := ctx.Query("columns"); selected != "" {
+		columns = strings.Split(selected, ",")
+		for _, column := range columns {
+			if !slices.Contains(userExportColumns, column) {
+				return fiber.NewError(fiber.StatusBadRequest, "unknown column "+column)
+			}
+		}
+	}
+
+	// The rows are written after the request, past its deadline, for as long
+	// as the client stays.
+	if ctx.Query("format") == "ndjson" {
+		return web.StreamNDJSON(ctx, func(streamContext context.Context, encode func(value interface{}) error) error {
+			return handler.users.Each(streamContext, spec, func(user *model.User) error {
+				return encode(user)
+			})
+		})
+	}
+	return web.StreamExport(ctx, "users", columns, func(streamContext context.Context, write func(row []string) error) error {
+		return handler.users.Each(streamContext, spec, func(user *model.User) error {
+			row := make([]string, len(columns))
+			for i, column := range columns {
+				row[i] = userExportValue(user, column)
+			}
+			return write(row)
+		})
+	})
+}
+
+// Import creates users from the CSV in the multipart "file" field. The header
+// row names the columns: username, email and name; others are ignored. The
+// response tells how many users were created and why the other rows were
+// rejected, or, for Accept: text/csv, is a CSV of the rejected rows with
+// their errors.
+func (handler *UserHandler) Import(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "missing file")
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid CSV: "+err.Error())
+	}
+	if len(records) == 0 || !slices.Contains(records[0], "username") {
+		return fiber.NewError(fiber.StatusBadRequest, "the header row must name a username column")
+	}
+	if len(records)-1 > maxImportRows {
+		return fiber.NewError(fiber.StatusBadRequest, "at most "+strconv.Itoa(maxImportRows)+" rows can be imported at once")
+	}
+
+	header := records[0]
+	requests := make([]*model.CreateUserRequest, len(records)-1)
+	for i, record := range records[1:] {
+		request := &model.CreateUserRequest{}
+		for column, value := range record {
+			switch header[column] {
+			case "username":
+				request.Username = value
+			case "email":
+				request.Email = value
+			case "name":
+				request.Name = value
+			}
+		}
+		requests[i] = request
+	}
+
+	result, err := handler.users.Import(ctx.UserContext(), requests)
+	if err != nil {
+		return err
+	}
+	if result.Imported > 0 {
+		err = handler.cache.Invalidate("/users")
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.Accepts(fiber.MIMEApplicationJSON, "text/csv") != "text/csv" {
+		return web.Respond(ctx, fiber.StatusOK, result)
+	}
+	ctx.Attachment("import-errors.csv")
+	ctx.Set(fiber.HeaderContentType, web.MIMETextCSV)
+	writer := csv.NewWriter(ctx)
+	err = writer.Write(append([]string{"row", "errors"}, header...))
+	if err != nil {
+		return err
+	}
+	for _, rejection := range result.Rejected {
+		err = writer.Write(append([]string{strconv.Itoa(rejection.Row), rejection.Errors.Error()}, records[rejection.Row]...))
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (handler *UserHandler) Get(ctx *fiber.Ctx) error {
+	user, err := handler.users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(err)
+	}
+
+	etag, err := middleware.ETagOf(user)
+	if err != nil {
+		return err
+	}
+	err = web.Respond(ctx, fiber.StatusOK, user)
+	if err != nil {
+		return err
+	}
+	middleware.SetRepresentationETag(ctx, etag)
+	return nil
+}
+
+// Avatar serves the avatar of the user in the size of ?size, rounded up to a
+// stored size. With ?v naming the current version of the avatar, the avatar
+// field of the user, the response never changes and is cached for good;
+// otherwise it is revalidated with its ETag after a few minutes.
+func (handler *UserHandler) Avatar(ctx *fiber.Ctx) error {
+	avatar, version, size, err := handler.avatars.Open(ctx.UserContext(), ctx.Params("id"), ctx.QueryInt("size"))
+	if errors.Is(err, model.ErrAvatarNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return userError(err)
+	}
+
+	ctx.Set(fiber.HeaderETag, `"`+version+"-"+strconv.Itoa(size)+`"`)
+	if ctx.Query("v") == version {
+		ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	} else {
+		ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	}
+	if ctx.Fresh() {
+		avatar.Close()
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	ctx.Set(fiber.HeaderContentType, "image/png")
+	return ctx.SendStream(avatar)
+}
+
+func (handler *UserHandler) Update(ctx *fiber.Ctx) error {
+	user, err := handler.users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(err)
+	}
+
+	etag, err := middleware.ETagOf(user)
+	if err != nil {
+		return err
+	}
+	err = middleware.CheckIfMatch(ctx, etag)
+	if err != nil {
+		return err
+	}
+
+	request := new(model.UpdateUserRequest)
+	err = web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+	if request.Version == 0 && ctx.Get(fiber.HeaderIfMatch) != "" {
+		// The If-Match check passed for this version; make sure it is still
+		// the one being replaced.
+		request.Version = user.Version
+	}
+	return handler.save(ctx, user.ID, request)
+}
+
+func (handler *UserHandler) save(ctx *fiber.Ctx, id string, request *model.UpdateUserRequest) error {
+	user, err := handler.users.Update(ctx.UserContext(), id, request)
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+user.ID)
+	if err != nil {
+		return err
+	}
+
+	etag, err := middleware.ETagOf(user)
+	if err != nil {
+		return err
+	}
+	err = web.Respond(ctx, fiber.StatusOK, user)
+	if err != nil {
+		return err
+	}
+	middleware.SetRepresentationETag(ctx, etag)
+	return nil
+}
+
+// Patch applies a JSON Patch or JSON Merge Patch to the editable fields of
+// the user, including version, and saves the result like Update does.
+func (handler *UserHandler) Patch(ctx *fiber.Ctx) error {
+	user, err := handler.users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(err)
+	}
+
+	etag, err := middleware.ETagOf(user)
+	if err != nil {
+		return err
+	}
+	err = middleware.CheckIfMatch(ctx, etag)
+	if err != nil {
+		return err
+	}
+
+	request := new(model.UpdateUserRequest)
+	err = web.ApplyPatch(ctx, &model.UpdateUserRequest{
+		Username: user.Username,
+		Email:    user.Email,
+		Name:     user.Name,
+		Version:  user.Version,
+	}, request)
+	if err != nil {
+		return err
+	}
+	return handler.save(ctx, user.ID, request)
+}
+
+func (handler *UserHandler) Delete(ctx *fiber.Ctx) error {
+	err := handler.users.Delete(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+ctx.Params("id"))
+	if err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (handler *UserHandler) Restore(ctx *fiber.Ctx) error {
+	user, err := handler.users.Restore(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(err)
+	}
+	err = handler.cache.Invalidate("/users", "/users/"+user.ID)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, user)
+}
+
+func (handler *UserHandler) adminForDeleted(ctx *fiber.Ctx) error {
+	if ctx.QueryBool("include_deleted") {
+		return handler.admin(ctx)
+	}
+	return ctx.Next()
+}
+
+func userError(err error) error {
+	if errors.Is(err, model.ErrUserNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, model.ErrVersionConflict) {
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type userListResponse struct {
+	Data       []model.User   `json:"data"`
+	Pagination web.Pagination `json:"pagination"`
+}
+
+type cacheInvalidatorMock struct {
+	paths []string
+}
+
+func (cache *cacheInvalidatorMock) Invalidate(paths ...string) error {
+	cache.paths = append(cache.paths, paths...)
+	return nil
+}
+
+func newUserApp(users repository.UserRepository, cache middleware.CacheInvalidator) *fiber.App {
+	userApp := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	userApp.Use(middleware.NewETag(true))
+	// X-User stands for the user an access token or API key authenticates.
+	userApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", ctx.Get("X-User"))
+		return ctx.Next()
+	})
+	Mount(userApp, "/users", NewUserHandler(service.NewUserService(users, repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()), event.Discard), nil, cache, config.AdminConfig{Username: "admin", Password: "secret"}))
+	return userApp
+}
+
+func newUserListApp(t *testing.T) *fiber.App {
+	users := repository.NewMemoryUserRepository()
+	for _, username := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		assert.Nil(t, users.Create(context.Background(), &model.User{Username: username, Email: username + "@example.com", Name: "Team " + string(username[0])}))
+	}
+	assert.Nil(t, users.Create(context.Background(), &model.User{Username: "frank", Email: "frank@example.com", Name: "Other"}))
+	return newUserApp(users, &cacheInvalidatorMock{})
+}
+
+func getUserList(t *testing.T, listApp *fiber.App, target string) (*http.Response, userListResponse) {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	response, err := listApp.Test(request)
+	assert.Nil(t, err)
+
+	var body userListResponse
+	if response.StatusCode == 200 {
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	}
+	return response, body
+}
+
+func TestListPagination(t *testing.T) {
+	response, body := getUserList(t, newUserListApp(t), "/users?page=2&per_page=2&sort=username:desc")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, web.Pagination{Page: 2, PerPage: 2, Total: 6, TotalPages: 3}, body.Pagination)
+	assert.Equal(t, "dave", body.Data[0].Username)
+	assert.Equal(t, "carol", body.Data[1].Username)
+	assert.Equal(t, "6", response.Header.Get("X-Total-Count"))
+	assert.Equal(t, `<http://example.com/users?page=1&per_page=2&sort=username%3Adesc>; rel="first", `+
+		`<http://example.com/users?page=1&per_page=2&sort=username%3Adesc>; rel="prev", `+
+		`<http://example.com/users?page=3&per_page=2&sort=username%3Adesc>; rel="next", `+
+		`<http://example.com/users?page=3&per_page=2&sort=username%3Adesc>; rel="last"`, response.Header.Get("Link"))
+}
+
+func TestListFilter(t *testing.T) {
+	response, body := getUserList(t, newUserListApp(t), "/users?filter[name]=other")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, "frank", body.Data[0].Username)
+
+	response, _ = getUserList(t, newUserListApp(t), "/users?filter[password]=secret")
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestListCursor(t *testing.T) {
+	listApp := newUserListApp(t)
+
+	response, body := getUserList(t, listApp, "/users?cursor=&per_page=4&sort=username")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Len(t, body.Data, 4)
+	assert.NotEmpty(t, body.Pagination.NextCursor)
+
+	response, body = getUserList(t, listApp, "/users?per_page=4&sort=username&cursor="+body.Pagination.NextCursor)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Len(t, body.Data, 2)
+	assert.Equal(t, "erin", body.Data[0].Username)
+	assert.Empty(t, body.Pagination.NextCursor)
+	assert.Empty(t, response.Header.Get("Link"))
+}
+
+func TestListInvalidSpec(t *testing.T) {
+	listApp := newUserListApp(t)
+	for _, target := range []string{"/users?page=0", "/users?per_page=1000", "/users?sort=password", "/users?sort=username:up", "/users?cursor=%%%"} {
+		response, _ := getUserList(t, listApp, target)
+		assert.Equal(t, 400, response.StatusCode, target)
+	}
+}
+
+func TestUserUpdateIfMatch(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	cache := &cacheInvalidatorMock{}
+	userApp := newUserApp(users, cache)
+
+	request := httptest.NewRequest(http.MethodGet, "/users/"+user.ID, nil)
+	response, err := userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	etag := response.Header.Get("ETag")
+	assert.False(t, strings.HasPrefix(etag, "W/"))
+
+	update := func(ifMatch string) *http.Response {
+		body := strings.NewReader(`{"username":"brian","name":"Brian Anashari"}`)
+		request := httptest.NewRequest(http.MethodPut, "/users/"+user.ID, body)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("If-Match", ifMatch)
+		request.Header.Set("X-User", user.ID)
+		response, err := userApp.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response = update(etag)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.NotEqual(t, etag, response.Header.Get("ETag"))
+	assert.Equal(t, []string{"/users", "/users/" + user.ID}, cache.paths)
+
+	response = update(etag)
+	assert.Equal(t, 412, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/users/"+user.ID, nil)
+	request.Header.Set("Accept", "application/xml")
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	xmlETag := response.Header.Get("ETag")
+	assert.True(t, strings.HasSuffix(xmlETag, `-xml"`), xmlETag)
+	request = httptest.NewRequest(http.MethodGet, "/users/"+user.ID, nil)
+	request.Header.Set("If-None-Match", xmlETag)
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	response = update(xmlETag)
+	assert.Equal(t, 200, response.StatusCode)
+
+	body := strings.NewReader(`{"username":"brian","name":"Stale","version":1}`)
+	request = httptest.NewRequest(http.MethodPut, "/users/"+user.ID, body)
+	request.Header.Set("Content-Type", "application/json")
+	request.SetBasicAuth("admin", "secret")
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 409, response.StatusCode)
+
+	for _, caller := range []string{"", "other"} {
+		request = httptest.NewRequest(http.MethodPut, "/users/"+user.ID, strings.NewReader(`{"username":"taken","email":"taken@example.com"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-User", caller)
+		response, err = userApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 401, response.StatusCode, caller)
+		request = httptest.NewRequest(http.MethodPatch, "/users/"+user.ID, strings.NewReader(`{"email":"taken@example.com"}`))
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+		request.Header.Set("X-User", caller)
+		response, err = userApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 401, response.StatusCode, caller)
+	}
+}
+
+func TestUserUpdateNotFound(t *testing.T) {
+	userApp := newUserApp(repository.NewMemoryUserRepository(), &cacheInvalidatorMock{})
+
+	request := httptest.NewRequest(http.MethodGet, "/users/missing", nil)
+	response, err := userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestUserDeleteAndRestore(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	cache := &cacheInvalidatorMock{}
+	userApp := newUserApp(users, cache)
+
+	response, err := userApp.Test(httptest.NewRequest(http.MethodDelete, "/users/"+user.ID, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	request := httptest.NewRequest(http.MethodDelete, "/users/"+user.ID, nil)
+	request.Header.Set("X-User", "other")
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	request = httptest.NewRequest(http.MethodDelete, "/users/"+user.ID, nil)
+	request.Header.Set("X-User", user.ID)
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 204, response.StatusCode)
+	assert.Equal(t, []string{"/users", "/users/" + user.ID}, cache.paths)
+
+	response, err = userApp.Test(httptest.NewRequest(http.MethodGet, "/users/"+user.ID, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	_, list := getUserList(t, userApp, "/users")
+	assert.Empty(t, list.Data)
+
+	response, _ = getUserList(t, userApp, "/users?include_deleted=true")
+	assert.Equal(t, 401, response.StatusCode)
+	request = httptest.NewRequest(http.MethodGet, "/users?include_deleted=true", nil)
+	request.SetBasicAuth("admin", "secret")
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&list))
+	assert.Len(t, list.Data, 1)
+	assert.NotNil(t, list.Data[0].DeletedAt)
+
+	response, err = userApp.Test(httptest.NewRequest(http.MethodPost, "/users/"+user.ID+"/restore", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	request = httptest.NewRequest(http.MethodPost, "/users/"+user.ID+"/restore", nil)
+	request.SetBasicAuth("admin", "secret")
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response, err = userApp.Test(httptest.NewRequest(http.MethodGet, "/users/"+user.ID, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestUserPatch(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	userApp := newUserApp(users, &cacheInvalidatorMock{})
+
+	patch := func(contentType, body string) *http.Response {
+		request := httptest.NewRequest(http.MethodPatch, "/users/"+user.ID, strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("X-User", user.ID)
+		response, err := userApp.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response := patch("application/merge-patch+json", `{"name":"Brian Ashari","email":null}`)
+	assert.Equal(t, 200, response.StatusCode)
+	var patched model.User
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&patched))
+	assert.Equal(t, "Brian Ashari", patched.Name)
+	assert.Empty(t, patched.Email)
+	assert.Equal(t, 2, patched.Version)
+
+	response = patch("application/json-patch+json", `[{"op":"test","path":"/version","value":2},{"op":"replace","path":"/username","value":"brian2"}]`)
+	assert.Equal(t, 200, response.StatusCode)
+	response = patch("application/json-patch+json", `[{"op":"test","path":"/version","value":2},{"op":"replace","path":"/username","value":"brian3"}]`)
+	assert.Equal(t, 409, response.StatusCode)
+
+	response = patch("application/json-patch+json", `[{"op":"add","path":"/roles","value":["admin"]}]`)
+	assert.Equal(t, 422, response.StatusCode)
+	response = patch("application/json-patch+json", `[{"op":"replace","path":"/username","value":"x"}]`)
+	assert.Equal(t, 422, response.StatusCode)
+	response = patch("application/json", `{"name":"Brian"}`)
+	assert.Equal(t, 415, response.StatusCode)
+	assert.Contains(t, response.Header.Get("Accept-Patch"), "application/merge-patch+json")
+}
+
+func TestUserExport(t *testing.T) {
+	exportApp := newUserListApp(t)
+
+	response, err := exportApp.Test(httptest.NewRequest(http.MethodGet, "/users/export?columns=username,email&filter[name]=Other", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Contains(t, response.Header.Get("Content-Disposition"), "users-")
+	records, err := csv.NewReader(response.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"username", "email"}, {"frank", "frank@example.com"}}, records)
+
+	response, err = exportApp.Test(httptest.NewRequest(http.MethodGet, "/users/export?format=xlsx&sort=username:desc", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, web.MIMEApplicationXLSX, response.Header.Get("Content-Type"))
+	workbook, err := excelize.OpenReader(response.Body)
+	assert.Nil(t, err)
+	rows, err := workbook.GetRows("Sheet1")
+	assert.Nil(t, err)
+	assert.Len(t, rows, 7)
+	assert.Equal(t, "frank", rows[1][1])
+
+	response, err = exportApp.Test(httptest.NewRequest(http.MethodGet, "/users/export?columns=password", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestUserImport(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	assert.Nil(t, users.Create(context.Background(), &model.User{Username: "brian", Email: "brian@example.com"}))
+	importApp := newUserApp(users, &cacheInvalidatorMock{})
+	content := "username,email,name\nbudi,budi@example.com,Budi\nx,siti,Siti\nbrian2,BRIAN@example.com,Brian\nsiti,,Siti Rahma\n"
+
+	request := uploadRequest(t, "users.csv", content)
+	request.RequestURI = "/users/import"
+	response, err := importApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	request = uploadRequest(t, "users.csv", content)
+	request.RequestURI = "/users/import"
+	request.SetBasicAuth("admin", "secret")
+	response, err = importApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var result model.ImportResult
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, []int{2, 3}, []int{result.Rejected[0].Row, result.Rejected[1].Row})
+	assert.Len(t, result.Rejected[0].Errors, 2)
+
+	_, total, err := users.List(context.Background(), &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+
+	request = uploadRequest(t, "users.csv", "username,email\nbr,\n")
+	request.RequestURI = "/users/import"
+	request.SetBasicAuth("admin", "secret")
+	request.Header.Set("Accept", "text/csv")
+	response, err = importApp.Test(request)
+	assert.Nil(t, err)
+	records, err := csv.NewReader(response.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"row", "errors", "username", "email"}, {"1", "username failed min=3", "br", ""}}, records)
+}
+
+func TestUserExportNDJSON(t *testing.T) {
+	exportApp := newUserListApp(t)
+
+	response, err := exportApp.Test(httptest.NewRequest(http.MethodGet, "/users/export?format=ndjson&sort=username", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "application/x-ndjson", response.Header.Get("Content-Type"))
+	decoder := json.NewDecoder(response.Body)
+	var usernames []string
+	for decoder.More() {
+		var user model.User
+		assert.Nil(t, decoder.Decode(&user))
+		usernames = append(usernames, user.Username)
+	}
+	assert.Equal(t, []string{"alice", "bob", "carol", "dave", "erin", "frank"}, usernames)
+}
+
+func TestUsersAreScopedByTenant(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	assert.Nil(t, users.Create(tenant.With(context.Background(), "acme"), &model.User{Username: "alice"}))
+	globexUser := &model.User{Username: "bob"}
+	assert.Nil(t, users.Create(tenant.With(context.Background(), "globex"), globexUser))
+
+	tenancy := config.Default().Tenancy
+	tenancy.Enabled = true
+	tenancy.Tenants = map[string]config.TenantConfig{"acme": {}, "globex": {}}
+	userApp := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	userApp.Use(tenant.New(tenancy))
+	Mount(userApp, "/users", NewUserHandler(service.NewUserService(users, repository.NewMemoryTransactor(), service.NewAuditService(repository.NewMemoryAuditRepository()), event.Discard), nil, &cacheInvalidatorMock{}, config.AdminConfig{}))
+
+	request := httptest.NewRequest(http.MethodGet, "/users", nil)
+	request.Header.Set("X-Tenant-ID", "acme")
+	response, err := userApp.Test(request)
+	assert.Nil(t, err)
+	var body userListResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, "alice", body.Data[0].Username)
+	assert.Equal(t, "acme", body.Data[0].TenantID)
+
+	request = httptest.NewRequest(http.MethodGet, "/users/"+globexUser.ID, nil)
+	request.Header.Set("X-Tenant-ID", "acme")
+	response, err = userApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
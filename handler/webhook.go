@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+var deliveryListOptions = web.ListOptions{
+	Sortable:   []string{"created_at", "status", "event"},
+	Filterable: []string{"status", "event"},
+}
+
+// createdWebhook shows the secret of a webhook, which is only done once.
+type createdWebhook struct {
+	*model.Webhook
+	Secret string `json:"secret" xml:"secret" yaml:"secret"`
+}
+
+type WebhookHandler struct {
+	config   config.AdminConfig
+	webhooks service.WebhookService
+}
+
+func NewWebhookHandler(config config.AdminConfig, webhooks service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{config: config, webhooks: webhooks}
+}
+
+// Register adds the webhook management routes behind basic auth. The handler
+// is meant to be mounted at /admin/webhooks.
+func (handler *WebhookHandler) Register(router fiber.Router) {
+	router.Use(middleware.NewAdminAuth(handler.config))
+
+	router.Get("", handler.List).Name("webhooks.list")
+	router.Post("", handler.Create).Name("webhooks.create")
+	router.Get("/:id", handler.Get).Name("webhooks.show")
+	router.Delete("/:id", handler.Delete).Name("webhooks.delete")
+	router.Get("/:id/deliveries", handler.Deliveries).Name("webhooks.deliveries")
+	router.Post("/:id/deliveries/:delivery/redeliver", handler.Redeliver).Name("webhooks.redeliver")
+}
+
+func (handler *WebhookHandler) List(ctx *fiber.Ctx) error {
+	webhooks, err := handler.webhooks.List(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{"data": webhooks})
+}
+
+func (handler *WebhookHandler) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateWebhookRequest)
+	err := web.ParseBody(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := handler.webhooks.Create(ctx.UserContext(), request)
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusCreated, createdWebhook{Webhook: webhook, Secret: webhook.Secret})
+}
+
+func (handler *WebhookHandler) Get(ctx *fiber.Ctx) error {
+	webhook, err := handler.webhooks.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return webhookError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, webhook)
+}
+
+func (handler *WebhookHandler) Delete(ctx *fiber.Ctx) error {
+	err := handler.webhooks.Delete(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return webhookError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// Deliveries lists the delivery log of the webhook, newest first.
+func (handler *WebhookHandler) Deliveries(ctx *fiber.Ctx) error {
+	spec, err := web.ParseListSpec(ctx, deliveryListOptions)
+	if err != nil {
+		return err
+	}
+
+	deliveries, total, err := handler.webhooks.Deliveries(ctx.UserContext(), ctx.Params("id"), spec)
+	if err != nil {
+		return webhookError(err)
+	}
+	return web.Respond(ctx, fiber.StatusOK, fiber.Map{
+		"data":       deliveries,
+		"pagination": web.SetPagination(ctx, spec, total),
+	})
+}
+
+// Redeliver queues the delivery to be sent again and answers 202, as the
+// attempt itself happens in the background.
+func (handler *WebhookHandler) Redeliver(ctx *fiber.Ctx) error {
+	delivery, err := handler.webhooks.Redeliver(ctx.UserContext(), ctx.Params("id"), ctx.Params("delivery"))
+	if err != nil {
+		return webhookError(err)
+	}
+	return web.Respond(ctx, fiber.StatusAccepted, delivery)
+}
+
+func webhookError(err error) error {
+	if errors.Is(err, model.ErrWebhookNotFound) || errors.Is(err, model.ErrDeliveryNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return err
+}
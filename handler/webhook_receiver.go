@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"golang-fiber-web/webhook"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type WebhookReceiverHandler struct {
+	verifiers map[string]webhook.Verifier
+	receiver  service.WebhookReceiverService
+}
+
+// NewWebhookReceiverHandler accepts the webhooks of the receivers that have a
+// secret.
+func NewWebhookReceiverHandler(receivers map[string]config.WebhookReceiverConfig, receiver service.WebhookReceiverService) (*WebhookReceiverHandler, error) {
+	verifiers := map[string]webhook.Verifier{}
+	for provider, receiverConfig := range receivers {
+		if receiverConfig.Secret == "" {
+			continue
+		}
+		verifier, err := webhook.NewVerifier(provider, receiverConfig)
+		if err != nil {
+			return nil, err
+		}
+		verifiers[provider] = verifier
+	}
+	return &WebhookReceiverHandler{verifiers: verifiers, receiver: receiver}, nil
+}
+
+// Register adds the receiver. The handler is meant to be mounted at /webhooks.
+func (handler *WebhookReceiverHandler) Register(router fiber.Router) {
+	router.Post("/:provider", handler.Receive).Name("webhooks.receive")
+}
+
+// Receive verifies the signature of the event and queues it for processing,
+// answering 202 without waiting for it. Form-encoded events, as Twilio
+// sends, are passed on as a JSON object of their fields. An event the provider sends again
+// gets a 200 and is not processed again.
+func (handler *WebhookReceiverHandler) Receive(ctx *fiber.Ctx) error {
+	verifier, ok := handler.verifiers[ctx.Params("provider")]
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	// The header values and the body outlive the request in the records.
+	header := func(name string) string {
+		return strings.Clone(ctx.Get(name))
+	}
+	body := slices.Clone(ctx.Body())
+	received, err := verifier.Verify(header, body, time.Now())
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEApplicationForm) {
+		body, err = formToJSON(body)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+	if !json.Valid(body) {
+		return fiber.NewError(fiber.StatusBadRequest, "webhook payload must be JSON")
+	}
+
+	err = handler.receiver.Receive(ctx.UserContext(), received, body)
+	if errors.Is(err, model.ErrDuplicateWebhook) {
+		return web.Respond(ctx, fiber.StatusOK, fiber.Map{"status": "duplicate"})
+	}
+	if err != nil {
+		return err
+	}
+	return web.Respond(ctx, fiber.StatusAccepted, fiber.Map{"status": "accepted"})
+}
+
+func formToJSON(body []byte) ([]byte, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(form))
+	for name := range form {
+		fields[name] = form.Get(name)
+	}
+	return json.Marshal(fields)
+}
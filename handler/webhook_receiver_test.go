@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (publisher *recordingPublisher) Publish(ctx context.Context, published event.Event) error {
+	publisher.events = append(publisher.events, published)
+	return nil
+}
+
+func githubRequest(body, secret string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	request.Header.Set("X-GitHub-Delivery", "72d3162e")
+	request.Header.Set("X-GitHub-Event", "issues")
+	return request
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	published := &recordingPublisher{}
+	receiver, err := NewWebhookReceiverHandler(map[string]config.WebhookReceiverConfig{"github": {Secret: "secret"}, "stripe": {}},
+		service.NewWebhookReceiverService(repository.NewMemoryReceivedWebhookRepository(), repository.NewMemoryTransactor(), published))
+	assert.Nil(t, err)
+	receiverApp := fiber.New()
+	Mount(receiverApp, "/webhooks", receiver)
+
+	response, err := receiverApp.Test(githubRequest(`{"action":"opened"}`, "wrong"))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	for _, status := range []int{202, 200} {
+		response, err = receiverApp.Test(githubRequest(`{"action":"opened"}`, "secret"))
+		assert.Nil(t, err)
+		assert.Equal(t, status, response.StatusCode)
+	}
+	assert.Len(t, published.events, 1)
+	received := published.events[0].(event.WebhookReceived)
+	assert.Equal(t, "github", received.Provider)
+	assert.Equal(t, "issues", received.Type)
+	assert.JSONEq(t, `{"action":"opened"}`, string(received.Payload))
+
+	response, err = receiverApp.Test(httptest.NewRequest(http.MethodPost, "/webhooks/stripe", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestWebhookReceiverForm(t *testing.T) {
+	published := &recordingPublisher{}
+	receiver, err := NewWebhookReceiverHandler(map[string]config.WebhookReceiverConfig{"twilio": {Secret: "auth-token", URL: "https://shop.example.com/webhooks/twilio"}},
+		service.NewWebhookReceiverService(repository.NewMemoryReceivedWebhookRepository(), repository.NewMemoryTransactor(), published))
+	assert.Nil(t, err)
+	receiverApp := fiber.New()
+	Mount(receiverApp, "/webhooks", receiver)
+
+	mac := hmac.New(sha1.New, []byte("auth-token"))
+	mac.Write([]byte("https://shop.example.com/webhooks/twilioErrorCode30003MessageSidSM1MessageStatusundelivered"))
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/twilio", strings.NewReader("MessageSid=SM1&MessageStatus=undelivered&ErrorCode=30003"))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	request.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	response, err := receiverApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 202, response.StatusCode)
+
+	received := published.events[0].(event.WebhookReceived)
+	assert.Equal(t, "SM1:undelivered", received.EventID)
+	assert.JSONEq(t, `{"MessageSid":"SM1","MessageStatus":"undelivered","ErrorCode":"30003"}`, string(received.Payload))
+}
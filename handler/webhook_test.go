@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func webhookRequest(method, target, body string) *http.Request {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.SetBasicAuth("admin", "secret")
+	return request
+}
+
+func TestWebhookHandler(t *testing.T) {
+	webhooks, deliveries := repository.NewMemoryWebhookRepositories()
+	webhookService := service.NewWebhookService(webhooks, deliveries, service.NewAuditService(repository.NewMemoryAuditRepository()))
+	webhookApp := fiber.New()
+	MountApp(webhookApp, "/admin/webhooks", NewWebhookHandler(config.AdminConfig{Username: "admin", Password: "secret"}, webhookService),
+		fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+
+	response, err := webhookApp.Test(webhookRequest(http.MethodPost, "/admin/webhooks", `{"url":"https://example.com/hook","events":["user.unknown"]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+
+	response, err = webhookApp.Test(webhookRequest(http.MethodPost, "/admin/webhooks", `{"url":"https://example.com/hook","events":["user.deleted"]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	var created struct {
+		ID     string `json:"id"`
+		Secret string `json:"secret"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&created))
+	assert.Len(t, created.Secret, 64)
+
+	response, err = webhookApp.Test(webhookRequest(http.MethodGet, "/admin/webhooks/"+created.ID, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.NotContains(t, string(body), created.Secret)
+
+	delivery := &model.WebhookDelivery{WebhookID: created.ID, Event: "user.deleted", Payload: []byte("{}"), Status: model.DeliveryFailed, Attempts: 8}
+	assert.Nil(t, deliveries.Create(context.Background(), delivery))
+
+	response, err = webhookApp.Test(webhookRequest(http.MethodGet, "/admin/webhooks/"+created.ID+"/deliveries?filter[status]=failed", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var listed struct {
+		Data []model.WebhookDelivery `json:"data"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&listed))
+	assert.Len(t, listed.Data, 1)
+
+	response, err = webhookApp.Test(webhookRequest(http.MethodPost, "/admin/webhooks/"+created.ID+"/deliveries/"+delivery.ID+"/redeliver", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 202, response.StatusCode)
+	redelivered, err := deliveries.FindByID(context.Background(), created.ID, delivery.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.DeliveryPending, redelivered.Status)
+
+	response, err = webhookApp.Test(webhookRequest(http.MethodDelete, "/admin/webhooks/"+created.ID, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 204, response.StatusCode)
+	response, err = webhookApp.Test(webhookRequest(http.MethodPost, "/admin/webhooks/"+created.ID+"/deliveries/"+delivery.ID+"/redeliver", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
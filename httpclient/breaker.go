@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	stateClosed = iota
+	stateHalfOpen
+	stateOpen
+)
+
+// breaker is the circuit of one host. It opens after threshold failures in a
+// row, then lets a single probe through once openTimeout has passed, closing
+// again if the probe succeeds.
+type breaker struct {
+	host        string
+	threshold   int
+	openTimeout time.Duration
+
+	mutex    sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(host string, threshold int, openTimeout time.Duration) *breaker {
+	CircuitState.WithLabelValues(host).Set(stateClosed)
+	return &breaker{host: host, threshold: threshold, openTimeout: openTimeout}
+}
+
+func (breaker *breaker) allow(now time.Time) bool {
+	if breaker.threshold <= 0 {
+		return true
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	switch breaker.state {
+	case stateOpen:
+		if now.Sub(breaker.openedAt) < breaker.openTimeout {
+			return false
+		}
+		breaker.setState(stateHalfOpen)
+	case stateHalfOpen:
+		if breaker.probing {
+			return false
+		}
+	default:
+		return true
+	}
+	breaker.probing = true
+	return true
+}
+
+func (breaker *breaker) record(success bool, now time.Time) {
+	if breaker.threshold <= 0 {
+		return
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.probing = false
+	if success {
+		breaker.failures = 0
+		breaker.setState(stateClosed)
+		return
+	}
+	breaker.failures++
+	if breaker.state == stateHalfOpen || breaker.failures >= breaker.threshold {
+		breaker.openedAt = now
+		breaker.setState(stateOpen)
+	}
+}
+
+func (breaker *breaker) setState(state int) {
+	breaker.state = state
+	CircuitState.WithLabelValues(breaker.host).Set(float64(state))
+}
@@ -0,0 +1,172 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// Client calls third-party APIs through a fiber.Client, bounding every
+// attempt with a timeout, retrying idempotent calls that failed transiently
+// and failing fast while a host keeps failing.
+type Client struct {
+	client   *fiber.Client
+	config   config.HTTPClientConfig
+	mutex    sync.Mutex
+	breakers map[string]*breaker
+}
+
+func New(config config.HTTPClientConfig) *Client {
+	return &Client{client: &fiber.Client{}, config: config, breakers: map[string]*breaker{}}
+}
+
+func (client *Client) Get(ctx context.Context, url string, prepare func(agent *fiber.Agent)) (*Response, error) {
+	return client.Do(ctx, fiber.MethodGet, url, prepare)
+}
+
+func (client *Client) Post(ctx context.Context, url string, prepare func(agent *fiber.Agent)) (*Response, error) {
+	return client.Do(ctx, fiber.MethodPost, url, prepare)
+}
+
+// Do sends the request, calling prepare on a fresh agent before every
+// attempt to set its headers and body; prepare may be nil. A response is
+// returned whatever its status, once retries are exhausted; an error means
+// no response was received, the circuit of the host is open or ctx ended.
+func (client *Client) Do(ctx context.Context, method, rawURL string, prepare func(agent *fiber.Agent)) (*Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := target.Host
+	breaker := client.breaker(host)
+
+	for attempt := 0; ; attempt++ {
+		timeout := client.timeout(host)
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return nil, context.DeadlineExceeded
+			}
+			if timeout <= 0 || remaining < timeout {
+				timeout = remaining
+			}
+		}
+		if !breaker.allow(time.Now()) {
+			RejectedTotal.WithLabelValues(host).Inc()
+			return nil, ErrCircuitOpen
+		}
+
+		agent := client.agent(method, rawURL)
+		if prepare != nil {
+			prepare(agent)
+		}
+		if timeout > 0 {
+			agent.Timeout(timeout)
+		}
+		start := time.Now()
+		status, body, errs := agent.Bytes()
+		RequestDuration.WithLabelValues(host, method).Observe(time.Since(start).Seconds())
+
+		err = nil
+		label := strconv.Itoa(status)
+		if len(errs) > 0 {
+			err = errs[0]
+			label = "error"
+		}
+		RequestsTotal.WithLabelValues(host, method, label).Inc()
+
+		failed := err != nil || isRetryableStatus(status)
+		breaker.record(!failed, time.Now())
+		if !failed || attempt >= client.config.Retries || !isIdempotent(method) {
+			if err != nil {
+				return nil, err
+			}
+			return &Response{Status: status, Body: body}, nil
+		}
+
+		RetriesTotal.WithLabelValues(host, method).Inc()
+		timer := time.NewTimer(client.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (client *Client) agent(method, url string) *fiber.Agent {
+	switch method {
+	case fiber.MethodHead:
+		return client.client.Head(url)
+	case fiber.MethodPost:
+		return client.client.Post(url)
+	case fiber.MethodPut:
+		return client.client.Put(url)
+	case fiber.MethodPatch:
+		return client.client.Patch(url)
+	case fiber.MethodDelete:
+		return client.client.Delete(url)
+	default:
+		agent := client.client.Get(url)
+		agent.Request().Header.SetMethod(method)
+		return agent
+	}
+}
+
+func (client *Client) breaker(host string) *breaker {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	hostBreaker, ok := client.breakers[host]
+	if !ok {
+		hostBreaker = newBreaker(host, client.config.FailureThreshold, client.config.OpenTimeout)
+		client.breakers[host] = hostBreaker
+	}
+	return hostBreaker
+}
+
+func (client *Client) timeout(host string) time.Duration {
+	if hostConfig, ok := client.config.Hosts[host]; ok && hostConfig.Timeout > 0 {
+		return hostConfig.Timeout
+	}
+	return client.config.Timeout
+}
+
+// backoff waits a random duration of up to Backoff doubled attempt times, so
+// clients that failed together don't retry together.
+func (client *Client) backoff(attempt int) time.Duration {
+	backoff := client.config.Backoff << attempt
+	if client.config.MaxBackoff > 0 && (backoff > client.config.MaxBackoff || backoff <= 0) {
+		backoff = client.config.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff + 1)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodPut, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	return status == fiber.StatusTooManyRequests || status >= fiber.StatusInternalServerError
+}
@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"golang-fiber-web/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestConfig() config.HTTPClientConfig {
+	return config.HTTPClientConfig{
+		Timeout:          time.Second,
+		Retries:          2,
+		Backoff:          time.Millisecond,
+		MaxBackoff:       time.Millisecond * 5,
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+	}
+}
+
+func newFlakyServer(failures int32) (*httptest.Server, *atomic.Int32) {
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if calls.Add(1) <= failures {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.Write([]byte(request.Method + " " + request.Header.Get("X-Test")))
+	}))
+	return server, calls
+}
+
+func host(server *httptest.Server) string {
+	target, _ := url.Parse(server.URL)
+	return target.Host
+}
+
+func TestClientRetries(t *testing.T) {
+	server, calls := newFlakyServer(2)
+	defer server.Close()
+
+	response, err := New(newTestConfig()).Get(context.Background(), server.URL, func(agent *fiber.Agent) {
+		agent.Set("X-Test", "prepared")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+	assert.Equal(t, "GET prepared", string(response.Body))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, float64(2), testutil.ToFloat64(RetriesTotal.WithLabelValues(host(server), "GET")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(RequestsTotal.WithLabelValues(host(server), "GET", "503")))
+}
+
+func TestClientReturnsLastResponse(t *testing.T) {
+	server, calls := newFlakyServer(10)
+	defer server.Close()
+
+	response, err := New(newTestConfig()).Get(context.Background(), server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.Status)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClientDoesNotRetryPost(t *testing.T) {
+	server, calls := newFlakyServer(1)
+	defer server.Close()
+
+	response, err := New(newTestConfig()).Post(context.Background(), server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.Status)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClientHostTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+	}))
+	defer server.Close()
+
+	clientConfig := newTestConfig()
+	clientConfig.Retries = 0
+	clientConfig.Hosts = map[string]config.HTTPHostConfig{host(server): {Timeout: time.Millisecond * 20}}
+	start := time.Now()
+	_, err := New(clientConfig).Get(context.Background(), server.URL, nil)
+	assert.ErrorIs(t, err, fasthttp.ErrTimeout)
+	assert.Less(t, time.Since(start), time.Millisecond*150)
+}
+
+func TestClientContextDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	start := time.Now()
+	_, err := New(newTestConfig()).Get(ctx, server.URL, nil)
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), time.Millisecond*150)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	server, calls := newFlakyServer(3)
+	defer server.Close()
+
+	clientConfig := newTestConfig()
+	clientConfig.Retries = 0
+	clientConfig.OpenTimeout = time.Millisecond * 50
+	client := New(clientConfig)
+
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(context.Background(), server.URL, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 503, response.Status)
+	}
+	_, err := client.Get(context.Background(), server.URL, nil)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, float64(stateOpen), testutil.ToFloat64(CircuitState.WithLabelValues(host(server))))
+
+	time.Sleep(time.Millisecond * 60)
+	response, err := client.Get(context.Background(), server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+	assert.Equal(t, float64(stateClosed), testutil.ToFloat64(CircuitState.WithLabelValues(host(server))))
+}
+
+func TestBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	now := time.Now()
+	breaker := newBreaker("probe.test", 1, time.Second)
+	breaker.record(false, now)
+	assert.False(t, breaker.allow(now))
+
+	later := now.Add(time.Second)
+	assert.True(t, breaker.allow(later))
+	assert.False(t, breaker.allow(later))
+	breaker.record(false, later)
+	assert.False(t, breaker.allow(later))
+}
@@ -0,0 +1,37 @@
+package httpclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "Number of outbound HTTP attempts by host, method and status code, or \"error\" when no response was received.",
+	}, []string{"host", "method", "status"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Duration of outbound HTTP attempts.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"host", "method"})
+
+	RetriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_retries_total",
+		Help: "Number of outbound HTTP attempts that were retried.",
+	}, []string{"host", "method"})
+
+	RejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_circuit_rejected_total",
+		Help: "Number of outbound HTTP calls rejected because the circuit of the host was open.",
+	}, []string{"host"})
+
+	CircuitState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_client_circuit_state",
+		Help: "State of the circuit of a host: 0 closed, 1 half-open, 2 open.",
+	}, []string{"host"})
+)
+
+func init() {
+	prometheus.MustRegister(RequestsTotal, RequestDuration, RetriesTotal, RejectedTotal, CircuitState)
+}
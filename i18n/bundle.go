@@ -0,0 +1,154 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"golang.org/x/text/language"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Bundle holds the message catalog of every locale. Messages may contain
+// {name} placeholders, which are replaced by the params of Translate.
+type Bundle struct {
+	fallback string
+	locales  []string
+	matcher  language.Matcher
+	catalogs map[string]map[string]string
+}
+
+// Load reads the catalogs of dir, one file per locale named after it, such as
+// en.json or id.toml. Nested objects become dotted keys. fallback is used
+// when no locale matches and for keys missing from a catalog.
+func Load(dir, fallback string) (*Bundle, error) {
+	return LoadFS(os.DirFS(dir), fallback)
+}
+
+func LoadFS(fsys fs.FS, fallback string) (*Bundle, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := map[string]map[string]string{}
+	for _, entry := range entries {
+		extension := path.Ext(entry.Name())
+		if entry.IsDir() || (extension != ".json" && extension != ".toml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		messages := map[string]interface{}{}
+		if extension == ".json" {
+			err = json.Unmarshal(data, &messages)
+		} else {
+			err = toml.Unmarshal(data, &messages)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		locale := strings.TrimSuffix(entry.Name(), extension)
+		if catalogs[locale] == nil {
+			catalogs[locale] = map[string]string{}
+		}
+		flatten(catalogs[locale], "", messages)
+	}
+	return NewBundle(fallback, catalogs)
+}
+
+func NewBundle(fallback string, catalogs map[string]map[string]string) (*Bundle, error) {
+	if _, err := language.Parse(fallback); err != nil {
+		return nil, errors.New("invalid fallback locale " + fallback)
+	}
+
+	// The fallback comes first, so the matcher picks it when nothing matches.
+	locales := []string{fallback}
+	tags := []language.Tag{language.Make(fallback)}
+	for locale := range catalogs {
+		if locale == fallback {
+			continue
+		}
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, errors.New("invalid locale " + locale)
+		}
+		locales = append(locales, locale)
+		tags = append(tags, tag)
+	}
+	return &Bundle{fallback: fallback, locales: locales, matcher: language.NewMatcher(tags), catalogs: catalogs}, nil
+}
+
+func (bundle *Bundle) Locales() []string {
+	return bundle.locales
+}
+
+// Match returns the supported locale closest to the first preference that
+// has one, where each preference is a locale or an Accept-Language header.
+func (bundle *Bundle) Match(preferences ...string) string {
+	for _, preference := range preferences {
+		if preference == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := bundle.matcher.Match(tags...)
+		if confidence != language.No {
+			return bundle.locales[index]
+		}
+	}
+	return bundle.fallback
+}
+
+// Lookup translates key into locale, falling back to the fallback locale.
+// params are pairs of placeholder names and values.
+func (bundle *Bundle) Lookup(locale, key string, params ...interface{}) (string, bool) {
+	message, ok := bundle.catalogs[locale][key]
+	if !ok {
+		message, ok = bundle.catalogs[bundle.fallback][key]
+	}
+	if !ok {
+		return "", false
+	}
+	return replace(message, params), true
+}
+
+// Translate is Lookup returning key itself when no catalog has it.
+func (bundle *Bundle) Translate(locale, key string, params ...interface{}) string {
+	message, ok := bundle.Lookup(locale, key, params...)
+	if !ok {
+		return key
+	}
+	return message
+}
+
+func replace(message string, params []interface{}) string {
+	if len(params) == 0 {
+		return message
+	}
+	pairs := make([]string, 0, len(params))
+	for i := 0; i+1 < len(params); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(params[i])+"}", fmt.Sprint(params[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+func flatten(catalog map[string]string, prefix string, messages map[string]interface{}) {
+	for key, value := range messages {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flatten(catalog, prefix+key+".", value)
+		default:
+			catalog[prefix+key] = fmt.Sprint(value)
+		}
+	}
+}
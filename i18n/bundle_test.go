@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	catalogs := fstest.MapFS{
+		"en.json":   {Data: []byte(`{"greeting": "Hello, {name}!", "home": {"title": "Home"}, "only_en": "English"}`)},
+		"id.toml":   {Data: []byte("greeting = \"Halo, {name}!\"\n\n[home]\ntitle = \"Beranda\"\n")},
+		"README.md": {Data: []byte("not a catalog")},
+	}
+	bundle, err := LoadFS(catalogs, "en")
+	assert.Nil(t, err)
+	return bundle
+}
+
+func TestBundleTranslate(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	assert.ElementsMatch(t, []string{"en", "id"}, bundle.Locales())
+	assert.Equal(t, "Halo, Brian!", bundle.Translate("id", "greeting", "name", "Brian"))
+	assert.Equal(t, "Beranda", bundle.Translate("id", "home.title"))
+	assert.Equal(t, "English", bundle.Translate("id", "only_en"))
+	assert.Equal(t, "missing.key", bundle.Translate("id", "missing.key"))
+
+	_, ok := bundle.Lookup("id", "missing.key")
+	assert.False(t, ok)
+}
+
+func TestBundleMatch(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	assert.Equal(t, "id", bundle.Match("id-ID"))
+	assert.Equal(t, "id", bundle.Match("fr-FR,fr;q=0.9,id;q=0.8"))
+	assert.Equal(t, "id", bundle.Match("", "fr", "id"))
+	assert.Equal(t, "en", bundle.Match("fr"))
+	assert.Equal(t, "en", bundle.Match("not a locale"))
+	assert.Equal(t, "en", bundle.Match())
+}
+
+func TestLoadInvalidCatalog(t *testing.T) {
+	_, err := LoadFS(fstest.MapFS{"en.json": {Data: []byte(`{`)}}, "en")
+	assert.ErrorContains(t, err, "en.json")
+}
@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+	"time"
+)
+
+const (
+	QueryLocale  = "lang"
+	CookieLocale = "lang"
+
+	localizerKey   = "localizer"
+	cookieLifetime = time.Hour * 24 * 365
+)
+
+// Localizer translates into the locale negotiated for a request.
+type Localizer struct {
+	bundle *Bundle
+	Locale string
+}
+
+func (localizer *Localizer) Lookup(key string, params ...interface{}) (string, bool) {
+	return localizer.bundle.Lookup(localizer.Locale, key, params...)
+}
+
+func (localizer *Localizer) T(key string, params ...interface{}) string {
+	return localizer.bundle.Translate(localizer.Locale, key, params...)
+}
+
+// Lambda returns T as a mustache lambda. The section holds the key followed
+// by name=value params, which may use variables:
+//
+//	<p>{{#t}}home.greeting name={{name}}{{/t}}</p>
+func (localizer *Localizer) Lambda() func(text string, render func(string) (string, error)) (string, error) {
+	return func(text string, render func(string) (string, error)) (string, error) {
+		rendered, err := render(text)
+		if err != nil {
+			return "", err
+		}
+		fields := strings.Fields(rendered)
+		if len(fields) == 0 {
+			return "", errors.New("t needs a message key")
+		}
+
+		var params []interface{}
+		for _, field := range fields[1:] {
+			name, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return "", errors.New("t parameter " + field + " is not name=value")
+			}
+			params = append(params, name, value)
+		}
+		return localizer.T(fields[0], params...), nil
+	}
+}
+
+// New negotiates the locale of every request from the lang query parameter,
+// which is remembered in the lang cookie, then that cookie, then the
+// Accept-Language header. The Localizer is kept in the locals together with
+// the "locale" view helper, "t" for mustache and "translate" for html
+// templates, called as {{call .translate "key"}}.
+func New(bundle *Bundle) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		query := ctx.Query(QueryLocale)
+		locale := bundle.Match(query, ctx.Cookies(CookieLocale), ctx.Get(fiber.HeaderAcceptLanguage))
+		if query != "" {
+			ctx.Cookie(&fiber.Cookie{
+				Name:     CookieLocale,
+				Value:    locale,
+				Path:     "/",
+				Expires:  time.Now().Add(cookieLifetime),
+				SameSite: fiber.CookieSameSiteLaxMode,
+			})
+		}
+
+		localizer := &Localizer{bundle: bundle, Locale: locale}
+		ctx.Locals(localizerKey, localizer)
+		ctx.Locals("locale", locale)
+		ctx.Locals("t", localizer.Lambda())
+		ctx.Locals("translate", localizer.T)
+		ctx.Set(fiber.HeaderContentLanguage, locale)
+		ctx.Vary(fiber.HeaderAcceptLanguage)
+		return ctx.Next()
+	}
+}
+
+// FromContext returns the Localizer of the request, or nil when New did not
+// run for it.
+func FromContext(ctx *fiber.Ctx) *Localizer {
+	localizer, _ := ctx.Locals(localizerKey).(*Localizer)
+	return localizer
+}
+
+// T translates key for the request, returning key itself without a Localizer.
+func T(ctx *fiber.Ctx, key string, params ...interface{}) string {
+	localizer := FromContext(ctx)
+	if localizer == nil {
+		return key
+	}
+	return localizer.T(key, params...)
+}
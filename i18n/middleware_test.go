@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func newLocaleApp(t *testing.T) *fiber.App {
+	views := fstest.MapFS{
+		"greeting.mustache": {Data: []byte(`<html lang="{{locale}}">{{#t}}greeting name={{name}}{{/t}}</html>`)},
+	}
+	localeApp := fiber.New(fiber.Config{
+		Views:             mustache.NewFileSystem(http.FS(views), ".mustache"),
+		PassLocalsToViews: true,
+	})
+	localeApp.Use(New(newTestBundle(t)))
+	localeApp.Get("/text", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(T(ctx, "home.title"))
+	})
+	localeApp.Get("/view", func(ctx *fiber.Ctx) error {
+		return ctx.Render("greeting", fiber.Map{"name": "Brian"})
+	})
+	return localeApp
+}
+
+func TestLocaleFromAcceptLanguage(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/text", nil)
+	request.Header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+	response, err := newLocaleApp(t).Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "Beranda", string(bytes))
+	assert.Equal(t, "id", response.Header.Get("Content-Language"))
+	assert.Contains(t, response.Header.Get("Vary"), "Accept-Language")
+}
+
+func TestLocaleFromQueryIsRemembered(t *testing.T) {
+	localeApp := newLocaleApp(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/text?lang=id", nil)
+	request.Header.Set("Accept-Language", "en")
+	response, err := localeApp.Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "Beranda", string(bytes))
+	cookies := response.Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "id", cookies[0].Value)
+
+	request = httptest.NewRequest(http.MethodGet, "/text", nil)
+	request.Header.Set("Accept-Language", "en")
+	request.AddCookie(cookies[0])
+	response, err = localeApp.Test(request)
+	assert.Nil(t, err)
+	bytes, err = io.ReadAll(response.Body)
+	assert.Equal(t, "Beranda", string(bytes))
+}
+
+func TestLocaleViewHelper(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/view?lang=id", nil)
+	response, err := newLocaleApp(t).Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, `<html lang="id">Halo, Brian!</html>`, string(bytes))
+}
@@ -0,0 +1,56 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/model"
+	"golang-fiber-web/testenv"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testenv.Main(m))
+}
+
+func listUsers(t *testing.T, env *testenv.Harness) []model.User {
+	response, err := env.App.Test(httptest.NewRequest("GET", "/users", nil), -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	var page struct {
+		Data []model.User `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(body, &page))
+	return page.Data
+}
+
+func TestUsersSeeded(t *testing.T) {
+	env := testenv.New(t)
+
+	users := listUsers(t, env)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "admin", users[0].Username)
+}
+
+func TestUsersCreated(t *testing.T) {
+	env := testenv.New(t)
+	repositories, err := env.Container.Repositories()
+	assert.Nil(t, err)
+	assert.Nil(t, repositories.Users.Create(context.Background(), &model.User{Username: "brian", Email: "brian@example.com", Name: "Brian"}))
+
+	assert.Len(t, listUsers(t, env), 2)
+}
+
+// TestUsersReset runs after TestUsersCreated: the user it created is gone.
+func TestUsersReset(t *testing.T) {
+	env := testenv.New(t)
+
+	assert.Len(t, listUsers(t, env), 1)
+}
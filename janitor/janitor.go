@@ -0,0 +1,217 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/telemetry"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var logger = telemetry.Logger("janitor")
+
+// The kinds of the files the janitor removes.
+const (
+	KindTemp      = "temp"
+	KindPartial   = "partial"
+	KindOrphan    = "orphan"
+	KindThumbnail = "thumbnail"
+)
+
+// Report sums up what a cleanup removed, or would have removed in a dry run.
+type Report struct {
+	Files int
+	Bytes int64
+}
+
+// Janitor removes the files the app leaves behind, as configured by
+// config.JanitorConfig. The uploaded files are the objects of the "files"
+// directory of the upload directory, named after the ID of their record.
+type Janitor struct {
+	config config.JanitorConfig
+	dir    string
+	files  repository.FileRepository
+	now    func() time.Time
+}
+
+func New(config config.JanitorConfig, uploads config.UploadConfig, files repository.FileRepository) *Janitor {
+	return &Janitor{config: config, dir: uploads.Dir, files: files, now: time.Now}
+}
+
+// Run cleans up every interval until ctx is done.
+func (janitor *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(janitor.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		_, err := janitor.Clean(ctx, janitor.config.DryRun)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("cleaning up files", "error", err)
+		}
+	}
+}
+
+// Clean removes the files to clean up once, or only reports them when
+// dryRun is set. It goes on past the files it fails to check or remove and
+// returns their errors along with what it removed.
+func (janitor *Janitor) Clean(ctx context.Context, dryRun bool) (Report, error) {
+	cleanup := &cleanup{janitor: janitor, ctx: ctx, dryRun: dryRun, now: janitor.now()}
+	for _, dir := range janitor.config.TempDirs {
+		cleanup.temp(dir)
+	}
+	cleanup.partial()
+	cleanup.orphans()
+	cleanup.thumbnails()
+
+	logger.InfoContext(ctx, "cleaned up files", "files", cleanup.report.Files, "bytes", cleanup.report.Bytes,
+		"errors", len(cleanup.errs), "dry_run", dryRun, "duration", time.Since(cleanup.now))
+	return cleanup.report, errors.Join(cleanup.errs...)
+}
+
+// cleanup is one run of the janitor.
+type cleanup struct {
+	janitor *Janitor
+	ctx     context.Context
+	dryRun  bool
+	now     time.Time
+	report  Report
+	errs    []error
+}
+
+// temp removes the files of dir older than the temp retention.
+func (cleanup *cleanup) temp(dir string) {
+	cleanup.walk(dir, func(path string, info fs.FileInfo) {
+		if cleanup.expired(info, cleanup.janitor.config.TempRetention) {
+			cleanup.remove(KindTemp, path, info.Size())
+		}
+	})
+}
+
+// partial removes the partial objects of the storage writes that were
+// interrupted.
+func (cleanup *cleanup) partial() {
+	cleanup.walk(cleanup.janitor.dir, func(path string, info fs.FileInfo) {
+		if strings.HasPrefix(info.Name(), storage.PartialPrefix) && cleanup.expired(info, cleanup.janitor.config.PartialRetention) {
+			cleanup.remove(KindPartial, path, info.Size())
+		}
+	})
+}
+
+// orphans removes the uploaded files without a record. The retention
+// leaves the time to create the record of a file being uploaded.
+func (cleanup *cleanup) orphans() {
+	dir := filepath.Join(cleanup.janitor.dir, "files")
+	entries := cleanup.entries(dir)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), storage.PartialPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			cleanup.fail(err)
+			continue
+		}
+		if cleanup.expired(info, cleanup.janitor.config.OrphanRetention) && cleanup.orphaned(entry.Name()) {
+			cleanup.remove(KindOrphan, filepath.Join(dir, entry.Name()), info.Size())
+		}
+	}
+}
+
+// thumbnails removes the thumbnails of the files without a record, which
+// are kept in a directory named after the ID of the file.
+func (cleanup *cleanup) thumbnails() {
+	dir := filepath.Join(cleanup.janitor.dir, ".thumbnails")
+	for _, entry := range cleanup.entries(dir) {
+		if !entry.IsDir() || !cleanup.orphaned(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		var size int64
+		cleanup.walk(path, func(path string, info fs.FileInfo) {
+			size += info.Size()
+		})
+		cleanup.remove(KindThumbnail, path, size)
+	}
+}
+
+// orphaned tells whether the file id has no record in any tenant.
+func (cleanup *cleanup) orphaned(id string) bool {
+	exists, err := cleanup.janitor.files.Exists(cleanup.ctx, id)
+	if err != nil {
+		cleanup.fail(err)
+		return false
+	}
+	return !exists
+}
+
+func (cleanup *cleanup) expired(info fs.FileInfo, retention time.Duration) bool {
+	return cleanup.now.Sub(info.ModTime()) > retention
+}
+
+func (cleanup *cleanup) remove(kind, path string, size int64) {
+	if !cleanup.dryRun {
+		err := os.RemoveAll(path)
+		if err != nil {
+			cleanup.fail(err)
+			return
+		}
+	}
+	logger.InfoContext(cleanup.ctx, "removed file", "kind", kind, "path", path, "bytes", size, "dry_run", cleanup.dryRun)
+	dryRun := strconv.FormatBool(cleanup.dryRun)
+	telemetry.JanitorRemovedFilesTotal.WithLabelValues(kind, dryRun).Inc()
+	telemetry.JanitorRemovedBytesTotal.WithLabelValues(kind, dryRun).Add(float64(size))
+	cleanup.report.Files++
+	cleanup.report.Bytes += size
+}
+
+// walk calls fn with the regular files under dir, which may not exist.
+func (cleanup *cleanup) walk(dir string, fn func(path string, info fs.FileInfo)) {
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return nil
+		}
+		if err != nil {
+			cleanup.fail(err)
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			cleanup.fail(err)
+			return nil
+		}
+		fn(path, info)
+		return cleanup.ctx.Err()
+	})
+	if err != nil {
+		cleanup.fail(err)
+	}
+}
+
+// entries lists dir, which may not exist.
+func (cleanup *cleanup) entries(dir string) []fs.DirEntry {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		cleanup.fail(err)
+	}
+	return entries
+}
+
+func (cleanup *cleanup) fail(err error) {
+	logger.WarnContext(cleanup.ctx, "cleaning up file failed", "error", err)
+	telemetry.JanitorErrorsTotal.Inc()
+	cleanup.errs = append(cleanup.errs, err)
+}
@@ -0,0 +1,74 @@
+package janitor
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, age time.Duration) {
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.Nil(t, os.WriteFile(path, []byte("hello"), 0644))
+	modified := time.Now().Add(-age)
+	assert.Nil(t, os.Chtimes(path, modified, modified))
+}
+
+func TestClean(t *testing.T) {
+	root := t.TempDir()
+	temp, uploads := filepath.Join(root, "target"), filepath.Join(root, "uploads")
+	files := repository.NewMemoryFileRepository()
+	kept := &model.File{Name: "kept.txt"}
+	// The janitor keeps the files of every tenant, not only the default one.
+	assert.Nil(t, files.Create(tenant.With(context.Background(), "acme"), kept))
+
+	writeFile(t, filepath.Join(temp, "old.txt"), time.Hour*48)
+	writeFile(t, filepath.Join(temp, "nested", "old.txt"), time.Hour*48)
+	writeFile(t, filepath.Join(temp, "new.txt"), time.Minute)
+	writeFile(t, filepath.Join(uploads, "files", kept.ID), time.Hour*48)
+	writeFile(t, filepath.Join(uploads, "files", "orphan"), time.Hour*2)
+	writeFile(t, filepath.Join(uploads, "files", "uploading"), time.Minute)
+	writeFile(t, filepath.Join(uploads, "files", ".put-123"), time.Hour*48)
+	writeFile(t, filepath.Join(uploads, ".thumbnails", kept.ID, "thumbnail.png"), time.Hour*48)
+	writeFile(t, filepath.Join(uploads, ".thumbnails", "orphan", "thumbnail.png"), time.Minute)
+
+	janitor := New(config.JanitorConfig{
+		Interval:         time.Hour,
+		TempDirs:         []string{temp},
+		TempRetention:    time.Hour * 24,
+		OrphanRetention:  time.Hour,
+		PartialRetention: time.Hour * 24,
+	}, config.UploadConfig{Dir: uploads}, files)
+
+	orphans := testutil.ToFloat64(telemetry.JanitorRemovedFilesTotal.WithLabelValues(KindOrphan, "true"))
+	report, err := janitor.Clean(context.Background(), true)
+	assert.Nil(t, err)
+	assert.Equal(t, Report{Files: 5, Bytes: 25}, report)
+	assert.FileExists(t, filepath.Join(temp, "old.txt"))
+	assert.Equal(t, orphans+1, testutil.ToFloat64(telemetry.JanitorRemovedFilesTotal.WithLabelValues(KindOrphan, "true")))
+
+	report, err = janitor.Clean(context.Background(), false)
+	assert.Nil(t, err)
+	assert.Equal(t, Report{Files: 5, Bytes: 25}, report)
+	assert.NoFileExists(t, filepath.Join(temp, "old.txt"))
+	assert.NoFileExists(t, filepath.Join(temp, "nested", "old.txt"))
+	assert.FileExists(t, filepath.Join(temp, "new.txt"))
+	assert.FileExists(t, filepath.Join(uploads, "files", kept.ID))
+	assert.NoFileExists(t, filepath.Join(uploads, "files", "orphan"))
+	assert.FileExists(t, filepath.Join(uploads, "files", "uploading"))
+	assert.NoFileExists(t, filepath.Join(uploads, "files", ".put-123"))
+	assert.DirExists(t, filepath.Join(uploads, ".thumbnails", kept.ID))
+	assert.NoDirExists(t, filepath.Join(uploads, ".thumbnails", "orphan"))
+
+	report, err = janitor.Clean(context.Background(), false)
+	assert.Nil(t, err)
+	assert.Equal(t, Report{}, report)
+}
@@ -0,0 +1,60 @@
+// Package jsoncodec picks the JSON encoder and decoder of the app by name.
+// encoding/json is always built in. jsoniter and sonic are built in with the
+// build tag of the same name. jsoniter is required by go.mod; sonic, whose
+// assembly only builds on amd64 and arm64, is added with go get first.
+package jsoncodec
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2/utils"
+	"sort"
+)
+
+const Standard = "encoding/json"
+
+// Codec encodes and decodes JSON the way encoding/json does.
+type Codec struct {
+	Marshal   utils.JSONMarshal
+	Unmarshal utils.JSONUnmarshal
+}
+
+var codecs = map[string]Codec{
+	Standard: {Marshal: json.Marshal, Unmarshal: json.Unmarshal},
+}
+
+// optional lists the codecs built in only with their build tag, and how to
+// build them in.
+var optional = map[string]string{
+	"jsoniter": "build with -tags jsoniter",
+	"sonic":    "go get github.com/bytedance/sonic and build with -tags sonic",
+}
+
+func register(name string, codec Codec) {
+	codecs[name] = codec
+}
+
+// Get returns the codec called name, encoding/json when name is empty.
+func Get(name string) (Codec, error) {
+	if name == "" {
+		name = Standard
+	}
+	codec, ok := codecs[name]
+	if ok {
+		return codec, nil
+	}
+	if hint, ok := optional[name]; ok {
+		return Codec{}, fmt.Errorf("json codec %s is not built in: %s", name, hint)
+	}
+	return Codec{}, fmt.Errorf("unknown json codec %q", name)
+}
+
+// Names lists the codecs built in.
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,30 @@
+package jsoncodec
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	codec, err := Get("")
+	assert.Nil(t, err)
+	encoded, err := codec.Marshal(map[string]int{"b": 2, "a": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":1,"b":2}`, string(encoded))
+	var decoded map[string]int
+	assert.Nil(t, codec.Unmarshal(encoded, &decoded))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, decoded)
+
+	_, err = Get("yaml")
+	assert.EqualError(t, err, `unknown json codec "yaml"`)
+
+	assert.Contains(t, Names(), Standard)
+	for name := range optional {
+		_, err := Get(name)
+		if _, ok := codecs[name]; ok {
+			assert.Nil(t, err)
+		} else {
+			assert.ErrorContains(t, err, "-tags "+name)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build jsoniter
+
+package jsoncodec
+
+import (
+	"github.com/json-iterator/go"
+)
+
+func init() {
+	api := jsoniter.ConfigCompatibleWithStandardLibrary
+	register("jsoniter", Codec{Marshal: api.Marshal, Unmarshal: api.Unmarshal})
+}
@@ -0,0 +1,12 @@
+//go:build sonic && (amd64 || arm64)
+
+package jsoncodec
+
+import (
+	"github.com/bytedance/sonic"
+)
+
+func init() {
+	api := sonic.ConfigStd
+	register("sonic", Codec{Marshal: api.Marshal, Unmarshal: api.Unmarshal})
+}
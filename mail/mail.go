@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"golang-fiber-web/config"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email. ReplyTo, when set, is where the answers
+// to the message go instead of the sender.
+type Message struct {
+	To      []string
+	ReplyTo string
+	Subject string
+	Body    string
+}
+
+// Sender sends email.
+type Sender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+type smtpSender struct {
+	config config.MailConfig
+}
+
+// NewSMTP sends through the SMTP server of config, upgrading the connection
+// with STARTTLS when the server offers it.
+func NewSMTP(config config.MailConfig) Sender {
+	return &smtpSender{config: config}
+}
+
+func (sender *smtpSender) Send(ctx context.Context, message *Message) error {
+	if len(message.To) == 0 {
+		return errors.New("mail has no recipient")
+	}
+	data, err := Format(sender.config.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(sender.config.From)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if sender.config.Username != "" {
+		auth = smtp.PlainAuth("", sender.config.Username, sender.config.Password, sender.config.Host)
+	}
+
+	// smtp.SendMail takes no context, so the send is abandoned, though not
+	// interrupted, once ctx is done.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(sender.config.Host, strconv.Itoa(sender.config.Port)), auth, from.Address, message.To, data)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Address formats the address with the display name, quoted or encoded as
+// needed, for the headers of a message.
+func Address(name, address string) string {
+	return (&mail.Address{Name: name, Address: address}).String()
+}
+
+// Format renders message as sent from the address from at date, with the
+// headers encoded as RFC 2047 words where they are not ASCII.
+func Format(from string, message *Message, date time.Time) ([]byte, error) {
+	headers := [][2]string{
+		{"From", from},
+		{"To", strings.Join(message.To, ", ")},
+	}
+	if message.ReplyTo != "" {
+		headers = append(headers, [2]string{"Reply-To", message.ReplyTo})
+	}
+	headers = append(headers,
+		[2]string{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		[2]string{"Date", date.Format(time.RFC1123Z)},
+		[2]string{"MIME-Version", "1.0"},
+		[2]string{"Content-Type", "text/plain; charset=utf-8"},
+		[2]string{"Content-Transfer-Encoding", "8bit"},
+	)
+
+	var output bytes.Buffer
+	for _, header := range headers {
+		if strings.ContainsAny(header[1], "\r\n") {
+			return nil, errors.New("mail header " + header[0] + " has a line break")
+		}
+		output.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	output.WriteString("\r\n")
+	body := strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n")
+	output.WriteString(body)
+	if !strings.HasSuffix(body, "\r\n") {
+		output.WriteString("\r\n")
+	}
+	return output.Bytes(), nil
+}
@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	data, err := Format("Shop <shop@example.com>", &Message{
+		To:      []string{"support@example.com"},
+		ReplyTo: "brian@example.com",
+		Subject: "Pesanan saya — tolong",
+		Body:    "Hello,\nwhere is my order?",
+	}, date)
+	assert.Nil(t, err)
+	assert.Equal(t, "From: Shop <shop@example.com>\r\n"+
+		"To: support@example.com\r\n"+
+		"Reply-To: brian@example.com\r\n"+
+		"Subject: =?utf-8?q?Pesanan_saya_=E2=80=94_tolong?=\r\n"+
+		"Date: Wed, 01 May 2024 10:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: 8bit\r\n"+
+		"\r\n"+
+		"Hello,\r\nwhere is my order?\r\n", string(data))
+
+	_, err = Format("shop@example.com", &Message{To: []string{"support@example.com"}, ReplyTo: "a@example.com\r\nBcc: b@example.com"}, date)
+	assert.NotNil(t, err)
+}
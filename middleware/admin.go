@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/service"
+	"strings"
+)
+
+// NewAdminAuth lets only the admin of config through, with basic auth, and
+// makes them the actor of the audit events recorded afterwards. Every request
+// is rejected while no admin password is configured.
+func NewAdminAuth(config config.AdminConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if config.Password == "" || !ValidBasicAuth(ctx.Get(fiber.HeaderAuthorization), config.Username, config.Password) {
+			ctx.Set(fiber.HeaderWWWAuthenticate, `basic realm="admin"`)
+			return ctx.SendStatus(fiber.StatusUnauthorized)
+		}
+		ctx.SetUserContext(service.WithActor(ctx.UserContext(), config.Username))
+		return ctx.Next()
+	}
+}
+
+// ValidBasicAuth reports whether an Authorization header carries the given
+// basic auth credentials.
+func ValidBasicAuth(header, username, password string) bool {
+	encoded, ok := strings.CutPrefix(header, "Basic ")
+	if !ok {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	gotUsername, gotPassword, ok := strings.Cut(string(decoded), ":")
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(gotUsername), []byte(username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(gotPassword), []byte(password)) == 1
+}
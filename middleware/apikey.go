@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+// NewAPIKeyAuth authenticates the requests carrying an X-API-Key header as
+// the user of the key, whose ID it puts in the user_id local and the ID of
+// the key in api_key_id. Requests without the header go through as they are;
+// those with an unknown key are answered 401.
+func NewAPIKeyAuth(keys service.APIKeyService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		value := ctx.Get("X-API-Key")
+		if value == "" {
+			return ctx.Next()
+		}
+		key, err := keys.Authenticate(ctx.UserContext(), value)
+		if errors.Is(err, model.ErrAPIKeyNotFound) {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusUnauthorized, "invalid API key"))
+		}
+		if err != nil {
+			return err
+		}
+		ctx.Locals("user_id", key.UserID)
+		ctx.Locals("api_key_id", key.ID)
+		return ctx.Next()
+	}
+}
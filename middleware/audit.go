@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/service"
+)
+
+const ActorAnonymous = "anonymous"
+
+// NewAuditContext puts the client IP and request ID in ctx.UserContext() for
+// the audit events recorded while handling the request. It must run after
+// the real IP and request ID middleware. The actor is anonymous until an
+// authentication middleware calls service.WithActor.
+func NewAuditContext() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(service.WithAuditMetadata(ctx.UserContext(), service.AuditMetadata{
+			Actor:     ActorAnonymous,
+			IP:        ctx.IP(),
+			RequestID: ctx.GetRespHeader(fiber.HeaderXRequestID),
+		}))
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/service"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditContext(t *testing.T) {
+	auditApp := fiber.New()
+	auditApp.Use(requestid.New(), NewAuditContext())
+	var metadata service.AuditMetadata
+	auditApp.Get("/", func(ctx *fiber.Ctx) error {
+		metadata = service.AuditMetadataFrom(ctx.UserContext())
+		return nil
+	})
+
+	response, err := auditApp.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, ActorAnonymous, metadata.Actor)
+	assert.Equal(t, "0.0.0.0", metadata.IP)
+	assert.Equal(t, response.Header.Get(fiber.HeaderXRequestID), metadata.RequestID)
+	assert.NotEmpty(t, metadata.RequestID)
+}
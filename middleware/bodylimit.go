@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"strconv"
+)
+
+// NewBodyLimit answers 413 with a problem document when the request body is
+// larger than the limit of the longest matching route prefix. The server
+// reads bodies up to the largest limit, fiber.Config.BodyLimit, before
+// routing, so that must be set to config.Max().
+func NewBodyLimit(config config.BodyLimitConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		limit := routeValue(config.Routes, config.Default, ctx.Path())
+		if limit <= 0 {
+			return ctx.Next()
+		}
+
+		size := ctx.Request().Header.ContentLength()
+		if body := len(ctx.Request().Body()); body > size {
+			size = body
+		}
+		if size > int(limit) {
+			problem := web.NewProblem(fiber.StatusRequestEntityTooLarge, "request body is larger than "+strconv.Itoa(int(limit))+" bytes")
+			return web.SendError(ctx, problem.With("limit", int(limit)))
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	limits := config.BodyLimitConfig{Default: 16, Routes: map[string]config.ByteSize{"/upload": 64}}
+	limitApp := fiber.New(fiber.Config{BodyLimit: int(limits.Max())})
+	limitApp.Use(NewBodyLimit(limits))
+	limitApp.Post("/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	post := func(path string, size int) *http.Response {
+		response, err := limitApp.Test(httptest.NewRequest(http.MethodPost, path, strings.NewReader(strings.Repeat("x", size))))
+		assert.Nil(t, err)
+		return response
+	}
+
+	assert.Equal(t, 200, post("/users", 16).StatusCode)
+	assert.Equal(t, 200, post("/upload", 64).StatusCode)
+
+	response := post("/users", 17)
+	assert.Equal(t, 413, response.StatusCode)
+	problem := map[string]interface{}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(t, float64(16), problem["limit"])
+
+	assert.Equal(t, 413, post("/users/1", 64).StatusCode)
+}
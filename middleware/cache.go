@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/tenant"
+	"sort"
+	"strings"
+	"time"
+)
+
+var uncachedHeaders = map[string]bool{
+	fiber.HeaderContentLength: true,
+	fiber.HeaderDate:          true,
+	fiber.HeaderServer:        true,
+	"X-Cache":                 true,
+}
+
+type CacheInvalidator interface {
+	Invalidate(paths ...string) error
+}
+
+type cachedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    []byte            `json:"body"`
+}
+
+type Cache struct {
+	store  cache.Store
+	config config.CacheConfig
+}
+
+func NewCache(store cache.Store, config config.CacheConfig) *Cache {
+	return &Cache{store: store, config: config}
+}
+
+// Middleware serves GET and HEAD requests from the store and caches successful
+// responses for the TTL configured for the longest matching route prefix.
+// Requests carrying credentials, responses setting cookies and streamed
+// responses are never cached.
+func (cache *Cache) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() != fiber.MethodGet && ctx.Method() != fiber.MethodHead {
+			return ctx.Next()
+		}
+		if ctx.Get(fiber.HeaderAuthorization) != "" || ctx.Get("X-API-Key") != "" || strings.Contains(ctx.Get(fiber.HeaderCacheControl), "no-cache") {
+			return ctx.Next()
+		}
+		ttl := cache.ttl(ctx.Path())
+		if ttl <= 0 {
+			return ctx.Next()
+		}
+
+		key := cache.key(ctx)
+		value, ok, err := cache.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			cached := cachedResponse{}
+			err = json.Unmarshal(value, &cached)
+			if err == nil {
+				for name, header := range cached.Headers {
+					ctx.Set(name, header)
+				}
+				ctx.Set("X-Cache", "HIT")
+				return ctx.Status(cached.Status).Send(cached.Body)
+			}
+		}
+
+		err = ctx.Next()
+		if err != nil {
+			return err
+		}
+
+		response := ctx.Response()
+		if response.StatusCode() != fiber.StatusOK || response.IsBodyStream() || len(response.Header.Peek(fiber.HeaderSetCookie)) > 0 {
+			return nil
+		}
+
+		cached := cachedResponse{
+			Status:  response.StatusCode(),
+			Headers: map[string]string{},
+			Body:    append([]byte(nil), response.Body()...),
+		}
+		response.Header.VisitAll(func(name, value []byte) {
+			if !uncachedHeaders[string(name)] {
+				cached.Headers[string(name)] = string(value)
+			}
+		})
+		value, err = json.Marshal(cached)
+		if err != nil {
+			return err
+		}
+		ctx.Set("X-Cache", "MISS")
+		return cache.store.Set(key, value, ttl, cacheTag(ctx.Path()))
+	}
+}
+
+// Invalidate purges every cached variant (query string and Vary headers) of
+// the given paths. Write handlers call it for the resources they change.
+func (cache *Cache) Invalidate(paths ...string) error {
+	for _, path := range paths {
+		err := cache.store.InvalidateTag(cacheTag(path))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cache *Cache) ttl(path string) time.Duration {
+	return routeValue(cache.config.Routes, cache.config.DefaultTTL, path)
+}
+
+// routeValue returns the value of the longest prefix of path in routes, or
+// fallback when none matches.
+func routeValue[T any](routes map[string]T, fallback T, path string) T {
+	result := fallback
+	matched := -1
+	for prefix, value := range routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > matched {
+			result = value
+			matched = len(prefix)
+		}
+	}
+	return result
+}
+
+// key identifies a response by the tenant, method, path, query and Vary
+// headers of its request, so tenants never see each other's responses.
+func (cache *Cache) key(ctx *fiber.Ctx) string {
+	args := []string{}
+	ctx.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		args = append(args, string(key)+"="+string(value))
+	})
+	sort.Strings(args)
+
+	hash := sha256.New()
+	hash.Write([]byte(tenant.From(ctx.UserContext()) + "\n"))
+	hash.Write([]byte(ctx.Method() + " " + ctx.Path() + "?" + strings.Join(args, "&")))
+	for _, header := range cache.config.Vary {
+		hash.Write([]byte("\n" + header + ": " + ctx.Get(header)))
+	}
+	return "cache:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func cacheTag(path string) string {
+	return "cache-path:" + path
+}
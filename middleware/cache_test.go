@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/tenant"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCacheApp(store cache.Store) (*fiber.App, *int) {
+	responseCache := NewCache(store, config.CacheConfig{
+		Routes: map[string]time.Duration{"/users": time.Minute},
+		Vary:   []string{"Accept"},
+	})
+	names := map[string]string{"1": "Brian"}
+	hits := 0
+
+	cacheApp := fiber.New()
+	cacheApp.Use(responseCache.Middleware())
+	cacheApp.Get("/counter", func(ctx *fiber.Ctx) error {
+		hits++
+		return ctx.SendString(strconv.Itoa(hits))
+	})
+	cacheApp.Use("/users", func(ctx *fiber.Ctx) error {
+		hits++
+		return ctx.Next()
+	})
+	cacheApp.Get("/users/:id", func(ctx *fiber.Ctx) error {
+		name := names[ctx.Params("id")]
+		if ctx.Accepts(fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML) == fiber.MIMEApplicationXML {
+			ctx.Type("xml")
+			return ctx.SendString("<name>" + name + "</name>")
+		}
+		return ctx.JSON(fiber.Map{"id": ctx.Params("id"), "name": name})
+	})
+	cacheApp.Put("/users/:id", func(ctx *fiber.Ctx) error {
+		names[ctx.Params("id")] = string(ctx.Body())
+		return responseCache.Invalidate("/users/" + ctx.Params("id"))
+	})
+	return cacheApp, &hits
+}
+
+func cacheGet(t *testing.T, cacheApp *fiber.App, target, accept string) (*http.Response, string) {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request.Header.Set("Accept", accept)
+	response, err := cacheApp.Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return response, string(bytes)
+}
+
+func testCacheStore(t *testing.T, store cache.Store) {
+	cacheApp, hits := newCacheApp(store)
+
+	response, first := cacheGet(t, cacheApp, "/users/1", "application/json")
+	assert.Equal(t, "MISS", response.Header.Get("X-Cache"))
+	response, second := cacheGet(t, cacheApp, "/users/1", "application/json")
+	assert.Equal(t, "HIT", response.Header.Get("X-Cache"))
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, *hits)
+
+	response, _ = cacheGet(t, cacheApp, "/users/1", "application/xml")
+	assert.Equal(t, "MISS", response.Header.Get("X-Cache"))
+	assert.Equal(t, 2, *hits)
+
+	request := httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader("Brian Anashari"))
+	response, err := cacheApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, 3, *hits)
+
+	response, updated := cacheGet(t, cacheApp, "/users/1", "application/json")
+	assert.Equal(t, "MISS", response.Header.Get("X-Cache"))
+	assert.Contains(t, updated, "Brian Anashari")
+
+	cacheGet(t, cacheApp, "/counter", "")
+	_, counter := cacheGet(t, cacheApp, "/counter", "")
+	assert.Equal(t, "6", counter)
+}
+
+func TestCacheMemory(t *testing.T) {
+	testCacheStore(t, cache.NewMemoryStore())
+}
+
+func TestCacheRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	testCacheStore(t, cache.NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()})))
+}
+
+func TestCacheTenants(t *testing.T) {
+	responseCache := NewCache(cache.NewMemoryStore(), config.CacheConfig{Routes: map[string]time.Duration{"/users": time.Minute}})
+	cacheApp := fiber.New()
+	cacheApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(tenant.With(ctx.UserContext(), ctx.Get("X-Tenant-ID")))
+		return ctx.Next()
+	})
+	cacheApp.Use(responseCache.Middleware())
+	cacheApp.Get("/users", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("users of " + tenant.From(ctx.UserContext()))
+	})
+
+	get := func(tenantID string) (string, string) {
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		request.Header.Set("X-Tenant-ID", tenantID)
+		response, err := cacheApp.Test(request)
+		assert.Nil(t, err)
+		body, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return response.Header.Get("X-Cache"), string(body)
+	}
+
+	_, body := get("acme")
+	assert.Equal(t, "users of acme", body)
+	status, body := get("globex")
+	assert.Equal(t, "MISS", status)
+	assert.Equal(t, "users of globex", body)
+	status, body = get("acme")
+	assert.Equal(t, "HIT", status)
+	assert.Equal(t, "users of acme", body)
+}
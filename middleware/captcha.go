@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/web"
+	"strconv"
+)
+
+const HeaderXCaptchaResponse = "X-Captcha-Response"
+
+// captchaProviders are the verification endpoints of the captcha providers
+// and the fields their widgets put the response in.
+var captchaProviders = map[string]struct {
+	verifyURL string
+	field     string
+}{
+	"hcaptcha":  {verifyURL: "https://api.hcaptcha.com/siteverify", field: "h-captcha-response"},
+	"recaptcha": {verifyURL: "https://www.google.com/recaptcha/api/siteverify", field: "g-recaptcha-response"},
+	"turnstile": {verifyURL: "https://challenges.cloudflare.com/turnstile/v0/siteverify", field: "cf-turnstile-response"},
+}
+
+// NewCaptcha lets through only the requests carrying a captcha response that
+// the provider of config verifies, read from the X-Captcha-Response header or
+// the form or query field of the provider's widget. Requests without one get
+// 400 and those failing verification 403. Every request goes through while
+// config is not enabled.
+func NewCaptcha(config config.CaptchaConfig, client *httpclient.Client) (fiber.Handler, error) {
+	if !config.Enabled {
+		return func(ctx *fiber.Ctx) error {
+			return ctx.Next()
+		}, nil
+	}
+	provider, ok := captchaProviders[config.Provider]
+	if !ok {
+		return nil, errors.New("unknown captcha provider " + strconv.Quote(config.Provider))
+	}
+	if config.Secret == "" {
+		return nil, errors.New("the captcha needs a secret")
+	}
+	verifyURL := provider.verifyURL
+	if config.VerifyURL != "" {
+		verifyURL = config.VerifyURL
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		response := ctx.Get(HeaderXCaptchaResponse)
+		if response == "" {
+			response = ctx.FormValue(provider.field)
+		}
+		if response == "" {
+			response = ctx.Query(provider.field)
+		}
+		if response == "" {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusBadRequest, "a captcha response is required").
+				With("field", provider.field))
+		}
+
+		args := fiber.AcquireArgs()
+		defer fiber.ReleaseArgs(args)
+		args.Set("secret", config.Secret)
+		args.Set("response", response)
+		args.Set("remoteip", ctx.IP())
+		verified, err := client.Post(ctx.UserContext(), verifyURL, func(agent *fiber.Agent) {
+			agent.Form(args)
+		})
+		if err != nil {
+			logger.ErrorContext(ctx.UserContext(), "verifying captcha", "error", err)
+			return fiber.NewError(fiber.StatusBadGateway, "the captcha could not be verified")
+		}
+
+		result := struct {
+			Success    bool     `json:"success"`
+			Score      *float64 `json:"score"`
+			ErrorCodes []string `json:"error-codes"`
+		}{}
+		if verified.Status != fiber.StatusOK || json.Unmarshal(verified.Body, &result) != nil {
+			logger.ErrorContext(ctx.UserContext(), "verifying captcha", "status", verified.Status)
+			return fiber.NewError(fiber.StatusBadGateway, "the captcha could not be verified")
+		}
+		if !result.Success || (result.Score != nil && *result.Score < config.MinScore) {
+			problem := web.NewProblem(fiber.StatusForbidden, "the captcha was not solved")
+			if len(result.ErrorCodes) > 0 {
+				problem.With("errors", result.ErrorCodes)
+			}
+			return web.SendError(ctx, problem)
+		}
+		return ctx.Next()
+	}, nil
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/web"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newFakeCaptchaProvider accepts the "solved" response, scoring it score
+// when not zero.
+func newFakeCaptchaProvider(t *testing.T, score float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Nil(t, request.ParseForm())
+		assert.Equal(t, "captcha-secret", request.PostForm.Get("secret"))
+		result := map[string]interface{}{"success": request.PostForm.Get("response") == "solved"}
+		if result["success"] == false {
+			result["error-codes"] = []string{"invalid-input-response"}
+		}
+		if score != 0 {
+			result["score"] = score
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(result)
+	}))
+}
+
+func newCaptchaApp(t *testing.T, captchaConfig config.CaptchaConfig) *fiber.App {
+	captcha, err := NewCaptcha(captchaConfig, httpclient.New(config.Default().HTTPClient))
+	assert.Nil(t, err)
+	app := fiber.New(fiber.Config{ErrorHandler: web.NewErrorHandler(true)})
+	app.Post("/login", captcha, func(ctx *fiber.Ctx) error {
+		return ctx.SendString("welcome")
+	})
+	return app
+}
+
+func postLogin(t *testing.T, app *fiber.App, form url.Values, header string) int {
+	request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	if header != "" {
+		request.Header.Set(HeaderXCaptchaResponse, header)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response.StatusCode
+}
+
+func TestCaptcha(t *testing.T) {
+	provider := newFakeCaptchaProvider(t, 0)
+	defer provider.Close()
+	app := newCaptchaApp(t, config.CaptchaConfig{Enabled: true, Provider: "hcaptcha", Secret: "captcha-secret", VerifyURL: provider.URL})
+
+	assert.Equal(t, 200, postLogin(t, app, url.Values{"h-captcha-response": {"solved"}}, ""))
+	assert.Equal(t, 200, postLogin(t, app, nil, "solved"))
+	assert.Equal(t, 403, postLogin(t, app, url.Values{"h-captcha-response": {"guessed"}}, ""))
+	assert.Equal(t, 400, postLogin(t, app, url.Values{"g-recaptcha-response": {"solved"}}, ""))
+}
+
+func TestCaptchaMinScore(t *testing.T) {
+	provider := newFakeCaptchaProvider(t, 0.3)
+	defer provider.Close()
+	captchaConfig := config.CaptchaConfig{Enabled: true, Provider: "recaptcha", Secret: "captcha-secret", VerifyURL: provider.URL, MinScore: 0.5}
+
+	assert.Equal(t, 403, postLogin(t, newCaptchaApp(t, captchaConfig), url.Values{"g-recaptcha-response": {"solved"}}, ""))
+	captchaConfig.MinScore = 0.2
+	assert.Equal(t, 200, postLogin(t, newCaptchaApp(t, captchaConfig), url.Values{"g-recaptcha-response": {"solved"}}, ""))
+}
+
+func TestCaptchaDisabled(t *testing.T) {
+	app := newCaptchaApp(t, config.CaptchaConfig{Provider: "turnstile"})
+	assert.Equal(t, 200, postLogin(t, app, nil, ""))
+
+	_, err := NewCaptcha(config.CaptchaConfig{Enabled: true, Provider: "unknown", Secret: "captcha-secret"}, nil)
+	assert.NotNil(t, err)
+	_, err = NewCaptcha(config.CaptchaConfig{Enabled: true, Provider: "turnstile"}, nil)
+	assert.NotNil(t, err)
+}
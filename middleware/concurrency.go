@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/web"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// globalGroup is the group label of the limit on all requests.
+const globalGroup = "*"
+
+// concurrencyLimiter lets limit requests in progress at once, and up to queue
+// more wait for one of them to finish.
+type concurrencyLimiter struct {
+	group   string
+	slots   chan struct{}
+	queue   int64
+	waiting atomic.Int64
+}
+
+func newConcurrencyLimiter(group string, limit int, queue int) *concurrencyLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{group: group, slots: make(chan struct{}, limit), queue: int64(queue)}
+}
+
+// acquire takes a slot, waiting up to timeout for one when there is room in
+// the queue, and reports whether it did. A nil limiter is unlimited.
+func (limiter *concurrencyLimiter) acquire(timeout time.Duration) bool {
+	if limiter == nil {
+		return true
+	}
+	select {
+	case limiter.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if limiter.waiting.Add(1) > limiter.queue {
+		limiter.waiting.Add(-1)
+		return false
+	}
+	defer limiter.waiting.Add(-1)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case limiter.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (limiter *concurrencyLimiter) release() {
+	if limiter != nil {
+		<-limiter.slots
+	}
+}
+
+// NewConcurrencyLimit bounds the requests in progress, in all and in the
+// group of the longest matching route prefix, and answers 503 with
+// Retry-After to those that waited too long for their turn or found the queue
+// full. A request takes the slot of its group before the global one, so the
+// requests queued on an expensive group don't hold up the others.
+func NewConcurrencyLimit(config config.ConcurrencyConfig) fiber.Handler {
+	global := newConcurrencyLimiter(globalGroup, config.Max, config.Queue)
+	groups := map[string]*concurrencyLimiter{}
+	for prefix, limit := range config.Groups {
+		if limiter := newConcurrencyLimiter(prefix, limit.Max, limit.Queue); limiter != nil {
+			groups[prefix] = limiter
+		}
+	}
+	retryAfter := strconv.Itoa(max(int(math.Ceil(config.RetryAfter.Seconds())), 1))
+
+	reject := func(ctx *fiber.Ctx, limiter *concurrencyLimiter) error {
+		telemetry.ConcurrencyRejectedTotal.WithLabelValues(limiter.group).Inc()
+		ctx.Set(fiber.HeaderRetryAfter, retryAfter)
+		return web.SendError(ctx, web.NewProblem(fiber.StatusServiceUnavailable, "too many requests in progress, retry later"))
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		group := routeValue(groups, nil, ctx.Path())
+		if !group.acquire(config.QueueTimeout) {
+			return reject(ctx, group)
+		}
+		defer group.release()
+		if !global.acquire(config.QueueTimeout) {
+			return reject(ctx, global)
+		}
+		defer global.release()
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newConcurrencyApp(limit config.ConcurrencyConfig, started chan struct{}, block chan struct{}) *fiber.App {
+	concurrencyApp := fiber.New()
+	concurrencyApp.Use(NewConcurrencyLimit(limit))
+	handler := func(ctx *fiber.Ctx) error {
+		if ctx.Query("block") != "" {
+			started <- struct{}{}
+			<-block
+		}
+		return ctx.SendString("OK")
+	}
+	concurrencyApp.Get("/upload", handler)
+	concurrencyApp.Get("/users", handler)
+	return concurrencyApp
+}
+
+func testConcurrency(app *fiber.App, path string) chan int {
+	done := make(chan int, 1)
+	go func() {
+		response, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+		if err != nil {
+			done <- 0
+			return
+		}
+		done <- response.StatusCode
+	}()
+	return done
+}
+
+func TestConcurrencyLimitGroup(t *testing.T) {
+	started := make(chan struct{})
+	block := make(chan struct{})
+	concurrencyApp := newConcurrencyApp(config.ConcurrencyConfig{
+		QueueTimeout: time.Second * 5,
+		RetryAfter:   time.Second * 2,
+		Groups:       map[string]config.ConcurrencyLimit{"/upload": {Max: 1}},
+	}, started, block)
+
+	first := testConcurrency(concurrencyApp, "/upload?block=1")
+	<-started
+	response, err := concurrencyApp.Test(httptest.NewRequest("GET", "/upload", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+	assert.Equal(t, "2", response.Header.Get(fiber.HeaderRetryAfter))
+
+	// The other routes are not held up by the group.
+	response, err = concurrencyApp.Test(httptest.NewRequest("GET", "/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	close(block)
+	assert.Equal(t, 200, <-first)
+	response, err = concurrencyApp.Test(httptest.NewRequest("GET", "/upload", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	limiter := newConcurrencyLimiter("/upload", 1, 1)
+	assert.True(t, limiter.acquire(0))
+
+	queued := make(chan bool)
+	go func() {
+		queued <- limiter.acquire(time.Second * 5)
+	}()
+	assert.Eventually(t, func() bool { return limiter.waiting.Load() == 1 }, time.Second, time.Millisecond*10)
+	// The queue is full.
+	assert.False(t, limiter.acquire(time.Second*5))
+
+	limiter.release()
+	assert.True(t, <-queued)
+	assert.Equal(t, int64(0), limiter.waiting.Load())
+	limiter.release()
+
+	assert.Nil(t, newConcurrencyLimiter("/upload", 0, 1))
+	assert.True(t, (*concurrencyLimiter)(nil).acquire(0))
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	started := make(chan struct{})
+	block := make(chan struct{})
+	concurrencyApp := newConcurrencyApp(config.ConcurrencyConfig{
+		Max:          1,
+		Queue:        1,
+		QueueTimeout: time.Millisecond * 50,
+	}, started, block)
+
+	first := testConcurrency(concurrencyApp, "/users?block=1")
+	<-started
+	response, err := concurrencyApp.Test(httptest.NewRequest("GET", "/upload", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+	assert.Equal(t, "1", response.Header.Get(fiber.HeaderRetryAfter))
+
+	close(block)
+	assert.Equal(t, 200, <-first)
+	response, err = concurrencyApp.Test(httptest.NewRequest("GET", "/upload", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
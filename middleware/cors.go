@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"golang-fiber-web/config"
+	"slices"
+)
+
+// NewCORS allows cross-origin requests from the CORS.AllowOrigins of live,
+// which are read on every request so a config reload applies at once.
+func NewCORS(live *config.Live) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOriginsFunc: func(origin string) bool {
+			origins := live.Get().CORS.AllowOrigins
+			return slices.Contains(origins, "*") || slices.Contains(origins, origin)
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	live := config.NewLive(config.Default())
+	corsApp := fiber.New()
+	corsApp.Use(NewCORS(live))
+	corsApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	allowedOrigin := func(origin string) string {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set(fiber.HeaderOrigin, origin)
+		response, err := corsApp.Test(request)
+		assert.Nil(t, err)
+		return response.Header.Get(fiber.HeaderAccessControlAllowOrigin)
+	}
+
+	assert.Equal(t, "", allowedOrigin("https://example.com"))
+
+	reloaded := config.Default()
+	reloaded.CORS.AllowOrigins = []string{"https://example.com"}
+	live.Update(reloaded)
+	assert.Equal(t, "https://example.com", allowedOrigin("https://example.com"))
+	assert.Equal(t, "", allowedOrigin("https://evil.example"))
+}
+
+func TestRequireFeature(t *testing.T) {
+	live := config.NewLive(config.Default())
+	featureApp := fiber.New()
+	featureApp.Get("/beta", RequireFeature(live, "beta"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	response, err := featureApp.Test(httptest.NewRequest(http.MethodGet, "/beta", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+
+	reloaded := config.Default()
+	reloaded.Features["beta"] = true
+	live.Update(reloaded)
+	response, err = featureApp.Test(httptest.NewRequest(http.MethodGet, "/beta", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
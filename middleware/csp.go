@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"strings"
+)
+
+// NewCSP sends the Content-Security-Policy of config with a nonce made for
+// every request, which it keeps in the "cspNonce" locals, where views find it
+// and handlers get it with CSPNonce.
+func NewCSP(config config.CSPConfig) fiber.Handler {
+	header := fiber.HeaderContentSecurityPolicy
+	if config.ReportOnly {
+		header = fiber.HeaderContentSecurityPolicyReportOnly
+	}
+	return func(ctx *fiber.Ctx) error {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			return err
+		}
+		nonce := base64.StdEncoding.EncodeToString(random)
+		ctx.Locals("cspNonce", nonce)
+		ctx.Set(header, strings.ReplaceAll(config.Policy, "{nonce}", nonce))
+		return ctx.Next()
+	}
+}
+
+// CSPNonce returns the nonce of the request made by NewCSP, or "" without it.
+func CSPNonce(ctx *fiber.Ctx) string {
+	nonce, _ := ctx.Locals("cspNonce").(string)
+	return nonce
+}
+
+// AllowCSPSources adds sources to the directives of the Content-Security-Policy
+// NewCSP set on the response, for a page embedding third-party content such
+// as a captcha widget. A directive the policy lacks starts from the sources
+// of default-src, which it would otherwise fall back to; without default-src
+// it is not restricted and stays out.
+func AllowCSPSources(ctx *fiber.Ctx, directives []string, sources ...string) {
+	header := fiber.HeaderContentSecurityPolicy
+	policy := ctx.GetRespHeader(header)
+	if policy == "" {
+		header = fiber.HeaderContentSecurityPolicyReportOnly
+		policy = ctx.GetRespHeader(header)
+	}
+	if policy == "" {
+		return
+	}
+
+	parts := strings.Split(policy, ";")
+	var defaultSources string
+	for _, part := range parts {
+		if name, value, _ := strings.Cut(strings.TrimSpace(part), " "); name == "default-src" {
+			defaultSources = value
+		}
+	}
+	for _, directive := range directives {
+		found := false
+		for i, part := range parts {
+			if name, _, _ := strings.Cut(strings.TrimSpace(part), " "); name == directive {
+				parts[i] = strings.TrimRight(part, " ") + " " + strings.Join(sources, " ")
+				found = true
+			}
+		}
+		if !found && defaultSources != "" {
+			parts = append(parts, " "+directive+" "+defaultSources+" "+strings.Join(sources, " "))
+		}
+	}
+	ctx.Set(header, strings.Join(parts, ";"))
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+var scriptNonce = regexp.MustCompile(`<script nonce="([^"]+)">`)
+
+func newCSPApp(cspConfig config.CSPConfig) *fiber.App {
+	views := fstest.MapFS{
+		"page.mustache": {Data: []byte(`<script nonce="{{cspNonce}}">start()</script>`)},
+	}
+	app := fiber.New(fiber.Config{
+		Views:             mustache.NewFileSystem(http.FS(views), ".mustache"),
+		PassLocalsToViews: true,
+	})
+	app.Use(NewCSP(cspConfig))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.Render("page", fiber.Map{})
+	})
+	return app
+}
+
+func TestCSPNonce(t *testing.T) {
+	app := newCSPApp(config.CSPConfig{Policy: "script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'"})
+
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		response, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		match := scriptNonce.FindStringSubmatch(string(body))
+		if assert.NotNil(t, match, string(body)) {
+			nonce := match[1]
+			assert.Len(t, nonce, 24)
+			assert.Equal(t, "script-src 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'", response.Header.Get(fiber.HeaderContentSecurityPolicy))
+			nonces = append(nonces, nonce)
+		}
+	}
+	if assert.Len(t, nonces, 2) {
+		assert.NotEqual(t, nonces[0], nonces[1])
+	}
+}
+
+func TestCSPReportOnly(t *testing.T) {
+	app := newCSPApp(config.CSPConfig{ReportOnly: true, Policy: "default-src 'self'"})
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(t, err)
+	assert.Empty(t, response.Header.Get(fiber.HeaderContentSecurityPolicy))
+	assert.Equal(t, "default-src 'self'", response.Header.Get(fiber.HeaderContentSecurityPolicyReportOnly))
+}
+
+func TestAllowCSPSources(t *testing.T) {
+	app := fiber.New()
+	app.Use(NewCSP(config.CSPConfig{Policy: "default-src 'self'; script-src 'self' 'nonce-{nonce}'; object-src 'none'"}))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		AllowCSPSources(ctx, []string{"script-src", "frame-src"}, "https://captcha.example.com")
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(t, err)
+	assert.Regexp(t, `^default-src 'self'; script-src 'self' 'nonce-[^']+' https://captcha.example.com; object-src 'none'; frame-src 'self' https://captcha.example.com$`,
+		response.Header.Get(fiber.HeaderContentSecurityPolicy))
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/web"
+)
+
+// StatusClientClosedRequest is the status, from nginx, of the requests whose
+// client went away before the response. It is only ever logged.
+const StatusClientClosedRequest = 499
+
+// NewDisconnect cancels ctx.UserContext() for the handlers after it when the
+// client closes the connection, so that the queries and outbound calls made
+// with it stop instead of running to completion for no one. The error they
+// then return is answered with 499.
+func NewDisconnect() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userContext, stop := web.WatchDisconnect(ctx, ctx.UserContext())
+		defer stop()
+		ctx.SetUserContext(userContext)
+
+		err := ctx.Next()
+		if err != nil && errors.Is(context.Cause(userContext), web.ErrClientGone) {
+			return fiber.NewError(StatusClientClosedRequest, web.ErrClientGone.Error())
+		}
+		return err
+	}
+}
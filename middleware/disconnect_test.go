@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/web"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDisconnect(t *testing.T) {
+	causes := make(chan error, 1)
+	disconnectApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	disconnectApp.Use(NewDisconnect())
+	disconnectApp.Get("/slow", func(ctx *fiber.Ctx) error {
+		select {
+		case <-ctx.UserContext().Done():
+			causes <- context.Cause(ctx.UserContext())
+			return ctx.UserContext().Err()
+		case <-time.After(time.Second * 5):
+			causes <- nil
+			return ctx.SendString("done")
+		}
+	})
+	disconnectApp.Get("/fast", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go disconnectApp.Listener(listener)
+	defer disconnectApp.Shutdown()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Nil(t, err)
+	_, err = conn.Write([]byte("GET /fast HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.Nil(t, err)
+	response := make([]byte, 512)
+	read, err := conn.Read(response)
+	assert.Nil(t, err)
+	assert.Contains(t, string(response[:read]), "200 OK")
+
+	_, err = conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.Nil(t, err)
+	time.Sleep(time.Millisecond * 100)
+	assert.Nil(t, conn.Close())
+
+	select {
+	case cause := <-causes:
+		assert.ErrorIs(t, cause, web.ErrClientGone)
+	case <-time.After(time.Second * 3):
+		t.Fatal("the handler context was not cancelled")
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"path"
+	"strings"
+)
+
+// NewEarlyHints announces the assets config lists for template, which the
+// route renders, before the route builds the page: as Link preload headers
+// and, when enabled, as a 103 Early Hints response. Asset names are resolved
+// to their fingerprinted URLs from the "assets" locals of NewViewHelpers;
+// other names are used as they are.
+//
+// The 103 response is only sent to HTTP/1.1 clients and is written straight
+// to the connection, which is not safe with pipelined requests; browsers do
+// not pipeline them.
+func NewEarlyHints(config config.EarlyHintsConfig, template string) fiber.Handler {
+	names := config.Views[template]
+	if len(names) == 0 {
+		return func(ctx *fiber.Ctx) error {
+			return ctx.Next()
+		}
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		urls, _ := ctx.Locals("assets").(map[string]string)
+		links := make([]string, len(names))
+		for i, name := range names {
+			url, ok := urls[strings.TrimPrefix(name, "/")]
+			if !ok {
+				url = name
+			}
+			links[i] = preloadLink(url)
+		}
+		link := strings.Join(links, ", ")
+
+		if config.Enabled && ctx.Request().Header.IsHTTP11() {
+			if _, err := ctx.Context().Conn().Write([]byte("HTTP/1.1 103 Early Hints\r\nLink: " + link + "\r\n\r\n")); err != nil {
+				return err
+			}
+		}
+		ctx.Append(fiber.HeaderLink, link)
+		return ctx.Next()
+	}
+}
+
+// preloadLink is the Link header value preloading url, with the as attribute
+// browsers require, guessed from the extension.
+func preloadLink(url string) string {
+	link := "<" + url + ">; rel=preload"
+	switch path.Ext(strings.SplitN(url, "?", 2)[0]) {
+	case ".css":
+		link += "; as=style"
+	case ".js", ".mjs":
+		link += "; as=script"
+	case ".woff", ".woff2", ".ttf", ".otf":
+		link += "; as=font; crossorigin"
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico":
+		link += "; as=image"
+	}
+	return link
+}
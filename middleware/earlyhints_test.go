@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+)
+
+func newEarlyHintsApp(enabled bool) *fiber.App {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("assets", map[string]string{"css/app.css": "/assets/css/app.0123456789.css"})
+		return ctx.Next()
+	})
+	hints := config.EarlyHintsConfig{Enabled: enabled, Views: map[string][]string{
+		"index": {"css/app.css", "/fonts/inter.woff2", "https://cdn.example.com/lib.js"},
+	}}
+	app.Get("/", NewEarlyHints(hints, "index"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("<html></html>")
+	})
+	app.Get("/plain", NewEarlyHints(hints, "plain"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("<html></html>")
+	})
+	return app
+}
+
+const earlyHintsLink = `</assets/css/app.0123456789.css>; rel=preload; as=style, ` +
+	`</fonts/inter.woff2>; rel=preload; as=font; crossorigin, ` +
+	`<https://cdn.example.com/lib.js>; rel=preload; as=script`
+
+func TestEarlyHintsLink(t *testing.T) {
+	app := newEarlyHintsApp(false)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, earlyHintsLink, response.Header.Get(fiber.HeaderLink))
+
+	response, err = app.Test(httptest.NewRequest(http.MethodGet, "/plain", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Header.Get(fiber.HeaderLink))
+}
+
+func TestEarlyHints103(t *testing.T) {
+	app := newEarlyHintsApp(true)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	var hints []http.Header
+	trace := &httptrace.ClientTrace{Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+		if code == http.StatusEarlyHints {
+			hints = append(hints, http.Header(header))
+		}
+		return nil
+	}}
+	request, err := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String()+"/", nil)
+	assert.Nil(t, err)
+	response, err := http.DefaultClient.Do(request.WithContext(httptrace.WithClientTrace(request.Context(), trace)))
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "<html></html>", string(body))
+	assert.Equal(t, earlyHintsLink, response.Header.Get(fiber.HeaderLink))
+	if assert.Len(t, hints, 1) {
+		assert.Equal(t, earlyHintsLink, hints[0].Get(fiber.HeaderLink))
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/encryptcookie"
+	"golang-fiber-web/config"
+	"strconv"
+)
+
+// NewEncryptCookie encrypts the cookies the app sets with the first of the
+// configured keys and decrypts the cookies of requests with any of them, so
+// clients can neither read nor forge them: AES-GCM authenticates what it
+// encrypts, and a cookie that does not decrypt reaches the handlers empty.
+// Keys are rotated by putting the new one first and keeping the old ones
+// until their cookies expire. The cookies named in Except are left as they
+// are.
+func NewEncryptCookie(config config.CookieConfig) (fiber.Handler, error) {
+	if len(config.Keys) == 0 {
+		return nil, errors.New("cookie encryption needs a key")
+	}
+	for i, key := range config.Keys {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, errors.New("cookie key " + strconv.Itoa(i) + " is not base64: " + err.Error())
+		}
+		if _, err = aes.NewCipher(decoded); err != nil {
+			return nil, errors.New("cookie key " + strconv.Itoa(i) + " is not a 16, 24 or 32 bytes AES key")
+		}
+	}
+
+	return encryptcookie.New(encryptcookie.Config{
+		Key:       config.Keys[0],
+		Except:    config.Except,
+		Encryptor: encryptcookie.EncryptCookie,
+		Decryptor: func(value, _ string) (string, error) {
+			var err error
+			for _, key := range config.Keys {
+				var decrypted string
+				decrypted, err = encryptcookie.DecryptCookie(value, key)
+				if err == nil {
+					return decrypted, nil
+				}
+			}
+			return "", err
+		},
+	}), nil
+}
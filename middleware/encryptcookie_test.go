@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/encryptcookie"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCookieApp(t *testing.T, cookieConfig config.CookieConfig) *fiber.App {
+	encryptCookie, err := NewEncryptCookie(cookieConfig)
+	assert.Nil(t, err)
+	app := fiber.New()
+	app.Use(encryptCookie)
+	app.Get("/set", func(ctx *fiber.Ctx) error {
+		ctx.Cookie(&fiber.Cookie{Name: "lastname", Value: "Anashari"})
+		ctx.Cookie(&fiber.Cookie{Name: "theme", Value: "dark"})
+		return nil
+	})
+	app.Get("/get", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(ctx.Cookies("lastname") + "|" + ctx.Cookies("theme"))
+	})
+	return app
+}
+
+func cookieRequest(t *testing.T, app *fiber.App, cookies ...*http.Cookie) string {
+	request := httptest.NewRequest(http.MethodGet, "/get", nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	return string(body)
+}
+
+func TestEncryptCookie(t *testing.T) {
+	key := encryptcookie.GenerateKey()
+	app := newCookieApp(t, config.CookieConfig{Keys: []string{key}, Except: []string{"theme"}})
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/set", nil))
+	assert.Nil(t, err)
+	cookies := response.Cookies()
+	assert.Len(t, cookies, 2)
+	for _, cookie := range cookies {
+		if cookie.Name == "lastname" {
+			assert.NotContains(t, cookie.Value, "Anashari")
+		} else {
+			assert.Equal(t, "dark", cookie.Value)
+		}
+	}
+
+	assert.Equal(t, "Anashari|dark", cookieRequest(t, app, cookies...))
+}
+
+func TestEncryptCookieRejectsForgedValues(t *testing.T) {
+	app := newCookieApp(t, config.CookieConfig{Keys: []string{encryptcookie.GenerateKey()}})
+
+	assert.Equal(t, "|", cookieRequest(t, app, &http.Cookie{Name: "lastname", Value: "Anashari"}))
+
+	otherKey, err := encryptcookie.EncryptCookie("Anashari", encryptcookie.GenerateKey())
+	assert.Nil(t, err)
+	assert.Equal(t, "|", cookieRequest(t, app, &http.Cookie{Name: "lastname", Value: otherKey}))
+}
+
+func TestEncryptCookieRotatesKeys(t *testing.T) {
+	oldKey, newKey := encryptcookie.GenerateKey(), encryptcookie.GenerateKey()
+	app := newCookieApp(t, config.CookieConfig{Keys: []string{newKey, oldKey}})
+
+	encrypted, err := encryptcookie.EncryptCookie("Anashari", oldKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "Anashari|", cookieRequest(t, app, &http.Cookie{Name: "lastname", Value: encrypted}))
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/set", nil))
+	assert.Nil(t, err)
+	for _, cookie := range response.Cookies() {
+		decrypted, err := encryptcookie.DecryptCookie(cookie.Value, newKey)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, decrypted)
+	}
+}
+
+func TestEncryptCookieRejectsInvalidKeys(t *testing.T) {
+	_, err := NewEncryptCookie(config.CookieConfig{})
+	assert.NotNil(t, err)
+	_, err = NewEncryptCookie(config.CookieConfig{Keys: []string{"not base64!"}})
+	assert.NotNil(t, err)
+	_, err = NewEncryptCookie(config.CookieConfig{Keys: []string{"c2hvcnQ="}})
+	assert.NotNil(t, err)
+}
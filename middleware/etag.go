@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/web"
+	"strings"
+)
+
+// NewETag computes an ETag for successful GET/HEAD JSON responses, unless the
+// handler already set one, and answers 304 Not Modified when it matches
+// If-None-Match. Weak tags are marked W/.
+func NewETag(weak bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err := ctx.Next()
+		if err != nil {
+			return err
+		}
+
+		if ctx.Method() != fiber.MethodGet && ctx.Method() != fiber.MethodHead {
+			return nil
+		}
+		if ctx.Response().StatusCode() != fiber.StatusOK || ctx.Response().IsBodyStream() {
+			return nil
+		}
+
+		etag := string(ctx.Response().Header.Peek(fiber.HeaderETag))
+		if etag == "" {
+			if !strings.Contains(string(ctx.Response().Header.ContentType()), "json") {
+				return nil
+			}
+			etag = computeETag(ctx.Response().Body(), weak)
+			ctx.Set(fiber.HeaderETag, etag)
+		}
+		if etagMatches(ctx.Get(fiber.HeaderIfNoneMatch), etag, true) {
+			ctx.Context().ResetBody()
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
+		return nil
+	}
+}
+
+// ETagOf returns the strong ETag of a resource's JSON representation, so
+// handlers can compare it with If-Match before applying an update.
+func ETagOf(resource interface{}) (string, error) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return "", err
+	}
+	return computeETag(body, false), nil
+}
+
+// representationSuffixes tell apart the ETags of the representations of a
+// resource other than JSON, which share ETagOf.
+var representationSuffixes = map[string]string{
+	fiber.MIMEApplicationXML: "xml",
+	fiber.MIMETextXML:        "xml",
+	web.MIMEApplicationYAML:  "yaml",
+	web.MIMETextYAML:         "yaml",
+}
+
+// SetRepresentationETag sets etag, as returned by ETagOf, as the ETag of the
+// response once its body is written, suffixed with the format of the body
+// when it isn't JSON, so every representation has its own strong ETag.
+func SetRepresentationETag(ctx *fiber.Ctx, etag string) {
+	mediaType, _, _ := strings.Cut(string(ctx.Response().Header.ContentType()), ";")
+	if suffix, ok := representationSuffixes[strings.TrimSpace(mediaType)]; ok {
+		etag = strings.TrimSuffix(etag, `"`) + "-" + suffix + `"`
+	}
+	ctx.Set(fiber.HeaderETag, etag)
+}
+
+// CheckIfMatch fails with 412 Precondition Failed when the request carries an
+// If-Match header that does not match the current ETag of the resource, in
+// any of its representations.
+func CheckIfMatch(ctx *fiber.Ctx, current string) error {
+	ifMatch := ctx.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" || etagMatches(ifMatch, current, false) {
+		return nil
+	}
+	for _, suffix := range []string{"xml", "yaml"} {
+		if etagMatches(ifMatch, strings.TrimSuffix(current, `"`)+"-"+suffix+`"`, false) {
+			return nil
+		}
+	}
+	return fiber.NewError(fiber.StatusPreconditionFailed, "resource has been modified")
+}
+
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// etagMatches compares a header value holding a list of ETags (or "*") with
+// etag, using weak comparison for If-None-Match and strong for If-Match.
+func etagMatches(header, etag string, weakComparison bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weakComparison {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(candidate, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
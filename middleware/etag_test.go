@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newETagApp(weak bool) *fiber.App {
+	user := fiber.Map{"username": "Brian"}
+
+	etagApp := fiber.New()
+	etagApp.Use(NewETag(weak))
+	etagApp.Get("/user", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(user)
+	})
+	etagApp.Get("/user/explicit", func(ctx *fiber.Ctx) error {
+		etag, err := ETagOf(user)
+		if err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderETag, etag)
+		return ctx.JSON(user)
+	})
+	etagApp.Put("/user", func(ctx *fiber.Ctx) error {
+		etag, err := ETagOf(user)
+		if err != nil {
+			return err
+		}
+		err = CheckIfMatch(ctx, etag)
+		if err != nil {
+			return err
+		}
+		user = fiber.Map{"username": string(ctx.Body())}
+		return ctx.JSON(user)
+	})
+	return etagApp
+}
+
+func TestETagNotModified(t *testing.T) {
+	etagApp := newETagApp(false)
+
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	response, err := etagApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	etag := response.Header.Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+	request = httptest.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Set("If-None-Match", `"other", `+etag)
+	response, err = etagApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Empty(t, bytes)
+}
+
+func TestETagWeak(t *testing.T) {
+	etagApp := newETagApp(true)
+
+	request := httptest.NewRequest(http.MethodGet, "/user", nil)
+	response, err := etagApp.Test(request)
+	assert.Nil(t, err)
+	etag := response.Header.Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	request = httptest.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Set("If-None-Match", strings.TrimPrefix(etag, "W/"))
+	response, err = etagApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+}
+
+func TestETagIfMatch(t *testing.T) {
+	etagApp := newETagApp(true)
+
+	request := httptest.NewRequest(http.MethodGet, "/user/explicit", nil)
+	response, err := etagApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	etag := response.Header.Get("ETag")
+	assert.False(t, strings.HasPrefix(etag, "W/"))
+
+	update := func(ifMatch string) *http.Response {
+		request := httptest.NewRequest(http.MethodPut, "/user", strings.NewReader("Budi"))
+		request.Header.Set("If-Match", ifMatch)
+		response, err := etagApp.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response = update(etag)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = update(etag)
+	assert.Equal(t, 412, response.StatusCode)
+}
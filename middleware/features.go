@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+)
+
+// NewFeatures stores the feature flags of live in the request locals as
+// "features", so views can test them with {{#features.name}}.
+func NewFeatures(live *config.Live) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.Locals("features", live.Get().Features)
+		return ctx.Next()
+	}
+}
+
+// RequireFeature answers 404 while the feature flag name is off, as if the
+// routes it guards did not exist.
+func RequireFeature(live *config.Live, name string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !live.Get().FeatureEnabled(name) {
+			return fiber.ErrNotFound
+		}
+		return ctx.Next()
+	}
+}
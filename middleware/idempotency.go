@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/web"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyPendingMessage = "a request with this Idempotency-Key is still in progress"
+)
+
+type idempotentResponse struct {
+	Fingerprint string          `json:"fingerprint"`
+	Pending     bool            `json:"pending"`
+	Response    *cachedResponse `json:"response,omitempty"`
+}
+
+// NewIdempotency makes POST and PATCH requests carrying an Idempotency-Key
+// safe to retry. The first request runs and its response is stored for
+// config.TTL; retries with the same key get the stored response replayed,
+// marked Idempotent-Replayed. Keys are scoped to the tenant, the
+// authenticated user or API key, the Authorization header, the method and the
+// path. A retry while the first request still runs gets 409, and reusing a
+// key with a different body gets 422. Errors and 5xx responses are not
+// stored, so those requests can be retried for real.
+func NewIdempotency(store cache.Store, config config.IdempotencyConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() != fiber.MethodPost && ctx.Method() != fiber.MethodPatch {
+			return ctx.Next()
+		}
+		key := ctx.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return ctx.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusBadRequest, "Idempotency-Key is longer than 255 characters"))
+		}
+
+		storeKey := idempotencyKey(ctx, key)
+		bodyHash := sha256.Sum256(ctx.Request().Body())
+		fingerprint := hex.EncodeToString(bodyHash[:])
+
+		pending, err := json.Marshal(idempotentResponse{Fingerprint: fingerprint, Pending: true})
+		if err != nil {
+			return err
+		}
+		added, err := store.SetNX(storeKey, pending, config.LockTTL)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return replayIdempotent(ctx, store, storeKey, fingerprint)
+		}
+
+		err = ctx.Next()
+		response := ctx.Response()
+		if err != nil || response.StatusCode() >= fiber.StatusInternalServerError {
+			if deleteErr := store.Delete(storeKey); deleteErr != nil && err == nil {
+				err = deleteErr
+			}
+			return err
+		}
+
+		stored := idempotentResponse{
+			Fingerprint: fingerprint,
+			Response: &cachedResponse{
+				Status:  response.StatusCode(),
+				Headers: map[string]string{},
+				Body:    append([]byte(nil), response.Body()...),
+			},
+		}
+		response.Header.VisitAll(func(name, value []byte) {
+			if !uncachedHeaders[string(name)] {
+				stored.Response.Headers[string(name)] = string(value)
+			}
+		})
+		value, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		return store.Set(storeKey, value, config.TTL)
+	}
+}
+
+func replayIdempotent(ctx *fiber.Ctx, store cache.Store, storeKey, fingerprint string) error {
+	value, ok, err := store.Get(storeKey)
+	if err != nil {
+		return err
+	}
+	stored := idempotentResponse{}
+	if ok {
+		err = json.Unmarshal(value, &stored)
+		if err != nil {
+			return err
+		}
+	}
+	// The key can expire between SetNX and Get, which is reported as a
+	// conflict too: the client simply retries.
+	if !ok || stored.Pending {
+		return web.SendError(ctx, web.NewProblem(fiber.StatusConflict, idempotencyPendingMessage))
+	}
+	if stored.Fingerprint != fingerprint {
+		return web.SendError(ctx, web.NewProblem(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body"))
+	}
+
+	for name, header := range stored.Response.Headers {
+		ctx.Set(name, header)
+	}
+	ctx.Set(HeaderIdempotentReplayed, "true")
+	return ctx.Status(stored.Response.Status).Send(stored.Response.Body)
+}
+
+// idempotencyKey scopes key to the tenant, the principal authenticated by
+// the auth middlewares before this one, the Authorization header, and the
+// method and path of the request, so clients never replay each other's
+// responses.
+func idempotencyKey(ctx *fiber.Ctx, key string) string {
+	userID, _ := ctx.Locals("user_id").(string)
+	apiKeyID, _ := ctx.Locals("api_key_id").(string)
+	hash := sha256.New()
+	for _, part := range []string{tenant.From(ctx.UserContext()), userID, apiKeyID, ctx.Get(fiber.HeaderAuthorization), ctx.Method() + " " + ctx.Path(), key} {
+		hash.Write([]byte(part + "\n"))
+	}
+	return "idempotency:" + hex.EncodeToString(hash.Sum(nil))
+}
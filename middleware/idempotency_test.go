@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/tenant"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	payments := 0
+	idempotencyApp := fiber.New()
+	idempotencyApp.Use(NewIdempotency(cache.NewMemoryStore(), config.IdempotencyConfig{TTL: time.Minute, LockTTL: time.Minute}))
+	idempotencyApp.Post("/payments", func(ctx *fiber.Ctx) error {
+		payments++
+		return ctx.Status(fiber.StatusCreated).SendString("payment " + strconv.Itoa(payments))
+	})
+	idempotencyApp.Post("/failing", func(ctx *fiber.Ctx) error {
+		payments++
+		return fiber.ErrServiceUnavailable
+	})
+
+	pay := func(path, key, body string) (*http.Response, string) {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			request.Header.Set(HeaderIdempotencyKey, key)
+		}
+		response, err := idempotencyApp.Test(request)
+		assert.Nil(t, err)
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return response, string(bytes)
+	}
+
+	response, body := pay("/payments", "key-1", "amount=10")
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "payment 1", body)
+
+	response, body = pay("/payments", "key-1", "amount=10")
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "payment 1", body)
+	assert.Equal(t, "true", response.Header.Get(HeaderIdempotentReplayed))
+
+	response, _ = pay("/payments", "key-1", "amount=99")
+	assert.Equal(t, 422, response.StatusCode)
+
+	response, body = pay("/payments", "", "amount=10")
+	assert.Equal(t, "payment 2", body)
+
+	pay("/failing", "key-2", "")
+	pay("/failing", "key-2", "")
+	assert.Equal(t, 4, payments)
+}
+
+func TestIdempotencyScopedByClient(t *testing.T) {
+	idempotencyApp := fiber.New()
+	idempotencyApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("api_key_id", ctx.Get("X-API-Key"))
+		ctx.SetUserContext(tenant.With(ctx.UserContext(), ctx.Get("X-Tenant-ID")))
+		return ctx.Next()
+	})
+	idempotencyApp.Use(NewIdempotency(cache.NewMemoryStore(), config.IdempotencyConfig{TTL: time.Minute, LockTTL: time.Minute}))
+	idempotencyApp.Post("/payments", func(ctx *fiber.Ctx) error {
+		return ctx.Status(fiber.StatusCreated).SendString("payment of " + ctx.Get("X-Tenant-ID") + "/" + ctx.Get("X-API-Key"))
+	})
+
+	pay := func(tenantID, apiKey string) (string, string) {
+		request := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader("amount=10"))
+		request.Header.Set(HeaderIdempotencyKey, "key-1")
+		request.Header.Set("X-Tenant-ID", tenantID)
+		request.Header.Set("X-API-Key", apiKey)
+		response, err := idempotencyApp.Test(request)
+		assert.Nil(t, err)
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return response.Header.Get(HeaderIdempotentReplayed), string(bytes)
+	}
+
+	pay("acme", "key-a")
+	replayed, body := pay("acme", "key-b")
+	assert.Equal(t, "", replayed)
+	assert.Equal(t, "payment of acme/key-b", body)
+	replayed, body = pay("globex", "key-a")
+	assert.Equal(t, "", replayed)
+	assert.Equal(t, "payment of globex/key-a", body)
+	replayed, body = pay("acme", "key-a")
+	assert.Equal(t, "true", replayed)
+	assert.Equal(t, "payment of acme/key-a", body)
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := cache.NewMemoryStore()
+	idempotencyApp := fiber.New()
+	idempotencyApp.Use(NewIdempotency(store, config.IdempotencyConfig{TTL: time.Minute, LockTTL: time.Minute}))
+	idempotencyApp.Post("/payments", func(ctx *fiber.Ctx) error {
+		request := httptest.NewRequest(http.MethodPost, "/payments", nil)
+		request.Header.Set(HeaderIdempotencyKey, "key")
+		retry, err := ctx.App().Test(request)
+		assert.Nil(t, err)
+		return ctx.SendStatus(retry.StatusCode)
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	request.Header.Set(HeaderIdempotencyKey, "key")
+	response, err := idempotencyApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 409, response.StatusCode)
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+const HeaderMethodOverride = "X-HTTP-Method-Override"
+
+var overridableMethods = []string{fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete}
+
+// NewMethodOverride lets a POST stand in for PUT, PATCH or DELETE, named in
+// the X-HTTP-Method-Override header or, for HTML forms, the _method field.
+// Routing restarts with the new method, so the middleware must be registered
+// first or the middleware before it runs twice. Other methods are ignored.
+func NewMethodOverride() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() != fiber.MethodPost {
+			return ctx.Next()
+		}
+
+		method := ctx.Get(HeaderMethodOverride)
+		if method == "" && isForm(ctx) {
+			method = ctx.FormValue("_method")
+		}
+		method = strings.ToUpper(strings.TrimSpace(method))
+		for _, overridable := range overridableMethods {
+			if method == overridable {
+				ctx.Method(method)
+				return ctx.RestartRouting()
+			}
+		}
+		return ctx.Next()
+	}
+}
+
+func isForm(ctx *fiber.Ctx) bool {
+	contentType := string(ctx.Request().Header.ContentType())
+	return strings.HasPrefix(contentType, fiber.MIMEApplicationForm) || strings.HasPrefix(contentType, fiber.MIMEMultipartForm)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	overrideApp := fiber.New()
+	overrideApp.Use(NewMethodOverride())
+	for _, method := range []string{fiber.MethodPost, fiber.MethodPut, fiber.MethodDelete} {
+		overrideApp.Add(method, "/users/:id", func(ctx *fiber.Ctx) error {
+			return ctx.SendString(ctx.Method() + " " + ctx.Params("id") + " " + ctx.FormValue("name"))
+		})
+	}
+
+	body := func(response *http.Response) string {
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return string(bytes)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader("_method=put&name=Brian"))
+	request.Header.Set("Content-Type", fiber.MIMEApplicationForm)
+	response, err := overrideApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT 1 Brian", body(response))
+
+	request = httptest.NewRequest(http.MethodPost, "/users/2", nil)
+	request.Header.Set(HeaderMethodOverride, "DELETE")
+	response, err = overrideApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE 2 ", body(response))
+
+	request = httptest.NewRequest(http.MethodPost, "/users/3", nil)
+	request.Header.Set(HeaderMethodOverride, "CONNECT")
+	response, err = overrideApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "POST 3 ", body(response))
+
+	request = httptest.NewRequest(http.MethodGet, "/users/4", nil)
+	request.Header.Set(HeaderMethodOverride, "DELETE")
+	response, err = overrideApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 405, response.StatusCode)
+}
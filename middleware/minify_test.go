@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func newMinifyApp() *fiber.App {
+	views := fstest.MapFS{
+		"page.mustache": {Data: []byte("<html>\n  <body>\n    <p>\n      {{name}}\n    </p>\n  </body>\n</html>\n")},
+	}
+	app := fiber.New(fiber.Config{Views: mustache.NewFileSystem(http.FS(views), ".mustache")})
+	app.Use(NewETag(true))
+	app.Use(NewMinify())
+	app.Get("/page", func(ctx *fiber.Ctx) error {
+		return ctx.Render("page", fiber.Map{"name": "Brian"})
+	})
+	app.Get("/app.css", func(ctx *fiber.Ctx) error {
+		ctx.Set(fiber.HeaderETag, `"original"`)
+		ctx.Type("css")
+		return ctx.SendString("body {\n  margin: 0;\n}\n")
+	})
+	app.Get("/data", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(fiber.Map{"text": "a  b"})
+	})
+	return app
+}
+
+func TestMinifyView(t *testing.T) {
+	response, err := newMinifyApp().Test(httptest.NewRequest(http.MethodGet, "/page", nil))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "<html><body><p> Brian </p></body></html>", string(body))
+}
+
+func TestMinifyRecomputesETag(t *testing.T) {
+	app := newMinifyApp()
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/app.css", nil))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "body{margin:0}", string(body))
+	etag := response.Header.Get(fiber.HeaderETag)
+	assert.Equal(t, computeETag([]byte("body{margin:0}"), false), etag)
+
+	request := httptest.NewRequest(http.MethodGet, "/app.css", nil)
+	request.Header.Set(fiber.HeaderIfNoneMatch, etag)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusNotModified, response.StatusCode)
+}
+
+func TestMinifyLeavesJSON(t *testing.T) {
+	response, err := newMinifyApp().Test(httptest.NewRequest(http.MethodGet, "/data", nil))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, `{"text":"a  b"}`, string(body))
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewQuota counts the requests of the user and the API key authenticated by
+// NewAPIKeyAuth against the Quotas of live, read on every request. The
+// X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset headers describe the
+// quota with the fewest requests left; once it is used up the request is
+// answered 429 with Retry-After. Anonymous requests and the Exclude paths
+// are not counted.
+func NewQuota(live *config.Live, quotas service.QuotaService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userID, _ := ctx.Locals("user_id").(string)
+		if userID == "" {
+			return ctx.Next()
+		}
+		quotaConfig := live.Get().Quotas
+		for _, prefix := range quotaConfig.Exclude {
+			if strings.HasPrefix(ctx.Path(), prefix) {
+				return ctx.Next()
+			}
+		}
+		apiKeyID, _ := ctx.Locals("api_key_id").(string)
+
+		counters, err := quotas.Consume(ctx.UserContext(), QuotaLimits(quotaConfig, userID, apiKeyID))
+		exceeded := errors.Is(err, model.ErrQuotaExceeded)
+		if err != nil && !exceeded {
+			return err
+		}
+		if len(counters) == 0 {
+			return ctx.Next()
+		}
+
+		tightest := counters[0]
+		for _, counter := range counters[1:] {
+			if counter.Remaining() < tightest.Remaining() ||
+				counter.Remaining() == tightest.Remaining() && counter.ResetAt.After(tightest.ResetAt) {
+				tightest = counter
+			}
+		}
+		seconds := strconv.Itoa(int(math.Ceil(time.Until(tightest.ResetAt).Seconds())))
+		ctx.Set("X-Quota-Limit", strconv.Itoa(tightest.Limit))
+		ctx.Set("X-Quota-Remaining", strconv.Itoa(tightest.Remaining()))
+		ctx.Set("X-Quota-Reset", seconds)
+		if exceeded {
+			ctx.Set(fiber.HeaderRetryAfter, seconds)
+			return web.SendError(ctx, web.NewProblem(fiber.StatusTooManyRequests, tightest.Period+" quota of "+strconv.Itoa(tightest.Limit)+" requests exceeded"))
+		}
+		return ctx.Next()
+	}
+}
+
+// QuotaLimits are the limits of quotaConfig applying to a user and, unless
+// apiKeyID is empty, to one of their API keys.
+func QuotaLimits(quotaConfig config.QuotaConfig, userID, apiKeyID string) []model.QuotaLimit {
+	limits := []model.QuotaLimit{
+		{Subject: "user:" + userID, Period: model.QuotaDaily, Limit: quotaConfig.User.Daily},
+		{Subject: "user:" + userID, Period: model.QuotaMonthly, Limit: quotaConfig.User.Monthly},
+	}
+	if apiKeyID != "" {
+		limits = append(limits,
+			model.QuotaLimit{Subject: "api_key:" + apiKeyID, Period: model.QuotaDaily, Limit: quotaConfig.APIKey.Daily},
+			model.QuotaLimit{Subject: "api_key:" + apiKeyID, Period: model.QuotaMonthly, Limit: quotaConfig.APIKey.Monthly})
+	}
+	return limits
+}
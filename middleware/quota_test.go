@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	repositories := repository.NewMemoryRepositories()
+	user := &model.User{Username: "brian"}
+	assert.Nil(t, repositories.Users.Create(context.Background(), user))
+	keys := service.NewAPIKeyService(repositories.APIKeys, repositories.Users, service.NewAuditService(repositories.Audit))
+	_, value, err := keys.Create(context.Background(), &model.CreateAPIKeyRequest{UserID: user.ID, Name: "ci"})
+	assert.Nil(t, err)
+
+	appConfig := config.Default()
+	appConfig.Quotas.User = config.QuotaLimits{Daily: 10, Monthly: 100}
+	appConfig.Quotas.APIKey = config.QuotaLimits{Daily: 2}
+	quotaApp := fiber.New()
+	quotaApp.Use(NewAPIKeyAuth(keys))
+	quotas := service.NewQuotaService(repositories.Quotas)
+	quotaApp.Use(NewQuota(config.NewLive(appConfig), quotas))
+	quotaApp.Get("/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	get := func(path, key string) *http.Response {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			request.Header.Set("X-API-Key", key)
+		}
+		response, err := quotaApp.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	assert.Equal(t, 401, get("/", "fw_unknown").StatusCode)
+	response := get("/", "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Header.Get("X-Quota-Limit"))
+
+	response = get("/", value)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "2", response.Header.Get("X-Quota-Limit"))
+	assert.Equal(t, "1", response.Header.Get("X-Quota-Remaining"))
+	assert.NotEmpty(t, response.Header.Get("X-Quota-Reset"))
+	assert.Equal(t, 200, get("/", value).StatusCode)
+	assert.Equal(t, 200, get("/quota", value).StatusCode)
+
+	response = get("/", value)
+	assert.Equal(t, 429, response.StatusCode)
+	assert.Equal(t, "0", response.Header.Get("X-Quota-Remaining"))
+	assert.NotEmpty(t, response.Header.Get(fiber.HeaderRetryAfter))
+
+	counters, err := quotas.Usage(context.Background(), QuotaLimits(appConfig.Quotas, user.ID, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, counters[0].Used)
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/web"
+	"math"
+	"strconv"
+)
+
+// NewRateLimit answers 429 with a problem document once a client IP has made
+// more than the allowed requests in its current window. The limit is the
+// RateLimit of live, or the one of the tenant of the request when it has its
+// own, and is read on every request so a config reload applies to the next
+// one. The windows are counted in store, which the processes of a prefork or
+// a cluster share when it is Redis. Requests go through when the store
+// fails.
+func NewRateLimit(live *config.Live, store cache.Store) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		tenantID := tenant.From(ctx.UserContext())
+		limit := live.Get().RateLimitFor(tenantID)
+		if limit.Max <= 0 || limit.Window <= 0 {
+			return ctx.Next()
+		}
+
+		key := "ratelimit:" + limit.Window.String() + ":" + tenantID + ":" + ctx.IP()
+		count, reset, err := store.Increment(key, limit.Window)
+		if err != nil {
+			logger.ErrorContext(ctx.UserContext(), "counting rate limit", "error", err)
+			return ctx.Next()
+		}
+
+		seconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+		ctx.Set("X-RateLimit-Limit", strconv.Itoa(limit.Max))
+		ctx.Set("X-RateLimit-Remaining", strconv.FormatInt(max(int64(limit.Max)-count, 0), 10))
+		ctx.Set("X-RateLimit-Reset", seconds)
+		if count > int64(limit.Max) {
+			ctx.Set(fiber.HeaderRetryAfter, seconds)
+			return web.SendError(ctx, web.NewProblem(fiber.StatusTooManyRequests, "rate limit of "+strconv.Itoa(limit.Max)+" requests per "+limit.Window.String()+" exceeded"))
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/tenant"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	appConfig := config.Default()
+	appConfig.RateLimit = config.RateLimitConfig{Max: 2, Window: time.Hour}
+	live := config.NewLive(appConfig)
+	limitApp := fiber.New()
+	limitApp.Use(NewRateLimit(live, cache.NewMemoryStore()))
+	limitApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	get := func() *http.Response {
+		response, err := limitApp.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Nil(t, err)
+		return response
+	}
+
+	response := get()
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "2", response.Header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", response.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 200, get().StatusCode)
+	response = get()
+	assert.Equal(t, 429, response.StatusCode)
+	assert.NotEmpty(t, response.Header.Get(fiber.HeaderRetryAfter))
+
+	reloaded := config.Default()
+	reloaded.RateLimit = config.RateLimitConfig{Max: 5, Window: time.Hour}
+	live.Update(reloaded)
+	assert.Equal(t, 200, get().StatusCode)
+
+	reloaded = config.Default()
+	live.Update(reloaded)
+	response = get()
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Header.Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimitPerTenant(t *testing.T) {
+	appConfig := config.Default()
+	appConfig.RateLimit = config.RateLimitConfig{Max: 1, Window: time.Hour}
+	appConfig.Tenancy.Tenants["acme"] = config.TenantConfig{RateLimit: &config.RateLimitConfig{Max: 3, Window: time.Hour}}
+	limitApp := fiber.New()
+	limitApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(tenant.With(ctx.UserContext(), ctx.Get("X-Tenant-ID")))
+		return ctx.Next()
+	})
+	limitApp.Use(NewRateLimit(config.NewLive(appConfig), cache.NewMemoryStore()))
+	limitApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	get := func(tenantID string) int {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("X-Tenant-ID", tenantID)
+		response, err := limitApp.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 200, get("acme"))
+	}
+	assert.Equal(t, 429, get("acme"))
+	assert.Equal(t, 200, get("globex"))
+	assert.Equal(t, 429, get("globex"))
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net"
+	"strings"
+)
+
+const HeaderXRealIP = "X-Real-IP"
+
+// NewRealIP replaces the remote address of requests that come through a
+// trusted proxy with the client address the proxies report, so ctx.IP() is
+// the real client everywhere: rate limiting, access logs and audit records.
+// X-Forwarded-For is read right to left and the first address that is not a
+// trusted proxy wins, since anything left of it can be forged by the client.
+// X-Real-IP is used when there is no X-Forwarded-For. trustedProxies holds
+// IPs or CIDRs, or "unix" to trust the peers of the Unix socket listeners,
+// which have no IP.
+func NewRealIP(trustedProxies []string) (fiber.Handler, error) {
+	var trusted []*net.IPNet
+	trustUnix := false
+	for _, proxy := range trustedProxies {
+		if proxy == "unix" {
+			trustUnix = true
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, network)
+	}
+
+	isTrusted := func(ip net.IP) bool {
+		for _, network := range trusted {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		// The connection address, not RemoteAddr(): fasthttp keeps an address
+		// set by an earlier request on the same keep-alive connection.
+		remoteAddr := ctx.Context().RemoteAddr()
+		if conn := ctx.Context().Conn(); conn != nil {
+			remoteAddr = conn.RemoteAddr()
+		}
+		var client net.IP
+		if _, ok := remoteAddr.(*net.UnixAddr); ok {
+			if !trustUnix {
+				return ctx.Next()
+			}
+		} else {
+			client = addrIP(remoteAddr)
+			if client == nil || !isTrusted(client) {
+				return ctx.Next()
+			}
+		}
+
+		forwardedFor := ctx.Request().Header.PeekAll(fiber.HeaderXForwardedFor)
+		if len(forwardedFor) > 0 {
+			var hops []string
+			for _, header := range forwardedFor {
+				hops = append(hops, strings.Split(string(header), ",")...)
+			}
+			for i := len(hops) - 1; i >= 0; i-- {
+				ip := net.ParseIP(strings.TrimSpace(hops[i]))
+				if ip == nil {
+					break
+				}
+				client = ip
+				if !isTrusted(ip) {
+					break
+				}
+			}
+		} else if ip := net.ParseIP(strings.TrimSpace(ctx.Get(HeaderXRealIP))); ip != nil {
+			client = ip
+		}
+		if client == nil {
+			return ctx.Next()
+		}
+
+		ctx.Context().SetRemoteAddr(&net.TCPAddr{IP: client})
+		return ctx.Next()
+	}, nil
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch value := addr.(type) {
+	case *net.TCPAddr:
+		return value.IP
+	case *net.UDPAddr:
+		return value.IP
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func realIPOf(t *testing.T, trustedProxies []string, headers map[string]string) string {
+	realIP, err := NewRealIP(trustedProxies)
+	assert.Nil(t, err)
+	realIPApp := fiber.New()
+	realIPApp.Use(realIP)
+	realIPApp.Get("/ip", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(ctx.IP())
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := realIPApp.Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return string(bytes)
+}
+
+func TestRealIP(t *testing.T) {
+	// app.Test connects from 0.0.0.0.
+	forwarded := map[string]string{"X-Forwarded-For": "6.6.6.6, 203.0.113.7, 10.0.0.2"}
+
+	assert.Equal(t, "0.0.0.0", realIPOf(t, nil, forwarded))
+	assert.Equal(t, "10.0.0.2", realIPOf(t, []string{"0.0.0.0"}, forwarded))
+	assert.Equal(t, "203.0.113.7", realIPOf(t, []string{"0.0.0.0", "10.0.0.0/8"}, forwarded))
+	assert.Equal(t, "198.51.100.1", realIPOf(t, []string{"0.0.0.0"}, map[string]string{"X-Real-IP": "198.51.100.1"}))
+	assert.Equal(t, "0.0.0.0", realIPOf(t, []string{"0.0.0.0"}, map[string]string{"X-Forwarded-For": "garbage"}))
+
+	_, err := NewRealIP([]string{"not-an-ip"})
+	assert.NotNil(t, err)
+}
+
+func TestRealIPOverUnixSocket(t *testing.T) {
+	realIPOverUnixSocket := func(trustedProxies []string, forwardedFor string) string {
+		realIP, err := NewRealIP(trustedProxies)
+		require.NoError(t, err)
+		realIPApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+		realIPApp.Use(realIP)
+		realIPApp.Get("/ip", func(ctx *fiber.Ctx) error {
+			return ctx.SendString(ctx.IP())
+		})
+		socket := filepath.Join(t.TempDir(), "app.sock")
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		go realIPApp.Listener(listener)
+		defer realIPApp.Shutdown()
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}}
+		request, err := http.NewRequest(http.MethodGet, "http://app/ip", nil)
+		require.NoError(t, err)
+		if forwardedFor != "" {
+			request.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		response, err := client.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		bytes, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return string(bytes)
+	}
+
+	assert.Equal(t, "203.0.113.7", realIPOverUnixSocket([]string{"unix"}, "6.6.6.6, 203.0.113.7"))
+	assert.Equal(t, "203.0.113.7", realIPOverUnixSocket([]string{"unix", "10.0.0.0/8"}, "203.0.113.7, 10.0.0.2"))
+	assert.NotEqual(t, "203.0.113.7", realIPOverUnixSocket(nil, "203.0.113.7"))
+	assert.NotEqual(t, "203.0.113.7", realIPOverUnixSocket([]string{"unix"}, ""))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/telemetry"
+	"runtime/debug"
+)
+
+var logger = telemetry.Logger("http")
+
+// NewRecover turns a panic in a later handler into an error for the
+// ErrorHandler, which answers 500. The stack trace is logged here, once, and
+// the panic is counted in http_panics_total.
+func NewRecover() fiber.Handler {
+	return func(ctx *fiber.Ctx) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			route := ctx.Route().Path
+			logger.Error("panic recovered", "method", ctx.Method(), "route", route, "panic", recovered, "stack", string(debug.Stack()))
+			telemetry.PanicsTotal.WithLabelValues(ctx.Method(), route).Inc()
+
+			if recoveredErr, ok := recovered.(error); ok {
+				err = recoveredErr
+			} else {
+				err = fmt.Errorf("%v", recovered)
+			}
+		}()
+
+		return ctx.Next()
+	}
+}
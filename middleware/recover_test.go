@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	recoverApp := fiber.New(fiber.Config{
+		ErrorHandler: web.NewErrorHandler(false),
+	})
+	recoverApp.Use(NewRecover())
+	recoverApp.Get("/panic/:id", func(ctx *fiber.Ctx) error {
+		panic("ups")
+	})
+	recoverApp.Get("/panic-error", func(ctx *fiber.Ctx) error {
+		panic(errors.New("database is down"))
+	})
+
+	before := testutil.ToFloat64(telemetry.PanicsTotal.WithLabelValues("GET", "/panic/:id"))
+
+	request := httptest.NewRequest(http.MethodGet, "/panic/1", nil)
+	response, err := recoverApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "Error: ups", string(bytes))
+	assert.Equal(t, before+1, testutil.ToFloat64(telemetry.PanicsTotal.WithLabelValues("GET", "/panic/:id")))
+
+	request = httptest.NewRequest(http.MethodGet, "/panic-error", nil)
+	response, err = recoverApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+	bytes, err = io.ReadAll(response.Body)
+	assert.Equal(t, "Error: database is down", string(bytes))
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/database"
+	"time"
+)
+
+// primaryCookie marks a client that wrote lately, whose reads go to the
+// primary database until the replicas have its writes.
+const primaryCookie = "db_primary"
+
+// NewReadReplicas lets the reads of a request go to the read replicas until
+// it writes. A request that writes sets a cookie so the reads of the client
+// go to the primary for StickyFor, which should cover the lag of the
+// replicas.
+func NewReadReplicas(config config.DatabaseConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userContext := database.WithReplicas(ctx.UserContext(), ctx.Cookies(primaryCookie) != "")
+		ctx.SetUserContext(userContext)
+		err := ctx.Next()
+		if config.StickyFor > 0 && database.Written(userContext) {
+			ctx.Cookie(&fiber.Cookie{
+				Name:     primaryCookie,
+				Value:    "1",
+				Path:     "/",
+				Expires:  time.Now().Add(config.StickyFor),
+				HTTPOnly: true,
+				SameSite: fiber.CookieSameSiteLaxMode,
+			})
+		}
+		return err
+	}
+}
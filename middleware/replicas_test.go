@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/database"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadReplicas(t *testing.T) {
+	app := fiber.New()
+	app.Use(NewReadReplicas(config.DatabaseConfig{StickyFor: time.Second * 5}))
+	app.Get("/users", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("read")
+	})
+	app.Post("/users", func(ctx *fiber.Ctx) error {
+		database.MarkWritten(ctx.UserContext())
+		return ctx.SendString("written")
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/users", nil))
+	assert.Nil(t, err)
+	assert.Empty(t, response.Cookies())
+
+	response, err = app.Test(httptest.NewRequest("POST", "/users", nil))
+	assert.Nil(t, err)
+	cookies := response.Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, primaryCookie, cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.WithinDuration(t, time.Now().Add(time.Second*5), cookies[0].Expires, time.Second*2)
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/web"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The priorities of LoadSheddingConfig.Priorities.
+const (
+	PriorityCritical = "critical"
+	PriorityNormal   = "normal"
+	PriorityLow      = "low"
+)
+
+const (
+	shedSampleInterval = time.Second
+	// latencyWeight is the weight of a request in the moving average of the
+	// latency of its route, and latencyStale how long a route that is not
+	// requested any more keeps counting against its budget.
+	latencyWeight      = 0.1
+	latencyStale       = time.Second * 10
+	schedLatencyMetric = "/sched/latencies:seconds"
+)
+
+// criticalRoutes are never shed, so orchestrators keep seeing the server.
+var criticalRoutes = []string{"/healthz", "/readyz", "/metrics"}
+
+// LoadShedder turns requests away while the server is saturated, low priority
+// routes first, so the others stay within their budgets.
+type LoadShedder struct {
+	config         config.LoadSheddingConfig
+	maxInFlight    int64
+	retryAfter     string
+	inFlight       atomic.Int64
+	schedSaturated atomic.Bool
+	overBudget     atomic.Bool
+	mutex          sync.Mutex
+	latencies      map[string]*routeLatency
+}
+
+// routeLatency is the moving average of the latency of the routes under a
+// budget prefix.
+type routeLatency struct {
+	average  time.Duration
+	budget   time.Duration
+	lastSeen time.Time
+}
+
+func NewLoadShedder(config config.LoadSheddingConfig) *LoadShedder {
+	maxInFlight := int64(config.MaxInFlight)
+	if maxInFlight <= 0 {
+		maxInFlight = int64(runtime.GOMAXPROCS(0)) * 256
+	}
+	return &LoadShedder{
+		config:      config,
+		maxInFlight: maxInFlight,
+		retryAfter:  strconv.Itoa(max(int(math.Ceil(config.RetryAfter.Seconds())), 1)),
+		latencies:   map[string]*routeLatency{},
+	}
+}
+
+func (shedder *LoadShedder) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		path := ctx.Path()
+		prefix, priority := shedder.priority(path)
+		inFlight := shedder.inFlight.Add(1)
+		defer shedder.inFlight.Add(-1)
+
+		if shedder.sheds(priority, inFlight) {
+			telemetry.ShedRequestsTotal.WithLabelValues(priority, prefix).Inc()
+			ctx.Set(fiber.HeaderRetryAfter, shedder.retryAfter)
+			return web.SendError(ctx, web.NewProblem(fiber.StatusServiceUnavailable, "server is busy, retry later"))
+		}
+		if priority == PriorityLow {
+			return ctx.Next()
+		}
+
+		start := time.Now()
+		err := ctx.Next()
+		shedder.observe(path, time.Since(start))
+		return err
+	}
+}
+
+// sheds reports whether to turn away a request of priority with inFlight
+// requests in progress, counting it.
+func (shedder *LoadShedder) sheds(priority string, inFlight int64) bool {
+	switch priority {
+	case PriorityCritical:
+		return false
+	case PriorityLow:
+		return inFlight > shedder.maxInFlight || shedder.schedSaturated.Load() || shedder.overBudget.Load()
+	default:
+		return inFlight > shedder.maxInFlight*2
+	}
+}
+
+// priority returns the prefix of path in the priorities and its priority.
+func (shedder *LoadShedder) priority(path string) (string, string) {
+	for _, route := range criticalRoutes {
+		if path == route {
+			return route, PriorityCritical
+		}
+	}
+	prefix := ""
+	priority := PriorityNormal
+	for routePrefix, routePriority := range shedder.config.Priorities {
+		if strings.HasPrefix(path, routePrefix) && len(routePrefix) > len(prefix) {
+			prefix = routePrefix
+			priority = routePriority
+		}
+	}
+	return prefix, priority
+}
+
+// observe adds latency to the moving average of the budget of path.
+func (shedder *LoadShedder) observe(path string, latency time.Duration) {
+	prefix := ""
+	budget := shedder.config.Budget
+	for routePrefix, routeBudget := range shedder.config.Budgets {
+		if strings.HasPrefix(path, routePrefix) && len(routePrefix) > len(prefix) {
+			prefix = routePrefix
+			budget = routeBudget
+		}
+	}
+	if budget <= 0 {
+		return
+	}
+
+	shedder.mutex.Lock()
+	defer shedder.mutex.Unlock()
+	route, ok := shedder.latencies[prefix]
+	if !ok {
+		shedder.latencies[prefix] = &routeLatency{average: latency, budget: budget, lastSeen: time.Now()}
+		return
+	}
+	route.average = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(route.average))
+	route.lastSeen = time.Now()
+}
+
+// Run samples how saturated the server is until ctx is done.
+func (shedder *LoadShedder) Run(ctx context.Context) {
+	samples := []metrics.Sample{{Name: schedLatencyMetric}}
+	var previous *metrics.Float64Histogram
+	ticker := time.NewTicker(shedSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		metrics.Read(samples)
+		if samples[0].Value.Kind() == metrics.KindFloat64Histogram {
+			current := samples[0].Value.Float64Histogram()
+			if previous != nil && shedder.config.MaxSchedLatency > 0 {
+				latency := histogramQuantile(previous, current, 0.99)
+				shedder.schedSaturated.Store(latency > shedder.config.MaxSchedLatency.Seconds())
+			}
+			// The next Read may reuse the counts.
+			previous = &metrics.Float64Histogram{Counts: slices.Clone(current.Counts), Buckets: current.Buckets}
+		}
+		shedder.overBudget.Store(shedder.checkBudgets(time.Now()))
+	}
+}
+
+// checkBudgets reports whether a route requested lately is over its budget,
+// and forgets the others.
+func (shedder *LoadShedder) checkBudgets(now time.Time) bool {
+	shedder.mutex.Lock()
+	defer shedder.mutex.Unlock()
+	overBudget := false
+	for prefix, route := range shedder.latencies {
+		if now.Sub(route.lastSeen) > latencyStale {
+			delete(shedder.latencies, prefix)
+			continue
+		}
+		if route.average > route.budget {
+			overBudget = true
+		}
+	}
+	return overBudget
+}
+
+// histogramQuantile is the q quantile of the values added to a cumulative
+// histogram between previous and current, as the upper bound of its bucket.
+func histogramQuantile(previous, current *metrics.Float64Histogram, q float64) float64 {
+	if len(previous.Counts) != len(current.Counts) {
+		return 0
+	}
+	var total uint64
+	for i := range current.Counts {
+		total += current.Counts[i] - previous.Counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i := range current.Counts {
+		seen += current.Counts[i] - previous.Counts[i]
+		if seen >= rank {
+			upper := current.Buckets[i+1]
+			if math.IsInf(upper, 1) {
+				return current.Buckets[i]
+			}
+			return upper
+		}
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"math"
+	"net/http/httptest"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+func newShedApp(shedder *LoadShedder, block chan struct{}) *fiber.App {
+	shedApp := fiber.New()
+	shedApp.Use(shedder.Middleware())
+	handler := func(ctx *fiber.Ctx) error {
+		if ctx.Query("block") != "" {
+			<-block
+		}
+		return ctx.SendString("OK")
+	}
+	shedApp.Get("/healthz", handler)
+	shedApp.Get("/users", handler)
+	shedApp.Get("/feeds", handler)
+	return shedApp
+}
+
+func TestLoadShedderOverBudget(t *testing.T) {
+	shedder := NewLoadShedder(config.LoadSheddingConfig{
+		Priorities: map[string]string{"/feeds": PriorityLow},
+		RetryAfter: time.Second * 5,
+	})
+	shedApp := newShedApp(shedder, nil)
+
+	response, err := shedApp.Test(httptest.NewRequest("GET", "/feeds", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	shedder.overBudget.Store(true)
+	response, err = shedApp.Test(httptest.NewRequest("GET", "/feeds", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+	assert.Equal(t, "5", response.Header.Get(fiber.HeaderRetryAfter))
+
+	for _, path := range []string{"/users", "/healthz"} {
+		response, err = shedApp.Test(httptest.NewRequest("GET", path, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode, path)
+	}
+}
+
+func TestLoadShedderInFlight(t *testing.T) {
+	shedder := NewLoadShedder(config.LoadSheddingConfig{
+		MaxInFlight: 1,
+		Priorities:  map[string]string{"/feeds": PriorityLow},
+	})
+	block := make(chan struct{})
+	shedApp := newShedApp(shedder, block)
+
+	done := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			response, err := shedApp.Test(httptest.NewRequest("GET", "/users?block=1", nil), -1)
+			if err != nil {
+				done <- 0
+				return
+			}
+			done <- response.StatusCode
+		}()
+	}
+	assert.Eventually(t, func() bool { return shedder.inFlight.Load() == 2 }, time.Second, time.Millisecond*10)
+
+	// Low priority routes are shed past the maximum, the others past twice
+	// the maximum, and critical ones never.
+	response, err := shedApp.Test(httptest.NewRequest("GET", "/feeds", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+	assert.Equal(t, "1", response.Header.Get(fiber.HeaderRetryAfter))
+
+	response, err = shedApp.Test(httptest.NewRequest("GET", "/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+
+	response, err = shedApp.Test(httptest.NewRequest("GET", "/healthz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	close(block)
+	assert.Equal(t, 200, <-done)
+	assert.Equal(t, 200, <-done)
+
+	response, err = shedApp.Test(httptest.NewRequest("GET", "/feeds", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestLoadShedderBudgets(t *testing.T) {
+	shedder := NewLoadShedder(config.LoadSheddingConfig{
+		Budgets: map[string]time.Duration{"/users": time.Millisecond * 100},
+	})
+
+	shedder.observe("/products", time.Second)
+	shedder.observe("/users/1", time.Millisecond*50)
+	assert.False(t, shedder.checkBudgets(time.Now()))
+
+	for i := 0; i < 20; i++ {
+		shedder.observe("/users/1", time.Millisecond*500)
+	}
+	assert.True(t, shedder.checkBudgets(time.Now()))
+
+	// A route not requested any more stops counting.
+	assert.False(t, shedder.checkBudgets(time.Now().Add(latencyStale*2)))
+	assert.Empty(t, shedder.latencies)
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := []float64{0, 0.001, 0.01, 0.1, math.Inf(1)}
+	previous := &metrics.Float64Histogram{Counts: []uint64{10, 10, 0, 0}, Buckets: buckets}
+
+	current := &metrics.Float64Histogram{Counts: []uint64{10, 10, 0, 0}, Buckets: buckets}
+	assert.Equal(t, 0.0, histogramQuantile(previous, current, 0.99))
+
+	current = &metrics.Float64Histogram{Counts: []uint64{110, 10, 1, 0}, Buckets: buckets}
+	assert.Equal(t, 0.001, histogramQuantile(previous, current, 0.99))
+
+	current = &metrics.Float64Histogram{Counts: []uint64{100, 10, 10, 5}, Buckets: buckets}
+	assert.Equal(t, 0.1, histogramQuantile(previous, current, 0.99))
+}
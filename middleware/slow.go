@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/web"
+	"time"
+)
+
+// NewSlowRequest flags the requests that take longer than the threshold of
+// their path in config: they are logged as a warning with their route,
+// parameters and status, and counted in http_slow_requests_total by route.
+func NewSlowRequest(config config.SlowRequestConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		threshold := routeValue(config.Routes, config.Default, ctx.Path())
+		if threshold <= 0 {
+			return ctx.Next()
+		}
+
+		start := time.Now()
+		err := ctx.Next()
+		latency := time.Since(start)
+		if latency <= threshold {
+			return err
+		}
+
+		route := ctx.Route().Path
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = web.ProblemFromError(ctx, err).Status
+		}
+		attributes := []any{
+			"method", ctx.Method(),
+			"route", route,
+			"path", ctx.Path(),
+			"params", ctx.AllParams(),
+			"query", string(ctx.Request().URI().QueryString()),
+			"status", status,
+			"latency", latency,
+			"threshold", threshold,
+		}
+		if requestID, ok := ctx.Locals("requestid").(string); ok {
+			attributes = append(attributes, "request_id", requestID)
+		}
+		logger.Warn("slow request", attributes...)
+		telemetry.SlowRequestsTotal.WithLabelValues(ctx.Method(), route).Inc()
+		return err
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/telemetry"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlowRequest(t *testing.T) {
+	slowApp := fiber.New()
+	slowApp.Use(NewSlowRequest(config.SlowRequestConfig{Default: time.Millisecond * 20, Routes: map[string]time.Duration{"/reports/fast": 0}}))
+	slowApp.Get("/reports/:name", func(ctx *fiber.Ctx) error {
+		if ctx.Query("sleep") != "" {
+			time.Sleep(time.Millisecond * 30)
+		}
+		return ctx.SendString("ok")
+	})
+
+	counter := telemetry.SlowRequestsTotal.WithLabelValues("GET", "/reports/:name")
+	before := testutil.ToFloat64(counter)
+	for _, target := range []string{"/reports/daily", "/reports/fast?sleep=1", "/reports/monthly?sleep=1"} {
+		response, err := slowApp.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+	}
+
+	// Only the monthly report is both slow and checked.
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+)
+
+// NewTimeout puts a deadline on ctx.UserContext() for the handlers after it,
+// from the longest matching route prefix in config or its default. Database
+// queries and outbound calls made with that context give up at the deadline,
+// and the error they return is answered with 504 Gateway Timeout. A handler
+// that ignores the context is not interrupted.
+func NewTimeout(config config.TimeoutConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		timeout := routeValue(config.Routes, config.Default, ctx.Path())
+		if timeout <= 0 {
+			return ctx.Next()
+		}
+
+		userContext, cancel := context.WithTimeout(ctx.UserContext(), timeout)
+		defer cancel()
+		ctx.SetUserContext(userContext)
+
+		err := ctx.Next()
+		if err != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(userContext.Err(), context.DeadlineExceeded)) {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusGatewayTimeout, "request did not complete within "+timeout.String()))
+		}
+		return err
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	timeoutApp := fiber.New()
+	timeoutApp.Use(NewTimeout(config.TimeoutConfig{
+		Default: time.Millisecond * 20,
+		Routes:  map[string]time.Duration{"/slow": time.Second, "/unbounded": 0},
+	}))
+	query := func(ctx *fiber.Ctx) error {
+		select {
+		case <-ctx.UserContext().Done():
+			return ctx.UserContext().Err()
+		case <-time.After(time.Millisecond * 100):
+			return ctx.SendString("done")
+		}
+	}
+	timeoutApp.Get("/fast", query)
+	timeoutApp.Get("/slow", query)
+	timeoutApp.Get("/unbounded", func(ctx *fiber.Ctx) error {
+		_, ok := ctx.UserContext().Deadline()
+		assert.False(t, ok)
+		return ctx.SendString("done")
+	})
+	timeoutApp.Get("/canceled", func(ctx *fiber.Ctx) error {
+		return context.Canceled
+	})
+
+	response, err := timeoutApp.Test(httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 504, response.StatusCode)
+	assert.Equal(t, web.MIMEApplicationProblemJSON, response.Header.Get("Content-Type"))
+
+	response, err = timeoutApp.Test(httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response, err = timeoutApp.Test(httptest.NewRequest(http.MethodGet, "/unbounded", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response, err = timeoutApp.Test(httptest.NewRequest(http.MethodGet, "/canceled", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+}
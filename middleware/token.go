@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"golang-fiber-web/service"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/token"
+	"golang-fiber-web/web"
+	"strings"
+)
+
+// NewTokenAuth authenticates the requests carrying an access token in an
+// Authorization: Bearer header as the user of its sub claim, whose ID it puts
+// in the user_id local and the ID of the session of its sid claim in
+// session_id. Requests without a bearer token go through as they are; those
+// with a token that is invalid, expired or of a revoked session are answered
+// 401, and those with a token whose tenant_id claim is not the tenant of the
+// request 403.
+func NewTokenAuth(keys *token.Keys, sessions service.SessionService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		scheme, value, _ := strings.Cut(ctx.Get(fiber.HeaderAuthorization), " ")
+		if !strings.EqualFold(scheme, "Bearer") || value == "" {
+			return ctx.Next()
+		}
+
+		claims, err := keys.Verify(ctx.UserContext(), value)
+		if errors.Is(err, token.ErrInvalid) || errors.Is(err, token.ErrExpired) {
+			return invalidToken(ctx, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		if tenantID, _ := claims["tenant_id"].(string); tenantID != tenant.From(ctx.UserContext()) {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusForbidden, "the token belongs to another tenant"))
+		}
+		userID, _ := claims["sub"].(string)
+		sessionID, _ := claims["sid"].(string)
+		session, err := sessions.Active(ctx.UserContext(), sessionID)
+		if errors.Is(err, model.ErrSessionNotFound) || (err == nil && session.UserID != userID) {
+			return invalidToken(ctx, "session expired or revoked")
+		}
+		if err != nil {
+			return err
+		}
+
+		ctx.Locals("user_id", userID)
+		ctx.Locals("session_id", sessionID)
+		return ctx.Next()
+	}
+}
+
+func invalidToken(ctx *fiber.Ctx, detail string) error {
+	ctx.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	return web.SendError(ctx, web.NewProblem(fiber.StatusUnauthorized, detail))
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"golang-fiber-web/tenant"
+	"golang-fiber-web/token"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenAuth(t *testing.T) {
+	jwtConfig := config.Default().JWT
+	keys := token.NewKeys(jwtConfig, repository.NewMemorySigningKeyRepository())
+	assert.Nil(t, keys.Rotate(context.Background()))
+	sessions := service.NewSessionService(repository.NewMemorySessionRepository(), service.NewAuditService(repository.NewMemoryAuditRepository()), jwtConfig.TTL)
+	session, err := sessions.Start(context.Background(), "user-1", "test", "127.0.0.1")
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	app.Use(NewTokenAuth(keys, sessions))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		userID, _ := ctx.Locals("user_id").(string)
+		sessionID, _ := ctx.Locals("session_id").(string)
+		return ctx.SendString(userID + " " + sessionID)
+	})
+	get := func(authorization string) (int, string) {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Authorization", authorization)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(body)
+	}
+
+	status, body := get("")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, " ", body)
+	status, _ = get("Basic YWRtaW46c2VjcmV0")
+	assert.Equal(t, 200, status, "other schemes are left to their own middleware")
+
+	valid, err := keys.Sign(token.Claims{"sub": "user-1", "sid": session.ID})
+	assert.Nil(t, err)
+	status, body = get("Bearer " + valid)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "user-1 "+session.ID, body)
+
+	stolen, err := keys.Sign(token.Claims{"sub": "user-2", "sid": session.ID})
+	assert.Nil(t, err)
+	status, _ = get("Bearer " + stolen)
+	assert.Equal(t, 401, status, "the session must be of the user of the token")
+
+	assert.Nil(t, sessions.Revoke(context.Background(), "user-1", session.ID))
+	status, _ = get("Bearer " + valid)
+	assert.Equal(t, 401, status)
+}
+
+func TestTokenAuthChecksTheTenant(t *testing.T) {
+	jwtConfig := config.Default().JWT
+	keys := token.NewKeys(jwtConfig, repository.NewMemorySigningKeyRepository())
+	assert.Nil(t, keys.Rotate(context.Background()))
+	sessions := service.NewSessionService(repository.NewMemorySessionRepository(), service.NewAuditService(repository.NewMemoryAuditRepository()), jwtConfig.TTL)
+	session, err := sessions.Start(tenant.With(context.Background(), "acme"), "user-1", "test", "127.0.0.1")
+	assert.Nil(t, err)
+	acme, err := keys.Sign(token.Claims{"sub": "user-1", "sid": session.ID, "tenant_id": "acme"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	// X-Tenant-ID stands for the tenant tenant.New resolves.
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.SetUserContext(tenant.With(ctx.UserContext(), ctx.Get("X-Tenant-ID")))
+		return ctx.Next()
+	})
+	app.Use(NewTokenAuth(keys, sessions))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+	get := func(tenantID string) int {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Authorization", "Bearer "+acme)
+		request.Header.Set("X-Tenant-ID", tenantID)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	assert.Equal(t, 204, get("acme"))
+	assert.Equal(t, 403, get("globex"))
+	assert.Equal(t, 403, get(""))
+}
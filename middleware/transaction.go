@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/repository"
+	"strings"
+)
+
+// errRollback rolls back the transaction of a request its handler answered
+// with an error status rather than an error.
+var errRollback = errors.New("rollback")
+
+// NewTransaction runs the requests with unsafe methods on the routes of
+// config in a database transaction, which the repositories called with the
+// user context of the request take part in, so a handler making several
+// changes makes all or none of them. It is committed when the handler
+// succeeds, and rolled back when it returns an error, answers with a 4xx or
+// 5xx status, or panics. The transaction holds a database connection for the
+// whole request.
+func NewTransaction(transactor repository.Transactor, config config.TransactionConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !transactional(ctx, config.Routes) {
+			return ctx.Next()
+		}
+
+		userContext := ctx.UserContext()
+		defer ctx.SetUserContext(userContext)
+		var handlerErr error
+		err := transactor.Transaction(userContext, func(txContext context.Context) error {
+			ctx.SetUserContext(txContext)
+			handlerErr = ctx.Next()
+			if handlerErr != nil {
+				return handlerErr
+			}
+			if ctx.Response().StatusCode() >= fiber.StatusBadRequest {
+				return errRollback
+			}
+			return nil
+		})
+		if handlerErr != nil || errors.Is(err, errRollback) {
+			return handlerErr
+		}
+		return err
+	}
+}
+
+// transactional reports whether the request changes something on a route
+// under one of prefixes.
+func transactional(ctx *fiber.Ctx, prefixes []string) bool {
+	switch ctx.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return false
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(ctx.Path(), prefix) {
+			return true
+		}
+	}
+	return false
+}
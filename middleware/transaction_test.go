@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"net/http/httptest"
+	"testing"
+)
+
+type testTxKey struct{}
+
+// testTransactor records how its transactions ended.
+type testTransactor struct {
+	outcomes []string
+}
+
+func (transactor *testTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	outcome := "rolled back"
+	defer func() {
+		transactor.outcomes = append(transactor.outcomes, outcome)
+	}()
+	err = fn(context.WithValue(ctx, testTxKey{}, true))
+	if err == nil {
+		outcome = "committed"
+	}
+	return err
+}
+
+func TestTransaction(t *testing.T) {
+	transactor := &testTransactor{}
+	app := fiber.New()
+	app.Use(NewRecover())
+	app.Use(NewTransaction(transactor, config.TransactionConfig{Routes: []string{"/orders"}}))
+	inTransaction := false
+	handler := func(ctx *fiber.Ctx) error {
+		inTransaction = ctx.UserContext().Value(testTxKey{}) != nil
+		switch ctx.Query("outcome") {
+		case "error":
+			return fiber.ErrConflict
+		case "status":
+			return ctx.SendStatus(fiber.StatusUnprocessableEntity)
+		case "panic":
+			panic("boom")
+		}
+		return ctx.SendStatus(fiber.StatusCreated)
+	}
+	app.Post("/orders", handler)
+	app.Get("/orders", handler)
+	app.Post("/products", handler)
+
+	tests := []struct {
+		method, target string
+		status         int
+		transaction    bool
+		outcome        string
+	}{
+		{"POST", "/orders", 201, true, "committed"},
+		{"POST", "/orders?outcome=error", 409, true, "rolled back"},
+		{"POST", "/orders?outcome=status", 422, true, "rolled back"},
+		{"POST", "/orders?outcome=panic", 500, true, "rolled back"},
+		{"GET", "/orders", 201, false, ""},
+		{"POST", "/products", 201, false, ""},
+	}
+	for _, test := range tests {
+		transactor.outcomes = nil
+		response, err := app.Test(httptest.NewRequest(test.method, test.target, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, test.status, response.StatusCode, test.target)
+		assert.Equal(t, test.transaction, inTransaction, test.target)
+		if test.outcome == "" {
+			assert.Empty(t, transactor.outcomes, test.target)
+		} else {
+			assert.Equal(t, []string{test.outcome}, transactor.outcomes, test.target)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/static"
+	"golang-fiber-web/web"
+)
+
+// NewViewHelpers stores the template helpers in the request locals, which
+// fiber passes to the views when PassLocalsToViews is enabled. urlFor is
+// web.URLForLambda; asset and assets resolve the fingerprinted URLs of
+// assets, as static.Assets.Lambda and static.Assets.URLs.
+func NewViewHelpers(assets *static.Assets) fiber.Handler {
+	lambda, urls := assets.Lambda(), assets.URLs()
+	return func(ctx *fiber.Ctx) error {
+		ctx.Locals("urlFor", web.URLForLambda(ctx))
+		ctx.Locals("asset", lambda)
+		ctx.Locals("assets", urls)
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/static"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestViewHelpersURLFor(t *testing.T) {
+	views := fstest.MapFS{
+		"user.mustache": {Data: []byte(`<a href="{{#urlFor}}users.show id={{id}}{{/urlFor}}">{{name}}</a>`)},
+	}
+	assets, err := static.New("/assets", fstest.MapFS{}, time.Hour)
+	assert.Nil(t, err)
+	viewApp := fiber.New(fiber.Config{
+		Views:             mustache.NewFileSystem(http.FS(views), ".mustache"),
+		PassLocalsToViews: true,
+	})
+	viewApp.Use(NewViewHelpers(assets))
+	viewApp.Get("/people/:id", func(ctx *fiber.Ctx) error {
+		return ctx.Render("user", fiber.Map{"id": ctx.Params("id"), "name": "Brian"})
+	}).Name("users.show")
+
+	response, err := viewApp.Test(httptest.NewRequest(http.MethodGet, "/people/3", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, `<a href="/people/3">Brian</a>`, string(bytes))
+}
+
+func TestViewHelpersAsset(t *testing.T) {
+	views := fstest.MapFS{
+		"page.mustache": {Data: []byte(`{{#assets}}<link href="{{#asset}}css/app.css{{/asset}}">{{/assets}}`)},
+	}
+	assets, err := static.New("/assets", fstest.MapFS{"css/app.css": {Data: []byte("body {}")}}, time.Hour)
+	assert.Nil(t, err)
+	url, err := assets.URL("css/app.css")
+	assert.Nil(t, err)
+	viewApp := fiber.New(fiber.Config{
+		Views:             mustache.NewFileSystem(http.FS(views), ".mustache"),
+		PassLocalsToViews: true,
+	})
+	viewApp.Use(NewViewHelpers(assets))
+	viewApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.Render("page", fiber.Map{})
+	})
+
+	response, err := viewApp.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, `<link href="`+url+`">`, string(bytes))
+}
@@ -0,0 +1,398 @@
+// Package minify shrinks HTML, CSS and JavaScript without parsing them fully.
+// It only drops what cannot change the meaning of a document: comments and
+// whitespace. Identifiers are never renamed.
+package minify
+
+import (
+	"bytes"
+	"strings"
+)
+
+// blockTags are the elements whose surrounding whitespace is never rendered,
+// so whitespace between one of them and another tag can go entirely.
+var blockTags = map[string]bool{
+	"": true, "!doctype": true, "html": true, "head": true, "body": true, "title": true, "meta": true,
+	"link": true, "script": true, "style": true, "noscript": true, "base": true,
+	"div": true, "p": true, "section": true, "article": true, "aside": true, "nav": true, "main": true,
+	"header": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true, "hr": true, "br": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "th": true, "td": true,
+	"caption": true, "colgroup": true, "col": true, "form": true, "fieldset": true, "legend": true,
+	"option": true, "optgroup": true, "figure": true, "figcaption": true, "blockquote": true,
+	"details": true, "summary": true, "template": true, "pre": true,
+}
+
+// HTML minifies an HTML document: comments other than conditional ones go,
+// runs of whitespace collapse to one space and whitespace next to block-level
+// tags goes entirely. The contents of pre and textarea are kept as they are;
+// those of script and style are minified with JS and CSS.
+func HTML(source []byte) []byte {
+	out := make([]byte, 0, len(source))
+	lastTag := ""
+	for i := 0; i < len(source); {
+		switch {
+		case bytes.HasPrefix(source[i:], []byte("<!--")):
+			stop := len(source)
+			if end := bytes.Index(source[i+4:], []byte("-->")); end >= 0 {
+				stop = i + 4 + end + 3
+			}
+			if bytes.HasPrefix(source[i+4:], []byte("[if")) || bytes.HasPrefix(source[i+4:], []byte("<![endif")) {
+				out = append(out, source[i:stop]...)
+			}
+			i = stop
+		case source[i] == '<' && i+1 < len(source) && isTagStart(source[i+1]):
+			end := tagEnd(source, i)
+			tag := source[i:end]
+			out = appendTag(out, tag)
+			lastTag = tagName(tag)
+			i = end
+			switch lastTag {
+			case "pre", "textarea", "script", "style":
+				stop := len(source)
+				if end := indexFold(source[i:], "</"+lastTag); end >= 0 {
+					stop = i + end
+				}
+				content := source[i:stop]
+				if lastTag == "script" && isJavaScript(tag) {
+					content = bytes.TrimSpace(JS(content))
+				} else if lastTag == "style" {
+					content = bytes.TrimSpace(CSS(content))
+				}
+				out = append(out, content...)
+				i = stop
+			}
+		case isSpace(source[i]):
+			j := i
+			for j < len(source) && isSpace(source[j]) {
+				j++
+			}
+			betweenTags := (len(out) == 0 || out[len(out)-1] == '>') && (j == len(source) || source[j] == '<')
+			if !betweenTags || !(blockTags[strings.TrimPrefix(lastTag, "/")] || blockTags[strings.TrimPrefix(tagName(source[j:]), "/")]) {
+				out = append(out, ' ')
+			}
+			i = j
+		default:
+			out = append(out, source[i])
+			i++
+		}
+	}
+	return out
+}
+
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || c == '?' || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+// tagEnd returns the index after the > closing the tag starting at i, skipping
+// the quoted attribute values.
+func tagEnd(source []byte, i int) int {
+	var quote byte
+	for j := i + 1; j < len(source); j++ {
+		switch {
+		case quote != 0:
+			if source[j] == quote {
+				quote = 0
+			}
+		case source[j] == '"' || source[j] == '\'':
+			quote = source[j]
+		case source[j] == '>':
+			return j + 1
+		}
+	}
+	return len(source)
+}
+
+// tagName returns the lower-case name of the tag source starts with, prefixed
+// with / for closing tags, or "" when it does not start with a tag.
+func tagName(source []byte) string {
+	if len(source) < 2 || source[0] != '<' {
+		return ""
+	}
+	end := 1
+	if source[end] == '/' {
+		end++
+	}
+	for end < len(source) && !isSpace(source[end]) && source[end] != '>' && source[end] != '/' {
+		end++
+	}
+	return strings.ToLower(string(source[1:end]))
+}
+
+// appendTag appends tag with the whitespace between its attributes collapsed.
+func appendTag(out, tag []byte) []byte {
+	var quote byte
+	space := false
+	for _, c := range tag {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case isSpace(c):
+			space = true
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		}
+		if space && c != '>' {
+			out = append(out, ' ')
+		}
+		space = false
+		out = append(out, c)
+	}
+	return out
+}
+
+// isJavaScript reports whether the script tag holds JavaScript rather than
+// data, such as JSON or a template.
+func isJavaScript(tag []byte) bool {
+	for _, field := range strings.Fields(strings.ToLower(strings.TrimSuffix(string(tag), ">"))) {
+		if value, ok := strings.CutPrefix(field, "type="); ok {
+			value = strings.Trim(value, `"'`)
+			return strings.Contains(value, "javascript") || value == "module"
+		}
+	}
+	return true
+}
+
+func indexFold(source []byte, needle string) int {
+	return bytes.Index(bytes.ToLower(source), []byte(needle))
+}
+
+// CSS minifies a stylesheet: comments go, runs of whitespace collapse to one
+// space, which goes entirely next to braces, semicolons, commas and the
+// child combinator, and so does the semicolon ending a block.
+func CSS(source []byte) []byte {
+	out := make([]byte, 0, len(source))
+	space := false
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '/' && i+1 < len(source) && source[i+1] == '*':
+			i = commentEnd(source, i)
+			space = true
+			continue
+		case isSpace(c):
+			space = true
+			i++
+			continue
+		}
+
+		if space && len(out) > 0 && !strings.ContainsRune("{}:;,>(", rune(out[len(out)-1])) && !strings.ContainsRune("{};,>)", rune(c)) {
+			out = append(out, ' ')
+		}
+		space = false
+		if c == '}' && len(out) > 0 && out[len(out)-1] == ';' {
+			out = out[:len(out)-1]
+		}
+		if c == '"' || c == '\'' {
+			end := stringEnd(source, i)
+			out = append(out, source[i:end]...)
+			i = end
+			continue
+		}
+		out = append(out, c)
+		i++
+	}
+	return out
+}
+
+// JS minifies a script: comments go and runs of whitespace collapse to one
+// space or, when they hold a line break that automatic semicolon insertion
+// may depend on, to that line break. Either goes entirely where no token
+// could be changed by it.
+func JS(source []byte) []byte {
+	out := make([]byte, 0, len(source))
+	space, newline := false, false
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '/' && i+1 < len(source) && source[i+1] == '/':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+			space = true
+			continue
+		case c == '/' && i+1 < len(source) && source[i+1] == '*':
+			end := commentEnd(source, i)
+			if bytes.ContainsAny(source[i:end], "\r\n") {
+				newline = true
+			}
+			space = true
+			i = end
+			continue
+		case isSpace(c):
+			if c == '\n' || c == '\r' {
+				newline = true
+			}
+			space = true
+			i++
+			continue
+		}
+
+		if space {
+			out = appendJSSpace(out, c, newline)
+			space, newline = false, false
+		}
+		end := i + 1
+		switch {
+		case c == '"' || c == '\'':
+			end = stringEnd(source, i)
+		case c == '`':
+			end = templateEnd(source, i)
+		case c == '/' && regexpAllowed(out):
+			end = regexpEnd(source, i)
+		}
+		out = append(out, source[i:end]...)
+		i = end
+	}
+	return out
+}
+
+// appendJSSpace appends the whitespace needed between the end of out and c.
+func appendJSSpace(out []byte, c byte, newline bool) []byte {
+	if len(out) == 0 {
+		return out
+	}
+	previous := out[len(out)-1]
+	if newline {
+		if strings.IndexByte("{;,([=:?&|*%<>!~^", previous) >= 0 || strings.IndexByte("}]),;", c) >= 0 {
+			return out
+		}
+		return append(out, '\n')
+	}
+	if isWord(previous) && isWord(c) ||
+		previous == c && strings.IndexByte("+-/", c) >= 0 ||
+		previous == '/' && c == '*' ||
+		previous >= '0' && previous <= '9' && c == '.' {
+		return append(out, ' ')
+	}
+	return out
+}
+
+// regexpKeywords are the keywords after which a slash starts a regular
+// expression rather than a division.
+var regexpKeywords = []string{"return", "typeof", "instanceof", "in", "of", "new", "delete", "void", "throw", "case", "do", "else", "yield", "await"}
+
+func regexpAllowed(out []byte) bool {
+	trimmed := bytes.TrimRight(out, " \n")
+	if len(trimmed) == 0 {
+		return true
+	}
+	previous := trimmed[len(trimmed)-1]
+	if !isWord(previous) {
+		return strings.IndexByte("(,=:[!&|?{};+-*%<>~^", previous) >= 0
+	}
+	start := len(trimmed)
+	for start > 0 && isWord(trimmed[start-1]) {
+		start--
+	}
+	word := string(trimmed[start:])
+	for _, keyword := range regexpKeywords {
+		if word == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// regexpEnd returns the index after the flags of the regular expression
+// starting at i, or i+1 when it is not terminated on its line.
+func regexpEnd(source []byte, i int) int {
+	inClass := false
+	for j := i + 1; j < len(source); j++ {
+		switch source[j] {
+		case '\\':
+			j++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n', '\r':
+			return i + 1
+		case '/':
+			if !inClass {
+				j++
+				for j < len(source) && isWord(source[j]) {
+					j++
+				}
+				return j
+			}
+		}
+	}
+	return i + 1
+}
+
+// stringEnd returns the index after the quote closing the string starting
+// at i, or the end of its line when it is not terminated.
+func stringEnd(source []byte, i int) int {
+	quote := source[i]
+	for j := i + 1; j < len(source); j++ {
+		switch source[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(source)
+}
+
+// templateEnd returns the index after the backtick closing the template
+// literal starting at i, skipping its substitutions.
+func templateEnd(source []byte, i int) int {
+	for j := i + 1; j < len(source); j++ {
+		switch source[j] {
+		case '\\':
+			j++
+		case '`':
+			return j + 1
+		case '$':
+			if j+1 < len(source) && source[j+1] == '{' {
+				j = substitutionEnd(source, j+2) - 1
+			}
+		}
+	}
+	return len(source)
+}
+
+func substitutionEnd(source []byte, j int) int {
+	depth := 1
+	for j < len(source) {
+		switch source[j] {
+		case '"', '\'':
+			j = stringEnd(source, j)
+			continue
+		case '`':
+			j = templateEnd(source, j)
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+		j++
+	}
+	return len(source)
+}
+
+func commentEnd(source []byte, i int) int {
+	if end := bytes.Index(source[i+2:], []byte("*/")); end >= 0 {
+		return i + 2 + end + 2
+	}
+	return len(source)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c == '$' || c == '\\' || c >= 0x80 ||
+		c >= '0' && c <= '9' || c|0x20 >= 'a' && c|0x20 <= 'z'
+}
@@ -0,0 +1,72 @@
+package minify
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	source := `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <!-- the title -->
+    <title>Hello</title>
+    <!--[if IE]><p>Old browser</p><![endif]-->
+    <style>
+        body { margin: 0 ; }
+    </style>
+</head>
+<body>
+    <p class="a   b"   id=x>
+        Hello   <b>big</b>   <i>world</i>
+    </p>
+    <pre>  keep
+    this  </pre>
+    <textarea>  and   this </textarea>
+    <script>
+        // greet
+        var a = 1
+        var b = a / 2
+    </script>
+    <script type="text/template">  {{ name }}  </script>
+</body>
+</html>
+`
+	expected := `<!DOCTYPE html><html lang="en"><head><title>Hello</title><!--[if IE]><p>Old browser</p><![endif]-->` +
+		`<style>body{margin:0}</style></head><body><p class="a   b" id=x> Hello <b>big</b> <i>world</i></p>` +
+		"<pre>  keep\n    this  </pre><textarea>  and   this </textarea><script>var a=1\nvar b=a/2</script>" +
+		`<script type="text/template">  {{ name }}  </script></body></html>`
+	assert.Equal(t, expected, string(HTML([]byte(source))))
+}
+
+func TestCSS(t *testing.T) {
+	source := `/* reset */
+a:hover , div :first-child > p {
+    color: red ;
+    font-family: "Open  Sans", sans-serif;
+    width: calc(100% - 2px);
+}
+@media screen and (max-width: 600px) { a { content: 'a ; }' } }
+`
+	expected := `a:hover,div :first-child>p{color:red;font-family:"Open  Sans",sans-serif;width:calc(100% - 2px)}` +
+		`@media screen and (max-width:600px){a{content:'a ; }'}}`
+	assert.Equal(t, expected, string(CSS([]byte(source))))
+}
+
+func TestJS(t *testing.T) {
+	cases := map[string]string{
+		"var a = 1 ;  // one\nvar b = 2": "var a=1;var b=2",
+		"let x = a\n++b":                 "let x=a\n++b",
+		"return\nvalue":                  "return\nvalue",
+		"a + +b; c - -d":                 "a+ +b;c- -d",
+		"1 .toString()":                  "1 .toString()",
+		"x = /ab  c\\/[/]/g.test(s) / 2": "x=/ab  c\\/[/]/g.test(s)/2",
+		"s = 'a  // b' + \"c /* d */\"":  "s='a  // b'+\"c /* d */\"",
+		"t = `a  ${ {b: `c  d`}.b }  e`": "t=`a  ${ {b: `c  d`}.b }  e`",
+		"if (a) {\n  b()\n}\n":           "if(a){b()}",
+		"a /* x */ b":                    "a b",
+	}
+	for source, expected := range cases {
+		assert.Equal(t, expected, string(JS([]byte(source))), source)
+	}
+}
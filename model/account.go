@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrEmailChangeNotFound = errors.New("email change not found or expired")
+
+// EmailChange is a new email address a user asked for, which replaces their
+// email once they confirm it with the token sent to it. Only the SHA-256
+// hash of the token is stored.
+type EmailChange struct {
+	UserID    string
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// UpdateProfileRequest replaces the fields users edit themselves. The email
+// is changed with ChangeEmailRequest instead, as it must be verified first.
+type UpdateProfileRequest struct {
+	Username string `json:"username" form:"username" xml:"username" validate:"required,min=3"`
+	Name     string `json:"name" form:"name" xml:"name"`
+	Version  int    `json:"version" form:"version" xml:"version" validate:"min=0"`
+}
+
+// ChangePasswordRequest sets the password of a user. CurrentPassword is
+// required once the user has a password. Bcrypt only uses the first 72 bytes
+// of a password, hence the maximum.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" form:"current_password" xml:"current_password"`
+	NewPassword     string `json:"new_password" form:"new_password" xml:"new_password" validate:"required,min=8,max=72"`
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" form:"email" xml:"email" validate:"required,email"`
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" form:"token" xml:"token" validate:"required"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Activity is an entry of the timeline of the user UserID: something they
+// did or that happened to their account. Kind is the name of the event it
+// comes from and Link, if set, the path of the resource it is about. Public
+// activities show on the profile of the user to everyone; the others only
+// to the user.
+type Activity struct {
+	ID         string    `json:"id" xml:"id" yaml:"id"`
+	UserID     string    `json:"user_id" xml:"user_id" yaml:"user_id"`
+	Kind       string    `json:"kind" xml:"kind" yaml:"kind"`
+	Resource   string    `json:"resource" xml:"resource" yaml:"resource"`
+	ResourceID string    `json:"resource_id" xml:"resource_id" yaml:"resource_id"`
+	Summary    string    `json:"summary" xml:"summary" yaml:"summary"`
+	Link       string    `json:"link,omitempty" xml:"link,omitempty" yaml:"link,omitempty"`
+	Public     bool      `json:"public" xml:"public" yaml:"public"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey authenticates the requests of a user with the X-API-Key header. Only
+// the SHA-256 Hash of the key is stored; the key itself is shown once, when
+// it is created. Prefix, its first characters, helps telling keys apart.
+type APIKey struct {
+	ID        string    `json:"id" xml:"id" yaml:"id"`
+	UserID    string    `json:"user_id" xml:"user_id" yaml:"user_id"`
+	Name      string    `json:"name" xml:"name" yaml:"name"`
+	Prefix    string    `json:"prefix" xml:"prefix" yaml:"prefix"`
+	Hash      string    `json:"-" xml:"-" yaml:"-"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	UserID string `json:"user_id" xml:"user_id" form:"user_id" validate:"required"`
+	Name   string `json:"name" xml:"name" form:"name" validate:"required,max=100"`
+}
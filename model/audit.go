@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// AuditEvent records who did what to which resource, and how its fields
+// changed.
+type AuditEvent struct {
+	ID         string            `json:"id" xml:"id" yaml:"id"`
+	TenantID   string            `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	Actor      string            `json:"actor" xml:"actor" yaml:"actor"`
+	Action     string            `json:"action" xml:"action" yaml:"action"`
+	Resource   string            `json:"resource" xml:"resource" yaml:"resource"`
+	ResourceID string            `json:"resource_id" xml:"resource_id" yaml:"resource_id"`
+	Changes    map[string]Change `json:"changes,omitempty" xml:"-" yaml:"changes,omitempty"`
+	IP         string            `json:"ip" xml:"ip" yaml:"ip"`
+	RequestID  string            `json:"request_id" xml:"request_id" yaml:"request_id"`
+	CreatedAt  time.Time         `json:"created_at" xml:"created_at" yaml:"created_at"`
+}
+
+// Change is the value of a field before and after an action. Before is nil
+// for created resources and After is nil for deleted ones.
+type Change struct {
+	Before interface{} `json:"before" yaml:"before"`
+	After  interface{} `json:"after" yaml:"after"`
+}
+
+// Diff compares the JSON form of before and after field by field, so fields
+// hidden from JSON never end up in the audit log. Either side may be nil.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = Change{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = Change{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(value interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if value == nil || reflect.ValueOf(value).IsZero() {
+		return fields, nil
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &fields)
+	return fields, err
+}
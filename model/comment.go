@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrCommentTooDeep means a reply would nest deeper than allowed.
+	ErrCommentTooDeep = errors.New("replies are nested too deep")
+	// ErrAlreadyRated means the user has rated the resource already.
+	ErrAlreadyRated = errors.New("resource already rated")
+	// ErrRatingOnReply means a reply came with a rating; only the comments
+	// on the resource itself rate it.
+	ErrRatingOnReply = errors.New("replies cannot rate")
+)
+
+// The statuses of a comment. Flagged comments were hidden as enough users
+// flagged them, hidden ones by a moderator; only visible comments are
+// listed and counted in the ratings.
+const (
+	CommentVisible = "visible"
+	CommentFlagged = "flagged"
+	CommentHidden  = "hidden"
+)
+
+// Comment is what the user UserID wrote about the resource ResourceID of the
+// type Resource, such as "product", or in reply to the comment ParentID on
+// it. Depth is 0 for the comments on the resource and one more than their
+// parent for the replies. A comment on the resource may rate it from 1 to 5.
+type Comment struct {
+	ID         string     `json:"id" xml:"id" yaml:"id"`
+	TenantID   string     `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	Resource   string     `json:"resource" xml:"resource" yaml:"resource"`
+	ResourceID string     `json:"resource_id" xml:"resource_id" yaml:"resource_id"`
+	UserID     string     `json:"user_id" xml:"user_id" yaml:"user_id"`
+	ParentID   string     `json:"parent_id,omitempty" xml:"parent_id,omitempty" yaml:"parent_id,omitempty"`
+	Depth      int        `json:"depth" xml:"depth" yaml:"depth"`
+	Body       string     `json:"body" xml:"body" yaml:"body"`
+	Rating     int        `json:"rating,omitempty" xml:"rating,omitempty" yaml:"rating,omitempty"`
+	Status     string     `json:"status" xml:"status" yaml:"status"`
+	Flags      int        `json:"flags" xml:"flags" yaml:"flags"`
+	Replies    []*Comment `json:"replies,omitempty" xml:"replies>comment,omitempty" yaml:"replies,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+
+	// FlaggedBy lists the users who flagged the comment, each counted once.
+	FlaggedBy []string `json:"-" xml:"-" yaml:"-"`
+}
+
+type CreateCommentRequest struct {
+	ParentID string `json:"parent_id" xml:"parent_id" form:"parent_id"`
+	Body     string `json:"body" xml:"body" form:"body" validate:"required,max=2000"`
+	Rating   int    `json:"rating" xml:"rating" form:"rating" validate:"omitempty,min=1,max=5"`
+}
+
+type ModerateCommentRequest struct {
+	Status string `json:"status" xml:"status" form:"status" validate:"required,oneof=visible hidden"`
+}
+
+// RatingSummary aggregates the ratings of a resource: how many there are,
+// their average and how many of each from 1 to 5 there are.
+type RatingSummary struct {
+	Count        int         `json:"count" xml:"count" yaml:"count"`
+	Average      float64     `json:"average" xml:"average" yaml:"average"`
+	Distribution map[int]int `json:"distribution" xml:"-" yaml:"distribution"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+// ErrMailDisabled means no mail server is set up to send email.
+var ErrMailDisabled = errors.New("mail sending is not set up")
+
+// ContactRequest is a message sent through the contact form. Website is a
+// honeypot: the form hides it from people, so only bots fill it in.
+type ContactRequest struct {
+	Name    string `json:"name" xml:"name" form:"name" validate:"required,max=100"`
+	Email   string `json:"email" xml:"email" form:"email" validate:"required,email,max=255"`
+	Subject string `json:"subject" xml:"subject" form:"subject" validate:"max=200"`
+	Message string `json:"message" xml:"message" form:"message" validate:"required,max=5000"`
+	Website string `json:"website" xml:"website" form:"website"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var ErrDeviceNotFound = errors.New("device not found")
+
+// The push services a device is registered with.
+const (
+	PlatformFCM  = "fcm"
+	PlatformAPNs = "apns"
+)
+
+// Device is a device of the user UserID that notifications are pushed to,
+// identified by the Token its Platform gave it.
+type Device struct {
+	ID        string    `json:"id" xml:"id" yaml:"id"`
+	UserID    string    `json:"user_id" xml:"user_id" yaml:"user_id"`
+	Platform  string    `json:"platform" xml:"platform" yaml:"platform"`
+	Token     string    `json:"token" xml:"token" yaml:"token"`
+	Name      string    `json:"name,omitempty" xml:"name,omitempty" yaml:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+type RegisterDeviceRequest struct {
+	Platform string `json:"platform" xml:"platform" form:"platform" validate:"required,oneof=fcm apns"`
+	Token    string `json:"token" xml:"token" form:"token" validate:"required,max=4096"`
+	Name     string `json:"name" xml:"name" form:"name" validate:"max=100"`
+}
+
+// NotificationPreferences are the choices of a user about push
+// notifications and texts: Push turns pushing off altogether, SMS turns on
+// texting to Phone, and the kinds of notifications in Muted are neither
+// pushed nor texted. Either way, the notifications are still kept in the
+// app.
+type NotificationPreferences struct {
+	Push      bool      `json:"push" xml:"push" yaml:"push"`
+	SMS       bool      `json:"sms" xml:"sms" yaml:"sms"`
+	Phone     string    `json:"phone,omitempty" xml:"phone,omitempty" yaml:"phone,omitempty"`
+	Muted     []string  `json:"muted" xml:"muted>kind" yaml:"muted"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" xml:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+}
+
+// DefaultNotificationPreferences are the preferences of the users who have
+// not chosen any: every notification is pushed.
+func DefaultNotificationPreferences() *NotificationPreferences {
+	return &NotificationPreferences{Push: true, Muted: []string{}}
+}
+
+// Pushes tells whether notifications of kind are pushed.
+func (preferences *NotificationPreferences) Pushes(kind string) bool {
+	return preferences.Push && !slices.Contains(preferences.Muted, kind)
+}
+
+// Texts tells whether notifications of kind are texted.
+func (preferences *NotificationPreferences) Texts(kind string) bool {
+	return preferences.SMS && preferences.Phone != "" && !slices.Contains(preferences.Muted, kind)
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Push  *bool    `json:"push" xml:"push" form:"push" validate:"required"`
+	SMS   bool     `json:"sms" xml:"sms" form:"sms"`
+	Phone string   `json:"phone" xml:"phone" form:"phone" validate:"required_if=SMS true,omitempty,e164"`
+	Muted []string `json:"muted" xml:"muted>kind" form:"muted" validate:"max=50,dive,required,max=100"`
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrFileNotFound         = errors.New("file not found")
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+const (
+	FilePending    = "pending"
+	FileProcessing = "processing"
+	FileProcessed  = "processed"
+	FileFailed     = "failed"
+
+	StepSucceeded = "succeeded"
+	StepSkipped   = "skipped"
+	StepFailed    = "failed"
+)
+
+// File is an uploaded file and the state of its processing. Its content is
+// stored under StorageKey, which does not change when the file is renamed.
+// It is pending until the upload pipeline picks it up, then processed or
+// failed once every step has run; Steps tells which step failed and why.
+// Files uploaded without an API key have no OwnerID.
+type File struct {
+	ID          string     `json:"id" xml:"id" yaml:"id"`
+	TenantID    string     `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	OwnerID     string     `json:"owner_id,omitempty" xml:"owner_id,omitempty" yaml:"owner_id,omitempty"`
+	Name        string     `json:"name" xml:"name" yaml:"name"`
+	Size        int64      `json:"size" xml:"size" yaml:"size"`
+	Status      string     `json:"status" xml:"status" yaml:"status"`
+	ContentType string     `json:"content_type,omitempty" xml:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Checksum    string     `json:"checksum,omitempty" xml:"checksum,omitempty" yaml:"checksum,omitempty"`
+	StorageKey  string     `json:"-" xml:"-" yaml:"-"`
+	Thumbnail   string     `json:"thumbnail,omitempty" xml:"thumbnail,omitempty" yaml:"thumbnail,omitempty"`
+	Steps       []FileStep `json:"steps" xml:"steps>step" yaml:"steps"`
+	CreatedAt   time.Time  `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+// FileStep is the outcome of one step of the upload pipeline.
+type FileStep struct {
+	Name   string `json:"name" xml:"name" yaml:"name"`
+	Status string `json:"status" xml:"status" yaml:"status"`
+	Error  string `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type RenameFileRequest struct {
+	Name string `json:"name" xml:"name" form:"name" validate:"required,max=255"`
+}
+
+// StorageUsage is how much storage the files of a user take up. Limit is
+// their storage quota, zero when there is none.
+type StorageUsage struct {
+	Files int   `json:"files" xml:"files" yaml:"files"`
+	Used  int64 `json:"used" xml:"used" yaml:"used"`
+	Limit int64 `json:"limit" xml:"limit" yaml:"limit"`
+}
+
+// Remaining returns how many bytes the user may still upload, or -1 when
+// there is no limit.
+func (usage *StorageUsage) Remaining() int64 {
+	if usage.Limit == 0 {
+		return -1
+	}
+	return max(usage.Limit-usage.Used, 0)
+}
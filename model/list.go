@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListSpec is the parsed form of ?page, ?per_page, ?cursor, ?sort and
+// ?filter[field] used by repositories to select a page of results.
+// Soft-deleted records are left out unless IncludeDeleted is set. Search, from
+// ?q, keeps the records whose text fields contain it, ignoring case; only the
+// lists whose options are Searchable set it.
+type ListSpec struct {
+	Page           int
+	PerPage        int
+	Cursor         string
+	CursorMode     bool
+	Sort           []SortField
+	Filters        map[string]string
+	Search         string
+	IncludeDeleted bool
+}
+
+// Offset is where the page starts: the position stored in the cursor in
+// cursor mode (an empty ?cursor= starts from the beginning), otherwise derived
+// from page and per_page.
+func (spec *ListSpec) Offset() int {
+	if spec.CursorMode {
+		if spec.Cursor == "" {
+			return 0
+		}
+		offset, _ := DecodeCursor(spec.Cursor)
+		return offset
+	}
+	return (spec.Page - 1) * spec.PerPage
+}
+
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(string(value))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// TimeRange selects the records from From up to, but not including, To. A
+// zero bound leaves that side open.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains reports whether at is within the range.
+func (timeRange TimeRange) Contains(at time.Time) bool {
+	return (timeRange.From.IsZero() || !at.Before(timeRange.From)) && (timeRange.To.IsZero() || at.Before(timeRange.To))
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Notification is a message to the user UserID, shown in the app until they
+// read it. Kind is the name of the event it tells about, and Link, if set,
+// the path of the resource it is about.
+type Notification struct {
+	ID        string     `json:"id" xml:"id" yaml:"id"`
+	TenantID  string     `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	UserID    string     `json:"user_id" xml:"user_id" yaml:"user_id"`
+	Kind      string     `json:"kind" xml:"kind" yaml:"kind"`
+	Title     string     `json:"title" xml:"title" yaml:"title"`
+	Body      string     `json:"body,omitempty" xml:"body,omitempty" yaml:"body,omitempty"`
+	Link      string     `json:"link,omitempty" xml:"link,omitempty" yaml:"link,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty" xml:"read_at,omitempty" yaml:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" xml:"created_at" yaml:"created_at"`
+}
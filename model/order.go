@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrOrderNotEditable       = errors.New("order can no longer be changed")
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+	ErrOrderNotPayable        = errors.New("only unpaid pending orders can be paid")
+)
+
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+
+	PaymentUnpaid  = "unpaid"
+	PaymentPending = "pending"
+	PaymentPaid    = "paid"
+	PaymentFailed  = "failed"
+)
+
+// orderTransitions lists the statuses each status may move to. Delivered and
+// cancelled orders are final.
+var orderTransitions = map[string][]string{
+	OrderPending: {OrderPaid, OrderCancelled},
+	OrderPaid:    {OrderShipped, OrderCancelled},
+	OrderShipped: {OrderDelivered},
+}
+
+// CanTransition reports whether an order may move from the status from to the
+// status to.
+func CanTransition(from, to string) bool {
+	return slices.Contains(orderTransitions[from], to)
+}
+
+// Order is an order placed by the user UserID. Its items and notes can only
+// be changed while it is pending. Total is the sum of the items, in the
+// smallest unit of Currency. PaymentStatus is pending from the moment a
+// checkout is started, PaymentID being the checkout session, until the
+// payment provider reports the payment paid or failed.
+type Order struct {
+	ID            string      `json:"id" xml:"id" yaml:"id"`
+	TenantID      string      `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	UserID        string      `json:"user_id" xml:"user_id" yaml:"user_id"`
+	Status        string      `json:"status" xml:"status" yaml:"status"`
+	Items         []OrderItem `json:"items" xml:"items>item" yaml:"items"`
+	Total         int64       `json:"total" xml:"total" yaml:"total"`
+	Currency      string      `json:"currency" xml:"currency" yaml:"currency"`
+	Notes         string      `json:"notes,omitempty" xml:"notes,omitempty" yaml:"notes,omitempty"`
+	PaymentStatus string      `json:"payment_status" xml:"payment_status" yaml:"payment_status"`
+	PaymentID     string      `json:"payment_id,omitempty" xml:"payment_id,omitempty" yaml:"payment_id,omitempty"`
+	CreatedAt     time.Time   `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+// OrderItem is Quantity times a product sold at UnitPrice, in the smallest
+// unit of the currency of its order.
+type OrderItem struct {
+	Name      string `json:"name" xml:"name" yaml:"name" validate:"required,max=255"`
+	Quantity  int    `json:"quantity" xml:"quantity" yaml:"quantity" validate:"required,min=1"`
+	UnitPrice int64  `json:"unit_price" xml:"unit_price" yaml:"unit_price" validate:"min=0"`
+}
+
+// Amount is what the item costs in all.
+func (item OrderItem) Amount() int64 {
+	return int64(item.Quantity) * item.UnitPrice
+}
+
+// SetItems replaces the items of the order and recomputes its total.
+func (order *Order) SetItems(items []OrderItem) {
+	order.Items = items
+	order.Total = 0
+	for _, item := range items {
+		order.Total += item.Amount()
+	}
+}
+
+type CreateOrderRequest struct {
+	Items    []OrderItem `json:"items" xml:"items>item" validate:"required,min=1,dive"`
+	Currency string      `json:"currency" xml:"currency" form:"currency" validate:"required,len=3,alpha"`
+	Notes    string      `json:"notes" xml:"notes" form:"notes" validate:"max=1000"`
+}
+
+type UpdateOrderRequest struct {
+	Items []OrderItem `json:"items" xml:"items>item" validate:"required,min=1,dive"`
+	Notes string      `json:"notes" xml:"notes" form:"notes" validate:"max=1000"`
+}
+
+// TransitionOrderRequest moves an order to Status. Orders are paid by the
+// payment provider only, never through a request.
+type TransitionOrderRequest struct {
+	Status string `json:"status" xml:"status" form:"status" validate:"required,oneof=shipped delivered cancelled"`
+}
+
+// Checkout is a payment session started for an order; the customer pays at
+// URL.
+type Checkout struct {
+	ID  string `json:"id" xml:"id" yaml:"id"`
+	URL string `json:"url" xml:"url" yaml:"url"`
+}
+
+type CheckoutRequest struct {
+	OrderID string `json:"order_id" xml:"order_id" form:"order_id" validate:"required"`
+}
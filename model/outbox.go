@@ -0,0 +1,17 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage is a domain event waiting in the outbox to be relayed.
+// PublishedAt is set once it has been.
+type OutboxMessage struct {
+	ID          string          `json:"id"`
+	TenantID    string          `json:"tenant_id,omitempty"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt *time.Time      `json:"published_at"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrProductNotFound      = errors.New("product not found")
+	ErrProductImageNotFound = errors.New("product image not found")
+)
+
+// Product is an item of the catalog. Price is in the smallest unit of
+// Currency and Stock is how many are left.
+type Product struct {
+	ID          string         `json:"id" xml:"id" yaml:"id"`
+	TenantID    string         `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	Name        string         `json:"name" xml:"name" yaml:"name"`
+	Description string         `json:"description,omitempty" xml:"description,omitempty" yaml:"description,omitempty"`
+	Category    string         `json:"category" xml:"category" yaml:"category"`
+	Price       int64          `json:"price" xml:"price" yaml:"price"`
+	Currency    string         `json:"currency" xml:"currency" yaml:"currency"`
+	Stock       int            `json:"stock" xml:"stock" yaml:"stock"`
+	Images      []ProductImage `json:"images" xml:"images>image" yaml:"images"`
+	CreatedAt   time.Time      `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+// ProductImage is an image of a product, stored as it was uploaded.
+type ProductImage struct {
+	ID          string `json:"id" xml:"id" yaml:"id"`
+	ContentType string `json:"content_type" xml:"content_type" yaml:"content_type"`
+	Size        int64  `json:"size" xml:"size" yaml:"size"`
+}
+
+// ProductCategory is a category and how many products are in it.
+type ProductCategory struct {
+	Name     string `json:"name" xml:"name" yaml:"name"`
+	Products int    `json:"products" xml:"products" yaml:"products"`
+}
+
+type SaveProductRequest struct {
+	Name        string `json:"name" xml:"name" form:"name" validate:"required,max=255"`
+	Description string `json:"description" xml:"description" form:"description" validate:"max=5000"`
+	Category    string `json:"category" xml:"category" form:"category" validate:"required,max=100"`
+	Price       int64  `json:"price" xml:"price" form:"price" validate:"min=0"`
+	Currency    string `json:"currency" xml:"currency" form:"currency" validate:"required,len=3,alpha"`
+	Stock       int    `json:"stock" xml:"stock" form:"stock" validate:"min=0"`
+}
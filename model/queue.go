@@ -0,0 +1,8 @@
+package model
+
+// QueueStatus is the backlog of the background jobs: the domain events waiting
+// in the outbox and the webhook deliveries by status.
+type QueueStatus struct {
+	OutboxPending int            `json:"outbox_pending"`
+	Deliveries    map[string]int `json:"deliveries"`
+}
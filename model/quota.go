@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+const (
+	QuotaDaily   = "daily"
+	QuotaMonthly = "monthly"
+)
+
+// QuotaLimit allows Subject Limit requests per Period.
+type QuotaLimit struct {
+	Subject string
+	Period  string
+	Limit   int
+}
+
+// QuotaCounter counts the requests of Subject, such as "user:<id>" or
+// "api_key:<id>", in the Period that started at Start and ends at ResetAt.
+// Used never exceeds Limit.
+type QuotaCounter struct {
+	Subject string    `json:"subject" xml:"subject" yaml:"subject"`
+	Period  string    `json:"period" xml:"period" yaml:"period"`
+	Start   time.Time `json:"-" xml:"-" yaml:"-"`
+	ResetAt time.Time `json:"reset_at" xml:"reset_at" yaml:"reset_at"`
+	Limit   int       `json:"limit" xml:"limit" yaml:"limit"`
+	Used    int       `json:"used" xml:"used" yaml:"used"`
+}
+
+func (counter *QuotaCounter) Remaining() int {
+	return max(counter.Limit-counter.Used, 0)
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicateWebhook means the provider already sent the event.
+var ErrDuplicateWebhook = errors.New("webhook event already received")
+
+// ReceivedWebhook records an event received from a provider, so the
+// provider's retries of the event are recognized.
+type ReceivedWebhook struct {
+	Provider   string    `json:"provider" xml:"provider" yaml:"provider"`
+	EventID    string    `json:"event_id" xml:"event_id" yaml:"event_id"`
+	Type       string    `json:"type" xml:"type" yaml:"type"`
+	ReceivedAt time.Time `json:"received_at" xml:"received_at" yaml:"received_at"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrReportNotFound = errors.New("report not found")
+	ErrReportNotReady = errors.New("report is not ready")
+)
+
+const (
+	ReportPending = "pending"
+	ReportReady   = "ready"
+	ReportFailed  = "failed"
+
+	ReportOrders = "orders"
+)
+
+// Report is a PDF report of the user UserID generated in the background. It
+// is pending until it is ready to download, or failed with Error.
+type Report struct {
+	ID          string     `json:"id" xml:"id" yaml:"id"`
+	UserID      string     `json:"user_id" xml:"user_id" yaml:"user_id"`
+	Kind        string     `json:"kind" xml:"kind" yaml:"kind"`
+	Status      string     `json:"status" xml:"status" yaml:"status"`
+	From        *time.Time `json:"from,omitempty" xml:"from,omitempty" yaml:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty" xml:"to,omitempty" yaml:"to,omitempty"`
+	Size        int64      `json:"size,omitempty" xml:"size,omitempty" yaml:"size,omitempty"`
+	Error       string     `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" xml:"created_at" yaml:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" xml:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+}
+
+// Period is the time range of the records the report covers.
+func (report *Report) Period() TimeRange {
+	var period TimeRange
+	if report.From != nil {
+		period.From = *report.From
+	}
+	if report.To != nil {
+		period.To = *report.To
+	}
+	return period
+}
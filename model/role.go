@@ -0,0 +1,6 @@
+package model
+
+type Role struct {
+	Name        string `json:"name" xml:"name" yaml:"name"`
+	Description string `json:"description" xml:"description" yaml:"description"`
+}
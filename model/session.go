@@ -0,0 +1,22 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a login of a user. The access tokens issued for it carry its ID
+// in their sid claim and are only accepted until it expires or is revoked.
+// Current marks, in the sessions listed to a user, the one of the request.
+type Session struct {
+	ID        string    `json:"id" xml:"id" yaml:"id"`
+	TenantID  string    `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	UserID    string    `json:"user_id" xml:"user_id" yaml:"user_id"`
+	UserAgent string    `json:"user_agent" xml:"user_agent" yaml:"user_agent"`
+	IPAddress string    `json:"ip_address" xml:"ip_address" yaml:"ip_address"`
+	Current   bool      `json:"current" xml:"current" yaml:"current"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" xml:"expires_at" yaml:"expires_at"`
+}
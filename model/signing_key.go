@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// SigningKey signs the tokens the app issues. PrivateKey is the PKCS #8 DER
+// encoding of its private key, of Algorithm. The key signs from CreatedAt
+// until a newer one replaces it and is published, so the tokens it signed
+// can be verified, until ExpiresAt.
+type SigningKey struct {
+	ID         string    `json:"id" xml:"id" yaml:"id"`
+	Algorithm  string    `json:"algorithm" xml:"algorithm" yaml:"algorithm"`
+	PrivateKey []byte    `json:"-" xml:"-" yaml:"-"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at" xml:"expires_at" yaml:"expires_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrSMSNotFound = errors.New("sms not found")
+
+// The statuses of a text message. A message is sent once the provider
+// accepts it; the provider reports later whether it was delivered or
+// failed. Rate-limited messages were never handed to the provider.
+const (
+	SMSSent        = "sent"
+	SMSDelivered   = "delivered"
+	SMSFailed      = "failed"
+	SMSRateLimited = "rate_limited"
+)
+
+// SMSMessage is a text message sent to the phone number To, known to
+// Provider as ProviderID. Kind names what it is about, such as the kind of
+// the notification it carries.
+type SMSMessage struct {
+	ID         string    `json:"id" xml:"id" yaml:"id"`
+	To         string    `json:"to" xml:"to" yaml:"to"`
+	Kind       string    `json:"kind" xml:"kind" yaml:"kind"`
+	Body       string    `json:"body" xml:"body" yaml:"body"`
+	Provider   string    `json:"provider" xml:"provider" yaml:"provider"`
+	ProviderID string    `json:"provider_id,omitempty" xml:"provider_id,omitempty" yaml:"provider_id,omitempty"`
+	Status     string    `json:"status" xml:"status" yaml:"status"`
+	Error      string    `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
+
+// ErrSMSDisabled means no SMS provider is set up to send texts.
+var ErrSMSDisabled = errors.New("sms sending is not set up")
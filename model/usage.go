@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// UsageStat aggregates the requests a Consumer, such as "user:<id>",
+// "api_key:<id>" or "anonymous", made to a route in the time bucket starting
+// at Bucket. The requests are counted by status class, and their latencies
+// summed so that stats of the same bucket add up.
+type UsageStat struct {
+	Bucket       time.Time     `json:"bucket"`
+	Method       string        `json:"method"`
+	Route        string        `json:"route"`
+	Consumer     string        `json:"consumer"`
+	Requests     int           `json:"requests"`
+	Status2xx    int           `json:"status_2xx"`
+	Status3xx    int           `json:"status_3xx"`
+	Status4xx    int           `json:"status_4xx"`
+	Status5xx    int           `json:"status_5xx"`
+	LatencyTotal time.Duration `json:"latency_total"`
+	LatencyMax   time.Duration `json:"latency_max"`
+}
+
+// Add counts the requests of other in stat.
+func (stat *UsageStat) Add(other *UsageStat) {
+	stat.Requests += other.Requests
+	stat.Status2xx += other.Status2xx
+	stat.Status3xx += other.Status3xx
+	stat.Status4xx += other.Status4xx
+	stat.Status5xx += other.Status5xx
+	stat.LatencyTotal += other.LatencyTotal
+	stat.LatencyMax = max(stat.LatencyMax, other.LatencyMax)
+}
+
+// UsageReport summarizes the UsageStats from From to To: by route, the
+// consumers with the most requests and the error rate of every bucket.
+type UsageReport struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Routes    []UsageSummary  `json:"routes"`
+	Consumers []UsageSummary  `json:"consumers"`
+	Trend     []UsageTrendRow `json:"trend"`
+}
+
+// UsageSummary totals the requests of a route ("GET /users") or a consumer.
+// ErrorRate is the share of them answered 5xx, and ClientErrorRate 4xx.
+type UsageSummary struct {
+	Name            string        `json:"name"`
+	Requests        int           `json:"requests"`
+	ErrorRate       float64       `json:"error_rate"`
+	ClientErrorRate float64       `json:"client_error_rate"`
+	AverageLatency  time.Duration `json:"average_latency"`
+	MaxLatency      time.Duration `json:"max_latency"`
+}
+
+type UsageTrendRow struct {
+	Bucket    time.Time `json:"bucket"`
+	Requests  int       `json:"requests"`
+	ErrorRate float64   `json:"error_rate"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+var ErrAvatarNotFound = errors.New("avatar not found")
+
+// ErrVersionConflict means a resource changed since the version an update was
+// based on was read.
+var ErrVersionConflict = errors.New("resource was modified by another request")
+
+// User is an account of the app. Avatar is the version of the avatar of the
+// user, empty without one, which changes with every new avatar.
+type User struct {
+	ID         string     `json:"id" xml:"id" yaml:"id"`
+	TenantID   string     `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	Username   string     `json:"username" xml:"username" yaml:"username"`
+	Email      string     `json:"email" xml:"email" yaml:"email"`
+	Name       string     `json:"name" xml:"name" yaml:"name"`
+	Avatar     string     `json:"avatar,omitempty" xml:"avatar,omitempty" yaml:"avatar,omitempty"`
+	Roles      []string   `json:"roles,omitempty" xml:"roles>role,omitempty" yaml:"roles,omitempty"`
+	Identities []Identity `json:"identities,omitempty" xml:"identities>identity,omitempty" yaml:"identities,omitempty"`
+	Version    int        `json:"version" xml:"version" yaml:"version"`
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at" yaml:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+
+	// PasswordHash is the bcrypt hash of the password of the user, empty for
+	// the users who only log in with OAuth.
+	PasswordHash string `json:"-" xml:"-" yaml:"-"`
+}
+
+// Identity links a local account to an account at an external OAuth provider.
+type Identity struct {
+	Provider string `json:"provider" xml:"provider" yaml:"provider"`
+	Subject  string `json:"subject" xml:"subject" yaml:"subject"`
+}
+
+// CreateUserRequest is a new user, as read from an import file.
+type CreateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Name     string `json:"name"`
+}
+
+// ImportResult tells how many users an import created and why the others
+// were rejected.
+type ImportResult struct {
+	Imported int               `json:"imported" xml:"imported" yaml:"imported"`
+	Rejected []ImportRejection `json:"rejected" xml:"rejected>row" yaml:"rejected"`
+}
+
+// ImportRejection explains why the record at Row, counting from 1, was not
+// imported.
+type ImportRejection struct {
+	Row    int              `json:"row" xml:"row" yaml:"row"`
+	Errors ValidationErrors `json:"errors" xml:"errors>error" yaml:"errors"`
+}
+
+// UpdateUserRequest replaces the editable fields of a user. A non-zero
+// Version must match the current version of the user.
+type UpdateUserRequest struct {
+	Username string `json:"username" form:"username" xml:"username" validate:"required,min=3"`
+	Email    string `json:"email" form:"email" xml:"email" validate:"omitempty,email"`
+	Name     string `json:"name" form:"name" xml:"name"`
+	Version  int    `json:"version" form:"version" xml:"version" validate:"min=0"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+)
+
+// FieldError keeps the failed validation Tag and its Param so the message can
+// be translated.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Tag     string `json:"-"`
+	Param   string `json:"-"`
+}
+
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Field + " " + err.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+var validate = validator.New()
+
+func init() {
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// ValidateStruct checks the `validate` tags of value and reports failures as
+// ValidationErrors keyed by the field's json name.
+func ValidateStruct(value interface{}) error {
+	err := validate.Struct(value)
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	result := make(ValidationErrors, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		message := "failed " + fieldError.Tag()
+		if fieldError.Param() != "" {
+			message += "=" + fieldError.Param()
+		}
+		result[i] = FieldError{Field: fieldError.Field(), Message: message, Tag: fieldError.Tag(), Param: fieldError.Param()}
+	}
+	return result
+}
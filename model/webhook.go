@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Webhook is an endpoint that receives the domain events named in Events.
+// Secret signs the deliveries and is only shown when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id" xml:"id" yaml:"id"`
+	TenantID  string    `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	URL       string    `json:"url" xml:"url" yaml:"url"`
+	Secret    string    `json:"-" xml:"-" yaml:"-"`
+	Events    []string  `json:"events" xml:"events>event" yaml:"events"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at" yaml:"created_at"`
+}
+
+// CreateWebhookRequest registers a webhook. A secret is generated when
+// Secret is empty.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" xml:"url" form:"url" validate:"required,http_url"`
+	Secret string   `json:"secret" xml:"secret" form:"secret" validate:"omitempty,min=16"`
+	Events []string `json:"events" xml:"events>event" form:"events" validate:"required,min=1"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is the log of sending one event to one webhook. It is
+// pending until the endpoint answers with a 2xx status, or failed once the
+// attempts are used up. StatusCode and Error describe the last attempt.
+type WebhookDelivery struct {
+	ID            string          `json:"id" xml:"id" yaml:"id"`
+	TenantID      string          `json:"tenant_id,omitempty" xml:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	WebhookID     string          `json:"webhook_id" xml:"webhook_id" yaml:"webhook_id"`
+	Event         string          `json:"event" xml:"event" yaml:"event"`
+	Payload       json.RawMessage `json:"payload" xml:"-" yaml:"-"`
+	Status        string          `json:"status" xml:"status" yaml:"status"`
+	Attempts      int             `json:"attempts" xml:"attempts" yaml:"attempts"`
+	StatusCode    int             `json:"status_code,omitempty" xml:"status_code,omitempty" yaml:"status_code,omitempty"`
+	Error         string          `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" xml:"next_attempt_at" yaml:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" xml:"created_at" yaml:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" xml:"updated_at" yaml:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+)
+
+// Outbox is an event.Publisher that stores events in the outbox instead of
+// delivering them. Publishing within a repository.Transactor transaction
+// stores the event together with the change it describes; the Relay delivers
+// it once the transaction has committed.
+type Outbox struct {
+	messages repository.OutboxRepository
+}
+
+func New(messages repository.OutboxRepository) *Outbox {
+	return &Outbox{messages: messages}
+}
+
+func (outbox *Outbox) Publish(ctx context.Context, published event.Event) error {
+	payload, err := json.Marshal(published)
+	if err != nil {
+		return err
+	}
+	return outbox.messages.Add(ctx, &model.OutboxMessage{Event: published.Name(), Payload: payload})
+}
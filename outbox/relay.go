@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"context"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"time"
+)
+
+var logger = telemetry.Logger("outbox")
+
+// Relay moves the messages of the outbox to a publisher, oldest first. A
+// message is marked published in the transaction that claimed it, so it is
+// published once; only a failed commit after publishing, which rolls the
+// claim back, publishes it again.
+type Relay struct {
+	messages  repository.OutboxRepository
+	publisher event.Publisher
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(messages repository.OutboxRepository, publisher event.Publisher, config config.OutboxConfig) *Relay {
+	return &Relay{messages: messages, publisher: publisher, interval: config.Interval, batchSize: config.BatchSize}
+}
+
+// Run relays the outbox every interval until ctx is done.
+func (relay *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+	for {
+		relayed, err := relay.Flush(ctx)
+		if relayed > 0 {
+			logger.Debug("relayed the outbox", "messages", relayed)
+		}
+		if err != nil && ctx.Err() == nil {
+			logger.Error("relaying the outbox", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush relays batches until the outbox is empty and returns the number of
+// messages relayed. A message that fails to publish stops the flush and is
+// retried with the rest of its batch next time. One that cannot be decoded
+// never will be, so it is logged and dropped. Each message is published in the
+// tenant it was added in.
+func (relay *Relay) Flush(ctx context.Context) (int, error) {
+	relayed := 0
+	for {
+		batch := 0
+		err := relay.messages.Claim(ctx, relay.batchSize, func(messages []*model.OutboxMessage) error {
+			for _, message := range messages {
+				decoded, err := event.Decode(message.Event, message.Payload)
+				if err != nil {
+					logger.Warn("dropping an undecodable message", "id", message.ID, "error", err)
+					continue
+				}
+				err = relay.publisher.Publish(tenant.With(ctx, message.TenantID), decoded)
+				if err != nil {
+					return err
+				}
+			}
+			batch = len(messages)
+			return nil
+		})
+		if err != nil {
+			return relayed, err
+		}
+		relayed += batch
+		if batch < relay.batchSize {
+			return relayed, nil
+		}
+	}
+}
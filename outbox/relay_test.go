@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/tenant"
+	"testing"
+)
+
+type recorder struct {
+	events  []event.Event
+	tenants []string
+}
+
+func (recorder *recorder) Publish(ctx context.Context, published event.Event) error {
+	recorder.events = append(recorder.events, published)
+	recorder.tenants = append(recorder.tenants, tenant.From(ctx))
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	messages := repository.NewMemoryOutboxRepository()
+	outbox := New(messages)
+	assert.Nil(t, outbox.Publish(ctx, event.UserRegistered{User: &model.User{ID: "1", Username: "brian"}}))
+	assert.Nil(t, outbox.Publish(ctx, event.FileUploaded{FileName: "notes.txt", Size: 5}))
+	assert.Nil(t, messages.Add(ctx, &model.OutboxMessage{Event: "user.unknown", Payload: []byte("{}")}))
+	assert.Nil(t, outbox.Publish(ctx, event.UserDeleted{UserID: "1"}))
+
+	published := &recorder{}
+	relay := NewRelay(messages, published, config.OutboxConfig{BatchSize: 2})
+	relayed, err := relay.Flush(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, relayed)
+	assert.Equal(t, []event.Event{
+		event.UserRegistered{User: &model.User{ID: "1", Username: "brian"}},
+		event.FileUploaded{FileName: "notes.txt", Size: 5},
+		event.UserDeleted{UserID: "1"},
+	}, published.events)
+
+	relayed, err = relay.Flush(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, relayed)
+	assert.Len(t, published.events, 3)
+}
+
+func TestRelayKeepsTheTenant(t *testing.T) {
+	messages := repository.NewMemoryOutboxRepository()
+	outbox := New(messages)
+	assert.Nil(t, outbox.Publish(tenant.With(context.Background(), "acme"), event.UserDeleted{UserID: "1"}))
+	assert.Nil(t, outbox.Publish(context.Background(), event.UserDeleted{UserID: "2"}))
+
+	published := &recorder{}
+	relayed, err := NewRelay(messages, published, config.OutboxConfig{BatchSize: 10}).Flush(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Equal(t, []string{"acme", ""}, published.tenants)
+}
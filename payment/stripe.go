@@ -0,0 +1,101 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/model"
+	"strconv"
+	"strings"
+)
+
+// The types of the Stripe events about checkout sessions.
+const (
+	CheckoutCompleted             = "checkout.session.completed"
+	CheckoutAsyncPaymentSucceeded = "checkout.session.async_payment_succeeded"
+	CheckoutAsyncPaymentFailed    = "checkout.session.async_payment_failed"
+	CheckoutExpired               = "checkout.session.expired"
+)
+
+// Stripe starts Stripe Checkout sessions through the Stripe API.
+type Stripe struct {
+	client *httpclient.Client
+	config config.PaymentConfig
+}
+
+func NewStripe(client *httpclient.Client, config config.PaymentConfig) *Stripe {
+	return &Stripe{client: client, config: config}
+}
+
+// CreateCheckout starts a checkout session for the items of order, referring
+// to the order by its ID and tenant. The session is created once per version of the
+// order, so retrying returns the same session.
+func (stripe *Stripe) CreateCheckout(ctx context.Context, order *model.Order) (*model.Checkout, error) {
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+	args.Set("mode", "payment")
+	args.Set("client_reference_id", order.ID)
+	args.Set("success_url", strings.ReplaceAll(stripe.config.SuccessURL, "{order_id}", order.ID))
+	args.Set("cancel_url", strings.ReplaceAll(stripe.config.CancelURL, "{order_id}", order.ID))
+	args.Set("metadata[order_id]", order.ID)
+	args.Set("metadata[tenant_id]", order.TenantID)
+	for i, item := range order.Items {
+		prefix := "line_items[" + strconv.Itoa(i) + "]"
+		args.Set(prefix+"[quantity]", strconv.Itoa(item.Quantity))
+		args.Set(prefix+"[price_data][currency]", strings.ToLower(order.Currency))
+		args.Set(prefix+"[price_data][unit_amount]", strconv.FormatInt(item.UnitPrice, 10))
+		args.Set(prefix+"[price_data][product_data][name]", item.Name)
+	}
+
+	response, err := stripe.client.Post(ctx, strings.TrimSuffix(stripe.config.StripeAPIURL, "/")+"/v1/checkout/sessions", func(agent *fiber.Agent) {
+		agent.Form(args).
+			Set(fiber.HeaderAuthorization, "Bearer "+stripe.config.StripeSecretKey).
+			Set("Idempotency-Key", "checkout-"+order.ID+"-"+strconv.FormatInt(order.UpdatedAt.UnixNano(), 10))
+	})
+	if err != nil {
+		return nil, err
+	}
+	session := struct {
+		ID    string `json:"id"`
+		URL   string `json:"url"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	err = json.Unmarshal(response.Body, &session)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != fiber.StatusOK || session.ID == "" {
+		return nil, fmt.Errorf("stripe checkout failed with status %d: %s", response.Status, session.Error.Message)
+	}
+	return &model.Checkout{ID: session.ID, URL: session.URL}, nil
+}
+
+// CheckoutSession is the checkout session a Stripe event is about.
+type CheckoutSession struct {
+	ID                string `json:"id"`
+	ClientReferenceID string `json:"client_reference_id"`
+	PaymentStatus     string `json:"payment_status"`
+	Metadata          struct {
+		TenantID string `json:"tenant_id"`
+	} `json:"metadata"`
+}
+
+// ParseCheckoutSession returns the checkout session of the payload of a
+// Stripe event.
+func ParseCheckoutSession(payload []byte) (*CheckoutSession, error) {
+	stripeEvent := struct {
+		Data struct {
+			Object CheckoutSession `json:"object"`
+		} `json:"data"`
+	}{}
+	err := json.Unmarshal(payload, &stripeEvent)
+	if err != nil {
+		return nil, err
+	}
+	return &stripeEvent.Data.Object, nil
+}
@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"golang-fiber-web/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateCheckout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/v1/checkout/sessions", request.URL.Path)
+		assert.Equal(t, "Bearer sk_test", request.Header.Get("Authorization"))
+		assert.NotEmpty(t, request.Header.Get("Idempotency-Key"))
+		assert.Nil(t, request.ParseForm())
+		assert.Equal(t, "order-1", request.PostForm.Get("client_reference_id"))
+		assert.Equal(t, "acme", request.PostForm.Get("metadata[tenant_id]"))
+		assert.Equal(t, "https://shop.example.com/orders/order-1/paid", request.PostForm.Get("success_url"))
+		assert.Equal(t, "idr", request.PostForm.Get("line_items[0][price_data][currency]"))
+		assert.Equal(t, "50000", request.PostForm.Get("line_items[0][price_data][unit_amount]"))
+		assert.Equal(t, "Book", request.PostForm.Get("line_items[0][price_data][product_data][name]"))
+		assert.Equal(t, "2", request.PostForm.Get("line_items[0][quantity]"))
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte(`{"id":"cs_test_1","url":"https://checkout.stripe.com/c/pay/cs_test_1"}`))
+	}))
+	defer server.Close()
+
+	stripe := NewStripe(httpclient.New(config.Default().HTTPClient), config.PaymentConfig{
+		StripeSecretKey: "sk_test",
+		StripeAPIURL:    server.URL,
+		SuccessURL:      "https://shop.example.com/orders/{order_id}/paid",
+		CancelURL:       "https://shop.example.com/orders/{order_id}",
+	})
+	order := &model.Order{ID: "order-1", TenantID: "acme", Currency: "IDR", Items: []model.OrderItem{{Name: "Book", Quantity: 2, UnitPrice: 50000}}}
+	checkout, err := stripe.CreateCheckout(context.Background(), order)
+	assert.Nil(t, err)
+	assert.Equal(t, &model.Checkout{ID: "cs_test_1", URL: "https://checkout.stripe.com/c/pay/cs_test_1"}, checkout)
+}
+
+func TestCreateCheckoutError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write([]byte(`{"error":{"message":"Invalid API Key provided"}}`))
+	}))
+	defer server.Close()
+
+	stripe := NewStripe(httpclient.New(config.Default().HTTPClient), config.PaymentConfig{StripeSecretKey: "sk_wrong", StripeAPIURL: server.URL})
+	_, err := stripe.CreateCheckout(context.Background(), &model.Order{ID: "order-1", Currency: "IDR"})
+	assert.ErrorContains(t, err, "Invalid API Key provided")
+}
+
+func TestParseCheckoutSession(t *testing.T) {
+	session, err := ParseCheckoutSession([]byte(`{"id":"evt_1","type":"checkout.session.completed","data":{"object":{"id":"cs_1","client_reference_id":"order-1","payment_status":"paid","metadata":{"tenant_id":"acme"}}}}`))
+	assert.Nil(t, err)
+	expected := &CheckoutSession{ID: "cs_1", ClientReferenceID: "order-1", PaymentStatus: "paid"}
+	expected.Metadata.TenantID = "acme"
+	assert.Equal(t, expected, session)
+}
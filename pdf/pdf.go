@@ -0,0 +1,229 @@
+// Package pdf writes plain text documents as PDF, with the standard fonts
+// every PDF reader has, so no font needs to be embedded.
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"golang-fiber-web/barcode"
+	"io"
+	"strings"
+)
+
+const (
+	pageWidth  = 595 // A4, in points
+	pageHeight = 842
+	margin     = 50
+)
+
+// The styles of the lines of a document.
+const (
+	Body = iota
+	Heading
+	Mono
+)
+
+type style struct {
+	font    string
+	size    float64
+	leading float64
+	// width is the width of an average character, as a fraction of size, to
+	// wrap the lines at.
+	width float64
+}
+
+var styles = map[int]style{
+	Body:    {font: "F1", size: 11, leading: 15, width: 0.5},
+	Heading: {font: "F2", size: 16, leading: 24, width: 0.55},
+	Mono:    {font: "F3", size: 9.5, leading: 12, width: 0.6},
+}
+
+// codePadding is the space above and below a code, in points.
+const codePadding = 6
+
+type line struct {
+	style int
+	text  string
+	// code, when set, is drawn instead of text, each module moduleWidth by
+	// moduleHeight points.
+	code                      barcode.Symbol
+	moduleWidth, moduleHeight float64
+}
+
+// Document is a list of lines laid out top to bottom on A4 pages, wrapped at
+// the margins. A new page starts when one is full.
+type Document struct {
+	lines []line
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// Add adds text in style, one line per line of text.
+func (document *Document) Add(style int, text string) {
+	for _, text := range strings.Split(text, "\n") {
+		document.lines = append(document.lines, line{style: style, text: text})
+	}
+}
+
+// AddCode adds a QR code or barcode, each of its modules moduleWidth by
+// moduleHeight points.
+func (document *Document) AddCode(code barcode.Symbol, moduleWidth, moduleHeight float64) {
+	document.lines = append(document.lines, line{code: code, moduleWidth: moduleWidth, moduleHeight: moduleHeight})
+}
+
+// WriteTo writes the document as a PDF file.
+func (document *Document) WriteTo(writer io.Writer) (int64, error) {
+	pdf := &pdfWriter{writer: bufio.NewWriter(writer)}
+	pages := document.pages()
+	pdf.write("%PDF-1.4\n")
+
+	// Objects 1 to 5 are the catalog, the page tree and the fonts; every page
+	// is followed by its content.
+	pdf.object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+	pdf.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for _, font := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		pdf.object("<< /Type /Font /Subtype /Type1 /BaseFont /" + font + " /Encoding /WinAnsiEncoding >>")
+	}
+	for i, content := range pages {
+		pdf.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 7+i*2))
+		pdf.object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	pdf.finish()
+	if pdf.err != nil {
+		return pdf.written, pdf.err
+	}
+	return pdf.written, pdf.writer.Flush()
+}
+
+// pages returns the content streams of the pages.
+func (document *Document) pages() []string {
+	var pages []string
+	var content strings.Builder
+	y := float64(pageHeight - margin)
+	for _, line := range document.lines {
+		if line.code != nil {
+			_, rows := line.code.Bounds()
+			if y-float64(rows)*line.moduleHeight-2*codePadding < margin {
+				pages = append(pages, content.String())
+				content.Reset()
+				y = pageHeight - margin
+			}
+			y -= codePadding
+			drawCode(&content, line, y)
+			y -= float64(rows)*line.moduleHeight + codePadding
+			continue
+		}
+		style := styles[line.style]
+		for _, text := range wrap(line.text, int((pageWidth-2*margin)/(style.size*style.width))) {
+			if y-style.leading < margin {
+				pages = append(pages, content.String())
+				content.Reset()
+				y = pageHeight - margin
+			}
+			y -= style.leading
+			if text != "" {
+				fmt.Fprintf(&content, "BT /%s %g Tf %d %g Td (%s) Tj ET\n", style.font, style.size, margin, y, escape(text))
+			}
+		}
+	}
+	return append(pages, content.String())
+}
+
+// drawCode fills a rectangle for each run of dark modules of each row of the
+// code of line, its top left corner at the margin and top.
+func drawCode(content *strings.Builder, line line, top float64) {
+	columns, rows := line.code.Bounds()
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; {
+			if !line.code.Dark(x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < columns && line.code.Dark(x, y) {
+				x++
+			}
+			fmt.Fprintf(content, "%g %g %g %g re\n", margin+float64(start)*line.moduleWidth, top-float64(y+1)*line.moduleHeight,
+				float64(x-start)*line.moduleWidth, line.moduleHeight)
+		}
+	}
+	content.WriteString("f\n")
+}
+
+// wrap splits text into lines of at most width characters, between words
+// when it can.
+func wrap(text string, width int) []string {
+	runes := []rune(text)
+	var lines []string
+	for len(runes) > width {
+		cut := width
+		for i := width; i > 0; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:cut]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	return append(lines, string(runes))
+}
+
+// escape encodes text in WinAnsiEncoding as a PDF string. Characters it
+// cannot encode become question marks.
+func escape(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteByte('\\')
+			escaped.WriteRune(r)
+		case r < 0x20 || r > 0xff || (r >= 0x7f && r < 0xa0):
+			escaped.WriteByte('?')
+		case r < 0x80:
+			escaped.WriteRune(r)
+		default:
+			fmt.Fprintf(&escaped, "\\%03o", r)
+		}
+	}
+	return escaped.String()
+}
+
+// pdfWriter writes numbered objects and the cross-reference table pointing
+// to them.
+type pdfWriter struct {
+	writer  *bufio.Writer
+	offsets []int64
+	written int64
+	err     error
+}
+
+func (pdf *pdfWriter) write(text string) {
+	if pdf.err != nil {
+		return
+	}
+	n, err := pdf.writer.WriteString(text)
+	pdf.written += int64(n)
+	pdf.err = err
+}
+
+func (pdf *pdfWriter) object(body string) {
+	pdf.offsets = append(pdf.offsets, pdf.written)
+	pdf.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", len(pdf.offsets), body))
+}
+
+func (pdf *pdfWriter) finish() {
+	start := pdf.written
+	pdf.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(pdf.offsets)+1))
+	for _, offset := range pdf.offsets {
+		pdf.write(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	pdf.write(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pdf.offsets)+1, start))
+}
@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/barcode"
+	"golang-fiber-web/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTo(t *testing.T) {
+	document := New()
+	document.Add(Heading, "Title (draft)")
+	for i := 0; i < 100; i++ {
+		document.Add(Body, "line")
+	}
+
+	var output bytes.Buffer
+	written, err := document.WriteTo(&output)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(output.Len()), written)
+	content := output.String()
+	assert.True(t, strings.HasPrefix(content, "%PDF-1.4\n"))
+	assert.Contains(t, content, `(Title \(draft\)) Tj`)
+	assert.Contains(t, content, "/Count 3 >>")
+	assert.True(t, strings.HasSuffix(content, "%%EOF\n"))
+
+	// The xref table points at every object.
+	xref := strings.Index(content, "xref\n")
+	for _, entry := range strings.Split(content[xref:], "\n")[3:11] {
+		var offset int
+		_, err := fmt.Sscanf(entry, "%010d", &offset)
+		assert.Nil(t, err)
+		assert.Regexp(t, `^\d+ 0 obj\n`, content[offset:])
+	}
+}
+
+func TestWriteToCode(t *testing.T) {
+	code, err := barcode.EncodeCode128("1234")
+	assert.Nil(t, err)
+	document := New()
+	document.AddCode(code, 1, 36)
+
+	var output bytes.Buffer
+	_, err = document.WriteTo(&output)
+	assert.Nil(t, err)
+	// The start symbol C begins with a bar two modules wide, below the top
+	// margin and the padding.
+	assert.Contains(t, output.String(), "50 750 2 36 re\n")
+	assert.Contains(t, output.String(), "re\nf\n")
+}
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, []string{"one two", "three"}, wrap("one two three", 9))
+	assert.Equal(t, []string{"abcde", "fgh"}, wrap("abcdefgh", 5))
+	assert.Equal(t, []string{""}, wrap("", 5))
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\\b \(c\)`, escape(`a\b (c)`))
+	assert.Equal(t, `caf\351 ?`, escape("café €"))
+}
+
+func TestMoney(t *testing.T) {
+	assert.Equal(t, "IDR 1,050.00", Money(105000, "IDR"))
+	assert.Equal(t, "USD -0.05", Money(-5, "USD"))
+	assert.Equal(t, "JPY 1,234,567", Money(1234567, "JPY"))
+}
+
+func TestRender(t *testing.T) {
+	order := &model.Order{ID: "order-1", Status: model.OrderPaid, Currency: "USD", CreatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	order.SetItems([]model.OrderItem{{Name: "Pen", Quantity: 3, UnitPrice: 250}})
+	document, err := Render("invoice", Invoice{User: &model.User{Username: "brian", Email: "brian@example.com"}, Order: order})
+	assert.Nil(t, err)
+
+	assert.Equal(t, line{style: Heading, text: "Invoice"}, document.lines[0])
+	var text []string
+	var codes int
+	for _, line := range document.lines {
+		text = append(text, line.text)
+		if line.code != nil {
+			codes++
+		}
+	}
+	assert.Equal(t, 1, codes)
+	assert.Contains(t, text, "Billed to: brian <brian@example.com>")
+	assert.Contains(t, strings.Join(text, "\n"), "USD 7.50")
+
+	_, err = Render("missing", nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"golang-fiber-web/barcode"
+	"golang-fiber-web/model"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"money": Money,
+	"date": func(at time.Time) string {
+		return at.UTC().Format("2 Jan 2006")
+	},
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// Invoice is the data of the "invoice" template.
+type Invoice struct {
+	User  *model.User
+	Order *model.Order
+}
+
+// OrdersReport is the data of the "orders_report" template: the orders of
+// User placed From up to To, and their totals by currency.
+type OrdersReport struct {
+	User        *model.User
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+	Orders      []*model.Order
+	Totals      map[string]int64
+}
+
+// zeroDecimalCurrencies are the currencies without a minor unit.
+var zeroDecimalCurrencies = map[string]bool{"JPY": true, "KRW": true, "VND": true, "CLP": true, "PYG": true, "UGX": true}
+
+// Money formats amount, in the smallest unit of currency, such as
+// "IDR 1,050.00".
+func Money(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	units, cents := amount, int64(0)
+	if !zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		units, cents = amount/100, amount%100
+	}
+	digits := fmt.Sprint(units)
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return fmt.Sprintf("%s %s%s", currency, sign, grouped.String())
+	}
+	return fmt.Sprintf("%s %s%s.%02d", currency, sign, grouped.String(), cents)
+}
+
+// Render executes the template name, from templates/, with data and lays
+// out the lines it outputs: a line starting with "# " is a heading, one
+// starting with "| " is set in a fixed-width font, for tables, "@qr " and
+// "@barcode " draw the rest of the line as a QR code or a Code 128 barcode,
+// and any other line is body text.
+func Render(name string, data interface{}) (*Document, error) {
+	var output bytes.Buffer
+	err := templates.ExecuteTemplate(&output, name+".tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	document := New()
+	for _, text := range strings.Split(strings.TrimRight(output.String(), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(text, "# "):
+			document.Add(Heading, text[2:])
+		case strings.HasPrefix(text, "| "):
+			document.Add(Mono, text[2:])
+		case strings.HasPrefix(text, "@qr "):
+			code, err := barcode.EncodeQR([]byte(text[4:]), barcode.LevelM)
+			if err != nil {
+				return nil, err
+			}
+			document.AddCode(code, 2, 2)
+		case strings.HasPrefix(text, "@barcode "):
+			code, err := barcode.EncodeCode128(text[9:])
+			if err != nil {
+				return nil, err
+			}
+			document.AddCode(code, 1, 36)
+		default:
+			document.Add(Body, text)
+		}
+	}
+	return document, nil
+}
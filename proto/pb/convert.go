@@ -0,0 +1,24 @@
+package pb
+
+import (
+	"golang-fiber-web/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromUser converts a user to its message, as served over gRPC and published
+// to the message broker.
+func FromUser(user *model.User) *User {
+	message := &User{
+		Id:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Name:      user.Name,
+		Roles:     user.Roles,
+		Version:   int32(user.Version),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}
+	for _, identity := range user.Identities {
+		message.Identities = append(message.Identities, &Identity{Provider: identity.Provider, Subject: identity.Subject})
+	}
+	return message
+}
@@ -0,0 +1,5 @@
+// Package pb holds the Go code generated from the protobuf definitions in the
+// proto directory.
+package pb
+
+//go:generate protoc -I .. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative user.proto auth.proto event.proto
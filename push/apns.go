@@ -0,0 +1,143 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apnsTokenLifetime is how long a provider token is used. APNs rejects
+// tokens older than an hour and refreshed more often than every 20 minutes.
+const apnsTokenLifetime = time.Minute * 50
+
+// apnsTimeout bounds a push to APNs.
+const apnsTimeout = time.Second * 10
+
+// APNs pushes messages through the Apple Push Notification service,
+// authenticated by provider tokens signed with the .p8 key of the team. APNs
+// only speaks HTTP/2, which fiber.Client does not, so it is called through
+// net/http.
+type APNs struct {
+	client *http.Client
+	config config.PushConfig
+	key    *ecdsa.PrivateKey
+	now    func() time.Time
+
+	mutex    sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNs(config config.PushConfig) (*APNs, error) {
+	data, err := os.ReadFile(config.APNsKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("apns key: %w", err)
+	}
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("apns key: the private key is not an ECDSA key")
+	}
+	return &APNs{client: &http.Client{Timeout: apnsTimeout}, config: config, key: ecdsaKey, now: time.Now}, nil
+}
+
+func (apns *APNs) Send(ctx context.Context, token string, message *Message) error {
+	providerToken, err := apns.providerToken()
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{}
+	for name, value := range message.Data {
+		payload[name] = value
+	}
+	if message.Link != "" {
+		payload["link"] = message.Link
+	}
+	payload["aps"] = fiber.Map{"alert": fiber.Map{"title": message.Title, "body": message.Body}, "sound": "default"}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(apns.config.APNsAPIURL, "/") + "/3/device/" + token
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "bearer "+providerToken)
+	request.Header.Set("apns-topic", apns.config.APNsTopic)
+	request.Header.Set("apns-push-type", "alert")
+	request.Header.Set("apns-priority", "10")
+	response, err := apns.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+	failure := struct {
+		Reason string `json:"reason"`
+	}{}
+	json.Unmarshal(data, &failure)
+	switch {
+	case response.StatusCode == http.StatusGone, failure.Reason == "BadDeviceToken", failure.Reason == "DeviceTokenNotForTopic":
+		return ErrUnregistered
+	case failure.Reason == "ExpiredProviderToken":
+		apns.mutex.Lock()
+		apns.token = ""
+		apns.mutex.Unlock()
+	}
+	return fmt.Errorf("apns answered %d: %s", response.StatusCode, failure.Reason)
+}
+
+// providerToken returns the provider token, signing a new one once the
+// current one is apnsTokenLifetime old.
+func (apns *APNs) providerToken() (string, error) {
+	apns.mutex.Lock()
+	defer apns.mutex.Unlock()
+
+	now := apns.now()
+	if apns.token != "" && now.Sub(apns.issuedAt) < apnsTokenLifetime {
+		return apns.token, nil
+	}
+
+	header, err := encodeSegment(fiber.Map{"alg": "ES256", "kid": apns.config.APNsKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := encodeSegment(fiber.Map{"iss": apns.config.APNsTeamID, "iat": now.Unix()})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + claims
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, apns.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	apns.token, apns.issuedAt = signed+"."+base64.RawURLEncoding.EncodeToString(signature), now
+	return apns.token, nil
+}
@@ -0,0 +1,73 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPNs(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "bearer ")
+		assert.True(t, ok)
+		parts := strings.Split(token, ".")
+		assert.Len(t, parts, 3)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		assert.Nil(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])))
+		assert.Equal(t, "com.example.shop", request.Header.Get("apns-topic"))
+
+		switch request.URL.Path {
+		case "/3/device/gone":
+			writer.WriteHeader(http.StatusGone)
+			writer.Write([]byte(`{"reason":"Unregistered"}`))
+		case "/3/device/throttled":
+			writer.WriteHeader(http.StatusTooManyRequests)
+			writer.Write([]byte(`{"reason":"TooManyRequests"}`))
+		case "/3/device/device-token":
+			payload := struct {
+				Aps struct {
+					Alert map[string]string `json:"alert"`
+				} `json:"aps"`
+				Link string `json:"link"`
+			}{}
+			assert.Nil(t, json.NewDecoder(request.Body).Decode(&payload))
+			assert.Equal(t, "Order placed", payload.Aps.Alert["title"])
+			assert.Equal(t, "/orders/1", payload.Link)
+		default:
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		}
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "AuthKey.p8")
+	assert.Nil(t, os.WriteFile(file, []byte(writePEMKey(t, key)), 0o600))
+	apns, err := NewAPNs(config.PushConfig{APNsKeyFile: file, APNsKeyID: "KEY123", APNsTeamID: "TEAM123", APNsTopic: "com.example.shop", APNsAPIURL: server.URL})
+	assert.Nil(t, err)
+
+	message := &Message{Title: "Order placed", Body: "Your order has been placed.", Link: "/orders/1"}
+	assert.Nil(t, apns.Send(context.Background(), "device-token", message))
+	assert.ErrorIs(t, apns.Send(context.Background(), "gone", message), ErrUnregistered)
+	assert.ErrorIs(t, apns.Send(context.Background(), "malformed", message), ErrUnregistered)
+	err = apns.Send(context.Background(), "throttled", message)
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrUnregistered)
+}
@@ -0,0 +1,174 @@
+package push
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// tokenRefreshMargin is how long before it expires an access token is
+// replaced.
+const tokenRefreshMargin = time.Minute * 5
+
+// serviceAccount is the part of the JSON key of a Google service account
+// needed to get access tokens.
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCM pushes messages through the HTTP v1 API of Firebase Cloud Messaging,
+// authenticated by OAuth access tokens of a service account.
+type FCM struct {
+	client  *httpclient.Client
+	config  config.PushConfig
+	account serviceAccount
+	key     *rsa.PrivateKey
+	now     func() time.Time
+
+	mutex       sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCM(client *httpclient.Client, config config.PushConfig) (*FCM, error) {
+	data, err := os.ReadFile(config.FCMCredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	account := serviceAccount{}
+	err = json.Unmarshal(data, &account)
+	if err != nil {
+		return nil, fmt.Errorf("fcm credentials: %w", err)
+	}
+	key, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("fcm credentials: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("fcm credentials: the private key is not an RSA key")
+	}
+	return &FCM{client: client, config: config, account: account, key: rsaKey, now: time.Now}, nil
+}
+
+func (fcm *FCM) Send(ctx context.Context, token string, message *Message) error {
+	accessToken, err := fcm.token(ctx)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{}
+	for name, value := range message.Data {
+		data[name] = value
+	}
+	if message.Link != "" {
+		data["link"] = message.Link
+	}
+	body := fiber.Map{"message": fiber.Map{
+		"token":        token,
+		"notification": fiber.Map{"title": message.Title, "body": message.Body},
+		"data":         data,
+	}}
+
+	url := strings.TrimSuffix(fcm.config.FCMAPIURL, "/") + "/v1/projects/" + fcm.config.FCMProjectID + "/messages:send"
+	response, err := fcm.client.Post(ctx, url, func(agent *fiber.Agent) {
+		agent.JSON(body).Set(fiber.HeaderAuthorization, "Bearer "+accessToken)
+	})
+	if err != nil {
+		return err
+	}
+	if response.Status == fiber.StatusOK {
+		return nil
+	}
+	if response.Status == fiber.StatusUnauthorized {
+		fcm.mutex.Lock()
+		fcm.accessToken = ""
+		fcm.mutex.Unlock()
+	}
+	if response.Status == fiber.StatusNotFound || strings.Contains(string(response.Body), "UNREGISTERED") {
+		return ErrUnregistered
+	}
+	return fmt.Errorf("fcm answered %d: %s", response.Status, response.Body)
+}
+
+// token returns an access token, getting a new one from the token URI of
+// the service account once the current one is about to expire.
+func (fcm *FCM) token(ctx context.Context) (string, error) {
+	fcm.mutex.Lock()
+	defer fcm.mutex.Unlock()
+
+	now := fcm.now()
+	if fcm.accessToken != "" && now.Add(tokenRefreshMargin).Before(fcm.expiresAt) {
+		return fcm.accessToken, nil
+	}
+
+	assertion, err := fcm.assertion(now)
+	if err != nil {
+		return "", err
+	}
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+	args.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	args.Set("assertion", assertion)
+	response, err := fcm.client.Post(ctx, fcm.account.TokenURI, func(agent *fiber.Agent) {
+		agent.Form(args)
+	})
+	if err != nil {
+		return "", err
+	}
+	if response.Status != fiber.StatusOK {
+		return "", fmt.Errorf("fcm token endpoint answered %d: %s", response.Status, response.Body)
+	}
+	granted := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	err = json.Unmarshal(response.Body, &granted)
+	if err != nil {
+		return "", err
+	}
+	fcm.accessToken, fcm.expiresAt = granted.AccessToken, now.Add(time.Duration(granted.ExpiresIn)*time.Second)
+	return fcm.accessToken, nil
+}
+
+// assertion is the JSON Web Token, signed with the key of the service
+// account, exchanged for an access token.
+func (fcm *FCM) assertion(now time.Time) (string, error) {
+	header, err := encodeSegment(fiber.Map{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := encodeSegment(fiber.Map{
+		"iss":   fcm.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   fcm.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + claims
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, fcm.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,87 @@
+package push
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePEMKey(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestFCM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	tokens, sends := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/token":
+			tokens++
+			assert.Nil(t, request.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", request.PostForm.Get("grant_type"))
+			parts := strings.Split(request.PostForm.Get("assertion"), ".")
+			assert.Len(t, parts, 3)
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			assert.Nil(t, err)
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			assert.Nil(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+			writer.Write([]byte(`{"access_token":"access-token","expires_in":3600}`))
+		case "/v1/projects/shop/messages:send":
+			sends++
+			assert.Equal(t, "Bearer access-token", request.Header.Get("Authorization"))
+			body := struct {
+				Message struct {
+					Token        string            `json:"token"`
+					Notification map[string]string `json:"notification"`
+					Data         map[string]string `json:"data"`
+				} `json:"message"`
+			}{}
+			assert.Nil(t, json.NewDecoder(request.Body).Decode(&body))
+			if body.Message.Token == "gone" {
+				writer.WriteHeader(http.StatusNotFound)
+				writer.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+				return
+			}
+			assert.Equal(t, "device-token", body.Message.Token)
+			assert.Equal(t, "Order placed", body.Message.Notification["title"])
+			assert.Equal(t, "/orders/1", body.Message.Data["link"])
+			writer.Write([]byte(`{"name":"projects/shop/messages/1"}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	credentials, err := json.Marshal(serviceAccount{ClientEmail: "push@shop.iam.gserviceaccount.com", PrivateKey: writePEMKey(t, key), TokenURI: server.URL + "/token"})
+	assert.Nil(t, err)
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	assert.Nil(t, os.WriteFile(file, credentials, 0o600))
+
+	fcm, err := NewFCM(httpclient.New(config.Default().HTTPClient), config.PushConfig{FCMProjectID: "shop", FCMCredentialsFile: file, FCMAPIURL: server.URL})
+	assert.Nil(t, err)
+	message := &Message{Title: "Order placed", Body: "Your order has been placed.", Link: "/orders/1"}
+	assert.Nil(t, fcm.Send(context.Background(), "device-token", message))
+	assert.Nil(t, fcm.Send(context.Background(), "device-token", message))
+	assert.ErrorIs(t, fcm.Send(context.Background(), "gone", message), ErrUnregistered)
+	assert.Equal(t, 1, tokens)
+	assert.Equal(t, 3, sends)
+}
@@ -0,0 +1,47 @@
+package push
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+)
+
+// ErrUnregistered is returned for a token the push service no longer
+// delivers to, as the app was uninstalled or the token expired. The device
+// of the token should be forgotten.
+var ErrUnregistered = errors.New("device token is not registered")
+
+// Message is a notification pushed to a device. Link and Data reach the app
+// along with it.
+type Message struct {
+	Title string
+	Body  string
+	Link  string
+	Data  map[string]string
+}
+
+// Sender pushes messages to the devices of one push service.
+type Sender interface {
+	Send(ctx context.Context, token string, message *Message) error
+}
+
+// parsePrivateKey reads the PKCS #8 private key of a PEM block.
+func parsePrivateKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM private key found")
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// encodeSegment encodes a header or the claims of a JSON Web Token.
+func encodeSegment(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
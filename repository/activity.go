@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ActivityRepository stores the timelines of the users.
+type ActivityRepository interface {
+	Create(ctx context.Context, activity *model.Activity) error
+	// List lists the activities of the user, only the public ones when
+	// public is set.
+	List(ctx context.Context, userID string, public bool, spec *model.ListSpec) ([]*model.Activity, int, error)
+}
+
+var defaultActivitySort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryActivityRepository struct {
+	mutex      sync.RWMutex
+	activities map[string]model.Activity
+}
+
+func NewMemoryActivityRepository() ActivityRepository {
+	return &memoryActivityRepository{activities: map[string]model.Activity{}}
+}
+
+func (repository *memoryActivityRepository) Create(ctx context.Context, activity *model.Activity) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if activity.ID == "" {
+		activity.ID = uuid.NewString()
+	}
+	if activity.CreatedAt.IsZero() {
+		activity.CreatedAt = time.Now()
+	}
+	repository.activities[activity.ID] = *activity
+	return nil
+}
+
+func (repository *memoryActivityRepository) List(ctx context.Context, userID string, public bool, spec *model.ListSpec) ([]*model.Activity, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var activities []*model.Activity
+	for _, activity := range repository.activities {
+		if activity.UserID == userID && (!public || activity.Public) && matchesFilters(activityFields(&activity), spec.Filters) &&
+			matchesSearch(spec.Search, activity.Summary) {
+			activities = append(activities, &activity)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultActivitySort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(activities, func(i, j int) bool {
+		left, right := activityFields(activities[i]), activityFields(activities[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(activities)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return activities[start:end], total, nil
+}
+
+func activityFields(activity *model.Activity) map[string]string {
+	return map[string]string{
+		"id":          activity.ID,
+		"kind":        activity.Kind,
+		"resource":    activity.Resource,
+		"resource_id": activity.ResourceID,
+		"created_at":  activity.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const activitySelect = "id, user_id, kind, resource, resource_id, summary, link, public, created_at"
+
+var activityColumns = map[string]string{
+	"id":          "id",
+	"kind":        "kind",
+	"resource":    "resource",
+	"resource_id": "resource_id",
+	"created_at":  "created_at",
+}
+
+type postgresActivityRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresActivityRepository(db *database.Cluster) ActivityRepository {
+	return &postgresActivityRepository{db: db}
+}
+
+func (repository *postgresActivityRepository) Create(ctx context.Context, activity *model.Activity) error {
+	if activity.ID == "" {
+		activity.ID = uuid.NewString()
+	}
+	if activity.CreatedAt.IsZero() {
+		activity.CreatedAt = time.Now()
+	}
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO activities ("+activitySelect+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		activity.ID, activity.UserID, activity.Kind, activity.Resource, activity.ResourceID, activity.Summary, activity.Link, activity.Public, activity.CreatedAt)
+	return err
+}
+
+func (repository *postgresActivityRepository) List(ctx context.Context, userID string, public bool, spec *model.ListSpec) ([]*model.Activity, int, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, 0, nil
+	}
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	if public {
+		conditions = append(conditions, "public")
+	}
+	for field, value := range spec.Filters {
+		column, ok := activityColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+"::TEXT) = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		conditions = append(conditions, "summary ILIKE $"+strconv.Itoa(len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM activities"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultActivitySort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := activityColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT "+activitySelect+" FROM activities"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var activities []*model.Activity
+	for rows.Next() {
+		activity := &model.Activity{}
+		err = rows.Scan(&activity.ID, &activity.UserID, &activity.Kind, &activity.Resource, &activity.ResourceID, &activity.Summary, &activity.Link,
+			&activity.Public, &activity.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, total, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// APIKeyRepository stores the API keys of the users. FindByHash finds a key
+// by the SHA-256 hash of its value, which is all that is stored of it.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	FindByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	List(ctx context.Context, userID string) ([]*model.APIKey, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type memoryAPIKeyRepository struct {
+	mutex sync.RWMutex
+	keys  map[string]model.APIKey
+}
+
+func NewMemoryAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepository{keys: map[string]model.APIKey{}}
+}
+
+func (repository *memoryAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	prepareAPIKey(key)
+	repository.keys[key.ID] = *key
+	return nil
+}
+
+func (repository *memoryAPIKeyRepository) FindByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	for _, key := range repository.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, model.ErrAPIKeyNotFound
+}
+
+func (repository *memoryAPIKeyRepository) List(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	keys := []*model.APIKey{}
+	for _, key := range repository.keys {
+		if key.UserID == userID {
+			keys = append(keys, &key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (repository *memoryAPIKeyRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.keys[id]; !ok {
+		return model.ErrAPIKeyNotFound
+	}
+	delete(repository.keys, id)
+	return nil
+}
+
+func prepareAPIKey(key *model.APIKey) {
+	if key.ID == "" {
+		key.ID = uuid.NewString()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+}
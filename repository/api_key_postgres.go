@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+)
+
+const apiKeySelect = "id, user_id, name, prefix, hash, created_at"
+
+type postgresAPIKeyRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresAPIKeyRepository(db *database.Cluster) APIKeyRepository {
+	return &postgresAPIKeyRepository{db: db}
+}
+
+func (repository *postgresAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	prepareAPIKey(key)
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO api_keys ("+apiKeySelect+") VALUES ($1, $2, $3, $4, $5, $6)",
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.CreatedAt)
+	return err
+}
+
+func (repository *postgresAPIKeyRepository) FindByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	keys, err := repository.query(ctx, "SELECT "+apiKeySelect+" FROM api_keys WHERE hash = $1", hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, model.ErrAPIKeyNotFound
+	}
+	return keys[0], nil
+}
+
+func (repository *postgresAPIKeyRepository) List(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return []*model.APIKey{}, nil
+	}
+	return repository.query(ctx, "SELECT "+apiKeySelect+" FROM api_keys WHERE user_id = $1 ORDER BY created_at, id", userID)
+}
+
+func (repository *postgresAPIKeyRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrAPIKeyNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (repository *postgresAPIKeyRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.APIKey, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		key := &model.APIKey{}
+		err = rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AuditRepository is an append-only store of audit events. Create records the
+// tenant of ctx and List only sees that tenant's events, newest first unless
+// spec says otherwise.
+type AuditRepository interface {
+	Create(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, spec *model.ListSpec) ([]*model.AuditEvent, int, error)
+}
+
+var defaultAuditSort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryAuditRepository struct {
+	mutex  sync.RWMutex
+	events []model.AuditEvent
+}
+
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (repository *memoryAuditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.TenantID = tenant.From(ctx)
+	repository.events = append(repository.events, *event)
+	return nil
+}
+
+func (repository *memoryAuditRepository) List(ctx context.Context, spec *model.ListSpec) ([]*model.AuditEvent, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var events []*model.AuditEvent
+	for _, event := range repository.events {
+		if event.TenantID == tenant.From(ctx) && matchesFilters(auditFields(&event), spec.Filters) {
+			events = append(events, &event)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultAuditSort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(events, func(i, j int) bool {
+		left, right := auditFields(events[i]), auditFields(events[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(events)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return events[start:end], total, nil
+}
+
+func auditFields(event *model.AuditEvent) map[string]string {
+	return map[string]string{
+		"id":          event.ID,
+		"actor":       event.Actor,
+		"action":      event.Action,
+		"resource":    event.Resource,
+		"resource_id": event.ResourceID,
+		"created_at":  event.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
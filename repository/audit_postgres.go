@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var auditColumns = map[string]string{
+	"id":          "id",
+	"actor":       "actor",
+	"action":      "action",
+	"resource":    "resource",
+	"resource_id": "resource_id",
+	"created_at":  "created_at",
+}
+
+type postgresAuditRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresAuditRepository(db *database.Cluster) AuditRepository {
+	return &postgresAuditRepository{db: db}
+}
+
+func (repository *postgresAuditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.TenantID = tenant.From(ctx)
+
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, repository.db).ExecContext(ctx, `INSERT INTO audit_events
+(id, tenant_id, actor, action, resource, resource_id, changes, ip, request_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		event.ID, event.TenantID, event.Actor, event.Action, event.Resource, event.ResourceID, changes, event.IP, event.RequestID, event.CreatedAt)
+	return err
+}
+
+func (repository *postgresAuditRepository) List(ctx context.Context, spec *model.ListSpec) ([]*model.AuditEvent, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenant.From(ctx)}
+	for field, value := range spec.Filters {
+		column, ok := auditColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+") = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultAuditSort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := auditColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT id, tenant_id, actor, action, resource, resource_id, changes, ip, request_id, created_at FROM audit_events"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var events []*model.AuditEvent
+	for rows.Next() {
+		event := &model.AuditEvent{}
+		var changes []byte
+		err = rows.Scan(&event.ID, &event.TenantID, &event.Actor, &event.Action, &event.Resource, &event.ResourceID, &changes, &event.IP, &event.RequestID, &event.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = json.Unmarshal(changes, &event.Changes)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	return events, total, rows.Err()
+}
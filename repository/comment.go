@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CommentRepository stores the comments on the resources, and their replies.
+// It only sees and creates the comments of the tenant of ctx.
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	FindByID(ctx context.Context, id string) (*model.Comment, error)
+	// List lists the visible comments on the resource itself, without their
+	// replies.
+	List(ctx context.Context, resource, resourceID string, spec *model.ListSpec) ([]*model.Comment, int, error)
+	// Replies lists the visible replies to the comments with parentIDs,
+	// oldest first.
+	Replies(ctx context.Context, parentIDs []string) ([]*model.Comment, error)
+	// Rating aggregates the ratings of the visible comments on the resource.
+	Rating(ctx context.Context, resource, resourceID string) (*model.RatingSummary, error)
+	// HasRated tells whether the user rated the resource, whatever the
+	// status of their comment.
+	HasRated(ctx context.Context, resource, resourceID, userID string) (bool, error)
+	// Flag counts the flag of the user on the comment, once per user, and
+	// has it flagged once threshold users flagged it while it was visible.
+	Flag(ctx context.Context, id, userID string, threshold int, at time.Time) (*model.Comment, error)
+	SetStatus(ctx context.Context, id, status string, at time.Time) error
+	// Delete deletes the comment along with its replies.
+	Delete(ctx context.Context, id string) error
+}
+
+var defaultCommentSort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryCommentRepository struct {
+	mutex    sync.RWMutex
+	comments map[string]model.Comment
+}
+
+func NewMemoryCommentRepository() CommentRepository {
+	return &memoryCommentRepository{comments: map[string]model.Comment{}}
+}
+
+func (repository *memoryCommentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if comment.ID == "" {
+		comment.ID = uuid.NewString()
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+	comment.UpdatedAt = comment.CreatedAt
+	comment.TenantID = tenant.From(ctx)
+	stored := *comment
+	stored.Replies = nil
+	stored.FlaggedBy = slices.Clone(comment.FlaggedBy)
+	repository.comments[comment.ID] = stored
+	return nil
+}
+
+func (repository *memoryCommentRepository) FindByID(ctx context.Context, id string) (*model.Comment, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	comment, ok := repository.comments[id]
+	if !ok || comment.TenantID != tenant.From(ctx) {
+		return nil, model.ErrCommentNotFound
+	}
+	comment.FlaggedBy = slices.Clone(comment.FlaggedBy)
+	return &comment, nil
+}
+
+func (repository *memoryCommentRepository) List(ctx context.Context, resource, resourceID string, spec *model.ListSpec) ([]*model.Comment, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var comments []*model.Comment
+	for _, comment := range repository.comments {
+		if comment.TenantID == tenant.From(ctx) && comment.Resource == resource && comment.ResourceID == resourceID && comment.ParentID == "" && comment.Status == model.CommentVisible &&
+			matchesFilters(commentFields(&comment), spec.Filters) && matchesSearch(spec.Search, comment.Body) {
+			comment.FlaggedBy = slices.Clone(comment.FlaggedBy)
+			comments = append(comments, &comment)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultCommentSort
+	}
+	sortComments(comments, sortFields)
+
+	total := len(comments)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return comments[start:end], total, nil
+}
+
+func (repository *memoryCommentRepository) Replies(ctx context.Context, parentIDs []string) ([]*model.Comment, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var replies []*model.Comment
+	for _, comment := range repository.comments {
+		if comment.TenantID == tenant.From(ctx) && comment.ParentID != "" && comment.Status == model.CommentVisible && slices.Contains(parentIDs, comment.ParentID) {
+			comment.FlaggedBy = slices.Clone(comment.FlaggedBy)
+			replies = append(replies, &comment)
+		}
+	}
+	sortComments(replies, []model.SortField{{Field: "created_at"}})
+	return replies, nil
+}
+
+func (repository *memoryCommentRepository) Rating(ctx context.Context, resource, resourceID string) (*model.RatingSummary, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	summary := &model.RatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	sum := 0
+	for _, comment := range repository.comments {
+		if comment.TenantID == tenant.From(ctx) && comment.Resource == resource && comment.ResourceID == resourceID && comment.Status == model.CommentVisible &&
+			comment.Rating > 0 {
+			summary.Count++
+			summary.Distribution[comment.Rating]++
+			sum += comment.Rating
+		}
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(sum) / float64(summary.Count)
+	}
+	return summary, nil
+}
+
+func (repository *memoryCommentRepository) HasRated(ctx context.Context, resource, resourceID, userID string) (bool, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	for _, comment := range repository.comments {
+		if comment.TenantID == tenant.From(ctx) && comment.Resource == resource && comment.ResourceID == resourceID && comment.UserID == userID &&
+			comment.Rating > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (repository *memoryCommentRepository) Flag(ctx context.Context, id, userID string, threshold int, at time.Time) (*model.Comment, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	comment, ok := repository.comments[id]
+	if !ok || comment.TenantID != tenant.From(ctx) {
+		return nil, model.ErrCommentNotFound
+	}
+	if !slices.Contains(comment.FlaggedBy, userID) {
+		comment.FlaggedBy = append(slices.Clone(comment.FlaggedBy), userID)
+		comment.Flags++
+		if comment.Status == model.CommentVisible && comment.Flags >= threshold {
+			comment.Status = model.CommentFlagged
+		}
+		comment.UpdatedAt = at
+		repository.comments[id] = comment
+	}
+	comment.FlaggedBy = slices.Clone(comment.FlaggedBy)
+	return &comment, nil
+}
+
+func (repository *memoryCommentRepository) SetStatus(ctx context.Context, id, status string, at time.Time) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	comment, ok := repository.comments[id]
+	if !ok || comment.TenantID != tenant.From(ctx) {
+		return model.ErrCommentNotFound
+	}
+	comment.Status = status
+	comment.UpdatedAt = at
+	repository.comments[id] = comment
+	return nil
+}
+
+func (repository *memoryCommentRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if comment, ok := repository.comments[id]; !ok || comment.TenantID != tenant.From(ctx) {
+		return model.ErrCommentNotFound
+	}
+	deleted := []string{id}
+	for len(deleted) > 0 {
+		parentID := deleted[0]
+		deleted = deleted[1:]
+		delete(repository.comments, parentID)
+		for replyID, comment := range repository.comments {
+			if comment.ParentID == parentID {
+				deleted = append(deleted, replyID)
+			}
+		}
+	}
+	return nil
+}
+
+func sortComments(comments []*model.Comment, sortFields []model.SortField) {
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(comments, func(i, j int) bool {
+		left, right := commentFields(comments[i]), commentFields(comments[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+}
+
+func commentFields(comment *model.Comment) map[string]string {
+	return map[string]string{
+		"id":         comment.ID,
+		"user_id":    comment.UserID,
+		"rating":     fmt.Sprintf("%d", comment.Rating),
+		"created_at": comment.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
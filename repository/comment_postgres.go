@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const commentSelect = "id, tenant_id, resource, resource_id, user_id, COALESCE(parent_id::TEXT, ''), depth, body, rating, status, flags, flagged_by, created_at, updated_at"
+
+var commentColumns = map[string]string{
+	"id":         "id",
+	"user_id":    "user_id",
+	"rating":     "rating",
+	"created_at": "created_at",
+}
+
+type postgresCommentRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresCommentRepository(db *database.Cluster) CommentRepository {
+	return &postgresCommentRepository{db: db}
+}
+
+func (repository *postgresCommentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	if comment.ID == "" {
+		comment.ID = uuid.NewString()
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+	comment.UpdatedAt = comment.CreatedAt
+	comment.TenantID = tenant.From(ctx)
+	flaggedBy, err := json.Marshal(append([]string{}, comment.FlaggedBy...))
+	if err != nil {
+		return err
+	}
+	var parentID interface{}
+	if comment.ParentID != "" {
+		parentID = comment.ParentID
+	}
+	_, err = conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO comments (id, tenant_id, resource, resource_id, user_id, parent_id, depth, body, rating, status, flags, flagged_by, created_at, updated_at) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		comment.ID, comment.TenantID, comment.Resource, comment.ResourceID, comment.UserID, parentID, comment.Depth, comment.Body, comment.Rating, comment.Status,
+		comment.Flags, flaggedBy, comment.CreatedAt, comment.UpdatedAt)
+	return err
+}
+
+func (repository *postgresCommentRepository) FindByID(ctx context.Context, id string) (*model.Comment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrCommentNotFound
+	}
+	comments, err := queryComments(ctx, reader(ctx, repository.db), "SELECT "+commentSelect+" FROM comments WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, model.ErrCommentNotFound
+	}
+	return comments[0], nil
+}
+
+func (repository *postgresCommentRepository) List(ctx context.Context, resource, resourceID string, spec *model.ListSpec) ([]*model.Comment, int, error) {
+	conditions := []string{"resource = $1", "resource_id = $2", "tenant_id = $3", "parent_id IS NULL", "status = '" + model.CommentVisible + "'"}
+	args := []interface{}{resource, resourceID, tenant.From(ctx)}
+	for field, value := range spec.Filters {
+		column, ok := commentColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+"::TEXT) = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		conditions = append(conditions, "body ILIKE $"+strconv.Itoa(len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM comments"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultCommentSort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := commentColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	result, err := queryComments(ctx, reader(ctx, repository.db), "SELECT "+commentSelect+" FROM comments"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+func (repository *postgresCommentRepository) Replies(ctx context.Context, parentIDs []string) ([]*model.Comment, error) {
+	var args []interface{}
+	var placeholders []string
+	for _, id := range parentIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			continue
+		}
+		args = append(args, id)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	if len(placeholders) == 0 {
+		return nil, nil
+	}
+	args = append(args, tenant.From(ctx))
+	return queryComments(ctx, reader(ctx, repository.db), "SELECT "+commentSelect+" FROM comments WHERE parent_id IN ("+strings.Join(placeholders, ", ")+
+		") AND tenant_id = $"+strconv.Itoa(len(args))+" AND status = '"+model.CommentVisible+"' ORDER BY created_at, id", args...)
+}
+
+func (repository *postgresCommentRepository) Rating(ctx context.Context, resource, resourceID string) (*model.RatingSummary, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT rating, COUNT(*) FROM comments WHERE resource = $1 AND resource_id = $2 AND tenant_id = $3 AND status = $4 AND rating > 0 GROUP BY rating",
+		resource, resourceID, tenant.From(ctx), model.CommentVisible)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &model.RatingSummary{Distribution: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	sum := 0
+	for rows.Next() {
+		var rating, count int
+		err = rows.Scan(&rating, &count)
+		if err != nil {
+			return nil, err
+		}
+		summary.Distribution[rating] = count
+		summary.Count += count
+		sum += rating * count
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(sum) / float64(summary.Count)
+	}
+	return summary, rows.Err()
+}
+
+func (repository *postgresCommentRepository) HasRated(ctx context.Context, resource, resourceID, userID string) (bool, error) {
+	var rated bool
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM comments WHERE resource = $1 AND resource_id = $2 AND user_id = $3 AND tenant_id = $4 AND rating > 0)",
+		resource, resourceID, userID, tenant.From(ctx)).Scan(&rated)
+	return rated, err
+}
+
+func (repository *postgresCommentRepository) Flag(ctx context.Context, id, userID string, threshold int, at time.Time) (*model.Comment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrCommentNotFound
+	}
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "UPDATE comments SET flagged_by = flagged_by || to_jsonb($2::TEXT), flags = flags + 1, "+
+		"status = CASE WHEN status = $3 AND flags + 1 >= $4 THEN $5 ELSE status END, updated_at = $6 WHERE id = $1 AND tenant_id = $7 AND NOT flagged_by ? $2",
+		id, userID, model.CommentVisible, threshold, model.CommentFlagged, at, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return repository.FindByID(ctx, id)
+}
+
+func (repository *postgresCommentRepository) SetStatus(ctx context.Context, id, status string, at time.Time) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrCommentNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "UPDATE comments SET status = $2, updated_at = $3 WHERE id = $1 AND tenant_id = $4", id, status, at, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrCommentNotFound
+	}
+	return nil
+}
+
+func (repository *postgresCommentRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrCommentNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM comments WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrCommentNotFound
+	}
+	return nil
+}
+
+func queryComments(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.Comment, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*model.Comment
+	for rows.Next() {
+		comment := &model.Comment{}
+		var flaggedBy []byte
+		err = rows.Scan(&comment.ID, &comment.TenantID, &comment.Resource, &comment.ResourceID, &comment.UserID, &comment.ParentID, &comment.Depth, &comment.Body,
+			&comment.Rating, &comment.Status, &comment.Flags, &flaggedBy, &comment.CreatedAt, &comment.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(flaggedBy, &comment.FlaggedBy)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeviceRepository stores the devices notifications are pushed to. A token
+// belongs to one device, of the user who registered it last.
+type DeviceRepository interface {
+	// Register creates the device or, when its platform and token are
+	// already registered, moves that device to its user and renames it,
+	// filling device with the stored ID and CreatedAt.
+	Register(ctx context.Context, device *model.Device) error
+	FindByID(ctx context.Context, id string) (*model.Device, error)
+	// FindByUser lists the devices of the user, oldest first.
+	FindByUser(ctx context.Context, userID string) ([]*model.Device, error)
+	Delete(ctx context.Context, id string) error
+	// DeleteByToken deletes the device of the token, if any.
+	DeleteByToken(ctx context.Context, platform, token string) error
+}
+
+type memoryDeviceRepository struct {
+	mutex   sync.RWMutex
+	devices map[string]model.Device
+}
+
+func NewMemoryDeviceRepository() DeviceRepository {
+	return &memoryDeviceRepository{devices: map[string]model.Device{}}
+}
+
+func (repository *memoryDeviceRepository) Register(ctx context.Context, device *model.Device) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	now := time.Now()
+	device.ID, device.CreatedAt, device.UpdatedAt = uuid.NewString(), now, now
+	for _, existing := range repository.devices {
+		if existing.Platform == device.Platform && existing.Token == device.Token {
+			device.ID, device.CreatedAt = existing.ID, existing.CreatedAt
+		}
+	}
+	repository.devices[device.ID] = *device
+	return nil
+}
+
+func (repository *memoryDeviceRepository) FindByID(ctx context.Context, id string) (*model.Device, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	device, ok := repository.devices[id]
+	if !ok {
+		return nil, model.ErrDeviceNotFound
+	}
+	return &device, nil
+}
+
+func (repository *memoryDeviceRepository) FindByUser(ctx context.Context, userID string) ([]*model.Device, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var devices []*model.Device
+	for _, device := range repository.devices {
+		if device.UserID == userID {
+			devices = append(devices, &device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].CreatedAt.Equal(devices[j].CreatedAt) {
+			return devices[i].CreatedAt.Before(devices[j].CreatedAt)
+		}
+		return devices[i].ID < devices[j].ID
+	})
+	return devices, nil
+}
+
+func (repository *memoryDeviceRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.devices[id]; !ok {
+		return model.ErrDeviceNotFound
+	}
+	delete(repository.devices, id)
+	return nil
+}
+
+func (repository *memoryDeviceRepository) DeleteByToken(ctx context.Context, platform, token string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for id, device := range repository.devices {
+		if device.Platform == platform && device.Token == token {
+			delete(repository.devices, id)
+		}
+	}
+	return nil
+}
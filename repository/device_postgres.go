@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+const deviceSelect = "id, user_id, platform, token, name, created_at, updated_at"
+
+type postgresDeviceRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresDeviceRepository(db *database.Cluster) DeviceRepository {
+	return &postgresDeviceRepository{db: db}
+}
+
+func (repository *postgresDeviceRepository) Register(ctx context.Context, device *model.Device) error {
+	now := time.Now()
+	device.UpdatedAt = now
+	return conn(ctx, repository.db).QueryRowContext(ctx, `INSERT INTO devices (`+deviceSelect+`) VALUES ($1, $2, $3, $4, $5, $6, $6)
+ON CONFLICT (platform, token) DO UPDATE SET user_id = EXCLUDED.user_id, name = EXCLUDED.name, updated_at = EXCLUDED.updated_at
+RETURNING id, created_at`, uuid.NewString(), device.UserID, device.Platform, device.Token, device.Name, now).Scan(&device.ID, &device.CreatedAt)
+}
+
+func (repository *postgresDeviceRepository) FindByID(ctx context.Context, id string) (*model.Device, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrDeviceNotFound
+	}
+	devices, err := queryDevices(ctx, reader(ctx, repository.db), "SELECT "+deviceSelect+" FROM devices WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, model.ErrDeviceNotFound
+	}
+	return devices[0], nil
+}
+
+func (repository *postgresDeviceRepository) FindByUser(ctx context.Context, userID string) ([]*model.Device, error) {
+	return queryDevices(ctx, reader(ctx, repository.db), "SELECT "+deviceSelect+" FROM devices WHERE user_id = $1 ORDER BY created_at, id", userID)
+}
+
+func (repository *postgresDeviceRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrDeviceNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM devices WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (repository *postgresDeviceRepository) DeleteByToken(ctx context.Context, platform, token string) error {
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM devices WHERE platform = $1 AND token = $2", platform, token)
+	return err
+}
+
+func queryDevices(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.Device, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*model.Device
+	for rows.Next() {
+		device := &model.Device{}
+		err = rows.Scan(&device.ID, &device.UserID, &device.Platform, &device.Token, &device.Name, &device.CreatedAt, &device.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"sync"
+	"time"
+)
+
+// EmailChangeRepository stores the email changes waiting for confirmation, at
+// most one per user.
+type EmailChangeRepository interface {
+	// Save stores change in place of the pending change of its user.
+	Save(ctx context.Context, change *model.EmailChange) error
+	// FindByToken returns the change of the token hash that has not expired
+	// at now, or model.ErrEmailChangeNotFound.
+	FindByToken(ctx context.Context, tokenHash string, now time.Time) (*model.EmailChange, error)
+	Delete(ctx context.Context, userID string) error
+}
+
+type memoryEmailChangeRepository struct {
+	mutex   sync.RWMutex
+	changes map[string]model.EmailChange
+}
+
+func NewMemoryEmailChangeRepository() EmailChangeRepository {
+	return &memoryEmailChangeRepository{changes: map[string]model.EmailChange{}}
+}
+
+func (repository *memoryEmailChangeRepository) Save(ctx context.Context, change *model.EmailChange) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.changes[change.UserID] = *change
+	return nil
+}
+
+func (repository *memoryEmailChangeRepository) FindByToken(ctx context.Context, tokenHash string, now time.Time) (*model.EmailChange, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	for _, change := range repository.changes {
+		if change.TokenHash == tokenHash && change.ExpiresAt.After(now) {
+			return &change, nil
+		}
+	}
+	return nil, model.ErrEmailChangeNotFound
+}
+
+func (repository *memoryEmailChangeRepository) Delete(ctx context.Context, userID string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	delete(repository.changes, userID)
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+type postgresEmailChangeRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresEmailChangeRepository(db *database.Cluster) EmailChangeRepository {
+	return &postgresEmailChangeRepository{db: db}
+}
+
+func (repository *postgresEmailChangeRepository) Save(ctx context.Context, change *model.EmailChange) error {
+	_, err := conn(ctx, repository.db).ExecContext(ctx, `INSERT INTO email_changes (user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, token_hash = EXCLUDED.token_hash, expires_at = EXCLUDED.expires_at`,
+		change.UserID, change.Email, change.TokenHash, change.ExpiresAt)
+	return err
+}
+
+func (repository *postgresEmailChangeRepository) FindByToken(ctx context.Context, tokenHash string, now time.Time) (*model.EmailChange, error) {
+	change := &model.EmailChange{}
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT user_id, email, token_hash, expires_at FROM email_changes WHERE token_hash = $1 AND expires_at > $2",
+		tokenHash, now).Scan(&change.UserID, &change.Email, &change.TokenHash, &change.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrEmailChangeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (repository *postgresEmailChangeRepository) Delete(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil
+	}
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM email_changes WHERE user_id = $1", userID)
+	return err
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileRepository stores the metadata of the uploaded files. List returns
+// the files of an owner and Usage adds up their sizes. CreateWithinQuota
+// fails with model.ErrStorageQuotaExceeded when the files of the owner would
+// take up more than quota bytes; checking and creating are atomic so that
+// uploads made at the same time cannot exceed the quota together. Exists
+// tells whether a file has a record in any tenant, the other methods only see
+// and create the files of the tenant of ctx.
+type FileRepository interface {
+	Create(ctx context.Context, file *model.File) error
+	CreateWithinQuota(ctx context.Context, file *model.File, quota int64) error
+	Update(ctx context.Context, file *model.File) error
+	FindByID(ctx context.Context, id string) (*model.File, error)
+	Exists(ctx context.Context, id string) (bool, error)
+	List(ctx context.Context, ownerID string, spec *model.ListSpec) ([]*model.File, int, error)
+	Usage(ctx context.Context, ownerID string) (*model.StorageUsage, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var defaultFileSort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryFileRepository struct {
+	mutex sync.RWMutex
+	files map[string]model.File
+}
+
+func NewMemoryFileRepository() FileRepository {
+	return &memoryFileRepository{files: map[string]model.File{}}
+}
+
+func (repository *memoryFileRepository) Create(ctx context.Context, file *model.File) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.create(ctx, file)
+	return nil
+}
+
+func (repository *memoryFileRepository) CreateWithinQuota(ctx context.Context, file *model.File, quota int64) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if repository.usage(ctx, file.OwnerID).Used+file.Size > quota {
+		return model.ErrStorageQuotaExceeded
+	}
+	repository.create(ctx, file)
+	return nil
+}
+
+func (repository *memoryFileRepository) create(ctx context.Context, file *model.File) {
+	if file.ID == "" {
+		file.ID = uuid.NewString()
+	}
+	if file.CreatedAt.IsZero() {
+		file.CreatedAt = time.Now()
+	}
+	file.UpdatedAt = file.CreatedAt
+	file.TenantID = tenant.From(ctx)
+	repository.save(file)
+}
+
+func (repository *memoryFileRepository) Update(ctx context.Context, file *model.File) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	saved, ok := repository.files[file.ID]
+	if !ok || saved.TenantID != tenant.From(ctx) {
+		return model.ErrFileNotFound
+	}
+	file.TenantID = saved.TenantID
+	file.UpdatedAt = time.Now()
+	repository.save(file)
+	return nil
+}
+
+func (repository *memoryFileRepository) save(file *model.File) {
+	saved := *file
+	saved.Steps = slices.Clone(file.Steps)
+	repository.files[file.ID] = saved
+}
+
+func (repository *memoryFileRepository) FindByID(ctx context.Context, id string) (*model.File, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	file, ok := repository.files[id]
+	if !ok || file.TenantID != tenant.From(ctx) {
+		return nil, model.ErrFileNotFound
+	}
+	file.Steps = slices.Clone(file.Steps)
+	return &file, nil
+}
+
+func (repository *memoryFileRepository) Exists(ctx context.Context, id string) (bool, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	_, ok := repository.files[id]
+	return ok, nil
+}
+
+func (repository *memoryFileRepository) List(ctx context.Context, ownerID string, spec *model.ListSpec) ([]*model.File, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var files []*model.File
+	for _, file := range repository.files {
+		if file.TenantID == tenant.From(ctx) && file.OwnerID == ownerID && matchesFilters(fileFields(&file), spec.Filters) && matchesSearch(spec.Search, file.Name) {
+			file.Steps = slices.Clone(file.Steps)
+			files = append(files, &file)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultFileSort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(files, func(i, j int) bool {
+		left, right := fileFields(files[i]), fileFields(files[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(files)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return files[start:end], total, nil
+}
+
+func (repository *memoryFileRepository) Usage(ctx context.Context, ownerID string) (*model.StorageUsage, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	return repository.usage(ctx, ownerID), nil
+}
+
+func (repository *memoryFileRepository) usage(ctx context.Context, ownerID string) *model.StorageUsage {
+	usage := &model.StorageUsage{}
+	for _, file := range repository.files {
+		if file.TenantID == tenant.From(ctx) && file.OwnerID == ownerID {
+			usage.Files++
+			usage.Used += file.Size
+		}
+	}
+	return usage
+}
+
+func (repository *memoryFileRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if file, ok := repository.files[id]; !ok || file.TenantID != tenant.From(ctx) {
+		return model.ErrFileNotFound
+	}
+	delete(repository.files, id)
+	return nil
+}
+
+func fileFields(file *model.File) map[string]string {
+	return map[string]string{
+		"id":           file.ID,
+		"name":         file.Name,
+		"status":       file.Status,
+		"content_type": file.ContentType,
+		"size":         fmt.Sprintf("%020d", file.Size),
+		"created_at":   file.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
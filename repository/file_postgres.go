@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const fileSelect = "id, tenant_id, owner_id, name, size, status, content_type, checksum, storage_key, thumbnail, steps, created_at, updated_at"
+
+var fileColumns = map[string]string{
+	"id":           "id",
+	"name":         "name",
+	"status":       "status",
+	"content_type": "content_type",
+	"size":         "size",
+	"created_at":   "created_at",
+}
+
+type postgresFileRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresFileRepository(db *database.Cluster) FileRepository {
+	return &postgresFileRepository{db: db}
+}
+
+func (repository *postgresFileRepository) Create(ctx context.Context, file *model.File) error {
+	if file.ID == "" {
+		file.ID = uuid.NewString()
+	}
+	if file.CreatedAt.IsZero() {
+		file.CreatedAt = time.Now()
+	}
+	file.UpdatedAt = file.CreatedAt
+	file.TenantID = tenant.From(ctx)
+
+	steps, err := json.Marshal(file.Steps)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO files ("+fileSelect+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		file.ID, file.TenantID, file.OwnerID, file.Name, file.Size, file.Status, file.ContentType, file.Checksum, file.StorageKey, file.Thumbnail, steps, file.CreatedAt, file.UpdatedAt)
+	return err
+}
+
+// CreateWithinQuota holds a lock on the files of the owner until the end of
+// the transaction, so the usage it checks stays true until the file is
+// created.
+func (repository *postgresFileRepository) CreateWithinQuota(ctx context.Context, file *model.File, quota int64) error {
+	return inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('files:' || $1 || ':' || $2))", tenant.From(ctx), file.OwnerID)
+		if err != nil {
+			return err
+		}
+		var used int64
+		err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(size), 0) FROM files WHERE owner_id = $1 AND tenant_id = $2", file.OwnerID, tenant.From(ctx)).Scan(&used)
+		if err != nil {
+			return err
+		}
+		if used+file.Size > quota {
+			return model.ErrStorageQuotaExceeded
+		}
+		return repository.Create(context.WithValue(ctx, txKey{}, tx), file)
+	})
+}
+
+func (repository *postgresFileRepository) Update(ctx context.Context, file *model.File) error {
+	if _, err := uuid.Parse(file.ID); err != nil {
+		return model.ErrFileNotFound
+	}
+	file.UpdatedAt = time.Now()
+
+	steps, err := json.Marshal(file.Steps)
+	if err != nil {
+		return err
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, `UPDATE files SET name = $2, size = $3, status = $4, content_type = $5, checksum = $6,
+thumbnail = $7, steps = $8, updated_at = $9 WHERE id = $1 AND tenant_id = $10`,
+		file.ID, file.Name, file.Size, file.Status, file.ContentType, file.Checksum, file.Thumbnail, steps, file.UpdatedAt, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrFileNotFound
+	}
+	return nil
+}
+
+func (repository *postgresFileRepository) FindByID(ctx context.Context, id string) (*model.File, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrFileNotFound
+	}
+	files, err := queryFiles(ctx, reader(ctx, repository.db), "SELECT "+fileSelect+" FROM files WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, model.ErrFileNotFound
+	}
+	return files[0], nil
+}
+
+func (repository *postgresFileRepository) Exists(ctx context.Context, id string) (bool, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return false, nil
+	}
+	var exists bool
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM files WHERE id = $1)", id).Scan(&exists)
+	return exists, err
+}
+
+func (repository *postgresFileRepository) List(ctx context.Context, ownerID string, spec *model.ListSpec) ([]*model.File, int, error) {
+	conditions := []string{"owner_id = $1", "tenant_id = $2"}
+	args := []interface{}{ownerID, tenant.From(ctx)}
+	for field, value := range spec.Filters {
+		column, ok := fileColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+"::TEXT) = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		conditions = append(conditions, "name ILIKE $"+strconv.Itoa(len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM files"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultFileSort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := fileColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	files, err := queryFiles(ctx, reader(ctx, repository.db), "SELECT "+fileSelect+" FROM files"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return files, total, nil
+}
+
+func (repository *postgresFileRepository) Usage(ctx context.Context, ownerID string) (*model.StorageUsage, error) {
+	usage := &model.StorageUsage{}
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(size), 0) FROM files WHERE owner_id = $1 AND tenant_id = $2", ownerID, tenant.From(ctx)).
+		Scan(&usage.Files, &usage.Used)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (repository *postgresFileRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrFileNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM files WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrFileNotFound
+	}
+	return nil
+}
+
+func queryFiles(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.File, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*model.File
+	for rows.Next() {
+		file := &model.File{}
+		var steps []byte
+		err = rows.Scan(&file.ID, &file.TenantID, &file.OwnerID, &file.Name, &file.Size, &file.Status, &file.ContentType, &file.Checksum,
+			&file.StorageKey, &file.Thumbnail, &steps, &file.CreatedAt, &file.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(steps, &file.Steps)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
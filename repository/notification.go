@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NotificationRepository stores the notifications of the users, and only sees
+// and creates the notifications of the tenant of ctx.
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	FindByID(ctx context.Context, id string) (*model.Notification, error)
+	// List lists the notifications of the user, only the unread ones when
+	// unread is set.
+	List(ctx context.Context, userID string, unread bool, spec *model.ListSpec) ([]*model.Notification, int, error)
+	CountUnread(ctx context.Context, userID string) (int, error)
+	// MarkRead marks the unread notifications of the user with ids, or all
+	// of them when ids is empty, read at at. It returns how many it marked.
+	MarkRead(ctx context.Context, userID string, ids []string, at time.Time) (int, error)
+}
+
+var defaultNotificationSort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryNotificationRepository struct {
+	mutex         sync.RWMutex
+	notifications map[string]model.Notification
+}
+
+func NewMemoryNotificationRepository() NotificationRepository {
+	return &memoryNotificationRepository{notifications: map[string]model.Notification{}}
+}
+
+func (repository *memoryNotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if notification.ID == "" {
+		notification.ID = uuid.NewString()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+	notification.TenantID = tenant.From(ctx)
+	repository.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (repository *memoryNotificationRepository) FindByID(ctx context.Context, id string) (*model.Notification, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	notification, ok := repository.notifications[id]
+	if !ok || notification.TenantID != tenant.From(ctx) {
+		return nil, model.ErrNotificationNotFound
+	}
+	return &notification, nil
+}
+
+func (repository *memoryNotificationRepository) List(ctx context.Context, userID string, unread bool, spec *model.ListSpec) ([]*model.Notification, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var notifications []*model.Notification
+	for _, notification := range repository.notifications {
+		if notification.TenantID == tenant.From(ctx) && notification.UserID == userID && (!unread || notification.ReadAt == nil) && matchesFilters(notificationFields(&notification), spec.Filters) &&
+			matchesSearch(spec.Search, notification.Title, notification.Body) {
+			notifications = append(notifications, &notification)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultNotificationSort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(notifications, func(i, j int) bool {
+		left, right := notificationFields(notifications[i]), notificationFields(notifications[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(notifications)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return notifications[start:end], total, nil
+}
+
+func (repository *memoryNotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	count := 0
+	for _, notification := range repository.notifications {
+		if notification.TenantID == tenant.From(ctx) && notification.UserID == userID && notification.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (repository *memoryNotificationRepository) MarkRead(ctx context.Context, userID string, ids []string, at time.Time) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	marked := 0
+	for id, notification := range repository.notifications {
+		if notification.TenantID == tenant.From(ctx) && notification.UserID == userID && notification.ReadAt == nil && (len(ids) == 0 || slices.Contains(ids, id)) {
+			notification.ReadAt = &at
+			repository.notifications[id] = notification
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func notificationFields(notification *model.Notification) map[string]string {
+	return map[string]string{
+		"id":         notification.ID,
+		"kind":       notification.Kind,
+		"created_at": notification.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const notificationSelect = "id, tenant_id, user_id, kind, title, body, link, read_at, created_at"
+
+var notificationColumns = map[string]string{
+	"id":         "id",
+	"kind":       "kind",
+	"created_at": "created_at",
+}
+
+type postgresNotificationRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresNotificationRepository(db *database.Cluster) NotificationRepository {
+	return &postgresNotificationRepository{db: db}
+}
+
+func (repository *postgresNotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	if notification.ID == "" {
+		notification.ID = uuid.NewString()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+	notification.TenantID = tenant.From(ctx)
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO notifications ("+notificationSelect+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		notification.ID, notification.TenantID, notification.UserID, notification.Kind, notification.Title, notification.Body, notification.Link, notification.ReadAt, notification.CreatedAt)
+	return err
+}
+
+func (repository *postgresNotificationRepository) FindByID(ctx context.Context, id string) (*model.Notification, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrNotificationNotFound
+	}
+	notifications, err := queryNotifications(ctx, reader(ctx, repository.db), "SELECT "+notificationSelect+" FROM notifications WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return nil, model.ErrNotificationNotFound
+	}
+	return notifications[0], nil
+}
+
+func (repository *postgresNotificationRepository) List(ctx context.Context, userID string, unread bool, spec *model.ListSpec) ([]*model.Notification, int, error) {
+	conditions := []string{"user_id = $1", "tenant_id = $2"}
+	args := []interface{}{userID, tenant.From(ctx)}
+	if unread {
+		conditions = append(conditions, "read_at IS NULL")
+	}
+	for field, value := range spec.Filters {
+		column, ok := notificationColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+"::TEXT) = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		conditions = append(conditions, "(title ILIKE $"+strconv.Itoa(len(args))+" OR body ILIKE $"+strconv.Itoa(len(args))+")")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultNotificationSort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := notificationColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	result, err := queryNotifications(ctx, reader(ctx, repository.db), "SELECT "+notificationSelect+" FROM notifications"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+func (repository *postgresNotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND tenant_id = $2 AND read_at IS NULL",
+		userID, tenant.From(ctx)).Scan(&count)
+	return count, err
+}
+
+func (repository *postgresNotificationRepository) MarkRead(ctx context.Context, userID string, ids []string, at time.Time) (int, error) {
+	query := "UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND tenant_id = $3 AND read_at IS NULL"
+	args := []interface{}{userID, at, tenant.From(ctx)}
+	if len(ids) > 0 {
+		var placeholders []string
+		for _, id := range ids {
+			if _, err := uuid.Parse(id); err != nil {
+				continue
+			}
+			args = append(args, id)
+			placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+		}
+		if len(placeholders) == 0 {
+			return 0, nil
+		}
+		query += " AND id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func queryNotifications(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.Notification, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*model.Notification
+	for rows.Next() {
+		notification := &model.Notification{}
+		err = rows.Scan(&notification.ID, &notification.TenantID, &notification.UserID, &notification.Kind, &notification.Title, &notification.Body, &notification.Link,
+			&notification.ReadAt, &notification.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
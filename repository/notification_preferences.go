@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"sync"
+	"time"
+)
+
+// NotificationPreferencesRepository stores the notification preferences of
+// the users. Find returns model.DefaultNotificationPreferences for the users
+// who never saved any.
+type NotificationPreferencesRepository interface {
+	Find(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+	Save(ctx context.Context, userID string, preferences *model.NotificationPreferences) error
+}
+
+type memoryNotificationPreferencesRepository struct {
+	mutex       sync.RWMutex
+	preferences map[string]model.NotificationPreferences
+}
+
+func NewMemoryNotificationPreferencesRepository() NotificationPreferencesRepository {
+	return &memoryNotificationPreferencesRepository{preferences: map[string]model.NotificationPreferences{}}
+}
+
+func (repository *memoryNotificationPreferencesRepository) Find(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	preferences, ok := repository.preferences[userID]
+	if !ok {
+		return model.DefaultNotificationPreferences(), nil
+	}
+	preferences.Muted = append([]string{}, preferences.Muted...)
+	return &preferences, nil
+}
+
+func (repository *memoryNotificationPreferencesRepository) Save(ctx context.Context, userID string, preferences *model.NotificationPreferences) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	preferences.UpdatedAt = time.Now()
+	saved := *preferences
+	saved.Muted = append([]string{}, preferences.Muted...)
+	repository.preferences[userID] = saved
+	return nil
+}
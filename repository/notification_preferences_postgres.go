@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+type postgresNotificationPreferencesRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresNotificationPreferencesRepository(db *database.Cluster) NotificationPreferencesRepository {
+	return &postgresNotificationPreferencesRepository{db: db}
+}
+
+func (repository *postgresNotificationPreferencesRepository) Find(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	preferences := &model.NotificationPreferences{}
+	var muted []byte
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT push, sms, phone, muted, updated_at FROM notification_preferences WHERE user_id = $1", userID).
+		Scan(&preferences.Push, &preferences.SMS, &preferences.Phone, &muted, &preferences.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.DefaultNotificationPreferences(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(muted, &preferences.Muted)
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (repository *postgresNotificationPreferencesRepository) Save(ctx context.Context, userID string, preferences *model.NotificationPreferences) error {
+	muted, err := json.Marshal(append([]string{}, preferences.Muted...))
+	if err != nil {
+		return err
+	}
+	preferences.UpdatedAt = time.Now()
+	_, err = conn(ctx, repository.db).ExecContext(ctx, `INSERT INTO notification_preferences (user_id, push, sms, phone, muted, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id) DO UPDATE SET push = EXCLUDED.push, sms = EXCLUDED.sms, phone = EXCLUDED.phone, muted = EXCLUDED.muted, updated_at = EXCLUDED.updated_at`,
+		userID, preferences.Push, preferences.SMS, preferences.Phone, muted, preferences.UpdatedAt)
+	return err
+}
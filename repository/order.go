@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OrderRepository stores the orders of the users, and only sees and creates
+// the orders of the tenant of ctx. List returns the orders of a user placed
+// within created.
+type OrderRepository interface {
+	Create(ctx context.Context, order *model.Order) error
+	Update(ctx context.Context, order *model.Order) error
+	FindByID(ctx context.Context, id string) (*model.Order, error)
+	List(ctx context.Context, userID string, created model.TimeRange, spec *model.ListSpec) ([]*model.Order, int, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var defaultOrderSort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryOrderRepository struct {
+	mutex  sync.RWMutex
+	orders map[string]model.Order
+}
+
+func NewMemoryOrderRepository() OrderRepository {
+	return &memoryOrderRepository{orders: map[string]model.Order{}}
+}
+
+func (repository *memoryOrderRepository) Create(ctx context.Context, order *model.Order) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if order.ID == "" {
+		order.ID = uuid.NewString()
+	}
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+	order.UpdatedAt = order.CreatedAt
+	order.TenantID = tenant.From(ctx)
+	repository.save(order)
+	return nil
+}
+
+func (repository *memoryOrderRepository) Update(ctx context.Context, order *model.Order) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	saved, ok := repository.orders[order.ID]
+	if !ok || saved.TenantID != tenant.From(ctx) {
+		return model.ErrOrderNotFound
+	}
+	order.TenantID = saved.TenantID
+	order.UpdatedAt = time.Now()
+	repository.save(order)
+	return nil
+}
+
+func (repository *memoryOrderRepository) save(order *model.Order) {
+	saved := *order
+	saved.Items = slices.Clone(order.Items)
+	repository.orders[order.ID] = saved
+}
+
+func (repository *memoryOrderRepository) FindByID(ctx context.Context, id string) (*model.Order, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	order, ok := repository.orders[id]
+	if !ok || order.TenantID != tenant.From(ctx) {
+		return nil, model.ErrOrderNotFound
+	}
+	order.Items = slices.Clone(order.Items)
+	return &order, nil
+}
+
+func (repository *memoryOrderRepository) List(ctx context.Context, userID string, created model.TimeRange, spec *model.ListSpec) ([]*model.Order, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var orders []*model.Order
+	for _, order := range repository.orders {
+		if order.TenantID == tenant.From(ctx) && order.UserID == userID && created.Contains(order.CreatedAt) && matchesFilters(orderFields(&order), spec.Filters) &&
+			matchesSearch(spec.Search, order.Notes) {
+			order.Items = slices.Clone(order.Items)
+			orders = append(orders, &order)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultOrderSort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(orders, func(i, j int) bool {
+		left, right := orderFields(orders[i]), orderFields(orders[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(orders)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return orders[start:end], total, nil
+}
+
+func (repository *memoryOrderRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if order, ok := repository.orders[id]; !ok || order.TenantID != tenant.From(ctx) {
+		return model.ErrOrderNotFound
+	}
+	delete(repository.orders, id)
+	return nil
+}
+
+func orderFields(order *model.Order) map[string]string {
+	return map[string]string{
+		"id":             order.ID,
+		"status":         order.Status,
+		"currency":       order.Currency,
+		"total":          fmt.Sprintf("%020d", order.Total),
+		"payment_status": order.PaymentStatus,
+		"created_at":     order.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+		"updated_at":     order.UpdatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const orderSelect = "id, tenant_id, user_id, status, items, total, currency, notes, payment_status, payment_id, created_at, updated_at"
+
+var orderColumns = map[string]string{
+	"id":             "id",
+	"status":         "status",
+	"currency":       "currency",
+	"total":          "total",
+	"payment_status": "payment_status",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+}
+
+type postgresOrderRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresOrderRepository(db *database.Cluster) OrderRepository {
+	return &postgresOrderRepository{db: db}
+}
+
+func (repository *postgresOrderRepository) Create(ctx context.Context, order *model.Order) error {
+	if order.ID == "" {
+		order.ID = uuid.NewString()
+	}
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+	order.UpdatedAt = order.CreatedAt
+	order.TenantID = tenant.From(ctx)
+
+	items, err := json.Marshal(order.Items)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO orders ("+orderSelect+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+		order.ID, order.TenantID, order.UserID, order.Status, items, order.Total, order.Currency, order.Notes, order.PaymentStatus, order.PaymentID, order.CreatedAt, order.UpdatedAt)
+	return err
+}
+
+func (repository *postgresOrderRepository) Update(ctx context.Context, order *model.Order) error {
+	if _, err := uuid.Parse(order.ID); err != nil {
+		return model.ErrOrderNotFound
+	}
+	order.UpdatedAt = time.Now()
+
+	items, err := json.Marshal(order.Items)
+	if err != nil {
+		return err
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, `UPDATE orders SET status = $2, items = $3, total = $4, notes = $5, payment_status = $6, payment_id = $7,
+updated_at = $8 WHERE id = $1 AND tenant_id = $9`,
+		order.ID, order.Status, items, order.Total, order.Notes, order.PaymentStatus, order.PaymentID, order.UpdatedAt, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrOrderNotFound
+	}
+	return nil
+}
+
+func (repository *postgresOrderRepository) FindByID(ctx context.Context, id string) (*model.Order, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrOrderNotFound
+	}
+	orders, err := queryOrders(ctx, reader(ctx, repository.db), "SELECT "+orderSelect+" FROM orders WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, model.ErrOrderNotFound
+	}
+	return orders[0], nil
+}
+
+func (repository *postgresOrderRepository) List(ctx context.Context, userID string, created model.TimeRange, spec *model.ListSpec) ([]*model.Order, int, error) {
+	conditions := []string{"user_id = $1", "tenant_id = $2"}
+	args := []interface{}{userID, tenant.From(ctx)}
+	if !created.From.IsZero() {
+		args = append(args, created.From)
+		conditions = append(conditions, "created_at >= $"+strconv.Itoa(len(args)))
+	}
+	if !created.To.IsZero() {
+		args = append(args, created.To)
+		conditions = append(conditions, "created_at < $"+strconv.Itoa(len(args)))
+	}
+	for field, value := range spec.Filters {
+		column, ok := orderColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+"::TEXT) = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		conditions = append(conditions, "notes ILIKE $"+strconv.Itoa(len(args)))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM orders"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultOrderSort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := orderColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	result, err := queryOrders(ctx, reader(ctx, repository.db), "SELECT "+orderSelect+" FROM orders"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+func (repository *postgresOrderRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrOrderNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM orders WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrOrderNotFound
+	}
+	return nil
+}
+
+func queryOrders(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.Order, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*model.Order
+	for rows.Next() {
+		order := &model.Order{}
+		var items []byte
+		err = rows.Scan(&order.ID, &order.TenantID, &order.UserID, &order.Status, &items, &order.Total, &order.Currency, &order.Notes,
+			&order.PaymentStatus, &order.PaymentID, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(items, &order.Items)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"sync"
+	"time"
+)
+
+// OutboxRepository stores domain events until they are relayed. Add takes part
+// in the transaction of ctx, so an event is stored if and only if the change
+// it describes is, and records the tenant of ctx. Claim passes the oldest unpublished messages, up to limit,
+// to fn and marks them published when fn returns nil. A message is claimed by
+// one caller at a time. Claim and Pending span all tenants.
+type OutboxRepository interface {
+	Add(ctx context.Context, messages ...*model.OutboxMessage) error
+	Claim(ctx context.Context, limit int, fn func(messages []*model.OutboxMessage) error) error
+	Pending(ctx context.Context) (int, error)
+}
+
+type memoryOutboxRepository struct {
+	mutex    sync.Mutex
+	messages []*model.OutboxMessage
+}
+
+func NewMemoryOutboxRepository() OutboxRepository {
+	return &memoryOutboxRepository{}
+}
+
+func (repository *memoryOutboxRepository) Add(ctx context.Context, messages ...*model.OutboxMessage) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, message := range messages {
+		prepareOutboxMessage(ctx, message)
+		saved := *message
+		repository.messages = append(repository.messages, &saved)
+	}
+	return nil
+}
+
+// Claim holds the lock while fn runs, so claims never overlap. Published
+// messages are dropped.
+func (repository *memoryOutboxRepository) Claim(ctx context.Context, limit int, fn func(messages []*model.OutboxMessage) error) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	claimed := repository.messages[:min(limit, len(repository.messages))]
+	if len(claimed) == 0 {
+		return nil
+	}
+	err := fn(claimed)
+	if err != nil {
+		return err
+	}
+	repository.messages = repository.messages[len(claimed):]
+	return nil
+}
+
+func (repository *memoryOutboxRepository) Pending(ctx context.Context) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	return len(repository.messages), nil
+}
+
+func prepareOutboxMessage(ctx context.Context, message *model.OutboxMessage) {
+	if message.ID == "" {
+		message.ID = uuid.NewString()
+	}
+	message.TenantID = tenant.From(ctx)
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+type postgresOutboxRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresOutboxRepository(db *database.Cluster) OutboxRepository {
+	return &postgresOutboxRepository{db: db}
+}
+
+func (repository *postgresOutboxRepository) Add(ctx context.Context, messages ...*model.OutboxMessage) error {
+	return inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		for _, message := range messages {
+			prepareOutboxMessage(ctx, message)
+			_, err := tx.ExecContext(ctx, "INSERT INTO outbox_messages (id, tenant_id, event, payload, created_at) VALUES ($1, $2, $3, $4, $5)",
+				message.ID, message.TenantID, message.Event, []byte(message.Payload), message.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Claim locks the messages with SKIP LOCKED, so relays running in several
+// processes share the work instead of publishing the same messages, and marks
+// them published in the same transaction.
+func (repository *postgresOutboxRepository) Claim(ctx context.Context, limit int, fn func(messages []*model.OutboxMessage) error) error {
+	return inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT id, tenant_id, event, payload, created_at FROM outbox_messages
+WHERE published_at IS NULL ORDER BY created_at, id LIMIT $1 FOR UPDATE SKIP LOCKED`, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var messages []*model.OutboxMessage
+		var ids []string
+		for rows.Next() {
+			message := &model.OutboxMessage{}
+			var payload []byte
+			err = rows.Scan(&message.ID, &message.TenantID, &message.Event, &payload, &message.CreatedAt)
+			if err != nil {
+				return err
+			}
+			message.Payload = payload
+			messages = append(messages, message)
+			ids = append(ids, message.ID)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		err = fn(messages)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE outbox_messages SET published_at = $1 WHERE id = ANY($2::uuid[])", time.Now(), ids)
+		return err
+	})
+}
+
+func (repository *postgresOutboxRepository) Pending(ctx context.Context) (int, error) {
+	var pending int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM outbox_messages WHERE published_at IS NULL").Scan(&pending)
+	return pending, err
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProductRepository stores the catalog of each tenant, and only sees and
+// creates the products of the tenant of ctx. List leaves out the products out
+// of stock when inStock is set; Categories returns every category of the
+// products, by name.
+type ProductRepository interface {
+	Create(ctx context.Context, product *model.Product) error
+	Update(ctx context.Context, product *model.Product) error
+	FindByID(ctx context.Context, id string) (*model.Product, error)
+	List(ctx context.Context, inStock bool, spec *model.ListSpec) ([]*model.Product, int, error)
+	Categories(ctx context.Context) ([]model.ProductCategory, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var defaultProductSort = []model.SortField{{Field: "name"}}
+
+type memoryProductRepository struct {
+	mutex    sync.RWMutex
+	products map[string]model.Product
+}
+
+func NewMemoryProductRepository() ProductRepository {
+	return &memoryProductRepository{products: map[string]model.Product{}}
+}
+
+func (repository *memoryProductRepository) Create(ctx context.Context, product *model.Product) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if product.ID == "" {
+		product.ID = uuid.NewString()
+	}
+	if product.CreatedAt.IsZero() {
+		product.CreatedAt = time.Now()
+	}
+	product.UpdatedAt = product.CreatedAt
+	product.TenantID = tenant.From(ctx)
+	repository.save(product)
+	return nil
+}
+
+func (repository *memoryProductRepository) Update(ctx context.Context, product *model.Product) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	saved, ok := repository.products[product.ID]
+	if !ok || saved.TenantID != tenant.From(ctx) {
+		return model.ErrProductNotFound
+	}
+	product.TenantID = saved.TenantID
+	product.UpdatedAt = time.Now()
+	repository.save(product)
+	return nil
+}
+
+func (repository *memoryProductRepository) save(product *model.Product) {
+	saved := *product
+	saved.Images = slices.Clone(product.Images)
+	repository.products[product.ID] = saved
+}
+
+func (repository *memoryProductRepository) FindByID(ctx context.Context, id string) (*model.Product, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	product, ok := repository.products[id]
+	if !ok || product.TenantID != tenant.From(ctx) {
+		return nil, model.ErrProductNotFound
+	}
+	product.Images = slices.Clone(product.Images)
+	return &product, nil
+}
+
+func (repository *memoryProductRepository) List(ctx context.Context, inStock bool, spec *model.ListSpec) ([]*model.Product, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var products []*model.Product
+	for _, product := range repository.products {
+		if product.TenantID == tenant.From(ctx) && (!inStock || product.Stock > 0) && matchesFilters(productFields(&product), spec.Filters) &&
+			matchesSearch(spec.Search, product.Name, product.Description) {
+			product.Images = slices.Clone(product.Images)
+			products = append(products, &product)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultProductSort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(products, func(i, j int) bool {
+		left, right := productFields(products[i]), productFields(products[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(products)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return products[start:end], total, nil
+}
+
+func (repository *memoryProductRepository) Categories(ctx context.Context) ([]model.ProductCategory, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	counts := map[string]int{}
+	for _, product := range repository.products {
+		if product.TenantID == tenant.From(ctx) {
+			counts[product.Category]++
+		}
+	}
+	categories := make([]model.ProductCategory, 0, len(counts))
+	for name, products := range counts {
+		categories = append(categories, model.ProductCategory{Name: name, Products: products})
+	}
+	slices.SortFunc(categories, func(left, right model.ProductCategory) int {
+		return strings.Compare(left.Name, right.Name)
+	})
+	return categories, nil
+}
+
+func (repository *memoryProductRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if product, ok := repository.products[id]; !ok || product.TenantID != tenant.From(ctx) {
+		return model.ErrProductNotFound
+	}
+	delete(repository.products, id)
+	return nil
+}
+
+func productFields(product *model.Product) map[string]string {
+	return map[string]string{
+		"id":         product.ID,
+		"name":       product.Name,
+		"category":   product.Category,
+		"currency":   product.Currency,
+		"price":      fmt.Sprintf("%020d", product.Price),
+		"stock":      fmt.Sprintf("%020d", product.Stock),
+		"created_at": product.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+		"updated_at": product.UpdatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/model"
+	"time"
+)
+
+// cachedProductRepository caches the reads of the catalog.
+type cachedProductRepository struct {
+	ProductRepository
+	cache *queryCache
+}
+
+func NewCachedProductRepository(products ProductRepository, store cache.Store, ttl time.Duration) ProductRepository {
+	return &cachedProductRepository{ProductRepository: products, cache: &queryCache{store: store, repository: "products", ttl: ttl}}
+}
+
+type productPage struct {
+	Products []*model.Product `json:"products"`
+	Total    int              `json:"total"`
+}
+
+func (repository *cachedProductRepository) FindByID(ctx context.Context, id string) (*model.Product, error) {
+	return cached(ctx, repository.cache, "FindByID", []any{id}, []string{repository.cache.recordTag(id)}, func() (*model.Product, error) {
+		return repository.ProductRepository.FindByID(ctx, id)
+	})
+}
+
+func (repository *cachedProductRepository) List(ctx context.Context, inStock bool, spec *model.ListSpec) ([]*model.Product, int, error) {
+	page, err := cached(ctx, repository.cache, "List", []any{inStock, spec}, []string{repository.cache.listTag()}, func() (productPage, error) {
+		products, total, err := repository.ProductRepository.List(ctx, inStock, spec)
+		return productPage{Products: products, Total: total}, err
+	})
+	return page.Products, page.Total, err
+}
+
+func (repository *cachedProductRepository) Categories(ctx context.Context) ([]model.ProductCategory, error) {
+	return cached(ctx, repository.cache, "Categories", nil, []string{repository.cache.listTag()}, func() ([]model.ProductCategory, error) {
+		return repository.ProductRepository.Categories(ctx)
+	})
+}
+
+func (repository *cachedProductRepository) Create(ctx context.Context, product *model.Product) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.ProductRepository.Create(ctx, product)
+}
+
+func (repository *cachedProductRepository) Update(ctx context.Context, product *model.Product) error {
+	defer repository.cache.invalidateAfterCommit(ctx, product.ID)
+	return repository.ProductRepository.Update(ctx, product)
+}
+
+func (repository *cachedProductRepository) Delete(ctx context.Context, id string) error {
+	defer repository.cache.invalidateAfterCommit(ctx, id)
+	return repository.ProductRepository.Delete(ctx, id)
+}
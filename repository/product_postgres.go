@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const productSelect = "id, tenant_id, name, description, category, price, currency, stock, images, created_at, updated_at"
+
+var productColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"category":   "category",
+	"currency":   "currency",
+	"price":      "price",
+	"stock":      "stock",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+type postgresProductRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresProductRepository(db *database.Cluster) ProductRepository {
+	return &postgresProductRepository{db: db}
+}
+
+func (repository *postgresProductRepository) Create(ctx context.Context, product *model.Product) error {
+	if product.ID == "" {
+		product.ID = uuid.NewString()
+	}
+	if product.CreatedAt.IsZero() {
+		product.CreatedAt = time.Now()
+	}
+	product.UpdatedAt = product.CreatedAt
+	product.TenantID = tenant.From(ctx)
+
+	images, err := json.Marshal(product.Images)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO products ("+productSelect+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		product.ID, product.TenantID, product.Name, product.Description, product.Category, product.Price, product.Currency, product.Stock, images, product.CreatedAt, product.UpdatedAt)
+	return err
+}
+
+func (repository *postgresProductRepository) Update(ctx context.Context, product *model.Product) error {
+	if _, err := uuid.Parse(product.ID); err != nil {
+		return model.ErrProductNotFound
+	}
+	product.UpdatedAt = time.Now()
+
+	images, err := json.Marshal(product.Images)
+	if err != nil {
+		return err
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, `UPDATE products SET name = $2, description = $3, category = $4, price = $5, currency = $6,
+stock = $7, images = $8, updated_at = $9 WHERE id = $1 AND tenant_id = $10`,
+		product.ID, product.Name, product.Description, product.Category, product.Price, product.Currency, product.Stock, images, product.UpdatedAt, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrProductNotFound
+	}
+	return nil
+}
+
+func (repository *postgresProductRepository) FindByID(ctx context.Context, id string) (*model.Product, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrProductNotFound
+	}
+	products, err := queryProducts(ctx, reader(ctx, repository.db), "SELECT "+productSelect+" FROM products WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, model.ErrProductNotFound
+	}
+	return products[0], nil
+}
+
+func (repository *postgresProductRepository) List(ctx context.Context, inStock bool, spec *model.ListSpec) ([]*model.Product, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenant.From(ctx)}
+	if inStock {
+		conditions = append(conditions, "stock > 0")
+	}
+	for field, value := range spec.Filters {
+		column, ok := productColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+"::TEXT) = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		conditions = append(conditions, "(name ILIKE $"+strconv.Itoa(len(args))+" OR description ILIKE $"+strconv.Itoa(len(args))+")")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultProductSort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := productColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	products, err := queryProducts(ctx, reader(ctx, repository.db), "SELECT "+productSelect+" FROM products"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (repository *postgresProductRepository) Categories(ctx context.Context) ([]model.ProductCategory, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT category, COUNT(*) FROM products WHERE tenant_id = $1 GROUP BY category ORDER BY category",
+		tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []model.ProductCategory{}
+	for rows.Next() {
+		var category model.ProductCategory
+		err = rows.Scan(&category.Name, &category.Products)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (repository *postgresProductRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrProductNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM products WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrProductNotFound
+	}
+	return nil
+}
+
+func queryProducts(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.Product, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*model.Product
+	for rows.Next() {
+		product := &model.Product{}
+		var images []byte
+		err = rows.Scan(&product.ID, &product.TenantID, &product.Name, &product.Description, &product.Category, &product.Price, &product.Currency, &product.Stock,
+			&images, &product.CreatedAt, &product.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(images, &product.Images)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
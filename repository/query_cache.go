@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"time"
+)
+
+var queryCacheLogger = telemetry.Logger("query_cache")
+
+// queryCache keeps the results of the reads of a repository in a cache store,
+// under the tags of what they read so the writes can invalidate them. The
+// results are stored as JSON, so they lose the fields left out of it.
+type queryCache struct {
+	store      cache.Store
+	repository string
+	ttl        time.Duration
+}
+
+// listTag tags the results of the queries that read several records of the
+// repository, which any write invalidates.
+func (cache *queryCache) listTag() string {
+	return "query:" + cache.repository
+}
+
+// recordTag tags the results read from the record id.
+func (cache *queryCache) recordTag(id string) string {
+	return "query:" + cache.repository + ":" + id
+}
+
+// invalidate drops the lists and the results read from the records ids.
+// Failures are logged: the results expire after the TTL anyway.
+func (cache *queryCache) invalidate(ids ...string) {
+	tags := []string{cache.listTag()}
+	for _, id := range ids {
+		tags = append(tags, cache.recordTag(id))
+	}
+	for _, tag := range tags {
+		err := cache.store.InvalidateTag(tag)
+		if err != nil {
+			queryCacheLogger.Error("invalidating cached queries", "tag", tag, "error", err)
+		}
+	}
+}
+
+// invalidateAfterCommit invalidates once the transaction of ctx is
+// committed, so no read in the meantime caches what it is about to change.
+func (cache *queryCache) invalidateAfterCommit(ctx context.Context, ids ...string) {
+	afterCommit(ctx, func() {
+		cache.invalidate(ids...)
+	})
+}
+
+// key identifies a query by method and arguments, within the tenant of ctx.
+func (cache *queryCache) key(ctx context.Context, method string, args ...any) (string, error) {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(append([]byte(tenant.From(ctx)+"\n"), encoded...))
+	return "query:" + cache.repository + "." + method + ":" + hex.EncodeToString(hash[:]), nil
+}
+
+// cached returns the cached result of method with args, or runs query and
+// caches its result under tags. It runs query when the store fails, and
+// within a transaction, which must read its own writes.
+func cached[T any](ctx context.Context, cache *queryCache, method string, args []any, tags []string, query func() (T, error)) (T, error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return query()
+	}
+	key, err := cache.key(ctx, method, args...)
+	if err != nil {
+		return query()
+	}
+
+	value, ok, err := cache.store.Get(key)
+	if err != nil {
+		queryCacheLogger.ErrorContext(ctx, "reading cached query", "key", key, "error", err)
+	}
+	if ok {
+		var result T
+		err = json.Unmarshal(value, &result)
+		if err == nil {
+			telemetry.QueryCacheRequestsTotal.WithLabelValues(cache.repository, method, "hit").Inc()
+			return result, nil
+		}
+		queryCacheLogger.ErrorContext(ctx, "decoding cached query", "key", key, "error", err)
+	}
+	telemetry.QueryCacheRequestsTotal.WithLabelValues(cache.repository, method, "miss").Inc()
+
+	result, err := query()
+	if err != nil {
+		return result, err
+	}
+	value, err = json.Marshal(result)
+	if err == nil {
+		err = cache.store.Set(key, value, cache.ttl, tags...)
+	}
+	if err != nil {
+		queryCacheLogger.ErrorContext(ctx, "caching query", "key", key, "error", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/model"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"testing"
+	"time"
+)
+
+func queryCacheRequests(repository, method, result string) float64 {
+	return testutil.ToFloat64(telemetry.QueryCacheRequestsTotal.WithLabelValues(repository, method, result))
+}
+
+func TestCachedProductRepository(t *testing.T) {
+	tests := []struct {
+		name string
+		// change runs between two reads of the product, through the cached
+		// repository or behind its back.
+		change func(ctx context.Context, cached, products ProductRepository, product *model.Product) error
+		// want is the name read the second time, and hit whether it comes from the cache.
+		want string
+		hit  bool
+	}{
+		{
+			name: "unchanged",
+			change: func(ctx context.Context, cached, products ProductRepository, product *model.Product) error {
+				return nil
+			},
+			want: "Keyboard",
+			hit:  true,
+		},
+		{
+			name: "updated behind the cache",
+			change: func(ctx context.Context, cached, products ProductRepository, product *model.Product) error {
+				product.Name = "Mouse"
+				return products.Update(ctx, product)
+			},
+			want: "Keyboard",
+			hit:  true,
+		},
+		{
+			name: "updated",
+			change: func(ctx context.Context, cached, products ProductRepository, product *model.Product) error {
+				product.Name = "Mouse"
+				return cached.Update(ctx, product)
+			},
+			want: "Mouse",
+		},
+		{
+			name: "other product created",
+			change: func(ctx context.Context, cached, products ProductRepository, product *model.Product) error {
+				return cached.Create(ctx, &model.Product{Name: "Screen"})
+			},
+			want: "Keyboard",
+			hit:  true,
+		},
+		{
+			name: "read in another tenant",
+			change: func(ctx context.Context, cached, products ProductRepository, product *model.Product) error {
+				_, err := cached.FindByID(tenant.With(ctx, "globex"), product.ID)
+				assert.ErrorIs(t, err, model.ErrProductNotFound)
+				return nil
+			},
+			want: "Keyboard",
+			hit:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := tenant.With(context.Background(), "acme")
+			products := NewMemoryProductRepository()
+			cached := NewCachedProductRepository(products, cache.NewMemoryStore(), time.Minute)
+			product := &model.Product{Name: "Keyboard"}
+			require.NoError(t, cached.Create(ctx, product))
+
+			_, err := cached.FindByID(ctx, product.ID)
+			require.NoError(t, err)
+			require.NoError(t, test.change(ctx, cached, products, product))
+
+			hits, misses := queryCacheRequests("products", "FindByID", "hit"), queryCacheRequests("products", "FindByID", "miss")
+			found, err := cached.FindByID(ctx, product.ID)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, found.Name)
+			if test.hit {
+				assert.Equal(t, hits+1, queryCacheRequests("products", "FindByID", "hit"))
+			} else {
+				assert.Equal(t, misses+1, queryCacheRequests("products", "FindByID", "miss"))
+			}
+		})
+	}
+}
+
+func TestCachedProductRepositoryList(t *testing.T) {
+	ctx := tenant.With(context.Background(), "acme")
+	cached := NewCachedProductRepository(NewMemoryProductRepository(), cache.NewMemoryStore(), time.Minute)
+	spec := &model.ListSpec{Page: 1, PerPage: 10}
+	keyboard := &model.Product{Name: "Keyboard"}
+	require.NoError(t, cached.Create(ctx, keyboard))
+
+	_, total, err := cached.List(ctx, false, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	require.NoError(t, cached.Create(ctx, &model.Product{Name: "Mouse"}))
+	_, total, err = cached.List(ctx, false, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	require.NoError(t, cached.Delete(ctx, keyboard.ID))
+	_, total, err = cached.List(ctx, false, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	_, total, err = cached.List(tenant.With(ctx, "globex"), false, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func TestCachedProductRepositoryInTransaction(t *testing.T) {
+	ctx := tenant.With(context.Background(), "acme")
+	products := NewMemoryProductRepository()
+	cached := NewCachedProductRepository(products, cache.NewMemoryStore(), time.Minute)
+	product := &model.Product{Name: "Keyboard"}
+	require.NoError(t, cached.Create(ctx, product))
+	_, err := cached.FindByID(ctx, product.ID)
+	require.NoError(t, err)
+
+	var committed []func()
+	tx := context.WithValue(context.WithValue(ctx, afterCommitKey{}, &committed), txKey{}, &sql.Tx{})
+	product.Name = "Mouse"
+	require.NoError(t, cached.Update(tx, product))
+
+	found, err := cached.FindByID(tx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Mouse", found.Name, "the transaction reads its own writes")
+	found, err = cached.FindByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Keyboard", found.Name, "the cache is invalidated after commit")
+
+	require.Len(t, committed, 1)
+	committed[0]()
+	found, err = cached.FindByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Mouse", found.Name)
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	ctx := tenant.With(context.Background(), "acme")
+	users := NewMemoryUserRepository()
+	store := cache.NewMemoryStore()
+	cached := NewCachedUserRepository(users, store, time.Minute)
+	spec := &model.ListSpec{Page: 1, PerPage: 10}
+	require.NoError(t, cached.Create(ctx, &model.User{Username: "budi", PasswordHash: "hash"}))
+
+	misses := queryCacheRequests(usersQueryCache, "List", "miss")
+	listed, total, err := cached.List(ctx, spec)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Empty(t, listed[0].PasswordHash)
+	assert.Equal(t, misses+1, queryCacheRequests(usersQueryCache, "List", "miss"))
+
+	hits := queryCacheRequests(usersQueryCache, "List", "hit")
+	_, _, err = cached.List(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, hits+1, queryCacheRequests(usersQueryCache, "List", "hit"))
+
+	require.NoError(t, users.Create(ctx, &model.User{Username: "joko"}))
+	_, total, err = cached.List(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "the changes behind the cache are not seen")
+
+	InvalidateCachedUsers(store)
+	_, total, err = cached.List(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	require.NoError(t, cached.Create(ctx, &model.User{Username: "eko"}))
+	_, total, err = cached.List(ctx, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	found, err := cached.FindByID(ctx, listed[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", found.PasswordHash)
+}
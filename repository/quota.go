@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"sync"
+	"time"
+)
+
+// QuotaRepository keeps the request counters of the quotas, one per subject,
+// period and period start, so that the counters of a new period start at
+// zero. Consume counts one request against every counter at once, unless one
+// of them has reached its Limit, in which case none is counted and it returns
+// false. Both Consume and Usage set Used on the counters.
+type QuotaRepository interface {
+	Consume(ctx context.Context, counters []*model.QuotaCounter) (bool, error)
+	Usage(ctx context.Context, counters []*model.QuotaCounter) error
+}
+
+type quotaKey struct {
+	subject string
+	period  string
+	start   time.Time
+}
+
+type memoryQuotaRepository struct {
+	mutex  sync.Mutex
+	counts map[quotaKey]int
+}
+
+func NewMemoryQuotaRepository() QuotaRepository {
+	return &memoryQuotaRepository{counts: map[quotaKey]int{}}
+}
+
+func (repository *memoryQuotaRepository) Consume(ctx context.Context, counters []*model.QuotaCounter) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, counter := range counters {
+		counter.Used = repository.counts[counterKey(counter)]
+	}
+	for _, counter := range counters {
+		if counter.Used >= counter.Limit {
+			return false, nil
+		}
+	}
+	for _, counter := range counters {
+		counter.Used++
+		repository.counts[counterKey(counter)] = counter.Used
+	}
+	return true, nil
+}
+
+func (repository *memoryQuotaRepository) Usage(ctx context.Context, counters []*model.QuotaCounter) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, counter := range counters {
+		counter.Used = repository.counts[counterKey(counter)]
+	}
+	return nil
+}
+
+func counterKey(counter *model.QuotaCounter) quotaKey {
+	return quotaKey{subject: counter.Subject, period: counter.Period, start: counter.Start.UTC()}
+}
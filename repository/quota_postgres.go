@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+)
+
+// errQuotaReached rolls back the counters of a request counted before one of
+// them turned out to be full.
+var errQuotaReached = errors.New("quota reached")
+
+type postgresQuotaRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresQuotaRepository(db *database.Cluster) QuotaRepository {
+	return &postgresQuotaRepository{db: db}
+}
+
+// Consume increments the counters only while they are below their limit, so
+// that concurrent requests, from this process or others, cannot exceed it.
+func (repository *postgresQuotaRepository) Consume(ctx context.Context, counters []*model.QuotaCounter) (bool, error) {
+	err := inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		for _, counter := range counters {
+			err := tx.QueryRowContext(ctx, `INSERT INTO quota_counters (subject, period, period_start, count) VALUES ($1, $2, $3, 1)
+ON CONFLICT (subject, period, period_start) DO UPDATE SET count = quota_counters.count + 1 WHERE quota_counters.count < $4
+RETURNING count`, counter.Subject, counter.Period, counter.Start, counter.Limit).Scan(&counter.Used)
+			if errors.Is(err, sql.ErrNoRows) {
+				return errQuotaReached
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errQuotaReached) {
+		return false, repository.Usage(ctx, counters)
+	}
+	return err == nil, err
+}
+
+func (repository *postgresQuotaRepository) Usage(ctx context.Context, counters []*model.QuotaCounter) error {
+	for _, counter := range counters {
+		err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT count FROM quota_counters WHERE subject = $1 AND period = $2 AND period_start = $3",
+			counter.Subject, counter.Period, counter.Start).Scan(&counter.Used)
+		if errors.Is(err, sql.ErrNoRows) {
+			counter.Used = 0
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"sync"
+	"time"
+)
+
+// ReceivedWebhookRepository records the events received from webhook
+// providers. Create fails with model.ErrDuplicateWebhook for an event that
+// was recorded before.
+type ReceivedWebhookRepository interface {
+	Create(ctx context.Context, received *model.ReceivedWebhook) error
+}
+
+type memoryReceivedWebhookRepository struct {
+	mutex    sync.Mutex
+	received map[[2]string]model.ReceivedWebhook
+}
+
+func NewMemoryReceivedWebhookRepository() ReceivedWebhookRepository {
+	return &memoryReceivedWebhookRepository{received: map[[2]string]model.ReceivedWebhook{}}
+}
+
+func (repository *memoryReceivedWebhookRepository) Create(ctx context.Context, received *model.ReceivedWebhook) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	key := [2]string{received.Provider, received.EventID}
+	if _, ok := repository.received[key]; ok {
+		return model.ErrDuplicateWebhook
+	}
+	if received.ReceivedAt.IsZero() {
+		received.ReceivedAt = time.Now()
+	}
+	repository.received[key] = *received
+	return nil
+}
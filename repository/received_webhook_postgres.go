@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+type postgresReceivedWebhookRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresReceivedWebhookRepository(db *database.Cluster) ReceivedWebhookRepository {
+	return &postgresReceivedWebhookRepository{db: db}
+}
+
+func (repository *postgresReceivedWebhookRepository) Create(ctx context.Context, received *model.ReceivedWebhook) error {
+	if received.ReceivedAt.IsZero() {
+		received.ReceivedAt = time.Now()
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, `INSERT INTO received_webhooks (provider, event_id, type, received_at)
+VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`, received.Provider, received.EventID, received.Type, received.ReceivedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrDuplicateWebhook
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"sync"
+	"time"
+)
+
+// ReportRepository stores the reports generated in the background. The
+// content of a report is kept in the storage, not here.
+type ReportRepository interface {
+	Create(ctx context.Context, report *model.Report) error
+	Update(ctx context.Context, report *model.Report) error
+	FindByID(ctx context.Context, id string) (*model.Report, error)
+}
+
+type memoryReportRepository struct {
+	mutex   sync.RWMutex
+	reports map[string]model.Report
+}
+
+func NewMemoryReportRepository() ReportRepository {
+	return &memoryReportRepository{reports: map[string]model.Report{}}
+}
+
+func (repository *memoryReportRepository) Create(ctx context.Context, report *model.Report) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if report.ID == "" {
+		report.ID = uuid.NewString()
+	}
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = time.Now()
+	}
+	repository.reports[report.ID] = *report
+	return nil
+}
+
+func (repository *memoryReportRepository) Update(ctx context.Context, report *model.Report) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, ok := repository.reports[report.ID]; !ok {
+		return model.ErrReportNotFound
+	}
+	repository.reports[report.ID] = *report
+	return nil
+}
+
+func (repository *memoryReportRepository) FindByID(ctx context.Context, id string) (*model.Report, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	report, ok := repository.reports[id]
+	if !ok {
+		return nil, model.ErrReportNotFound
+	}
+	return &report, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+const reportSelect = "id, user_id, kind, status, period_from, period_to, size, error, created_at, completed_at"
+
+type postgresReportRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresReportRepository(db *database.Cluster) ReportRepository {
+	return &postgresReportRepository{db: db}
+}
+
+func (repository *postgresReportRepository) Create(ctx context.Context, report *model.Report) error {
+	if report.ID == "" {
+		report.ID = uuid.NewString()
+	}
+	if report.CreatedAt.IsZero() {
+		report.CreatedAt = time.Now()
+	}
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO reports ("+reportSelect+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		report.ID, report.UserID, report.Kind, report.Status, report.From, report.To, report.Size, report.Error, report.CreatedAt, report.CompletedAt)
+	return err
+}
+
+func (repository *postgresReportRepository) Update(ctx context.Context, report *model.Report) error {
+	if _, err := uuid.Parse(report.ID); err != nil {
+		return model.ErrReportNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "UPDATE reports SET status = $2, size = $3, error = $4, completed_at = $5 WHERE id = $1",
+		report.ID, report.Status, report.Size, report.Error, report.CompletedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrReportNotFound
+	}
+	return nil
+}
+
+func (repository *postgresReportRepository) FindByID(ctx context.Context, id string) (*model.Report, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrReportNotFound
+	}
+	report := &model.Report{}
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT "+reportSelect+" FROM reports WHERE id = $1", id).
+		Scan(&report.ID, &report.UserID, &report.Kind, &report.Status, &report.From, &report.To, &report.Size, &report.Error, &report.CreatedAt, &report.CompletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import "golang-fiber-web/database"
+
+// Repositories groups the repositories of every model so they can be created
+// and passed around together.
+type Repositories struct {
+	Users  UserRepository
+	Roles  RoleRepository
+	Audit  AuditRepository
+	Outbox OutboxRepository
+
+	Webhooks          WebhookRepository
+	WebhookDeliveries WebhookDeliveryRepository
+	ReceivedWebhooks  ReceivedWebhookRepository
+
+	APIKeys APIKeyRepository
+	Quotas  QuotaRepository
+	Usage   UsageRepository
+	Files   FileRepository
+
+	SigningKeys  SigningKeyRepository
+	Sessions     SessionRepository
+	EmailChanges EmailChangeRepository
+
+	Orders        OrderRepository
+	Products      ProductRepository
+	Reports       ReportRepository
+	Notifications NotificationRepository
+	Activities    ActivityRepository
+	Comments      CommentRepository
+
+	Devices                 DeviceRepository
+	NotificationPreferences NotificationPreferencesRepository
+	SMS                     SMSRepository
+
+	Transactor Transactor
+}
+
+func NewMemoryRepositories() *Repositories {
+	webhooks, webhookDeliveries := NewMemoryWebhookRepositories()
+	return &Repositories{
+		Users:  NewMemoryUserRepository(),
+		Roles:  NewMemoryRoleRepository(),
+		Audit:  NewMemoryAuditRepository(),
+		Outbox: NewMemoryOutboxRepository(),
+
+		Webhooks:          webhooks,
+		WebhookDeliveries: webhookDeliveries,
+		ReceivedWebhooks:  NewMemoryReceivedWebhookRepository(),
+
+		APIKeys: NewMemoryAPIKeyRepository(),
+		Quotas:  NewMemoryQuotaRepository(),
+		Usage:   NewMemoryUsageRepository(),
+		Files:   NewMemoryFileRepository(),
+
+		SigningKeys:  NewMemorySigningKeyRepository(),
+		Sessions:     NewMemorySessionRepository(),
+		EmailChanges: NewMemoryEmailChangeRepository(),
+
+		Orders:        NewMemoryOrderRepository(),
+		Products:      NewMemoryProductRepository(),
+		Reports:       NewMemoryReportRepository(),
+		Notifications: NewMemoryNotificationRepository(),
+		Activities:    NewMemoryActivityRepository(),
+		Comments:      NewMemoryCommentRepository(),
+
+		Devices:                 NewMemoryDeviceRepository(),
+		NotificationPreferences: NewMemoryNotificationPreferencesRepository(),
+		SMS:                     NewMemorySMSRepository(),
+
+		Transactor: NewMemoryTransactor(),
+	}
+}
+
+func NewPostgresRepositories(db *database.Cluster) *Repositories {
+	return &Repositories{
+		Users:  NewPostgresUserRepository(db),
+		Roles:  NewPostgresRoleRepository(db),
+		Audit:  NewPostgresAuditRepository(db),
+		Outbox: NewPostgresOutboxRepository(db),
+
+		Webhooks:          NewPostgresWebhookRepository(db),
+		WebhookDeliveries: NewPostgresWebhookDeliveryRepository(db),
+		ReceivedWebhooks:  NewPostgresReceivedWebhookRepository(db),
+
+		APIKeys: NewPostgresAPIKeyRepository(db),
+		Quotas:  NewPostgresQuotaRepository(db),
+		Usage:   NewPostgresUsageRepository(db),
+		Files:   NewPostgresFileRepository(db),
+
+		SigningKeys:  NewPostgresSigningKeyRepository(db),
+		Sessions:     NewPostgresSessionRepository(db),
+		EmailChanges: NewPostgresEmailChangeRepository(db),
+
+		Orders:        NewPostgresOrderRepository(db),
+		Products:      NewPostgresProductRepository(db),
+		Reports:       NewPostgresReportRepository(db),
+		Notifications: NewPostgresNotificationRepository(db),
+		Activities:    NewPostgresActivityRepository(db),
+		Comments:      NewPostgresCommentRepository(db),
+
+		Devices:                 NewPostgresDeviceRepository(db),
+		NotificationPreferences: NewPostgresNotificationPreferencesRepository(db),
+		SMS:                     NewPostgresSMSRepository(db),
+
+		Transactor: NewPostgresTransactor(db),
+	}
+}
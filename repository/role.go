@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"sort"
+	"sync"
+)
+
+type RoleRepository interface {
+	// Save creates the role or updates the description of an existing one.
+	Save(ctx context.Context, role *model.Role) error
+	List(ctx context.Context) ([]*model.Role, error)
+}
+
+type memoryRoleRepository struct {
+	mutex sync.RWMutex
+	roles map[string]model.Role
+}
+
+func NewMemoryRoleRepository() RoleRepository {
+	return &memoryRoleRepository{roles: map[string]model.Role{}}
+}
+
+func (repository *memoryRoleRepository) Save(ctx context.Context, role *model.Role) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.roles[role.Name] = *role
+	return nil
+}
+
+func (repository *memoryRoleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	roles := make([]*model.Role, 0, len(repository.roles))
+	for _, role := range repository.roles {
+		roles = append(roles, &role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+)
+
+type postgresRoleRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresRoleRepository(db *database.Cluster) RoleRepository {
+	return &postgresRoleRepository{db: db}
+}
+
+func (repository *postgresRoleRepository) Save(ctx context.Context, role *model.Role) error {
+	_, err := conn(ctx, repository.db).ExecContext(ctx, `INSERT INTO roles (name, description) VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description`, role.Name, role.Description)
+	return err
+}
+
+func (repository *postgresRoleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT name, description FROM roles ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*model.Role
+	for rows.Next() {
+		role := &model.Role{}
+		err = rows.Scan(&role.Name, &role.Description)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
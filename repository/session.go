@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionRepository stores the logins of the users. Revoking a session
+// deletes it; expired sessions are only deleted by DeleteExpired, which spans
+// all tenants. The other methods only see and create the sessions of the
+// tenant of ctx.
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	// FindActive returns the session if it has not expired at now, or
+	// model.ErrSessionNotFound.
+	FindActive(ctx context.Context, id string, now time.Time) (*model.Session, error)
+	// List returns the sessions of the user that have not expired at now,
+	// the newest first.
+	List(ctx context.Context, userID string, now time.Time) ([]*model.Session, error)
+	// Delete deletes the session of the user, or fails with
+	// model.ErrSessionNotFound.
+	Delete(ctx context.Context, userID, id string) error
+	// DeleteOthers deletes the sessions of the user but the one of keepID,
+	// which may be empty to delete them all.
+	DeleteOthers(ctx context.Context, userID, keepID string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type memorySessionRepository struct {
+	mutex    sync.RWMutex
+	sessions map[string]model.Session
+}
+
+func NewMemorySessionRepository() SessionRepository {
+	return &memorySessionRepository{sessions: map[string]model.Session{}}
+}
+
+func (repository *memorySessionRepository) Create(ctx context.Context, session *model.Session) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	prepareSession(ctx, session)
+	repository.sessions[session.ID] = *session
+	return nil
+}
+
+func (repository *memorySessionRepository) FindActive(ctx context.Context, id string, now time.Time) (*model.Session, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	session, ok := repository.sessions[id]
+	if !ok || session.TenantID != tenant.From(ctx) || !session.ExpiresAt.After(now) {
+		return nil, model.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (repository *memorySessionRepository) List(ctx context.Context, userID string, now time.Time) ([]*model.Session, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	sessions := []*model.Session{}
+	for _, session := range repository.sessions {
+		if session.TenantID == tenant.From(ctx) && session.UserID == userID && session.ExpiresAt.After(now) {
+			sessions = append(sessions, &session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+func (repository *memorySessionRepository) Delete(ctx context.Context, userID, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if session, ok := repository.sessions[id]; !ok || session.TenantID != tenant.From(ctx) || session.UserID != userID {
+		return model.ErrSessionNotFound
+	}
+	delete(repository.sessions, id)
+	return nil
+}
+
+func (repository *memorySessionRepository) DeleteOthers(ctx context.Context, userID, keepID string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for id, session := range repository.sessions {
+		if session.TenantID == tenant.From(ctx) && session.UserID == userID && id != keepID {
+			delete(repository.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (repository *memorySessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	deleted := 0
+	for id, session := range repository.sessions {
+		if !session.ExpiresAt.After(now) {
+			delete(repository.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func prepareSession(ctx context.Context, session *model.Session) {
+	if session.ID == "" {
+		session.ID = uuid.NewString()
+	}
+	session.TenantID = tenant.From(ctx)
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"time"
+)
+
+const sessionSelect = "id, tenant_id, user_id, user_agent, ip_address, created_at, expires_at"
+
+type postgresSessionRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresSessionRepository(db *database.Cluster) SessionRepository {
+	return &postgresSessionRepository{db: db}
+}
+
+func (repository *postgresSessionRepository) Create(ctx context.Context, session *model.Session) error {
+	prepareSession(ctx, session)
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO sessions ("+sessionSelect+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		session.ID, session.TenantID, session.UserID, session.UserAgent, session.IPAddress, session.CreatedAt, session.ExpiresAt)
+	return err
+}
+
+func (repository *postgresSessionRepository) FindActive(ctx context.Context, id string, now time.Time) (*model.Session, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrSessionNotFound
+	}
+	sessions, err := repository.query(ctx, "SELECT "+sessionSelect+" FROM sessions WHERE id = $1 AND tenant_id = $2 AND expires_at > $3", id, tenant.From(ctx), now)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, model.ErrSessionNotFound
+	}
+	return sessions[0], nil
+}
+
+func (repository *postgresSessionRepository) List(ctx context.Context, userID string, now time.Time) ([]*model.Session, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return []*model.Session{}, nil
+	}
+	return repository.query(ctx, "SELECT "+sessionSelect+" FROM sessions WHERE user_id = $1 AND tenant_id = $2 AND expires_at > $3 ORDER BY created_at DESC, id", userID, tenant.From(ctx), now)
+}
+
+func (repository *postgresSessionRepository) Delete(ctx context.Context, userID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrSessionNotFound
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return model.ErrSessionNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM sessions WHERE id = $1 AND user_id = $2 AND tenant_id = $3", id, userID, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (repository *postgresSessionRepository) DeleteOthers(ctx context.Context, userID, keepID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil
+	}
+	_, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM sessions WHERE user_id = $1 AND tenant_id = $2 AND id::text <> $3", userID, tenant.From(ctx), keepID)
+	return err
+}
+
+func (repository *postgresSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (repository *postgresSessionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.Session, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*model.Session{}
+	for rows.Next() {
+		session := &model.Session{}
+		err = rows.Scan(&session.ID, &session.TenantID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.CreatedAt, &session.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SigningKeyRepository stores the keys signing the tokens the app issues.
+// CreateIfNoneSince creates key unless another was created after since, and
+// reports whether it did, so that the processes of the app rotating at the
+// same time create a single key. List returns the keys that have not expired
+// at now, newest first.
+type SigningKeyRepository interface {
+	CreateIfNoneSince(ctx context.Context, key *model.SigningKey, since time.Time) (bool, error)
+	List(ctx context.Context, now time.Time) ([]*model.SigningKey, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type memorySigningKeyRepository struct {
+	mutex sync.RWMutex
+	keys  map[string]model.SigningKey
+}
+
+func NewMemorySigningKeyRepository() SigningKeyRepository {
+	return &memorySigningKeyRepository{keys: map[string]model.SigningKey{}}
+}
+
+func (repository *memorySigningKeyRepository) CreateIfNoneSince(ctx context.Context, key *model.SigningKey, since time.Time) (bool, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, existing := range repository.keys {
+		if existing.CreatedAt.After(since) {
+			return false, nil
+		}
+	}
+	prepareSigningKey(key)
+	repository.keys[key.ID] = *key
+	return true, nil
+}
+
+func (repository *memorySigningKeyRepository) List(ctx context.Context, now time.Time) ([]*model.SigningKey, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	keys := []*model.SigningKey{}
+	for _, key := range repository.keys {
+		if key.ExpiresAt.After(now) {
+			keys = append(keys, &key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+func (repository *memorySigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	deleted := 0
+	for id, key := range repository.keys {
+		if !key.ExpiresAt.After(now) {
+			delete(repository.keys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func prepareSigningKey(key *model.SigningKey) {
+	if key.ID == "" {
+		key.ID = uuid.NewString()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+}
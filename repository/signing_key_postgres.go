@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+const signingKeySelect = "id, algorithm, private_key, created_at, expires_at"
+
+type postgresSigningKeyRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresSigningKeyRepository(db *database.Cluster) SigningKeyRepository {
+	return &postgresSigningKeyRepository{db: db}
+}
+
+func (repository *postgresSigningKeyRepository) CreateIfNoneSince(ctx context.Context, key *model.SigningKey, since time.Time) (bool, error) {
+	created := false
+	err := inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('signing_keys'))")
+		if err != nil {
+			return err
+		}
+		var exists bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM signing_keys WHERE created_at > $1)", since).Scan(&exists)
+		if err != nil || exists {
+			return err
+		}
+		prepareSigningKey(key)
+		_, err = tx.ExecContext(ctx, "INSERT INTO signing_keys ("+signingKeySelect+") VALUES ($1, $2, $3, $4, $5)",
+			key.ID, key.Algorithm, key.PrivateKey, key.CreatedAt, key.ExpiresAt)
+		created = err == nil
+		return err
+	})
+	return created && err == nil, err
+}
+
+func (repository *postgresSigningKeyRepository) List(ctx context.Context, now time.Time) ([]*model.SigningKey, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx,
+		"SELECT "+signingKeySelect+" FROM signing_keys WHERE expires_at > $1 ORDER BY created_at DESC, id DESC", now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*model.SigningKey{}
+	for rows.Next() {
+		key := &model.SigningKey{}
+		err = rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &key.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (repository *postgresSigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM signing_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"sync"
+	"time"
+)
+
+// SMSRepository records the text messages sent and their delivery.
+type SMSRepository interface {
+	Create(ctx context.Context, message *model.SMSMessage) error
+	FindByProviderID(ctx context.Context, provider, providerID string) (*model.SMSMessage, error)
+	// UpdateStatus sets the status of the message Provider knows as
+	// providerID, along with failure, the reason it failed. Only sent
+	// messages change, so a report coming after a later one is ignored.
+	UpdateStatus(ctx context.Context, provider, providerID, status, failure string) error
+}
+
+type memorySMSRepository struct {
+	mutex    sync.RWMutex
+	messages map[string]model.SMSMessage
+}
+
+func NewMemorySMSRepository() SMSRepository {
+	return &memorySMSRepository{messages: map[string]model.SMSMessage{}}
+}
+
+func (repository *memorySMSRepository) Create(ctx context.Context, message *model.SMSMessage) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if message.ID == "" {
+		message.ID = uuid.NewString()
+	}
+	now := time.Now()
+	message.CreatedAt, message.UpdatedAt = now, now
+	repository.messages[message.ID] = *message
+	return nil
+}
+
+func (repository *memorySMSRepository) FindByProviderID(ctx context.Context, provider, providerID string) (*model.SMSMessage, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	for _, message := range repository.messages {
+		if message.Provider == provider && message.ProviderID == providerID {
+			return &message, nil
+		}
+	}
+	return nil, model.ErrSMSNotFound
+}
+
+func (repository *memorySMSRepository) UpdateStatus(ctx context.Context, provider, providerID, status, failure string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for id, message := range repository.messages {
+		if message.Provider == provider && message.ProviderID == providerID && message.Status == model.SMSSent {
+			message.Status, message.Error, message.UpdatedAt = status, failure, time.Now()
+			repository.messages[id] = message
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+type postgresSMSRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresSMSRepository(db *database.Cluster) SMSRepository {
+	return &postgresSMSRepository{db: db}
+}
+
+func (repository *postgresSMSRepository) Create(ctx context.Context, message *model.SMSMessage) error {
+	if message.ID == "" {
+		message.ID = uuid.NewString()
+	}
+	now := time.Now()
+	message.CreatedAt, message.UpdatedAt = now, now
+	_, err := conn(ctx, repository.db).ExecContext(ctx, `INSERT INTO sms_messages (id, recipient, kind, body, provider, provider_id, status, error, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)`,
+		message.ID, message.To, message.Kind, message.Body, message.Provider, message.ProviderID, message.Status, message.Error, now)
+	return err
+}
+
+func (repository *postgresSMSRepository) FindByProviderID(ctx context.Context, provider, providerID string) (*model.SMSMessage, error) {
+	message := &model.SMSMessage{}
+	err := reader(ctx, repository.db).QueryRowContext(ctx, `SELECT id, recipient, kind, body, provider, provider_id, status, error, created_at, updated_at
+FROM sms_messages WHERE provider = $1 AND provider_id = $2`, provider, providerID).Scan(&message.ID, &message.To, &message.Kind, &message.Body, &message.Provider, &message.ProviderID,
+		&message.Status, &message.Error, &message.CreatedAt, &message.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrSMSNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (repository *postgresSMSRepository) UpdateStatus(ctx context.Context, provider, providerID, status, failure string) error {
+	_, err := conn(ctx, repository.db).ExecContext(ctx, `UPDATE sms_messages SET status = $3, error = $4, updated_at = $5
+WHERE provider = $1 AND provider_id = $2 AND status = $6`, provider, providerID, status, failure, time.Now(), model.SMSSent)
+	return err
+}
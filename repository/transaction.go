@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-fiber-web/database"
+)
+
+// Transactor runs fn in a database transaction that is committed when fn
+// returns nil and rolled back otherwise. The repositories called with the ctx
+// passed to fn take part in the transaction, and a Transaction within fn
+// joins the outer one. What the repositories defer with afterCommit runs once
+// the outer transaction is committed.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type afterCommitKey struct{}
+
+type postgresTransactor struct {
+	db *database.Cluster
+}
+
+func NewPostgresTransactor(db *database.Cluster) Transactor {
+	return &postgresTransactor{db: db}
+}
+
+func (transactor *postgresTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var committed []func()
+	ctx = context.WithValue(ctx, afterCommitKey{}, &committed)
+	err := inTransaction(ctx, transactor.db, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil {
+		return err
+	}
+	for _, fn := range committed {
+		fn()
+	}
+	return nil
+}
+
+// afterCommit runs fn once the transaction of ctx is committed, and not at
+// all if it is rolled back, or right away outside a transaction.
+func afterCommit(ctx context.Context, fn func()) {
+	if committed, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*committed = append(*committed, fn)
+		return
+	}
+	fn()
+}
+
+// memoryTransactor only runs fn: the memory repositories apply every change
+// right away and have nothing to roll back.
+type memoryTransactor struct{}
+
+func NewMemoryTransactor() Transactor {
+	return memoryTransactor{}
+}
+
+func (transactor memoryTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// executor is what *sql.DB and *sql.Tx have in common.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of ctx, or the primary outside a transaction,
+// to write. The reads of ctx that follow go to the primary too.
+func conn(ctx context.Context, db *database.Cluster) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	database.MarkWritten(ctx)
+	return db.Primary()
+}
+
+// reader returns the transaction of ctx, or the database to read from
+// outside a transaction.
+func reader(ctx context.Context, db *database.Cluster) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db.Reader(ctx)
+}
+
+// inTransaction runs fn on the transaction of ctx, or on a new transaction of
+// the primary that is committed when fn succeeds.
+func inTransaction(ctx context.Context, db *database.Cluster, fn func(tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(tx)
+	}
+
+	database.MarkWritten(ctx)
+	tx, err := db.Primary().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageRepository keeps the request statistics of the API. Add adds the
+// stats to those of the same bucket, method, route and consumer. List returns
+// the stats of the buckets starting from from and before to, oldest first.
+type UsageRepository interface {
+	Add(ctx context.Context, stats []*model.UsageStat) error
+	List(ctx context.Context, from, to time.Time) ([]*model.UsageStat, error)
+}
+
+type usageKey struct {
+	bucket   time.Time
+	method   string
+	route    string
+	consumer string
+}
+
+type memoryUsageRepository struct {
+	mutex sync.RWMutex
+	stats map[usageKey]model.UsageStat
+}
+
+func NewMemoryUsageRepository() UsageRepository {
+	return &memoryUsageRepository{stats: map[usageKey]model.UsageStat{}}
+}
+
+func (repository *memoryUsageRepository) Add(ctx context.Context, stats []*model.UsageStat) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, stat := range stats {
+		key := usageKey{bucket: stat.Bucket.UTC(), method: stat.Method, route: stat.Route, consumer: stat.Consumer}
+		saved, ok := repository.stats[key]
+		if !ok {
+			saved = model.UsageStat{Bucket: key.bucket, Method: stat.Method, Route: stat.Route, Consumer: stat.Consumer}
+		}
+		saved.Add(stat)
+		repository.stats[key] = saved
+	}
+	return nil
+}
+
+func (repository *memoryUsageRepository) List(ctx context.Context, from, to time.Time) ([]*model.UsageStat, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	stats := []*model.UsageStat{}
+	for _, stat := range repository.stats {
+		if !stat.Bucket.Before(from) && stat.Bucket.Before(to) {
+			stats = append(stats, &stat)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		left, right := stats[i], stats[j]
+		if !left.Bucket.Equal(right.Bucket) {
+			return left.Bucket.Before(right.Bucket)
+		}
+		if left.Route != right.Route {
+			return left.Route < right.Route
+		}
+		if left.Method != right.Method {
+			return left.Method < right.Method
+		}
+		return left.Consumer < right.Consumer
+	})
+	return stats, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"time"
+)
+
+type postgresUsageRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresUsageRepository(db *database.Cluster) UsageRepository {
+	return &postgresUsageRepository{db: db}
+}
+
+// Add upserts the stats, so that every process of a prefork or a cluster adds
+// to the same rows.
+func (repository *postgresUsageRepository) Add(ctx context.Context, stats []*model.UsageStat) error {
+	return inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		for _, stat := range stats {
+			_, err := tx.ExecContext(ctx, `INSERT INTO usage_stats
+(bucket, method, route, consumer, requests, status_2xx, status_3xx, status_4xx, status_5xx, latency_total_us, latency_max_us)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (bucket, method, route, consumer) DO UPDATE SET
+requests = usage_stats.requests + EXCLUDED.requests,
+status_2xx = usage_stats.status_2xx + EXCLUDED.status_2xx,
+status_3xx = usage_stats.status_3xx + EXCLUDED.status_3xx,
+status_4xx = usage_stats.status_4xx + EXCLUDED.status_4xx,
+status_5xx = usage_stats.status_5xx + EXCLUDED.status_5xx,
+latency_total_us = usage_stats.latency_total_us + EXCLUDED.latency_total_us,
+latency_max_us = GREATEST(usage_stats.latency_max_us, EXCLUDED.latency_max_us)`,
+				stat.Bucket, stat.Method, stat.Route, stat.Consumer, stat.Requests,
+				stat.Status2xx, stat.Status3xx, stat.Status4xx, stat.Status5xx,
+				stat.LatencyTotal.Microseconds(), stat.LatencyMax.Microseconds())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (repository *postgresUsageRepository) List(ctx context.Context, from, to time.Time) ([]*model.UsageStat, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, `SELECT bucket, method, route, consumer, requests,
+status_2xx, status_3xx, status_4xx, status_5xx, latency_total_us, latency_max_us
+FROM usage_stats WHERE bucket >= $1 AND bucket < $2 ORDER BY bucket, route, method, consumer`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*model.UsageStat{}
+	for rows.Next() {
+		stat := &model.UsageStat{}
+		var latencyTotal, latencyMax int64
+		err = rows.Scan(&stat.Bucket, &stat.Method, &stat.Route, &stat.Consumer, &stat.Requests,
+			&stat.Status2xx, &stat.Status3xx, &stat.Status4xx, &stat.Status5xx, &latencyTotal, &latencyMax)
+		if err != nil {
+			return nil, err
+		}
+		stat.LatencyTotal = time.Duration(latencyTotal) * time.Microsecond
+		stat.LatencyMax = time.Duration(latencyMax) * time.Microsecond
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserRepository keeps the users of each tenant apart: its methods only see
+// and create the users of the tenant of their ctx.
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	// CreateMany creates all users or, on error, none of them.
+	CreateMany(ctx context.Context, users []*model.User) error
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindByIdentity(ctx context.Context, provider, subject string) (*model.User, error)
+	// FindByIDWithDeleted is FindByID that also finds soft-deleted users.
+	FindByIDWithDeleted(ctx context.Context, id string) (*model.User, error)
+	// Update saves user if its Version is still the stored one, and increments
+	// Version. Otherwise it fails with model.ErrVersionConflict.
+	Update(ctx context.Context, user *model.User) error
+	// SetDeleted soft-deletes the user at deletedAt, or restores it when
+	// deletedAt is nil. Soft-deleted users are only found by
+	// FindByIDWithDeleted and by List with spec.IncludeDeleted.
+	SetDeleted(ctx context.Context, id string, deletedAt *time.Time) error
+	// SetPasswordHash replaces the password hash of the user, leaving its
+	// Version as it is.
+	SetPasswordHash(ctx context.Context, id, hash string) error
+	// SetAvatar replaces the avatar version of the user, leaving its Version
+	// as it is.
+	SetAvatar(ctx context.Context, id, avatar string) error
+	LinkIdentity(ctx context.Context, userID string, identity model.Identity) error
+	List(ctx context.Context, spec *model.ListSpec) ([]*model.User, int, error)
+	// Each calls fn for every user matching the filters and sort of spec,
+	// ignoring pagination, without loading them all at once. The users come
+	// without roles and identities.
+	Each(ctx context.Context, spec *model.ListSpec, fn func(user *model.User) error) error
+}
+
+type memoryUserRepository struct {
+	mutex sync.RWMutex
+	users map[string]*model.User
+}
+
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: map[string]*model.User{}}
+}
+
+func (repository *memoryUserRepository) Create(ctx context.Context, user *model.User) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	user.TenantID = tenant.From(ctx)
+	if existing, ok := repository.users[user.ID]; ok && existing.TenantID != user.TenantID {
+		return errors.New("user " + user.ID + " belongs to another tenant")
+	}
+
+	repository.users[user.ID] = copyUser(user)
+	return nil
+}
+
+func (repository *memoryUserRepository) CreateMany(ctx context.Context, users []*model.User) error {
+	for _, user := range users {
+		err := repository.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repository *memoryUserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	user, ok := repository.find(ctx, id)
+	if !ok || user.DeletedAt != nil {
+		return nil, model.ErrUserNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (repository *memoryUserRepository) FindByIDWithDeleted(ctx context.Context, id string) (*model.User, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	user, ok := repository.find(ctx, id)
+	if !ok {
+		return nil, model.ErrUserNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (repository *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	for _, user := range repository.users {
+		if user.TenantID == tenant.From(ctx) && user.DeletedAt == nil && email != "" && strings.EqualFold(user.Email, email) {
+			return copyUser(user), nil
+		}
+	}
+	return nil, model.ErrUserNotFound
+}
+
+func (repository *memoryUserRepository) FindByIdentity(ctx context.Context, provider, subject string) (*model.User, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	for _, user := range repository.users {
+		if user.TenantID != tenant.From(ctx) || user.DeletedAt != nil {
+			continue
+		}
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				return copyUser(user), nil
+			}
+		}
+	}
+	return nil, model.ErrUserNotFound
+}
+
+func (repository *memoryUserRepository) Update(ctx context.Context, user *model.User) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	existing, ok := repository.find(ctx, user.ID)
+	if !ok || existing.DeletedAt != nil {
+		return model.ErrUserNotFound
+	}
+	if existing.Version != user.Version {
+		return model.ErrVersionConflict
+	}
+	user.Version++
+	user.TenantID = existing.TenantID
+	repository.users[user.ID] = copyUser(user)
+	return nil
+}
+
+func (repository *memoryUserRepository) SetDeleted(ctx context.Context, id string, deletedAt *time.Time) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	user, ok := repository.find(ctx, id)
+	if !ok {
+		return model.ErrUserNotFound
+	}
+	user.DeletedAt = deletedAt
+	user.Version++
+	return nil
+}
+
+func (repository *memoryUserRepository) SetPasswordHash(ctx context.Context, id, hash string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	user, ok := repository.find(ctx, id)
+	if !ok || user.DeletedAt != nil {
+		return model.ErrUserNotFound
+	}
+	user.PasswordHash = hash
+	return nil
+}
+
+func (repository *memoryUserRepository) SetAvatar(ctx context.Context, id, avatar string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	user, ok := repository.find(ctx, id)
+	if !ok || user.DeletedAt != nil {
+		return model.ErrUserNotFound
+	}
+	user.Avatar = avatar
+	return nil
+}
+
+func (repository *memoryUserRepository) LinkIdentity(ctx context.Context, userID string, identity model.Identity) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	user, ok := repository.find(ctx, userID)
+	if !ok {
+		return model.ErrUserNotFound
+	}
+	user.Identities = append(user.Identities, identity)
+	return nil
+}
+
+func (repository *memoryUserRepository) List(ctx context.Context, spec *model.ListSpec) ([]*model.User, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var users []*model.User
+	for _, user := range repository.users {
+		if user.TenantID == tenant.From(ctx) && (user.DeletedAt == nil || spec.IncludeDeleted) && matchesFilters(userFields(user), spec.Filters) &&
+			matchesSearch(spec.Search, user.Username, user.Email, user.Name) {
+			users = append(users, copyUser(user))
+		}
+	}
+
+	sortFields := append(append([]model.SortField(nil), spec.Sort...), model.SortField{Field: "id"})
+	sort.SliceStable(users, func(i, j int) bool {
+		left, right := userFields(users[i]), userFields(users[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(users)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return users[start:end], total, nil
+}
+
+func (repository *memoryUserRepository) Each(ctx context.Context, spec *model.ListSpec, fn func(user *model.User) error) error {
+	all := *spec
+	all.Page, all.PerPage, all.CursorMode = 1, len(repository.users)+1, false
+	users, _, err := repository.List(ctx, &all)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		user.Roles, user.Identities = nil, nil
+		err = fn(user)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find returns the user with id if it belongs to the tenant of ctx. The
+// caller holds the mutex.
+func (repository *memoryUserRepository) find(ctx context.Context, id string) (*model.User, bool) {
+	user, ok := repository.users[id]
+	if !ok || user.TenantID != tenant.From(ctx) {
+		return nil, false
+	}
+	return user, true
+}
+
+func userFields(user *model.User) map[string]string {
+	return map[string]string{
+		"id":         user.ID,
+		"username":   user.Username,
+		"email":      user.Email,
+		"name":       user.Name,
+		"created_at": user.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
+
+func matchesFilters(fields map[string]string, filters map[string]string) bool {
+	for field, value := range filters {
+		if !strings.EqualFold(fields[field], value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesSearch(search string, values ...string) bool {
+	if search == "" {
+		return true
+	}
+	search = strings.ToLower(search)
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), search) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyUser(user *model.User) *model.User {
+	result := *user
+	result.Roles = append([]string(nil), user.Roles...)
+	result.Identities = append([]model.Identity(nil), user.Identities...)
+	if user.DeletedAt != nil {
+		deletedAt := *user.DeletedAt
+		result.DeletedAt = &deletedAt
+	}
+	return &result
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/model"
+	"time"
+)
+
+const usersQueryCache = "users"
+
+// cachedUserRepository caches the lists of users, which come without their
+// password hashes. The users found one by one are not cached, as they come
+// with theirs.
+type cachedUserRepository struct {
+	UserRepository
+	cache *queryCache
+}
+
+func NewCachedUserRepository(users UserRepository, store cache.Store, ttl time.Duration) UserRepository {
+	return &cachedUserRepository{UserRepository: users, cache: &queryCache{store: store, repository: usersQueryCache, ttl: ttl}}
+}
+
+// InvalidateCachedUsers drops the cached lists of users, for the changes made
+// without the cached repository.
+func InvalidateCachedUsers(store cache.Store) {
+	(&queryCache{store: store, repository: usersQueryCache}).invalidate()
+}
+
+type userPage struct {
+	Users []*model.User `json:"users"`
+	Total int           `json:"total"`
+}
+
+func (repository *cachedUserRepository) List(ctx context.Context, spec *model.ListSpec) ([]*model.User, int, error) {
+	page, err := cached(ctx, repository.cache, "List", []any{spec}, []string{repository.cache.listTag()}, func() (userPage, error) {
+		users, total, err := repository.UserRepository.List(ctx, spec)
+		for _, user := range users {
+			user.PasswordHash = ""
+		}
+		return userPage{Users: users, Total: total}, err
+	})
+	return page.Users, page.Total, err
+}
+
+func (repository *cachedUserRepository) Create(ctx context.Context, user *model.User) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.UserRepository.Create(ctx, user)
+}
+
+func (repository *cachedUserRepository) CreateMany(ctx context.Context, users []*model.User) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.UserRepository.CreateMany(ctx, users)
+}
+
+func (repository *cachedUserRepository) Update(ctx context.Context, user *model.User) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.UserRepository.Update(ctx, user)
+}
+
+func (repository *cachedUserRepository) SetDeleted(ctx context.Context, id string, deletedAt *time.Time) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.UserRepository.SetDeleted(ctx, id, deletedAt)
+}
+
+func (repository *cachedUserRepository) SetAvatar(ctx context.Context, id, avatar string) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.UserRepository.SetAvatar(ctx, id, avatar)
+}
+
+func (repository *cachedUserRepository) LinkIdentity(ctx context.Context, userID string, identity model.Identity) error {
+	defer repository.cache.invalidateAfterCommit(ctx)
+	return repository.UserRepository.LinkIdentity(ctx, userID, identity)
+}
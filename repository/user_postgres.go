@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var userColumns = map[string]string{
+	"id":         "id",
+	"username":   "username",
+	"email":      "email",
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+const userSelectColumns = "id, tenant_id, username, email, name, version, created_at, deleted_at, password_hash, avatar"
+
+type postgresUserRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresUserRepository(db *database.Cluster) UserRepository {
+	return &postgresUserRepository{db: db}
+}
+
+// userInsertBatchSize bounds the rows of one INSERT, well below the 65535
+// parameters Postgres accepts per statement.
+const userInsertBatchSize = 500
+
+func (repository *postgresUserRepository) Create(ctx context.Context, user *model.User) error {
+	return repository.CreateMany(ctx, []*model.User{user})
+}
+
+// CreateMany inserts the users in batches of userInsertBatchSize rows within
+// one transaction.
+func (repository *postgresUserRepository) CreateMany(ctx context.Context, users []*model.User) error {
+	for _, user := range users {
+		user.TenantID = tenant.From(ctx)
+		if user.ID == "" {
+			user.ID = uuid.NewString()
+		}
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = time.Now()
+		}
+		if user.Version == 0 {
+			user.Version = 1
+		}
+	}
+
+	return inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		for batch := range slices.Chunk(users, userInsertBatchSize) {
+			var values []string
+			var args []interface{}
+			for _, user := range batch {
+				n := len(args)
+				values = append(values, "($"+strconv.Itoa(n+1)+", $"+strconv.Itoa(n+2)+", $"+strconv.Itoa(n+3)+
+					", $"+strconv.Itoa(n+4)+", $"+strconv.Itoa(n+5)+", $"+strconv.Itoa(n+6)+", $"+strconv.Itoa(n+7)+", $"+strconv.Itoa(n+8)+", $"+strconv.Itoa(n+9)+")")
+				args = append(args, user.ID, user.TenantID, user.Username, user.Email, user.Name, user.Version, user.CreatedAt, user.PasswordHash, user.Avatar)
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO users (id, tenant_id, username, email, name, version, created_at, password_hash, avatar) VALUES "+strings.Join(values, ", "), args...)
+			if err != nil {
+				return err
+			}
+		}
+		for _, user := range users {
+			for _, identity := range user.Identities {
+				_, err := tx.ExecContext(ctx, "INSERT INTO user_identities (provider, subject, user_id) VALUES ($1, $2, $3)",
+					identity.Provider, identity.Subject, user.ID)
+				if err != nil {
+					return err
+				}
+			}
+			err := insertUserRoles(ctx, tx, user)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (repository *postgresUserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrUserNotFound
+	}
+	return repository.findOne(ctx, "SELECT "+userSelectColumns+" FROM users WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL", id, tenant.From(ctx))
+}
+
+func (repository *postgresUserRepository) FindByIDWithDeleted(ctx context.Context, id string) (*model.User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrUserNotFound
+	}
+	return repository.findOne(ctx, "SELECT "+userSelectColumns+" FROM users WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+}
+
+func (repository *postgresUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	if email == "" {
+		return nil, model.ErrUserNotFound
+	}
+	return repository.findOne(ctx, "SELECT "+userSelectColumns+" FROM users WHERE LOWER(email) = LOWER($1) AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1", email, tenant.From(ctx))
+}
+
+func (repository *postgresUserRepository) FindByIdentity(ctx context.Context, provider, subject string) (*model.User, error) {
+	return repository.findOne(ctx, `SELECT u.id, u.tenant_id, u.username, u.email, u.name, u.version, u.created_at, u.deleted_at, u.password_hash, u.avatar FROM users u
+JOIN user_identities i ON i.user_id = u.id WHERE i.provider = $1 AND i.subject = $2 AND u.tenant_id = $3 AND u.deleted_at IS NULL`, provider, subject, tenant.From(ctx))
+}
+
+func (repository *postgresUserRepository) Update(ctx context.Context, user *model.User) error {
+	err := inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		var current int
+		err := tx.QueryRowContext(ctx, "SELECT version FROM users WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE",
+			user.ID, tenant.From(ctx)).Scan(&current)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if current != user.Version {
+			return model.ErrVersionConflict
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET username = $1, email = $2, name = $3, version = version + 1 WHERE id = $4",
+			user.Username, user.Email, user.Name, user.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1", user.ID)
+		if err != nil {
+			return err
+		}
+		return insertUserRoles(ctx, tx, user)
+	})
+	if err != nil {
+		return err
+	}
+	user.Version++
+	return nil
+}
+
+func (repository *postgresUserRepository) SetDeleted(ctx context.Context, id string, deletedAt *time.Time) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrUserNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND tenant_id = $3",
+		deletedAt, id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrUserNotFound
+	}
+	return nil
+}
+
+func (repository *postgresUserRepository) SetPasswordHash(ctx context.Context, id, hash string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrUserNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL",
+		hash, id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrUserNotFound
+	}
+	return nil
+}
+
+func (repository *postgresUserRepository) SetAvatar(ctx context.Context, id, avatar string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrUserNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "UPDATE users SET avatar = $1 WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL",
+		avatar, id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrUserNotFound
+	}
+	return nil
+}
+
+func (repository *postgresUserRepository) LinkIdentity(ctx context.Context, userID string, identity model.Identity) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return model.ErrUserNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO user_identities (provider, subject, user_id) SELECT $1, $2, id FROM users WHERE id = $3 AND tenant_id = $4",
+		identity.Provider, identity.Subject, userID, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrUserNotFound
+	}
+	return nil
+}
+
+func (repository *postgresUserRepository) List(ctx context.Context, spec *model.ListSpec) ([]*model.User, int, error) {
+	where, orderBy, args, err := userListClauses(tenant.From(ctx), spec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	query := "SELECT " + userSelectColumns + " FROM users" + where + orderBy +
+		" LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args))
+	users, err := repository.query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (repository *postgresUserRepository) Each(ctx context.Context, spec *model.ListSpec, fn func(user *model.User) error) error {
+	where, orderBy, args, err := userListClauses(tenant.From(ctx), spec)
+	if err != nil {
+		return err
+	}
+
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT "+userSelectColumns+" FROM users"+where+orderBy, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &model.User{}
+		err = rows.Scan(&user.ID, &user.TenantID, &user.Username, &user.Email, &user.Name, &user.Version, &user.CreatedAt, &user.DeletedAt, &user.PasswordHash, &user.Avatar)
+		if err != nil {
+			return err
+		}
+		err = fn(user)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, with the default
+// backslash escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// userListClauses builds the WHERE and ORDER BY clauses selecting the users
+// of spec in tenantID, and the arguments of the WHERE clause.
+func userListClauses(tenantID string, spec *model.ListSpec) (string, string, []interface{}, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	if !spec.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	for field, value := range spec.Filters {
+		column, ok := userColumns[field]
+		if !ok {
+			return "", "", nil, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+") = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	if spec.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(spec.Search)+"%")
+		placeholder := "$" + strconv.Itoa(len(args))
+		conditions = append(conditions, "(username ILIKE "+placeholder+" OR email ILIKE "+placeholder+" OR name ILIKE "+placeholder+")")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), spec.Sort...), model.SortField{Field: "id"}) {
+		column, ok := userColumns[field.Field]
+		if !ok {
+			return "", "", nil, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+	return where, " ORDER BY " + strings.Join(orders, ", "), args, nil
+}
+
+func (repository *postgresUserRepository) findOne(ctx context.Context, query string, args ...interface{}) (*model.User, error) {
+	users, err := repository.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, model.ErrUserNotFound
+	}
+	return users[0], nil
+}
+
+func (repository *postgresUserRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.User, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	byID := map[string]*model.User{}
+	var ids []string
+	for rows.Next() {
+		user := &model.User{}
+		err = rows.Scan(&user.ID, &user.TenantID, &user.Username, &user.Email, &user.Name, &user.Version, &user.CreatedAt, &user.DeletedAt, &user.PasswordHash, &user.Avatar)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+		byID[user.ID] = user
+		ids = append(ids, user.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	identities, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT provider, subject, user_id FROM user_identities WHERE user_id = ANY($1::uuid[]) ORDER BY provider", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer identities.Close()
+
+	for identities.Next() {
+		var identity model.Identity
+		var userID string
+		err = identities.Scan(&identity.Provider, &identity.Subject, &userID)
+		if err != nil {
+			return nil, err
+		}
+		byID[userID].Identities = append(byID[userID].Identities, identity)
+	}
+	if err = identities.Err(); err != nil {
+		return nil, err
+	}
+
+	roles, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT role, user_id FROM user_roles WHERE user_id = ANY($1::uuid[]) ORDER BY role", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer roles.Close()
+
+	for roles.Next() {
+		var role, userID string
+		err = roles.Scan(&role, &userID)
+		if err != nil {
+			return nil, err
+		}
+		byID[userID].Roles = append(byID[userID].Roles, role)
+	}
+	return users, roles.Err()
+}
+
+func insertUserRoles(ctx context.Context, tx *sql.Tx, user *model.User) error {
+	for _, role := range user.Roles {
+		_, err := tx.ExecContext(ctx, "INSERT INTO user_roles (user_id, role) VALUES ($1, $2)", user.ID, role)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
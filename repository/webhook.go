@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebhookRepository only sees and creates the webhooks of the tenant of ctx.
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	FindByID(ctx context.Context, id string) (*model.Webhook, error)
+	// FindByEvent returns the webhooks subscribed to the event named name.
+	FindByEvent(ctx context.Context, name string) ([]*model.Webhook, error)
+	List(ctx context.Context) ([]*model.Webhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookDeliveryRepository stores the deliveries of the webhooks. Claim
+// returns the pending deliveries due at now, up to limit, and postpones them
+// by lease so no one else claims them while they are being sent; a delivery
+// whose sender dies is retried once the lease is over. Deleting a webhook
+// deletes its deliveries. CountByStatus counts the deliveries of every webhook
+// by status. Claim and CountByStatus span all tenants, the other methods only
+// the tenant of ctx.
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, deliveries ...*model.WebhookDelivery) error
+	Update(ctx context.Context, delivery *model.WebhookDelivery) error
+	FindByID(ctx context.Context, webhookID, id string) (*model.WebhookDelivery, error)
+	List(ctx context.Context, webhookID string, spec *model.ListSpec) ([]*model.WebhookDelivery, int, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error)
+	CountByStatus(ctx context.Context) (map[string]int, error)
+}
+
+var defaultDeliverySort = []model.SortField{{Field: "created_at", Desc: true}}
+
+type memoryWebhookRepository struct {
+	mutex      sync.RWMutex
+	webhooks   map[string]model.Webhook
+	deliveries *memoryWebhookDeliveryRepository
+}
+
+// NewMemoryWebhookRepositories returns the memory repositories of webhooks
+// and their deliveries, which share their data to delete deliveries along
+// with their webhook.
+func NewMemoryWebhookRepositories() (WebhookRepository, WebhookDeliveryRepository) {
+	deliveries := &memoryWebhookDeliveryRepository{deliveries: map[string]model.WebhookDelivery{}}
+	return &memoryWebhookRepository{webhooks: map[string]model.Webhook{}, deliveries: deliveries}, deliveries
+}
+
+func (repository *memoryWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewString()
+	}
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = time.Now()
+	}
+	webhook.TenantID = tenant.From(ctx)
+	saved := *webhook
+	saved.Events = slices.Clone(webhook.Events)
+	repository.webhooks[webhook.ID] = saved
+	return nil
+}
+
+func (repository *memoryWebhookRepository) FindByID(ctx context.Context, id string) (*model.Webhook, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	webhook, ok := repository.webhooks[id]
+	if !ok || webhook.TenantID != tenant.From(ctx) {
+		return nil, model.ErrWebhookNotFound
+	}
+	webhook.Events = slices.Clone(webhook.Events)
+	return &webhook, nil
+}
+
+func (repository *memoryWebhookRepository) FindByEvent(ctx context.Context, name string) ([]*model.Webhook, error) {
+	webhooks, err := repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(webhooks, func(webhook *model.Webhook) bool {
+		return !slices.Contains(webhook.Events, name)
+	}), nil
+}
+
+func (repository *memoryWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var webhooks []*model.Webhook
+	for _, webhook := range repository.webhooks {
+		if webhook.TenantID != tenant.From(ctx) {
+			continue
+		}
+		webhook.Events = slices.Clone(webhook.Events)
+		webhooks = append(webhooks, &webhook)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID < webhooks[j].ID
+	})
+	return webhooks, nil
+}
+
+func (repository *memoryWebhookRepository) Delete(ctx context.Context, id string) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if webhook, ok := repository.webhooks[id]; !ok || webhook.TenantID != tenant.From(ctx) {
+		return model.ErrWebhookNotFound
+	}
+	delete(repository.webhooks, id)
+	repository.deliveries.deleteWebhook(id)
+	return nil
+}
+
+type memoryWebhookDeliveryRepository struct {
+	mutex      sync.RWMutex
+	deliveries map[string]model.WebhookDelivery
+}
+
+func (repository *memoryWebhookDeliveryRepository) Create(ctx context.Context, deliveries ...*model.WebhookDelivery) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for _, delivery := range deliveries {
+		prepareDelivery(delivery)
+		delivery.TenantID = tenant.From(ctx)
+		repository.deliveries[delivery.ID] = *delivery
+	}
+	return nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	saved, ok := repository.deliveries[delivery.ID]
+	if !ok || saved.TenantID != tenant.From(ctx) {
+		return model.ErrDeliveryNotFound
+	}
+	delivery.TenantID = saved.TenantID
+	delivery.UpdatedAt = time.Now()
+	repository.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) FindByID(ctx context.Context, webhookID, id string) (*model.WebhookDelivery, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	delivery, ok := repository.deliveries[id]
+	if !ok || delivery.TenantID != tenant.From(ctx) || delivery.WebhookID != webhookID {
+		return nil, model.ErrDeliveryNotFound
+	}
+	return &delivery, nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) List(ctx context.Context, webhookID string, spec *model.ListSpec) ([]*model.WebhookDelivery, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var deliveries []*model.WebhookDelivery
+	for _, delivery := range repository.deliveries {
+		if delivery.TenantID == tenant.From(ctx) && delivery.WebhookID == webhookID && matchesFilters(deliveryFields(&delivery), spec.Filters) {
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultDeliverySort
+	}
+	sortFields = append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"})
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		left, right := deliveryFields(deliveries[i]), deliveryFields(deliveries[j])
+		for _, field := range sortFields {
+			if left[field.Field] != right[field.Field] {
+				return (left[field.Field] < right[field.Field]) != field.Desc
+			}
+		}
+		return false
+	})
+
+	total := len(deliveries)
+	start := min(spec.Offset(), total)
+	end := min(start+spec.PerPage, total)
+	return deliveries[start:end], total, nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	var due []*model.WebhookDelivery
+	for _, delivery := range repository.deliveries {
+		if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, &delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	due = due[:min(limit, len(due))]
+	for _, delivery := range due {
+		delivery.NextAttemptAt = now.Add(lease)
+		repository.deliveries[delivery.ID] = *delivery
+	}
+	return due, nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	counts := map[string]int{}
+	for _, delivery := range repository.deliveries {
+		counts[delivery.Status]++
+	}
+	return counts, nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) deleteWebhook(webhookID string) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	for id, delivery := range repository.deliveries {
+		if delivery.WebhookID == webhookID {
+			delete(repository.deliveries, id)
+		}
+	}
+}
+
+func prepareDelivery(delivery *model.WebhookDelivery) {
+	if delivery.ID == "" {
+		delivery.ID = uuid.NewString()
+	}
+	now := time.Now()
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = now
+	}
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = now
+	}
+	if delivery.Status == "" {
+		delivery.Status = model.DeliveryPending
+	}
+	delivery.UpdatedAt = now
+}
+
+func deliveryFields(delivery *model.WebhookDelivery) map[string]string {
+	return map[string]string{
+		"id":         delivery.ID,
+		"event":      delivery.Event,
+		"status":     delivery.Status,
+		"created_at": delivery.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000"),
+	}
+}
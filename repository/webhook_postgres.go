@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/database"
+	"golang-fiber-web/model"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var deliveryColumns = map[string]string{
+	"id":         "id",
+	"event":      "event",
+	"status":     "status",
+	"created_at": "created_at",
+}
+
+type postgresWebhookRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresWebhookRepository(db *database.Cluster) WebhookRepository {
+	return &postgresWebhookRepository{db: db}
+}
+
+func (repository *postgresWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewString()
+	}
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = time.Now()
+	}
+	webhook.TenantID = tenant.From(ctx)
+
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, repository.db).ExecContext(ctx, "INSERT INTO webhooks (id, tenant_id, url, secret, events, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		webhook.ID, webhook.TenantID, webhook.URL, webhook.Secret, events, webhook.CreatedAt)
+	return err
+}
+
+func (repository *postgresWebhookRepository) FindByID(ctx context.Context, id string) (*model.Webhook, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrWebhookNotFound
+	}
+	webhooks, err := repository.query(ctx, "SELECT "+webhookSelect+" FROM webhooks WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) == 0 {
+		return nil, model.ErrWebhookNotFound
+	}
+	return webhooks[0], nil
+}
+
+func (repository *postgresWebhookRepository) FindByEvent(ctx context.Context, name string) ([]*model.Webhook, error) {
+	events, err := json.Marshal([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	return repository.query(ctx, "SELECT "+webhookSelect+" FROM webhooks WHERE events @> $1 AND tenant_id = $2 ORDER BY created_at, id", events, tenant.From(ctx))
+}
+
+func (repository *postgresWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	return repository.query(ctx, "SELECT "+webhookSelect+" FROM webhooks WHERE tenant_id = $1 ORDER BY created_at, id", tenant.From(ctx))
+}
+
+func (repository *postgresWebhookRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.ErrWebhookNotFound
+	}
+	result, err := conn(ctx, repository.db).ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND tenant_id = $2", id, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrWebhookNotFound
+	}
+	return nil
+}
+
+const webhookSelect = "id, tenant_id, url, secret, events, created_at"
+
+func (repository *postgresWebhookRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.Webhook, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*model.Webhook
+	for rows.Next() {
+		webhook := &model.Webhook{}
+		var events []byte
+		err = rows.Scan(&webhook.ID, &webhook.TenantID, &webhook.URL, &webhook.Secret, &events, &webhook.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(events, &webhook.Events)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+type postgresWebhookDeliveryRepository struct {
+	db *database.Cluster
+}
+
+func NewPostgresWebhookDeliveryRepository(db *database.Cluster) WebhookDeliveryRepository {
+	return &postgresWebhookDeliveryRepository{db: db}
+}
+
+func (repository *postgresWebhookDeliveryRepository) Create(ctx context.Context, deliveries ...*model.WebhookDelivery) error {
+	return inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		for _, delivery := range deliveries {
+			prepareDelivery(delivery)
+			delivery.TenantID = tenant.From(ctx)
+			_, err := tx.ExecContext(ctx, `INSERT INTO webhook_deliveries
+(id, tenant_id, webhook_id, event, payload, status, attempts, status_code, error, next_attempt_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+				delivery.ID, delivery.TenantID, delivery.WebhookID, delivery.Event, []byte(delivery.Payload), delivery.Status, delivery.Attempts,
+				delivery.StatusCode, delivery.Error, delivery.NextAttemptAt, delivery.CreatedAt, delivery.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (repository *postgresWebhookDeliveryRepository) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now()
+	result, err := conn(ctx, repository.db).ExecContext(ctx, `UPDATE webhook_deliveries
+SET status = $1, attempts = $2, status_code = $3, error = $4, next_attempt_at = $5, updated_at = $6 WHERE id = $7 AND tenant_id = $8`,
+		delivery.Status, delivery.Attempts, delivery.StatusCode, delivery.Error, delivery.NextAttemptAt, delivery.UpdatedAt, delivery.ID, tenant.From(ctx))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return model.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (repository *postgresWebhookDeliveryRepository) FindByID(ctx context.Context, webhookID, id string) (*model.WebhookDelivery, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, model.ErrDeliveryNotFound
+	}
+	if _, err := uuid.Parse(webhookID); err != nil {
+		return nil, model.ErrDeliveryNotFound
+	}
+	deliveries, err := queryDeliveries(ctx, reader(ctx, repository.db), "SELECT "+deliverySelect+" FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2 AND tenant_id = $3", id, webhookID, tenant.From(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, model.ErrDeliveryNotFound
+	}
+	return deliveries[0], nil
+}
+
+func (repository *postgresWebhookDeliveryRepository) List(ctx context.Context, webhookID string, spec *model.ListSpec) ([]*model.WebhookDelivery, int, error) {
+	if _, err := uuid.Parse(webhookID); err != nil {
+		return nil, 0, nil
+	}
+	conditions := []string{"webhook_id = $1", "tenant_id = $2"}
+	args := []interface{}{webhookID, tenant.From(ctx)}
+	for field, value := range spec.Filters {
+		column, ok := deliveryColumns[field]
+		if !ok {
+			return nil, 0, errors.New("unknown filter field " + field)
+		}
+		args = append(args, value)
+		conditions = append(conditions, "LOWER("+column+") = LOWER($"+strconv.Itoa(len(args))+")")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := reader(ctx, repository.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_deliveries"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortFields := spec.Sort
+	if len(sortFields) == 0 {
+		sortFields = defaultDeliverySort
+	}
+	var orders []string
+	for _, field := range append(append([]model.SortField(nil), sortFields...), model.SortField{Field: "id"}) {
+		column, ok := deliveryColumns[field.Field]
+		if !ok {
+			return nil, 0, errors.New("unknown sort field " + field.Field)
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		orders = append(orders, column)
+	}
+
+	args = append(args, spec.PerPage, spec.Offset())
+	deliveries, err := queryDeliveries(ctx, reader(ctx, repository.db), "SELECT "+deliverySelect+" FROM webhook_deliveries"+where+
+		" ORDER BY "+strings.Join(orders, ", ")+
+		" LIMIT $"+strconv.Itoa(len(args)-1)+" OFFSET $"+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (repository *postgresWebhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := inTransaction(ctx, repository.db, func(tx *sql.Tx) error {
+		var err error
+		deliveries, err = queryDeliveries(ctx, tx, "SELECT "+deliverySelect+` FROM webhook_deliveries
+WHERE status = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED`, model.DeliveryPending, now, limit)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		var ids []string
+		for _, delivery := range deliveries {
+			delivery.NextAttemptAt = now.Add(lease)
+			ids = append(ids, delivery.ID)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id = ANY($2::uuid[])", now.Add(lease), ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+const deliverySelect = "id, tenant_id, webhook_id, event, payload, status, attempts, status_code, error, next_attempt_at, created_at, updated_at"
+
+func queryDeliveries(ctx context.Context, db executor, query string, args ...interface{}) ([]*model.WebhookDelivery, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		delivery := &model.WebhookDelivery{}
+		var payload []byte
+		err = rows.Scan(&delivery.ID, &delivery.TenantID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+			&delivery.StatusCode, &delivery.Error, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (repository *postgresWebhookDeliveryRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := reader(ctx, repository.db).QueryContext(ctx, "SELECT status, COUNT(*) FROM webhook_deliveries GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		err = rows.Scan(&status, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,26 @@
+package rpc
+
+import (
+	"context"
+	"golang-fiber-web/proto/pb"
+	"golang-fiber-web/service"
+)
+
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	auth service.AuthService
+}
+
+func (server *authServer) Provision(ctx context.Context, request *pb.ProvisionRequest) (*pb.User, error) {
+	user, err := server.auth.Provision(ctx, request.Provider, &service.OAuthProfile{
+		Subject:       request.Subject,
+		Email:         request.Email,
+		EmailVerified: request.EmailVerified,
+		Username:      request.Username,
+		Name:          request.Name,
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return pb.FromUser(user), nil
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang-fiber-web/config"
+	"golang-fiber-web/middleware"
+	"golang-fiber-web/model"
+	"golang-fiber-web/proto/pb"
+	"golang-fiber-web/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"net"
+	"strings"
+)
+
+const metadataRequestID = "x-request-id"
+
+// NewServer builds the gRPC server exposing the user and auth services next
+// to the standard health and reflection services. Every call except health
+// checks needs the admin basic auth credentials in the authorization
+// metadata, like the admin endpoints of the HTTP API.
+func NewServer(users service.UserService, auth service.AuthService, admin config.AdminConfig) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(newAuthInterceptor(admin)))
+	pb.RegisterUserServiceServer(server, &userServer{users: users})
+	pb.RegisterAuthServiceServer(server, &authServer{auth: auth})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(pb.AuthService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	return server
+}
+
+// newAuthInterceptor checks the admin credentials and puts the audit metadata
+// in the context, as middleware.NewAuditContext and middleware.NewAdminAuth do
+// for HTTP requests.
+func newAuthInterceptor(admin config.AdminConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+			return handler(ctx, request)
+		}
+
+		incoming, _ := metadata.FromIncomingContext(ctx)
+		if admin.Password == "" || !middleware.ValidBasicAuth(first(incoming, "authorization"), admin.Username, admin.Password) {
+			return nil, status.Error(codes.Unauthenticated, "admin credentials required")
+		}
+
+		requestID := first(incoming, metadataRequestID)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		clientIP := ""
+		if client, ok := peer.FromContext(ctx); ok && client.Addr != nil {
+			clientIP, _, _ = net.SplitHostPort(client.Addr.String())
+		}
+		ctx = service.WithAuditMetadata(ctx, service.AuditMetadata{Actor: admin.Username, IP: clientIP, RequestID: requestID})
+		return handler(ctx, request)
+	}
+}
+
+func first(incoming metadata.MD, key string) string {
+	values := incoming.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// statusError maps service errors onto gRPC status codes, as the HTTP
+// handlers map them onto status codes.
+func statusError(err error) error {
+	var validationErrors model.ValidationErrors
+	var fiberError *fiber.Error
+	switch {
+	case errors.Is(err, model.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, model.ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.As(err, &validationErrors):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &fiberError) && fiberError.Code < fiber.StatusInternalServerError:
+		return status.Error(codes.InvalidArgument, fiberError.Message)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
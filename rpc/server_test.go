@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/proto/pb"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+func newTestClient(t *testing.T, users repository.UserRepository) *grpc.ClientConn {
+	audit := service.NewAuditService(repository.NewMemoryAuditRepository())
+	server := NewServer(service.NewUserService(users, repository.NewMemoryTransactor(), audit, event.Discard), service.NewAuthService(users, repository.NewMemoryTransactor(), audit, event.Discard), config.AdminConfig{Username: "admin", Password: "secret"})
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func adminContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("admin:secret")))
+}
+
+func TestUserService(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	client := pb.NewUserServiceClient(newTestClient(t, users))
+
+	_, err := client.GetUser(context.Background(), &pb.GetUserRequest{Id: user.ID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	found, err := client.GetUser(adminContext(), &pb.GetUserRequest{Id: user.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, "brian", found.Username)
+
+	updated, err := client.UpdateUser(adminContext(), &pb.UpdateUserRequest{Id: user.ID, Username: "brian", Name: "Brian", Version: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), updated.Version)
+	_, err = client.UpdateUser(adminContext(), &pb.UpdateUserRequest{Id: user.ID, Username: "brian", Version: 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	_, err = client.UpdateUser(adminContext(), &pb.UpdateUserRequest{Id: user.ID, Username: "b"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := client.ListUsers(adminContext(), &pb.ListUsersRequest{Filters: map[string]string{"name": "Brian"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), list.Total)
+	_, err = client.ListUsers(adminContext(), &pb.ListUsersRequest{Sort: "password"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.DeleteUser(adminContext(), &pb.DeleteUserRequest{Id: user.ID})
+	assert.Nil(t, err)
+	_, err = client.GetUser(adminContext(), &pb.GetUserRequest{Id: user.ID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHealthWithoutCredentials(t *testing.T) {
+	client := grpc_health_v1.NewHealthClient(newTestClient(t, repository.NewMemoryUserRepository()))
+
+	response, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: pb.UserService_ServiceDesc.ServiceName})
+	assert.Nil(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+}
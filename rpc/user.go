@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"golang-fiber-web/handler"
+	"golang-fiber-web/model"
+	"golang-fiber-web/proto/pb"
+	"golang-fiber-web/service"
+	"golang-fiber-web/web"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	users service.UserService
+}
+
+func (server *userServer) ListUsers(ctx context.Context, request *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	page := int(request.Page)
+	if page == 0 {
+		page = 1
+	}
+	spec, err := web.NewListSpec(handler.UserListOptions, page, int(request.PerPage), request.Sort, request.Filters)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	users, total, err := server.users.List(ctx, spec)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &pb.ListUsersResponse{Total: int32(total)}
+	for _, user := range users {
+		response.Users = append(response.Users, pb.FromUser(user))
+	}
+	return response, nil
+}
+
+func (server *userServer) GetUser(ctx context.Context, request *pb.GetUserRequest) (*pb.User, error) {
+	user, err := server.users.Get(ctx, request.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return pb.FromUser(user), nil
+}
+
+func (server *userServer) UpdateUser(ctx context.Context, request *pb.UpdateUserRequest) (*pb.User, error) {
+	user, err := server.users.Update(ctx, request.Id, &model.UpdateUserRequest{
+		Username: request.Username,
+		Email:    request.Email,
+		Name:     request.Name,
+		Version:  int(request.Version),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return pb.FromUser(user), nil
+}
+
+func (server *userServer) DeleteUser(ctx context.Context, request *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	err := server.users.Delete(ctx, request.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.DeleteUserResponse{}, nil
+}
@@ -0,0 +1,130 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"slices"
+)
+
+// Seeder inserts a known set of data. Seeders must be idempotent so they can
+// run against a database that has already been seeded.
+type Seeder interface {
+	Name() string
+	Seed(ctx context.Context, repositories *repository.Repositories) error
+}
+
+// Defaults returns the roles and admin seeders, followed by the demo
+// data seeder when config enables it.
+func Defaults(config config.SeedConfig) []Seeder {
+	seeders := []Seeder{
+		roleSeeder{},
+		adminSeeder{username: config.AdminUsername, email: config.AdminEmail},
+	}
+	if config.Demo {
+		seeders = append(seeders, demoSeeder{})
+	}
+	return seeders
+}
+
+func Run(ctx context.Context, repositories *repository.Repositories, seeders []Seeder) ([]string, error) {
+	var names []string
+	for _, seeder := range seeders {
+		err := seeder.Seed(ctx, repositories)
+		if err != nil {
+			return names, errors.New("seeder " + seeder.Name() + ": " + err.Error())
+		}
+		names = append(names, seeder.Name())
+	}
+	return names, nil
+}
+
+var defaultRoles = []model.Role{
+	{Name: "admin", Description: "Full access to every resource"},
+	{Name: "user", Description: "Access to the user's own resources"},
+}
+
+type roleSeeder struct{}
+
+func (seeder roleSeeder) Name() string {
+	return "roles"
+}
+
+func (seeder roleSeeder) Seed(ctx context.Context, repositories *repository.Repositories) error {
+	for _, role := range defaultRoles {
+		err := repositories.Roles.Save(ctx, &role)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type adminSeeder struct {
+	username string
+	email    string
+}
+
+func (seeder adminSeeder) Name() string {
+	return "admin"
+}
+
+func (seeder adminSeeder) Seed(ctx context.Context, repositories *repository.Repositories) error {
+	if seeder.email == "" {
+		return errors.New("admin email is not configured")
+	}
+	return ensureUser(ctx, repositories.Users, &model.User{
+		Username: seeder.username,
+		Email:    seeder.email,
+		Name:     "Administrator",
+		Roles:    []string{"admin"},
+	})
+}
+
+var demoUsers = []model.User{
+	{Username: "brian", Email: "brian@example.com", Name: "Brian Ashari", Roles: []string{"user"}},
+	{Username: "budi", Email: "budi@example.com", Name: "Budi Santoso", Roles: []string{"user"}},
+	{Username: "siti", Email: "siti@example.com", Name: "Siti Rahma", Roles: []string{"user"}},
+}
+
+type demoSeeder struct{}
+
+func (seeder demoSeeder) Name() string {
+	return "demo"
+}
+
+func (seeder demoSeeder) Seed(ctx context.Context, repositories *repository.Repositories) error {
+	for _, user := range demoUsers {
+		err := ensureUser(ctx, repositories.Users, &user)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureUser creates user unless a user with the same email exists, in which
+// case only the missing roles are added.
+func ensureUser(ctx context.Context, users repository.UserRepository, user *model.User) error {
+	existing, err := users.FindByEmail(ctx, user.Email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return users.Create(ctx, user)
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, role := range user.Roles {
+		if !slices.Contains(existing.Roles, role) {
+			existing.Roles = append(existing.Roles, role)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return users.Update(ctx, existing)
+}
@@ -0,0 +1,68 @@
+package seed
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+var testConfig = config.SeedConfig{AdminUsername: "admin", AdminEmail: "admin@example.com", Demo: true}
+
+func newSeededRepositories(t *testing.T) *repository.Repositories {
+	repositories := repository.NewMemoryRepositories()
+	_, err := Run(context.Background(), repositories, Defaults(testConfig))
+	assert.Nil(t, err)
+	return repositories
+}
+
+func TestSeeders(t *testing.T) {
+	repositories := newSeededRepositories(t)
+
+	roles, err := repositories.Roles.List(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, roles, 2)
+	assert.Equal(t, "admin", roles[0].Name)
+
+	admin, err := repositories.Users.FindByEmail(context.Background(), "admin@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", admin.Username)
+	assert.Equal(t, []string{"admin"}, admin.Roles)
+
+	_, total, err := repositories.Users.List(context.Background(), &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1+len(demoUsers), total)
+}
+
+func TestSeedersIdempotent(t *testing.T) {
+	repositories := newSeededRepositories(t)
+
+	names, err := Run(context.Background(), repositories, Defaults(testConfig))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"roles", "admin", "demo"}, names)
+
+	_, total, err := repositories.Users.List(context.Background(), &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1+len(demoUsers), total)
+	roles, err := repositories.Roles.List(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, roles, 2)
+}
+
+func TestSeedersRestoreAdminRole(t *testing.T) {
+	repositories := repository.NewMemoryRepositories()
+	assert.Nil(t, repositories.Users.Create(context.Background(), &model.User{Username: "root", Email: "Admin@example.com"}))
+
+	_, err := Run(context.Background(), repositories, Defaults(config.SeedConfig{AdminUsername: "admin", AdminEmail: "admin@example.com"}))
+	assert.Nil(t, err)
+
+	admin, err := repositories.Users.FindByEmail(context.Background(), "admin@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "root", admin.Username)
+	assert.Equal(t, []string{"admin"}, admin.Roles)
+	_, total, err := repositories.Users.List(context.Background(), &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+}
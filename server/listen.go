@@ -0,0 +1,237 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/quic-go/quic-go/http3"
+	"golang-fiber-web/config"
+	"golang-fiber-web/systemd"
+	"golang.org/x/crypto/acme/autocert"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const NetworkUnix = "unix"
+
+// Listen serves app on every configured listener until one of them fails or
+// the app shuts down. A TLS listener serves HTTPS, from the certificate files
+// or through autocert, with its HTTP redirect and HTTP/3 listeners next to
+// it. Those only run in the prefork parent, which binds them once. Prefork
+// supports a single TCP listener. The sockets are bound through upgrader,
+// which is told once they all serve.
+func Listen(app *fiber.App, server config.ServerConfig, upgrader *Upgrader) error {
+	listeners := server.ListenerConfigs()
+	if len(listeners) == 0 {
+		return errors.New("server has no listeners configured")
+	}
+	prefork := app.Config().Prefork
+	if prefork && (len(listeners) > 1 || listeners[0].Network == NetworkUnix) {
+		return errors.New("prefork supports a single tcp listener")
+	}
+
+	errs := make(chan error, len(listeners)*3)
+	var closers []io.Closer
+	defer func() {
+		for _, closer := range closers {
+			closer.Close()
+		}
+	}()
+
+	var netListeners []net.Listener
+	for _, listener := range listeners {
+		var manager *autocert.Manager
+		if listener.TLS.Enabled && listener.TLS.Autocert {
+			if len(listener.TLS.Domains) == 0 {
+				return errors.New("tls.domains is required for autocert on " + listener.Address)
+			}
+			manager = NewAutocertManager(listener.TLS)
+		}
+
+		if !fiber.IsChild() {
+			sideServers, err := startSideServers(app, listener, manager, upgrader, errs)
+			closers = append(closers, sideServers...)
+			if err != nil {
+				return err
+			}
+		}
+
+		if prefork && manager == nil {
+			// Every child binds the address itself.
+			go func() {
+				if listener.TLS.Enabled {
+					errs <- app.ListenTLS(listener.Address, listener.TLS.CertFile, listener.TLS.KeyFile)
+				} else {
+					errs <- app.Listen(listener.Address)
+				}
+			}()
+			continue
+		}
+
+		netListener, err := openListener(app, listener, manager, upgrader)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, netListener)
+		netListeners = append(netListeners, netListener)
+	}
+
+	if len(netListeners) == 1 {
+		go func() {
+			errs <- app.Listener(netListeners[0])
+		}()
+	} else if len(netListeners) > 1 {
+		// app.Listener builds the router on every call, which would race with
+		// requests on the listeners already serving, so build it once here.
+		app.Handler()
+		for _, netListener := range netListeners {
+			fmt.Printf("Listening on %s %s\n", netListener.Addr().Network(), netListener.Addr())
+			go func(netListener net.Listener) {
+				errs <- app.Server().Serve(netListener)
+			}(netListener)
+		}
+	}
+
+	// The listeners are bound already, so connections wait in their backlog
+	// until they accept. After an upgrade systemd is told to follow the new
+	// process, which needs NotifyAccess=all.
+	err := upgrader.Ready()
+	if err != nil {
+		return err
+	}
+	if !fiber.IsChild() {
+		states := []string{systemd.Ready}
+		if upgrader.Upgraded() {
+			states = []string{systemd.MainPID(), systemd.Ready}
+		}
+		_, err = systemd.Notify(states...)
+		if err != nil {
+			fmt.Println("Failed to notify systemd:", err)
+		}
+	}
+	return <-errs
+}
+
+// startSideServers starts the HTTP redirect and HTTP/3 listeners of a TLS
+// listener. Their errors are sent to errs.
+func startSideServers(app *fiber.App, listener config.ListenerConfig, manager *autocert.Manager, upgrader *Upgrader, errs chan<- error) ([]io.Closer, error) {
+	var servers []io.Closer
+	if !listener.TLS.Enabled {
+		return servers, nil
+	}
+
+	if listener.TLS.RedirectAddress != "" {
+		var redirect http.Handler = RedirectHandler(listener.Address)
+		if manager != nil {
+			redirect = manager.HTTPHandler(redirect)
+		}
+		redirectListener, err := upgrader.Listen("tcp", listener.TLS.RedirectAddress)
+		if err != nil {
+			return servers, err
+		}
+		redirectServer := &http.Server{
+			Handler:           redirect,
+			ReadHeaderTimeout: time.Second * 10,
+		}
+		go func() {
+			errs <- redirectServer.Serve(redirectListener)
+		}()
+		servers = append(servers, redirectServer)
+	}
+
+	if listener.TLS.HTTP3 {
+		tlsConfig, err := newTLSConfig(listener.TLS, manager)
+		if err != nil {
+			return servers, err
+		}
+		conn, err := upgrader.ListenPacket("udp", listener.Address)
+		if err != nil {
+			return servers, err
+		}
+		http3Server := &http3.Server{
+			Handler:   adaptor.FiberApp(app),
+			TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+		}
+		go func() {
+			errs <- http3Server.Serve(conn)
+		}()
+		servers = append(servers, conn)
+		servers = append(servers, http3Server)
+	}
+	return servers, nil
+}
+
+// openListener binds listener through upgrader on its network, the app
+// network by default.
+func openListener(app *fiber.App, listener config.ListenerConfig, manager *autocert.Manager, upgrader *Upgrader) (net.Listener, error) {
+	network := listener.Network
+	if network == "" {
+		network = app.Config().Network
+	}
+
+	netListener, err := upgrader.Listen(network, listener.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s %s: %w", network, listener.Address, err)
+	}
+
+	if listener.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(listener.TLS, manager)
+		if err != nil {
+			netListener.Close()
+			return nil, err
+		}
+		netListener = tls.NewListener(netListener, tlsConfig)
+	}
+	return netListener, nil
+}
+
+func NewAutocertManager(config config.TLSConfig) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Cache:      autocert.DirCache(config.CacheDir),
+		Email:      config.Email,
+	}
+}
+
+func newTLSConfig(config config.TLSConfig, manager *autocert.Manager) (*tls.Config, error) {
+	if manager != nil {
+		return manager.TLSConfig(), nil
+	}
+	certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// NewAltSvc advertises the HTTP/3 listener on address to clients that
+// connected over TCP, so they can switch to QUIC on the next request.
+func NewAltSvc(address string) fiber.Handler {
+	_, port, _ := net.SplitHostPort(address)
+	value := `h3=":` + port + `"; ma=86400`
+	return func(ctx *fiber.Ctx) error {
+		ctx.Set(fiber.HeaderAltSvc, value)
+		return ctx.Next()
+	}
+}
+
+// RedirectHandler answers 301 with the same URL on HTTPS, on the port of
+// httpsAddress unless that is 443.
+func RedirectHandler(httpsAddress string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddress)
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		host := request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(writer, request, "https://"+host+request.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
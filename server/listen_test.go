@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	RedirectHandler(":443").ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://example.com/users?page=2", nil))
+	assert.Equal(t, 301, recorder.Code)
+	assert.Equal(t, "https://example.com/users?page=2", recorder.Header().Get("Location"))
+
+	recorder = httptest.NewRecorder()
+	RedirectHandler("localhost:8443").ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "http://localhost:8080/upload", nil))
+	assert.Equal(t, 301, recorder.Code)
+	assert.Equal(t, "https://localhost:8443/upload", recorder.Header().Get("Location"))
+}
+
+func TestAltSvc(t *testing.T) {
+	altSvcApp := fiber.New()
+	altSvcApp.Use(NewAltSvc("localhost:8443"))
+	altSvcApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("Hello World")
+	})
+
+	response, err := altSvcApp.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, `h3=":8443"; ma=86400`, response.Header.Get("Alt-Svc"))
+}
+
+func TestListenUnixAndTCP(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	listenApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	listenApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("Hello World")
+	})
+
+	tcpListener, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := tcpListener.Addr().String()
+	tcpListener.Close()
+
+	upgrader, err := NewUpgrader()
+	assert.Nil(t, err)
+	go Listen(listenApp, config.ServerConfig{
+		Address:   address,
+		Listeners: []config.ListenerConfig{{Network: NetworkUnix, Address: socket}},
+	}, upgrader)
+	defer listenApp.Shutdown()
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial(NetworkUnix, socket)
+		},
+	}}
+	for _, client := range []*http.Client{http.DefaultClient, unixClient} {
+		var response *http.Response
+		assert.Eventually(t, func() bool {
+			response, err = client.Get("http://" + address + "/")
+			return err == nil
+		}, time.Second*2, time.Millisecond*20)
+		bytes, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, "Hello World", string(bytes))
+	}
+
+	info, err := os.Stat(socket)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+}
+
+func TestListenPreforkSupportsOneTCPListener(t *testing.T) {
+	upgrader, err := NewUpgrader()
+	assert.Nil(t, err)
+	err = Listen(fiber.New(fiber.Config{Prefork: true}), config.ServerConfig{
+		Listeners: []config.ListenerConfig{{Network: NetworkUnix, Address: "app.sock"}},
+	}, upgrader)
+	assert.NotNil(t, err)
+}
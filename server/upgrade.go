@@ -0,0 +1,277 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The sockets are passed the way systemd passes them on socket activation:
+// LISTEN_FDS of them from fd 3, named in LISTEN_FDNAMES. UPGRADE_READY_FD is
+// the pipe the new process signals its readiness on. WATCHDOG_PID names the
+// process the systemd watchdog is for, which the new process is not yet.
+const (
+	envListenFDs     = "LISTEN_FDS"
+	envListenFDNames = "LISTEN_FDNAMES"
+	envListenPID     = "LISTEN_PID"
+	envReadyFD       = "UPGRADE_READY_FD"
+	envWatchdogPID   = "WATCHDOG_PID"
+	listenFDsStart   = 3
+)
+
+// Upgrader hands the listening sockets of the server over to a new process
+// of the binary, which serves on them before this one stops accepting, so a
+// deploy doesn't refuse or drop connections.
+type Upgrader struct {
+	mutex     sync.Mutex
+	inherited map[string]*os.File
+	sockets   []socket
+	ready     *os.File
+	upgraded  bool
+	upgrading bool
+}
+
+// socket is a listening socket of the server, to hand over by name.
+type socket struct {
+	name string
+	conn syscall.Conn
+}
+
+// NewUpgrader takes the sockets inherited from the process that started this
+// one for an upgrade, if any.
+func NewUpgrader() (*Upgrader, error) {
+	upgrader := &Upgrader{inherited: map[string]*os.File{}}
+	count := os.Getenv(envListenFDs)
+	if count == "" {
+		return upgrader, nil
+	}
+	if pid := os.Getenv(envListenPID); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return upgrader, nil
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid %s %q", envListenFDs, count)
+	}
+	names := strings.Split(os.Getenv(envListenFDNames), ":")
+	for i := 0; i < n; i++ {
+		name := strconv.Itoa(i)
+		if i < len(names) {
+			name, err = url.QueryUnescape(names[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", envListenFDNames, err)
+			}
+		}
+		upgrader.inherited[name] = os.NewFile(uintptr(listenFDsStart+i), name)
+	}
+	if fd := os.Getenv(envReadyFD); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", envReadyFD, fd)
+		}
+		upgrader.ready = os.NewFile(uintptr(n), "ready")
+		upgrader.upgraded = true
+	}
+	for _, key := range []string{envListenFDs, envListenFDNames, envListenPID, envReadyFD} {
+		os.Unsetenv(key)
+	}
+	return upgrader, nil
+}
+
+// Listen returns the listener inherited for address on network, or binds a
+// new one. A stale Unix socket left by a crashed process is removed first,
+// and a new one is made group-writable so a reverse proxy in the group can
+// connect.
+func (upgrader *Upgrader) Listen(network string, address string) (net.Listener, error) {
+	upgrader.mutex.Lock()
+	defer upgrader.mutex.Unlock()
+
+	name := network + "/" + address
+	var listener net.Listener
+	if file, ok := upgrader.inherited[name]; ok {
+		delete(upgrader.inherited, name)
+		var err error
+		listener, err = net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if network == NetworkUnix {
+			if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+				os.Remove(address)
+			}
+		}
+		var err error
+		listener, err = net.Listen(network, address)
+		if err != nil {
+			return nil, err
+		}
+		if network == NetworkUnix {
+			err = os.Chmod(address, 0660)
+			if err != nil {
+				listener.Close()
+				return nil, err
+			}
+		}
+	}
+
+	conn, ok := listener.(syscall.Conn)
+	if ok {
+		upgrader.sockets = append(upgrader.sockets, socket{name: name, conn: conn})
+	}
+	return listener, nil
+}
+
+// ListenPacket is Listen for the UDP socket of HTTP/3.
+func (upgrader *Upgrader) ListenPacket(network string, address string) (net.PacketConn, error) {
+	upgrader.mutex.Lock()
+	defer upgrader.mutex.Unlock()
+
+	name := network + "/" + address
+	var conn net.PacketConn
+	if file, ok := upgrader.inherited[name]; ok {
+		delete(upgrader.inherited, name)
+		var err error
+		conn, err = net.FilePacketConn(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		conn, err = net.ListenPacket(network, address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if udpConn, ok := conn.(*net.UDPConn); ok {
+		upgrader.sockets = append(upgrader.sockets, socket{name: name, conn: udpConn})
+	}
+	return conn, nil
+}
+
+// Ready tells the process that started this one for an upgrade that it
+// serves, so that one can stop. The inherited sockets this process didn't
+// listen on any more are closed.
+func (upgrader *Upgrader) Ready() error {
+	upgrader.mutex.Lock()
+	defer upgrader.mutex.Unlock()
+
+	for name, file := range upgrader.inherited {
+		file.Close()
+		delete(upgrader.inherited, name)
+	}
+	if upgrader.ready == nil {
+		return nil
+	}
+	_, err := upgrader.ready.Write([]byte{1})
+	upgrader.ready.Close()
+	upgrader.ready = nil
+	return err
+}
+
+// Upgraded reports whether this process was started by the upgrade of
+// another one.
+func (upgrader *Upgrader) Upgraded() bool {
+	return upgrader.upgraded
+}
+
+// Upgrade starts the binary again with the sockets of this process and waits
+// until it is ready, up to timeout. On success this process should stop
+// accepting and drain its connections; on failure the new process is killed
+// and this one goes on serving.
+func (upgrader *Upgrader) Upgrade(timeout time.Duration) error {
+	upgrader.mutex.Lock()
+	if upgrader.upgrading {
+		upgrader.mutex.Unlock()
+		return errors.New("an upgrade is in progress already")
+	}
+	upgrader.upgrading = true
+	sockets := upgrader.sockets
+	upgrader.mutex.Unlock()
+	defer func() {
+		upgrader.mutex.Lock()
+		upgrader.upgrading = false
+		upgrader.mutex.Unlock()
+	}()
+
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	names := make([]string, 0, len(sockets))
+	for _, socket := range sockets {
+		// The new process serves on the socket file once this one closes
+		// its listener.
+		if listener, ok := socket.conn.(*net.UnixListener); ok {
+			listener.SetUnlinkOnClose(false)
+		}
+		file, err := dupSocket(socket.conn, socket.name)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		names = append(names, url.QueryEscape(socket.name))
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyReader.Close()
+
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, readyWriter)
+	for _, variable := range os.Environ() {
+		key, _, _ := strings.Cut(variable, "=")
+		if key != envListenFDs && key != envListenFDNames && key != envListenPID && key != envReadyFD && key != envWatchdogPID {
+			cmd.Env = append(cmd.Env, variable)
+		}
+	}
+	cmd.Env = append(cmd.Env,
+		envListenFDs+"="+strconv.Itoa(len(files)),
+		envListenFDNames+"="+strings.Join(names, ":"),
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+	err = cmd.Start()
+	readyWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	// The read ends when the new process signals it is ready, or with EOF
+	// when it exits before.
+	ready := make(chan error, 1)
+	go func() {
+		buffer := make([]byte, 1)
+		_, err := readyReader.Read(buffer)
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+		if err == nil {
+			cmd.Process.Release()
+			return nil
+		}
+		err = errors.New("the new process exited before it was ready")
+	case <-time.After(timeout):
+		err = fmt.Errorf("the new process was not ready within %s", timeout)
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package server
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func dupSocket(conn syscall.Conn, name string) (*os.File, error) {
+	return nil, errors.New("upgrades are not supported on this platform")
+}
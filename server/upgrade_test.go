@@ -0,0 +1,100 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/systemd"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const upgradeHelperEnv = "UPGRADE_TEST_HELPER"
+
+// TestUpgradeHelper is the new process of the upgrade tests, serving "new"
+// on the socket it inherits for a second.
+func TestUpgradeHelper(t *testing.T) {
+	mode := os.Getenv(upgradeHelperEnv)
+	if mode == "" {
+		t.Skip("only runs as the new process of an upgrade")
+	}
+	if mode == "fail" {
+		os.Exit(1)
+	}
+	if mode == "watchdog" && systemd.WatchdogInterval() != 5*time.Second {
+		os.Exit(1)
+	}
+	upgrader, err := NewUpgrader()
+	if err != nil {
+		os.Exit(1)
+	}
+	listener, err := upgrader.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.Exit(1)
+	}
+	go http.Serve(listener, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		io.WriteString(writer, "new")
+	}))
+	upgrader.Ready()
+	time.Sleep(time.Second)
+	os.Exit(0)
+}
+
+func startUpgradeHelper(t *testing.T, mode string) {
+	t.Setenv(upgradeHelperEnv, mode)
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestUpgradeHelper$"}
+	t.Cleanup(func() { os.Args = args })
+}
+
+func TestUpgradeHandsSocketsOver(t *testing.T) {
+	upgrader, err := NewUpgrader()
+	assert.Nil(t, err)
+	listener, err := upgrader.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	oldServer := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		io.WriteString(writer, "old")
+	})}
+	go oldServer.Serve(listener)
+	url := "http://" + listener.Addr().String() + "/"
+
+	startUpgradeHelper(t, "serve")
+	assert.Nil(t, upgrader.Upgrade(time.Second*10))
+	oldServer.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	response, err := client.Get(url)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "new", string(bytes))
+}
+
+func TestUpgradeFailsWhenTheNewProcessExits(t *testing.T) {
+	upgrader, err := NewUpgrader()
+	assert.Nil(t, err)
+	listener, err := upgrader.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	startUpgradeHelper(t, "fail")
+	assert.NotNil(t, upgrader.Upgrade(time.Second*10))
+}
+
+// TestUpgradeKeepsTheWatchdog checks the new process gets the watchdog
+// interval but not the pid of this process, which would turn it off there.
+func TestUpgradeKeepsTheWatchdog(t *testing.T) {
+	upgrader, err := NewUpgrader()
+	assert.Nil(t, err)
+	listener, err := upgrader.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("WATCHDOG_USEC", "5000000")
+	startUpgradeHelper(t, "watchdog")
+	assert.Nil(t, upgrader.Upgrade(time.Second*10))
+}
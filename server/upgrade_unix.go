@@ -0,0 +1,35 @@
+//go:build unix
+
+package server
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupSocket duplicates the descriptor of conn for a new process. Unlike the
+// File method of the listeners, it leaves the socket nonblocking, which the
+// listener serving on it in this process relies on until the upgrade ends.
+func dupSocket(conn syscall.Conn, name string) (*os.File, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var fd int
+	var dupErr error
+	err = rawConn.Control(func(sysfd uintptr) {
+		syscall.ForkLock.RLock()
+		defer syscall.ForkLock.RUnlock()
+		fd, dupErr = syscall.Dup(int(sysfd))
+		if dupErr == nil {
+			syscall.CloseOnExec(fd)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dupErr != nil {
+		return nil, os.NewSyscallError("dup", dupErr)
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
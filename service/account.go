@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
+)
+
+// emailChangeLifetime is how long the token confirming an email change is
+// valid.
+const emailChangeLifetime = time.Hour * 24
+
+// AccountService lets users manage their own account. The profile and the
+// email are saved through UserService, which audits the changes and
+// publishes their events.
+type AccountService interface {
+	Profile(ctx context.Context, userID string) (*model.User, error)
+	UpdateProfile(ctx context.Context, userID string, request *model.UpdateProfileRequest) (*model.User, error)
+	// ChangePassword sets the password of the user and revokes their
+	// sessions but the one of sessionID. The current password must match
+	// once the user has one.
+	ChangePassword(ctx context.Context, userID, sessionID string, request *model.ChangePasswordRequest) error
+	// RequestEmailChange publishes event.UserEmailChangeRequested with the
+	// token confirming the change, which replaces any pending one.
+	RequestEmailChange(ctx context.Context, userID string, request *model.ChangeEmailRequest) error
+	// ConfirmEmailChange applies the change of the token, or fails with
+	// model.ErrEmailChangeNotFound.
+	ConfirmEmailChange(ctx context.Context, request *model.ConfirmEmailRequest) (*model.User, error)
+}
+
+type accountService struct {
+	users        repository.UserRepository
+	userService  UserService
+	sessions     SessionService
+	emailChanges repository.EmailChangeRepository
+	transactor   repository.Transactor
+	audit        AuditService
+	events       event.Publisher
+	now          func() time.Time
+}
+
+func NewAccountService(users repository.UserRepository, userService UserService, sessions SessionService, emailChanges repository.EmailChangeRepository,
+	transactor repository.Transactor, audit AuditService, events event.Publisher) AccountService {
+	return &accountService{users: users, userService: userService, sessions: sessions, emailChanges: emailChanges,
+		transactor: transactor, audit: audit, events: events, now: time.Now}
+}
+
+func (service *accountService) Profile(ctx context.Context, userID string) (*model.User, error) {
+	return service.users.FindByID(ctx, userID)
+}
+
+func (service *accountService) UpdateProfile(ctx context.Context, userID string, request *model.UpdateProfileRequest) (*model.User, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return service.userService.Update(ctx, userID, &model.UpdateUserRequest{
+		Username: request.Username,
+		Email:    user.Email,
+		Name:     request.Name,
+		Version:  request.Version,
+	})
+}
+
+func (service *accountService) ChangePassword(ctx context.Context, userID, sessionID string, request *model.ChangePasswordRequest) error {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return err
+	}
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.CurrentPassword)) != nil {
+		return model.ValidationErrors{{Field: "current_password", Message: "is incorrect"}}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.SetPasswordHash(ctx, userID, string(hash))
+		if err != nil {
+			return err
+		}
+		err = service.sessions.RevokeOthers(ctx, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "user.change_password", "user", userID, nil, nil)
+	})
+}
+
+func (service *accountService) RequestEmailChange(ctx context.Context, userID string, request *model.ChangeEmailRequest) error {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return err
+	}
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, request.Email) {
+		return model.ValidationErrors{{Field: "email", Message: "is already the email of the account"}}
+	}
+	err = service.checkEmailFree(ctx, userID, request.Email)
+	if err != nil {
+		return err
+	}
+
+	random := make([]byte, 24)
+	_, err = rand.Read(random)
+	if err != nil {
+		return err
+	}
+	token := hex.EncodeToString(random)
+	change := &model.EmailChange{UserID: userID, Email: request.Email, TokenHash: hashEmailToken(token), ExpiresAt: service.now().Add(emailChangeLifetime)}
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.emailChanges.Save(ctx, change)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "user.request_email_change", "user", userID, nil, map[string]string{"email": change.Email})
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.UserEmailChangeRequested{UserID: userID, Email: change.Email, Token: token, ExpiresAt: change.ExpiresAt})
+	})
+}
+
+func (service *accountService) ConfirmEmailChange(ctx context.Context, request *model.ConfirmEmailRequest) (*model.User, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	change, err := service.emailChanges.FindByToken(ctx, hashEmailToken(request.Token), service.now())
+	if err != nil {
+		return nil, err
+	}
+	user, err := service.users.FindByID(ctx, change.UserID)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, model.ErrEmailChangeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = service.checkEmailFree(ctx, user.ID, change.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		user, err = service.userService.Update(ctx, user.ID, &model.UpdateUserRequest{
+			Username: user.Username,
+			Email:    change.Email,
+			Name:     user.Name,
+			Version:  user.Version,
+		})
+		if err != nil {
+			return err
+		}
+		return service.emailChanges.Delete(ctx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkEmailFree fails with model.ValidationErrors when another user has
+// email.
+func (service *accountService) checkEmailFree(ctx context.Context, userID, email string) error {
+	other, err := service.users.FindByEmail(ctx, email)
+	if err == nil && other.ID != userID {
+		return model.ValidationErrors{{Field: "email", Message: "failed unique"}}
+	}
+	if err != nil && !errors.Is(err, model.ErrUserNotFound) {
+		return err
+	}
+	return nil
+}
+
+func hashEmailToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
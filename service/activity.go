@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+)
+
+// ActivityService keeps the timelines of the users, built from the domain
+// events about them as they are delivered.
+type ActivityService interface {
+	// List lists the activities of the user, all of them when the viewer is
+	// the user and only the public ones otherwise. It fails with
+	// model.ErrUserNotFound for the users not found.
+	List(ctx context.Context, userID, viewerID string, spec *model.ListSpec) ([]*model.Activity, int, error)
+	// HandleEvent subscribes to the user and order events, adding them to
+	// the timeline of their user.
+	HandleEvent(ctx context.Context, happened event.Event) error
+}
+
+type activityService struct {
+	users      repository.UserRepository
+	activities repository.ActivityRepository
+}
+
+func NewActivityService(users repository.UserRepository, activities repository.ActivityRepository) ActivityService {
+	return &activityService{users: users, activities: activities}
+}
+
+func (service *activityService) List(ctx context.Context, userID, viewerID string, spec *model.ListSpec) ([]*model.Activity, int, error) {
+	if _, err := service.users.FindByID(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	return service.activities.List(ctx, userID, viewerID != userID, spec)
+}
+
+func (service *activityService) HandleEvent(ctx context.Context, happened event.Event) error {
+	activity := activityOf(happened)
+	if activity == nil {
+		return nil
+	}
+	return service.activities.Create(ctx, activity)
+}
+
+// activityOf is the activity telling about happened, or nil for the events
+// that are not about a user.
+func activityOf(happened event.Event) *model.Activity {
+	switch happened := happened.(type) {
+	case event.UserRegistered:
+		return &model.Activity{UserID: happened.User.ID, Kind: happened.Name(), Resource: "user", ResourceID: happened.User.ID,
+			Summary: "Joined", Link: "/users/" + happened.User.ID, Public: true}
+	case event.UserUpdated:
+		return &model.Activity{UserID: happened.User.ID, Kind: happened.Name(), Resource: "user", ResourceID: happened.User.ID,
+			Summary: "Updated their profile", Link: "/users/" + happened.User.ID, Public: true}
+	case event.UserRestored:
+		return &model.Activity{UserID: happened.User.ID, Kind: happened.Name(), Resource: "user", ResourceID: happened.User.ID,
+			Summary: "Restored their account", Link: "/users/" + happened.User.ID}
+	case event.UserEmailChangeRequested:
+		return &model.Activity{UserID: happened.UserID, Kind: happened.Name(), Resource: "user", ResourceID: happened.UserID,
+			Summary: "Asked to change their email to " + happened.Email}
+	case event.OrderPlaced:
+		order := happened.Order
+		return &model.Activity{UserID: order.UserID, Kind: happened.Name(), Resource: "order", ResourceID: order.ID,
+			Summary: "Placed the order " + order.ID, Link: "/users/" + order.UserID + "/orders/" + order.ID}
+	case event.OrderStatusChanged:
+		return &model.Activity{UserID: happened.UserID, Kind: happened.Name(), Resource: "order", ResourceID: happened.OrderID,
+			Summary: "Order " + happened.OrderID + " went from " + happened.From + " to " + happened.To,
+			Link:    "/users/" + happened.UserID + "/orders/" + happened.OrderID}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+func TestActivitiesFromEvents(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "alice", Email: "alice@example.com", Name: "Alice"}
+	assert.Nil(t, users.Create(ctx, user))
+	activities := NewActivityService(users, repository.NewMemoryActivityRepository())
+
+	order := &model.Order{ID: "order-1", UserID: user.ID}
+	assert.Nil(t, activities.HandleEvent(ctx, event.UserRegistered{User: user}))
+	assert.Nil(t, activities.HandleEvent(ctx, event.OrderPlaced{Order: order}))
+	assert.Nil(t, activities.HandleEvent(ctx, event.FileUploaded{FileID: "file-1"}))
+
+	spec := &model.ListSpec{Page: 1, PerPage: 10}
+	mine, total, err := activities.List(ctx, user.ID, user.ID, spec)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "/users/"+user.ID+"/orders/order-1", mine[0].Link)
+
+	public, total, err := activities.List(ctx, user.ID, "", spec)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, event.NameUserRegistered, public[0].Kind)
+
+	_, _, err = activities.List(ctx, "missing", "missing", spec)
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
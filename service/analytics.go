@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"sort"
+	"time"
+)
+
+type AnalyticsService interface {
+	// Report summarizes the usage from from to to, listing the top consumers
+	// with the most requests.
+	Report(ctx context.Context, from, to time.Time, top int) (*model.UsageReport, error)
+}
+
+type analyticsService struct {
+	usage repository.UsageRepository
+}
+
+func NewAnalyticsService(usage repository.UsageRepository) AnalyticsService {
+	return &analyticsService{usage: usage}
+}
+
+func (service *analyticsService) Report(ctx context.Context, from, to time.Time, top int) (*model.UsageReport, error) {
+	stats, err := service.usage.List(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := map[string]*model.UsageStat{}
+	consumers := map[string]*model.UsageStat{}
+	buckets := map[time.Time]*model.UsageStat{}
+	var trend []time.Time
+	for _, stat := range stats {
+		addUsage(routes, stat.Method+" "+stat.Route, stat)
+		addUsage(consumers, stat.Consumer, stat)
+		if buckets[stat.Bucket] == nil {
+			trend = append(trend, stat.Bucket)
+		}
+		addUsage(buckets, stat.Bucket, stat)
+	}
+
+	report := &model.UsageReport{
+		From:      from,
+		To:        to,
+		Routes:    summarizeUsage(routes),
+		Consumers: summarizeUsage(consumers),
+		Trend:     make([]model.UsageTrendRow, len(trend)),
+	}
+	report.Consumers = report.Consumers[:min(top, len(report.Consumers))]
+	for i, bucket := range trend {
+		report.Trend[i] = model.UsageTrendRow{
+			Bucket:    bucket,
+			Requests:  buckets[bucket].Requests,
+			ErrorRate: usageRate(buckets[bucket].Status5xx, buckets[bucket].Requests),
+		}
+	}
+	return report, nil
+}
+
+func addUsage[K comparable](totals map[K]*model.UsageStat, key K, stat *model.UsageStat) {
+	total := totals[key]
+	if total == nil {
+		total = &model.UsageStat{}
+		totals[key] = total
+	}
+	total.Add(stat)
+}
+
+// summarizeUsage lists the totals by name, the most requested first.
+func summarizeUsage(totals map[string]*model.UsageStat) []model.UsageSummary {
+	summaries := make([]model.UsageSummary, 0, len(totals))
+	for name, total := range totals {
+		summary := model.UsageSummary{
+			Name:            name,
+			Requests:        total.Requests,
+			ErrorRate:       usageRate(total.Status5xx, total.Requests),
+			ClientErrorRate: usageRate(total.Status4xx, total.Requests),
+			MaxLatency:      total.LatencyMax,
+		}
+		if total.Requests > 0 {
+			summary.AverageLatency = total.LatencyTotal / time.Duration(total.Requests)
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Requests != summaries[j].Requests {
+			return summaries[i].Requests > summaries[j].Requests
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+func usageRate(count, requests int) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(count) / float64(requests)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"strings"
+)
+
+// apiKeyPrefix starts every API key, so that leaked keys are easy to spot.
+const apiKeyPrefix = "fw_"
+
+type APIKeyService interface {
+	// Create returns the key along with its value, which is not stored and
+	// cannot be shown again.
+	Create(ctx context.Context, request *model.CreateAPIKeyRequest) (*model.APIKey, string, error)
+	// Authenticate returns the key of value, or model.ErrAPIKeyNotFound.
+	Authenticate(ctx context.Context, value string) (*model.APIKey, error)
+	List(ctx context.Context, userID string) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type apiKeyService struct {
+	keys  repository.APIKeyRepository
+	users repository.UserRepository
+	audit AuditService
+}
+
+func NewAPIKeyService(keys repository.APIKeyRepository, users repository.UserRepository, audit AuditService) APIKeyService {
+	return &apiKeyService{keys: keys, users: users, audit: audit}
+}
+
+func (service *apiKeyService) Create(ctx context.Context, request *model.CreateAPIKeyRequest) (*model.APIKey, string, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = service.users.FindByID(ctx, request.UserID)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, "", model.ValidationErrors{{Field: "user_id", Message: "unknown user"}}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	random := make([]byte, 24)
+	_, err = rand.Read(random)
+	if err != nil {
+		return nil, "", err
+	}
+	value := apiKeyPrefix + hex.EncodeToString(random)
+	key := &model.APIKey{UserID: request.UserID, Name: request.Name, Prefix: value[:len(apiKeyPrefix)+6], Hash: hashAPIKey(value)}
+	err = service.keys.Create(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	err = service.audit.Record(ctx, "api_key.create", "api_key", key.ID, nil, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, value, nil
+}
+
+func (service *apiKeyService) Authenticate(ctx context.Context, value string) (*model.APIKey, error) {
+	if !strings.HasPrefix(value, apiKeyPrefix) {
+		return nil, model.ErrAPIKeyNotFound
+	}
+	return service.keys.FindByHash(ctx, hashAPIKey(value))
+}
+
+func (service *apiKeyService) List(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	return service.keys.List(ctx, userID)
+}
+
+func (service *apiKeyService) Revoke(ctx context.Context, id string) error {
+	err := service.keys.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	return service.audit.Record(ctx, "api_key.revoke", "api_key", id, nil, nil)
+}
+
+func hashAPIKey(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
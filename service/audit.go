@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+)
+
+// AuditMetadata describes where an action came from. The audit middleware
+// puts it in the request context; actions outside a request, like seeding,
+// are recorded as the system actor.
+type AuditMetadata struct {
+	Actor     string
+	IP        string
+	RequestID string
+}
+
+const ActorSystem = "system"
+
+type auditMetadataKey struct{}
+
+func WithAuditMetadata(ctx context.Context, metadata AuditMetadata) context.Context {
+	return context.WithValue(ctx, auditMetadataKey{}, metadata)
+}
+
+// WithActor replaces the actor of the audit metadata in ctx, for
+// authentication that happens after the audit middleware.
+func WithActor(ctx context.Context, actor string) context.Context {
+	metadata := AuditMetadataFrom(ctx)
+	metadata.Actor = actor
+	return WithAuditMetadata(ctx, metadata)
+}
+
+func AuditMetadataFrom(ctx context.Context) AuditMetadata {
+	metadata, ok := ctx.Value(auditMetadataKey{}).(AuditMetadata)
+	if !ok {
+		return AuditMetadata{Actor: ActorSystem}
+	}
+	return metadata
+}
+
+type AuditService interface {
+	// Record stores an action on a resource with the fields that changed
+	// between before and after. Pass nil as before for creations and as after
+	// for deletions.
+	Record(ctx context.Context, action, resource, resourceID string, before, after interface{}) error
+	List(ctx context.Context, spec *model.ListSpec) ([]*model.AuditEvent, int, error)
+}
+
+type auditService struct {
+	events repository.AuditRepository
+}
+
+func NewAuditService(events repository.AuditRepository) AuditService {
+	return &auditService{events: events}
+}
+
+func (service *auditService) Record(ctx context.Context, action, resource, resourceID string, before, after interface{}) error {
+	changes, err := model.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	metadata := AuditMetadataFrom(ctx)
+	return service.events.Create(ctx, &model.AuditEvent{
+		Actor:      metadata.Actor,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Changes:    changes,
+		IP:         metadata.IP,
+		RequestID:  metadata.RequestID,
+	})
+}
+
+func (service *auditService) List(ctx context.Context, spec *model.ListSpec) ([]*model.AuditEvent, int, error) {
+	return service.events.List(ctx, spec)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+func TestAuditUserUpdate(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	events := repository.NewMemoryAuditRepository()
+
+	ctx := WithAuditMetadata(context.Background(), AuditMetadata{Actor: "anonymous", IP: "10.0.0.1", RequestID: "abc"})
+	ctx = WithActor(ctx, "admin")
+	_, err := NewUserService(users, repository.NewMemoryTransactor(), NewAuditService(events), event.Discard).Update(ctx, user.ID, &model.UpdateUserRequest{Username: "brian", Name: "Brian Ashari"})
+	assert.Nil(t, err)
+
+	recorded, total, err := events.List(context.Background(), &model.ListSpec{Page: 1, PerPage: 10, Filters: map[string]string{"resource_id": user.ID}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	event := recorded[0]
+	assert.Equal(t, "admin", event.Actor)
+	assert.Equal(t, "user.update", event.Action)
+	assert.Equal(t, "10.0.0.1", event.IP)
+	assert.Equal(t, "abc", event.RequestID)
+	assert.Equal(t, map[string]model.Change{
+		"email":   {Before: "brian@example.com", After: ""},
+		"name":    {Before: "", After: "Brian Ashari"},
+		"version": {Before: float64(1), After: float64(2)},
+	}, event.Changes)
+}
+
+func TestAuditOutsideRequest(t *testing.T) {
+	events := repository.NewMemoryAuditRepository()
+	user := &model.User{ID: "1", Username: "brian"}
+	assert.Nil(t, NewAuditService(events).Record(context.Background(), "user.create", "user", user.ID, nil, user))
+
+	recorded, _, err := events.List(context.Background(), &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, ActorSystem, recorded[0].Actor)
+	assert.Equal(t, model.Change{After: "brian"}, recorded[0].Changes["username"])
+	assert.Nil(t, recorded[0].Changes["username"].Before)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+)
+
+// OAuthProfile is the account information returned by an OAuth provider.
+type OAuthProfile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+}
+
+type AuthService interface {
+	Provision(ctx context.Context, provider string, profile *OAuthProfile) (*model.User, error)
+}
+
+type authService struct {
+	users      repository.UserRepository
+	transactor repository.Transactor
+	audit      AuditService
+	events     event.Publisher
+}
+
+func NewAuthService(users repository.UserRepository, transactor repository.Transactor, audit AuditService, events event.Publisher) AuthService {
+	return &authService{users: users, transactor: transactor, audit: audit, events: events}
+}
+
+// Provision returns the user already linked to the provider account, links the
+// account to a local user with the same verified email, or creates a new user.
+func (service *authService) Provision(ctx context.Context, provider string, profile *OAuthProfile) (*model.User, error) {
+	user, err := service.users.FindByIdentity(ctx, provider, profile.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, model.ErrUserNotFound) {
+		return nil, err
+	}
+
+	identity := model.Identity{Provider: provider, Subject: profile.Subject}
+
+	if profile.EmailVerified {
+		user, err = service.users.FindByEmail(ctx, profile.Email)
+		if err == nil {
+			err = service.users.LinkIdentity(ctx, user.ID, identity)
+			if err != nil {
+				return nil, err
+			}
+			return service.users.FindByID(ctx, user.ID)
+		}
+		if !errors.Is(err, model.ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	user = &model.User{
+		Username:   profile.Username,
+		Name:       profile.Name,
+		Identities: []model.Identity{identity},
+	}
+	if profile.EmailVerified {
+		user.Email = profile.Email
+	}
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "user.create", "user", user.ID, nil, user)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.UserRegistered{User: user})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+func TestAuthServiceProvisionCreatesUser(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	auth := NewAuthService(users, repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()), event.Discard)
+
+	profile := &OAuthProfile{Subject: "42", Email: "brian@example.com", Username: "brian"}
+	user, err := auth.Provision(context.Background(), "github", profile)
+	assert.Nil(t, err)
+	assert.Empty(t, user.Email)
+	assert.Equal(t, []model.Identity{{Provider: "github", Subject: "42"}}, user.Identities)
+
+	again, err := auth.Provision(context.Background(), "github", profile)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, again.ID)
+}
+
+func TestAuthServiceProvisionLinksVerifiedEmail(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	local := &model.User{Username: "brian", Email: "Brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), local))
+
+	user, err := NewAuthService(users, repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()), event.Discard).Provision(context.Background(), "google", &OAuthProfile{Subject: "abc", Email: "brian@example.com", EmailVerified: true})
+	assert.Nil(t, err)
+	assert.Equal(t, local.ID, user.ID)
+	assert.Len(t, user.Identities, 1)
+}
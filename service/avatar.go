@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/upload"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+)
+
+var logger = telemetry.Logger("service")
+
+// AvatarService stores the avatars of the users in the sizes of
+// config.AvatarConfig, under "avatars/<user ID>/<avatar version>/".
+type AvatarService interface {
+	// Upload replaces the avatar of the user with the image of content,
+	// whose size is given. Images that are not GIF, JPEG or PNG or are too
+	// large fail with model.ValidationErrors.
+	Upload(ctx context.Context, userID string, size int64, content io.Reader) (*model.User, error)
+	Delete(ctx context.Context, userID string) error
+	// Open returns the avatar of the user in the smallest size of at least
+	// size pixels, or the largest one, along with its version and size. It
+	// fails with model.ErrAvatarNotFound when the user has no avatar.
+	Open(ctx context.Context, userID string, size int) (io.ReadCloser, string, int, error)
+}
+
+type avatarService struct {
+	config     config.AvatarConfig
+	users      repository.UserRepository
+	storage    storage.Storage
+	transactor repository.Transactor
+	audit      AuditService
+	events     event.Publisher
+}
+
+func NewAvatarService(config config.AvatarConfig, users repository.UserRepository, storage storage.Storage, transactor repository.Transactor,
+	audit AuditService, events event.Publisher) AvatarService {
+	sizes := slices.Clone(config.Sizes)
+	slices.Sort(sizes)
+	config.Sizes = sizes
+	return &avatarService{config: config, users: users, storage: storage, transactor: transactor, audit: audit, events: events}
+}
+
+func (service *avatarService) Upload(ctx context.Context, userID string, size int64, content io.Reader) (*model.User, error) {
+	if size > int64(service.config.MaxSize) {
+		return nil, model.ValidationErrors{{Field: "avatar", Message: "must be at most " + strconv.Itoa(int(service.config.MaxSize)) + " bytes"}}
+	}
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, int64(service.config.MaxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > int(service.config.MaxSize) {
+		return nil, model.ValidationErrors{{Field: "avatar", Message: "must be at most " + strconv.Itoa(int(service.config.MaxSize)) + " bytes"}}
+	}
+	avatars, err := upload.Avatar(bytes.NewReader(data), service.config.Sizes, service.config.MaxDimension)
+	if errors.Is(err, upload.ErrNotImage) {
+		return nil, model.ValidationErrors{{Field: "avatar", Message: "must be a GIF, JPEG or PNG image"}}
+	}
+	if errors.Is(err, upload.ErrImageTooLarge) {
+		return nil, model.ValidationErrors{{Field: "avatar", Message: "must be at most " + strconv.Itoa(service.config.MaxDimension) + " pixels wide and high"}}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+	version := hex.EncodeToString(hash[:8])
+	for size, avatar := range avatars {
+		err = service.storage.Put(ctx, avatarKey(userID, version, size), bytes.NewReader(avatar))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	before := *user
+	user.Avatar = version
+	err = service.save(ctx, &before, user)
+	if err != nil {
+		return nil, err
+	}
+	if before.Avatar != "" && before.Avatar != version {
+		service.deleteVersion(ctx, userID, before.Avatar)
+	}
+	return user, nil
+}
+
+func (service *avatarService) Delete(ctx context.Context, userID string) error {
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Avatar == "" {
+		return model.ErrAvatarNotFound
+	}
+	before := *user
+	user.Avatar = ""
+	err = service.save(ctx, &before, user)
+	if err != nil {
+		return err
+	}
+	service.deleteVersion(ctx, userID, before.Avatar)
+	return nil
+}
+
+func (service *avatarService) Open(ctx context.Context, userID string, size int) (io.ReadCloser, string, int, error) {
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if user.Avatar == "" || len(service.config.Sizes) == 0 {
+		return nil, "", 0, model.ErrAvatarNotFound
+	}
+	index, _ := slices.BinarySearch(service.config.Sizes, size)
+	size = service.config.Sizes[min(index, len(service.config.Sizes)-1)]
+	avatar, err := service.storage.Open(ctx, avatarKey(userID, user.Avatar, size))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", 0, model.ErrAvatarNotFound
+	}
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return avatar, user.Avatar, size, nil
+}
+
+// save records the new avatar of user, which was before, and publishes the
+// change like any other change of the user.
+func (service *avatarService) save(ctx context.Context, before, user *model.User) error {
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.SetAvatar(ctx, user.ID, user.Avatar)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "user.update_avatar", "user", user.ID, before, user)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.UserUpdated{User: user})
+	})
+}
+
+// deleteVersion deletes the images of an avatar that was replaced. Failing to
+// only leaves them behind.
+func (service *avatarService) deleteVersion(ctx context.Context, userID, version string) {
+	err := service.storage.DeleteAll(ctx, path.Dir(avatarKey(userID, version, 0))+"/")
+	if err != nil {
+		logger.WarnContext(ctx, "deleting replaced avatar", "user_id", userID, "avatar", version, "error", err)
+	}
+}
+
+func avatarKey(userID, version string, size int) string {
+	return path.Join("avatars", userID, version, strconv.Itoa(size)+".png")
+}
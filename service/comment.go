@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"time"
+)
+
+// CommentTarget checks that the resource id exists and that the user, empty
+// when anonymous, may see it, failing with the not-found error of the
+// resource otherwise.
+type CommentTarget func(ctx context.Context, userID, id string) error
+
+// CommentService keeps the comments and ratings on the resources of the
+// types it has a CommentTarget for; the other types have no comments. The
+// comments not on the resource given fail with model.ErrCommentNotFound.
+type CommentService interface {
+	// Create adds a comment of the user on the resource, or a reply when
+	// request has a ParentID. A user rates a resource once, and creates at
+	// most config.CommentsConfig.DailyLimit comments a day, failing with
+	// model.ErrQuotaExceeded after that.
+	Create(ctx context.Context, resource, resourceID, userID string, request *model.CreateCommentRequest) (*model.Comment, error)
+	// List lists the visible comments on the resource with their visible
+	// replies nested in them.
+	List(ctx context.Context, resource, resourceID, userID string, spec *model.ListSpec) ([]*model.Comment, int, error)
+	Rating(ctx context.Context, resource, resourceID, userID string) (*model.RatingSummary, error)
+	// Flag reports a comment for moderation on behalf of the user.
+	Flag(ctx context.Context, resource, resourceID, userID, id string) (*model.Comment, error)
+	// Moderate sets the status of a comment, for moderators.
+	Moderate(ctx context.Context, resource, resourceID, id string, request *model.ModerateCommentRequest) (*model.Comment, error)
+	// Delete deletes a comment of the user along with its replies.
+	Delete(ctx context.Context, resource, resourceID, userID, id string) error
+}
+
+type commentService struct {
+	config     config.CommentsConfig
+	targets    map[string]CommentTarget
+	comments   repository.CommentRepository
+	quotas     QuotaService
+	transactor repository.Transactor
+	audit      AuditService
+	now        func() time.Time
+}
+
+func NewCommentService(config config.CommentsConfig, targets map[string]CommentTarget, comments repository.CommentRepository, quotas QuotaService,
+	transactor repository.Transactor, audit AuditService) CommentService {
+	return &commentService{config: config, targets: targets, comments: comments, quotas: quotas, transactor: transactor, audit: audit, now: time.Now}
+}
+
+func (service *commentService) Create(ctx context.Context, resource, resourceID, userID string, request *model.CreateCommentRequest) (*model.Comment, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	err = service.target(ctx, resource, resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{Resource: resource, ResourceID: resourceID, UserID: userID, Body: request.Body, Rating: request.Rating,
+		Status: model.CommentVisible, CreatedAt: service.now()}
+	if request.ParentID != "" {
+		if request.Rating > 0 {
+			return nil, model.ErrRatingOnReply
+		}
+		parent, err := service.find(ctx, resource, resourceID, request.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Depth >= service.config.MaxDepth {
+			return nil, model.ErrCommentTooDeep
+		}
+		comment.ParentID = parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+	if comment.Rating > 0 {
+		rated, err := service.comments.HasRated(ctx, resource, resourceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if rated {
+			return nil, model.ErrAlreadyRated
+		}
+	}
+
+	_, err = service.quotas.Consume(ctx, []model.QuotaLimit{{Subject: "comments:" + userID, Period: model.QuotaDaily, Limit: service.config.DailyLimit}})
+	if err != nil {
+		return nil, err
+	}
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.comments.Create(ctx, comment)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "comment.create", "comment", comment.ID, nil, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (service *commentService) List(ctx context.Context, resource, resourceID, userID string, spec *model.ListSpec) ([]*model.Comment, int, error) {
+	err := service.target(ctx, resource, resourceID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	comments, total, err := service.comments.List(ctx, resource, resourceID, spec)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The replies are fetched a level at a time and nested in their parents.
+	parents := comments
+	for len(parents) > 0 {
+		ids := make([]string, len(parents))
+		byID := make(map[string]*model.Comment, len(parents))
+		for i, parent := range parents {
+			ids[i] = parent.ID
+			byID[parent.ID] = parent
+		}
+		replies, err := service.comments.Replies(ctx, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, reply := range replies {
+			byID[reply.ParentID].Replies = append(byID[reply.ParentID].Replies, reply)
+		}
+		parents = replies
+	}
+	return comments, total, nil
+}
+
+func (service *commentService) Rating(ctx context.Context, resource, resourceID, userID string) (*model.RatingSummary, error) {
+	err := service.target(ctx, resource, resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return service.comments.Rating(ctx, resource, resourceID)
+}
+
+func (service *commentService) Flag(ctx context.Context, resource, resourceID, userID, id string) (*model.Comment, error) {
+	err := service.target(ctx, resource, resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := service.find(ctx, resource, resourceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Status != model.CommentVisible {
+		return comment, nil
+	}
+	return service.comments.Flag(ctx, id, userID, service.config.FlagThreshold, service.now())
+}
+
+func (service *commentService) Moderate(ctx context.Context, resource, resourceID, id string, request *model.ModerateCommentRequest) (*model.Comment, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	before, err := service.find(ctx, resource, resourceID, id)
+	if err != nil {
+		return nil, err
+	}
+	comment := *before
+	comment.Status = request.Status
+	comment.UpdatedAt = service.now()
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.comments.SetStatus(ctx, id, comment.Status, comment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "comment.moderate", "comment", id, before, &comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (service *commentService) Delete(ctx context.Context, resource, resourceID, userID, id string) error {
+	comment, err := service.find(ctx, resource, resourceID, id)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID {
+		return model.ErrCommentNotFound
+	}
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.comments.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "comment.delete", "comment", id, comment, nil)
+	})
+}
+
+func (service *commentService) target(ctx context.Context, resource, resourceID, userID string) error {
+	target, ok := service.targets[resource]
+	if !ok {
+		return model.ErrCommentNotFound
+	}
+	return target(ctx, userID, resourceID)
+}
+
+// find finds the comment with id on the resource.
+func (service *commentService) find(ctx context.Context, resource, resourceID, id string) (*model.Comment, error) {
+	comment, err := service.comments.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Resource != resource || comment.ResourceID != resourceID {
+		return nil, model.ErrCommentNotFound
+	}
+	return comment, nil
+}
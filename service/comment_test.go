@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+func newTestCommentService() CommentService {
+	targets := map[string]CommentTarget{"product": func(ctx context.Context, userID, id string) error {
+		if id != "p1" {
+			return model.ErrProductNotFound
+		}
+		return nil
+	}}
+	return NewCommentService(config.CommentsConfig{DailyLimit: 5, MaxDepth: 1, FlagThreshold: 2}, targets, repository.NewMemoryCommentRepository(),
+		NewQuotaService(repository.NewMemoryQuotaRepository()), repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()))
+}
+
+func TestComments(t *testing.T) {
+	ctx := context.Background()
+	comments := newTestCommentService()
+
+	_, err := comments.Create(ctx, "product", "p2", "1", &model.CreateCommentRequest{Body: "Nice"})
+	assert.ErrorIs(t, err, model.ErrProductNotFound)
+	_, err = comments.Create(ctx, "order", "o1", "1", &model.CreateCommentRequest{Body: "Nice"})
+	assert.ErrorIs(t, err, model.ErrCommentNotFound)
+
+	first, err := comments.Create(ctx, "product", "p1", "1", &model.CreateCommentRequest{Body: "Nice", Rating: 5})
+	assert.Nil(t, err)
+	_, err = comments.Create(ctx, "product", "p1", "1", &model.CreateCommentRequest{Body: "Still nice", Rating: 4})
+	assert.ErrorIs(t, err, model.ErrAlreadyRated)
+	_, err = comments.Create(ctx, "product", "p1", "2", &model.CreateCommentRequest{Body: "Meh", Rating: 2})
+	assert.Nil(t, err)
+
+	reply, err := comments.Create(ctx, "product", "p1", "2", &model.CreateCommentRequest{ParentID: first.ID, Body: "Agreed"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, reply.Depth)
+	_, err = comments.Create(ctx, "product", "p1", "1", &model.CreateCommentRequest{ParentID: reply.ID, Body: "Thanks"})
+	assert.ErrorIs(t, err, model.ErrCommentTooDeep)
+	_, err = comments.Create(ctx, "product", "p1", "3", &model.CreateCommentRequest{ParentID: first.ID, Body: "Agreed", Rating: 5})
+	assert.ErrorIs(t, err, model.ErrRatingOnReply)
+
+	list, total, err := comments.List(ctx, "product", "p1", "", &model.ListSpec{Page: 1, PerPage: 10, Sort: []model.SortField{{Field: "created_at"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, list[0].Replies, 1)
+	assert.Equal(t, reply.ID, list[0].Replies[0].ID)
+
+	rating, err := comments.Rating(ctx, "product", "p1", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rating.Count)
+	assert.Equal(t, 3.5, rating.Average)
+	assert.Equal(t, 1, rating.Distribution[5])
+
+	flagged, err := comments.Flag(ctx, "product", "p1", "2", first.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.CommentVisible, flagged.Status)
+	flagged, err = comments.Flag(ctx, "product", "p1", "2", first.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, flagged.Flags)
+	flagged, err = comments.Flag(ctx, "product", "p1", "3", first.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.CommentFlagged, flagged.Status)
+
+	rating, err = comments.Rating(ctx, "product", "p1", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, rating.Count)
+
+	restored, err := comments.Moderate(ctx, "product", "p1", first.ID, &model.ModerateCommentRequest{Status: model.CommentVisible})
+	assert.Nil(t, err)
+	assert.Equal(t, model.CommentVisible, restored.Status)
+
+	assert.ErrorIs(t, comments.Delete(ctx, "product", "p1", "2", first.ID), model.ErrCommentNotFound)
+	assert.Nil(t, comments.Delete(ctx, "product", "p1", "1", first.ID))
+	_, total, err = comments.List(ctx, "product", "p1", "", &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func TestCommentDailyLimit(t *testing.T) {
+	ctx := context.Background()
+	comments := newTestCommentService()
+	for i := 0; i < 5; i++ {
+		_, err := comments.Create(ctx, "product", "p1", "1", &model.CreateCommentRequest{Body: "Hello"})
+		assert.Nil(t, err)
+	}
+	_, err := comments.Create(ctx, "product", "p1", "1", &model.CreateCommentRequest{Body: "Hello"})
+	assert.ErrorIs(t, err, model.ErrQuotaExceeded)
+	_, err = comments.Create(ctx, "product", "p1", "2", &model.CreateCommentRequest{Body: "Hello"})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/config"
+	"golang-fiber-web/mail"
+	"golang-fiber-web/model"
+	"strings"
+)
+
+// ContactService mails the messages of the contact form to the address of
+// config.ContactConfig.
+type ContactService interface {
+	// Send mails the message of the client at ip, who sends at most
+	// config.ContactConfig.DailyLimit a day and fails with
+	// model.ErrQuotaExceeded after that. Messages with the honeypot filled
+	// in are dropped as if sent. It fails with model.ErrMailDisabled while
+	// no mail server or recipient is set up.
+	Send(ctx context.Context, ip string, request *model.ContactRequest) error
+}
+
+type contactService struct {
+	sender mail.Sender
+	config config.ContactConfig
+	quotas QuotaService
+}
+
+// NewContactService sends through sender, nil when no mail server is set up.
+func NewContactService(sender mail.Sender, config config.ContactConfig, quotas QuotaService) ContactService {
+	return &contactService{sender: sender, config: config, quotas: quotas}
+}
+
+func (service *contactService) Send(ctx context.Context, ip string, request *model.ContactRequest) error {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return err
+	}
+	if request.Website != "" {
+		logger.InfoContext(ctx, "dropping contact message with the honeypot filled in", "ip", ip)
+		return nil
+	}
+	if service.sender == nil || service.config.To == "" {
+		return model.ErrMailDisabled
+	}
+
+	_, err = service.quotas.Consume(ctx, []model.QuotaLimit{{Subject: "contact:" + ip, Period: model.QuotaDaily, Limit: service.config.DailyLimit}})
+	if err != nil {
+		return err
+	}
+	from := mail.Address(request.Name, request.Email)
+	subject := strings.Join(strings.Fields(request.Subject), " ")
+	if subject == "" {
+		subject = "Message from " + strings.Join(strings.Fields(request.Name), " ")
+	}
+	return service.sender.Send(ctx, &mail.Message{
+		To:      []string{service.config.To},
+		ReplyTo: from,
+		Subject: "[Contact] " + subject,
+		Body:    "From: " + from + "\nIP: " + ip + "\n\n" + request.Message,
+	})
+}
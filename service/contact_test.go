@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/mail"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+type fakeMailSender struct {
+	sent []*mail.Message
+}
+
+func (sender *fakeMailSender) Send(ctx context.Context, message *mail.Message) error {
+	sender.sent = append(sender.sent, message)
+	return nil
+}
+
+func TestContact(t *testing.T) {
+	ctx := context.Background()
+	sender := &fakeMailSender{}
+	contact := NewContactService(sender, config.ContactConfig{To: "support@example.com", DailyLimit: 1}, NewQuotaService(repository.NewMemoryQuotaRepository()))
+
+	var validation model.ValidationErrors
+	assert.ErrorAs(t, contact.Send(ctx, "10.0.0.1", &model.ContactRequest{Name: "Brian", Email: "not an email", Message: "Hi"}), &validation)
+
+	assert.Nil(t, contact.Send(ctx, "10.0.0.1", &model.ContactRequest{Name: "Bot", Email: "bot@example.com", Message: "Buy", Website: "http://spam.example.com"}))
+	assert.Empty(t, sender.sent)
+
+	request := &model.ContactRequest{Name: "Brian", Email: "brian@example.com", Subject: "Order\r\nBcc: x@example.com", Message: "Where is my order?"}
+	assert.Nil(t, contact.Send(ctx, "10.0.0.1", request))
+	assert.Len(t, sender.sent, 1)
+	assert.Equal(t, []string{"support@example.com"}, sender.sent[0].To)
+	assert.Equal(t, `"Brian" <brian@example.com>`, sender.sent[0].ReplyTo)
+	assert.Equal(t, "[Contact] Order Bcc: x@example.com", sender.sent[0].Subject)
+
+	assert.ErrorIs(t, contact.Send(ctx, "10.0.0.1", request), model.ErrQuotaExceeded)
+	assert.Nil(t, contact.Send(ctx, "10.0.0.2", request))
+
+	disabled := NewContactService(nil, config.ContactConfig{To: "support@example.com", DailyLimit: 1}, NewQuotaService(repository.NewMemoryQuotaRepository()))
+	assert.ErrorIs(t, disabled.Send(ctx, "10.0.0.1", request), model.ErrMailDisabled)
+}
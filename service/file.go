@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/upload"
+	"io"
+	"strings"
+)
+
+// FileService manages the uploaded files. The files of an owner are only
+// found by the same owner; FindByID finds any file, for the routes that
+// serve files by their unguessable ID.
+type FileService interface {
+	// Upload stores content as a file of ownerID called name, records it as
+	// pending and publishes event.FileUploaded for the upload pipeline to
+	// process it. It fails with model.ErrStorageQuotaExceeded when the file
+	// does not fit in the storage quota of the owner.
+	Upload(ctx context.Context, ownerID, name string, size int64, content io.Reader) (*model.File, error)
+	FindByID(ctx context.Context, id string) (*model.File, error)
+	Find(ctx context.Context, ownerID, id string) (*model.File, error)
+	List(ctx context.Context, ownerID string, spec *model.ListSpec) ([]*model.File, int, error)
+	Usage(ctx context.Context, ownerID string) (*model.StorageUsage, error)
+	Rename(ctx context.Context, ownerID, id string, request *model.RenameFileRequest) (*model.File, error)
+	// Delete deletes the file along with its content and thumbnails.
+	Delete(ctx context.Context, ownerID, id string) error
+}
+
+type fileService struct {
+	files      repository.FileRepository
+	storage    storage.Storage
+	transactor repository.Transactor
+	events     event.Publisher
+	quota      func(ownerID string) int64
+}
+
+// NewFileService returns the FileService storing the files in storage.
+// quota returns the storage quota of an owner in bytes, zero for no limit;
+// without it there are no quotas.
+func NewFileService(files repository.FileRepository, storage storage.Storage, transactor repository.Transactor, events event.Publisher, quota func(ownerID string) int64) FileService {
+	return &fileService{files: files, storage: storage, transactor: transactor, events: events, quota: quota}
+}
+
+func (service *fileService) Upload(ctx context.Context, ownerID, name string, size int64, content io.Reader) (*model.File, error) {
+	err := validateFileName(name)
+	if err != nil {
+		return nil, err
+	}
+	quota := service.ownerQuota(ownerID)
+	if quota > 0 {
+		// Checked before storing the file not to store files that are
+		// too big, and again when creating the record in case other
+		// uploads took up the quota in the meantime.
+		usage, err := service.files.Usage(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if usage.Used+size > quota {
+			return nil, model.ErrStorageQuotaExceeded
+		}
+	}
+
+	id := uuid.NewString()
+	file := &model.File{ID: id, OwnerID: ownerID, Name: name, Size: size, Status: model.FilePending, StorageKey: "files/" + id}
+	err = service.storage.Put(ctx, file.StorageKey, content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if quota > 0 {
+			err = service.files.CreateWithinQuota(ctx, file, quota)
+		} else {
+			err = service.files.Create(ctx, file)
+		}
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.FileUploaded{FileID: file.ID, FileName: name, Size: size})
+	})
+	if err != nil {
+		return nil, errors.Join(err, service.storage.Delete(ctx, file.StorageKey))
+	}
+	return file, nil
+}
+
+func (service *fileService) FindByID(ctx context.Context, id string) (*model.File, error) {
+	return service.files.FindByID(ctx, id)
+}
+
+func (service *fileService) Find(ctx context.Context, ownerID, id string) (*model.File, error) {
+	file, err := service.files.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.OwnerID != ownerID {
+		return nil, model.ErrFileNotFound
+	}
+	return file, nil
+}
+
+func (service *fileService) List(ctx context.Context, ownerID string, spec *model.ListSpec) ([]*model.File, int, error) {
+	return service.files.List(ctx, ownerID, spec)
+}
+
+func (service *fileService) Usage(ctx context.Context, ownerID string) (*model.StorageUsage, error) {
+	usage, err := service.files.Usage(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Limit = service.ownerQuota(ownerID)
+	return usage, nil
+}
+
+// ownerQuota returns the storage quota of ownerID, zero for the files
+// without an owner.
+func (service *fileService) ownerQuota(ownerID string) int64 {
+	if ownerID == "" || service.quota == nil {
+		return 0
+	}
+	return service.quota(ownerID)
+}
+
+func (service *fileService) Rename(ctx context.Context, ownerID, id string, request *model.RenameFileRequest) (*model.File, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	err = validateFileName(request.Name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := service.Find(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	file.Name = request.Name
+	err = service.files.Update(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (service *fileService) Delete(ctx context.Context, ownerID, id string) error {
+	file, err := service.Find(ctx, ownerID, id)
+	if err != nil {
+		return err
+	}
+	err = service.files.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = service.storage.Delete(ctx, file.StorageKey)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return service.storage.DeleteAll(ctx, upload.ThumbnailPrefix(id))
+}
+
+// validateFileName rejects the names that are paths rather than file names.
+func validateFileName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return model.ValidationErrors{{Field: "name", Message: "must be a file name"}}
+	}
+	return nil
+}
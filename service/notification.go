@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"sync"
+	"time"
+)
+
+// notificationBuffer is how many messages a subscriber may fall behind
+// before the next ones are dropped for it.
+const notificationBuffer = 16
+
+// NotificationMessage is what Subscribe delivers: a new notification along
+// with the unread count of the user, or only the count after a read.
+type NotificationMessage struct {
+	Notification *model.Notification `json:"notification,omitempty"`
+	Unread       int                 `json:"unread"`
+}
+
+// NotificationService keeps the in-app notifications of the users. The
+// notifications of a user are only found by the same user; the others fail
+// with model.ErrNotificationNotFound.
+type NotificationService interface {
+	// Notify stores the notification, delivers it to the subscribers of its
+	// user and publishes event.NotificationCreated to have it pushed.
+	Notify(ctx context.Context, notification *model.Notification) error
+	List(ctx context.Context, userID string, unread bool, spec *model.ListSpec) ([]*model.Notification, int, error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+	// MarkRead marks a notification of the user read, leaving one already
+	// read as it is.
+	MarkRead(ctx context.Context, userID, id string) (*model.Notification, error)
+	// MarkAllRead marks every notification of the user read and returns how
+	// many were unread.
+	MarkAllRead(ctx context.Context, userID string) (int, error)
+	// Subscribe delivers the notifications of the user, and the changes of
+	// their unread count, until unsubscribe is called. Subscribers only hear
+	// of the changes made by this process; under prefork, each child has
+	// its own.
+	Subscribe(userID string) (messages <-chan NotificationMessage, unsubscribe func())
+	// HandleEvent subscribes to the order events, notifying the user of
+	// their orders being placed and changing status.
+	HandleEvent(ctx context.Context, happened event.Event) error
+}
+
+type notificationService struct {
+	notifications repository.NotificationRepository
+	transactor    repository.Transactor
+	events        event.Publisher
+	now           func() time.Time
+
+	mutex       sync.Mutex
+	subscribers map[string]map[chan NotificationMessage]struct{}
+}
+
+func NewNotificationService(notifications repository.NotificationRepository, transactor repository.Transactor, events event.Publisher) NotificationService {
+	return &notificationService{notifications: notifications, transactor: transactor, events: events, now: time.Now,
+		subscribers: map[string]map[chan NotificationMessage]struct{}{}}
+}
+
+func (service *notificationService) Notify(ctx context.Context, notification *model.Notification) error {
+	err := service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.notifications.Create(ctx, notification)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.NotificationCreated{Notification: notification})
+	})
+	if err != nil {
+		return err
+	}
+	return service.broadcast(ctx, notification.UserID, notification)
+}
+
+func (service *notificationService) List(ctx context.Context, userID string, unread bool, spec *model.ListSpec) ([]*model.Notification, int, error) {
+	return service.notifications.List(ctx, userID, unread, spec)
+}
+
+func (service *notificationService) UnreadCount(ctx context.Context, userID string) (int, error) {
+	return service.notifications.CountUnread(ctx, userID)
+}
+
+func (service *notificationService) MarkRead(ctx context.Context, userID, id string) (*model.Notification, error) {
+	notification, err := service.notifications.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if notification.UserID != userID {
+		return nil, model.ErrNotificationNotFound
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	now := service.now()
+	marked, err := service.notifications.MarkRead(ctx, userID, []string{id}, now)
+	if err != nil {
+		return nil, err
+	}
+	notification.ReadAt = &now
+	if marked > 0 {
+		err = service.broadcast(ctx, userID, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return notification, nil
+}
+
+func (service *notificationService) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	marked, err := service.notifications.MarkRead(ctx, userID, nil, service.now())
+	if err != nil {
+		return 0, err
+	}
+	if marked > 0 {
+		err = service.broadcast(ctx, userID, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return marked, nil
+}
+
+func (service *notificationService) Subscribe(userID string) (<-chan NotificationMessage, func()) {
+	messages := make(chan NotificationMessage, notificationBuffer)
+	service.mutex.Lock()
+	if service.subscribers[userID] == nil {
+		service.subscribers[userID] = map[chan NotificationMessage]struct{}{}
+	}
+	service.subscribers[userID][messages] = struct{}{}
+	service.mutex.Unlock()
+
+	var once sync.Once
+	return messages, func() {
+		once.Do(func() {
+			service.mutex.Lock()
+			defer service.mutex.Unlock()
+			delete(service.subscribers[userID], messages)
+			if len(service.subscribers[userID]) == 0 {
+				delete(service.subscribers, userID)
+			}
+		})
+	}
+}
+
+func (service *notificationService) HandleEvent(ctx context.Context, happened event.Event) error {
+	switch happened := happened.(type) {
+	case event.OrderPlaced:
+		order := happened.Order
+		return service.Notify(ctx, &model.Notification{
+			UserID: order.UserID,
+			Kind:   event.NameOrderPlaced,
+			Title:  "Order placed",
+			Body:   "Your order " + order.ID + " has been placed.",
+			Link:   "/users/" + order.UserID + "/orders/" + order.ID,
+		})
+	case event.OrderStatusChanged:
+		return service.Notify(ctx, &model.Notification{
+			UserID: happened.UserID,
+			Kind:   event.NameOrderStatusChanged,
+			Title:  "Order " + happened.To,
+			Body:   "Your order " + happened.OrderID + " went from " + happened.From + " to " + happened.To + ".",
+			Link:   "/users/" + happened.UserID + "/orders/" + happened.OrderID,
+		})
+	}
+	return nil
+}
+
+// broadcast tells the subscribers of the user about notification, if any,
+// and their new unread count. Subscribers whose buffer is full miss it.
+func (service *notificationService) broadcast(ctx context.Context, userID string, notification *model.Notification) error {
+	service.mutex.Lock()
+	subscribed := len(service.subscribers[userID]) > 0
+	service.mutex.Unlock()
+	if !subscribed {
+		return nil
+	}
+
+	unread, err := service.notifications.CountUnread(ctx, userID)
+	if err != nil {
+		return err
+	}
+	message := NotificationMessage{Notification: notification, Unread: unread}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	for messages := range service.subscribers[userID] {
+		select {
+		case messages <- message:
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+func TestNotificationsFromOrderEvents(t *testing.T) {
+	ctx := context.Background()
+	notifications := NewNotificationService(repository.NewMemoryNotificationRepository(), repository.NewMemoryTransactor(), event.Discard)
+	messages, unsubscribe := notifications.Subscribe("1")
+	defer unsubscribe()
+
+	order := &model.Order{ID: "order-1", UserID: "1"}
+	assert.Nil(t, notifications.HandleEvent(ctx, event.OrderPlaced{Order: order}))
+	assert.Nil(t, notifications.HandleEvent(ctx, event.OrderStatusChanged{OrderID: order.ID, UserID: "1", From: model.OrderPending, To: "paid"}))
+	assert.Nil(t, notifications.HandleEvent(ctx, event.UserDeleted{}))
+
+	message := <-messages
+	assert.Equal(t, event.NameOrderPlaced, message.Notification.Kind)
+	assert.Equal(t, "/users/1/orders/order-1", message.Notification.Link)
+	assert.Equal(t, 1, message.Unread)
+	message = <-messages
+	assert.Equal(t, "Order paid", message.Notification.Title)
+	assert.Equal(t, 2, message.Unread)
+
+	marked, err := notifications.MarkAllRead(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, marked)
+	message = <-messages
+	assert.Nil(t, message.Notification)
+	assert.Equal(t, 0, message.Unread)
+
+	unsubscribe()
+	assert.Nil(t, notifications.HandleEvent(ctx, event.OrderPlaced{Order: order}))
+	assert.Len(t, messages, 0)
+}
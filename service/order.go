@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"strings"
+)
+
+// OrderService manages the orders of the users. The orders of a user are
+// only found by the same user; the orders of others fail with
+// model.ErrOrderNotFound.
+type OrderService interface {
+	// Place creates a pending order of the user.
+	Place(ctx context.Context, userID string, request *model.CreateOrderRequest) (*model.Order, error)
+	Find(ctx context.Context, userID, id string) (*model.Order, error)
+	List(ctx context.Context, userID string, created model.TimeRange, spec *model.ListSpec) ([]*model.Order, int, error)
+	// Update replaces the items and notes of a pending order. Other orders
+	// fail with model.ErrOrderNotEditable.
+	Update(ctx context.Context, userID, id string, request *model.UpdateOrderRequest) (*model.Order, error)
+	// Transition moves the order to the status of request, failing with
+	// model.ErrInvalidOrderTransition unless model.CanTransition allows it.
+	Transition(ctx context.Context, userID, id string, request *model.TransitionOrderRequest) (*model.Order, error)
+	// Delete deletes a pending order. Other orders fail with
+	// model.ErrOrderNotEditable; they are cancelled instead.
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type orderService struct {
+	orders     repository.OrderRepository
+	transactor repository.Transactor
+	audit      AuditService
+	events     event.Publisher
+}
+
+func NewOrderService(orders repository.OrderRepository, transactor repository.Transactor, audit AuditService, events event.Publisher) OrderService {
+	return &orderService{orders: orders, transactor: transactor, audit: audit, events: events}
+}
+
+func (service *orderService) Place(ctx context.Context, userID string, request *model.CreateOrderRequest) (*model.Order, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	order := &model.Order{UserID: userID, Status: model.OrderPending, PaymentStatus: model.PaymentUnpaid, Currency: strings.ToUpper(request.Currency), Notes: request.Notes}
+	order.SetItems(request.Items)
+
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.orders.Create(ctx, order)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "order.create", "order", order.ID, nil, order)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.OrderPlaced{Order: order})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (service *orderService) Find(ctx context.Context, userID, id string) (*model.Order, error) {
+	order, err := service.orders.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, model.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (service *orderService) List(ctx context.Context, userID string, created model.TimeRange, spec *model.ListSpec) ([]*model.Order, int, error) {
+	return service.orders.List(ctx, userID, created, spec)
+}
+
+func (service *orderService) Update(ctx context.Context, userID, id string, request *model.UpdateOrderRequest) (*model.Order, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	order, err := service.Find(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.OrderPending {
+		return nil, model.ErrOrderNotEditable
+	}
+
+	before := *order
+	order.SetItems(request.Items)
+	order.Notes = request.Notes
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.orders.Update(ctx, order)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "order.update", "order", order.ID, &before, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (service *orderService) Transition(ctx context.Context, userID, id string, request *model.TransitionOrderRequest) (*model.Order, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	order, err := service.Find(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !model.CanTransition(order.Status, request.Status) {
+		return nil, model.ErrInvalidOrderTransition
+	}
+
+	from := order.Status
+	order.Status = request.Status
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.orders.Update(ctx, order)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "order.transition", "order", order.ID, map[string]string{"status": from}, map[string]string{"status": order.Status})
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.OrderStatusChanged{OrderID: order.ID, UserID: order.UserID, From: from, To: order.Status})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (service *orderService) Delete(ctx context.Context, userID, id string) error {
+	order, err := service.Find(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if order.Status != model.OrderPending {
+		return model.ErrOrderNotEditable
+	}
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.orders.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "order.delete", "order", id, order, nil)
+	})
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/payment"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/tenant"
+)
+
+// CheckoutProvider starts the payment of an order with a payment provider.
+type CheckoutProvider interface {
+	CreateCheckout(ctx context.Context, order *model.Order) (*model.Checkout, error)
+}
+
+// PaymentService lets the users pay their orders through Stripe Checkout and
+// records the outcome Stripe reports through its webhooks.
+type PaymentService interface {
+	// Checkout starts the payment of an unpaid pending order of the user.
+	// Other orders fail with model.ErrOrderNotPayable.
+	Checkout(ctx context.Context, userID string, request *model.CheckoutRequest) (*model.Checkout, error)
+	// HandleWebhook subscribes to event.WebhookReceived. A completed checkout
+	// marks its order paid, moving it from pending to paid; a failed or
+	// expired one marks it failed. Events about an order already paid are
+	// ignored, so Stripe may send them again. The order is looked up in the
+	// tenant recorded in the metadata of the checkout session.
+	HandleWebhook(ctx context.Context, received event.Event) error
+}
+
+type paymentService struct {
+	orders     repository.OrderRepository
+	provider   CheckoutProvider
+	transactor repository.Transactor
+	audit      AuditService
+	events     event.Publisher
+}
+
+func NewPaymentService(orders repository.OrderRepository, provider CheckoutProvider, transactor repository.Transactor, audit AuditService,
+	events event.Publisher) PaymentService {
+	return &paymentService{orders: orders, provider: provider, transactor: transactor, audit: audit, events: events}
+}
+
+func (service *paymentService) Checkout(ctx context.Context, userID string, request *model.CheckoutRequest) (*model.Checkout, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	order, err := service.orders.FindByID(ctx, request.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, model.ErrOrderNotFound
+	}
+	if order.Status != model.OrderPending || order.PaymentStatus == model.PaymentPaid {
+		return nil, model.ErrOrderNotPayable
+	}
+
+	checkout, err := service.provider.CreateCheckout(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	before := *order
+	order.PaymentStatus = model.PaymentPending
+	order.PaymentID = checkout.ID
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.orders.Update(ctx, order)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "order.checkout", "order", order.ID, &before, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkout, nil
+}
+
+func (service *paymentService) HandleWebhook(ctx context.Context, received event.Event) error {
+	webhook, ok := received.(event.WebhookReceived)
+	if !ok || webhook.Provider != "stripe" {
+		return nil
+	}
+	var paymentStatus string
+	switch webhook.Type {
+	case payment.CheckoutCompleted, payment.CheckoutAsyncPaymentSucceeded:
+		paymentStatus = model.PaymentPaid
+	case payment.CheckoutAsyncPaymentFailed, payment.CheckoutExpired:
+		paymentStatus = model.PaymentFailed
+	default:
+		return nil
+	}
+	session, err := payment.ParseCheckoutSession(webhook.Payload)
+	if err != nil {
+		return err
+	}
+	// A completed checkout of a delayed payment method is only paid once
+	// Stripe sends checkout.session.async_payment_succeeded.
+	if webhook.Type == payment.CheckoutCompleted && session.PaymentStatus != "paid" {
+		return nil
+	}
+
+	return service.transactor.Transaction(tenant.With(ctx, session.Metadata.TenantID), func(ctx context.Context) error {
+		order, err := service.orders.FindByID(ctx, session.ClientReferenceID)
+		if err != nil {
+			return err
+		}
+		if order.PaymentStatus == model.PaymentPaid || order.PaymentStatus == paymentStatus {
+			return nil
+		}
+		before := *order
+		order.PaymentStatus = paymentStatus
+		order.PaymentID = session.ID
+		moved := paymentStatus == model.PaymentPaid && model.CanTransition(order.Status, model.OrderPaid)
+		if moved {
+			order.Status = model.OrderPaid
+		}
+		err = service.orders.Update(ctx, order)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "order.payment", "order", order.ID, &before, order)
+		if err != nil || !moved {
+			return err
+		}
+		return service.events.Publish(ctx, event.OrderStatusChanged{OrderID: order.ID, UserID: order.UserID, From: before.Status, To: order.Status})
+	})
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"image"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ProductService manages the catalog. The images of a product are stored
+// under "products/<product ID>/<image ID>".
+type ProductService interface {
+	Create(ctx context.Context, request *model.SaveProductRequest) (*model.Product, error)
+	Get(ctx context.Context, id string) (*model.Product, error)
+	List(ctx context.Context, inStock bool, spec *model.ListSpec) ([]*model.Product, int, error)
+	Categories(ctx context.Context) ([]model.ProductCategory, error)
+	Update(ctx context.Context, id string, request *model.SaveProductRequest) (*model.Product, error)
+	// Delete deletes the product along with its images.
+	Delete(ctx context.Context, id string) error
+	// AddImage stores the image of content, whose size is given, as an image
+	// of the product. Images that are not GIF, JPEG or PNG, too large or
+	// beyond the images a product may have fail with model.ValidationErrors.
+	AddImage(ctx context.Context, id string, size int64, content io.Reader) (*model.ProductImage, error)
+	DeleteImage(ctx context.Context, id, imageID string) error
+	// OpenImage fails with model.ErrProductImageNotFound when the product
+	// has no such image.
+	OpenImage(ctx context.Context, id, imageID string) (io.ReadCloser, *model.ProductImage, error)
+}
+
+type productService struct {
+	config     config.ProductConfig
+	products   repository.ProductRepository
+	storage    storage.Storage
+	transactor repository.Transactor
+	audit      AuditService
+}
+
+func NewProductService(config config.ProductConfig, products repository.ProductRepository, storage storage.Storage, transactor repository.Transactor,
+	audit AuditService) ProductService {
+	return &productService{config: config, products: products, storage: storage, transactor: transactor, audit: audit}
+}
+
+func (service *productService) Create(ctx context.Context, request *model.SaveProductRequest) (*model.Product, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	product := &model.Product{Images: []model.ProductImage{}}
+	setProduct(product, request)
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.products.Create(ctx, product)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "product.create", "product", product.ID, nil, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (service *productService) Get(ctx context.Context, id string) (*model.Product, error) {
+	return service.products.FindByID(ctx, id)
+}
+
+func (service *productService) List(ctx context.Context, inStock bool, spec *model.ListSpec) ([]*model.Product, int, error) {
+	return service.products.List(ctx, inStock, spec)
+}
+
+func (service *productService) Categories(ctx context.Context) ([]model.ProductCategory, error) {
+	return service.products.Categories(ctx)
+}
+
+func (service *productService) Update(ctx context.Context, id string, request *model.SaveProductRequest) (*model.Product, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	product, err := service.products.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *product
+	setProduct(product, request)
+	err = service.save(ctx, "product.update", &before, product)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (service *productService) Delete(ctx context.Context, id string) error {
+	product, err := service.products.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.products.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, "product.delete", "product", id, product, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return service.storage.DeleteAll(ctx, "products/"+id+"/")
+}
+
+func (service *productService) AddImage(ctx context.Context, id string, size int64, content io.Reader) (*model.ProductImage, error) {
+	maxSize := int64(service.config.ImageMaxSize)
+	if size > maxSize {
+		return nil, model.ValidationErrors{{Field: "image", Message: "must be at most " + strconv.FormatInt(maxSize, 10) + " bytes"}}
+	}
+	product, err := service.products.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(product.Images) >= service.config.MaxImages {
+		return nil, model.ValidationErrors{{Field: "image", Message: "a product has at most " + strconv.Itoa(service.config.MaxImages) + " images"}}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, model.ValidationErrors{{Field: "image", Message: "must be at most " + strconv.FormatInt(maxSize, 10) + " bytes"}}
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, model.ValidationErrors{{Field: "image", Message: "must be a GIF, JPEG or PNG image"}}
+	}
+
+	productImage := model.ProductImage{ID: uuid.NewString(), ContentType: "image/" + format, Size: int64(len(data))}
+	key := productImageKey(id, productImage.ID)
+	err = service.storage.Put(ctx, key, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	before := *product
+	product.Images = append(slices.Clone(product.Images), productImage)
+	err = service.save(ctx, "product.add_image", &before, product)
+	if err != nil {
+		return nil, errors.Join(err, service.storage.Delete(ctx, key))
+	}
+	return &productImage, nil
+}
+
+func (service *productService) DeleteImage(ctx context.Context, id, imageID string) error {
+	product, err := service.products.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(product.Images, func(productImage model.ProductImage) bool {
+		return productImage.ID == imageID
+	})
+	if index < 0 {
+		return model.ErrProductImageNotFound
+	}
+	before := *product
+	product.Images = slices.Delete(slices.Clone(product.Images), index, index+1)
+	err = service.save(ctx, "product.delete_image", &before, product)
+	if err != nil {
+		return err
+	}
+	return service.storage.Delete(ctx, productImageKey(id, imageID))
+}
+
+func (service *productService) OpenImage(ctx context.Context, id, imageID string) (io.ReadCloser, *model.ProductImage, error) {
+	product, err := service.products.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	index := slices.IndexFunc(product.Images, func(productImage model.ProductImage) bool {
+		return productImage.ID == imageID
+	})
+	if index < 0 {
+		return nil, nil, model.ErrProductImageNotFound
+	}
+	content, err := service.storage.Open(ctx, productImageKey(id, imageID))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, model.ErrProductImageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, &product.Images[index], nil
+}
+
+func (service *productService) save(ctx context.Context, action string, before, product *model.Product) error {
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.products.Update(ctx, product)
+		if err != nil {
+			return err
+		}
+		return service.audit.Record(ctx, action, "product", product.ID, before, product)
+	})
+}
+
+func setProduct(product *model.Product, request *model.SaveProductRequest) {
+	product.Name = request.Name
+	product.Description = request.Description
+	product.Category = request.Category
+	product.Price = request.Price
+	product.Currency = strings.ToUpper(request.Currency)
+	product.Stock = request.Stock
+}
+
+func productImageKey(productID, imageID string) string {
+	return "products/" + productID + "/" + imageID
+}
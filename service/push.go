@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/push"
+	"golang-fiber-web/repository"
+)
+
+// PushService pushes the notifications of the users to their devices, as
+// their preferences allow. The devices of a user are only found by the same
+// user; the others fail with model.ErrDeviceNotFound.
+type PushService interface {
+	// RegisterDevice registers the device of the token for the user, taking
+	// it over from whoever registered it before.
+	RegisterDevice(ctx context.Context, userID string, request *model.RegisterDeviceRequest) (*model.Device, error)
+	Devices(ctx context.Context, userID string) ([]*model.Device, error)
+	UnregisterDevice(ctx context.Context, userID, id string) error
+	Preferences(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, userID string, request *model.UpdateNotificationPreferencesRequest) (*model.NotificationPreferences, error)
+	// Push subscribes to event.NotificationCreated. It sends the
+	// notification to every device of its user whose platform has a sender,
+	// forgetting the devices whose token is no longer registered.
+	Push(ctx context.Context, created event.Event) error
+}
+
+type pushService struct {
+	devices     repository.DeviceRepository
+	preferences repository.NotificationPreferencesRepository
+	senders     map[string]push.Sender
+}
+
+// NewPushService pushes to the devices of the platforms of senders, keyed by
+// model.PlatformFCM or model.PlatformAPNs.
+func NewPushService(devices repository.DeviceRepository, preferences repository.NotificationPreferencesRepository, senders map[string]push.Sender) PushService {
+	return &pushService{devices: devices, preferences: preferences, senders: senders}
+}
+
+func (service *pushService) RegisterDevice(ctx context.Context, userID string, request *model.RegisterDeviceRequest) (*model.Device, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	device := &model.Device{UserID: userID, Platform: request.Platform, Token: request.Token, Name: request.Name}
+	err = service.devices.Register(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (service *pushService) Devices(ctx context.Context, userID string) ([]*model.Device, error) {
+	return service.devices.FindByUser(ctx, userID)
+}
+
+func (service *pushService) UnregisterDevice(ctx context.Context, userID, id string) error {
+	device, err := service.devices.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if device.UserID != userID {
+		return model.ErrDeviceNotFound
+	}
+	return service.devices.Delete(ctx, id)
+}
+
+func (service *pushService) Preferences(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	return service.preferences.Find(ctx, userID)
+}
+
+func (service *pushService) UpdatePreferences(ctx context.Context, userID string, request *model.UpdateNotificationPreferencesRequest) (*model.NotificationPreferences, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	preferences := &model.NotificationPreferences{Push: *request.Push, SMS: request.SMS, Phone: request.Phone, Muted: append([]string{}, request.Muted...)}
+	err = service.preferences.Save(ctx, userID, preferences)
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (service *pushService) Push(ctx context.Context, created event.Event) error {
+	notification := created.(event.NotificationCreated).Notification
+	preferences, err := service.preferences.Find(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+	if !preferences.Pushes(notification.Kind) {
+		return nil
+	}
+	devices, err := service.devices.FindByUser(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	message := &push.Message{Title: notification.Title, Body: notification.Body, Link: notification.Link,
+		Data: map[string]string{"notification_id": notification.ID, "kind": notification.Kind}}
+	var failures []error
+	for _, device := range devices {
+		sender, ok := service.senders[device.Platform]
+		if !ok {
+			continue
+		}
+		err = sender.Send(ctx, device.Token, message)
+		if errors.Is(err, push.ErrUnregistered) {
+			logger.Info("forgetting unregistered device", "device", device.ID, "platform", device.Platform)
+			err = service.devices.DeleteByToken(ctx, device.Platform, device.Token)
+		}
+		if err != nil {
+			failures = append(failures, err)
+		}
+	}
+	return errors.Join(failures...)
+}
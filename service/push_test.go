@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/push"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+type fakeSender struct {
+	sent         []string
+	unregistered string
+}
+
+func (sender *fakeSender) Send(ctx context.Context, token string, message *push.Message) error {
+	if token == sender.unregistered {
+		return push.ErrUnregistered
+	}
+	sender.sent = append(sender.sent, token+": "+message.Title)
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	ctx := context.Background()
+	devices := repository.NewMemoryDeviceRepository()
+	fcm := &fakeSender{unregistered: "stale"}
+	pushes := NewPushService(devices, repository.NewMemoryNotificationPreferencesRepository(), map[string]push.Sender{model.PlatformFCM: fcm})
+	for _, request := range []*model.RegisterDeviceRequest{
+		{Platform: model.PlatformFCM, Token: "phone"},
+		{Platform: model.PlatformFCM, Token: "stale"},
+		{Platform: model.PlatformAPNs, Token: "tablet"},
+	} {
+		_, err := pushes.RegisterDevice(ctx, "1", request)
+		assert.Nil(t, err)
+	}
+
+	placed := event.NotificationCreated{Notification: &model.Notification{ID: "n1", UserID: "1", Kind: event.NameOrderPlaced, Title: "Order placed"}}
+	assert.Nil(t, pushes.Push(ctx, placed))
+	assert.Equal(t, []string{"phone: Order placed"}, fcm.sent)
+	registered, err := pushes.Devices(ctx, "1")
+	assert.Nil(t, err)
+	assert.Len(t, registered, 2)
+
+	enabled := true
+	_, err = pushes.UpdatePreferences(ctx, "1", &model.UpdateNotificationPreferencesRequest{Push: &enabled, Muted: []string{event.NameOrderPlaced}})
+	assert.Nil(t, err)
+	assert.Nil(t, pushes.Push(ctx, placed))
+	assert.Len(t, fcm.sent, 1)
+}
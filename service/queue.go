@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+)
+
+type QueueService interface {
+	Status(ctx context.Context) (*model.QueueStatus, error)
+}
+
+type queueService struct {
+	outbox     repository.OutboxRepository
+	deliveries repository.WebhookDeliveryRepository
+}
+
+func NewQueueService(outbox repository.OutboxRepository, deliveries repository.WebhookDeliveryRepository) QueueService {
+	return &queueService{outbox: outbox, deliveries: deliveries}
+}
+
+func (service *queueService) Status(ctx context.Context) (*model.QueueStatus, error) {
+	pending, err := service.outbox.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := service.deliveries.CountByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &model.QueueStatus{OutboxPending: pending, Deliveries: deliveries}, nil
+}
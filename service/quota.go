@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"time"
+)
+
+type QuotaService interface {
+	// Consume counts a request against every limit, unless one of them is
+	// used up, in which case it counts none and fails with
+	// model.ErrQuotaExceeded. The counters are returned either way.
+	Consume(ctx context.Context, limits []model.QuotaLimit) ([]*model.QuotaCounter, error)
+	// Usage returns the counters of limits in their current period.
+	Usage(ctx context.Context, limits []model.QuotaLimit) ([]*model.QuotaCounter, error)
+}
+
+type quotaService struct {
+	quotas repository.QuotaRepository
+	now    func() time.Time
+}
+
+func NewQuotaService(quotas repository.QuotaRepository) QuotaService {
+	return &quotaService{quotas: quotas, now: time.Now}
+}
+
+func (service *quotaService) Consume(ctx context.Context, limits []model.QuotaLimit) ([]*model.QuotaCounter, error) {
+	counters := service.counters(limits)
+	if len(counters) == 0 {
+		return counters, nil
+	}
+	ok, err := service.quotas.Consume(ctx, counters)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return counters, model.ErrQuotaExceeded
+	}
+	return counters, nil
+}
+
+func (service *quotaService) Usage(ctx context.Context, limits []model.QuotaLimit) ([]*model.QuotaCounter, error) {
+	counters := service.counters(limits)
+	err := service.quotas.Usage(ctx, counters)
+	if err != nil {
+		return nil, err
+	}
+	return counters, nil
+}
+
+// counters returns the counters of the limits above zero, in the periods
+// now falls in.
+func (service *quotaService) counters(limits []model.QuotaLimit) []*model.QuotaCounter {
+	now := service.now()
+	counters := []*model.QuotaCounter{}
+	for _, limit := range limits {
+		if limit.Limit <= 0 {
+			continue
+		}
+		start, reset := quotaPeriod(limit.Period, now)
+		counters = append(counters, &model.QuotaCounter{
+			Subject: limit.Subject,
+			Period:  limit.Period,
+			Start:   start,
+			ResetAt: reset,
+			Limit:   limit.Limit,
+		})
+	}
+	return counters
+}
+
+// quotaPeriod returns the start and the end of the daily or monthly period
+// of now, in UTC.
+func quotaPeriod(period string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if period == model.QuotaMonthly {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
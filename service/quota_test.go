@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+	"time"
+)
+
+func TestQuotaConsume(t *testing.T) {
+	now := time.Date(2024, time.January, 31, 23, 0, 0, 0, time.UTC)
+	service := &quotaService{quotas: repository.NewMemoryQuotaRepository(), now: func() time.Time { return now }}
+	limits := []model.QuotaLimit{
+		{Subject: "user:1", Period: model.QuotaDaily, Limit: 2},
+		{Subject: "user:1", Period: model.QuotaMonthly, Limit: 3},
+		{Subject: "api_key:1", Period: model.QuotaDaily},
+	}
+
+	for range 2 {
+		_, err := service.Consume(context.Background(), limits)
+		assert.Nil(t, err)
+	}
+	counters, err := service.Consume(context.Background(), limits)
+	assert.ErrorIs(t, err, model.ErrQuotaExceeded)
+	assert.Len(t, counters, 2)
+	assert.Equal(t, 0, counters[0].Remaining())
+	assert.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), counters[0].ResetAt)
+	assert.Equal(t, 1, counters[1].Remaining())
+
+	now = now.Add(time.Hour)
+	counters, err = service.Consume(context.Background(), limits)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, counters[0].Used)
+	assert.Equal(t, 1, counters[1].Used)
+	assert.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), counters[1].ResetAt)
+
+	counters, err = service.Usage(context.Background(), limits)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, counters[0].Used)
+}
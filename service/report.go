@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/pdf"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"io"
+	"time"
+)
+
+// reportPageSize is how many orders are read at a time to build a report.
+const reportPageSize = 100
+
+// ReportService renders the invoices and reports of the users as PDF. The
+// reports generated in the background are stored under
+// "reports/<report ID>.pdf".
+type ReportService interface {
+	// Invoice renders the invoice of an order of the user.
+	Invoice(ctx context.Context, userID, orderID string) (*pdf.Document, error)
+	// OrdersReport renders the report of the orders of the user placed
+	// within created. When there are more orders than config.ReportConfig
+	// allows to render right away, it records a pending report instead,
+	// generated in the background, and returns it with no document.
+	OrdersReport(ctx context.Context, userID string, created model.TimeRange) (*pdf.Document, *model.Report, error)
+	Find(ctx context.Context, userID, id string) (*model.Report, error)
+	// Open fails with model.ErrReportNotReady until the report is ready.
+	Open(ctx context.Context, userID, id string) (io.ReadCloser, *model.Report, error)
+	// Generate subscribes to event.ReportRequested. It generates the
+	// report, marking it ready or failed; reports that are no longer pending
+	// are left alone, so an event delivered twice does no harm.
+	Generate(ctx context.Context, requested event.Event) error
+}
+
+type reportService struct {
+	config     config.ReportConfig
+	users      repository.UserRepository
+	orders     repository.OrderRepository
+	reports    repository.ReportRepository
+	storage    storage.Storage
+	transactor repository.Transactor
+	events     event.Publisher
+	now        func() time.Time
+}
+
+func NewReportService(config config.ReportConfig, users repository.UserRepository, orders repository.OrderRepository, reports repository.ReportRepository,
+	storage storage.Storage, transactor repository.Transactor, events event.Publisher) ReportService {
+	return &reportService{config: config, users: users, orders: orders, reports: reports, storage: storage, transactor: transactor, events: events, now: time.Now}
+}
+
+func (service *reportService) Invoice(ctx context.Context, userID, orderID string) (*pdf.Document, error) {
+	order, err := service.orders.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, model.ErrOrderNotFound
+	}
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pdf.Render("invoice", pdf.Invoice{User: user, Order: order})
+}
+
+func (service *reportService) OrdersReport(ctx context.Context, userID string, created model.TimeRange) (*pdf.Document, *model.Report, error) {
+	_, total, err := service.orders.List(ctx, userID, created, &model.ListSpec{Page: 1, PerPage: 1})
+	if err != nil {
+		return nil, nil, err
+	}
+	if total <= service.config.InlineMaxOrders {
+		document, err := service.renderOrders(ctx, userID, created)
+		return document, nil, err
+	}
+
+	report := &model.Report{UserID: userID, Kind: model.ReportOrders, Status: model.ReportPending}
+	if !created.From.IsZero() {
+		report.From = &created.From
+	}
+	if !created.To.IsZero() {
+		report.To = &created.To
+	}
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.reports.Create(ctx, report)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.ReportRequested{ReportID: report.ID})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, report, nil
+}
+
+func (service *reportService) renderOrders(ctx context.Context, userID string, created model.TimeRange) (*pdf.Document, error) {
+	user, err := service.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	data := pdf.OrdersReport{User: user, From: created.From, To: created.To, GeneratedAt: service.now(), Totals: map[string]int64{}}
+	spec := &model.ListSpec{Page: 1, PerPage: reportPageSize, Sort: []model.SortField{{Field: "created_at"}}}
+	for {
+		orders, total, err := service.orders.List(ctx, userID, created, spec)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			data.Orders = append(data.Orders, order)
+			if order.Status != model.OrderCancelled {
+				data.Totals[order.Currency] += order.Total
+			}
+		}
+		if spec.Offset()+len(orders) >= total || len(orders) == 0 {
+			break
+		}
+		spec.Page++
+	}
+	return pdf.Render("orders_report", data)
+}
+
+func (service *reportService) Find(ctx context.Context, userID, id string) (*model.Report, error) {
+	report, err := service.reports.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report.UserID != userID {
+		return nil, model.ErrReportNotFound
+	}
+	return report, nil
+}
+
+func (service *reportService) Open(ctx context.Context, userID, id string) (io.ReadCloser, *model.Report, error) {
+	report, err := service.Find(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.Status != model.ReportReady {
+		return nil, nil, model.ErrReportNotReady
+	}
+	content, err := service.storage.Open(ctx, reportKey(report.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, report, nil
+}
+
+func (service *reportService) Generate(ctx context.Context, requested event.Event) error {
+	report, err := service.reports.FindByID(ctx, requested.(event.ReportRequested).ReportID)
+	if err != nil {
+		return err
+	}
+	if report.Status != model.ReportPending {
+		return nil
+	}
+
+	err = service.generate(ctx, report)
+	completedAt := service.now()
+	report.CompletedAt = &completedAt
+	report.Status = model.ReportReady
+	if err != nil {
+		report.Status = model.ReportFailed
+		report.Error = "the report could not be generated"
+		logger.Error("report failed", "report", report.ID, "error", err)
+	}
+	return service.reports.Update(ctx, report)
+}
+
+func (service *reportService) generate(ctx context.Context, report *model.Report) error {
+	document, err := service.renderOrders(ctx, report.UserID, report.Period())
+	if err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	_, err = document.WriteTo(&content)
+	if err != nil {
+		return err
+	}
+	report.Size = int64(content.Len())
+	return service.storage.Put(ctx, reportKey(report.ID), &content)
+}
+
+func reportKey(id string) string {
+	return "reports/" + id + ".pdf"
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"time"
+)
+
+type SessionService interface {
+	// Start creates a session of the user, lasting as long as the tokens
+	// issued for it.
+	Start(ctx context.Context, userID, userAgent, ipAddress string) (*model.Session, error)
+	// Active returns the session unless it expired or was revoked, in which
+	// case it fails with model.ErrSessionNotFound.
+	Active(ctx context.Context, id string) (*model.Session, error)
+	List(ctx context.Context, userID string) ([]*model.Session, error)
+	Revoke(ctx context.Context, userID, id string) error
+	// RevokeOthers revokes every session of the user but the one of keepID.
+	RevokeOthers(ctx context.Context, userID, keepID string) error
+}
+
+type sessionService struct {
+	sessions repository.SessionRepository
+	audit    AuditService
+	lifetime time.Duration
+	now      func() time.Time
+}
+
+func NewSessionService(sessions repository.SessionRepository, audit AuditService, lifetime time.Duration) SessionService {
+	return &sessionService{sessions: sessions, audit: audit, lifetime: lifetime, now: time.Now}
+}
+
+// Start also deletes the sessions that have expired, so they do not pile up.
+func (service *sessionService) Start(ctx context.Context, userID, userAgent, ipAddress string) (*model.Session, error) {
+	now := service.now()
+	_, err := service.sessions.DeleteExpired(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	session := &model.Session{UserID: userID, UserAgent: userAgent, IPAddress: ipAddress, CreatedAt: now, ExpiresAt: now.Add(service.lifetime)}
+	err = service.sessions.Create(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (service *sessionService) Active(ctx context.Context, id string) (*model.Session, error) {
+	return service.sessions.FindActive(ctx, id, service.now())
+}
+
+func (service *sessionService) List(ctx context.Context, userID string) ([]*model.Session, error) {
+	return service.sessions.List(ctx, userID, service.now())
+}
+
+func (service *sessionService) Revoke(ctx context.Context, userID, id string) error {
+	err := service.sessions.Delete(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return service.audit.Record(ctx, "session.revoke", "session", id, nil, nil)
+}
+
+func (service *sessionService) RevokeOthers(ctx context.Context, userID, keepID string) error {
+	return service.sessions.DeleteOthers(ctx, userID, keepID)
+}
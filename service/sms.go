@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/sms"
+	"strings"
+	"time"
+)
+
+// SMSService texts the users through the SMS provider of config.SMSConfig,
+// recording every text along with its delivery. A recipient gets at most
+// RecipientDailyLimit texts a day; the texts beyond are recorded as
+// model.SMSRateLimited and fail with model.ErrQuotaExceeded.
+type SMSService interface {
+	// SendCode texts the one-time code to phone, valid for valid. It is the
+	// fallback second factor, for the users who cannot use their first.
+	SendCode(ctx context.Context, phone, code string, valid time.Duration) error
+	// Notify subscribes to event.NotificationCreated. It texts the
+	// notification, rendered with the SMS template of its kind, to the users
+	// who turned texts on; kinds without a template are not texted.
+	Notify(ctx context.Context, created event.Event) error
+	// HandleWebhook subscribes to event.WebhookReceived, recording the
+	// delivery reports of Twilio and Vonage.
+	HandleWebhook(ctx context.Context, received event.Event) error
+}
+
+type smsService struct {
+	sender      sms.Sender
+	config      config.SMSConfig
+	baseURL     string
+	messages    repository.SMSRepository
+	preferences repository.NotificationPreferencesRepository
+	quotas      QuotaService
+}
+
+// NewSMSService texts through sender, which is nil when no provider is set
+// up; texting then fails with model.ErrSMSDisabled and notifications are not
+// texted. Links in the texts are made absolute with baseURL.
+func NewSMSService(sender sms.Sender, config config.SMSConfig, baseURL string, messages repository.SMSRepository,
+	preferences repository.NotificationPreferencesRepository, quotas QuotaService) SMSService {
+	return &smsService{sender: sender, config: config, baseURL: strings.TrimSuffix(baseURL, "/"), messages: messages, preferences: preferences, quotas: quotas}
+}
+
+func (service *smsService) SendCode(ctx context.Context, phone, code string, valid time.Duration) error {
+	body, err := sms.Render("verification_code", map[string]interface{}{"Code": code, "Minutes": int(valid.Minutes())})
+	if err != nil {
+		return err
+	}
+	return service.send(ctx, phone, "verification_code", body)
+}
+
+func (service *smsService) Notify(ctx context.Context, created event.Event) error {
+	if service.sender == nil {
+		return nil
+	}
+	notification := created.(event.NotificationCreated).Notification
+	preferences, err := service.preferences.Find(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+	if !preferences.Texts(notification.Kind) {
+		return nil
+	}
+
+	data := map[string]string{"Title": notification.Title, "Body": notification.Body}
+	if notification.Link != "" {
+		data["URL"] = service.baseURL + notification.Link
+	}
+	body, err := sms.Render(notification.Kind, data)
+	if errors.Is(err, sms.ErrNoTemplate) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return service.send(ctx, preferences.Phone, notification.Kind, body)
+}
+
+func (service *smsService) HandleWebhook(ctx context.Context, received event.Event) error {
+	webhook, ok := received.(event.WebhookReceived)
+	if !ok {
+		return nil
+	}
+	var status, failure string
+	switch webhook.Provider {
+	case "twilio":
+		report := struct {
+			ErrorCode    string `json:"ErrorCode"`
+			ErrorMessage string `json:"ErrorMessage"`
+		}{}
+		json.Unmarshal(webhook.Payload, &report)
+		switch webhook.Type {
+		case "delivered":
+			status = model.SMSDelivered
+		case "undelivered", "failed":
+			status, failure = model.SMSFailed, strings.TrimSpace(report.ErrorCode+" "+report.ErrorMessage)
+		}
+	case "vonage":
+		report := struct {
+			Error struct {
+				Title  string `json:"title"`
+				Detail string `json:"detail"`
+			} `json:"error"`
+		}{}
+		json.Unmarshal(webhook.Payload, &report)
+		switch webhook.Type {
+		case "delivered":
+			status = model.SMSDelivered
+		case "rejected", "undeliverable":
+			status, failure = model.SMSFailed, strings.TrimSpace(report.Error.Title+" "+report.Error.Detail)
+		}
+	}
+	if status == "" {
+		return nil
+	}
+	providerID, _, _ := strings.Cut(webhook.EventID, ":")
+	return service.messages.UpdateStatus(ctx, webhook.Provider, providerID, status, failure)
+}
+
+// send texts body to the recipient, unless they are over their daily
+// limit, and records the text.
+func (service *smsService) send(ctx context.Context, to, kind, body string) error {
+	if service.sender == nil {
+		return model.ErrSMSDisabled
+	}
+	message := &model.SMSMessage{To: to, Kind: kind, Body: body, Provider: service.config.Provider, Status: model.SMSSent}
+	_, err := service.quotas.Consume(ctx, []model.QuotaLimit{{Subject: "sms:" + to, Period: model.QuotaDaily, Limit: service.config.RecipientDailyLimit}})
+	if errors.Is(err, model.ErrQuotaExceeded) {
+		message.Status = model.SMSRateLimited
+		return errors.Join(err, service.messages.Create(ctx, message))
+	}
+	if err != nil {
+		return err
+	}
+
+	message.ProviderID, err = service.sender.Send(ctx, to, body)
+	if err != nil {
+		message.Status, message.Error = model.SMSFailed, err.Error()
+		return errors.Join(err, service.messages.Create(ctx, message))
+	}
+	return service.messages.Create(ctx, message)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeSMSSender struct {
+	sent []string
+}
+
+func (sender *fakeSMSSender) Send(ctx context.Context, to, body string) (string, error) {
+	sender.sent = append(sender.sent, to+": "+body)
+	return "SM" + strconv.Itoa(len(sender.sent)), nil
+}
+
+func TestSMS(t *testing.T) {
+	ctx := context.Background()
+	sender := &fakeSMSSender{}
+	messages := repository.NewMemorySMSRepository()
+	preferences := repository.NewMemoryNotificationPreferencesRepository()
+	texts := NewSMSService(sender, config.SMSConfig{Provider: "twilio", RecipientDailyLimit: 2}, "https://shop.example.com/", messages, preferences,
+		NewQuotaService(repository.NewMemoryQuotaRepository()))
+
+	placed := event.NotificationCreated{Notification: &model.Notification{UserID: "1", Kind: event.NameOrderPlaced, Title: "Order placed",
+		Body: "Your order 1 has been placed.", Link: "/users/1/orders/1"}}
+	assert.Nil(t, texts.Notify(ctx, placed))
+	assert.Empty(t, sender.sent)
+
+	assert.Nil(t, preferences.Save(ctx, "1", &model.NotificationPreferences{Push: true, SMS: true, Phone: "+6281234567890"}))
+	assert.Nil(t, texts.Notify(ctx, placed))
+	assert.Equal(t, []string{"+6281234567890: Order placed: Your order 1 has been placed. Details: https://shop.example.com/users/1/orders/1"}, sender.sent)
+	assert.Nil(t, texts.Notify(ctx, event.NotificationCreated{Notification: &model.Notification{UserID: "1", Kind: "report.ready", Title: "Report ready"}}))
+	assert.Len(t, sender.sent, 1)
+
+	assert.Nil(t, texts.SendCode(ctx, "+6281234567890", "123456", time.Minute*10))
+	assert.Equal(t, "+6281234567890: 123456 is your verification code. It expires in 10 minutes. Do not share it with anyone.", sender.sent[1])
+	assert.ErrorIs(t, texts.SendCode(ctx, "+6281234567890", "654321", time.Minute*10), model.ErrQuotaExceeded)
+	assert.Len(t, sender.sent, 2)
+
+	assert.Nil(t, texts.HandleWebhook(ctx, event.WebhookReceived{Provider: "twilio", EventID: "SM1:undelivered", Type: "undelivered",
+		Payload: []byte(`{"MessageSid":"SM1","MessageStatus":"undelivered","ErrorCode":"30003"}`)}))
+	assert.Nil(t, texts.HandleWebhook(ctx, event.WebhookReceived{Provider: "twilio", EventID: "SM2:delivered", Type: "delivered", Payload: []byte(`{}`)}))
+	assert.Nil(t, texts.HandleWebhook(ctx, event.WebhookReceived{Provider: "twilio", EventID: "SM2:sent", Type: "sent", Payload: []byte(`{}`)}))
+
+	message, err := messages.FindByProviderID(ctx, "twilio", "SM1")
+	assert.Nil(t, err)
+	assert.Equal(t, model.SMSFailed, message.Status)
+	assert.Equal(t, "30003", message.Error)
+	message, err = messages.FindByProviderID(ctx, "twilio", "SM2")
+	assert.Nil(t, err)
+	assert.Equal(t, model.SMSDelivered, message.Status)
+	assert.Equal(t, "verification_code", message.Kind)
+}
+
+func TestSMSDisabled(t *testing.T) {
+	texts := NewSMSService(nil, config.SMSConfig{}, "", repository.NewMemorySMSRepository(), repository.NewMemoryNotificationPreferencesRepository(),
+		NewQuotaService(repository.NewMemoryQuotaRepository()))
+	assert.ErrorIs(t, texts.SendCode(context.Background(), "+6281234567890", "123456", time.Minute), model.ErrSMSDisabled)
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"strings"
+	"time"
+)
+
+type UserService interface {
+	List(ctx context.Context, spec *model.ListSpec) ([]*model.User, int, error)
+	Each(ctx context.Context, spec *model.ListSpec, fn func(user *model.User) error) error
+	Get(ctx context.Context, id string) (*model.User, error)
+	Update(ctx context.Context, id string, request *model.UpdateUserRequest) (*model.User, error)
+	Import(ctx context.Context, requests []*model.CreateUserRequest) (*model.ImportResult, error)
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) (*model.User, error)
+}
+
+// userService saves a change, its audit event and its domain event in one
+// transaction, so events are published for exactly the changes made.
+type userService struct {
+	users      repository.UserRepository
+	transactor repository.Transactor
+	audit      AuditService
+	events     event.Publisher
+}
+
+func NewUserService(users repository.UserRepository, transactor repository.Transactor, audit AuditService, events event.Publisher) UserService {
+	return &userService{users: users, transactor: transactor, audit: audit, events: events}
+}
+
+func (service *userService) List(ctx context.Context, spec *model.ListSpec) ([]*model.User, int, error) {
+	return service.users.List(ctx, spec)
+}
+
+func (service *userService) Each(ctx context.Context, spec *model.ListSpec, fn func(user *model.User) error) error {
+	return service.users.Each(ctx, spec, fn)
+}
+
+func (service *userService) Get(ctx context.Context, id string) (*model.User, error) {
+	return service.users.FindByID(ctx, id)
+}
+
+// Update validates request and applies it to the user. Invalid requests fail
+// with model.ValidationErrors before the user is looked up. A request.Version
+// other than the user's fails with model.ErrVersionConflict, as does a change
+// made by someone else between reading and saving the user.
+func (service *userService) Update(ctx context.Context, id string, request *model.UpdateUserRequest) (*model.User, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := service.users.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Version != 0 && request.Version != before.Version {
+		return nil, model.ErrVersionConflict
+	}
+	user := *before
+	user.Username = request.Username
+	user.Email = request.Email
+	user.Name = request.Name
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.Update(ctx, &user)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "user.update", "user", user.ID, before, &user)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.UserUpdated{User: &user})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Import creates a user for every valid request in one go, and reports the
+// requests that fail validation or use an email that is already taken.
+func (service *userService) Import(ctx context.Context, requests []*model.CreateUserRequest) (*model.ImportResult, error) {
+	result := &model.ImportResult{Rejected: []model.ImportRejection{}}
+	var users []*model.User
+	emails := map[string]bool{}
+	for i, request := range requests {
+		err := model.ValidateStruct(request)
+		var validationErrors model.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			result.Rejected = append(result.Rejected, model.ImportRejection{Row: i + 1, Errors: validationErrors})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if request.Email != "" {
+			email := strings.ToLower(request.Email)
+			_, err = service.users.FindByEmail(ctx, email)
+			if err == nil || emails[email] {
+				result.Rejected = append(result.Rejected, model.ImportRejection{Row: i + 1, Errors: model.ValidationErrors{{Field: "email", Message: "failed unique"}}})
+				continue
+			}
+			if !errors.Is(err, model.ErrUserNotFound) {
+				return nil, err
+			}
+			emails[email] = true
+		}
+		users = append(users, &model.User{Username: request.Username, Email: request.Email, Name: request.Name})
+	}
+
+	err := service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.CreateMany(ctx, users)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			err = service.audit.Record(ctx, "user.create", "user", user.ID, nil, user)
+			if err != nil {
+				return err
+			}
+			err = service.events.Publish(ctx, event.UserRegistered{User: user})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = len(users)
+	return result, nil
+}
+
+// Delete soft-deletes the user, which can be brought back with Restore.
+func (service *userService) Delete(ctx context.Context, id string) error {
+	before, err := service.users.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	user := *before
+	deletedAt := time.Now()
+	user.DeletedAt = &deletedAt
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.SetDeleted(ctx, id, user.DeletedAt)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "user.delete", "user", id, before, &user)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.UserDeleted{UserID: id})
+	})
+}
+
+// Restore undoes Delete. Restoring a user that is not deleted changes nothing.
+func (service *userService) Restore(ctx context.Context, id string) (*model.User, error) {
+	before, err := service.users.FindByIDWithDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.DeletedAt == nil {
+		return before, nil
+	}
+	user := *before
+	user.DeletedAt = nil
+	err = service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.users.SetDeleted(ctx, id, nil)
+		if err != nil {
+			return err
+		}
+		err = service.audit.Record(ctx, "user.restore", "user", id, before, &user)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.UserRestored{User: &user})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
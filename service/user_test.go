@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"testing"
+)
+
+func TestUserServiceUpdate(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian", Email: "brian@example.com"}
+	assert.Nil(t, users.Create(context.Background(), user))
+
+	updated, err := NewUserService(users, repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()), event.Discard).Update(context.Background(), user.ID, &model.UpdateUserRequest{Username: "brian", Name: "Brian Anashari"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Brian Anashari", updated.Name)
+	assert.Empty(t, updated.Email)
+
+	saved, err := users.FindByID(context.Background(), user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Brian Anashari", saved.Name)
+}
+
+func TestUserServiceUpdateInvalid(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian"}
+	assert.Nil(t, users.Create(context.Background(), user))
+
+	_, err := NewUserService(users, repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()), event.Discard).Update(context.Background(), user.ID, &model.UpdateUserRequest{Username: "br", Email: "brian"})
+	var validationErrors model.ValidationErrors
+	assert.ErrorAs(t, err, &validationErrors)
+	assert.Len(t, validationErrors, 2)
+
+	_, err = NewUserService(users, repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()), event.Discard).Update(context.Background(), "missing", &model.UpdateUserRequest{Username: "brian"})
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
+
+func TestUserServiceUpdateVersionConflict(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user := &model.User{Username: "brian"}
+	assert.Nil(t, users.Create(context.Background(), user))
+	userService := NewUserService(users, repository.NewMemoryTransactor(), NewAuditService(repository.NewMemoryAuditRepository()), event.Discard)
+
+	updated, err := userService.Update(context.Background(), user.ID, &model.UpdateUserRequest{Username: "brian", Name: "Brian", Version: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = userService.Update(context.Background(), user.ID, &model.UpdateUserRequest{Username: "brian", Name: "Stale", Version: 1})
+	assert.ErrorIs(t, err, model.ErrVersionConflict)
+
+	stale, err := users.FindByID(context.Background(), user.ID)
+	assert.Nil(t, err)
+	stale.Version = 1
+	assert.ErrorIs(t, users.Update(context.Background(), stale), model.ErrVersionConflict)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"slices"
+	"time"
+)
+
+type WebhookService interface {
+	Create(ctx context.Context, request *model.CreateWebhookRequest) (*model.Webhook, error)
+	List(ctx context.Context) ([]*model.Webhook, error)
+	Get(ctx context.Context, id string) (*model.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	Deliveries(ctx context.Context, webhookID string, spec *model.ListSpec) ([]*model.WebhookDelivery, int, error)
+	Redeliver(ctx context.Context, webhookID, id string) (*model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+	audit      AuditService
+}
+
+func NewWebhookService(webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository, audit AuditService) WebhookService {
+	return &webhookService{webhooks: webhooks, deliveries: deliveries, audit: audit}
+}
+
+// Create validates request, including that it names known events, and
+// registers the webhook with a random secret unless request has one.
+func (service *webhookService) Create(ctx context.Context, request *model.CreateWebhookRequest) (*model.Webhook, error) {
+	err := model.ValidateStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range request.Events {
+		if !slices.Contains(event.Names(), name) {
+			return nil, model.ValidationErrors{{Field: "events", Message: "unknown event " + name}}
+		}
+	}
+
+	webhook := &model.Webhook{URL: request.URL, Secret: request.Secret, Events: slices.Compact(slices.Sorted(slices.Values(request.Events)))}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	err = service.webhooks.Create(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	err = service.audit.Record(ctx, "webhook.create", "webhook", webhook.ID, nil, webhook)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (service *webhookService) List(ctx context.Context) ([]*model.Webhook, error) {
+	return service.webhooks.List(ctx)
+}
+
+func (service *webhookService) Get(ctx context.Context, id string) (*model.Webhook, error) {
+	return service.webhooks.FindByID(ctx, id)
+}
+
+func (service *webhookService) Delete(ctx context.Context, id string) error {
+	webhook, err := service.webhooks.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = service.webhooks.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	return service.audit.Record(ctx, "webhook.delete", "webhook", id, webhook, nil)
+}
+
+func (service *webhookService) Deliveries(ctx context.Context, webhookID string, spec *model.ListSpec) ([]*model.WebhookDelivery, int, error) {
+	_, err := service.webhooks.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return service.deliveries.List(ctx, webhookID, spec)
+}
+
+// Redeliver queues the delivery to be sent again right away, whatever its
+// status. A failed delivery gets one more attempt.
+func (service *webhookService) Redeliver(ctx context.Context, webhookID, id string) (*model.WebhookDelivery, error) {
+	delivery, err := service.deliveries.FindByID(ctx, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = model.DeliveryPending
+	delivery.NextAttemptAt = time.Now()
+	err = service.deliveries.Update(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+)
+
+type WebhookReceiverService interface {
+	// Receive records an event received from a provider and publishes it as
+	// event.WebhookReceived, with payload, for the subscribers to process in
+	// the background. It fails with model.ErrDuplicateWebhook for an event
+	// that was received before.
+	Receive(ctx context.Context, received *model.ReceivedWebhook, payload []byte) error
+}
+
+type webhookReceiverService struct {
+	received   repository.ReceivedWebhookRepository
+	transactor repository.Transactor
+	events     event.Publisher
+}
+
+func NewWebhookReceiverService(received repository.ReceivedWebhookRepository, transactor repository.Transactor, events event.Publisher) WebhookReceiverService {
+	return &webhookReceiverService{received: received, transactor: transactor, events: events}
+}
+
+func (service *webhookReceiverService) Receive(ctx context.Context, received *model.ReceivedWebhook, payload []byte) error {
+	return service.transactor.Transaction(ctx, func(ctx context.Context) error {
+		err := service.received.Create(ctx, received)
+		if err != nil {
+			return err
+		}
+		return service.events.Publish(ctx, event.WebhookReceived{
+			Provider: received.Provider,
+			EventID:  received.EventID,
+			Type:     received.Type,
+			Payload:  payload,
+		})
+	})
+}
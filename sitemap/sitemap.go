@@ -0,0 +1,278 @@
+// Package sitemap serves /sitemap.xml and /robots.txt, generated in the
+// background so crawlers never wait on the database.
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/telemetry"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = telemetry.Logger("sitemap")
+
+// Source lists pages of the site, calling add with the path and, if known,
+// the last modification time of each.
+type Source func(ctx context.Context, add func(path string, modified time.Time)) error
+
+// Routes lists the paths of the routes of app named names, which must exist
+// and take no parameters.
+func Routes(app *fiber.App, names []string) Source {
+	return func(ctx context.Context, add func(path string, modified time.Time)) error {
+		for _, name := range names {
+			route := app.GetRoute(name)
+			if route.Name != name {
+				return errors.New("unknown route " + name)
+			}
+			if len(route.Params) > 0 {
+				return errors.New("route " + name + " takes parameters")
+			}
+			add(route.Path, time.Time{})
+		}
+		return nil
+	}
+}
+
+// Sitemap keeps the sitemap last generated from its sources, as configured
+// by config.SitemapConfig, and the robots.txt of config.RobotsConfig.
+type Sitemap struct {
+	config  config.SitemapConfig
+	robots  []byte
+	baseURL string
+	sources []Source
+	now     func() time.Time
+
+	// generate makes concurrent first requests wait on the same generation.
+	generate sync.Mutex
+	mutex    sync.RWMutex
+	current  *generated
+}
+
+type generated struct {
+	at    time.Time
+	index []byte
+	pages [][]byte
+}
+
+func New(sitemapConfig config.SitemapConfig, robots config.RobotsConfig, baseURL string, sources ...Source) *Sitemap {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &Sitemap{config: sitemapConfig, robots: robotsTXT(robots, baseURL), baseURL: baseURL, sources: sources, now: time.Now}
+}
+
+// AddSource adds source to the next generations. It is meant to be called
+// while the app is being built, before Run.
+func (sitemap *Sitemap) AddSource(source Source) {
+	sitemap.sources = append(sitemap.sources, source)
+}
+
+// Run generates the sitemap right away, then every interval until ctx is
+// done.
+func (sitemap *Sitemap) Run(ctx context.Context) {
+	ticker := time.NewTicker(sitemap.config.Interval)
+	defer ticker.Stop()
+	for {
+		err := sitemap.Generate(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("generating the sitemap", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Generate lists the pages of every source and replaces the sitemap. On
+// error the previous sitemap is kept.
+func (sitemap *Sitemap) Generate(ctx context.Context) error {
+	sitemap.generate.Lock()
+	defer sitemap.generate.Unlock()
+	return sitemap.generateLocked(ctx)
+}
+
+// latest returns the current generation, generating the first one if there
+// is none yet.
+func (sitemap *Sitemap) latest(ctx context.Context) (*generated, error) {
+	sitemap.mutex.RLock()
+	current := sitemap.current
+	sitemap.mutex.RUnlock()
+	if current != nil {
+		return current, nil
+	}
+
+	sitemap.generate.Lock()
+	defer sitemap.generate.Unlock()
+	if sitemap.current == nil {
+		err := sitemap.generateLocked(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sitemap.mutex.RLock()
+	defer sitemap.mutex.RUnlock()
+	return sitemap.current, nil
+}
+
+// generateLocked is Generate for a caller holding the generate mutex.
+func (sitemap *Sitemap) generateLocked(ctx context.Context) error {
+	var urls []url
+	for _, source := range sitemap.sources {
+		err := source(ctx, func(path string, modified time.Time) {
+			page := url{Loc: sitemap.baseURL + path}
+			if !modified.IsZero() {
+				page.LastMod = modified.UTC().Format(time.RFC3339)
+			}
+			urls = append(urls, page)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	next := &generated{at: sitemap.now().UTC().Truncate(time.Second)}
+	index := sitemapIndex{XMLNS: namespace}
+	for start := 0; start == 0 || start < len(urls); start += sitemap.config.PageSize {
+		var page bytes.Buffer
+		compressor := gzip.NewWriter(&page)
+		err := writeXML(compressor, urlSet{XMLNS: namespace, URLs: urls[start:min(start+sitemap.config.PageSize, len(urls))]})
+		if err != nil {
+			return err
+		}
+		err = compressor.Close()
+		if err != nil {
+			return err
+		}
+		next.pages = append(next.pages, page.Bytes())
+		index.Sitemaps = append(index.Sitemaps, sitemapEntry{
+			Loc:     fmt.Sprintf("%s/sitemaps/%d.xml.gz", sitemap.baseURL, len(next.pages)),
+			LastMod: next.at.Format(time.RFC3339),
+		})
+	}
+	var content bytes.Buffer
+	err := writeXML(&content, index)
+	if err != nil {
+		return err
+	}
+	next.index = content.Bytes()
+
+	sitemap.mutex.Lock()
+	sitemap.current = next
+	sitemap.mutex.Unlock()
+	logger.InfoContext(ctx, "generated the sitemap", "urls", len(urls), "pages", len(next.pages))
+	return nil
+}
+
+func robotsTXT(robotsConfig config.RobotsConfig, baseURL string) []byte {
+	var robots bytes.Buffer
+	robots.WriteString("User-agent: *\n")
+	for _, path := range robotsConfig.Allow {
+		robots.WriteString("Allow: " + path + "\n")
+	}
+	for _, path := range robotsConfig.Disallow {
+		robots.WriteString("Disallow: " + path + "\n")
+	}
+	if len(robotsConfig.Disallow) == 0 {
+		robots.WriteString("Disallow:\n")
+	}
+	robots.WriteString("\nSitemap: " + baseURL + "/sitemap.xml\n")
+	return robots.Bytes()
+}
+
+// Register adds /sitemap.xml, the index of the pages of the sitemap, its
+// pages and /robots.txt, so it is meant to be mounted at the root.
+func (sitemap *Sitemap) Register(router fiber.Router) {
+	router.Get("/sitemap.xml", sitemap.Index).Name("sitemap.index")
+	router.Get("/sitemaps/:page.xml.gz", sitemap.Page).Name("sitemap.page")
+	router.Get("/robots.txt", sitemap.Robots).Name("sitemap.robots")
+}
+
+func (sitemap *Sitemap) Index(ctx *fiber.Ctx) error {
+	return sitemap.send(ctx, fiber.MIMEApplicationXMLCharsetUTF8, func(current *generated) []byte {
+		return current.index
+	})
+}
+
+func (sitemap *Sitemap) Page(ctx *fiber.Ctx) error {
+	page, err := strconv.Atoi(ctx.Params("page"))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+	return sitemap.send(ctx, "application/gzip", func(current *generated) []byte {
+		if page < 1 || page > len(current.pages) {
+			return nil
+		}
+		return current.pages[page-1]
+	})
+}
+
+// Robots answers robots.txt, which only changes with the configuration.
+func (sitemap *Sitemap) Robots(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return ctx.Send(sitemap.robots)
+}
+
+// send answers the content pick chooses from the latest generation. The content is cached by clients
+// until the next generation, and answered 304 Not Modified when they have
+// it already.
+func (sitemap *Sitemap) send(ctx *fiber.Ctx, contentType string, pick func(current *generated) []byte) error {
+	current, err := sitemap.latest(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	content := pick(current)
+	if content == nil {
+		return fiber.ErrNotFound
+	}
+	ctx.Set(fiber.HeaderLastModified, current.at.Format(http.TimeFormat))
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(sitemap.config.Interval.Seconds())))
+	if ctx.Fresh() {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.Send(content)
+}
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	URLs    []url    `xml:"url"`
+}
+
+type url struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	XMLNS    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+func writeXML(writer io.Writer, document interface{}) error {
+	_, err := io.WriteString(writer, xml.Header)
+	if err != nil {
+		return err
+	}
+	return xml.NewEncoder(writer).Encode(document)
+}
@@ -0,0 +1,101 @@
+package sitemap
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sitemapApp(t *testing.T) (*fiber.App, *Sitemap) {
+	app := fiber.New()
+	app.Get("/", func(ctx *fiber.Ctx) error { return nil }).Name("home")
+	app.Get("/products", func(ctx *fiber.Ctx) error { return nil }).Name("products.list")
+	app.Get("/products/:id", func(ctx *fiber.Ctx) error { return nil }).Name("products.show")
+
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	products := func(ctx context.Context, add func(path string, modified time.Time)) error {
+		for _, id := range []string{"1", "2", "3"} {
+			add("/products/"+id, modified)
+		}
+		return nil
+	}
+	sitemap := New(config.SitemapConfig{Interval: time.Hour, PageSize: 2}, config.RobotsConfig{Disallow: []string{"/admin"}, Allow: []string{"/admin/public"}},
+		"https://shop.example.com/", Routes(app, []string{"home", "products.list"}), products)
+	sitemap.now = func() time.Time { return modified.Add(time.Hour) }
+	sitemap.Register(app)
+	return app, sitemap
+}
+
+func get(t *testing.T, app *fiber.App, target string, headers ...string) (*http.Response, string) {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return response, string(body)
+}
+
+func TestSitemap(t *testing.T) {
+	app, _ := sitemapApp(t)
+
+	response, index := get(t, app, "/sitemap.xml")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/xml; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Equal(t, "public, max-age=3600", response.Header.Get("Cache-Control"))
+	assert.Equal(t, "Wed, 01 May 2024 11:00:00 GMT", response.Header.Get("Last-Modified"))
+	assert.Contains(t, index, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, index, "<loc>https://shop.example.com/sitemaps/3.xml.gz</loc><lastmod>2024-05-01T11:00:00Z</lastmod>")
+	assert.NotContains(t, index, "sitemaps/4.xml.gz")
+
+	response, page := get(t, app, "/sitemaps/1.xml.gz")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "application/gzip", response.Header.Get("Content-Type"))
+	reader, err := gzip.NewReader(strings.NewReader(page))
+	assert.Nil(t, err)
+	urls, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Contains(t, string(urls), "<url><loc>https://shop.example.com/</loc></url><url><loc>https://shop.example.com/products</loc></url></urlset>")
+
+	response, page = get(t, app, "/sitemaps/3.xml.gz")
+	assert.Equal(t, 200, response.StatusCode)
+	reader, err = gzip.NewReader(strings.NewReader(page))
+	assert.Nil(t, err)
+	urls, err = io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Contains(t, string(urls), "<url><loc>https://shop.example.com/products/3</loc><lastmod>2024-05-01T10:00:00Z</lastmod></url>")
+
+	response, _ = get(t, app, "/sitemaps/4.xml.gz")
+	assert.Equal(t, 404, response.StatusCode)
+	response, _ = get(t, app, "/sitemap.xml", "If-Modified-Since", "Wed, 01 May 2024 11:00:00 GMT")
+	assert.Equal(t, 304, response.StatusCode)
+}
+
+func TestRobots(t *testing.T) {
+	app, _ := sitemapApp(t)
+
+	response, robots := get(t, app, "/robots.txt")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Equal(t, "User-agent: *\nAllow: /admin/public\nDisallow: /admin\n\nSitemap: https://shop.example.com/sitemap.xml\n", robots)
+}
+
+func TestGenerateKeepsPreviousOnError(t *testing.T) {
+	app, sitemap := sitemapApp(t)
+	assert.Nil(t, sitemap.Generate(context.Background()))
+
+	sitemap.AddSource(Routes(app, []string{"products.show"}))
+	assert.EqualError(t, sitemap.Generate(context.Background()), "route products.show takes parameters")
+	response, _ := get(t, app, "/sitemap.xml")
+	assert.Equal(t, 200, response.StatusCode)
+}
@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+// ErrNoTemplate is returned by Render for a template that does not exist.
+var ErrNoTemplate = errors.New("no such sms template")
+
+// Sender texts through one SMS provider. Send returns the ID the provider
+// gave the message, which its delivery reports refer to.
+type Sender interface {
+	Send(ctx context.Context, to, body string) (string, error)
+}
+
+// New returns the sender of the provider of config, "twilio" or "vonage".
+func New(client *httpclient.Client, config config.SMSConfig) (Sender, error) {
+	switch config.Provider {
+	case "twilio":
+		return NewTwilio(client, config), nil
+	case "vonage":
+		return NewVonage(client, config), nil
+	}
+	return nil, errors.New("unknown sms provider " + config.Provider)
+}
+
+// Render executes the template name, from templates/, with data. Texts are
+// kept on one line each, without the spacing of the templates.
+func Render(name string, data interface{}) (string, error) {
+	if templates.Lookup(name+".tmpl") == nil {
+		return "", ErrNoTemplate
+	}
+	var output bytes.Buffer
+	err := templates.ExecuteTemplate(&output, name+".tmpl", data)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(output.String()), " "), nil
+}
@@ -0,0 +1,77 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	text, err := Render("verification_code", map[string]interface{}{"Code": "123456", "Minutes": 10})
+	assert.Nil(t, err)
+	assert.Equal(t, "123456 is your verification code. It expires in 10 minutes. Do not share it with anyone.", text)
+
+	text, err = Render("order.placed", map[string]string{"Title": "Order placed", "Body": "Your order 1 has been placed.", "URL": "https://shop.example.com/orders/1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Order placed: Your order 1 has been placed. Details: https://shop.example.com/orders/1", text)
+
+	_, err = Render("user.deleted", nil)
+	assert.ErrorIs(t, err, ErrNoTemplate)
+}
+
+func TestTwilio(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", request.URL.Path)
+		user, password, ok := request.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "auth-token", password)
+		assert.Nil(t, request.ParseForm())
+		if request.PostForm.Get("To") == "+1" {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(`{"code":21211,"message":"The 'To' number +1 is not a valid phone number."}`))
+			return
+		}
+		assert.Equal(t, "+6281234567890", request.PostForm.Get("To"))
+		assert.Equal(t, "+15005550006", request.PostForm.Get("From"))
+		assert.Equal(t, "Hello", request.PostForm.Get("Body"))
+		assert.Equal(t, "https://shop.example.com/webhooks/twilio", request.PostForm.Get("StatusCallback"))
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio(httpclient.New(config.Default().HTTPClient), config.SMSConfig{From: "+15005550006", TwilioAccountSID: "AC123", TwilioAuthToken: "auth-token",
+		TwilioAPIURL: server.URL, StatusCallbackURL: "https://shop.example.com/webhooks/twilio"})
+	id, err := twilio.Send(context.Background(), "+6281234567890", "Hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "SM123", id)
+	_, err = twilio.Send(context.Background(), "+1", "Hello")
+	assert.ErrorContains(t, err, "not a valid phone number")
+}
+
+func TestVonage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/v1/messages", request.URL.Path)
+		user, password, ok := request.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "key", user)
+		assert.Equal(t, "secret", password)
+		message := map[string]string{}
+		assert.Nil(t, json.NewDecoder(request.Body).Decode(&message))
+		assert.Equal(t, map[string]string{"message_type": "text", "channel": "sms", "to": "6281234567890", "from": "Shop", "text": "Hello"}, message)
+		writer.WriteHeader(http.StatusAccepted)
+		writer.Write([]byte(`{"message_uuid":"aaaaaaaa-bbbb"}`))
+	}))
+	defer server.Close()
+
+	vonage := NewVonage(httpclient.New(config.Default().HTTPClient), config.SMSConfig{From: "Shop", VonageAPIKey: "key", VonageAPISecret: "secret", VonageAPIURL: server.URL})
+	id, err := vonage.Send(context.Background(), "+6281234567890", "Hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "aaaaaaaa-bbbb", id)
+}
@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"strings"
+)
+
+// Twilio texts through the Messages resource of the Twilio REST API.
+type Twilio struct {
+	client *httpclient.Client
+	config config.SMSConfig
+}
+
+func NewTwilio(client *httpclient.Client, config config.SMSConfig) *Twilio {
+	return &Twilio{client: client, config: config}
+}
+
+func (twilio *Twilio) Send(ctx context.Context, to, body string) (string, error) {
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+	args.Set("To", to)
+	args.Set("From", twilio.config.From)
+	args.Set("Body", body)
+	if twilio.config.StatusCallbackURL != "" {
+		args.Set("StatusCallback", twilio.config.StatusCallbackURL)
+	}
+
+	credentials := base64.StdEncoding.EncodeToString([]byte(twilio.config.TwilioAccountSID + ":" + twilio.config.TwilioAuthToken))
+	url := strings.TrimSuffix(twilio.config.TwilioAPIURL, "/") + "/2010-04-01/Accounts/" + twilio.config.TwilioAccountSID + "/Messages.json"
+	response, err := twilio.client.Post(ctx, url, func(agent *fiber.Agent) {
+		agent.Form(args).Set(fiber.HeaderAuthorization, "Basic "+credentials)
+	})
+	if err != nil {
+		return "", err
+	}
+	message := struct {
+		SID     string `json:"sid"`
+		Message string `json:"message"`
+	}{}
+	json.Unmarshal(response.Body, &message)
+	if response.Status != fiber.StatusCreated {
+		return "", fmt.Errorf("twilio answered %d: %s", response.Status, message.Message)
+	}
+	return message.SID, nil
+}
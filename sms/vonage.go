@@ -0,0 +1,50 @@
+package sms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/httpclient"
+	"strings"
+)
+
+// Vonage texts through the Vonage Messages API. It reports the delivery to
+// the status URL of the application of the API key.
+type Vonage struct {
+	client *httpclient.Client
+	config config.SMSConfig
+}
+
+func NewVonage(client *httpclient.Client, config config.SMSConfig) *Vonage {
+	return &Vonage{client: client, config: config}
+}
+
+func (vonage *Vonage) Send(ctx context.Context, to, body string) (string, error) {
+	message := fiber.Map{
+		"message_type": "text",
+		"channel":      "sms",
+		"to":           strings.TrimPrefix(to, "+"),
+		"from":         strings.TrimPrefix(vonage.config.From, "+"),
+		"text":         body,
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte(vonage.config.VonageAPIKey + ":" + vonage.config.VonageAPISecret))
+	response, err := vonage.client.Post(ctx, strings.TrimSuffix(vonage.config.VonageAPIURL, "/")+"/v1/messages", func(agent *fiber.Agent) {
+		agent.JSON(message).Set(fiber.HeaderAuthorization, "Basic "+credentials)
+	})
+	if err != nil {
+		return "", err
+	}
+	accepted := struct {
+		MessageUUID string `json:"message_uuid"`
+		Title       string `json:"title"`
+		Detail      string `json:"detail"`
+	}{}
+	json.Unmarshal(response.Body, &accepted)
+	if response.Status != fiber.StatusAccepted {
+		return "", fmt.Errorf("vonage answered %d: %s %s", response.Status, accepted.Title, accepted.Detail)
+	}
+	return accepted.MessageUUID, nil
+}
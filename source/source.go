@@ -0,0 +1,7 @@
+// Package source holds the sample files served under /public.
+package source
+
+import "embed"
+
+//go:embed *.txt
+var FS embed.FS
@@ -0,0 +1,7 @@
+// Confirms the forms that ask for it before they are submitted.
+document.addEventListener("submit", function (event) {
+    var message = event.target.getAttribute("data-confirm");
+    if (message && !window.confirm(message)) {
+        event.preventDefault();
+    }
+});
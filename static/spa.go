@@ -0,0 +1,80 @@
+package static
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SPA serves a single-page app, falling back to its index for the paths the
+// app routes itself. See config.SPAConfig.
+type SPA struct {
+	config config.SPAConfig
+	files  fs.FS
+}
+
+func NewSPA(spaConfig config.SPAConfig) *SPA {
+	return NewSPAFS(spaConfig, os.DirFS(spaConfig.Dir))
+}
+
+// NewSPAFS is NewSPA serving files instead of the files of config.Dir.
+func NewSPAFS(spaConfig config.SPAConfig, files fs.FS) *SPA {
+	if spaConfig.Index == "" {
+		spaConfig.Index = "index.html"
+	}
+	if len(spaConfig.Exclude) == 0 {
+		spaConfig.Exclude = []string{"/api"}
+	}
+	return &SPA{config: spaConfig, files: files}
+}
+
+// Register adds the catch-all route of the app, so it is meant to be mounted
+// after the other modules. The excluded paths get a problem document even
+// when a browser asks, as an API would answer.
+func (spa *SPA) Register(router fiber.Router) {
+	router.Get("/*", spa.Serve)
+}
+
+func (spa *SPA) Serve(ctx *fiber.Ctx) error {
+	for _, exclude := range spa.config.Exclude {
+		if ctx.Path() == exclude || strings.HasPrefix(ctx.Path(), strings.TrimSuffix(exclude, "/")+"/") {
+			return web.SendProblem(ctx, web.NewProblem(fiber.StatusNotFound, "Cannot "+ctx.Method()+" "+ctx.Path()))
+		}
+	}
+
+	name := strings.Trim(path.Clean("/"+ctx.Params("*")), "/")
+	if name == "" {
+		name = spa.config.Index
+	}
+	content, err := fs.ReadFile(spa.files, name)
+	if err != nil {
+		content, err = fs.ReadFile(spa.files, path.Join(name, spa.config.Index))
+		if err == nil {
+			name = path.Join(name, spa.config.Index)
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) && path.Ext(name) == "" {
+		name = spa.config.Index
+		content, err = fs.ReadFile(spa.files, name)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return web.SendError(ctx, web.NewProblem(fiber.StatusNotFound, "Cannot "+ctx.Method()+" "+ctx.Path()))
+	}
+	if err != nil {
+		return err
+	}
+
+	if path.Base(name) == path.Base(spa.config.Index) {
+		ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	} else if spa.config.MaxAge > 0 {
+		ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(spa.config.MaxAge.Seconds())))
+	}
+	ctx.Type(strings.TrimPrefix(path.Ext(name), "."))
+	return ctx.Send(content)
+}
@@ -0,0 +1,98 @@
+package static
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newSPAApp(spaConfig config.SPAConfig) *fiber.App {
+	app := fiber.New()
+	app.Get("/api/users", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(fiber.Map{"users": []string{}})
+	})
+	NewSPAFS(spaConfig, fstest.MapFS{
+		"index.html":       {Data: []byte("<div id=app></div>")},
+		"js/app.js":        {Data: []byte("console.log(1)")},
+		"docs/index.html":  {Data: []byte("docs")},
+		"images/empty.txt": {Data: []byte("")},
+	}).Register(app.Group(spaConfig.Prefix))
+	app.Use(web.NotFound)
+	return app
+}
+
+func getSPA(t *testing.T, app *fiber.App, path string) (int, string, string) {
+	request := httptest.NewRequest("GET", path, nil)
+	request.Header.Set("Accept", "text/html")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, response.Header.Get("Cache-Control"), string(body)
+}
+
+func TestSPAServesFiles(t *testing.T) {
+	app := newSPAApp(config.SPAConfig{Prefix: "/", MaxAge: time.Hour})
+
+	status, cacheControl, body := getSPA(t, app, "/js/app.js")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "public, max-age=3600", cacheControl)
+	assert.Equal(t, "console.log(1)", body)
+
+	status, cacheControl, body = getSPA(t, app, "/docs")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "no-cache", cacheControl)
+	assert.Equal(t, "docs", body)
+}
+
+func TestSPAFallsBackToIndex(t *testing.T) {
+	app := newSPAApp(config.SPAConfig{Prefix: "/"})
+
+	for _, path := range []string{"/", "/users/3", "/settings/profile/"} {
+		status, cacheControl, body := getSPA(t, app, path)
+		assert.Equal(t, 200, status, path)
+		assert.Equal(t, "no-cache", cacheControl, path)
+		assert.Equal(t, "<div id=app></div>", body, path)
+	}
+
+	status, _, _ := getSPA(t, app, "/js/missing.js")
+	assert.Equal(t, 404, status)
+}
+
+func TestSPAExcludesAPI(t *testing.T) {
+	app := newSPAApp(config.SPAConfig{Prefix: "/"})
+
+	status, _, body := getSPA(t, app, "/api/users")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"users":[]}`, body)
+
+	request := httptest.NewRequest("GET", "/api/missing", nil)
+	request.Header.Set("Accept", "text/html")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, web.MIMEApplicationProblemJSON, response.Header.Get("Content-Type"))
+	var problem web.Problem
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(t, "Cannot GET /api/missing", problem.Detail)
+}
+
+func TestSPAExcludeConfigurable(t *testing.T) {
+	app := newSPAApp(config.SPAConfig{Prefix: "/app", Exclude: []string{"/app/api"}})
+
+	status, _, body := getSPA(t, app, "/app/orders/7")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "<div id=app></div>", body)
+
+	status, _, _ = getSPA(t, app, "/app/api/orders")
+	assert.Equal(t, 404, status)
+
+	status, _, _ = getSPA(t, app, "/elsewhere")
+	assert.Equal(t, 404, status)
+}
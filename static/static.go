@@ -0,0 +1,123 @@
+package static
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FS holds the front-end assets so a single binary can serve them.
+//
+//go:embed assets
+var FS embed.FS
+
+// fingerprintLength is the number of hex digits of the content hash put in
+// the fingerprinted names.
+const fingerprintLength = 10
+
+// Assets serves the files of a file system under Prefix. Every file is also
+// served under a fingerprinted name with the hash of its content before the
+// extension, such as css/app.0123456789.css, which is cached for MaxAge and
+// never revalidated: a new version has a new name. The plain names are
+// revalidated on every use.
+type Assets struct {
+	prefix       string
+	maxAge       time.Duration
+	files        fs.FS
+	fingerprints map[string]string
+	names        map[string]string
+	urls         map[string]string
+}
+
+// New fingerprints the files of files, which are read once here.
+func New(prefix string, files fs.FS, maxAge time.Duration) (*Assets, error) {
+	assets := &Assets{prefix: strings.TrimSuffix(prefix, "/"), maxAge: maxAge, files: files,
+		fingerprints: map[string]string{}, names: map[string]string{}}
+	err := fs.WalkDir(files, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(content)
+		extension := path.Ext(name)
+		fingerprinted := strings.TrimSuffix(name, extension) + "." + hex.EncodeToString(hash[:])[:fingerprintLength] + extension
+		assets.fingerprints[name] = fingerprinted
+		assets.names[fingerprinted] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	assets.urls = make(map[string]string, len(assets.fingerprints))
+	for name, fingerprinted := range assets.fingerprints {
+		assets.urls[name] = assets.prefix + "/" + fingerprinted
+	}
+	return assets, nil
+}
+
+// URL returns the URL of the fingerprinted name of the file called name.
+func (assets *Assets) URL(name string) (string, error) {
+	url, ok := assets.urls[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", errors.New("unknown asset " + name)
+	}
+	return url, nil
+}
+
+// URLs maps the name of every file to the URL of its fingerprinted name, for
+// the templates that cannot call URL, such as html ones:
+//
+//	<link rel="stylesheet" href="{{index .assets "css/app.css"}}">
+func (assets *Assets) URLs() map[string]string {
+	return assets.urls
+}
+
+// Lambda returns URL as a mustache lambda, whose section is the name of the
+// file:
+//
+//	<link rel="stylesheet" href="{{#asset}}css/app.css{{/asset}}">
+func (assets *Assets) Lambda() func(text string, render func(string) (string, error)) (string, error) {
+	return func(text string, render func(string) (string, error)) (string, error) {
+		name, err := render(text)
+		if err != nil {
+			return "", err
+		}
+		return assets.URL(strings.TrimSpace(name))
+	}
+}
+
+// Register adds the route serving the files. Assets is meant to be mounted
+// at its prefix.
+func (assets *Assets) Register(router fiber.Router) {
+	router.Get("/*", assets.Serve).Name("assets." + strings.Trim(assets.prefix, "/"))
+}
+
+func (assets *Assets) Serve(ctx *fiber.Ctx) error {
+	name := ctx.Params("*")
+	cacheControl := "public, max-age=" + strconv.Itoa(int(assets.maxAge.Seconds())) + ", immutable"
+	if original, ok := assets.names[name]; ok {
+		name = original
+	} else if _, ok := assets.fingerprints[name]; ok {
+		cacheControl = "no-cache"
+	} else {
+		return fiber.ErrNotFound
+	}
+
+	content, err := fs.ReadFile(assets.files, name)
+	if err != nil {
+		return err
+	}
+	ctx.Type(strings.TrimPrefix(path.Ext(name), "."))
+	ctx.Set(fiber.HeaderCacheControl, cacheControl)
+	return ctx.Send(content)
+}
@@ -0,0 +1,65 @@
+package static
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newAssetsApp(t *testing.T) (*fiber.App, *Assets) {
+	assets, err := New("/assets", fstest.MapFS{
+		"css/app.css": {Data: []byte("body { margin: 0 }")},
+	}, time.Hour)
+	assert.Nil(t, err)
+	app := fiber.New()
+	assets.Register(app.Group("/assets"))
+	return app, assets
+}
+
+func TestURL(t *testing.T) {
+	_, assets := newAssetsApp(t)
+
+	url, err := assets.URL("css/app.css")
+	assert.Nil(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^/assets/css/app\.[0-9a-f]{10}\.css$`), url)
+	assert.Equal(t, url, assets.URLs()["css/app.css"])
+
+	_, err = assets.URL("css/missing.css")
+	assert.NotNil(t, err)
+}
+
+func TestServeFingerprinted(t *testing.T) {
+	app, assets := newAssetsApp(t)
+	url, err := assets.URL("css/app.css")
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest("GET", url, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "public, max-age=3600, immutable", response.Header.Get("Cache-Control"))
+	assert.Contains(t, response.Header.Get("Content-Type"), "text/css")
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "body { margin: 0 }", string(body))
+}
+
+func TestServePlainName(t *testing.T) {
+	app, _ := newAssetsApp(t)
+
+	response, err := app.Test(httptest.NewRequest("GET", "/assets/css/app.css", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "no-cache", response.Header.Get("Cache-Control"))
+}
+
+func TestServeUnknown(t *testing.T) {
+	app, _ := newAssetsApp(t)
+
+	response, err := app.Test(httptest.NewRequest("GET", "/assets/css/app.0000000000.css", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
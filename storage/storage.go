@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// PartialPrefix starts the names of the files Local writes objects to before
+// they are complete. Those of the writes that were interrupted are left
+// behind.
+const PartialPrefix = ".put-"
+
+// Storage stores the objects of the app, such as the uploaded files and
+// their thumbnails, under slash separated keys.
+type Storage interface {
+	// Open fails with ErrNotFound when no object is stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put stores content under key, replacing the object stored there.
+	Put(ctx context.Context, key string, content io.Reader) error
+	Delete(ctx context.Context, key string) error
+	// DeleteAll deletes the objects under prefix, a key followed by a slash.
+	DeleteAll(ctx context.Context, prefix string) error
+}
+
+// Local stores the objects as files of a directory. Put writes a temporary
+// file renamed once it is complete, so readers never see half an object.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (local *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := local.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (local *Local) Put(ctx context.Context, key string, content io.Reader) error {
+	path, err := local.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(path), PartialPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	_, err = io.Copy(temporary, content)
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), path)
+}
+
+func (local *Local) Delete(ctx context.Context, key string) error {
+	path, err := local.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (local *Local) DeleteAll(ctx context.Context, prefix string) error {
+	path, err := local.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// path returns the file of key, which must not leave the directory.
+func (local *Local) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(local.dir, name), nil
+}
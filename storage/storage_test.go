@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	local := NewLocal(t.TempDir())
+	ctx := context.Background()
+
+	_, err := local.Open(ctx, "a/b.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Nil(t, local.Put(ctx, "a/b.txt", strings.NewReader("hello")))
+	content, err := local.Open(ctx, "a/b.txt")
+	assert.Nil(t, err)
+	read, err := io.ReadAll(content)
+	assert.Nil(t, err)
+	assert.Nil(t, content.Close())
+	assert.Equal(t, "hello", string(read))
+
+	assert.ErrorIs(t, local.Put(ctx, "../escape.txt", strings.NewReader("")), ErrInvalidKey)
+	_, err = local.Open(ctx, "/etc/passwd")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	assert.Nil(t, local.Delete(ctx, "a/b.txt"))
+	assert.ErrorIs(t, local.Delete(ctx, "a/b.txt"), ErrNotFound)
+
+	assert.Nil(t, local.Put(ctx, "c/d/e.txt", strings.NewReader("hello")))
+	assert.Nil(t, local.DeleteAll(ctx, "c"))
+	_, err = local.Open(ctx, "c/d/e.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,101 @@
+// Package systemd tells the service manager about the state of the process
+// through the sd_notify protocol: when it is ready, stopping, and alive for
+// the watchdog. Outside of a notify service every call is a no-op.
+package systemd
+
+import (
+	"context"
+	"golang-fiber-web/telemetry"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+var logger = telemetry.Logger("systemd")
+
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Alive    = "WATCHDOG=1"
+)
+
+// MainPID makes this process the main process of the service, for the new
+// process of an upgrade to send along with Ready.
+func MainPID() string {
+	return "MAINPID=" + strconv.Itoa(os.Getpid())
+}
+
+// Notify sends the state lines to the socket in NOTIFY_SOCKET. It reports
+// false when there is none.
+func Notify(states ...string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// A leading @ names a socket in the abstract namespace.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var message []byte
+	for _, state := range states {
+		message = append(message, state...)
+		message = append(message, '\n')
+	}
+	_, err = conn.Write(message)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogInterval is the interval systemd expects Alive within, zero when
+// the watchdog is off for this process.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Watchdog sends Alive twice per watchdog interval while check passes, until
+// ctx is done. When the process hangs or check keeps failing, systemd stops
+// hearing from it and restarts the service.
+func Watchdog(ctx context.Context, check func(ctx context.Context) error) {
+	interval := WatchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval/2)
+			err := check(checkCtx)
+			cancel()
+			if err != nil {
+				logger.Warn("health check failed, skipping the watchdog", "error", err)
+				continue
+			}
+			_, err = Notify(Alive)
+			if err != nil {
+				logger.Warn("failed to notify the watchdog", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package systemd
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func listenNotify(t *testing.T) *net.UnixConn {
+	// Unix socket paths are short, so the directory is not under t.TempDir.
+	dir, err := os.MkdirTemp("", "notify")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readNotify(t *testing.T, conn *net.UnixConn) string {
+	buffer := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	n, err := conn.Read(buffer)
+	assert.Nil(t, err)
+	return string(buffer[:n])
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	sent, err := Notify(Ready)
+	assert.Nil(t, err)
+	assert.False(t, sent)
+
+	conn := listenNotify(t)
+	sent, err = Notify(MainPID(), Ready)
+	assert.Nil(t, err)
+	assert.True(t, sent)
+	assert.Equal(t, "MAINPID="+strconv.Itoa(os.Getpid())+"\nREADY=1\n", readNotify(t, conn))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	assert.Equal(t, time.Second*30, WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", "1")
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+}
+
+func TestWatchdog(t *testing.T) {
+	conn := listenNotify(t)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Watchdog(ctx, func(ctx context.Context) error { return nil })
+		close(done)
+	}()
+	assert.Equal(t, "WATCHDOG=1\n", readNotify(t, conn))
+	cancel()
+	<-done
+}
@@ -0,0 +1,150 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/config"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+)
+
+var (
+	logLevel       = new(slog.LevelVar)
+	logOutputMutex sync.RWMutex
+	debugModules   sync.Map
+)
+
+var logOutput slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+// InitLogging makes the standard log and slog packages write through the
+// module-aware handler, as text or JSON, and applies the level and the debug
+// modules of config. Both can be changed afterwards with SetLogLevel and
+// SetModuleDebug.
+func InitLogging(config config.LogConfig) error {
+	return initLogging(config, os.Stderr)
+}
+
+func initLogging(config config.LogConfig, writer io.Writer) error {
+	level, err := ParseLogLevel(config.Level)
+	if err != nil {
+		return err
+	}
+
+	var output slog.Handler
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch config.Format {
+	case "", "text":
+		output = slog.NewTextHandler(writer, options)
+	case "json":
+		output = slog.NewJSONHandler(writer, options)
+	default:
+		return errors.New("unknown log format " + config.Format)
+	}
+
+	logOutputMutex.Lock()
+	logOutput = output
+	logOutputMutex.Unlock()
+	logLevel.Set(level)
+	debugModules.Clear()
+	for _, module := range config.Debug {
+		debugModules.Store(module, true)
+	}
+	slog.SetDefault(slog.New(&moduleHandler{}))
+	return nil
+}
+
+// ParseLogLevel parses debug, info, warn or error; the empty string is info.
+func ParseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(name))
+	return level, err
+}
+
+func LogLevel() slog.Level {
+	return logLevel.Level()
+}
+
+func SetLogLevel(level slog.Level) {
+	logLevel.Set(level)
+}
+
+// SetModuleDebug turns debug logging of a module on or off, whatever the
+// level.
+func SetModuleDebug(module string, enabled bool) {
+	if enabled {
+		debugModules.Store(module, true)
+	} else {
+		debugModules.Delete(module)
+	}
+}
+
+// DebugModules lists the modules logging at debug level, sorted.
+func DebugModules() []string {
+	modules := []string{}
+	debugModules.Range(func(module, _ any) bool {
+		modules = append(modules, module.(string))
+		return true
+	})
+	sort.Strings(modules)
+	return modules
+}
+
+// Logger is the logger of a module, such as "outbox" or "webhooks". Its
+// records carry the module and are written at the log level, or at debug
+// level while debug logging of the module is on.
+func Logger(module string) *slog.Logger {
+	return slog.New(&moduleHandler{module: module})
+}
+
+// moduleHandler filters records by module and hands them to the current
+// output, so that a logger created before InitLogging still follows it. The
+// attributes and groups added to it are replayed on the output in order.
+type moduleHandler struct {
+	module  string
+	derived []func(slog.Handler) slog.Handler
+}
+
+func (handler *moduleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= logLevel.Level() {
+		return true
+	}
+	_, debug := debugModules.Load(handler.module)
+	return debug && level >= slog.LevelDebug
+}
+
+func (handler *moduleHandler) Handle(ctx context.Context, record slog.Record) error {
+	logOutputMutex.RLock()
+	output := logOutput
+	logOutputMutex.RUnlock()
+
+	if handler.module != "" {
+		output = output.WithAttrs([]slog.Attr{slog.String("module", handler.module)})
+	}
+	for _, derive := range handler.derived {
+		output = derive(output)
+	}
+	return output.Handle(ctx, record)
+}
+
+func (handler *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler.derive(func(output slog.Handler) slog.Handler {
+		return output.WithAttrs(attrs)
+	})
+}
+
+func (handler *moduleHandler) WithGroup(name string) slog.Handler {
+	return handler.derive(func(output slog.Handler) slog.Handler {
+		return output.WithGroup(name)
+	})
+}
+
+func (handler *moduleHandler) derive(derive func(slog.Handler) slog.Handler) *moduleHandler {
+	derived := append(append([]func(slog.Handler) slog.Handler{}, handler.derived...), derive)
+	return &moduleHandler{module: handler.module, derived: derived}
+}
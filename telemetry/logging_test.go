@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"log"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func useLogBuffer(t *testing.T, logConfig config.LogConfig) *bytes.Buffer {
+	var buffer bytes.Buffer
+	assert.Nil(t, initLogging(logConfig, &buffer))
+	previous := slog.Default()
+	t.Cleanup(func() {
+		assert.Nil(t, initLogging(config.LogConfig{}, os.Stderr))
+		slog.SetDefault(previous)
+	})
+	return &buffer
+}
+
+func TestLoggerLevels(t *testing.T) {
+	buffer := useLogBuffer(t, config.LogConfig{Level: "warn", Format: "json", Debug: []string{"outbox"}})
+
+	Logger("webhooks").Info("hidden")
+	Logger("webhooks").Warn("shown", "attempt", 1)
+	Logger("outbox").Debug("relayed")
+	assert.NotContains(t, buffer.String(), "hidden")
+	assert.Contains(t, buffer.String(), `"level":"WARN","msg":"shown","module":"webhooks","attempt":1}`)
+	assert.Contains(t, buffer.String(), `"msg":"relayed","module":"outbox"`)
+
+	buffer.Reset()
+	SetLogLevel(slog.LevelInfo)
+	SetModuleDebug("outbox", false)
+	SetModuleDebug("webhooks", true)
+	assert.Equal(t, []string{"webhooks"}, DebugModules())
+	Logger("outbox").Debug("hidden")
+	Logger("webhooks").Debug("delivered")
+	log.Print("standard")
+	assert.NotContains(t, buffer.String(), "hidden")
+	assert.Contains(t, buffer.String(), `"msg":"delivered"`)
+	assert.Contains(t, buffer.String(), `"level":"INFO","msg":"standard"`)
+}
+
+func TestInitLoggingErrors(t *testing.T) {
+	assert.NotNil(t, initLogging(config.LogConfig{Level: "loud"}, os.Stderr))
+	assert.NotNil(t, initLogging(config.LogConfig{Format: "xml"}, os.Stderr))
+}
@@ -0,0 +1,58 @@
+package telemetry
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var PanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_panics_total",
+	Help: "Number of panics recovered from request handlers.",
+}, []string{"method", "route"})
+
+var SlowRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_slow_requests_total",
+	Help: "Number of requests that took longer than their slow request threshold.",
+}, []string{"method", "route"})
+
+var ShedRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_shed_requests_total",
+	Help: "Number of requests turned away with 503 while the server was saturated.",
+}, []string{"priority", "prefix"})
+
+var ConcurrencyRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_concurrency_rejected_requests_total",
+	Help: "Number of requests turned away with 503 by a concurrency limit, by route group.",
+}, []string{"group"})
+
+var QueryCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "repository_query_cache_requests_total",
+	Help: "Number of cacheable repository reads, by repository, method and whether the cache had the result.",
+}, []string{"repository", "method", "result"})
+
+// JanitorRemovedFilesTotal and JanitorRemovedBytesTotal count what the
+// janitor removed, or would have removed in a dry run, by kind of file.
+var JanitorRemovedFilesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "janitor_removed_files_total",
+	Help: "Number of files removed by the janitor.",
+}, []string{"kind", "dry_run"})
+
+var JanitorRemovedBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "janitor_removed_bytes_total",
+	Help: "Number of bytes of the files removed by the janitor.",
+}, []string{"kind", "dry_run"})
+
+var JanitorErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "janitor_errors_total",
+	Help: "Number of files the janitor failed to check or remove.",
+})
+
+func init() {
+	prometheus.MustRegister(PanicsTotal, SlowRequestsTotal, ShedRequestsTotal, ConcurrencyRejectedTotal, QueryCacheRequestsTotal, JanitorRemovedFilesTotal, JanitorRemovedBytesTotal, JanitorErrorsTotal)
+}
+
+func MetricsHandler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
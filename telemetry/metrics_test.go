@@ -0,0 +1,24 @@
+package telemetry
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	metricsApp := fiber.New()
+	metricsApp.Get("/metrics", MetricsHandler())
+	PanicsTotal.WithLabelValues("GET", "/metrics-test").Inc()
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	response, err := metricsApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.True(t, strings.Contains(string(bytes), `http_panics_total{method="GET",route="/metrics-test"} 1`))
+}
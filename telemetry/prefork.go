@@ -0,0 +1,136 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChildLabel is the label telling apart the series of the prefork children
+// in the metrics gathered from all of them.
+const ChildLabel = "child"
+
+const childMetricsTimeout = time.Second * 5
+
+// ChildMetricsDir is the directory where the prefork children of the parent
+// parentPID serve their metrics, on a unix socket each named after their pid.
+func ChildMetricsDir(parentPID int) string {
+	return filepath.Join(os.TempDir(), "golang-fiber-web-metrics-"+strconv.Itoa(parentPID))
+}
+
+// ServeChildMetrics serves the metrics of this process on its socket in dir,
+// for the child answering /metrics to gather. Closing the returned closer
+// stops serving and removes the socket.
+func ServeChildMetrics(dir string) (io.Closer, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, strconv.Itoa(os.Getpid())+".sock")
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: promhttp.Handler(), ReadHeaderTimeout: childMetricsTimeout}
+	go server.Serve(listener)
+	return server, nil
+}
+
+// PreforkMetricsHandler serves the metrics of every child serving them in
+// dir, each series labeled with the pid of its child, so that whichever
+// child answers, Prometheus sees the numbers of all of them.
+func PreforkMetricsHandler(dir string) fiber.Handler {
+	gatherer := &childrenGatherer{dir: dir}
+	return adaptor.HTTPHandler(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}))
+}
+
+type childrenGatherer struct {
+	dir string
+}
+
+// Gather merges the metric families of the children. The sockets of the
+// children that are gone are removed; the errors of the others are returned
+// along with what could be gathered.
+func (gatherer *childrenGatherer) Gather() ([]*io_prometheus_client.MetricFamily, error) {
+	sockets, err := filepath.Glob(filepath.Join(gatherer.dir, "*.sock"))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs prometheus.MultiError
+	merged := map[string]*io_prometheus_client.MetricFamily{}
+	for _, socket := range sockets {
+		child := strings.TrimSuffix(filepath.Base(socket), ".sock")
+		families, err := gatherChild(socket)
+		var netError *net.OpError
+		if errors.As(err, &netError) && netError.Op == "dial" {
+			os.Remove(socket)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for name, family := range families {
+			for _, metric := range family.Metric {
+				metric.Label = append(metric.Label, &io_prometheus_client.LabelPair{Name: stringPointer(ChildLabel), Value: stringPointer(child)})
+				sort.Slice(metric.Label, func(i, j int) bool {
+					return metric.Label[i].GetName() < metric.Label[j].GetName()
+				})
+			}
+			if existing, ok := merged[name]; ok {
+				existing.Metric = append(existing.Metric, family.Metric...)
+			} else {
+				merged[name] = family
+			}
+		}
+	}
+
+	result := make([]*io_prometheus_client.MetricFamily, 0, len(merged))
+	for _, family := range merged {
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result, errs.MaybeUnwrap()
+}
+
+func gatherChild(socket string) (map[string]*io_prometheus_client.MetricFamily, error) {
+	client := &http.Client{
+		Timeout: childMetricsTimeout,
+		Transport: &http.Transport{DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}},
+	}
+	response, err := client.Get("http://child/metrics")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("child metrics answered " + response.Status)
+	}
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(response.Body)
+}
+
+func stringPointer(value string) *string {
+	return &value
+}
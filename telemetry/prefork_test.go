@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func serveFakeChild(t *testing.T, dir string, pid string, metrics string) {
+	listener, err := net.Listen("unix", filepath.Join(dir, pid+".sock"))
+	assert.Nil(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		io.WriteString(writer, metrics)
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+}
+
+func TestPreforkMetricsHandler(t *testing.T) {
+	// Unix socket paths are short, so the directory is not under t.TempDir.
+	dir, err := os.MkdirTemp("", "metrics")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	serveFakeChild(t, dir, "101", "# TYPE http_requests_total counter\nhttp_requests_total{method=\"GET\"} 3\n")
+	serveFakeChild(t, dir, "102", "# TYPE http_requests_total counter\nhttp_requests_total{method=\"GET\"} 4\n# TYPE up gauge\nup 1\n")
+	stale := filepath.Join(dir, "103.sock")
+	listener, err := net.Listen("unix", stale)
+	assert.Nil(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	metricsApp := fiber.New()
+	metricsApp.Get("/metrics", PreforkMetricsHandler(dir))
+	response, err := metricsApp.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	body := string(bytes)
+	assert.True(t, strings.Contains(body, `http_requests_total{child="101",method="GET"} 3`), body)
+	assert.True(t, strings.Contains(body, `http_requests_total{child="102",method="GET"} 4`), body)
+	assert.True(t, strings.Contains(body, `up{child="102"} 1`), body)
+	assert.Equal(t, 1, strings.Count(body, "# TYPE http_requests_total counter"))
+
+	_, err = os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestServeChildMetrics(t *testing.T) {
+	dir, err := os.MkdirTemp("", "metrics")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	PanicsTotal.WithLabelValues("GET", "/prefork-test").Inc()
+	closer, err := ServeChildMetrics(dir)
+	assert.Nil(t, err)
+	defer closer.Close()
+
+	families, err := (&childrenGatherer{dir: dir}).Gather()
+	assert.Nil(t, err)
+	found := false
+	for _, family := range families {
+		if family.GetName() == "http_panics_total" {
+			for _, metric := range family.Metric {
+				labels := map[string]string{}
+				for _, label := range metric.Label {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["route"] == "/prefork-test" {
+					found = labels[ChildLabel] == strconv.Itoa(os.Getpid())
+				}
+			}
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"strings"
+	"time"
+)
+
+const (
+	sentryHubKey      = "sentry_hub"
+	sentryReportedKey = "sentry_reported"
+)
+
+func InitSentry(config config.SentryConfig) error {
+	return sentry.Init(sentry.ClientOptions{
+		Dsn:              config.DSN,
+		Environment:      config.Environment,
+		Release:          config.Release,
+		SampleRate:       config.SampleRate,
+		AttachStacktrace: true,
+	})
+}
+
+// NewSentry gives every request its own hub whose scope carries the request,
+// route, request ID and user ID, plus a breadcrumb trail. Panics are reported
+// and re-raised so middleware.NewRecover still turns them into a 500.
+func NewSentry() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		hub := sentry.CurrentHub().Clone()
+		ctx.Locals(sentryHubKey, hub)
+		ctx.SetUserContext(sentry.SetHubOnContext(ctx.UserContext(), hub))
+
+		request := sentryRequest(ctx)
+		hub.Scope().AddEventProcessor(func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+			event.Request = request
+			return event
+		})
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Type:      "http",
+			Category:  "request",
+			Message:   ctx.Method() + " " + ctx.OriginalURL(),
+			Timestamp: time.Now(),
+		}, nil)
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				configureSentryScope(ctx, hub)
+				hub.RecoverWithContext(ctx.UserContext(), recovered)
+				ctx.Locals(sentryReportedKey, true)
+				panic(recovered)
+			}
+		}()
+
+		return ctx.Next()
+	}
+}
+
+// AddBreadcrumb records a step of the current request for the Sentry event
+// that may be reported later on.
+func AddBreadcrumb(ctx *fiber.Ctx, category, message string) {
+	if hub, ok := ctx.Locals(sentryHubKey).(*sentry.Hub); ok {
+		hub.AddBreadcrumb(&sentry.Breadcrumb{Category: category, Message: message, Timestamp: time.Now()}, nil)
+	}
+}
+
+// ReportError sends err to Sentry unless the request already reported it,
+// as happens for panics captured by NewSentry.
+func ReportError(ctx *fiber.Ctx, err error) {
+	hub, ok := ctx.Locals(sentryHubKey).(*sentry.Hub)
+	if !ok || ctx.Locals(sentryReportedKey) == true {
+		return
+	}
+
+	configureSentryScope(ctx, hub)
+	hub.CaptureException(err)
+	ctx.Locals(sentryReportedKey, true)
+}
+
+func configureSentryScope(ctx *fiber.Ctx, hub *sentry.Hub) {
+	scope := hub.Scope()
+	scope.SetTag("route", ctx.Route().Path)
+	scope.SetTag("method", ctx.Method())
+	if requestID, ok := ctx.Locals("requestid").(string); ok {
+		scope.SetTag("request_id", requestID)
+	}
+	if userID, ok := ctx.Locals("user_id").(string); ok {
+		scope.SetUser(sentry.User{ID: userID})
+	}
+}
+
+// sentryFilteredHeaders carry credentials, signatures and other secrets,
+// lower case, and are left out of the requests sent to Sentry.
+var sentryFilteredHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"x-api-key":           true,
+	"x-captcha-response":  true,
+	"x-webhook-signature": true,
+	"x-hub-signature":     true,
+	"x-hub-signature-256": true,
+	"x-twilio-signature":  true,
+	"stripe-signature":    true,
+}
+
+func sentryRequest(ctx *fiber.Ctx) *sentry.Request {
+	headers := map[string]string{}
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		name := string(key)
+		if !sentryFilteredHeaders[strings.ToLower(name)] {
+			headers[name] = string(value)
+		}
+	})
+
+	return &sentry.Request{
+		URL:         ctx.BaseURL() + ctx.Path(),
+		Method:      ctx.Method(),
+		QueryString: string(ctx.Request().URI().QueryString()),
+		Headers:     headers,
+		Env:         map[string]string{"REMOTE_ADDR": ctx.IP()},
+	}
+}
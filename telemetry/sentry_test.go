@@ -0,0 +1,116 @@
+package telemetry
+
+import (
+	"errors"
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sentryTransportMock struct {
+	mutex  sync.Mutex
+	events []*sentry.Event
+}
+
+func (transport *sentryTransportMock) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func (transport *sentryTransportMock) Configure(options sentry.ClientOptions) {
+}
+
+func (transport *sentryTransportMock) SendEvent(event *sentry.Event) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.events = append(transport.events, event)
+}
+
+func useSentryTransport(t *testing.T) *sentryTransportMock {
+	transport := &sentryTransportMock{}
+	err := sentry.Init(sentry.ClientOptions{Dsn: "https://public@sentry.example.com/1", Transport: transport})
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		sentry.CurrentHub().BindClient(nil)
+	})
+	return transport
+}
+
+func newSentryApp() *fiber.App {
+	sentryApp := fiber.New(fiber.Config{
+		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+			status := fiber.StatusInternalServerError
+			var fiberError *fiber.Error
+			if errors.As(err, &fiberError) {
+				status = fiberError.Code
+			} else {
+				ReportError(ctx, err)
+			}
+			return ctx.Status(status).SendString("Error: " + err.Error())
+		},
+	})
+	sentryApp.Use(recover.New())
+	sentryApp.Use(requestid.New())
+	sentryApp.Use(NewSentry())
+	sentryApp.Get("/panic/:id", func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", "user-1")
+		AddBreadcrumb(ctx, "users", "loading user "+ctx.Params("id"))
+		panic("ups")
+	})
+	sentryApp.Get("/error", func(ctx *fiber.Ctx) error {
+		return errors.New("database is down")
+	})
+	sentryApp.Get("/missing", func(ctx *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+	return sentryApp
+}
+
+func TestSentryCapturesPanic(t *testing.T) {
+	transport := useSentryTransport(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/panic/7?debug=true", nil)
+	request.Header.Set("X-Request-ID", "request-123")
+	for _, name := range []string{"Authorization", "Proxy-Authorization", "Cookie", "X-API-Key", "X-Captcha-Response", "Stripe-Signature"} {
+		request.Header.Set(name, "secret")
+	}
+	response, err := newSentryApp().Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+
+	assert.Len(t, transport.events, 1)
+	event := transport.events[0]
+	assert.Equal(t, "/panic/:id", event.Tags["route"])
+	assert.Equal(t, "request-123", event.Tags["request_id"])
+	assert.Equal(t, "user-1", event.User.ID)
+	assert.Equal(t, "debug=true", event.Request.QueryString)
+	assert.Equal(t, "request-123", event.Request.Headers["X-Request-Id"])
+	for name, value := range event.Request.Headers {
+		assert.NotEqual(t, "secret", value, name)
+	}
+	assert.Len(t, event.Breadcrumbs, 2)
+	assert.Equal(t, "loading user 7", event.Breadcrumbs[1].Message)
+}
+
+func TestSentryCapturesServerErrors(t *testing.T) {
+	transport := useSentryTransport(t)
+	sentryApp := newSentryApp()
+
+	response, err := sentryApp.Test(httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+
+	response, err = sentryApp.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+
+	assert.Len(t, transport.events, 1)
+	assert.Equal(t, "database is down", transport.events[0].Exception[0].Value)
+	assert.Equal(t, "/error", transport.events[0].Tags["route"])
+}
@@ -0,0 +1,103 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang-fiber-web/config"
+	"strings"
+)
+
+const tracerName = "golang-fiber-web"
+
+// NewTracerProvider configures the global tracer provider to export spans to
+// an OTLP/HTTP collector and the global propagator to W3C trace context.
+func NewTracerProvider(config config.TracingConfig) (*sdktrace.TracerProvider, error) {
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(config.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+// NewTracing starts a server span per request, continuing the trace of an
+// inbound traceparent header. The span context is stored as the user context
+// so handlers and outbound calls can create child spans from it.
+func NewTracing() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		headers := propagation.MapCarrier{}
+		ctx.Request().Header.VisitAll(func(key, value []byte) {
+			headers[strings.ToLower(string(key))] = string(value)
+		})
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), headers)
+
+		spanContext, span := otel.Tracer(tracerName).Start(parent, ctx.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Method()),
+				semconv.URLPath(ctx.Path()),
+			),
+		)
+		defer span.End()
+		ctx.SetUserContext(spanContext)
+
+		err := ctx.Next()
+
+		route := ctx.Route().Path
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+		if requestID, ok := ctx.Locals("requestid").(string); ok {
+			span.SetAttributes(attribute.String("http.request_id", requestID))
+		}
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberError *fiber.Error
+			if errors.As(err, &fiberError) {
+				status = fiberError.Code
+			}
+			span.RecordError(err)
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+		return err
+	}
+}
+
+// Trace runs fn inside a child span of ctx, recording a returned error on it.
+func Trace(ctx context.Context, name string, kind trace.SpanKind, fn func(context.Context) error, attributes ...attribute.KeyValue) error {
+	spanContext, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attributes...))
+	defer span.End()
+
+	err := fn(spanContext)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// jwtClaim returns the string claim of an HS256 JSON Web Token signed with
+// secret, once its signature and expiry are verified.
+func jwtClaim(token, secret, claim string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || secret == "" {
+		return "", errInvalidToken
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil || header.Algorithm != "HS256" {
+		return "", errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errInvalidToken
+	}
+
+	var claims map[string]interface{}
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return "", errInvalidToken
+	}
+	if expiry, ok := claims["exp"].(float64); ok && time.Now().Unix() >= int64(expiry) {
+		return "", errors.New("token expired")
+	}
+	value, _ := claims[claim].(string)
+	return value, nil
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
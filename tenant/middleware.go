@@ -0,0 +1,86 @@
+package tenant
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/config"
+	"golang-fiber-web/web"
+	"net"
+	"slices"
+	"strings"
+)
+
+// New resolves the tenant of every request as config describes and makes it
+// the tenant of the user context. The tenant and its branding are also
+// stored in the request locals as "tenant" and "branding" for the views.
+// Requests without a tenant are answered 400, those of a tenant that is not
+// configured 404, and those whose bearer token names another tenant than the
+// one resolved, such as from a header, 403. It does nothing unless tenancy is
+// enabled.
+func New(config config.TenancyConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !config.Enabled {
+			return ctx.Next()
+		}
+
+		tenant := resolve(ctx, config)
+		if tenant == "" {
+			tenant = config.Default
+		}
+		if tenant == "" {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusBadRequest, "tenant is required"))
+		}
+		if verified := jwtTenant(ctx, config); verified != "" && verified != tenant {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusForbidden, "the token belongs to another tenant than "+tenant))
+		}
+		tenantConfig, ok := config.Tenants[tenant]
+		if !ok {
+			return web.SendError(ctx, web.NewProblem(fiber.StatusNotFound, "unknown tenant "+tenant))
+		}
+
+		ctx.SetUserContext(With(ctx.UserContext(), tenant))
+		ctx.Locals("tenant", tenant)
+		ctx.Locals("branding", tenantConfig.Branding)
+		return ctx.Next()
+	}
+}
+
+func resolve(ctx *fiber.Ctx, config config.TenancyConfig) string {
+	for _, resolver := range config.Resolvers {
+		var tenant string
+		switch resolver {
+		case "subdomain":
+			if config.Domain != "" {
+				host := ctx.Hostname()
+				if hostname, _, err := net.SplitHostPort(host); err == nil {
+					host = hostname
+				}
+				subdomain, ok := strings.CutSuffix(host, "."+config.Domain)
+				if ok && !strings.Contains(subdomain, ".") {
+					tenant = strings.Clone(subdomain)
+				}
+			}
+		case "header":
+			tenant = strings.Clone(ctx.Get(config.Header))
+		case "jwt":
+			tenant = jwtTenant(ctx, config)
+		}
+		if tenant != "" {
+			return tenant
+		}
+	}
+	return ""
+}
+
+// jwtTenant is the tenant of the verified bearer token of the request, when
+// the jwt resolver is configured.
+func jwtTenant(ctx *fiber.Ctx, config config.TenancyConfig) string {
+	if !slices.Contains(config.Resolvers, "jwt") {
+		return ""
+	}
+	token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return ""
+	}
+	tenant, _ := jwtClaim(token, config.JWTSecret, config.JWTClaim)
+	return tenant
+}
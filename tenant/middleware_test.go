@@ -0,0 +1,119 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signToken(secret, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTenantApp(tenancy config.TenancyConfig) *fiber.App {
+	tenantApp := fiber.New()
+	tenantApp.Use(New(tenancy))
+	tenantApp.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(From(ctx.UserContext()))
+	})
+	return tenantApp
+}
+
+func TestResolve(t *testing.T) {
+	tenancy := config.Default().Tenancy
+	tenancy.Enabled = true
+	tenancy.Resolvers = []string{"jwt", "subdomain", "header"}
+	tenancy.Domain = "example.com"
+	tenancy.JWTSecret = "secret"
+	tenancy.Tenants = map[string]config.TenantConfig{"acme": {}, "globex": {}, "initech": {}}
+	tenantApp := newTenantApp(tenancy)
+
+	resolve := func(host string, headers map[string]string) (int, string) {
+		request := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		response, err := tenantApp.Test(request)
+		assert.Nil(t, err)
+		body, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return response.StatusCode, string(body)
+	}
+
+	status, body := resolve("acme.example.com:8080", map[string]string{"X-Tenant-ID": "globex"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "acme", body)
+
+	status, body = resolve("example.com", map[string]string{"X-Tenant-ID": "globex"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "globex", body)
+
+	status, body = resolve("example.com", map[string]string{fiber.HeaderAuthorization: "Bearer " + signToken("secret", `{"tenant_id":"initech"}`)})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "initech", body)
+
+	// The header or the host can't override the tenant of a verified token.
+	initech := "Bearer " + signToken("secret", `{"tenant_id":"initech"}`)
+	status, body = resolve("acme.example.com", map[string]string{fiber.HeaderAuthorization: initech, "X-Tenant-ID": "globex"})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "initech", body)
+
+	status, _ = resolve("example.com", map[string]string{fiber.HeaderAuthorization: "Bearer " + signToken("wrong", `{"tenant_id":"initech"}`)})
+	assert.Equal(t, 400, status)
+
+	expired := `{"tenant_id":"initech","exp":` + strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10) + `}`
+	status, _ = resolve("example.com", map[string]string{fiber.HeaderAuthorization: "Bearer " + signToken("secret", expired)})
+	assert.Equal(t, 400, status)
+
+	status, _ = resolve("umbrella.example.com", nil)
+	assert.Equal(t, 404, status)
+
+	tenancy.Default = "acme"
+	tenantApp = newTenantApp(tenancy)
+	status, body = resolve("example.com", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "acme", body)
+}
+
+func TestResolveTokenMismatch(t *testing.T) {
+	tenancy := config.Default().Tenancy
+	tenancy.Enabled = true
+	tenancy.Resolvers = []string{"subdomain", "header", "jwt"}
+	tenancy.Domain = "example.com"
+	tenancy.JWTSecret = "secret"
+	tenancy.Tenants = map[string]config.TenantConfig{"acme": {}, "globex": {}, "initech": {}}
+	tenantApp := newTenantApp(tenancy)
+
+	resolve := func(host, tenantHeader string) int {
+		request := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		request.Header.Set(fiber.HeaderAuthorization, "Bearer "+signToken("secret", `{"tenant_id":"initech"}`))
+		request.Header.Set("X-Tenant-ID", tenantHeader)
+		response, err := tenantApp.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	assert.Equal(t, 403, resolve("example.com", "globex"))
+	assert.Equal(t, 403, resolve("acme.example.com", ""))
+	assert.Equal(t, 200, resolve("initech.example.com", "globex"))
+	assert.Equal(t, 200, resolve("example.com", "initech"))
+}
+
+func TestDisabled(t *testing.T) {
+	response, err := newTenantApp(config.Default().Tenancy).Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
@@ -0,0 +1,18 @@
+package tenant
+
+import "context"
+
+type tenantKey struct{}
+
+// With returns a copy of ctx belonging to tenant. The repositories of tenant
+// scoped models only see and create the rows of the tenant of their ctx.
+func With(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// From is the tenant of ctx, or "" when it has none, which is also the tenant
+// of everything created without tenancy.
+func From(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
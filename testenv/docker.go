@@ -0,0 +1,81 @@
+package testenv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// dockerContainer is a container started with the docker CLI, removed when
+// it stops.
+type dockerContainer struct {
+	id string
+}
+
+// startContainer runs image detached, with its exposed ports published on
+// random ports of the host, and the environment variables env.
+func startContainer(ctx context.Context, image string, env ...string) (*dockerContainer, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, errors.New("docker is not installed")
+	}
+	args := []string{"run", "--detach", "--rm", "--publish-all", "--label", "golang-fiber-web.testenv=true"}
+	for _, variable := range env {
+		args = append(args, "--env", variable)
+	}
+	output, err := docker(ctx, append(args, image)...)
+	if err != nil {
+		return nil, err
+	}
+	return &dockerContainer{id: output}, nil
+}
+
+// address is the host address the port of the container is published on,
+// such as "127.0.0.1:49153".
+func (container *dockerContainer) address(ctx context.Context, port string) (string, error) {
+	output, err := docker(ctx, "port", container.id, port)
+	if err != nil {
+		return "", err
+	}
+	// One line per address family; the first one does.
+	address, _, _ := strings.Cut(output, "\n")
+	address = strings.Replace(address, "0.0.0.0:", "127.0.0.1:", 1)
+	return strings.Replace(address, "[::]:", "[::1]:", 1), nil
+}
+
+func (container *dockerContainer) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	_, err := docker(ctx, "stop", container.id)
+	return err
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// waitFor calls ready until it succeeds or ctx is done.
+func waitFor(ctx context.Context, ready func(ctx context.Context) error) error {
+	for {
+		err := ready(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(time.Millisecond * 250):
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package testenv runs integration tests against a real Postgres and Redis:
+// started in Docker containers for the tests of a package, or the ones of
+// TESTENV_POSTGRES_DSN and TESTENV_REDIS_ADDRESS when set, such as services
+// of a CI job. The schema is migrated once, and every test gets an app wired
+// by its own container after the data of the previous test is removed and
+// the seeds inserted again.
+//
+// A package of integration tests starts the environment in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testenv.Main(m))
+//	}
+//
+// and its tests get their app from New. They are skipped when the
+// environment can't start, such as without Docker.
+package testenv
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"golang-fiber-web/cache"
+	"golang-fiber-web/config"
+	"golang-fiber-web/database"
+	"golang-fiber-web/di"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/seed"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	envPostgresDSN  = "TESTENV_POSTGRES_DSN"
+	envRedisAddress = "TESTENV_REDIS_ADDRESS"
+	postgresImage   = "postgres:16-alpine"
+	redisImage      = "redis:7-alpine"
+	startTimeout    = time.Minute * 2
+)
+
+// Env is the Postgres and the Redis of the tests.
+type Env struct {
+	PostgresDSN  string
+	RedisAddress string
+	db           *sql.DB
+	redis        *redis.Client
+	containers   []*dockerContainer
+}
+
+var (
+	shared   *Env
+	startErr error
+)
+
+// Main starts the environment, runs the tests and stops it, returning the
+// exit code of the tests.
+func Main(m *testing.M) int {
+	shared, startErr = Start(context.Background())
+	if startErr != nil {
+		fmt.Fprintln(os.Stderr, "testenv: skipping the integration tests:", startErr)
+	}
+	code := m.Run()
+	if shared != nil {
+		err := shared.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "testenv:", err)
+		}
+	}
+	return code
+}
+
+// Start starts the containers the environment variables don't replace,
+// waits until they accept connections and migrates the database.
+func Start(ctx context.Context) (*Env, error) {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	env := &Env{PostgresDSN: os.Getenv(envPostgresDSN), RedisAddress: os.Getenv(envRedisAddress)}
+	err := env.start(ctx)
+	if err != nil {
+		env.Close()
+		return nil, err
+	}
+	return env, nil
+}
+
+func (env *Env) start(ctx context.Context) error {
+	if env.PostgresDSN == "" {
+		postgres, err := startContainer(ctx, postgresImage, "POSTGRES_USER=app", "POSTGRES_PASSWORD=app", "POSTGRES_DB=app")
+		if err != nil {
+			return err
+		}
+		env.containers = append(env.containers, postgres)
+		address, err := postgres.address(ctx, "5432/tcp")
+		if err != nil {
+			return err
+		}
+		env.PostgresDSN = "postgres://app:app@" + address + "/app?sslmode=disable"
+	}
+	if env.RedisAddress == "" {
+		redisContainer, err := startContainer(ctx, redisImage)
+		if err != nil {
+			return err
+		}
+		env.containers = append(env.containers, redisContainer)
+		env.RedisAddress, err = redisContainer.address(ctx, "6379/tcp")
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	env.db, err = sql.Open("pgx", env.PostgresDSN)
+	if err != nil {
+		return err
+	}
+	err = waitFor(ctx, env.db.PingContext)
+	if err != nil {
+		return fmt.Errorf("waiting for postgres: %w", err)
+	}
+	env.redis = redis.NewClient(&redis.Options{Addr: env.RedisAddress})
+	err = waitFor(ctx, func(ctx context.Context) error {
+		return env.redis.Ping(ctx).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for redis: %w", err)
+	}
+
+	migrator, err := database.NewMigrator(env.db)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+// Config is the default config with the database and Redis of env, and
+// Redis as the cache backend.
+func (env *Env) Config() *config.Config {
+	appConfig := config.Default()
+	appConfig.Database.DSN = env.PostgresDSN
+	appConfig.Redis.Address = env.RedisAddress
+	appConfig.Cache.Backend = "redis"
+	return appConfig
+}
+
+// Reset removes every row but the migrations, and every Redis key, then
+// inserts the seeds again.
+func (env *Env) Reset(ctx context.Context) error {
+	rows, err := env.db.QueryContext(ctx, "SELECT quote_ident(table_name) FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name <> 'schema_migrations'")
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		err = rows.Scan(&table)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(tables) > 0 {
+		_, err = env.db.ExecContext(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE")
+		if err != nil {
+			return err
+		}
+	}
+	err = env.redis.FlushDB(ctx).Err()
+	if err != nil {
+		return err
+	}
+
+	cluster := database.NewCluster(config.DatabaseConfig{}, env.db)
+	repositories := repository.NewPostgresRepositories(cluster)
+	_, err = seed.Run(ctx, repositories, seed.Defaults(env.Config().Seed))
+	return err
+}
+
+// Close stops the containers.
+func (env *Env) Close() error {
+	var errs []error
+	if env.db != nil {
+		errs = append(errs, env.db.Close())
+	}
+	if env.redis != nil {
+		errs = append(errs, env.redis.Close())
+	}
+	for _, container := range env.containers {
+		errs = append(errs, container.stop())
+	}
+	return errors.Join(errs...)
+}
+
+// Harness is the app of a test and the container that wired it, for the
+// services and repositories behind it.
+type Harness struct {
+	App       *fiber.App
+	Container *di.Container
+	Store     cache.Store
+}
+
+// New resets the shared environment and wires an app on it with the config
+// edited by configure, if given. The container is closed when the test ends.
+// The test is skipped when the environment didn't start.
+func New(t testing.TB, configure ...func(appConfig *config.Config)) *Harness {
+	t.Helper()
+	if shared == nil {
+		if startErr == nil {
+			t.Skip("testenv: the environment is started by testenv.Main")
+		}
+		t.Skip("testenv:", startErr)
+	}
+
+	err := shared.Reset(context.Background())
+	if err != nil {
+		t.Fatal("testenv: resetting:", err)
+	}
+	appConfig := shared.Config()
+	for _, edit := range configure {
+		edit(appConfig)
+	}
+	container := di.New(appConfig)
+	t.Cleanup(func() {
+		container.Close()
+	})
+	app, err := container.App()
+	if err != nil {
+		t.Fatal("testenv: building the app:", err)
+	}
+	store, err := container.CacheStore()
+	if err != nil {
+		t.Fatal("testenv: cache store:", err)
+	}
+	return &Harness{App: app, Container: container, Store: store}
+}
@@ -0,0 +1,111 @@
+// Package testfactory builds the records and the requests of tests. The
+// builders fill every required field with a valid default, unique across the
+// test binary where the model wants it unique, and apply the overrides given
+// after:
+//
+//	user := testfactory.CreateUser(t, users, func(user *model.User) {
+//		user.Roles = []string{"admin"}
+//	})
+package testfactory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+var sequence atomic.Int64
+
+// next is a number no other call returns, for unique usernames and emails.
+func next() string {
+	return strconv.FormatInt(sequence.Add(1), 10)
+}
+
+func apply[T any](record *T, overrides []func(*T)) *T {
+	for _, override := range overrides {
+		override(record)
+	}
+	return record
+}
+
+// User is a user with a unique username and email.
+func User(overrides ...func(user *model.User)) *model.User {
+	n := next()
+	return apply(&model.User{
+		Username: "user" + n,
+		Email:    "user" + n + "@example.com",
+		Name:     "User " + n,
+		Roles:    []string{"user"},
+	}, overrides)
+}
+
+// Order is a pending, unpaid order of one item, whose total is computed from
+// its items after the overrides.
+func Order(overrides ...func(order *model.Order)) *model.Order {
+	order := apply(&model.Order{
+		Status:        model.OrderPending,
+		Items:         []model.OrderItem{{Name: "Item " + next(), Quantity: 1, UnitPrice: 10000}},
+		Currency:      "IDR",
+		PaymentStatus: model.PaymentUnpaid,
+	}, overrides)
+	order.SetItems(order.Items)
+	return order
+}
+
+// File is a processed text file; its size and checksum are those of content.
+func File(content string, overrides ...func(file *model.File)) *model.File {
+	n := next()
+	checksum := sha256.Sum256([]byte(content))
+	return apply(&model.File{
+		Name:        "file" + n + ".txt",
+		Size:        int64(len(content)),
+		Status:      model.FileProcessed,
+		ContentType: "text/plain; charset=utf-8",
+		Checksum:    hex.EncodeToString(checksum[:]),
+		StorageKey:  "files/file" + n + ".txt",
+		Steps:       []model.FileStep{},
+	}, overrides)
+}
+
+// CreateUser saves User(overrides...) in users, failing the test on error.
+func CreateUser(t testing.TB, users repository.UserRepository, overrides ...func(user *model.User)) *model.User {
+	t.Helper()
+	user := User(overrides...)
+	err := users.Create(context.Background(), user)
+	if err != nil {
+		t.Fatal("testfactory: creating user:", err)
+	}
+	return user
+}
+
+// CreateOrder saves Order(overrides...) of the user userID in orders.
+func CreateOrder(t testing.TB, orders repository.OrderRepository, userID string, overrides ...func(order *model.Order)) *model.Order {
+	t.Helper()
+	order := Order(append([]func(*model.Order){func(order *model.Order) {
+		order.UserID = userID
+	}}, overrides...)...)
+	err := orders.Create(context.Background(), order)
+	if err != nil {
+		t.Fatal("testfactory: creating order:", err)
+	}
+	return order
+}
+
+// CreateFile saves File(content, overrides...) of the user ownerID in files.
+// Only the record is saved, not content.
+func CreateFile(t testing.TB, files repository.FileRepository, ownerID, content string, overrides ...func(file *model.File)) *model.File {
+	t.Helper()
+	file := File(content, append([]func(*model.File){func(file *model.File) {
+		file.OwnerID = ownerID
+	}}, overrides...)...)
+	err := files.Create(context.Background(), file)
+	if err != nil {
+		t.Fatal("testfactory: creating file:", err)
+	}
+	return file
+}
@@ -0,0 +1,62 @@
+package testfactory
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"io"
+	"testing"
+)
+
+func TestFactories(t *testing.T) {
+	first, second := User(), User(func(user *model.User) {
+		user.Roles = []string{"admin"}
+	})
+	assert.NotEqual(t, first.Username, second.Username)
+	assert.NotEqual(t, first.Email, second.Email)
+	assert.Equal(t, []string{"admin"}, second.Roles)
+
+	order := Order(func(order *model.Order) {
+		order.Items = []model.OrderItem{{Name: "Book", Quantity: 3, UnitPrice: 5000}}
+	})
+	assert.Equal(t, int64(15000), order.Total)
+	assert.Equal(t, model.OrderPending, order.Status)
+
+	users := repository.NewMemoryUserRepository()
+	user := CreateUser(t, users)
+	assert.NotEmpty(t, user.ID)
+	file := CreateFile(t, repository.NewMemoryFileRepository(), user.ID, "hello")
+	assert.Equal(t, user.ID, file.OwnerID)
+	assert.Equal(t, int64(5), file.Size)
+	order = CreateOrder(t, repository.NewMemoryOrderRepository(), user.ID)
+	assert.Equal(t, user.ID, order.UserID)
+}
+
+func TestRequestBuilders(t *testing.T) {
+	app := fiber.New()
+	app.Post("/upload", func(ctx *fiber.Ctx) error {
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(ctx.FormValue("title") + ":" + file.Filename + ":" + ctx.Get(fiber.HeaderAuthorization))
+	})
+	app.Put("/me", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(string(ctx.Request().Header.ContentType()) + " " + string(ctx.Body()))
+	})
+
+	request := NewRequest(t, "POST", "/upload").
+		Bearer("token").
+		Multipart(NewMultipart().Field("title", "Notes").File("file", "notes.txt", []byte("hello"))).
+		Build()
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "Notes:notes.txt:Bearer token", string(body))
+
+	response, err = app.Test(NewRequest(t, "PUT", "/me").JSON(map[string]string{"name": "Brian"}).Build())
+	assert.Nil(t, err)
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, `application/json {"name":"Brian"}`, string(body))
+}
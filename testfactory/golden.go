@@ -0,0 +1,78 @@
+package testfactory
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// update rewrites the golden files with what the tests got instead of
+// comparing, for a change that is meant to change the output:
+//
+//	go test ./views -update
+var update = flag.Bool("update", false, "rewrite the golden files of testfactory.Golden")
+
+// Golden compares got, normalized by NormalizeHTML, to the golden file path,
+// such as "testdata/index.golden.html", and fails the test on a difference.
+// With -update it writes the file instead.
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	normalized := NormalizeHTML(string(got))
+	if *update {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, []byte(normalized), 0644)
+		}
+		if err != nil {
+			t.Fatal("testfactory: updating golden file:", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("testfactory: reading golden file: %v; run the test with -update to create it", err)
+	}
+	if string(want) != normalized {
+		t.Errorf("testfactory: %s differs from the output, run the test with -update if the change is expected:\n%s", path, diffLines(string(want), normalized))
+	}
+}
+
+// NormalizeHTML trims the lines of html and drops the blank ones, so the
+// indentation and the blank lines the template engines leave don't count.
+func NormalizeHTML(html string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(html, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// diffLines lists the first lines where want and got differ.
+func diffLines(want, got string) string {
+	const maxLines = 10
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var diff strings.Builder
+	shown := 0
+	for i := 0; i < max(len(wantLines), len(gotLines)) && shown < maxLines; i++ {
+		var wantLine, gotLine string
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if wantLine == gotLine {
+			continue
+		}
+		diff.WriteString("line " + strconv.Itoa(i+1) + ":\n  - " + wantLine + "\n  + " + gotLine + "\n")
+		shown++
+	}
+	return diff.String()
+}
@@ -0,0 +1,145 @@
+package testfactory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"golang-fiber-web/service"
+	"golang-fiber-web/token"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// RequestBuilder builds a request for fiber.App.Test step by step:
+//
+//	request := testfactory.NewRequest(t, "PUT", "/me").Bearer(accessToken).JSON(body).Build()
+type RequestBuilder struct {
+	t       testing.TB
+	method  string
+	target  string
+	body    io.Reader
+	headers http.Header
+}
+
+func NewRequest(t testing.TB, method, target string) *RequestBuilder {
+	return &RequestBuilder{t: t, method: method, target: target, headers: http.Header{}}
+}
+
+func (builder *RequestBuilder) Header(key, value string) *RequestBuilder {
+	builder.headers.Set(key, value)
+	return builder
+}
+
+// Bearer authenticates the request with an access token, such as one of
+// Login.
+func (builder *RequestBuilder) Bearer(accessToken string) *RequestBuilder {
+	return builder.Header("Authorization", "Bearer "+accessToken)
+}
+
+// BasicAuth authenticates the request as the admin of config.AdminConfig.
+func (builder *RequestBuilder) BasicAuth(username, password string) *RequestBuilder {
+	request := http.Request{Header: http.Header{}}
+	request.SetBasicAuth(username, password)
+	return builder.Header("Authorization", request.Header.Get("Authorization"))
+}
+
+// JSON sends value as the JSON body, or a string or []byte as is.
+func (builder *RequestBuilder) JSON(value any) *RequestBuilder {
+	switch body := value.(type) {
+	case string:
+		builder.body = strings.NewReader(body)
+	case []byte:
+		builder.body = bytes.NewReader(body)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			builder.t.Fatal("testfactory: encoding body:", err)
+		}
+		builder.body = bytes.NewReader(encoded)
+	}
+	return builder.Header("Content-Type", "application/json")
+}
+
+// Multipart sends the form of form as the multipart body.
+func (builder *RequestBuilder) Multipart(form *MultipartBuilder) *RequestBuilder {
+	body, contentType := form.encode(builder.t)
+	builder.body = body
+	return builder.Header("Content-Type", contentType)
+}
+
+func (builder *RequestBuilder) Build() *http.Request {
+	request := httptest.NewRequest(builder.method, builder.target, builder.body)
+	for key, values := range builder.headers {
+		request.Header[key] = values
+	}
+	return request
+}
+
+// MultipartBuilder is the fields and files of a multipart/form-data body.
+type MultipartBuilder struct {
+	parts []multipartPart
+}
+
+type multipartPart struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func NewMultipart() *MultipartBuilder {
+	return &MultipartBuilder{}
+}
+
+func (builder *MultipartBuilder) Field(name, value string) *MultipartBuilder {
+	builder.parts = append(builder.parts, multipartPart{field: name, content: []byte(value)})
+	return builder
+}
+
+func (builder *MultipartBuilder) File(field, filename string, content []byte) *MultipartBuilder {
+	builder.parts = append(builder.parts, multipartPart{field: field, filename: filename, content: content})
+	return builder
+}
+
+func (builder *MultipartBuilder) encode(t testing.TB) (io.Reader, string) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, part := range builder.parts {
+		var err error
+		if part.filename == "" {
+			err = writer.WriteField(part.field, string(part.content))
+		} else {
+			var file io.Writer
+			file, err = writer.CreateFormFile(part.field, part.filename)
+			if err == nil {
+				_, err = file.Write(part.content)
+			}
+		}
+		if err != nil {
+			t.Fatal("testfactory: writing multipart body:", err)
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		t.Fatal("testfactory: writing multipart body:", err)
+	}
+	return body, writer.FormDataContentType()
+}
+
+// Login starts a session of the user userID and returns its access token, as
+// the token endpoint would.
+func Login(t testing.TB, keys *token.Keys, sessions service.SessionService, userID string) string {
+	t.Helper()
+	session, err := sessions.Start(context.Background(), userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal("testfactory: starting session:", err)
+	}
+	accessToken, err := keys.Sign(token.Claims{"sub": userID, "sid": session.ID})
+	if err != nil {
+		t.Fatal("testfactory: signing access token:", err)
+	}
+	return accessToken
+}
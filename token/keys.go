@@ -0,0 +1,220 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/telemetry"
+	"sync"
+	"time"
+)
+
+var logger = telemetry.Logger("token")
+
+// Algorithm is the JWS algorithm of the tokens: ECDSA with P-256 and SHA-256.
+const Algorithm = "ES256"
+
+var ErrNoSigningKey = errors.New("no signing key")
+
+// reloadInterval is how often at most a token signed with an unknown key
+// makes the keys load again, so forged key IDs can't flood the repository.
+const reloadInterval = 5 * time.Second
+
+type signingKey struct {
+	id        string
+	private   *ecdsa.PrivateKey
+	createdAt time.Time
+}
+
+// Keys issues and verifies the tokens of the app with the signing keys of
+// the repository, as configured by config.JWTConfig. Every key that has not
+// expired verifies tokens and is published in the JSON Web Key Set. A new
+// key is created PublishAhead before the current one has signed for
+// RotationInterval and only signs once published for PublishAhead, so that
+// the other processes of the app and the services caching the key set know
+// it by then.
+type Keys struct {
+	config config.JWTConfig
+	keys   repository.SigningKeyRepository
+	now    func() time.Time
+
+	mutex  sync.RWMutex
+	loaded []*signingKey
+
+	reloadMutex sync.Mutex
+	reloadedAt  time.Time
+}
+
+func NewKeys(config config.JWTConfig, keys repository.SigningKeyRepository) *Keys {
+	return &Keys{config: config, keys: keys, now: time.Now}
+}
+
+// Run rotates the keys every refresh interval until ctx is done, which also
+// loads the keys the other processes of the app created.
+func (keys *Keys) Run(ctx context.Context) {
+	ticker := time.NewTicker(keys.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := keys.Rotate(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("rotating signing keys", "error", err)
+		}
+	}
+}
+
+// Rotate creates the next signing key when it is due, deletes the expired
+// keys and loads the others.
+func (keys *Keys) Rotate(ctx context.Context) error {
+	now := keys.now()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	encoded, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	key := &model.SigningKey{
+		Algorithm:  Algorithm,
+		PrivateKey: encoded,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(keys.config.PublishAhead + keys.config.RotationInterval + keys.config.TTL),
+	}
+	created, err := keys.keys.CreateIfNoneSince(ctx, key, now.Add(keys.config.PublishAhead-keys.config.RotationInterval))
+	if err != nil {
+		return err
+	}
+	if created {
+		logger.InfoContext(ctx, "created signing key", "kid", key.ID, "expires_at", key.ExpiresAt)
+	}
+	deleted, err := keys.keys.DeleteExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logger.InfoContext(ctx, "deleted expired signing keys", "count", deleted)
+	}
+	return keys.load(ctx)
+}
+
+// load replaces the loaded keys with those of the repository.
+func (keys *Keys) load(ctx context.Context) error {
+	stored, err := keys.keys.List(ctx, keys.now())
+	if err != nil {
+		return err
+	}
+	loaded := make([]*signingKey, 0, len(stored))
+	for _, key := range stored {
+		if key.Algorithm != Algorithm {
+			continue
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+		private, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return errors.New("signing key " + key.ID + " is not an ECDSA key")
+		}
+		loaded = append(loaded, &signingKey{id: key.ID, private: private, createdAt: key.CreatedAt})
+	}
+
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+	keys.loaded = loaded
+	return nil
+}
+
+// reload loads the keys again unless they were reloaded in the last
+// reloadInterval. Concurrent callers wait for the one reload in progress
+// instead of starting their own.
+func (keys *Keys) reload(ctx context.Context) error {
+	keys.reloadMutex.Lock()
+	defer keys.reloadMutex.Unlock()
+
+	now := keys.now()
+	if now.Sub(keys.reloadedAt) < reloadInterval {
+		return nil
+	}
+	keys.reloadedAt = now
+	return keys.load(ctx)
+}
+
+// signing returns the newest key published for PublishAhead, or the oldest
+// key when none has been published that long yet, as after the first start.
+func (keys *Keys) signing() (*signingKey, error) {
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	if len(keys.loaded) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	publishedBefore := keys.now().Add(-keys.config.PublishAhead)
+	for _, key := range keys.loaded {
+		if !key.createdAt.After(publishedBefore) {
+			return key, nil
+		}
+	}
+	return keys.loaded[len(keys.loaded)-1], nil
+}
+
+func (keys *Keys) find(id string) *signingKey {
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	for _, key := range keys.loaded {
+		if key.id == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// JSONWebKey is the public part of a signing key, as published in the JSON
+// Web Key Set.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys verifying the tokens, newest first.
+func (keys *Keys) JWKS() JSONWebKeySet {
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, len(keys.loaded))}
+	for i, key := range keys.loaded {
+		public := key.private.PublicKey
+		set.Keys[i] = JSONWebKey{
+			KeyType:   "EC",
+			Use:       "sig",
+			Algorithm: Algorithm,
+			KeyID:     key.id,
+			Curve:     "P-256",
+			X:         base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32))),
+			Y:         base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32))),
+		}
+	}
+	return set
+}
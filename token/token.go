@@ -0,0 +1,137 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalid = errors.New("invalid token")
+	ErrExpired = errors.New("token expired")
+)
+
+// Claims are the claims of a token, decoded from JSON.
+type Claims map[string]interface{}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid"`
+}
+
+// Sign issues a JSON Web Token with claims, signed with the current signing
+// key, whose ID is the kid of its header. The iss, iat and exp claims are
+// set from the config.
+func (keys *Keys) Sign(claims Claims) (string, error) {
+	key, err := keys.signing()
+	if err != nil {
+		return "", err
+	}
+
+	now := keys.now()
+	payload := Claims{"iat": now.Unix(), "exp": now.Add(keys.config.TTL).Unix()}
+	if keys.config.Issuer != "" {
+		payload["iss"] = keys.config.Issuer
+	}
+	for name, value := range claims {
+		payload[name] = value
+	}
+	encodedHeader, err := encodeSegment(header{Algorithm: Algorithm, Type: "JWT", KeyID: key.id})
+	if err != nil {
+		return "", err
+	}
+	encodedPayload, err := encodeSegment(payload)
+	if err != nil {
+		return "", err
+	}
+
+	signed := encodedHeader + "." + encodedPayload
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify returns the claims of token once its signature, by one of the keys,
+// its expiry and its issuer are verified. A key ID that is not loaded yet
+// makes the keys load again, as another process of the app may have just
+// created it, at most once every few seconds.
+func (keys *Keys) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+	var tokenHeader header
+	if decodeSegment(parts[0], &tokenHeader) != nil || tokenHeader.Algorithm != Algorithm {
+		return nil, ErrInvalid
+	}
+	key := keys.find(tokenHeader.KeyID)
+	if key == nil {
+		if err := keys.reload(ctx); err != nil {
+			return nil, err
+		}
+		if key = keys.find(tokenHeader.KeyID); key == nil {
+			return nil, ErrInvalid
+		}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return nil, ErrInvalid
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&key.private.PublicKey, digest[:], r, s) {
+		return nil, ErrInvalid
+	}
+
+	var claims Claims
+	if decodeSegment(parts[1], &claims) != nil {
+		return nil, ErrInvalid
+	}
+	now := keys.now().Unix()
+	if expiry, ok := claims["exp"].(float64); !ok || now >= int64(expiry) {
+		return nil, ErrExpired
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now < int64(notBefore) {
+		return nil, ErrInvalid
+	}
+	if issuer := keys.config.Issuer; issuer != "" && claims["iss"] != issuer {
+		return nil, ErrInvalid
+	}
+	return claims, nil
+}
+
+// TTL is how long the tokens are valid.
+func (keys *Keys) TTL() time.Duration {
+	return keys.config.TTL
+}
+
+func encodeSegment(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
@@ -0,0 +1,186 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testConfig = config.JWTConfig{
+	Issuer:           "https://app.example.com",
+	TTL:              time.Minute * 15,
+	RotationInterval: time.Hour * 24,
+	PublishAhead:     time.Hour,
+	RefreshInterval:  time.Minute,
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (clock *clock) Now() time.Time {
+	return clock.now
+}
+
+func newTestKeys(t *testing.T, repository repository.SigningKeyRepository, clock *clock) *Keys {
+	keys := NewKeys(testConfig, repository)
+	keys.now = clock.Now
+	assert.Nil(t, keys.Rotate(context.Background()))
+	return keys
+}
+
+func TestSignAndVerify(t *testing.T) {
+	clock := &clock{now: time.Now()}
+	keys := newTestKeys(t, repository.NewMemorySigningKeyRepository(), clock)
+
+	signed, err := keys.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+	claims, err := keys.Verify(context.Background(), signed)
+	assert.Nil(t, err)
+	assert.Equal(t, "42", claims["sub"])
+	assert.Equal(t, testConfig.Issuer, claims["iss"])
+
+	var tokenHeader header
+	assert.Nil(t, decodeSegment(strings.Split(signed, ".")[0], &tokenHeader))
+	assert.Equal(t, "ES256", tokenHeader.Algorithm)
+	assert.Equal(t, keys.JWKS().Keys[0].KeyID, tokenHeader.KeyID)
+
+	parts := strings.Split(signed, ".")
+	forged, _ := encodeSegment(Claims{"sub": "1", "iss": testConfig.Issuer, "exp": clock.now.Add(time.Hour).Unix()})
+	_, err = keys.Verify(context.Background(), parts[0]+"."+forged+"."+parts[2])
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	clock.now = clock.now.Add(testConfig.TTL)
+	_, err = keys.Verify(context.Background(), signed)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestRotation(t *testing.T) {
+	clock := &clock{now: time.Now()}
+	keys := newTestKeys(t, repository.NewMemorySigningKeyRepository(), clock)
+	first := keys.JWKS().Keys[0].KeyID
+	signed, err := keys.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+
+	// Not due yet.
+	clock.now = clock.now.Add(testConfig.RotationInterval - testConfig.PublishAhead - time.Minute)
+	assert.Nil(t, keys.Rotate(context.Background()))
+	assert.Len(t, keys.JWKS().Keys, 1)
+
+	// The next key is published ahead, while the first one still signs.
+	clock.now = clock.now.Add(time.Minute * 2)
+	assert.Nil(t, keys.Rotate(context.Background()))
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	second := jwks.Keys[0].KeyID
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, signingKeyID(t, keys))
+
+	// Then it signs, and the tokens of the first one still verify.
+	clock.now = clock.now.Add(testConfig.PublishAhead)
+	assert.Nil(t, keys.Rotate(context.Background()))
+	assert.Equal(t, second, signingKeyID(t, keys))
+	renewed, err := keys.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+	_, err = keys.Verify(context.Background(), renewed)
+	assert.Nil(t, err)
+
+	// The first key expires once the last tokens it may have signed did.
+	clock.now = clock.now.Add(testConfig.PublishAhead + testConfig.TTL)
+	assert.Nil(t, keys.Rotate(context.Background()))
+	assert.Len(t, keys.JWKS().Keys, 1)
+	_, err = keys.Verify(context.Background(), signed)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestVerifyLoadsKeysOfOtherProcesses(t *testing.T) {
+	clock := &clock{now: time.Now()}
+	signingKeys := repository.NewMemorySigningKeyRepository()
+	keys := newTestKeys(t, signingKeys, clock)
+	other := newTestKeys(t, repository.NewMemorySigningKeyRepository(), clock)
+	signed, err := other.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+	_, err = keys.Verify(context.Background(), signed)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	shared := newTestKeys(t, signingKeys, clock)
+	assert.Equal(t, keys.JWKS(), shared.JWKS())
+	signed, err = shared.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+	_, err = keys.Verify(context.Background(), signed)
+	assert.Nil(t, err)
+}
+
+// countingSigningKeys counts how often the keys are listed.
+type countingSigningKeys struct {
+	repository.SigningKeyRepository
+	lists atomic.Int32
+}
+
+func (keys *countingSigningKeys) List(ctx context.Context, now time.Time) ([]*model.SigningKey, error) {
+	keys.lists.Add(1)
+	return keys.SigningKeyRepository.List(ctx, now)
+}
+
+func TestVerifyRateLimitsReloads(t *testing.T) {
+	clock := &clock{now: time.Now()}
+	signingKeys := &countingSigningKeys{SigningKeyRepository: repository.NewMemorySigningKeyRepository()}
+	keys := newTestKeys(t, signingKeys, clock)
+	other := newTestKeys(t, repository.NewMemorySigningKeyRepository(), clock)
+	signed, err := other.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+	signingKeys.lists.Store(0)
+
+	var wait sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			_, err := keys.Verify(context.Background(), signed)
+			assert.ErrorIs(t, err, ErrInvalid)
+		}()
+	}
+	wait.Wait()
+	assert.Equal(t, int32(1), signingKeys.lists.Load())
+
+	clock.now = clock.now.Add(reloadInterval)
+	_, err = keys.Verify(context.Background(), signed)
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.Equal(t, int32(2), signingKeys.lists.Load())
+}
+
+func TestJWKSVerifiesTokens(t *testing.T) {
+	keys := newTestKeys(t, repository.NewMemorySigningKeyRepository(), &clock{now: time.Now()})
+	signed, err := keys.Sign(Claims{"sub": "42"})
+	assert.Nil(t, err)
+
+	jwk := keys.JWKS().Keys[0]
+	assert.Equal(t, "EC", jwk.KeyType)
+	assert.Equal(t, "P-256", jwk.Curve)
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+	public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+
+	parts := strings.Split(signed, ".")
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.True(t, ecdsa.Verify(public, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])))
+}
+
+func signingKeyID(t *testing.T, keys *Keys) string {
+	key, err := keys.signing()
+	assert.Nil(t, err)
+	return key.id
+}
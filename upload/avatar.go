@@ -0,0 +1,58 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+)
+
+var (
+	ErrNotImage      = errors.New("not a GIF, JPEG or PNG image")
+	ErrImageTooLarge = errors.New("image too large")
+)
+
+// Avatar crops the GIF, JPEG or PNG image of content to squares of every
+// one of sizes, encoded as PNG. Images wider or taller than maxDimension
+// pixels fail with ErrImageTooLarge before they are decoded.
+func Avatar(content io.Reader, sizes []int, maxDimension int) (map[int][]byte, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	source, err := decode(bytes.NewReader(data), maxDimension)
+	if errors.Is(err, ErrImageTooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	avatars := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		var avatar bytes.Buffer
+		err = png.Encode(&avatar, Cover(source, size, size))
+		if err != nil {
+			return nil, err
+		}
+		avatars[size] = avatar.Bytes()
+	}
+	return avatars, nil
+}
+
+// decode decodes the image of content, reading only its header first so an
+// image wider or taller than maxDimension pixels fails with ErrImageTooLarge
+// before its pixels are allocated.
+func decode(content io.Reader, maxDimension int) (image.Image, error) {
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(content, &header))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, ErrImageTooLarge
+	}
+	source, _, err := image.Decode(io.MultiReader(&header, content))
+	return source, err
+}
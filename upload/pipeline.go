@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"golang-fiber-web/telemetry"
+	"path/filepath"
+)
+
+var logger = telemetry.Logger("uploads")
+
+// ErrSkipped is returned by a step that does not apply to a file, such as
+// the thumbnail of a text file.
+var ErrSkipped = errors.New("step skipped")
+
+// Step is a stage of the pipeline. Run processes the file stored at path
+// and records what it learns about it in file.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, path string, file *model.File) error
+}
+
+// Pipeline processes the files once they are uploaded, running its steps in
+// order and saving the status of the file after each of them so it can be
+// followed while it runs. A failed step stops the pipeline and fails the
+// file.
+type Pipeline struct {
+	files repository.FileRepository
+	dir   string
+	steps []Step
+}
+
+func NewPipeline(files repository.FileRepository, dir string, steps ...Step) *Pipeline {
+	return &Pipeline{files: files, dir: dir, steps: steps}
+}
+
+// Steps returns the steps of the pipeline configured by config: the
+// checksum, the check of the content type, the virus scan when a command is
+// configured and the thumbnail of the images, written to storage.
+func Steps(config config.UploadConfig, storage storage.Storage) []Step {
+	steps := []Step{Checksum(), VerifyContentType()}
+	if len(config.ScanCommand) > 0 {
+		steps = append(steps, Scan(config.ScanCommand))
+	}
+	return append(steps, Thumbnail(storage, config.ThumbnailSize, config.ImageMaxDimension))
+}
+
+// Handle is the event.Handler processing the file of an event.FileUploaded.
+func (pipeline *Pipeline) Handle(ctx context.Context, published event.Event) error {
+	return pipeline.Process(ctx, published.(event.FileUploaded).FileID)
+}
+
+// Process runs the pipeline on the uploaded file id, which is skipped when
+// it was deleted in the meantime. Only the errors saving the status are
+// returned; those of the steps are recorded in the file.
+func (pipeline *Pipeline) Process(ctx context.Context, id string) error {
+	file, err := pipeline.files.FindByID(ctx, id)
+	if errors.Is(err, model.ErrFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	file.Status = model.FileProcessing
+	file.Steps = nil
+	err = pipeline.files.Update(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(pipeline.dir, filepath.FromSlash(file.StorageKey))
+	for _, step := range pipeline.steps {
+		err := step.Run(ctx, path, file)
+		switch {
+		case err == nil:
+			file.Steps = append(file.Steps, model.FileStep{Name: step.Name, Status: model.StepSucceeded})
+		case errors.Is(err, ErrSkipped):
+			file.Steps = append(file.Steps, model.FileStep{Name: step.Name, Status: model.StepSkipped})
+		default:
+			logger.WarnContext(ctx, "upload processing failed", "file", file.ID, "step", step.Name, "error", err)
+			file.Steps = append(file.Steps, model.FileStep{Name: step.Name, Status: model.StepFailed, Error: err.Error()})
+			file.Status = model.FileFailed
+			return pipeline.files.Update(ctx, file)
+		}
+		err = pipeline.files.Update(ctx, file)
+		if err != nil {
+			return err
+		}
+	}
+	file.Status = model.FileProcessed
+	return pipeline.files.Update(ctx, file)
+}
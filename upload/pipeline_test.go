@@ -0,0 +1,101 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/storage"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createFile(t *testing.T, files repository.FileRepository, dir, name string, content []byte) *model.File {
+	file := &model.File{OwnerID: "1", Name: name, Size: int64(len(content)), Status: model.FilePending}
+	assert.Nil(t, files.Create(context.Background(), file))
+	file.StorageKey = "files/" + file.ID
+	assert.Nil(t, files.Update(context.Background(), file))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "files"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "files", file.ID), content, 0644))
+	return file
+}
+
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	files := repository.NewMemoryFileRepository()
+	pipeline := NewPipeline(files, dir, Steps(config.UploadConfig{ThumbnailSize: 32, ImageMaxDimension: 100}, storage.NewLocal(dir))...)
+	ctx := context.Background()
+
+	var content bytes.Buffer
+	assert.Nil(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 100, 50))))
+	photo := createFile(t, files, dir, "photo.png", content.Bytes())
+	assert.Nil(t, pipeline.Handle(ctx, event.FileUploaded{FileID: photo.ID, FileName: "photo.png"}))
+	file, err := files.FindByID(ctx, photo.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FileProcessed, file.Status)
+	assert.Equal(t, "image/png", file.ContentType)
+	assert.Len(t, file.Checksum, 64)
+	assert.Equal(t, ".thumbnails/"+photo.ID+"/thumbnail.png", file.Thumbnail)
+	thumbnail, err := os.Open(filepath.Join(dir, filepath.FromSlash(file.Thumbnail)))
+	assert.Nil(t, err)
+	defer thumbnail.Close()
+	decoded, err := png.DecodeConfig(thumbnail)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{32, 16}, []int{decoded.Width, decoded.Height})
+
+	notes := createFile(t, files, dir, "notes.txt", []byte("hello"))
+	assert.Nil(t, pipeline.Process(ctx, notes.ID))
+	file, err = files.FindByID(ctx, notes.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FileProcessed, file.Status)
+	assert.Equal(t, "text/plain", file.ContentType)
+	assert.Equal(t, model.StepSkipped, file.Steps[2].Status)
+	assert.Empty(t, file.Thumbnail)
+
+	fake := createFile(t, files, dir, "fake.png", []byte("not an image"))
+	assert.Nil(t, pipeline.Process(ctx, fake.ID))
+	file, err = files.FindByID(ctx, fake.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FileFailed, file.Status)
+	assert.Len(t, file.Steps, 2)
+	assert.Equal(t, model.FileStep{Name: "content_type", Status: model.StepFailed, Error: "content is text/plain, not the image/png of its extension"}, file.Steps[1])
+
+	content.Reset()
+	assert.Nil(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 101, 10))))
+	panorama := createFile(t, files, dir, "panorama.png", content.Bytes())
+	assert.Nil(t, pipeline.Process(ctx, panorama.ID))
+	file, err = files.FindByID(ctx, panorama.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FileFailed, file.Status)
+	assert.Equal(t, model.FileStep{Name: "thumbnail", Status: model.StepFailed, Error: ErrImageTooLarge.Error()}, file.Steps[2])
+
+	assert.Nil(t, pipeline.Process(ctx, "deleted"))
+}
+
+func TestPipelineScan(t *testing.T) {
+	dir := t.TempDir()
+	files := repository.NewMemoryFileRepository()
+	ctx := context.Background()
+	notes := createFile(t, files, dir, "notes.txt", []byte("hello"))
+	path := filepath.Join(dir, "files", notes.ID)
+
+	clean := NewPipeline(files, dir, Scan([]string{"sh", "-c", "exit 0", "scan"}))
+	assert.Nil(t, clean.Process(ctx, notes.ID))
+	file, err := files.FindByID(ctx, notes.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FileProcessed, file.Status)
+
+	infected := NewPipeline(files, dir, Scan([]string{"sh", "-c", `echo "$1: Eicar FOUND"; exit 1`, "scan"}))
+	assert.Nil(t, infected.Process(ctx, notes.ID))
+	file, err = files.FindByID(ctx, notes.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.FileFailed, file.Status)
+	assert.Equal(t, "infected: "+path+": Eicar FOUND", file.Steps[0].Error)
+	assert.NoFileExists(t, path)
+}
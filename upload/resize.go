@@ -0,0 +1,63 @@
+package upload
+
+import (
+	"image"
+	"image/color"
+)
+
+// Fit scales source down to fit in width by height pixels, keeping its
+// aspect ratio. Images that already fit are returned as they are.
+func Fit(source image.Image, width, height int) image.Image {
+	bounds := source.Bounds()
+	if bounds.Dx() <= width && bounds.Dy() <= height {
+		return source
+	}
+	if bounds.Dx()*height > bounds.Dy()*width {
+		height = max(1, bounds.Dy()*width/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*height/bounds.Dy())
+	}
+	return resize(source, bounds, width, height)
+}
+
+// Cover scales source to cover width by height pixels, keeping its aspect
+// ratio, and crops the middle of the result to exactly that size.
+func Cover(source image.Image, width, height int) image.Image {
+	area := source.Bounds()
+	if area.Dx()*height > area.Dy()*width {
+		cropped := area.Dy() * width / height
+		area.Min.X += (area.Dx() - cropped) / 2
+		area.Max.X = area.Min.X + cropped
+	} else {
+		cropped := area.Dx() * height / width
+		area.Min.Y += (area.Dy() - cropped) / 2
+		area.Max.Y = area.Min.Y + cropped
+	}
+	return resize(source, area, width, height)
+}
+
+// resize scales the area of source to width by height pixels. Every pixel of
+// the result averages the pixels of source it covers, or repeats the one it
+// falls on when enlarging.
+func resize(source image.Image, area image.Rectangle, width, height int) image.Image {
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top := area.Min.Y + y*area.Dy()/height
+		bottom := max(top+1, area.Min.Y+(y+1)*area.Dy()/height)
+		for x := 0; x < width; x++ {
+			left := area.Min.X + x*area.Dx()/width
+			right := max(left+1, area.Min.X+(x+1)*area.Dx()/width)
+
+			var r, g, b, a, count uint64
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := source.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			result.Set(x, y, color.RGBA64{R: uint16(r / count), G: uint16(g / count), B: uint16(b / count), A: uint16(a / count)})
+		}
+	}
+	return result
+}
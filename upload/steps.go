@@ -0,0 +1,149 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang-fiber-web/model"
+	"golang-fiber-web/storage"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ThumbnailPrefix is the storage prefix of the thumbnails of the file id.
+func ThumbnailPrefix(id string) string {
+	return ".thumbnails/" + id
+}
+
+// Checksum records the hex SHA-256 of the file.
+func Checksum() Step {
+	return Step{Name: "checksum", Run: func(ctx context.Context, path string, file *model.File) error {
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+
+		hash := sha256.New()
+		_, err = io.Copy(hash, content)
+		if err != nil {
+			return err
+		}
+		file.Checksum = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	}}
+}
+
+// VerifyContentType records the content type sniffed from the content of
+// the file, and fails when its extension names a type that can be sniffed,
+// such as an image or a PDF, which the content does not have.
+func VerifyContentType() Step {
+	return Step{Name: "content_type", Run: func(ctx context.Context, path string, file *model.File) error {
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+
+		head := make([]byte, 512)
+		n, err := io.ReadFull(content, head)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return err
+		}
+		detected := mediaType(http.DetectContentType(head[:n]))
+		file.ContentType = detected
+
+		expected := mediaType(mime.TypeByExtension(filepath.Ext(file.Name)))
+		if expected != "" && expected != detected && sniffable(expected) {
+			return fmt.Errorf("content is %s, not the %s of its extension", detected, expected)
+		}
+		return nil
+	}}
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType
+}
+
+// sniffable tells whether http.DetectContentType recognizes the content of
+// mediaType, so a file of another type is not what its name claims.
+func sniffable(mediaType string) bool {
+	for _, prefix := range []string{"image/", "audio/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	switch mediaType {
+	case "application/pdf", "application/zip", "application/x-gzip", "application/wasm":
+		return true
+	}
+	return false
+}
+
+// Scan runs command with the path of the file appended to scan it for
+// viruses. An infected file, for which the command exits with 1, is removed.
+func Scan(command []string) Step {
+	return Step{Name: "scan", Run: func(ctx context.Context, path string, file *model.File) error {
+		output, err := exec.CommandContext(ctx, command[0], append(command[1:], path)...).CombinedOutput()
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 1 {
+			removeErr := os.Remove(path)
+			if removeErr != nil {
+				return fmt.Errorf("infected file not removed: %w", removeErr)
+			}
+			return fmt.Errorf("infected: %s", strings.TrimSpace(string(output)))
+		}
+		if err != nil {
+			return fmt.Errorf("scan: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}}
+}
+
+// Thumbnail writes a PNG thumbnail of the GIF, JPEG and PNG images, fitting
+// in a square of size pixels, to storage. Other files are skipped, and
+// images wider or taller than maxDimension pixels fail with ErrImageTooLarge.
+func Thumbnail(storage storage.Storage, size, maxDimension int) Step {
+	return Step{Name: "thumbnail", Run: func(ctx context.Context, path string, file *model.File) error {
+		switch file.ContentType {
+		case "image/gif", "image/jpeg", "image/png":
+		default:
+			return ErrSkipped
+		}
+
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		source, err := decode(content, maxDimension)
+		if err != nil {
+			return err
+		}
+
+		var thumbnail bytes.Buffer
+		err = png.Encode(&thumbnail, Fit(source, size, size))
+		if err != nil {
+			return err
+		}
+		key := ThumbnailPrefix(file.ID) + "/thumbnail.png"
+		err = storage.Put(ctx, key, &thumbnail)
+		if err != nil {
+			return err
+		}
+		file.Thumbnail = key
+		return nil
+	}}
+}
@@ -0,0 +1,131 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"golang-fiber-web/model"
+	"golang-fiber-web/storage"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+	"sync"
+)
+
+// Format is an image format the thumbnails can be encoded in.
+type Format struct {
+	ContentType string
+	Extension   string
+	Encode      func(writer io.Writer, image image.Image) error
+}
+
+var (
+	formatsMutex sync.RWMutex
+	formats      = []Format{
+		{ContentType: "image/jpeg", Extension: "jpg", Encode: func(writer io.Writer, image image.Image) error {
+			return jpeg.Encode(writer, image, &jpeg.Options{Quality: 85})
+		}},
+		{ContentType: "image/png", Extension: "png", Encode: png.Encode},
+	}
+)
+
+// RegisterFormat adds format to the formats the thumbnails are offered in,
+// preferred to the formats registered before it. JPEG and PNG are built in;
+// WebP and AVIF have no encoder in the standard library and are offered once
+// one is registered.
+func RegisterFormat(format Format) {
+	formatsMutex.Lock()
+	defer formatsMutex.Unlock()
+
+	formats = append([]Format{format}, formats...)
+}
+
+// Formats returns the formats the thumbnails are offered in, preferred
+// first.
+func Formats() []Format {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	return append([]Format(nil), formats...)
+}
+
+// Resize is how a thumbnail is sized: to exactly Width by Height pixels,
+// cropping what goes beyond, when Crop is set and both are given, and to fit
+// in them keeping the aspect ratio otherwise. A zero Width or Height does
+// not limit that dimension.
+type Resize struct {
+	Width  int
+	Height int
+	Crop   bool
+}
+
+func (resize Resize) apply(source image.Image) image.Image {
+	if resize.Crop && resize.Width > 0 && resize.Height > 0 {
+		return Cover(source, resize.Width, resize.Height)
+	}
+	width, height := resize.Width, resize.Height
+	if width == 0 {
+		width = source.Bounds().Dx()
+	}
+	if height == 0 {
+		height = source.Bounds().Dy()
+	}
+	return Fit(source, width, height)
+}
+
+func (resize Resize) key() string {
+	key := strconv.Itoa(resize.Width) + "x" + strconv.Itoa(resize.Height)
+	if resize.Crop {
+		key += "-crop"
+	}
+	return key
+}
+
+// Thumbnailer makes the thumbnails of the uploaded images on demand. Every
+// thumbnail is made once and cached in the storage with the other
+// thumbnails of the image, under a key with its checksum. Images wider or
+// taller than maxDimension pixels are not decoded.
+type Thumbnailer struct {
+	storage      storage.Storage
+	maxDimension int
+}
+
+func NewThumbnailer(storage storage.Storage, maxDimension int) *Thumbnailer {
+	return &Thumbnailer{storage: storage, maxDimension: maxDimension}
+}
+
+// Thumbnail returns the thumbnail of file sized by resize and encoded in
+// format, or ErrImageTooLarge for an image over the maximum dimension.
+func (thumbnailer *Thumbnailer) Thumbnail(ctx context.Context, file *model.File, resize Resize, format Format) (io.ReadCloser, error) {
+	key := path.Join(ThumbnailPrefix(file.ID), file.Checksum, resize.key()+"."+format.Extension)
+	cached, err := thumbnailer.storage.Open(ctx, key)
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	content, err := thumbnailer.storage.Open(ctx, file.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	source, err := decode(content, thumbnailer.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+	var thumbnail bytes.Buffer
+	err = format.Encode(&thumbnail, resize.apply(source))
+	if err != nil {
+		return nil, err
+	}
+	err = thumbnailer.storage.Put(ctx, key, bytes.NewReader(thumbnail.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&thumbnail), nil
+}
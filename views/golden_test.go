@@ -0,0 +1,108 @@
+package views
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/i18n"
+	"golang-fiber-web/testfactory"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// goldenAssets stand for the fingerprinted URLs of the assets, which change
+// with every edit of the assets.
+var goldenAssets = map[string]string{
+	"css/app.css": "/static/css/app.golden.css",
+	"js/app.js":   "/static/js/app.golden.js",
+}
+
+// goldenPages are the pages rendered with the data their handlers give them.
+var goldenPages = []struct {
+	name string
+	data fiber.Map
+}{
+	{"index", fiber.Map{"title": "Home", "header": "Welcome", "content": "<b>escaped</b>"}},
+	{"error", fiber.Map{"title": "Not Found", "status": 404, "detail": "no user 42"}},
+	{"contact", fiber.Map{
+		"title":     "Contact us",
+		"action":    "/contact",
+		"name":      "Brian",
+		"email":     "not-an-email",
+		"subject":   "Hello",
+		"message":   "Hi <there>",
+		"errors":    []fiber.Map{{"field": "email", "message": "must be a valid email"}},
+		"hasErrors": true,
+		"captcha":   fiber.Map{"script": "https://captcha.example.com/api.js", "class": "captcha", "siteKey": "site-key"},
+	}},
+	{"contact_sent", fiber.Map{"title": "Message sent", "detail": "Thanks, Brian"}},
+	{"admin/dashboard", fiber.Map{
+		"title":         "Admin",
+		"users":         3,
+		"outboxPending": 1,
+		"deliveries":    []fiber.Map{{"status": "failed", "count": 2}, {"status": "succeeded", "count": 5}},
+		"events":        []fiber.Map{{"created_at": "2024-01-02T03:04:05Z", "actor": "admin", "action": "update", "resource": "user", "resource_id": "42"}},
+	}},
+	{"admin/users", fiber.Map{
+		"title":      "Users",
+		"q":          "bri",
+		"users":      []fiber.Map{{"id": "42", "username": "brian", "email": "brian@example.com", "name": "Brian", "created_at": "2024-01-02T03:04:05Z"}},
+		"total":      21,
+		"page":       2,
+		"totalPages": 3,
+		"prevURL":    "/admin/users?page=1",
+		"nextURL":    "/admin/users?page=3",
+	}},
+	{"admin/config", fiber.Map{"title": "Config", "config": "server:\n  address: :3000\n"}},
+	{"admin/analytics", fiber.Map{
+		"title":     "Analytics",
+		"from":      "2024-01-01T00:00:00Z",
+		"to":        "2024-01-02T00:00:00Z",
+		"routes":    []fiber.Map{{"name": "GET /users", "requests": 10, "error_rate": "10.0%", "client_error_rate": "0.0%", "average_latency": "10ms", "max_latency": "20ms"}},
+		"consumers": []fiber.Map{},
+		"trend":     []fiber.Map{{"bucket": "2024-01-01T00:00:00Z", "requests": 10, "error_rate": "10.0%"}},
+	}},
+}
+
+// TestGoldenPages renders every page in both engines and compares it to its
+// golden file in testdata; run it with -update after changing a template.
+func TestGoldenPages(t *testing.T) {
+	bundle, err := i18n.Load("../locales", "en")
+	assert.Nil(t, err)
+
+	for _, engine := range []string{"mustache", "html"} {
+		views, err := New(config.ServerConfig{ViewsEngine: engine, ViewsEmbedded: true})
+		assert.Nil(t, err)
+		viewApp := fiber.New(fiber.Config{Views: views, ViewsLayout: "layouts/main", PassLocalsToViews: true})
+		viewApp.Use(i18n.New(bundle))
+		viewApp.Use(func(ctx *fiber.Ctx) error {
+			ctx.Locals("branding", config.BrandingConfig{Name: "Acme", PrimaryColor: "#d00"})
+			ctx.Locals("cspNonce", "golden-nonce")
+			ctx.Locals("assets", goldenAssets)
+			ctx.Locals("asset", func(text string, render func(string) (string, error)) (string, error) {
+				name, err := render(text)
+				return goldenAssets[strings.TrimSpace(name)], err
+			})
+			return ctx.Next()
+		})
+
+		for _, page := range goldenPages {
+			t.Run(engine+"/"+page.name, func(t *testing.T) {
+				viewApp.Get("/"+page.name, func(ctx *fiber.Ctx) error {
+					return ctx.Render(page.name, page.data)
+				})
+
+				response, err := viewApp.Test(httptest.NewRequest(http.MethodGet, "/"+page.name, nil))
+				assert.Nil(t, err)
+				assert.Equal(t, 200, response.StatusCode)
+				body, err := io.ReadAll(response.Body)
+				assert.Nil(t, err)
+				testfactory.Golden(t, filepath.Join("testdata", engine, page.name+".golden.html"), body)
+			})
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package views
+
+import (
+	"errors"
+	"github.com/fsnotify/fsnotify"
+	"github.com/gofiber/fiber/v2"
+	core "github.com/gofiber/template"
+	"github.com/gofiber/template/html/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"golang-fiber-web/telemetry"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var logger = telemetry.Logger("views")
+
+// Watcher marks the templates of an engine built by New stale whenever a file
+// of its directory changes, so the next render loads them again and they can
+// be edited without restarting the server.
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+func Watch(engine fiber.Views, dir string) (*Watcher, error) {
+	var base *core.Engine
+	switch engine := engine.(type) {
+	case *mustache.Engine:
+		base = &engine.Engine
+	case *html.Engine:
+		base = &engine.Engine
+	default:
+		return nil, errors.New("views: cannot watch the templates of this engine")
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// fsnotify does not watch subdirectories, so each one is added.
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		return fsWatcher.Add(path)
+	})
+	if err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	watcher := &Watcher{watcher: fsWatcher, done: make(chan struct{})}
+	go watcher.run(base)
+	return watcher, nil
+}
+
+func (watcher *Watcher) run(engine *core.Engine) {
+	defer close(watcher.done)
+	for {
+		select {
+		case change, ok := <-watcher.watcher.Events:
+			if !ok {
+				return
+			}
+			if change.Op == fsnotify.Chmod {
+				continue
+			}
+			if change.Has(fsnotify.Create) {
+				if info, err := os.Stat(change.Name); err == nil && info.IsDir() {
+					watcher.watcher.Add(change.Name)
+				}
+			}
+			engine.Mutex.Lock()
+			engine.Loaded = false
+			engine.Mutex.Unlock()
+			logger.Info("reloading templates", "changed", change.Name)
+		case err, ok := <-watcher.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("watching templates", "error", err)
+		}
+	}
+}
+
+func (watcher *Watcher) Close() error {
+	err := watcher.watcher.Close()
+	<-watcher.done
+	return err
+}
@@ -0,0 +1,44 @@
+package views
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchReloadsChangedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "partials"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "index.mustache"), []byte(`{{> partials/name}}`), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "partials", "name.mustache"), []byte(`Hello {{name}}`), 0644))
+
+	engine, err := New(config.ServerConfig{Views: dir})
+	assert.Nil(t, err)
+	watcher, err := Watch(engine, dir)
+	assert.Nil(t, err)
+	defer watcher.Close()
+
+	render := func() string {
+		var out bytes.Buffer
+		err := engine.Render(&out, "index", map[string]interface{}{"name": "Brian"})
+		if err != nil {
+			return err.Error()
+		}
+		return out.String()
+	}
+	assert.Equal(t, "Hello Brian", render())
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "partials", "name.mustache"), []byte(`Halo {{name}}`), 0644))
+	assert.Eventually(t, func() bool {
+		return render() == "Halo Brian"
+	}, time.Second*2, time.Millisecond*10)
+}
+
+func TestWatchUnknownEngine(t *testing.T) {
+	_, err := Watch(nil, t.TempDir())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,42 @@
+package views
+
+import (
+	"embed"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/html/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"golang-fiber-web/config"
+	"net/http"
+)
+
+// FS holds the templates of this directory so a single binary can serve them.
+//
+//go:embed *.mustache *.html admin layouts partials
+var FS embed.FS
+
+// New builds the view engine of config.ViewsEngine, "mustache" or "html",
+// over the config.Views directory or, with config.ViewsEmbedded, over FS.
+// It returns nil when views are disabled. Templates are named after their
+// path without the extension, such as "layouts/main", which is also how
+// partials are included.
+func New(config config.ServerConfig) (fiber.Views, error) {
+	var fileSystem http.FileSystem
+	switch {
+	case config.ViewsEmbedded:
+		fileSystem = http.FS(FS)
+	case config.Views != "":
+		fileSystem = http.Dir(config.Views)
+	default:
+		return nil, nil
+	}
+
+	switch config.ViewsEngine {
+	case "", "mustache":
+		return mustache.NewFileSystem(fileSystem, ".mustache"), nil
+	case "html":
+		return html.NewFileSystem(fileSystem, ".html"), nil
+	default:
+		return nil, errors.New("unknown views engine " + config.ViewsEngine)
+	}
+}
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnginesRenderLayoutAndPartials(t *testing.T) {
+	for _, engine := range []string{"mustache", "html"} {
+		t.Run(engine, func(t *testing.T) {
+			views, err := New(config.ServerConfig{ViewsEngine: engine, ViewsEmbedded: true})
+			assert.Nil(t, err)
+			viewApp := fiber.New(fiber.Config{Views: views, ViewsLayout: "layouts/main", PassLocalsToViews: true})
+			viewApp.Get("/", func(ctx *fiber.Ctx) error {
+				ctx.Locals("locale", "id")
+				return ctx.Render("index", fiber.Map{"title": "Hello Title", "header": "Hello Header", "content": "<b>Hello</b>"})
+			})
+
+			response, err := viewApp.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Nil(t, err)
+			assert.Equal(t, 200, response.StatusCode)
+			bytes, err := io.ReadAll(response.Body)
+			body := string(bytes)
+			assert.True(t, strings.HasPrefix(body, "<!DOCTYPE html>"))
+			assert.Contains(t, body, `<html lang="id">`)
+			assert.Contains(t, body, "<title>Hello Title</title>")
+			assert.Contains(t, body, "<h1>Hello Header</h1>")
+			assert.Contains(t, body, "&lt;b&gt;Hello&lt;/b&gt;")
+		})
+	}
+}
+
+func TestNewDisabledAndUnknown(t *testing.T) {
+	views, err := New(config.ServerConfig{})
+	assert.Nil(t, err)
+	assert.Nil(t, views)
+
+	_, err = New(config.ServerConfig{Views: "./", ViewsEngine: "jet"})
+	assert.NotNil(t, err)
+}
+
+func TestEnginesRenderBranding(t *testing.T) {
+	for _, engine := range []string{"mustache", "html"} {
+		t.Run(engine, func(t *testing.T) {
+			views, err := New(config.ServerConfig{ViewsEngine: engine, ViewsEmbedded: true})
+			assert.Nil(t, err)
+			viewApp := fiber.New(fiber.Config{Views: views, ViewsLayout: "layouts/main", PassLocalsToViews: true})
+			viewApp.Get("/", func(ctx *fiber.Ctx) error {
+				ctx.Locals("branding", config.BrandingConfig{Name: "Acme", LogoURL: "/acme.png", PrimaryColor: "#d00"})
+				return ctx.Render("index", fiber.Map{"title": "Home"})
+			})
+
+			response, err := viewApp.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Nil(t, err)
+			bytes, err := io.ReadAll(response.Body)
+			assert.Nil(t, err)
+			body := string(bytes)
+			assert.Contains(t, body, "<title>Home - Acme</title>")
+			assert.Contains(t, body, `<meta name="theme-color" content="#d00">`)
+			assert.Contains(t, body, `<img src="/acme.png" alt="Acme">`)
+		})
+	}
+}
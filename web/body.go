@@ -0,0 +1,19 @@
+package web
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParseBody parses the request body into out like ctx.BodyParser, but a body
+// that is malformed or doesn't fit out is a 400 rather than the 500 of a bare
+// decoding error. The fiber errors of BodyParser, such as 422 for an
+// unsupported content type, are kept.
+func ParseBody(ctx *fiber.Ctx, out interface{}) error {
+	err := ctx.BodyParser(out)
+	var fiberError *fiber.Error
+	if err == nil || errors.As(err, &fiberError) {
+		return err
+	}
+	return fiber.NewError(fiber.StatusBadRequest, "malformed request body: "+err.Error())
+}
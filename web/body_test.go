@@ -0,0 +1,42 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseBody(t *testing.T) {
+	app := fiber.New()
+	app.Post("/", func(ctx *fiber.Ctx) error {
+		var request struct {
+			Quantity int `json:"quantity" xml:"quantity" form:"quantity"`
+		}
+		err := ParseBody(ctx, &request)
+		if err != nil {
+			return err
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		contentType, body string
+		status            int
+	}{
+		{"application/json", `{"quantity":2}`, 204},
+		{"application/json", `{"quantity":`, 400},
+		{"application/json", `{"quantity":"two"}`, 400},
+		{"application/xml", `<request><quantity>`, 400},
+		{"application/x-www-form-urlencoded", `quantity=two`, 400},
+		{"text/plain", `quantity=2`, 422},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		request.Header.Set("Content-Type", test.contentType)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, test.status, response.StatusCode, test.body)
+	}
+}
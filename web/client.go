@@ -0,0 +1,92 @@
+package web
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+var outboundClient = &fiber.Client{}
+
+// OutboundClient creates fiber agents that carry the correlation headers of
+// the inbound request, so downstream services log the same request ID and
+// join the same trace.
+type OutboundClient struct {
+	client  *fiber.Client
+	headers map[string]string
+}
+
+func Outbound(ctx *fiber.Ctx) *OutboundClient {
+	headers := map[string]string{}
+
+	requestID, _ := ctx.Locals("requestid").(string)
+	if requestID == "" {
+		requestID = ctx.Get(fiber.HeaderXRequestID)
+	}
+	if requestID != "" {
+		headers[fiber.HeaderXRequestID] = requestID
+	}
+	for _, header := range []string{HeaderTraceParent, HeaderTraceState} {
+		if value := ctx.Get(header); value != "" {
+			headers[header] = value
+		}
+	}
+
+	outbound := &OutboundClient{client: outboundClient, headers: headers}
+	return outbound.WithContext(ctx.UserContext())
+}
+
+// WithContext returns a copy whose trace headers point at the span in ctx,
+// when there is one, so the downstream call becomes its child.
+func (outbound *OutboundClient) WithContext(ctx context.Context) *OutboundClient {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return outbound
+	}
+
+	headers := map[string]string{}
+	for name, value := range outbound.headers {
+		headers[name] = value
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return &OutboundClient{client: outbound.client, headers: headers}
+}
+
+func (outbound *OutboundClient) Get(url string) *fiber.Agent {
+	return outbound.Propagate(outbound.client.Get(url))
+}
+
+func (outbound *OutboundClient) Head(url string) *fiber.Agent {
+	return outbound.Propagate(outbound.client.Head(url))
+}
+
+func (outbound *OutboundClient) Post(url string) *fiber.Agent {
+	return outbound.Propagate(outbound.client.Post(url))
+}
+
+func (outbound *OutboundClient) Put(url string) *fiber.Agent {
+	return outbound.Propagate(outbound.client.Put(url))
+}
+
+func (outbound *OutboundClient) Patch(url string) *fiber.Agent {
+	return outbound.Propagate(outbound.client.Patch(url))
+}
+
+func (outbound *OutboundClient) Delete(url string) *fiber.Agent {
+	return outbound.Propagate(outbound.client.Delete(url))
+}
+
+// Propagate sets the correlation headers on an agent created elsewhere, such
+// as by an httpclient.Client.
+func (outbound *OutboundClient) Propagate(agent *fiber.Agent) *fiber.Agent {
+	for name, value := range outbound.headers {
+		agent.Set(name, value)
+	}
+	return agent
+}
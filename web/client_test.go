@@ -0,0 +1,108 @@
+package web
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang-fiber-web/telemetry"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newPropagationApp(downstream *httptest.Server) *fiber.App {
+	propagationApp := fiber.New()
+	propagationApp.Use(requestid.New())
+	propagationApp.Get("/proxy", func(ctx *fiber.Ctx) error {
+		status, body, errs := Outbound(ctx).Get(downstream.URL).String()
+		if len(errs) > 0 {
+			return errs[0]
+		}
+		return ctx.Status(status).SendString(body)
+	})
+	return propagationApp
+}
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.Header.Get("X-Request-ID") + "|" + request.Header.Get("traceparent")))
+	}))
+}
+
+func TestOutboundPropagatesHeaders(t *testing.T) {
+	downstream := newEchoServer()
+	defer downstream.Close()
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	request := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	request.Header.Set("X-Request-ID", "request-123")
+	request.Header.Set("traceparent", traceparent)
+	response, err := newPropagationApp(downstream).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "request-123|"+traceparent, string(bytes))
+}
+
+func TestOutboundPropagatesGeneratedRequestID(t *testing.T) {
+	downstream := newEchoServer()
+	defer downstream.Close()
+
+	request := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	response, err := newPropagationApp(downstream).Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.NotEmpty(t, response.Header.Get("X-Request-ID"))
+	assert.Equal(t, response.Header.Get("X-Request-ID")+"|", string(bytes))
+}
+
+func TestOutboundTraceSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+	downstream := newEchoServer()
+	defer downstream.Close()
+
+	tracingApp := fiber.New()
+	tracingApp.Use(telemetry.NewTracing())
+	tracingApp.Get("/proxy", func(ctx *fiber.Ctx) error {
+		var body string
+		err := telemetry.Trace(ctx.UserContext(), "GET downstream", trace.SpanKindClient, func(spanContext context.Context) error {
+			_, body, _ = Outbound(ctx).WithContext(spanContext).Get(downstream.URL).String()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(body)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	response, err := tracingApp.Test(request)
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	client, server := spans[0], spans[1]
+	assert.Equal(t, "GET downstream", client.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), client.Parent().SpanID())
+
+	traceparent := strings.Split(string(bytes), "|")[1]
+	assert.Equal(t, "00-"+client.SpanContext().TraceID().String()+"-"+client.SpanContext().SpanID().String()+"-01", traceparent)
+}
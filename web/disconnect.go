@@ -0,0 +1,90 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrClientGone is the cause of the contexts cancelled because the client
+// closed the connection.
+var ErrClientGone = errors.New("client disconnected")
+
+// disconnectCheckInterval is how often the connection of a request is checked
+// for a close by the client.
+const disconnectCheckInterval = time.Millisecond * 250
+
+// WatchDisconnect returns a copy of parent that is cancelled, with the cause
+// ErrClientGone, once the client closes the connection of ctx. fasthttp
+// does not tell when that happens, so the socket is peeked at every
+// disconnectCheckInterval, which reads nothing from it. stop must be called
+// before the request is done, as the connection then serves the next one; it
+// cancels the context. Connections that cannot be peeked at, such as those of
+// app.Test or of platforms without MSG_PEEK, are never reported closed. Over
+// TLS, a close_notify sent before closing hides the close.
+func WatchDisconnect(ctx *fiber.Ctx, parent context.Context) (context.Context, func()) {
+	watched, cancel := context.WithCancelCause(parent)
+	rawConn := peekableConn(ctx.Context().Conn())
+	if rawConn == nil {
+		return watched, func() { cancel(nil) }
+	}
+
+	done := make(chan struct{})
+	var wait sync.WaitGroup
+	wait.Add(1)
+	go func() {
+		defer wait.Done()
+		ticker := time.NewTicker(disconnectCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-watched.Done():
+				return
+			case <-ticker.C:
+			}
+			if peerClosed(rawConn) {
+				cancel(ErrClientGone)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return watched, func() {
+		once.Do(func() {
+			close(done)
+			wait.Wait()
+			cancel(nil)
+		})
+	}
+}
+
+// StreamContext is a context for the producer of a streamed response, which
+// runs once the handler has returned: it keeps the values of the request
+// context but not its deadline, and is cancelled when the client goes away.
+// stop must be called when the response has been written.
+func StreamContext(ctx *fiber.Ctx) (context.Context, func()) {
+	return WatchDisconnect(ctx, context.WithoutCancel(ctx.UserContext()))
+}
+
+func peekableConn(conn net.Conn) syscall.RawConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	syscallConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rawConn, err := syscallConn.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	return rawConn
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package web
+
+import (
+	"syscall"
+)
+
+// peerClosed cannot peek at connections on this platform, so they are never
+// reported closed.
+func peerClosed(rawConn syscall.RawConn) bool {
+	return false
+}
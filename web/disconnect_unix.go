@@ -0,0 +1,21 @@
+//go:build unix
+
+package web
+
+import (
+	"syscall"
+)
+
+// peerClosed reports whether the client closed the connection: a peek finds
+// the end of the stream, or the connection failed. Pending data, such as a
+// pipelined request, means it is still open.
+func peerClosed(rawConn syscall.RawConn) bool {
+	closed := false
+	buffer := make([]byte, 1)
+	err := rawConn.Read(func(fd uintptr) bool {
+		read, _, err := syscall.Recvfrom(int(fd), buffer, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		closed = read == 0 && err == nil || err != nil && err != syscall.EAGAIN && err != syscall.EWOULDBLOCK && err != syscall.EINTR
+		return true
+	})
+	return closed || err != nil
+}
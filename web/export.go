@@ -0,0 +1,132 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"time"
+)
+
+const (
+	MIMETextCSV         = "text/csv; charset=utf-8"
+	MIMEApplicationXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// RowWriter encodes the rows of an export.
+type RowWriter interface {
+	Write(row []string) error
+	Close() error
+}
+
+// StreamExport answers with a download named name in the format of ?format,
+// csv by default or xlsx. header is the first row and rows writes the others.
+// rows runs after the handler has returned, while the response is being sent,
+// so an export is never held in memory as a whole; it must therefore not use
+// ctx but the StreamContext it gets, which is cancelled when the client goes
+// away. An error in rows cuts the download short.
+func StreamExport(ctx *fiber.Ctx, name string, header []string, rows func(streamContext context.Context, write func(row []string) error) error) error {
+	format := ctx.Query("format", "csv")
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = MIMETextCSV
+	case "xlsx":
+		contentType = MIMEApplicationXLSX
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be csv or xlsx")
+	}
+
+	ctx.Attachment(name + "-" + time.Now().UTC().Format("20060102T150405Z") + "." + format)
+	ctx.Set(fiber.HeaderContentType, contentType)
+	streamContext, stop := StreamContext(ctx)
+	ctx.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		defer stop()
+		err := writeExport(writer, format, header, func(write func(row []string) error) error {
+			return rows(streamContext, write)
+		})
+		if err != nil {
+			logger.Warn("export cut short", "name", name, "error", err)
+		}
+	})
+	return nil
+}
+
+func writeExport(writer *bufio.Writer, format string, header []string, rows func(write func(row []string) error) error) error {
+	rowWriter, err := NewRowWriter(format, writer)
+	if err != nil {
+		return err
+	}
+	err = rowWriter.Write(header)
+	if err != nil {
+		return err
+	}
+	err = rows(rowWriter.Write)
+	if err != nil {
+		return err
+	}
+	err = rowWriter.Close()
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func NewRowWriter(format string, writer io.Writer) (RowWriter, error) {
+	if format == "xlsx" {
+		file := excelize.NewFile()
+		stream, err := file.NewStreamWriter("Sheet1")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxRowWriter{file: file, stream: stream, writer: writer}, nil
+	}
+	return &csvRowWriter{writer: csv.NewWriter(writer)}, nil
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (rowWriter *csvRowWriter) Write(row []string) error {
+	return rowWriter.writer.Write(row)
+}
+
+func (rowWriter *csvRowWriter) Close() error {
+	rowWriter.writer.Flush()
+	return rowWriter.writer.Error()
+}
+
+// xlsxRowWriter keeps rows in excelize's stream writer, which spills to a
+// temporary file once they outgrow its memory buffer, and writes the
+// workbook out on Close.
+type xlsxRowWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	writer io.Writer
+	rows   int
+}
+
+func (rowWriter *xlsxRowWriter) Write(row []string) error {
+	rowWriter.rows++
+	cell, err := excelize.CoordinatesToCellName(1, rowWriter.rows)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		values[i] = value
+	}
+	return rowWriter.stream.SetRow(cell, values)
+}
+
+func (rowWriter *xlsxRowWriter) Close() error {
+	defer rowWriter.file.Close()
+	err := rowWriter.stream.Flush()
+	if err != nil {
+		return err
+	}
+	return rowWriter.file.Write(rowWriter.writer)
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"strings"
+)
+
+// NotFound answers requests no route matched. It is meant to be registered
+// with app.Use after every other route. When the path exists for other
+// methods the answer is 405 with an Allow header instead of 404.
+func NotFound(ctx *fiber.Ctx) error {
+	allowed := AllowedMethods(ctx.App(), ctx.Path())
+	if len(allowed) > 0 {
+		return MethodNotAllowed(ctx, allowed)
+	}
+	return SendError(ctx, NewProblem(fiber.StatusNotFound, "Cannot "+ctx.Method()+" "+ctx.Path()))
+}
+
+func MethodNotAllowed(ctx *fiber.Ctx, allowed []string) error {
+	ctx.Set(fiber.HeaderAllow, strings.Join(allowed, ", "))
+	return SendError(ctx, NewProblem(fiber.StatusMethodNotAllowed, ctx.Method()+" is not allowed for "+ctx.Path()))
+}
+
+// SendError sends problem as a rendered "error" view to browsers, which ask
+// for text/html first, and as a problem document to everyone else. Without
+// views the problem document is always used.
+func SendError(ctx *fiber.Ctx, problem *Problem) error {
+	if problem.Instance == "" {
+		problem.Instance = ctx.OriginalURL()
+	}
+	localizeTitle(ctx, problem)
+	ctx.Vary(fiber.HeaderAccept)
+	if ctx.App().Config().Views == nil || ctx.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON, fiber.MIMETextHTML) != fiber.MIMETextHTML {
+		return SendProblem(ctx, problem)
+	}
+
+	return ctx.Status(problem.Status).Render("error", fiber.Map{
+		"status": problem.Status,
+		"title":  problem.Title,
+		"detail": problem.Detail,
+	})
+}
+
+// AllowedMethods lists the methods of the routes in app that match path, in
+// the order fiber stores them.
+func AllowedMethods(app *fiber.App, path string) []string {
+	var allowed []string
+	for _, route := range app.GetRoutes(true) {
+		if matchPath(route.Path, path) && !slices.Contains(allowed, route.Method) {
+			allowed = append(allowed, route.Method)
+		}
+	}
+	return allowed
+}
+
+// matchPath matches path against a route pattern with the same rules as a
+// default fiber app: case-insensitive, trailing slash optional, ":name" and
+// ":name?" parameters and "*" and "+" wildcards for the rest of the path.
+func matchPath(pattern, path string) bool {
+	patternSegments := splitPath(pattern)
+	pathSegments := splitPath(path)
+
+	for i, segment := range patternSegments {
+		switch {
+		case segment == "*":
+			return true
+		case segment == "+":
+			return i < len(pathSegments)
+		case strings.HasPrefix(segment, ":") && strings.HasSuffix(segment, "?"):
+			if i >= len(pathSegments) {
+				return i == len(patternSegments)-1
+			}
+		case i >= len(pathSegments):
+			return false
+		case strings.HasPrefix(segment, ":"):
+		case !strings.EqualFold(segment, pathSegments[i]):
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/template/mustache/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchPath(t *testing.T) {
+	assert.True(t, matchPath("/users/:id", "/users/1"))
+	assert.True(t, matchPath("/users/:id", "/Users/1/"))
+	assert.False(t, matchPath("/users/:id", "/users"))
+	assert.False(t, matchPath("/users/:id", "/users/1/files"))
+	assert.True(t, matchPath("/files/:name?", "/files"))
+	assert.True(t, matchPath("/static/*", "/static"))
+	assert.True(t, matchPath("/static/+", "/static/app.js"))
+	assert.False(t, matchPath("/static/+", "/static"))
+	assert.True(t, matchPath("/", "/"))
+}
+
+func newFallbackApp() *fiber.App {
+	views := fstest.MapFS{
+		"error.mustache": {Data: []byte(`<h1>{{status}} {{title}}</h1>`)},
+	}
+	fallbackApp := fiber.New(fiber.Config{
+		Views: mustache.NewFileSystem(http.FS(views), ".mustache"),
+	})
+	fallbackApp.Get("/users/:id", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("user")
+	})
+	fallbackApp.Put("/users/:id", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("updated")
+	})
+	fallbackApp.Use(NotFound)
+	return fallbackApp
+}
+
+func TestNotFound(t *testing.T) {
+	fallbackApp := newFallbackApp()
+
+	response, err := fallbackApp.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, MIMEApplicationProblemJSON, response.Header.Get("Content-Type"))
+	problem := map[string]interface{}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(t, "Cannot GET /missing", problem["detail"])
+
+	request := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	request.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	response, err = fallbackApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "<h1>404 Not Found</h1>", string(bytes))
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	response, err := newFallbackApp().Test(httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 405, response.StatusCode)
+	assert.Equal(t, "GET, HEAD, PUT", response.Header.Get("Allow"))
+	assert.Equal(t, MIMEApplicationProblemJSON, response.Header.Get("Content-Type"))
+}
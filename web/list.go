@@ -0,0 +1,143 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/model"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ListOptions struct {
+	DefaultPerPage int
+	MaxPerPage     int
+	Sortable       []string
+	Filterable     []string
+	Searchable     bool
+}
+
+type Pagination struct {
+	Page       int    `json:"page" xml:"page" yaml:"page"`
+	PerPage    int    `json:"per_page" xml:"per_page" yaml:"per_page"`
+	Total      int    `json:"total" xml:"total" yaml:"total"`
+	TotalPages int    `json:"total_pages" xml:"total_pages" yaml:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty" xml:"next_cursor,omitempty" yaml:"next_cursor,omitempty"`
+}
+
+func ParseListSpec(ctx *fiber.Ctx, options ListOptions) (*model.ListSpec, error) {
+	filters := map[string]string{}
+	ctx.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		name := string(key)
+		if strings.HasPrefix(name, "filter[") && strings.HasSuffix(name, "]") {
+			filters[name[len("filter["):len(name)-1]] = string(value)
+		}
+	})
+
+	spec, err := NewListSpec(options, ctx.QueryInt("page", 1), ctx.QueryInt("per_page", 0), ctx.Query("sort"), filters)
+	if err != nil {
+		return nil, err
+	}
+	if options.Searchable {
+		spec.Search = strings.TrimSpace(ctx.Query("q"))
+	}
+	spec.Cursor = ctx.Query("cursor")
+	spec.CursorMode = ctx.Context().QueryArgs().Has("cursor")
+	if spec.Cursor != "" {
+		if _, err := model.DecodeCursor(spec.Cursor); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid cursor")
+		}
+	}
+	return spec, nil
+}
+
+// NewListSpec checks a page, page size, ?sort value and filters against
+// options, for lists requested through other means than a query string. A
+// perPage of 0 means the default page size.
+func NewListSpec(options ListOptions, page, perPage int, sort string, filters map[string]string) (*model.ListSpec, error) {
+	if options.DefaultPerPage == 0 {
+		options.DefaultPerPage = 20
+	}
+	if options.MaxPerPage == 0 {
+		options.MaxPerPage = 100
+	}
+	if perPage == 0 {
+		perPage = options.DefaultPerPage
+	}
+
+	spec := &model.ListSpec{
+		Page:    page,
+		PerPage: perPage,
+		Filters: map[string]string{},
+	}
+	if spec.Page < 1 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "page must be greater than 0")
+	}
+	if spec.PerPage < 1 || spec.PerPage > options.MaxPerPage {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "per_page must be between 1 and "+strconv.Itoa(options.MaxPerPage))
+	}
+
+	if sort != "" {
+		for _, item := range strings.Split(sort, ",") {
+			field, direction, _ := strings.Cut(strings.TrimSpace(item), ":")
+			if !slices.Contains(options.Sortable, field) {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "cannot sort by "+field)
+			}
+			if direction != "" && direction != "asc" && direction != "desc" {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "invalid sort direction "+direction)
+			}
+			spec.Sort = append(spec.Sort, model.SortField{Field: field, Desc: direction == "desc"})
+		}
+	}
+
+	for field, value := range filters {
+		if !slices.Contains(options.Filterable, field) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "cannot filter by "+field)
+		}
+		spec.Filters[field] = value
+	}
+	return spec, nil
+}
+
+// SetPagination builds the pagination metadata for a list response and emits
+// RFC 5988 Link headers (first, prev, next, last) plus X-Total-Count.
+func SetPagination(ctx *fiber.Ctx, spec *model.ListSpec, total int) Pagination {
+	pagination := Pagination{
+		Page:       spec.Page,
+		PerPage:    spec.PerPage,
+		Total:      total,
+		TotalPages: (total + spec.PerPage - 1) / spec.PerPage,
+	}
+
+	var links []string
+	if spec.CursorMode {
+		next := spec.Offset() + spec.PerPage
+		if next < total {
+			pagination.NextCursor = model.EncodeCursor(next)
+			links = append(links, pageLink(ctx, "next", "cursor", pagination.NextCursor))
+		}
+	} else {
+		links = append(links, pageLink(ctx, "first", "page", "1"))
+		if spec.Page > 1 {
+			links = append(links, pageLink(ctx, "prev", "page", strconv.Itoa(spec.Page-1)))
+		}
+		if spec.Page < pagination.TotalPages {
+			links = append(links, pageLink(ctx, "next", "page", strconv.Itoa(spec.Page+1)))
+		}
+		if pagination.TotalPages > 0 {
+			links = append(links, pageLink(ctx, "last", "page", strconv.Itoa(pagination.TotalPages)))
+		}
+	}
+
+	ctx.Set("X-Total-Count", strconv.Itoa(total))
+	if len(links) > 0 {
+		ctx.Set(fiber.HeaderLink, strings.Join(links, ", "))
+	}
+	return pagination
+}
+
+func pageLink(ctx *fiber.Ctx, rel, key, value string) string {
+	query, _ := url.ParseQuery(string(ctx.Context().QueryArgs().QueryString()))
+	query.Set(key, value)
+	return "<" + ctx.BaseURL() + ctx.Path() + "?" + query.Encode() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,42 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/telemetry"
+)
+
+var logger = telemetry.Logger("web")
+
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+// StreamNDJSON answers with newline-delimited JSON: one line per value that
+// produce passes to encode. Every line is flushed to the client as soon as it
+// is encoded, so clients can consume results while they are produced.
+// produce runs after the handler has returned and must not use ctx; it gets
+// the StreamContext of the request instead, which is cancelled when the
+// client goes away. Once encode fails, produce should return its error,
+// which ends the stream.
+func StreamNDJSON(ctx *fiber.Ctx, produce func(streamContext context.Context, encode func(value interface{}) error) error) error {
+	ctx.Set(fiber.HeaderContentType, MIMEApplicationNDJSON)
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	path := ctx.Path()
+	streamContext, stop := StreamContext(ctx)
+	ctx.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		defer stop()
+		encoder := json.NewEncoder(writer)
+		err := produce(streamContext, func(value interface{}) error {
+			err := encoder.Encode(value)
+			if err != nil {
+				return err
+			}
+			return writer.Flush()
+		})
+		if err != nil {
+			logger.Warn("ndjson stream cut short", "path", path, "error", err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,63 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+const (
+	MIMEApplicationJSONPatch  = "application/json-patch+json"
+	MIMEApplicationMergePatch = "application/merge-patch+json"
+)
+
+// ApplyPatch applies the JSON Patch (RFC 6902) or JSON Merge Patch (RFC 7386)
+// in the request body to the JSON form of document and decodes the result
+// into target. Patches that touch fields target does not have are rejected
+// with 422, a failed "test" operation with 409 and other media types with 415.
+func ApplyPatch(ctx *fiber.Ctx, document interface{}, target interface{}) error {
+	original, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch mediaType(ctx.Get(fiber.HeaderContentType)) {
+	case MIMEApplicationJSONPatch:
+		patch, err := jsonpatch.DecodePatch(ctx.Body())
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid JSON Patch: "+err.Error())
+		}
+		patched, err = patch.Apply(original)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return fiber.NewError(fiber.StatusConflict, "JSON Patch test operation failed")
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, "cannot apply JSON Patch: "+err.Error())
+		}
+	case MIMEApplicationMergePatch:
+		patched, err = jsonpatch.MergePatch(original, ctx.Body())
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid JSON Merge Patch: "+err.Error())
+		}
+	default:
+		ctx.Set(fiber.HeaderAcceptPatch, MIMEApplicationJSONPatch+", "+MIMEApplicationMergePatch)
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "PATCH requires "+MIMEApplicationJSONPatch+" or "+MIMEApplicationMergePatch)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(target)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "patched document is invalid: "+err.Error())
+	}
+	return nil
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang-fiber-web/i18n"
+	"golang-fiber-web/model"
+	"golang-fiber-web/telemetry"
+	"net/http"
+	"strconv"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document. Extensions are serialized
+// as additional top-level members.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (problem *Problem) Error() string {
+	if problem.Detail != "" {
+		return problem.Detail
+	}
+	return problem.Title
+}
+
+func (problem *Problem) With(key string, value interface{}) *Problem {
+	if problem.Extensions == nil {
+		problem.Extensions = map[string]interface{}{}
+	}
+	problem.Extensions[key] = value
+	return problem
+}
+
+func (problem *Problem) MarshalJSON() ([]byte, error) {
+	document := map[string]interface{}{}
+	for key, value := range problem.Extensions {
+		document[key] = value
+	}
+	document["type"] = problem.Type
+	document["title"] = problem.Title
+	document["status"] = problem.Status
+	if problem.Detail != "" {
+		document["detail"] = problem.Detail
+	}
+	if problem.Instance != "" {
+		document["instance"] = problem.Instance
+	}
+	return json.Marshal(document)
+}
+
+// ProblemFromError maps handler errors onto a problem document: *Problem is
+// used as is, model.ValidationErrors become 422 with an "errors" extension and
+// *fiber.Error keeps its status code. Anything else is a 500 whose detail
+// leaves out the error, which NewErrorHandler logs instead, along with the
+// request ID the problem carries. The title and the validation messages are
+// translated when the request has a Localizer.
+func ProblemFromError(ctx *fiber.Ctx, err error) *Problem {
+	var problem *Problem
+	var validationErrors model.ValidationErrors
+	var fiberError *fiber.Error
+
+	switch {
+	case errors.As(err, &problem):
+		copied := *problem
+		problem = &copied
+	case errors.As(err, &validationErrors):
+		problem = NewProblem(fiber.StatusUnprocessableEntity, "request validation failed").With("errors", validationErrors)
+		if localizer := i18n.FromContext(ctx); localizer != nil {
+			problem.Detail = localizer.T("errors.validation_failed")
+			problem.Extensions["errors"] = localizeFieldErrors(localizer, validationErrors)
+		}
+	case errors.As(err, &fiberError):
+		problem = NewProblem(fiberError.Code, fiberError.Message)
+	default:
+		problem = NewProblem(fiber.StatusInternalServerError, "an unexpected error occurred")
+		if requestID, ok := ctx.Locals("requestid").(string); ok && requestID != "" {
+			problem.With("request_id", requestID)
+		}
+	}
+
+	if problem.Instance == "" {
+		problem.Instance = ctx.OriginalURL()
+	}
+	localizeTitle(ctx, problem)
+	return problem
+}
+
+// localizeTitle translates the default title of the status with the key
+// "status.<code>".
+func localizeTitle(ctx *fiber.Ctx, problem *Problem) {
+	localizer := i18n.FromContext(ctx)
+	if localizer == nil || problem.Title != http.StatusText(problem.Status) {
+		return
+	}
+	if title, ok := localizer.Lookup("status." + strconv.Itoa(problem.Status)); ok {
+		problem.Title = title
+	}
+}
+
+// localizeFieldErrors translates every message with the key
+// "validation.<tag>", keeping the message of tags without one.
+func localizeFieldErrors(localizer *i18n.Localizer, validationErrors model.ValidationErrors) model.ValidationErrors {
+	localized := make(model.ValidationErrors, len(validationErrors))
+	for i, fieldError := range validationErrors {
+		localized[i] = fieldError
+		if message, ok := localizer.Lookup("validation."+fieldError.Tag, "field", fieldError.Field, "param", fieldError.Param); ok {
+			localized[i].Message = message
+		}
+	}
+	return localized
+}
+
+func SendProblem(ctx *fiber.Ctx, problem *Problem) error {
+	return ctx.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+}
+
+// NewErrorHandler builds the app ErrorHandler. With problemDetails enabled
+// errors are sent as application/problem+json, otherwise as "Error: ..." text.
+func NewErrorHandler(problemDetails bool) fiber.ErrorHandler {
+	return func(ctx *fiber.Ctx, err error) error {
+		problem := ProblemFromError(ctx, err)
+		if problem.Status >= fiber.StatusInternalServerError {
+			requestID, _ := ctx.Locals("requestid").(string)
+			logger.ErrorContext(ctx.UserContext(), "handling request", "method", ctx.Method(), "path", ctx.Path(), "request_id", requestID, "error", err)
+			telemetry.ReportError(ctx, err)
+		}
+		if problemDetails {
+			return SendProblem(ctx, problem)
+		}
+
+		ctx.Status(problem.Status)
+		return ctx.SendString("Error: " + err.Error())
+	}
+}
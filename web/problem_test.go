@@ -0,0 +1,172 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/i18n"
+	"golang-fiber-web/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3"`
+	Email    string `json:"email" validate:"required,email"`
+}
+
+func newProblemApp(problemDetails bool) *fiber.App {
+	problemApp := fiber.New(fiber.Config{
+		ErrorHandler: NewErrorHandler(problemDetails),
+	})
+	problemApp.Get("/users/:id", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusNotFound, "user "+ctx.Params("id")+" not found")
+	})
+	problemApp.Get("/error", func(ctx *fiber.Ctx) error {
+		return errors.New("ups")
+	})
+	problemApp.Get("/quota", func(ctx *fiber.Ctx) error {
+		return NewProblem(fiber.StatusForbidden, "quota exceeded").With("remaining", 0)
+	})
+	problemApp.Post("/users", func(ctx *fiber.Ctx) error {
+		request := new(CreateUserRequest)
+		err := ctx.BodyParser(request)
+		if err != nil {
+			return err
+		}
+		err = model.ValidateStruct(request)
+		if err != nil {
+			return err
+		}
+		return ctx.SendStatus(fiber.StatusCreated)
+	})
+	return problemApp
+}
+
+func TestProblemNotFound(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/users/10", nil)
+	response, err := newProblemApp(true).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "user 10 not found",
+		"instance": "/users/10"
+	}`, string(bytes))
+}
+
+func TestProblemExtensions(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/quota", nil)
+	response, err := newProblemApp(true).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 403, response.StatusCode)
+
+	problem := map[string]interface{}{}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(t, "quota exceeded", problem["detail"])
+	assert.Equal(t, float64(0), problem["remaining"])
+}
+
+func TestProblemValidation(t *testing.T) {
+	body := strings.NewReader(`{"username":"br","email":"not-an-email"}`)
+	request := httptest.NewRequest(http.MethodPost, "/users", body)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := newProblemApp(true).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"detail": "request validation failed",
+		"instance": "/users",
+		"errors": [
+			{"field": "username", "message": "failed min=3"},
+			{"field": "email", "message": "failed email"}
+		]
+	}`, string(bytes))
+}
+
+func TestProblemValidationLocalized(t *testing.T) {
+	bundle, err := i18n.NewBundle("en", map[string]map[string]string{
+		"id": {
+			"status.422":               "Data Tidak Dapat Diproses",
+			"errors.validation_failed": "validasi permintaan gagal",
+			"validation.min":           "minimal {param}",
+		},
+	})
+	assert.Nil(t, err)
+	localizedApp := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(true)})
+	localizedApp.Use(i18n.New(bundle))
+	localizedApp.Mount("/", newProblemApp(true))
+
+	body := strings.NewReader(`{"username":"br","email":"not-an-email"}`)
+	request := httptest.NewRequest(http.MethodPost, "/users", body)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept-Language", "id")
+	response, err := localizedApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Data Tidak Dapat Diproses",
+		"status": 422,
+		"detail": "validasi permintaan gagal",
+		"instance": "/users",
+		"errors": [
+			{"field": "username", "message": "minimal 3"},
+			{"field": "email", "message": "failed email"}
+		]
+	}`, string(bytes))
+}
+
+func TestProblemDisabled(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/error", nil)
+	response, err := newProblemApp(false).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "Error: ups", string(bytes))
+}
+
+func TestProblemHidesInternalErrors(t *testing.T) {
+	problemApp := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(true)})
+	problemApp.Use(requestid.New())
+	problemApp.Get("/error", func(ctx *fiber.Ctx) error {
+		return errors.New("pq: password authentication failed for user \"app\"")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/error", nil)
+	request.Header.Set("X-Request-ID", "request-123")
+	response, err := problemApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Internal Server Error",
+		"status": 500,
+		"detail": "an unexpected error occurred",
+		"instance": "/error",
+		"request_id": "request-123"
+	}`, string(bytes))
+}
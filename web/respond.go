@@ -0,0 +1,87 @@
+package web
+
+import (
+	"encoding/xml"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+	"sort"
+)
+
+const (
+	MIMEApplicationYAML = "application/yaml"
+	MIMETextYAML        = "text/yaml"
+)
+
+// Respond serializes payload in the format preferred by the Accept header:
+// JSON, XML or YAML. JSON is used when the client has no preference or asks
+// for a format that is not supported.
+func Respond(ctx *fiber.Ctx, status int, payload interface{}) error {
+	ctx.Vary(fiber.HeaderAccept)
+	ctx.Status(status)
+
+	switch ctx.Accepts(fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML, fiber.MIMETextXML, MIMEApplicationYAML, MIMETextYAML) {
+	case fiber.MIMEApplicationXML, fiber.MIMETextXML:
+		return ctx.XML(xmlPayload(payload))
+	case MIMEApplicationYAML, MIMETextYAML:
+		body, err := yaml.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderContentType, MIMEApplicationYAML)
+		return ctx.Send(body)
+	default:
+		return ctx.JSON(payload)
+	}
+}
+
+// xmlMap lets map payloads such as fiber.Map be encoded as XML, which
+// encoding/xml does not support natively.
+type xmlMap map[string]interface{}
+
+func (payload xmlMap) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	if start.Name.Local == "" || start.Name.Local == "xmlMap" {
+		start.Name.Local = "response"
+	}
+	err := encoder.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(payload))
+	for key := range payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err = encoder.EncodeElement(xmlPayload(payload[key]), xml.StartElement{Name: xml.Name{Local: key}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func xmlPayload(payload interface{}) interface{} {
+	switch value := payload.(type) {
+	case fiber.Map:
+		return xmlMap(value)
+	case map[string]interface{}:
+		return xmlMap(value)
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = xmlPayload(item)
+		}
+		return items
+	case []fiber.Map:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = xmlMap(item)
+		}
+		return items
+	default:
+		return payload
+	}
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+type RouteInfo struct {
+	Method     string   `json:"method" xml:"method" yaml:"method"`
+	Path       string   `json:"path" xml:"path" yaml:"path"`
+	Name       string   `json:"name,omitempty" xml:"name,omitempty" yaml:"name,omitempty"`
+	Handler    string   `json:"handler" xml:"handler" yaml:"handler"`
+	Middleware []string `json:"middleware" xml:"middleware" yaml:"middleware"`
+}
+
+// RouteTable lists the routes app will actually serve, HEAD excluded. The
+// middleware of a route are the app.Use handlers registered before it on a
+// prefix that covers its path, in the order they run; the handler is the last
+// function of the route itself.
+func RouteTable(app *fiber.App) []RouteInfo {
+	routes := map[*fiber.Handler]bool{}
+	for _, route := range app.GetRoutes(true) {
+		if len(route.Handlers) > 0 {
+			routes[&route.Handlers[0]] = true
+		}
+	}
+
+	table := []RouteInfo{}
+	for _, stack := range app.Stack() {
+		var middleware []*fiber.Route
+		for _, route := range stack {
+			if len(route.Handlers) == 0 {
+				continue
+			}
+			if !routes[&route.Handlers[0]] {
+				middleware = append(middleware, route)
+				continue
+			}
+			if route.Method == fiber.MethodHead {
+				continue
+			}
+
+			info := RouteInfo{
+				Method:     route.Method,
+				Path:       route.Path,
+				Name:       route.Name,
+				Handler:    handlerName(route.Handlers[len(route.Handlers)-1]),
+				Middleware: []string{},
+			}
+			for _, use := range middleware {
+				if coversPath(use.Path, route.Path) {
+					for _, handler := range use.Handlers {
+						info.Middleware = append(info.Middleware, handlerName(handler))
+					}
+				}
+			}
+			for _, handler := range route.Handlers[:len(route.Handlers)-1] {
+				info.Middleware = append(info.Middleware, handlerName(handler))
+			}
+			table = append(table, info)
+		}
+	}
+	return table
+}
+
+func coversPath(prefix, path string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func handlerName(handler fiber.Handler) string {
+	function := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if function == nil {
+		return "unknown"
+	}
+	return strings.TrimSuffix(function.Name(), "-fm")
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MIMETextEventStream = "text/event-stream"
+
+// SSEHeartbeat is how often StreamSSE writes a comment to an idle stream, so
+// that proxies do not close it and a client that went away is noticed.
+var SSEHeartbeat = time.Second * 15
+
+// StreamSSE answers with a stream of server-sent events: one per call of
+// send, named by event and carrying data encoded as JSON. Like StreamNDJSON,
+// produce runs after the handler has returned, gets the StreamContext of the
+// request and should return once send fails or the context is done.
+func StreamSSE(ctx *fiber.Ctx, produce func(streamContext context.Context, send func(event string, data interface{}) error) error) error {
+	ctx.Set(fiber.HeaderContentType, MIMETextEventStream)
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set("X-Accel-Buffering", "no")
+	path := ctx.Path()
+	streamContext, stop := StreamContext(ctx)
+	ctx.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		defer stop()
+		var mutex sync.Mutex
+		write := func(text string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			_, err := writer.WriteString(text)
+			if err != nil {
+				return err
+			}
+			return writer.Flush()
+		}
+
+		heartbeatDone := make(chan struct{})
+		defer close(heartbeatDone)
+		go func() {
+			ticker := time.NewTicker(SSEHeartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-heartbeatDone:
+					return
+				case <-streamContext.Done():
+					return
+				case <-ticker.C:
+					if write(": heartbeat\n\n") != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		err := produce(streamContext, func(event string, data interface{}) error {
+			encoded, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			return write(formatSSE(event, string(encoded)))
+		})
+		if err != nil {
+			logger.Warn("sse stream cut short", "path", path, "error", err)
+		}
+	})
+	return nil
+}
+
+func formatSSE(event, data string) string {
+	var builder strings.Builder
+	if event != "" {
+		builder.WriteString("event: " + event + "\n")
+	}
+	for _, line := range strings.Split(data, "\n") {
+		builder.WriteString("data: " + line + "\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"strings"
+)
+
+// URLFor builds the path of the route registered under name, filling its
+// parameters from params. Unlike fiber's GetRouteURL it fails on an unknown
+// route or a missing parameter instead of returning a broken path, and it
+// escapes the values.
+func URLFor(ctx *fiber.Ctx, name string, params fiber.Map) (string, error) {
+	route := ctx.App().GetRoute(name)
+	if name == "" || route.Name != name {
+		return "", errors.New("unknown route " + name)
+	}
+
+	escaped := fiber.Map{}
+	for _, param := range route.Params {
+		value, ok := params[param]
+		if !ok {
+			return "", fmt.Errorf("route %s: missing parameter %s", name, param)
+		}
+		escaped[param] = url.PathEscape(fmt.Sprint(value))
+	}
+	return ctx.GetRouteURL(name, escaped)
+}
+
+// URLForLambda returns URLFor as a mustache lambda. The section holds the
+// route name followed by key=value parameters, which may use variables:
+//
+//	<a href="{{#urlFor}}users.show id={{id}}{{/urlFor}}">
+func URLForLambda(ctx *fiber.Ctx) func(text string, render func(string) (string, error)) (string, error) {
+	return func(text string, render func(string) (string, error)) (string, error) {
+		rendered, err := render(text)
+		if err != nil {
+			return "", err
+		}
+		fields := strings.Fields(rendered)
+		if len(fields) == 0 {
+			return "", errors.New("urlFor needs a route name")
+		}
+
+		params := fiber.Map{}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return "", errors.New("urlFor parameter " + field + " is not key=value")
+			}
+			params[key] = value
+		}
+		return URLFor(ctx, fields[0], params)
+	}
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURLFor(t *testing.T) {
+	urlApp := fiber.New()
+	urlApp.Get("/users/:id/files/:name", func(ctx *fiber.Ctx) error {
+		return nil
+	}).Name("files.show")
+	urlApp.Get("/url", func(ctx *fiber.Ctx) error {
+		location, err := URLFor(ctx, "files.show", fiber.Map{"id": 42, "name": "a b.txt"})
+		assert.Nil(t, err)
+
+		_, err = URLFor(ctx, "files.show", fiber.Map{"id": 42})
+		assert.NotNil(t, err)
+		_, err = URLFor(ctx, "files.missing", nil)
+		assert.NotNil(t, err)
+
+		lambda := URLForLambda(ctx)
+		rendered, err := lambda("files.show id=7 name=report", func(text string) (string, error) {
+			return text, nil
+		})
+		assert.Nil(t, err)
+		return ctx.SendString(location + " " + rendered)
+	})
+
+	response, err := urlApp.Test(httptest.NewRequest(http.MethodGet, "/url", nil))
+	assert.Nil(t, err)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Equal(t, "/users/42/files/a%20b.txt /users/7/files/report", string(bytes))
+}
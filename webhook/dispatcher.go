@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/telemetry"
+	"golang-fiber-web/tenant"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var logger = telemetry.Logger("webhooks")
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// leaseMargin is added to the request timeout to hold a claimed delivery
+// while it is sent.
+const leaseMargin = time.Minute
+
+// Sign returns the X-Webhook-Signature of a delivery: the hex HMAC-SHA256,
+// keyed with the secret of the webhook, of the X-Webhook-Timestamp, a dot and
+// the body. Receivers compute it to check the delivery comes from us, and
+// reject old timestamps so a captured delivery cannot be replayed.
+func Sign(secret string, timestamp int64, body []byte) string {
+	return "sha256=" + string(hexHMAC(secret, []byte(strconv.FormatInt(timestamp, 10)+"."), body))
+}
+
+// payload is the JSON body of a delivery.
+type payload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      event.Event `json:"data"`
+}
+
+// Dispatcher turns the events of the bus into deliveries and sends the
+// deliveries to the webhooks.
+type Dispatcher struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+	client     *fiber.Client
+	config     config.WebhookConfig
+}
+
+func NewDispatcher(webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository, config config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{webhooks: webhooks, deliveries: deliveries, client: &fiber.Client{}, config: config}
+}
+
+// Enqueue is the event.Handler creating a pending delivery of published for
+// every webhook subscribed to it.
+func (dispatcher *Dispatcher) Enqueue(ctx context.Context, published event.Event) error {
+	webhooks, err := dispatcher.webhooks.FindByEvent(ctx, published.Name())
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	var deliveries []*model.WebhookDelivery
+	for _, webhook := range webhooks {
+		delivery := &model.WebhookDelivery{ID: uuid.NewString(), WebhookID: webhook.ID, Event: published.Name(), CreatedAt: time.Now()}
+		delivery.Payload, err = json.Marshal(payload{ID: delivery.ID, Event: delivery.Event, CreatedAt: delivery.CreatedAt, Data: published})
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return dispatcher.deliveries.Create(ctx, deliveries...)
+}
+
+// Run sends the due deliveries every interval until ctx is done.
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.config.Interval)
+	defer ticker.Stop()
+	for {
+		_, err := dispatcher.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("dispatching webhooks", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush sends the deliveries that are due, a batch at a time, and returns
+// the number of deliveries attempted. The deliveries of a batch are sent
+// concurrently.
+func (dispatcher *Dispatcher) Flush(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		deliveries, err := dispatcher.deliveries.Claim(ctx, time.Now(), dispatcher.config.Timeout+leaseMargin, dispatcher.config.BatchSize)
+		if err != nil {
+			return attempted, err
+		}
+
+		var wait sync.WaitGroup
+		errs := make([]error, len(deliveries))
+		for i, delivery := range deliveries {
+			wait.Add(1)
+			go func() {
+				defer wait.Done()
+				errs[i] = dispatcher.deliver(ctx, delivery)
+			}()
+		}
+		wait.Wait()
+		attempted += len(deliveries)
+		if err = errors.Join(errs...); err != nil {
+			return attempted, err
+		}
+		if len(deliveries) < dispatcher.config.BatchSize {
+			return attempted, nil
+		}
+	}
+}
+
+// deliver makes one attempt at sending delivery and records its outcome, in
+// the tenant of the delivery since the deliveries of every tenant are claimed
+// together.
+func (dispatcher *Dispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	ctx = tenant.With(ctx, delivery.TenantID)
+	webhook, err := dispatcher.webhooks.FindByID(ctx, delivery.WebhookID)
+	if errors.Is(err, model.ErrWebhookNotFound) {
+		// Deleted since, along with its deliveries.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	agent := dispatcher.client.Post(webhook.URL)
+	agent.Timeout(dispatcher.config.Timeout)
+	agent.ContentType(fiber.MIMEApplicationJSON)
+	agent.Set(HeaderEvent, delivery.Event)
+	agent.Set(HeaderDelivery, delivery.ID)
+	agent.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	agent.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+	agent.Body(delivery.Payload)
+	status, _, errs := agent.Bytes()
+	logger.Debug("delivered webhook", "delivery", delivery.ID, "webhook", webhook.ID, "status", status, "errors", len(errs))
+
+	delivery.Attempts++
+	delivery.StatusCode = status
+	delivery.Error = ""
+	switch {
+	case len(errs) > 0:
+		delivery.Error = errors.Join(errs...).Error()
+	case status >= fiber.StatusOK && status < fiber.StatusMultipleChoices:
+		delivery.Status = model.DeliverySucceeded
+		return dispatcher.deliveries.Update(ctx, delivery)
+	default:
+		delivery.Error = "unexpected status " + strconv.Itoa(status)
+	}
+
+	if delivery.Attempts >= dispatcher.config.MaxAttempts {
+		delivery.Status = model.DeliveryFailed
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(dispatcher.config.Backoff << (delivery.Attempts - 1))
+	}
+	return dispatcher.deliveries.Update(ctx, delivery)
+}
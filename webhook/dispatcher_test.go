@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"golang-fiber-web/event"
+	"golang-fiber-web/model"
+	"golang-fiber-web/repository"
+	"golang-fiber-web/tenant"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testConfig = config.WebhookConfig{BatchSize: 10, Timeout: time.Second, MaxAttempts: 2, Backoff: time.Hour}
+
+func TestDispatcherDelivers(t *testing.T) {
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		received = append(received, request)
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	webhooks, deliveries := repository.NewMemoryWebhookRepositories()
+	subscribed := &model.Webhook{URL: server.URL, Secret: "0123456789abcdef", Events: []string{event.NameUserDeleted}}
+	assert.Nil(t, webhooks.Create(ctx, subscribed))
+	assert.Nil(t, webhooks.Create(ctx, &model.Webhook{URL: server.URL, Secret: "0123456789abcdef", Events: []string{event.NameFileUploaded}}))
+
+	dispatcher := NewDispatcher(webhooks, deliveries, testConfig)
+	assert.Nil(t, dispatcher.Enqueue(ctx, event.UserDeleted{UserID: "1"}))
+	attempted, err := dispatcher.Flush(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, attempted)
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, event.NameUserDeleted, received[0].Header.Get(HeaderEvent))
+	timestamp, err := strconv.ParseInt(received[0].Header.Get(HeaderTimestamp), 10, 64)
+	assert.Nil(t, err)
+	assert.Equal(t, Sign(subscribed.Secret, timestamp, bodies[0]), received[0].Header.Get(HeaderSignature))
+	var body struct {
+		ID    string            `json:"id"`
+		Event string            `json:"event"`
+		Data  map[string]string `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(bodies[0], &body))
+	assert.Equal(t, received[0].Header.Get(HeaderDelivery), body.ID)
+	assert.Equal(t, map[string]string{"user_id": "1"}, body.Data)
+
+	logged, total, err := deliveries.List(ctx, subscribed.ID, &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, model.DeliverySucceeded, logged[0].Status)
+	assert.Equal(t, 200, logged[0].StatusCode)
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	webhooks, deliveries := repository.NewMemoryWebhookRepositories()
+	subscribed := &model.Webhook{URL: server.URL, Secret: "0123456789abcdef", Events: []string{event.NameUserDeleted}}
+	assert.Nil(t, webhooks.Create(ctx, subscribed))
+	dispatcher := NewDispatcher(webhooks, deliveries, testConfig)
+	assert.Nil(t, dispatcher.Enqueue(ctx, event.UserDeleted{UserID: "1"}))
+
+	_, err := dispatcher.Flush(ctx)
+	assert.Nil(t, err)
+	logged, _, err := deliveries.List(ctx, subscribed.ID, &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, model.DeliveryPending, logged[0].Status)
+	assert.Equal(t, 1, logged[0].Attempts)
+	assert.Equal(t, "unexpected status 503", logged[0].Error)
+	assert.True(t, logged[0].NextAttemptAt.After(time.Now().Add(time.Minute*59)))
+
+	attempted, err := dispatcher.Flush(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, attempted)
+
+	logged[0].NextAttemptAt = time.Now()
+	assert.Nil(t, deliveries.Update(ctx, logged[0]))
+	_, err = dispatcher.Flush(ctx)
+	assert.Nil(t, err)
+	failed, err := deliveries.FindByID(ctx, subscribed.ID, logged[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.DeliveryFailed, failed.Status)
+	assert.Equal(t, 2, failed.Attempts)
+}
+
+func TestDispatcherDeliversInTheTenantOfTheWebhook(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	acme, globex := tenant.With(context.Background(), "acme"), tenant.With(context.Background(), "globex")
+	webhooks, deliveries := repository.NewMemoryWebhookRepositories()
+	subscribed := &model.Webhook{URL: server.URL, Secret: "0123456789abcdef", Events: []string{event.NameUserDeleted}}
+	assert.Nil(t, webhooks.Create(acme, subscribed))
+
+	dispatcher := NewDispatcher(webhooks, deliveries, testConfig)
+	assert.Nil(t, dispatcher.Enqueue(globex, event.UserDeleted{UserID: "1"}))
+	assert.Nil(t, dispatcher.Enqueue(acme, event.UserDeleted{UserID: "2"}))
+	attempted, err := dispatcher.Flush(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, 1, received)
+
+	logged, _, err := deliveries.List(acme, subscribed.ID, &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, model.DeliverySucceeded, logged[0].Status)
+	_, total, err := deliveries.List(globex, subscribed.ID, &model.ListSpec{Page: 1, PerPage: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, total)
+}
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"golang-fiber-web/config"
+	"golang-fiber-web/model"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// stripeTolerance is how old the timestamp of a Stripe signature may be.
+const stripeTolerance = time.Minute * 5
+
+// vonageTolerance is how far the issue time of a Vonage signature may be.
+const vonageTolerance = time.Minute * 5
+
+// Verifier checks that a webhook request comes from its provider, signed
+// with secret, and identifies the event it carries. header returns the
+// request header of a name.
+type Verifier interface {
+	Verify(header func(name string) string, body []byte, now time.Time) (*model.ReceivedWebhook, error)
+}
+
+// NewVerifier returns the verifier of provider, "stripe", "github",
+// "twilio" or "vonage".
+func NewVerifier(provider string, receiver config.WebhookReceiverConfig) (Verifier, error) {
+	switch provider {
+	case "stripe":
+		return stripeVerifier{secret: receiver.Secret}, nil
+	case "github":
+		return githubVerifier{secret: receiver.Secret}, nil
+	case "twilio":
+		if receiver.URL == "" {
+			return nil, errors.New("the twilio webhook receiver needs its url")
+		}
+		return twilioVerifier{secret: receiver.Secret, url: receiver.URL}, nil
+	case "vonage":
+		return vonageVerifier{secret: receiver.Secret}, nil
+	}
+	return nil, errors.New("unknown webhook provider " + provider)
+}
+
+// stripeVerifier checks the Stripe-Signature header, "t=<timestamp>,v1=<hex
+// HMAC-SHA256 of the timestamp, a dot and the body>", which may list several
+// v1 signatures while the secret is rolled. The event ID and type are those
+// of the body.
+type stripeVerifier struct {
+	secret string
+}
+
+func (verifier stripeVerifier) Verify(header func(name string) string, body []byte, now time.Time) (*model.ReceivedWebhook, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header("Stripe-Signature"), ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || now.Sub(time.Unix(seconds, 0)).Abs() > stripeTolerance {
+		return nil, ErrInvalidSignature
+	}
+	if !anyEqual(signatures, hexHMAC(verifier.secret, []byte(timestamp+"."), body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var stripeEvent struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	err = json.Unmarshal(body, &stripeEvent)
+	if err != nil || stripeEvent.ID == "" {
+		return nil, errors.New("stripe event without an id")
+	}
+	return &model.ReceivedWebhook{Provider: "stripe", EventID: stripeEvent.ID, Type: stripeEvent.Type}, nil
+}
+
+// githubVerifier checks the X-Hub-Signature-256 header, "sha256=<hex
+// HMAC-SHA256 of the body>". The event ID and type come from the
+// X-GitHub-Delivery and X-GitHub-Event headers.
+type githubVerifier struct {
+	secret string
+}
+
+func (verifier githubVerifier) Verify(header func(name string) string, body []byte, now time.Time) (*model.ReceivedWebhook, error) {
+	signature, ok := strings.CutPrefix(header("X-Hub-Signature-256"), "sha256=")
+	if !ok || !anyEqual([]string{signature}, hexHMAC(verifier.secret, body)) {
+		return nil, ErrInvalidSignature
+	}
+	id := header("X-GitHub-Delivery")
+	if id == "" {
+		return nil, errors.New("github event without a delivery id")
+	}
+	return &model.ReceivedWebhook{Provider: "github", EventID: id, Type: header("X-GitHub-Event")}, nil
+}
+
+// twilioVerifier checks the X-Twilio-Signature header, the base64
+// HMAC-SHA1 of the URL of the receiver followed by the names and values of
+// the form fields sorted by name. Twilio only sends status callbacks here:
+// the event ID is the message SID and its status, which is the type.
+type twilioVerifier struct {
+	secret string
+	url    string
+}
+
+func (verifier twilioVerifier) Verify(header func(name string) string, body []byte, now time.Time) (*model.ReceivedWebhook, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	mac := hmac.New(sha1.New, []byte(verifier.secret))
+	mac.Write([]byte(verifier.url))
+	for _, name := range names {
+		for _, value := range form[name] {
+			mac.Write([]byte(name + value))
+		}
+	}
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !anyEqual([]string{header("X-Twilio-Signature")}, []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+
+	sid, status := form.Get("MessageSid"), form.Get("MessageStatus")
+	if sid == "" || status == "" {
+		return nil, errors.New("twilio callback without a message sid and status")
+	}
+	return &model.ReceivedWebhook{Provider: "twilio", EventID: sid + ":" + status, Type: status}, nil
+}
+
+// vonageVerifier checks the bearer token of the Authorization header, a JSON
+// Web Token signed with HMAC-SHA256 by the signature secret whose
+// payload_hash claim is the hex SHA-256 of the body. The event ID is the
+// message UUID and its status, which is the type.
+type vonageVerifier struct {
+	secret string
+}
+
+func (verifier vonageVerifier) Verify(header func(name string) string, body []byte, now time.Time) (*model.ReceivedWebhook, error) {
+	token, ok := strings.CutPrefix(header("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if !ok || len(parts) != 3 {
+		return nil, ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(verifier.secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !anyEqual([]string{parts[2]}, []byte(base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))) {
+		return nil, ErrInvalidSignature
+	}
+	var claims struct {
+		IssuedAt    int64  `json:"iat"`
+		PayloadHash string `json:"payload_hash"`
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return nil, ErrInvalidSignature
+	}
+	digest := sha256.Sum256(body)
+	if now.Sub(time.Unix(claims.IssuedAt, 0)).Abs() > vonageTolerance || !anyEqual([]string{claims.PayloadHash}, []byte(hex.EncodeToString(digest[:]))) {
+		return nil, ErrInvalidSignature
+	}
+
+	var status struct {
+		MessageUUID string `json:"message_uuid"`
+		Status      string `json:"status"`
+	}
+	err = json.Unmarshal(body, &status)
+	if err != nil || status.MessageUUID == "" || status.Status == "" {
+		return nil, errors.New("vonage status without a message uuid and status")
+	}
+	return &model.ReceivedWebhook{Provider: "vonage", EventID: status.MessageUUID + ":" + status.Status, Type: status.Status}, nil
+}
+
+func hexHMAC(secret string, parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return []byte(hex.EncodeToString(mac.Sum(nil)))
+}
+
+// anyEqual compares in constant time.
+func anyEqual(signatures []string, expected []byte) bool {
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), expected) {
+			return true
+		}
+	}
+	return false
+}
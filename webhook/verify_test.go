@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"golang-fiber-web/config"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStripeVerifier(t *testing.T) {
+	verifier, err := NewVerifier("stripe", config.WebhookReceiverConfig{Secret: "whsec_test"})
+	assert.Nil(t, err)
+	body := []byte(`{"id":"evt_1","type":"charge.succeeded"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := string(hexHMAC("whsec_test", []byte(timestamp+"."), body))
+	header := func(value string) func(string) string {
+		return func(name string) string {
+			if name == "Stripe-Signature" {
+				return value
+			}
+			return ""
+		}
+	}
+
+	received, err := verifier.Verify(header("t="+timestamp+",v1=bad,v1="+signature), body, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "evt_1", received.EventID)
+	assert.Equal(t, "charge.succeeded", received.Type)
+
+	_, err = verifier.Verify(header("t="+timestamp+",v1="+signature), body, now.Add(time.Minute*6))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = verifier.Verify(header("t="+timestamp+",v1="+signature), []byte(`{"id":"evt_2"}`), now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestGitHubVerifier(t *testing.T) {
+	verifier, err := NewVerifier("github", config.WebhookReceiverConfig{Secret: "secret"})
+	assert.Nil(t, err)
+	body := []byte(`{"action":"opened"}`)
+	headers := map[string]string{
+		"X-Hub-Signature-256": "sha256=" + string(hexHMAC("secret", body)),
+		"X-GitHub-Delivery":   "72d3162e",
+		"X-GitHub-Event":      "issues",
+	}
+	header := func(name string) string {
+		return headers[name]
+	}
+
+	received, err := verifier.Verify(header, body, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, "72d3162e", received.EventID)
+	assert.Equal(t, "issues", received.Type)
+
+	headers["X-Hub-Signature-256"] = "sha256=00"
+	_, err = verifier.Verify(header, body, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestTwilioVerifier(t *testing.T) {
+	_, err := NewVerifier("twilio", config.WebhookReceiverConfig{Secret: "auth-token"})
+	assert.NotNil(t, err)
+	verifier, err := NewVerifier("twilio", config.WebhookReceiverConfig{Secret: "auth-token", URL: "https://shop.example.com/webhooks/twilio"})
+	assert.Nil(t, err)
+
+	body := []byte("MessageStatus=delivered&To=%2B6281234567890&MessageSid=SM123")
+	mac := hmac.New(sha1.New, []byte("auth-token"))
+	mac.Write([]byte("https://shop.example.com/webhooks/twilioMessageSidSM123MessageStatusdeliveredTo+6281234567890"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	header := func(name string) string {
+		if name == "X-Twilio-Signature" {
+			return signature
+		}
+		return ""
+	}
+
+	received, err := verifier.Verify(header, body, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, "SM123:delivered", received.EventID)
+	assert.Equal(t, "delivered", received.Type)
+
+	_, err = verifier.Verify(header, []byte("MessageStatus=failed&To=%2B6281234567890&MessageSid=SM123"), time.Now())
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestVonageVerifier(t *testing.T) {
+	verifier, err := NewVerifier("vonage", config.WebhookReceiverConfig{Secret: "signature-secret"})
+	assert.Nil(t, err)
+	body := []byte(`{"message_uuid":"aaaaaaaa-bbbb","to":"6281234567890","status":"delivered"}`)
+	now := time.Now()
+	sign := func(body []byte, issuedAt time.Time) string {
+		digest := sha256.Sum256(body)
+		encodedHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+		encodedClaims := base64.RawURLEncoding.EncodeToString([]byte(`{"iat":` + strconv.FormatInt(issuedAt.Unix(), 10) + `,"payload_hash":"` + hex.EncodeToString(digest[:]) + `"}`))
+		mac := hmac.New(sha256.New, []byte("signature-secret"))
+		mac.Write([]byte(encodedHeader + "." + encodedClaims))
+		return "Bearer " + encodedHeader + "." + encodedClaims + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	header := func(value string) func(string) string {
+		return func(name string) string {
+			if name == "Authorization" {
+				return value
+			}
+			return ""
+		}
+	}
+
+	received, err := verifier.Verify(header(sign(body, now)), body, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "aaaaaaaa-bbbb:delivered", received.EventID)
+	assert.Equal(t, "delivered", received.Type)
+
+	_, err = verifier.Verify(header(sign(body, now)), []byte(`{"message_uuid":"aaaaaaaa-bbbb","status":"rejected"}`), now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = verifier.Verify(header(sign(body, now.Add(-time.Minute*10))), body, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}